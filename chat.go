@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/conflicts"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/slack"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// IDs the chat subcommand's messages are sent with, in place of Slack's.
+const (
+	chatUserID    = "U0CLI"
+	chatChannelID = "D0CLI"
+)
+
+// runChat implements the "chat" subcommand: it sends lines read from stdin
+// through the same message handler the Slack bot uses and prints the replies
+// to stdout, without Slack credentials. Lines starting with / are chat
+// commands; /new starts a new conversation and /quit exits. It returns the
+// process exit code.
+func runChat(args []string, logger *slog.Logger) int {
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	repoPath := fs.String("repo", "", "repository to work on (default STORMSTACK_REPO_PATH, or the current directory)")
+	user := fs.String("user", os.Getenv("USER"), "name the messages are sent as")
+	tools := fs.Bool("tools", false, "print each tool call to stderr")
+	logLevel := fs.String("log-level", "warn", "level of the logs written to stderr: debug, info, warn or error")
+	fs.Parse(args)
+
+	// Logs go to stderr so stdout carries only the replies
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		logger.Error("Invalid log level", "level", *logLevel, "error", err)
+		return 2
+	}
+	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	cfg, err := config.LoadOffline()
+	if err != nil {
+		logger.Error("Failed to load configuration", "error", err)
+		return 1
+	}
+
+	path := *repoPath
+	if path == "" {
+		path = cfg.RepoPath
+	}
+	if path == "" {
+		path = "."
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		logger.Error("Failed to resolve repository path", "error", err)
+		return 1
+	}
+	cfg.RepoPath = path
+
+	lessons, err := storage.NewFileLessonStore(cfg.LessonsFile)
+	if err != nil {
+		logger.Error("Failed to load lessons", "error", err)
+		return 1
+	}
+	handler, err := slack.NewHandler(cfg, path, storage.NewMemoryStore(), lessons, conflicts.NewTracker(), nil, nil, logger)
+	if err != nil {
+		logger.Error("Failed to create message handler", "error", err)
+		return 1
+	}
+
+	// Warnings and announcements meant for the thread are printed inline
+	handler.NotifyWith(func(channelID, threadTS, text string) error {
+		fmt.Printf("[notice] %s\n", text)
+		return nil
+	})
+	if *tools {
+		handler.ObserveTools(func(name string, input json.RawMessage, output string, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[tool] %s %s: error: %v\n", name, input, err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "[tool] %s %s\n", name, input)
+		})
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	return chat(ctx, handler, *user, path, os.Stdin, os.Stdout)
+}
+
+// chat runs the read-eval-print loop of the chat subcommand until in ends,
+// /quit is entered or ctx is done.
+func chat(ctx context.Context, handler *slack.Handler, user, repoPath string, in io.Reader, out io.Writer) int {
+	interactive := isTerminal(in)
+	newThread := func() string { return fmt.Sprintf("cli-%d", time.Now().UnixNano()) }
+	threadTS := newThread()
+
+	if interactive {
+		fmt.Fprintf(out, "Chatting about %s. /new starts a new conversation, /quit exits.\n", repoPath)
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		if interactive {
+			fmt.Fprint(out, "> ")
+		}
+		if !scanner.Scan() || ctx.Err() != nil {
+			return 0
+		}
+		text := strings.TrimSpace(scanner.Text())
+
+		switch text {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return 0
+		case "/new":
+			threadTS = newThread()
+			fmt.Fprintln(out, "Started a new conversation.")
+			continue
+		}
+
+		reply, err := handler.HandleMessage(ctx, &slack.IncomingMessage{
+			Text:      text,
+			UserID:    chatUserID,
+			UserName:  user,
+			ChannelID: chatChannelID,
+			ThreadTS:  threadTS,
+			IsDM:      true,
+		})
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			if ctx.Err() != nil {
+				return 1
+			}
+			continue
+		}
+		if reply == nil {
+			continue
+		}
+		fmt.Fprintln(out, reply.Text)
+		for _, f := range reply.Files {
+			fmt.Fprintf(out, "[attached %s, %d bytes]\n", f.Name, len(f.Content))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// isTerminal reports whether r is an interactive terminal rather than a pipe
+// or file, so prompts are only shown to people.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/eval"
+)
+
+// runEval implements the "eval" subcommand: it replays recorded fixtures
+// through the conversation pipeline and reports scores and regressions.
+// It returns the process exit code.
+func runEval(args []string, logger *slog.Logger) int {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	dir := fs.String("dir", "evals", "directory containing eval fixtures")
+	filter := fs.String("run", "", "only run fixtures whose name contains this string")
+	baseline := fs.String("baseline", "", "previous report to compare against for regressions")
+	out := fs.String("out", "", "write the JSON report to this file")
+	fs.Parse(args)
+
+	cfg, err := config.LoadOffline()
+	if err != nil {
+		logger.Error("Failed to load configuration", "error", err)
+		return 1
+	}
+
+	fixtures, err := eval.LoadFixtures(*dir)
+	if err != nil {
+		logger.Error("Failed to load fixtures", "error", err)
+		return 1
+	}
+	if *filter != "" {
+		var selected []*eval.Fixture
+		for _, f := range fixtures {
+			if strings.Contains(f.Name, *filter) {
+				selected = append(selected, f)
+			}
+		}
+		fixtures = selected
+	}
+	if len(fixtures) == 0 {
+		logger.Error("No fixtures to run", "dir", *dir, "run", *filter)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	report := eval.NewRunner(cfg, logger).Run(ctx, fixtures)
+	fmt.Print(eval.FormatReport(report))
+
+	if *out != "" {
+		if err := eval.SaveReport(*out, report); err != nil {
+			logger.Error("Failed to save report", "error", err)
+			return 1
+		}
+	}
+
+	if *baseline != "" {
+		previous, err := eval.LoadReport(*baseline)
+		if err != nil {
+			logger.Error("Failed to load baseline", "error", err)
+			return 1
+		}
+		if regressions := report.Regressions(previous); len(regressions) > 0 {
+			fmt.Println("\nRegressions:")
+			for _, r := range regressions {
+				fmt.Println("  " + r)
+			}
+			return 1
+		}
+		fmt.Println("\nNo regressions against baseline.")
+	}
+
+	return 0
+}
@@ -0,0 +1,12 @@
+package retry
+
+import "time"
+
+// Backoff returns the delay before the given retry attempt.
+func Backoff(attempt int) time.Duration {
+	d := 100 * time.Millisecond
+	for i := 0; i < attempt; i++ {
+		d *= 2
+	}
+	return d
+}
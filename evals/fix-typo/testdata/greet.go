@@ -0,0 +1,6 @@
+package greet
+
+// Greeting returns a greeting for name.
+func Greeting(name string) string {
+	return "Helo, " + name + "!"
+}
@@ -0,0 +1,156 @@
+// Package activity provides a day-by-day log of the bot's usage.
+package activity
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event kinds.
+const (
+	// KindMessage is a message handled for a user
+	KindMessage = "message"
+	// KindTool is a tool call
+	KindTool = "tool"
+	// KindTokens is a Claude API call and the tokens it used
+	KindTokens = "tokens"
+)
+
+// dayFormat names the file of each day's events.
+const dayFormat = "2006-01-02"
+
+// Event is a single thing the bot did.
+type Event struct {
+	Time           time.Time `json:"time"`
+	Kind           string    `json:"kind"`
+	ChannelID      string    `json:"channel_id,omitempty"`
+	UserID         string    `json:"user_id,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	Tool           string    `json:"tool,omitempty"`
+	Failed         bool      `json:"failed,omitempty"`
+	// Blocked tool calls were refused by a policy, e.g. restricted paths
+	Blocked bool `json:"blocked,omitempty"`
+	// Detail is a command run, a PR opened or why a call was blocked
+	Detail       string `json:"detail,omitempty"`
+	InputTokens  int64  `json:"input_tokens,omitempty"`
+	OutputTokens int64  `json:"output_tokens,omitempty"`
+}
+
+// Log appends events to one JSON Lines file per day in a directory. Replicas
+// may share the directory: each event is a single append.
+type Log struct {
+	mu     sync.Mutex
+	dir    string
+	logger *slog.Logger
+}
+
+// NewLog creates a log in dir. A nil *Log records nothing.
+func NewLog(dir string, logger *slog.Logger) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create activity directory: %w", err)
+	}
+	return &Log{dir: dir, logger: logger}, nil
+}
+
+// Record stores an event in the file of its day.
+func (l *Log) Record(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		l.logger.Warn("failed to encode activity event", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.dayFile(event.Time), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.logger.Warn("failed to open activity log", "error", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		l.logger.Warn("failed to write activity event", "error", err)
+	}
+}
+
+// Day returns the events recorded on the local day containing t.
+func (l *Log) Day(t time.Time) ([]Event, error) {
+	file, err := os.Open(l.dayFile(t))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open activity log: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue // Skip lines torn by a crash
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activity log: %w", err)
+	}
+	return events, nil
+}
+
+// MarkReported records that the day containing t was reported, returning
+// false if it already was, so each day is reported once across restarts and
+// replicas.
+func (l *Log) MarkReported(t time.Time) (bool, error) {
+	file, err := os.OpenFile(filepath.Join(l.dir, t.Local().Format(dayFormat)+".reported"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark activity reported: %w", err)
+	}
+	return true, file.Close()
+}
+
+// Prune deletes the files of days before the local day containing before.
+func (l *Log) Prune(before time.Time) error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list activity directory: %w", err)
+	}
+
+	cutoff := before.Local().Format(dayFormat)
+	for _, entry := range entries {
+		day, _, _ := strings.Cut(entry.Name(), ".")
+		if _, err := time.Parse(dayFormat, day); err != nil || day >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(l.dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to prune activity log: %w", err)
+		}
+	}
+	return nil
+}
+
+// dayFile returns the file of the local day containing t.
+func (l *Log) dayFile(t time.Time) string {
+	return filepath.Join(l.dir, t.Local().Format(dayFormat)+".jsonl")
+}
@@ -0,0 +1,195 @@
+// The daily activity report.
+
+package activity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits of the report's lists.
+const (
+	maxReportChannels = 10
+	maxReportBlocked  = 10
+)
+
+// commandTools are the tools that run commands in the repository.
+var commandTools = map[string]bool{
+	"run_command": true,
+	"run_build":   true,
+	"run_tests":   true,
+	"run_lint":    true,
+
+	"list_dependencies":    true,
+	"check_outdated":       true,
+	"bump_dependency":      true,
+	"scan_vulnerabilities": true,
+}
+
+// Prices are Claude's prices in US dollars per million tokens.
+type Prices struct {
+	Input  float64
+	Output float64
+}
+
+// Cost returns the price of the given tokens.
+func (p Prices) Cost(input, output int64) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1e6
+}
+
+// ChannelUsage is one channel's share of a day's activity. Background work
+// such as triage and review learning has no channel.
+type ChannelUsage struct {
+	ChannelID     string
+	Conversations int
+	ToolCalls     int
+	InputTokens   int64
+	OutputTokens  int64
+}
+
+// Report summarizes a day of activity.
+type Report struct {
+	Day           time.Time
+	Conversations int
+	Messages      int
+	Users         int
+	ToolCalls     int
+	FailedCalls   int
+	Commands      int
+	PRs           []string
+	InputTokens   int64
+	OutputTokens  int64
+	Channels      []ChannelUsage
+	// Blocked are the tool calls refused by a policy
+	Blocked []Event
+}
+
+// Summarize builds the report of a day's events.
+func Summarize(day time.Time, events []Event) *Report {
+	report := &Report{Day: day}
+	channels := make(map[string]*ChannelUsage)
+	conversations := make(map[string]map[string]bool)
+	users := make(map[string]bool)
+
+	channel := func(id string) *ChannelUsage {
+		if c, ok := channels[id]; ok {
+			return c
+		}
+		c := &ChannelUsage{ChannelID: id}
+		channels[id] = c
+		conversations[id] = make(map[string]bool)
+		return c
+	}
+
+	for _, e := range events {
+		c := channel(e.ChannelID)
+		switch e.Kind {
+		case KindMessage:
+			report.Messages++
+			conversations[e.ChannelID][e.ConversationID] = true
+			if e.UserID != "" {
+				users[e.UserID] = true
+			}
+		case KindTool:
+			report.ToolCalls++
+			c.ToolCalls++
+			if e.Failed {
+				report.FailedCalls++
+			}
+			if e.Blocked {
+				report.Blocked = append(report.Blocked, e)
+			}
+			if commandTools[e.Tool] && !e.Blocked {
+				report.Commands++
+			}
+			if e.Tool == "create_pr" && !e.Failed && e.Detail != "" {
+				report.PRs = append(report.PRs, e.Detail)
+			}
+		case KindTokens:
+			report.InputTokens += e.InputTokens
+			report.OutputTokens += e.OutputTokens
+			c.InputTokens += e.InputTokens
+			c.OutputTokens += e.OutputTokens
+		}
+	}
+
+	for id, c := range channels {
+		c.Conversations = len(conversations[id])
+		report.Conversations += c.Conversations
+		report.Channels = append(report.Channels, *c)
+	}
+	sort.Slice(report.Channels, func(i, j int) bool {
+		a, b := report.Channels[i], report.Channels[j]
+		if a.InputTokens+a.OutputTokens != b.InputTokens+b.OutputTokens {
+			return a.InputTokens+a.OutputTokens > b.InputTokens+b.OutputTokens
+		}
+		return a.ChannelID < b.ChannelID
+	})
+	report.Users = len(users)
+	return report
+}
+
+// Format renders the report as a Slack message, with costs at prices.
+func (r *Report) Format(prices Prices) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":bar_chart: *Daily activity for %s*\n", r.Day.Format("Mon 2 Jan 2006")))
+	if r.Messages == 0 && r.ToolCalls == 0 && r.InputTokens == 0 {
+		sb.WriteString("No activity.")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("• %d conversations (%d messages) from %d people\n", r.Conversations, r.Messages, r.Users))
+	sb.WriteString(fmt.Sprintf("• %d tool calls (%d failed), %d commands run\n", r.ToolCalls, r.FailedCalls, r.Commands))
+	sb.WriteString(fmt.Sprintf("• %d PRs opened", len(r.PRs)))
+	if len(r.PRs) > 0 {
+		sb.WriteString(": " + strings.Join(r.PRs, ", "))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("• Claude: %s input and %s output tokens, about $%.2f\n",
+		formatTokens(r.InputTokens), formatTokens(r.OutputTokens), prices.Cost(r.InputTokens, r.OutputTokens)))
+	sb.WriteString(fmt.Sprintf("• %d policy violations blocked\n", len(r.Blocked)))
+
+	sb.WriteString("\n*By channel*\n")
+	for i, c := range r.Channels {
+		if i == maxReportChannels {
+			sb.WriteString(fmt.Sprintf("• … and %d more\n", len(r.Channels)-i))
+			break
+		}
+		name := "background jobs"
+		if c.ChannelID != "" {
+			name = "<#" + c.ChannelID + ">"
+		}
+		sb.WriteString(fmt.Sprintf("• %s: %d conversations, %d tool calls, %s tokens, $%.2f\n",
+			name, c.Conversations, c.ToolCalls, formatTokens(c.InputTokens+c.OutputTokens), prices.Cost(c.InputTokens, c.OutputTokens)))
+	}
+
+	if len(r.Blocked) > 0 {
+		sb.WriteString("\n:no_entry: *Blocked*\n")
+		for i, e := range r.Blocked {
+			if i == maxReportBlocked {
+				sb.WriteString(fmt.Sprintf("• … and %d more\n", len(r.Blocked)-i))
+				break
+			}
+			who := "background job"
+			if e.UserID != "" {
+				who = "<@" + e.UserID + ">"
+			}
+			sb.WriteString(fmt.Sprintf("• %s `%s`: %s\n", who, e.Tool, e.Detail))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatTokens abbreviates a token count, e.g. 1.2M.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,75 @@
+// Per-user usage totals.
+
+package activity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Usage totals one user's activity over recent days.
+type Usage struct {
+	Days          int
+	Conversations int
+	Messages      int
+	ToolCalls     int
+	FailedCalls   int
+	InputTokens   int64
+	OutputTokens  int64
+}
+
+// UserUsage totals userID's events over the last days local days, the day
+// containing now included.
+func (l *Log) UserUsage(userID string, days int, now time.Time) (*Usage, error) {
+	usage := &Usage{Days: days}
+	conversations := make(map[string]bool)
+	for i := 0; i < days; i++ {
+		events, err := l.Day(now.AddDate(0, 0, -i))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if e.UserID != userID {
+				continue
+			}
+			switch e.Kind {
+			case KindMessage:
+				usage.Messages++
+				conversations[e.ConversationID] = true
+			case KindTool:
+				usage.ToolCalls++
+				if e.Failed {
+					usage.FailedCalls++
+				}
+			case KindTokens:
+				usage.InputTokens += e.InputTokens
+				usage.OutputTokens += e.OutputTokens
+			}
+		}
+	}
+	usage.Conversations = len(conversations)
+	return usage, nil
+}
+
+// Format renders the usage as a Slack message, with costs at prices.
+func (u *Usage) Format(prices Prices) string {
+	var sb strings.Builder
+	period := "today"
+	if u.Days > 1 {
+		period = fmt.Sprintf("the last %d days", u.Days)
+	}
+	sb.WriteString(fmt.Sprintf(":bar_chart: *Your usage over %s*\n", period))
+	if u.Messages == 0 && u.ToolCalls == 0 && u.InputTokens == 0 {
+		sb.WriteString("No activity.")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("• %d requests in %d conversations\n", u.Messages, u.Conversations))
+	sb.WriteString(fmt.Sprintf("• %d tool calls (%d failed)\n", u.ToolCalls, u.FailedCalls))
+	sb.WriteString(fmt.Sprintf("• Claude: %s input and %s output tokens", formatTokens(u.InputTokens), formatTokens(u.OutputTokens)))
+	if prices.Input > 0 || prices.Output > 0 {
+		sb.WriteString(fmt.Sprintf(", about $%.2f", prices.Cost(u.InputTokens, u.OutputTokens)))
+	}
+	return sb.String()
+}
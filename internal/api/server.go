@@ -0,0 +1,343 @@
+// Package api provides the HTTP REST API other systems, such as CI or
+// dashboards, drive the bot with.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRequestSize bounds request bodies.
+	maxRequestSize = 1 << 20
+	// jobRetention is how long finished jobs can be polled for.
+	jobRetention = time.Hour
+	// maxWait bounds how long a request with ?wait=true blocks.
+	maxWait = 10 * time.Minute
+)
+
+// Job states.
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// Request is a message sent to a conversation.
+type Request struct {
+	ConversationID string
+	Text           string
+	// Caller names the API key the request was made with
+	Caller string
+}
+
+// Reply is the bot's reply to a request.
+type Reply struct {
+	Text  string `json:"text"`
+	Files []File `json:"files,omitempty"`
+}
+
+// File is a file attached to a reply.
+type File struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Handler works on a request and returns the bot's reply.
+type Handler func(ctx context.Context, req Request) (*Reply, error)
+
+// Job is the work on one message, polled at /v1/jobs/{id}.
+type Job struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversation_id"`
+	State          string     `json:"state"`
+	Reply          *Reply     `json:"reply,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+
+	// caller names the API key that started the job; no other key sees it
+	caller string
+	done   chan struct{}
+}
+
+// Server serves the REST API:
+//
+//	POST /v1/conversations/{id}/messages  send a message; returns its job
+//	GET  /v1/jobs/{id}                    a job's state and reply
+//	GET  /v1/status                       running and recent jobs
+//
+// Requests authenticate with "Authorization: Bearer <key>". Messages are
+// worked on in the background; ?wait=true answers once the reply is ready.
+type Server struct {
+	addr    string
+	keys    map[[sha256.Size]byte]string
+	handler Handler
+	started time.Time
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	// busy maps conversations to the job running in them; a conversation
+	// takes one message at a time
+	busy   map[conversation]string
+	logger *slog.Logger
+}
+
+// conversation identifies an API conversation. Each caller has conversations
+// of its own, even when it picks the same ID as another.
+type conversation struct {
+	caller string
+	id     string
+}
+
+// NewServer creates an API server listening on addr. keys are the accepted
+// API keys as "name=key" or just "key"; the name identifies the caller.
+func NewServer(addr string, keys []string, handler Handler, logger *slog.Logger) *Server {
+	s := &Server{
+		addr:    addr,
+		keys:    make(map[[sha256.Size]byte]string),
+		handler: handler,
+		started: time.Now(),
+		jobs:    make(map[string]*Job),
+		busy:    make(map[conversation]string),
+		logger:  logger,
+	}
+	for _, entry := range keys {
+		name, key, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, key = "api", entry
+		}
+		s.keys[sha256.Sum256([]byte(key))] = name
+	}
+	return s
+}
+
+// Run serves the API until the context is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/conversations/{id}/messages", s.authed(func(w http.ResponseWriter, r *http.Request, caller string) {
+		s.handleMessage(ctx, w, r, caller)
+	}))
+	mux.HandleFunc("GET /v1/jobs/{id}", s.authed(s.handleJob))
+	mux.HandleFunc("GET /v1/status", s.authed(s.handleStatus))
+
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("REST API listening", "addr", s.addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve REST API: %w", err)
+	}
+	return nil
+}
+
+// authed rejects requests without a valid API key and passes the caller's
+// name to next.
+func (s *Server) authed(next func(w http.ResponseWriter, r *http.Request, caller string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		caller, valid := s.caller(key)
+		if !ok || !valid {
+			s.logger.Warn("rejected API request with invalid key", "remote", r.RemoteAddr, "path", r.URL.Path)
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+		next(w, r, caller)
+	}
+}
+
+// caller returns the name of an API key, comparing keys in constant time.
+func (s *Server) caller(key string) (string, bool) {
+	sum := sha256.Sum256([]byte(key))
+	for known, name := range s.keys {
+		if subtle.ConstantTimeCompare(known[:], sum[:]) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// handleMessage starts a job for a message and returns it, or with
+// ?wait=true waits for the reply first.
+func (s *Server) handleMessage(ctx context.Context, w http.ResponseWriter, r *http.Request, caller string) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if strings.TrimSpace(body.Text) == "" {
+		writeError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+
+	conversationID := r.PathValue("id")
+	job, err := s.start(ctx, Request{ConversationID: conversationID, Text: body.Text, Caller: caller})
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("wait") == "true" {
+		select {
+		case <-job.done:
+		case <-r.Context().Done():
+			return
+		case <-time.After(maxWait):
+		}
+	}
+	s.writeJob(w, job)
+}
+
+// handleJob returns a job's state and reply, when the caller started it.
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request, caller string) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok || job.caller != caller {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	s.writeJob(w, job)
+}
+
+// handleStatus returns the caller's running jobs and counts of its recent ones.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request, caller string) {
+	type status struct {
+		Uptime  string         `json:"uptime"`
+		Running []Job          `json:"running"`
+		Recent  map[string]int `json:"recent"`
+	}
+
+	s.mu.Lock()
+	s.prune()
+	out := status{
+		Uptime:  time.Since(s.started).Round(time.Second).String(),
+		Running: []Job{},
+		Recent:  make(map[string]int),
+	}
+	for _, job := range s.jobs {
+		if job.caller != caller {
+			continue
+		}
+		out.Recent[job.State]++
+		if job.State == StateRunning {
+			out.Running = append(out.Running, *job)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out.Running, func(i, j int) bool { return out.Running[i].CreatedAt.Before(out.Running[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, out)
+}
+
+// start registers a job for req and works on it in the background. It fails
+// when the conversation is still working on an earlier message.
+func (s *Server) start(ctx context.Context, req Request) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+
+	key := conversation{caller: req.Caller, id: req.ConversationID}
+	if running, ok := s.busy[key]; ok {
+		return nil, fmt.Errorf("conversation is busy with job %s", running)
+	}
+	job := &Job{
+		ID:             newJobID(),
+		ConversationID: req.ConversationID,
+		State:          StateRunning,
+		CreatedAt:      time.Now(),
+		caller:         req.Caller,
+		done:           make(chan struct{}),
+	}
+	s.jobs[job.ID] = job
+	s.busy[key] = job.ID
+
+	go s.run(ctx, job, req)
+	return job, nil
+}
+
+// run works on a job's request and records the outcome.
+func (s *Server) run(ctx context.Context, job *Job, req Request) {
+	s.logger.Info("API request started", "job", job.ID, "conversation", req.ConversationID, "caller", req.Caller)
+	reply, err := s.handler(ctx, req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.State = StateFailed
+		job.Error = err.Error()
+		s.logger.Warn("API request failed", "job", job.ID, "error", err)
+	} else {
+		job.State = StateSucceeded
+		job.Reply = reply
+	}
+	delete(s.busy, conversation{caller: req.Caller, id: req.ConversationID})
+	close(job.done)
+}
+
+// writeJob writes a snapshot of a job, with 202 while it is running.
+func (s *Server) writeJob(w http.ResponseWriter, job *Job) {
+	s.mu.Lock()
+	snapshot := *job
+	s.mu.Unlock()
+
+	code := http.StatusOK
+	if snapshot.State == StateRunning {
+		code = http.StatusAccepted
+	}
+	writeJSON(w, code, snapshot)
+}
+
+// prune forgets jobs finished longer than jobRetention ago. The caller must
+// hold s.mu.
+func (s *Server) prune() {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// newJobID returns a random job ID.
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "job-" + hex.EncodeToString(b)
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
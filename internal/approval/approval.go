@@ -0,0 +1,205 @@
+// Package approval holds mutating actions until a human approves them.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNotAuthorized is returned when a user may not approve actions.
+var ErrNotAuthorized = fmt.Errorf("not authorized to approve")
+
+// ErrNotParticipant is returned when a user approving a request has not taken
+// part in the conversation it was made in.
+var ErrNotParticipant = fmt.Errorf("not a participant in the conversation")
+
+// ErrSelfApproval is returned when a user tries to approve their own request.
+var ErrSelfApproval = fmt.Errorf("may not approve own request")
+
+// Request is an action waiting for approval.
+type Request struct {
+	ConversationID string
+	ChannelID      string
+	Tool           string
+	Input          json.RawMessage
+	Summary        string
+	RequestedBy    string
+	CreatedAt      time.Time
+}
+
+// Manager tracks pending approval requests, at most one per conversation.
+type Manager struct {
+	mu        sync.Mutex
+	pending   map[string]*Request
+	approvers map[string]bool
+	ttl       time.Duration
+}
+
+// NewManager creates a new approval manager. If approvers is empty any user
+// may approve; requests expire after ttl (0 means never).
+func NewManager(approvers []string, ttl time.Duration) *Manager {
+	m := &Manager{
+		pending:   make(map[string]*Request),
+		approvers: make(map[string]bool, len(approvers)),
+		ttl:       ttl,
+	}
+	for _, id := range approvers {
+		m.approvers[id] = true
+	}
+	return m
+}
+
+// Submit records a request, replacing any request already pending in the conversation.
+func (m *Manager) Submit(req Request) {
+	if req.CreatedAt.IsZero() {
+		req.CreatedAt = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[req.ConversationID] = &req
+}
+
+// Pending returns the request waiting in a conversation, if any.
+func (m *Manager) Pending(conversationID string) (*Request, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, ok := m.pending[conversationID]
+	if !ok {
+		return nil, false
+	}
+	if m.expired(req) {
+		delete(m.pending, conversationID)
+		return nil, false
+	}
+	return req, true
+}
+
+// Approve removes and returns the pending request in a conversation if userID
+// may approve it. No one may approve a request they made. Unless participants
+// is nil, the user must also be one of the participants who have already taken
+// part in the conversation.
+func (m *Manager) Approve(conversationID, userID string, participants []string) (*Request, error) {
+	if !m.CanApprove(userID) {
+		return nil, ErrNotAuthorized
+	}
+
+	req, ok := m.Pending(conversationID)
+	if !ok {
+		return nil, fmt.Errorf("nothing is waiting for approval")
+	}
+	if req.RequestedBy != "" && req.RequestedBy == userID {
+		return nil, ErrSelfApproval
+	}
+	if participants != nil && !slices.Contains(participants, userID) {
+		return nil, ErrNotParticipant
+	}
+
+	m.mu.Lock()
+	delete(m.pending, conversationID)
+	m.mu.Unlock()
+
+	return req, nil
+}
+
+// Reject removes and returns the pending request in a conversation if userID
+// may turn it down: the user who made it, or anyone who may approve it.
+// Unless participants is nil, anyone else must have already taken part in
+// the conversation.
+func (m *Manager) Reject(conversationID, userID string, participants []string) (*Request, error) {
+	req, ok := m.Pending(conversationID)
+	if !ok {
+		return nil, fmt.Errorf("nothing is waiting for approval")
+	}
+	if req.RequestedBy == "" || req.RequestedBy != userID {
+		if !m.CanApprove(userID) {
+			return nil, ErrNotAuthorized
+		}
+		if participants != nil && !slices.Contains(participants, userID) {
+			return nil, ErrNotParticipant
+		}
+	}
+
+	m.mu.Lock()
+	delete(m.pending, conversationID)
+	m.mu.Unlock()
+
+	return req, nil
+}
+
+// Cancel discards the pending request in a conversation.
+func (m *Manager) Cancel(conversationID string) (*Request, bool) {
+	req, ok := m.Pending(conversationID)
+	if ok {
+		m.mu.Lock()
+		delete(m.pending, conversationID)
+		m.mu.Unlock()
+	}
+	return req, ok
+}
+
+// CanApprove reports whether a user may approve actions.
+func (m *Manager) CanApprove(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.approvers) == 0 {
+		return true
+	}
+	return m.approvers[userID]
+}
+
+// IsApprover reports whether a user is one of the configured approvers. Unlike
+// CanApprove, it is false for everyone when there are none.
+func (m *Manager) IsApprover(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.approvers[userID]
+}
+
+// Approvers returns the users who may approve actions, sorted; none means
+// anyone may.
+func (m *Manager) Approvers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(m.approvers))
+}
+
+// SetApprovers replaces the users who may approve actions. Pending requests
+// are kept, and approved by whoever may approve them now.
+func (m *Manager) SetApprovers(approvers []string) {
+	set := make(map[string]bool, len(approvers))
+	for _, id := range approvers {
+		set[id] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approvers = set
+}
+
+// expired reports whether a request has outlived the TTL.
+func (m *Manager) expired(req *Request) bool {
+	return m.ttl > 0 && time.Since(req.CreatedAt) > m.ttl
+}
+
+type approvedKey struct{}
+
+// WithApproved marks a context as carrying a human approval, so gated tools run.
+func WithApproved(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedKey{}, true)
+}
+
+// IsApproved reports whether the context carries a human approval.
+func IsApproved(ctx context.Context) bool {
+	approved, _ := ctx.Value(approvedKey{}).(bool)
+	return approved
+}
@@ -0,0 +1,112 @@
+// Package awsapi provides calls to AWS JSON APIs, such as CloudWatch Logs and
+// Secrets Manager, signed with SigV4 but without each service's SDK.
+package awsapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// maxResponseSize bounds the response bodies read.
+const maxResponseSize = 32 << 20
+
+// Client calls the actions of one service's JSON API in one region.
+type Client struct {
+	service  string
+	target   string
+	region   string
+	endpoint string
+	creds    aws.CredentialsProvider
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+// New creates a client of service's JSON API (e.g. "logs"), whose actions
+// are named target.Action (e.g. "Logs_20140328"), using the default AWS
+// credential chain. An empty region uses the default configuration's.
+func New(ctx context.Context, service, target, region string, client *http.Client) (*Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region is configured (set AWS_REGION)")
+	}
+	// Fail when created rather than on the first call if no credentials are available
+	if _, err := awsCfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+
+	return &Client{
+		service:  service,
+		target:   target,
+		region:   awsCfg.Region,
+		endpoint: fmt.Sprintf("https://%s.%s.amazonaws.com/", service, awsCfg.Region),
+		creds:    awsCfg.Credentials,
+		signer:   v4.NewSigner(),
+		http:     client,
+	}, nil
+}
+
+// Region returns the region the client calls.
+func (c *Client) Region() string {
+	return c.region
+}
+
+// Call invokes an action with a signed JSON request, decoding the response
+// into out.
+func (c *Client) Call(ctx context.Context, action string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", c.target+"."+action)
+
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), c.service, c.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign %s request: %w", action, err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", action, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s: %s", action, resp.Status, strings.TrimSpace(string(body[:min(len(body), 500)])))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", action, err)
+	}
+	return nil
+}
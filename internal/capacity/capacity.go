@@ -0,0 +1,156 @@
+// Package capacity provides a priority-aware limit on concurrent work.
+package capacity
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority orders work waiting for a slot.
+type Priority int
+
+// Priority lanes, lowest first.
+const (
+	// Low is for background work such as scheduled jobs
+	Low Priority = iota
+	// Normal is for ordinary requests
+	Normal
+	// High is for incidents and on-call users; it is served before everything else
+	High
+)
+
+// String returns the lane name.
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case High:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// Limiter bounds how much work runs at once. When it is full, waiting work is
+// served highest priority first and in arrival order within a priority.
+type Limiter struct {
+	mu      sync.Mutex
+	max     int
+	active  int
+	waiting [High + 1][]chan struct{}
+}
+
+// New creates a limiter allowing max concurrent slots. A max of 0 or less
+// means no limit.
+func New(max int) *Limiter {
+	return &Limiter{max: max}
+}
+
+// TryAcquire takes a slot without waiting and reports whether it got one.
+func (l *Limiter) TryAcquire(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.available(p) {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// Acquire waits for a slot until ctx is cancelled.
+func (l *Limiter) Acquire(ctx context.Context, p Priority) error {
+	p = clamp(p)
+
+	l.mu.Lock()
+	if l.available(p) {
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l.waiting[p] = append(l.waiting[p], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		removed := l.remove(p, ready)
+		l.mu.Unlock()
+		if !removed {
+			// The slot was handed over as we gave up; pass it on
+			l.Release()
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by TryAcquire or Acquire, handing it to the
+// highest-priority waiter if there is one.
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for p := High; p >= Low; p-- {
+		if len(l.waiting[p]) > 0 {
+			ready := l.waiting[p][0]
+			l.waiting[p] = l.waiting[p][1:]
+			close(ready)
+			return
+		}
+	}
+	l.active--
+}
+
+// Waiting returns how much work is queued for a slot.
+func (l *Limiter) Waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for _, q := range l.waiting {
+		n += len(q)
+	}
+	return n
+}
+
+// available reports whether work at priority p may take a slot now: one must
+// be free and nothing of equal or higher priority may be waiting for it.
+func (l *Limiter) available(p Priority) bool {
+	if l.max <= 0 {
+		return true
+	}
+	if l.active >= l.max {
+		return false
+	}
+	for q := clamp(p); q <= High; q++ {
+		if len(l.waiting[q]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// remove drops a waiter from its queue and reports whether it was still queued.
+func (l *Limiter) remove(p Priority, ready chan struct{}) bool {
+	for i, w := range l.waiting[p] {
+		if w == ready {
+			l.waiting[p] = append(l.waiting[p][:i], l.waiting[p][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// clamp limits p to the defined lanes.
+func clamp(p Priority) Priority {
+	if p < Low {
+		return Low
+	}
+	if p > High {
+		return High
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+// The AWS Bedrock provider.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ModelBedrockOpus is the Bedrock model ID for Claude Opus 4.5.
+const ModelBedrockOpus = "anthropic.claude-opus-4-5-20251101-v1:0"
+
+// newBedrockProvider creates a client for Claude on AWS Bedrock. Requests are
+// signed with SigV4 using the default AWS credential chain (environment,
+// shared profile, or instance/task role), so no code leaves the AWS account.
+func newBedrockProvider(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("a region is required")
+	}
+
+	loadOpts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(cfg.Region)}
+	if cfg.Profile != "" {
+		loadOpts = append(loadOpts, awsconfig.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	// Fail at startup rather than on the first message if no credentials are available
+	if _, err := awsCfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+
+	opts := []option.RequestOption{bedrock.WithConfig(awsCfg)}
+	if cfg.BaseURL != "" {
+		// e.g. a VPC interface endpoint for bedrock-runtime
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+	return NewAnthropicClient(modelOrDefault(cfg.Model, ModelBedrockOpus), cfg.Retry, logger, opts...), nil
+}
@@ -0,0 +1,121 @@
+// The budgets that bound the work done on a request.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// DefaultMaxIterations is the number of Claude calls a request may make when
+// no budget is set.
+const DefaultMaxIterations = 20
+
+// budgetWrapUpPrompt asks Claude to report on a request it ran out of budget
+// for.
+const budgetWrapUpPrompt = `You have run out of budget for this request (%s) and can't call any more tools now. Without calling tools, reply to the user with:
+- what you accomplished, including branches, commits and PRs
+- what remains to be done, and how you would go about it
+Then ask whether you should continue.`
+
+// Budget bounds the work done on a single request in a conversation: the
+// Claude calls made, the tokens they use and the time taken. Zero fields
+// are unlimited. Time is checked between Claude calls, so a long tool call
+// can overrun it.
+type Budget struct {
+	MaxTokens     int64
+	MaxIterations int
+	MaxDuration   time.Duration
+}
+
+// budgetUsage is what a request has used of its budget so far.
+type budgetUsage struct {
+	started    time.Time
+	tokens     int64
+	iterations int
+	// tools counts the tool calls made, by tool
+	tools map[string]int
+}
+
+// newBudgetUsage starts measuring a request's usage.
+func newBudgetUsage() *budgetUsage {
+	return &budgetUsage{started: time.Now(), tools: make(map[string]int)}
+}
+
+// add records a Claude response's usage.
+func (u *budgetUsage) add(response *anthropic.Message) {
+	u.iterations++
+	usage := response.Usage
+	u.tokens += usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens + usage.OutputTokens
+}
+
+// exceeded describes the limit of b that usage has reached, or returns ""
+// while it is within budget.
+func (b Budget) exceeded(u *budgetUsage) string {
+	switch {
+	case b.MaxIterations > 0 && u.iterations >= b.MaxIterations:
+		return fmt.Sprintf("%d steps, the most one request may take", u.iterations)
+	case b.MaxTokens > 0 && u.tokens >= b.MaxTokens:
+		return fmt.Sprintf("%d tokens used of %d", u.tokens, b.MaxTokens)
+	case b.MaxDuration > 0 && time.Since(u.started) >= b.MaxDuration:
+		return fmt.Sprintf("%s spent of %s", time.Since(u.started).Round(time.Second), b.MaxDuration)
+	}
+	return ""
+}
+
+// SetBudget replaces the budget of requests, from the next one.
+func (m *ConversationManager) SetBudget(budget Budget) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budget = budget
+}
+
+// currentBudget returns the budget of requests.
+func (m *ConversationManager) currentBudget() Budget {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.budget
+}
+
+// wrapUp ends a request that ran out of budget: Claude is asked, one last
+// time and told not to use tools, what it accomplished and what remains, and
+// to ask whether to continue. If it can't answer, the reply lists the tools
+// called instead.
+func (m *ConversationManager) wrapUp(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	reason string,
+	usage *budgetUsage,
+) string {
+	m.logger.Info("request ran out of budget", "reason", reason, "iterations", usage.iterations, "tokens", usage.tokens)
+	notice := fmt.Sprintf("_I stopped here: %s._\n\n", reason)
+
+	// The last message holds the tool results, so the request joins them
+	prompt := anthropic.NewTextBlock(fmt.Sprintf(budgetWrapUpPrompt, reason))
+	last := &messages[len(messages)-1]
+	last.Content = append(last.Content[:len(last.Content):len(last.Content)], prompt)
+
+	response, err := m.client.CreateMessageWithTools(ctx, systemPrompt, messages, m.toolDefinitions())
+	if err != nil {
+		m.logger.Warn("failed to summarize request", "error", err)
+	} else if text := strings.TrimSpace(ExtractTextContent(response)); text != "" {
+		return notice + text
+	}
+
+	names := make([]string, 0, len(usage.tools))
+	for name := range usage.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	calls := make([]string, len(names))
+	for i, name := range names {
+		calls[i] = fmt.Sprintf("%s ×%d", name, usage.tools[name])
+	}
+	return notice + fmt.Sprintf("So far I called: %s. Should I continue?", strings.Join(calls, ", "))
+}
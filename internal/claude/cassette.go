@@ -0,0 +1,271 @@
+// Record-and-replay of Claude API calls and tool executions.
+
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Interaction kinds stored in a cassette.
+const (
+	InteractionAPI  = "api"
+	InteractionTool = "tool"
+)
+
+// Interaction is a single recorded API call or tool execution.
+type Interaction struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+
+	// API calls
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Tool executions
+	Tool   string          `json:"tool,omitempty"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Output string          `json:"output,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Cassette is a JSON Lines file of recorded interactions. A recording cassette
+// wraps a live Client and ToolExecutor and appends every call to the file; a
+// loaded cassette replays them in order without calling the API or touching
+// the repository.
+//
+// Replay is sequential, so record one conversation at a time if you intend to
+// replay it.
+type Cassette struct {
+	mu           sync.Mutex
+	path         string
+	interactions []Interaction
+	next         map[string]int
+}
+
+// NewCassette creates an empty cassette that records to path, truncating any
+// existing file.
+func NewCassette(path string) (*Cassette, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	return &Cassette{path: path, next: make(map[string]int)}, nil
+}
+
+// LoadCassette loads a recorded cassette for replay.
+func LoadCassette(path string) (*Cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette entry %d: %w", len(interactions)+1, err)
+		}
+		interactions = append(interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	return &Cassette{interactions: interactions, next: make(map[string]int)}, nil
+}
+
+// RecordClient wraps a client so every call is recorded.
+func (c *Cassette) RecordClient(inner Client) Client {
+	return &recordingClient{inner: inner, cassette: c}
+}
+
+// RecordTools wraps a tool executor so every tool call is recorded.
+func (c *Cassette) RecordTools(inner ToolExecutor) ToolExecutor {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		output, err := inner(ctx, name, input)
+		i := Interaction{Kind: InteractionTool, Tool: name, Input: input, Output: output}
+		if err != nil {
+			i.Error = err.Error()
+		}
+		c.append(i)
+		return output, err
+	}
+}
+
+// ReplayClient returns a client that answers with the recorded responses, in order.
+func (c *Cassette) ReplayClient() Client {
+	return &replayClient{cassette: c}
+}
+
+// ReplayTools returns a tool executor that returns the recorded tool results,
+// in order. It fails if the conversation asks for a different tool than was
+// recorded.
+func (c *Cassette) ReplayTools() ToolExecutor {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		i, err := c.nextOf(InteractionTool)
+		if err != nil {
+			return "", err
+		}
+		if i.Tool != name {
+			return "", fmt.Errorf("replay diverged: expected tool %s, got %s", i.Tool, name)
+		}
+		if i.Error != "" {
+			return i.Output, errors.New(i.Error)
+		}
+		return i.Output, nil
+	}
+}
+
+// append writes an interaction to the cassette file.
+func (c *Cassette) append(i Interaction) {
+	if i.Time.IsZero() {
+		i.Time = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, i)
+	if c.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+// nextOf returns the next unreplayed interaction of the given kind.
+func (c *Cassette) nextOf(kind string) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for idx := c.next[kind]; idx < len(c.interactions); idx++ {
+		if c.interactions[idx].Kind == kind {
+			c.next[kind] = idx + 1
+			return c.interactions[idx], nil
+		}
+	}
+	return Interaction{}, fmt.Errorf("replay exhausted: no more recorded %s interactions", kind)
+}
+
+// recordingClient records calls made through a live client.
+type recordingClient struct {
+	inner    Client
+	cassette *Cassette
+}
+
+// CreateMessage sends a message and records the exchange.
+func (r *recordingClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	msg, err := r.inner.CreateMessage(ctx, params)
+	r.record(params, msg, err)
+	return msg, err
+}
+
+// CreateMessageWithTools sends a message with tools and records the exchange.
+func (r *recordingClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	msg, err := r.inner.CreateMessageWithTools(ctx, systemPrompt, messages, tools)
+
+	params := anthropic.MessageNewParams{Messages: messages, Tools: tools}
+	if systemPrompt != "" {
+		params.System = []anthropic.TextBlockParam{{Text: systemPrompt}}
+	}
+	r.record(params, msg, err)
+	return msg, err
+}
+
+// record appends an API exchange to the cassette.
+func (r *recordingClient) record(params anthropic.MessageNewParams, msg *anthropic.Message, err error) {
+	i := Interaction{Kind: InteractionAPI}
+	if request, mErr := json.Marshal(params); mErr == nil {
+		i.Request = request
+	}
+	if msg != nil {
+		i.Response = messageJSON(msg)
+	}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	r.cassette.append(i)
+}
+
+// replayClient answers calls from a cassette.
+type replayClient struct {
+	cassette *Cassette
+}
+
+// CreateMessage returns the next recorded response.
+func (r *replayClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	return r.next()
+}
+
+// CreateMessageWithTools returns the next recorded response.
+func (r *replayClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	return r.next()
+}
+
+// next decodes the next recorded API response.
+func (r *replayClient) next() (*anthropic.Message, error) {
+	i, err := r.cassette.nextOf(InteractionAPI)
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return nil, errors.New(i.Error)
+	}
+
+	var msg anthropic.Message
+	if err := json.Unmarshal(i.Response, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response: %w", err)
+	}
+	return &msg, nil
+}
+
+// messageJSON returns the wire JSON of a message, falling back to re-encoding
+// messages that weren't decoded from an API response.
+func messageJSON(msg *anthropic.Message) json.RawMessage {
+	if raw := msg.RawJSON(); raw != "" {
+		return json.RawMessage(raw)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	return data
+}
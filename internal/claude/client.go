@@ -4,6 +4,7 @@ package claude
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -16,23 +17,42 @@ const (
 	MaxTokens = 8192
 )
 
-// Client wraps the Anthropic SDK client.
-type Client struct {
+// Client sends messages to Claude.
+type Client interface {
+	// CreateMessage sends a message to Claude and returns the response.
+	CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error)
+	// CreateMessageWithTools sends a message with tool definitions.
+	CreateMessageWithTools(
+		ctx context.Context,
+		systemPrompt string,
+		messages []anthropic.MessageParam,
+		tools []anthropic.ToolUnionParam,
+	) (*anthropic.Message, error)
+}
+
+// AnthropicClient is a Client backed by the Anthropic SDK.
+type AnthropicClient struct {
 	client anthropic.Client
 	model  string
+	retry  RetryPolicy
+	logger *slog.Logger
 }
 
-// NewClient creates a new Claude API client.
-func NewClient(apiKey string) *Client {
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-	return &Client{
-		client: client,
-		model:  ModelOpus,
+// NewAnthropicClient creates a client for the given model. The request options
+// select the endpoint and authentication (API key, Bedrock, Vertex, ...).
+func NewAnthropicClient(model string, retry RetryPolicy, logger *slog.Logger, opts ...option.RequestOption) *AnthropicClient {
+	// Retries are handled by the client so they can be logged and budgeted
+	opts = append(opts, option.WithMaxRetries(0))
+	return &AnthropicClient{
+		client: anthropic.NewClient(opts...),
+		model:  model,
+		retry:  retry,
+		logger: logger,
 	}
 }
 
 // CreateMessage sends a message to Claude and returns the response.
-func (c *Client) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+func (c *AnthropicClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
 	// Ensure model is set
 	if params.Model == "" {
 		params.Model = anthropic.Model(c.model)
@@ -43,11 +63,13 @@ func (c *Client) CreateMessage(ctx context.Context, params anthropic.MessageNewP
 		params.MaxTokens = MaxTokens
 	}
 
-	return c.client.Messages.New(ctx, params)
+	return withRetry(ctx, c.retry, c.logger, func() (*anthropic.Message, error) {
+		return c.client.Messages.New(ctx, params)
+	})
 }
 
 // CreateMessageWithTools sends a message with tool definitions.
-func (c *Client) CreateMessageWithTools(
+func (c *AnthropicClient) CreateMessageWithTools(
 	ctx context.Context,
 	systemPrompt string,
 	messages []anthropic.MessageParam,
@@ -66,7 +88,9 @@ func (c *Client) CreateMessageWithTools(
 		}
 	}
 
-	return c.client.Messages.New(ctx, params)
+	return withRetry(ctx, c.retry, c.logger, func() (*anthropic.Message, error) {
+		return c.client.Messages.New(ctx, params)
+	})
 }
 
 // BuildUserMessage creates a user message param.
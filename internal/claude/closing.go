@@ -0,0 +1,91 @@
+// Closing summaries for idle conversations.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// maxClosingTranscript caps how much of a conversation is sent for its closing summary.
+const maxClosingTranscript = 30000
+
+// closingPrompt instructs Claude to summarize a conversation that is being closed.
+const closingPrompt = `You are closing a Slack thread in which an AI developer bot helped a team. The thread has gone quiet and is being archived.
+
+Write a short closing summary for the people in the thread, in Slack mrkdwn, with these sections (omit a section if it would be empty):
+*What changed*: files, branches and commits that were changed, with commit SHAs
+*Links*: pull requests and commits, as full URLs when they appear in the transcript
+*Open questions*: anything left unanswered or unfinished, and who it is waiting on
+
+Only report what the transcript shows. Do not invent links, SHAs or outcomes. Keep it under 150 words.`
+
+// IdleConversations returns the open conversations with no activity for at least idleFor.
+func (m *ConversationManager) IdleConversations(ctx context.Context, idleFor time.Duration) ([]*storage.Conversation, error) {
+	convs, err := m.store.ListIdle(ctx, idleFor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list idle conversations: %w", err)
+	}
+	return convs, nil
+}
+
+// Summarize asks Claude for a closing summary of a conversation. Extra lists
+// context the transcript may lack, such as the pull requests opened from it.
+func (m *ConversationManager) Summarize(ctx context.Context, conv *storage.Conversation, extra []string) (string, error) {
+	return m.summarize(ctx, conv, extra, closingPrompt)
+}
+
+// summarize asks Claude to summarize a conversation as instructed by prompt.
+func (m *ConversationManager) summarize(ctx context.Context, conv *storage.Conversation, extra []string, prompt string) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range conv.Messages {
+		switch msg.Role {
+		case "user":
+			transcript.WriteString("[user] " + attributed(msg) + "\n\n")
+		case "assistant":
+			transcript.WriteString("[bot] " + msg.Content + "\n\n")
+		}
+	}
+
+	// Keep the end of long threads, where the outcome is
+	text := transcript.String()
+	if len(text) > maxClosingTranscript {
+		text = "[earlier messages omitted]\n\n" + text[len(text)-maxClosingTranscript:]
+	}
+	if len(extra) > 0 {
+		text += "Also known about this thread:\n- " + strings.Join(extra, "\n- ") + "\n"
+	}
+
+	response, err := m.client.CreateMessage(ctx, anthropic.MessageNewParams{
+		System:   []anthropic.TextBlockParam{{Text: prompt}},
+		Messages: []anthropic.MessageParam{BuildUserMessage(text)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	return strings.TrimSpace(ExtractTextContent(response)), nil
+}
+
+// Archive marks a conversation closed. Its history is kept, and a new message
+// in the thread reopens it. It reports false, leaving the conversation open,
+// when there has been activity since conv was read.
+func (m *ConversationManager) Archive(ctx context.Context, conv *storage.Conversation) (bool, error) {
+	current, err := m.store.Get(ctx, conv.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if current == nil || current.UpdatedAt.After(conv.UpdatedAt) {
+		return false, nil
+	}
+
+	current.ArchivedAt = time.Now()
+	if err := m.store.Save(ctx, current); err != nil {
+		return false, fmt.Errorf("failed to archive conversation: %w", err)
+	}
+	return true, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
@@ -16,37 +17,52 @@ type ToolExecutor func(ctx context.Context, name string, input json.RawMessage)
 
 // ConversationManager manages conversations with Claude.
 type ConversationManager struct {
-	client       *Client
-	store        storage.ConversationStore
+	client   Client
+	store    storage.ConversationStore
+	lessons  storage.LessonStore
+	executor ToolExecutor
+	logger   *slog.Logger
+
+	// mu guards the system prompt and tools, which a configuration reload
+	// may replace
+	mu           sync.RWMutex
 	systemPrompt string
 	tools        []anthropic.ToolUnionParam
-	executor     ToolExecutor
-	logger       *slog.Logger
+	budget       Budget
+	// parallelism bounds the tool calls of a turn run at once, of the tools
+	// concurrent allows
+	parallelism int
+	concurrent  func(name string) bool
 }
 
 // NewConversationManager creates a new conversation manager.
 func NewConversationManager(
-	client *Client,
+	client Client,
 	store storage.ConversationStore,
+	lessons storage.LessonStore,
 	systemPrompt string,
+	tools []anthropic.ToolUnionParam,
 	executor ToolExecutor,
 	logger *slog.Logger,
 ) *ConversationManager {
 	return &ConversationManager{
 		client:       client,
 		store:        store,
+		lessons:      lessons,
 		systemPrompt: systemPrompt,
-		tools:        GetAllTools(),
+		tools:        tools,
 		executor:     executor,
 		logger:       logger,
+		budget:       Budget{MaxIterations: DefaultMaxIterations},
 	}
 }
 
-// ProcessMessage processes a user message and returns the response.
+// ProcessMessage processes a message from author and returns the response.
 func (m *ConversationManager) ProcessMessage(
 	ctx context.Context,
 	conversationID string,
 	channelID string,
+	author storage.Participant,
 	userMessage string,
 ) (string, error) {
 	// Get existing conversation or create new one
@@ -59,13 +75,16 @@ func (m *ConversationManager) ProcessMessage(
 	messages := m.buildMessageHistory(conv)
 
 	// Add user message
-	messages = append(messages, BuildUserMessage(userMessage))
+	message := storage.Message{
+		Role:     "user",
+		Content:  userMessage,
+		UserID:   author.UserID,
+		UserName: author.Name,
+	}
+	messages = append(messages, BuildUserMessage(attributed(message)))
 
 	// Store user message
-	if err := m.store.AddMessage(ctx, conversationID, channelID, storage.Message{
-		Role:    "user",
-		Content: userMessage,
-	}); err != nil {
+	if err := m.store.AddMessage(ctx, conversationID, channelID, message); err != nil {
 		m.logger.Warn("failed to store user message", "error", err)
 	}
 
@@ -86,6 +105,26 @@ func (m *ConversationManager) ProcessMessage(
 	return response, nil
 }
 
+// RecordExchange stores a user message and the bot's reply that were handled
+// without calling Claude, so later turns have the context.
+func (m *ConversationManager) RecordExchange(
+	ctx context.Context,
+	conversationID string,
+	channelID string,
+	author storage.Participant,
+	userMessage string,
+	response string,
+) {
+	for _, msg := range []storage.Message{
+		{Role: "user", Content: userMessage, UserID: author.UserID, UserName: author.Name},
+		{Role: "assistant", Content: response},
+	} {
+		if err := m.store.AddMessage(ctx, conversationID, channelID, msg); err != nil {
+			m.logger.Warn("failed to store message", "role", msg.Role, "error", err)
+		}
+	}
+}
+
 // buildMessageHistory builds message params from stored conversation.
 func (m *ConversationManager) buildMessageHistory(conv *storage.Conversation) []anthropic.MessageParam {
 	if conv == nil {
@@ -96,7 +135,7 @@ func (m *ConversationManager) buildMessageHistory(conv *storage.Conversation) []
 	for _, msg := range conv.Messages {
 		switch msg.Role {
 		case "user":
-			messages = append(messages, BuildUserMessage(msg.Content))
+			messages = append(messages, BuildUserMessage(attributed(msg)))
 		case "assistant":
 			messages = append(messages, BuildAssistantMessage(msg.Content))
 		}
@@ -104,19 +143,62 @@ func (m *ConversationManager) buildMessageHistory(conv *storage.Conversation) []
 	return messages
 }
 
-// processWithToolLoop handles the Claude response including tool use.
+// attributed prefixes a user message with its sender, so Claude can tell the
+// people in a multi-user thread apart.
+func attributed(msg storage.Message) string {
+	switch {
+	case msg.UserID == "":
+		return msg.Content
+	case msg.UserName == "":
+		return fmt.Sprintf("<@%s> said:\n%s", msg.UserID, msg.Content)
+	default:
+		return fmt.Sprintf("%s (<@%s>) said:\n%s", msg.UserName, msg.UserID, msg.Content)
+	}
+}
+
+// Participants returns the users who have taken part in a conversation.
+func (m *ConversationManager) Participants(ctx context.Context, conversationID string) []storage.Participant {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		m.logger.Warn("failed to get conversation participants", "error", err)
+		return nil
+	}
+	return conv.Participants()
+}
+
+// Conversation returns a conversation's stored history, or nil when there
+// is none.
+func (m *ConversationManager) Conversation(ctx context.Context, conversationID string) (*storage.Conversation, error) {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return conv, nil
+}
+
+// processWithToolLoop handles the Claude response including tool use. When
+// the request runs out of budget, it stops and replies with what was done
+// and what remains.
 func (m *ConversationManager) processWithToolLoop(
 	ctx context.Context,
 	messages []anthropic.MessageParam,
 ) (string, error) {
-	const maxIterations = 20
+	budget := m.currentBudget()
+	usage := newBudgetUsage()
+
+	systemPrompt := m.buildSystemPrompt(ctx)
+
+	for {
+		if reason := budget.exceeded(usage); reason != "" {
+			return m.wrapUp(ctx, systemPrompt, messages, reason, usage), nil
+		}
 
-	for i := 0; i < maxIterations; i++ {
 		// Call Claude
-		response, err := m.client.CreateMessageWithTools(ctx, m.systemPrompt, messages, m.tools)
+		response, err := m.client.CreateMessageWithTools(ctx, systemPrompt, messages, m.toolDefinitions())
 		if err != nil {
 			return "", fmt.Errorf("claude API error: %w", err)
 		}
+		usage.add(response)
 
 		// Check if we need to handle tool use
 		if !HasToolUse(response) {
@@ -146,35 +228,56 @@ func (m *ConversationManager) processWithToolLoop(
 		})
 
 		// Execute tools and collect results
-		var results []ToolResult
 		for _, toolUse := range toolUses {
-			m.logger.Debug("executing tool", "name", toolUse.Name, "id", toolUse.ID)
-
-			result, err := m.executor(ctx, toolUse.Name, toolUse.Input)
-			isError := err != nil
-			if isError {
-				result = FormatError(err)
-			}
-
-			results = append(results, ToolResult{
-				ToolUseID: toolUse.ID,
-				Result:    result,
-				IsError:   isError,
-			})
+			usage.tools[toolUse.Name]++
 		}
+		results := m.executeTools(ctx, toolUses)
 
 		// Add tool results as user message
 		messages = append(messages, BuildToolResultsMessage(results))
 	}
+}
 
-	return "", fmt.Errorf("exceeded maximum tool use iterations (%d)", maxIterations)
+// buildSystemPrompt returns the system prompt with lessons from past reviews appended.
+func (m *ConversationManager) buildSystemPrompt(ctx context.Context) string {
+	m.mu.RLock()
+	systemPrompt := m.systemPrompt
+	m.mu.RUnlock()
+
+	if m.lessons == nil {
+		return systemPrompt
+	}
+
+	lessons, err := m.lessons.ListLessons(ctx, MaxPromptLessons)
+	if err != nil {
+		m.logger.Warn("failed to load lessons", "error", err)
+		return systemPrompt
+	}
+
+	return systemPrompt + BuildLessonsSection(lessons)
 }
 
 // SetSystemPrompt updates the system prompt.
 func (m *ConversationManager) SetSystemPrompt(prompt string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.systemPrompt = prompt
 }
 
+// SetTools replaces the tools offered to Claude, from its next call.
+func (m *ConversationManager) SetTools(tools []anthropic.ToolUnionParam) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools = tools
+}
+
+// toolDefinitions returns the tools offered to Claude.
+func (m *ConversationManager) toolDefinitions() []anthropic.ToolUnionParam {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tools
+}
+
 // ClearConversation removes a conversation from storage.
 func (m *ConversationManager) ClearConversation(ctx context.Context, conversationID string) error {
 	return m.store.Delete(ctx, conversationID)
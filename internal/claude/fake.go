@@ -0,0 +1,185 @@
+// A scripted fake Claude backend for local development.
+
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// FakeRule scripts the fake backend's answer to user messages matching a pattern.
+type FakeRule struct {
+	// Match is a regular expression matched against the latest user message
+	Match string `json:"match"`
+	// ToolCalls are requested first, before replying
+	ToolCalls []FakeToolCall `json:"tool_calls,omitempty"`
+	// Reply is the final text response
+	Reply string `json:"reply"`
+
+	pattern *regexp.Regexp
+}
+
+// FakeToolCall is a tool call requested by the fake backend.
+type FakeToolCall struct {
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// FakeClient is a Client that answers from a script instead of calling the
+// API, so the bot can run without an Anthropic key.
+type FakeClient struct {
+	rules []FakeRule
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// NewFakeClient creates a fake client. The first rule whose pattern matches
+// the latest user message decides the response; unmatched messages are echoed.
+func NewFakeClient(rules []FakeRule) (*FakeClient, error) {
+	for i := range rules {
+		pattern, err := regexp.Compile(rules[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fake rule pattern %q: %w", rules[i].Match, err)
+		}
+		rules[i].pattern = pattern
+	}
+	return &FakeClient{rules: rules}, nil
+}
+
+// LoadFakeClient creates a fake client from a JSON file containing a list of rules.
+// An empty path creates a client that only echoes.
+func LoadFakeClient(path string) (*FakeClient, error) {
+	if path == "" {
+		return NewFakeClient(nil)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake script: %w", err)
+	}
+
+	var rules []FakeRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse fake script: %w", err)
+	}
+	return NewFakeClient(rules)
+}
+
+// CreateMessage answers a single message.
+func (f *FakeClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	return f.respond(params.Messages)
+}
+
+// CreateMessageWithTools answers a message, requesting the scripted tool calls
+// before the final reply.
+func (f *FakeClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	return f.respond(messages)
+}
+
+// respond builds the scripted response to a message history.
+func (f *FakeClient) respond(messages []anthropic.MessageParam) (*anthropic.Message, error) {
+	text, awaitingTools := latestUserText(messages)
+
+	rule := f.match(text)
+	if rule == nil {
+		return f.message("end_turn", []map[string]any{
+			{"type": "text", "text": "(fake) You said: " + text},
+		})
+	}
+
+	// Tool results have come back, or there are none to request
+	if !awaitingTools || len(rule.ToolCalls) == 0 {
+		return f.message("end_turn", []map[string]any{
+			{"type": "text", "text": rule.Reply},
+		})
+	}
+
+	content := make([]map[string]any, 0, len(rule.ToolCalls))
+	for _, call := range rule.ToolCalls {
+		input := call.Input
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		content = append(content, map[string]any{
+			"type":  "tool_use",
+			"id":    f.newID("toolu"),
+			"name":  call.Name,
+			"input": input,
+		})
+	}
+	return f.message("tool_use", content)
+}
+
+// match returns the first rule matching text.
+func (f *FakeClient) match(text string) *FakeRule {
+	for i := range f.rules {
+		if f.rules[i].pattern.MatchString(text) {
+			return &f.rules[i]
+		}
+	}
+	return nil
+}
+
+// message builds a response by decoding its wire JSON, as the SDK would.
+func (f *FakeClient) message(stopReason string, content []map[string]any) (*anthropic.Message, error) {
+	data, err := json.Marshal(map[string]any{
+		"id":          f.newID("msg_fake"),
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "fake",
+		"stop_reason": stopReason,
+		"content":     content,
+		"usage":       map[string]any{"input_tokens": 0, "output_tokens": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msg anthropic.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to build fake response: %w", err)
+	}
+	return &msg, nil
+}
+
+// newID returns a unique identifier with the given prefix.
+func (f *FakeClient) newID(prefix string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	return fmt.Sprintf("%s_%d", prefix, f.nextID)
+}
+
+// latestUserText returns the text of the latest user message, and whether the
+// conversation is still waiting on tools (i.e. no tool results have been sent
+// since that message).
+func latestUserText(messages []anthropic.MessageParam) (string, bool) {
+	awaitingTools := true
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != anthropic.MessageParamRoleUser {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.OfRequestToolResultBlock != nil {
+				awaitingTools = false
+			}
+			if block.OfRequestTextBlock != nil {
+				return block.OfRequestTextBlock.Text, awaitingTools
+			}
+		}
+	}
+	return "", awaitingTools
+}
@@ -0,0 +1,74 @@
+// Handing conversations off to people.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// handoffPrompt instructs Claude to brief the teammate taking over a conversation.
+const handoffPrompt = `You are handing a Slack thread over to a human developer. An AI developer bot has been working on it and has reached the limit of what it should do on its own. The developer taking over has not read the thread.
+
+Write a concise handoff brief for them, in Slack mrkdwn, with these sections (omit a section if it would be empty):
+*Goal*: what was asked for, and by whom
+*Done so far*: what the bot changed, tried and found, with commit SHAs and links as they appear in the transcript
+*Where it stopped*: why the work needs a human now
+*Open questions*: decisions and questions still unanswered, and who can answer them
+
+Only report what the transcript shows. Do not invent links, SHAs or outcomes. Keep it under 200 words.`
+
+// HandoffSummary asks Claude for a brief of a conversation for the person
+// taking it over. Extra lists context the transcript may lack, such as the
+// pull requests opened from it.
+func (m *ConversationManager) HandoffSummary(ctx context.Context, conversationID string, extra []string) (string, error) {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil || len(conv.Messages) == 0 {
+		return "", nil
+	}
+	return m.summarize(ctx, conv, extra, handoffPrompt)
+}
+
+// HandOff marks a conversation handed off to userID, or re-engages the bot
+// when userID is empty. It returns the user it was handed off to before.
+func (m *ConversationManager) HandOff(ctx context.Context, conversationID, channelID, userID string) (string, error) {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		conv = &storage.Conversation{
+			ID:        conversationID,
+			ChannelID: channelID,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	previous := conv.HandedOffTo
+	conv.HandedOffTo = userID
+	if err := m.store.Save(ctx, conv); err != nil {
+		return "", fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return previous, nil
+}
+
+// HandedOffTo returns the user a conversation was handed off to, or "" when
+// the bot is engaged in it.
+func (m *ConversationManager) HandedOffTo(ctx context.Context, conversationID string) string {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		m.logger.Warn("failed to get conversation", "error", err)
+		return ""
+	}
+	if conv == nil {
+		return ""
+	}
+	return conv.HandedOffTo
+}
@@ -0,0 +1,40 @@
+// Summaries of repository health reports.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxHealthInput caps how much check output is sent for a health summary.
+const maxHealthInput = 40000
+
+// healthPrompt instructs Claude to summarize the results of a health report.
+const healthPrompt = `You are writing the summary of a scheduled health report on a software repository for its team's Slack channel. You are given the results of its build, tests, linter and vulnerability scan, with the end of each command's output.
+
+Write a short summary in Slack mrkdwn:
+- Start with one sentence on the overall state.
+- Then list what needs attention, most urgent first: the failing tests or build errors by name and file, lint problems worth fixing, and vulnerabilities with a fixed version to upgrade to.
+- End with the single next step you would take.
+
+Only report what the results show. Do not invent failures, files or versions. Keep it under 150 words, and omit the list when everything passed.`
+
+// HealthSummary asks Claude to summarize the results of a health report.
+func (m *ConversationManager) HealthSummary(ctx context.Context, results string) (string, error) {
+	if len(results) > maxHealthInput {
+		results = results[:maxHealthInput] + "\n[output truncated]"
+	}
+
+	response, err := m.client.CreateMessage(ctx, anthropic.MessageNewParams{
+		System:   []anthropic.TextBlockParam{{Text: healthPrompt}},
+		Messages: []anthropic.MessageParam{BuildUserMessage(results)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize health report: %w", err)
+	}
+	return strings.TrimSpace(ExtractTextContent(response)), nil
+}
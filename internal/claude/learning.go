@@ -0,0 +1,160 @@
+// A learning loop that distills PR review feedback.
+
+package claude
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// MaxPromptLessons is the maximum number of lessons included in the system prompt.
+const MaxPromptLessons = 20
+
+// distillPrompt instructs Claude to turn raw review comments into reusable rules.
+const distillPrompt = `You are distilling code review feedback left by human reviewers on pull requests written by an AI developer bot.
+
+Extract only general, reusable conventions the bot should follow on future changes in this repository (e.g. "Never use panics in HTTP handlers", "Wrap errors with %w and context").
+Ignore praise, one-off requests specific to this PR, questions, and anything that isn't a rule.
+
+Reply with one rule per line, each starting with "- ". Keep each rule under 20 words.
+If there are no generalizable rules, reply with exactly: NONE`
+
+// Learner distills reviewer feedback on bot PRs into long-term lessons.
+type Learner struct {
+	client  Client
+	forge   git.Forge
+	lessons storage.LessonStore
+	logger  *slog.Logger
+
+	// reviewers are the forge users whose feedback is learned from, and bot
+	// the bot's own forge user, whose comments never are
+	reviewers map[string]bool
+	bot       string
+}
+
+// NewLearner creates a new review feedback learner, learning only from the
+// comments of reviewers.
+func NewLearner(client Client, forge git.Forge, lessons storage.LessonStore, reviewers []string, bot string, logger *slog.Logger) *Learner {
+	allowed := make(map[string]bool, len(reviewers))
+	for _, r := range reviewers {
+		allowed[strings.ToLower(r)] = true
+	}
+	return &Learner{
+		client:    client,
+		forge:     forge,
+		lessons:   lessons,
+		logger:    logger,
+		reviewers: allowed,
+		bot:       strings.ToLower(bot),
+	}
+}
+
+// LearnFromPR fetches the review feedback on a PR, distills it into rules and
+// stores them. It returns the lessons that were learned. Only comments by the
+// configured reviewers that weren't learned from before are used, so running
+// it again on the same PR learns nothing new.
+func (l *Learner) LearnFromPR(ctx context.Context, prRef string) ([]string, error) {
+	if len(l.reviewers) == 0 {
+		return nil, fmt.Errorf("no reviewers to learn from; set STORMSTACK_LEARN_REVIEWERS")
+	}
+
+	all, err := l.forge.GetPRReviewComments(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []git.ReviewComment
+	var keys []string
+	for _, c := range all {
+		author := strings.ToLower(c.Author)
+		if author == l.bot || !l.reviewers[author] || strings.TrimSpace(c.Body) == "" {
+			continue
+		}
+		comments = append(comments, c)
+		keys = append(keys, commentKey(c))
+	}
+	learned, err := l.lessons.LearnedComments(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check learned comments: %w", err)
+	}
+	fresh := comments[:0]
+	var freshKeys []string
+	for i, c := range comments {
+		if !learned[keys[i]] {
+			fresh = append(fresh, c)
+			freshKeys = append(freshKeys, keys[i])
+		}
+	}
+	comments = fresh
+	if len(comments) == 0 {
+		return nil, nil
+	}
+
+	var feedback strings.Builder
+	for _, c := range comments {
+		if c.Path != "" {
+			feedback.WriteString(fmt.Sprintf("[%s on %s:%d] %s\n\n", c.Author, c.Path, c.Line, c.Body))
+		} else {
+			feedback.WriteString(fmt.Sprintf("[%s] %s\n\n", c.Author, c.Body))
+		}
+	}
+
+	response, err := l.client.CreateMessage(ctx, anthropic.MessageNewParams{
+		System:   []anthropic.TextBlockParam{{Text: distillPrompt}},
+		Messages: []anthropic.MessageParam{BuildUserMessage(feedback.String())},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to distill review feedback: %w", err)
+	}
+
+	rules := parseRules(ExtractTextContent(response))
+	for _, rule := range rules {
+		if err := l.lessons.AddLesson(ctx, storage.Lesson{Text: rule, Source: prRef}); err != nil {
+			return nil, fmt.Errorf("failed to store lesson: %w", err)
+		}
+	}
+	if err := l.lessons.MarkLearned(ctx, freshKeys); err != nil {
+		return nil, fmt.Errorf("failed to record learned comments: %w", err)
+	}
+
+	l.logger.Info("learned from PR review", "pr", prRef, "comments", len(comments), "lessons", len(rules))
+	return rules, nil
+}
+
+// commentKey identifies a review comment across runs: by its forge ID, or
+// by its content for forges that give it none.
+func commentKey(c git.ReviewComment) string {
+	if c.ID != "" {
+		return "id:" + c.ID
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%s", c.Author, c.Path, c.Line, c.Body)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// parseRules extracts "- " prefixed rules from a distillation response.
+func parseRules(text string) []string {
+	if strings.TrimSpace(text) == "NONE" {
+		return nil
+	}
+
+	var rules []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") {
+			continue
+		}
+		rule := strings.TrimSpace(strings.TrimPrefix(line, "- "))
+		if rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,282 @@
+// A client for OpenAI-compatible chat completion gateways.
+
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// openAITimeout bounds a single gateway request.
+const openAITimeout = 10 * time.Minute
+
+// OpenAIClient is a Client for gateways that expose the OpenAI chat
+// completions API. It translates Anthropic messages and tool definitions into
+// the OpenAI format and translates responses back, so the rest of the bot is
+// unaware of the difference.
+type OpenAIClient struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+	retry   RetryPolicy
+	logger  *slog.Logger
+}
+
+// NewOpenAIClient creates a client for the chat completions API at baseURL.
+func NewOpenAIClient(baseURL, apiKey, model string, retry RetryPolicy, logger *slog.Logger) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		http:    &http.Client{Timeout: openAITimeout},
+		retry:   retry,
+		logger:  logger,
+	}
+}
+
+// newOpenAIProvider creates a client for an OpenAI-compatible gateway.
+func newOpenAIProvider(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("a base URL is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("a model is required")
+	}
+	return NewOpenAIClient(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Retry, logger), nil
+}
+
+// CreateMessage sends a message to the gateway and returns the response.
+func (c *OpenAIClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	var system string
+	for _, block := range params.System {
+		system += block.Text
+	}
+	return c.CreateMessageWithTools(ctx, system, params.Messages, params.Tools)
+}
+
+// CreateMessageWithTools sends a message with tool definitions.
+func (c *OpenAIClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	request := openAIRequest{
+		Model:     c.model,
+		MaxTokens: MaxTokens,
+		Messages:  toOpenAIMessages(systemPrompt, messages),
+		Tools:     toOpenAITools(tools),
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return withRetry(ctx, c.retry, c.logger, func() (*anthropic.Message, error) {
+		return c.send(ctx, body)
+	})
+}
+
+// send posts a chat completion request and converts the response.
+func (c *OpenAIClient) send(ctx context.Context, body []byte) (*anthropic.Message, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		// Reuse the SDK error type so retries treat both providers alike
+		apiErr := &anthropic.Error{StatusCode: resp.StatusCode, Request: req, Response: resp}
+		return nil, fmt.Errorf("gateway returned %d: %s: %w", resp.StatusCode, strings.TrimSpace(string(data)), apiErr)
+	}
+
+	var completion openAIResponse
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return nil, fmt.Errorf("failed to parse gateway response: %w", err)
+	}
+	return fromOpenAIResponse(&completion)
+}
+
+// OpenAI chat completions wire format.
+type openAIRequest struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens"`
+	Messages  []openAIMessage `json:"messages"`
+	Tools     []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// toOpenAIMessages converts Anthropic messages into OpenAI chat messages. Tool
+// results become "tool" messages and tool uses become assistant tool calls.
+func toOpenAIMessages(systemPrompt string, messages []anthropic.MessageParam) []openAIMessage {
+	var out []openAIMessage
+	if systemPrompt != "" {
+		out = append(out, openAIMessage{Role: "system", Content: systemPrompt})
+	}
+
+	for _, msg := range messages {
+		current := openAIMessage{Role: string(msg.Role)}
+		for _, block := range msg.Content {
+			switch {
+			case block.OfRequestTextBlock != nil:
+				current.Content += block.OfRequestTextBlock.Text
+			case block.OfRequestToolUseBlock != nil:
+				args, _ := json.Marshal(block.OfRequestToolUseBlock.Input)
+				call := openAIToolCall{ID: block.OfRequestToolUseBlock.ID, Type: "function"}
+				call.Function.Name = block.OfRequestToolUseBlock.Name
+				call.Function.Arguments = string(args)
+				current.ToolCalls = append(current.ToolCalls, call)
+			case block.OfRequestToolResultBlock != nil:
+				result := block.OfRequestToolResultBlock
+				var content string
+				for _, c := range result.Content {
+					if c.OfRequestTextBlock != nil {
+						content += c.OfRequestTextBlock.Text
+					}
+				}
+				out = append(out, openAIMessage{Role: "tool", ToolCallID: result.ToolUseID, Content: content})
+			}
+		}
+		if current.Content != "" || len(current.ToolCalls) > 0 {
+			out = append(out, current)
+		}
+	}
+	return out
+}
+
+// toOpenAITools converts Anthropic tool definitions into OpenAI function tools.
+func toOpenAITools(tools []anthropic.ToolUnionParam) []openAITool {
+	out := make([]openAITool, 0, len(tools))
+	for _, t := range tools {
+		if t.OfTool == nil {
+			continue
+		}
+		schema, err := json.Marshal(t.OfTool.InputSchema)
+		if err != nil {
+			continue
+		}
+
+		tool := openAITool{Type: "function"}
+		tool.Function.Name = t.OfTool.Name
+		tool.Function.Description = t.OfTool.Description.Value
+		tool.Function.Parameters = schema
+		out = append(out, tool)
+	}
+	return out
+}
+
+// fromOpenAIResponse converts a chat completion into an Anthropic message.
+func fromOpenAIResponse(resp *openAIResponse) (*anthropic.Message, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("gateway returned no choices")
+	}
+	choice := resp.Choices[0]
+
+	var content []map[string]any
+	if choice.Message.Content != "" {
+		content = append(content, map[string]any{"type": "text", "text": choice.Message.Content})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		content = append(content, map[string]any{
+			"type":  "tool_use",
+			"id":    call.ID,
+			"name":  call.Function.Name,
+			"input": input,
+		})
+	}
+
+	stopReason := "end_turn"
+	switch {
+	case len(choice.Message.ToolCalls) > 0:
+		stopReason = "tool_use"
+	case choice.FinishReason == "length":
+		stopReason = "max_tokens"
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"id":          resp.ID,
+		"type":        "message",
+		"role":        "assistant",
+		"model":       resp.Model,
+		"stop_reason": stopReason,
+		"content":     content,
+		"usage": map[string]any{
+			"input_tokens":  resp.Usage.PromptTokens,
+			"output_tokens": resp.Usage.CompletionTokens,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msg anthropic.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to convert gateway response: %w", err)
+	}
+	return &msg, nil
+}
@@ -0,0 +1,86 @@
+// Concurrent execution of the independent tool calls Claude makes in one
+// turn.
+
+package claude
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// DefaultToolParallelism is how many tool calls of a turn run at once when
+// no limit is set.
+const DefaultToolParallelism = 4
+
+// SetParallelism sets how many of a turn's tool calls may run at once, and
+// which tools are safe to run alongside each other; other tools always run
+// alone, in turn order. A limit of 1 runs every call on its own.
+func (m *ConversationManager) SetParallelism(limit int, concurrent func(name string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parallelism = limit
+	m.concurrent = concurrent
+}
+
+// executeTools runs a turn's tool calls and returns their results in the
+// order they were made. Consecutive calls of tools that are safe together,
+// such as several file reads, run concurrently, at most parallelism at a
+// time; a call of any other tool waits for those before it and runs alone.
+func (m *ConversationManager) executeTools(ctx context.Context, toolUses []anthropic.ToolUseBlock) []ToolResult {
+	m.mu.RLock()
+	limit, concurrent := m.parallelism, m.concurrent
+	m.mu.RUnlock()
+	if limit < 1 {
+		limit = 1
+	}
+
+	results := make([]ToolResult, len(toolUses))
+	run := func(i int) {
+		toolUse := toolUses[i]
+		m.logger.Debug("executing tool", "name", toolUse.Name, "id", toolUse.ID)
+
+		start := time.Now()
+		result, err := m.executor(ctx, toolUse.Name, toolUse.Input)
+		response := NewToolResponse(toolUse.Name, result, err, time.Since(start))
+
+		results[i] = ToolResult{
+			ToolUseID: toolUse.ID,
+			Result:    response.JSON(),
+			IsError:   err != nil,
+		}
+	}
+
+	for i := 0; i < len(toolUses); {
+		// The run of consecutive calls that may share the turn
+		end := i + 1
+		if limit > 1 && concurrent != nil && concurrent(toolUses[i].Name) {
+			for end < len(toolUses) && concurrent(toolUses[end].Name) {
+				end++
+			}
+		}
+		if end-i == 1 {
+			run(i)
+			i = end
+			continue
+		}
+
+		m.logger.Debug("executing tools concurrently", "count", end-i, "parallelism", limit)
+		slots := make(chan struct{}, limit)
+		var wg sync.WaitGroup
+		for j := i; j < end; j++ {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(j int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				run(j)
+			}(j)
+		}
+		wg.Wait()
+		i = end
+	}
+	return results
+}
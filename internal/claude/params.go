@@ -0,0 +1,666 @@
+// Typed tool parameters and their validation.
+
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parameter structs are decoded from a tool call's input with Bind and are the
+// source of the tool's input schema (see Schema). Fields are described by their
+// `desc` tag and validated by their `validate` tag, a comma-separated list of rules:
+//
+//	required     the field must be present and non-empty
+//	present      the field must be present but may be empty
+//	min=N,max=N  numbers must fall in the range; strings and lists are bounded by length
+//	oneof=a b c  a non-empty value must be one of the listed values
+//
+// Rules that span fields are checked by a Validate method on the struct.
+
+// NoParams are the parameters of tools that take none.
+type NoParams struct{}
+
+// ReadFileParams are the read_file tool's parameters.
+type ReadFileParams struct {
+	Path      string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+	StartLine int    `json:"start_line" validate:"min=0" desc:"Optional start line number (1-indexed). If provided, only returns lines from this point."`
+	EndLine   int    `json:"end_line" validate:"min=0" desc:"Optional end line number (1-indexed). If provided, only returns lines up to this point."`
+	Cursor    int    `json:"cursor" validate:"min=0" desc:"Optional cursor from a previous page of a long file, to read the next page"`
+}
+
+// Validate checks the line range is in order and not mixed with a cursor.
+func (p ReadFileParams) Validate() error {
+	if p.Cursor > 0 && (p.StartLine > 0 || p.EndLine > 0) {
+		return fmt.Errorf("cursor cannot be combined with start_line or end_line")
+	}
+	if p.StartLine > 0 && p.EndLine > 0 && p.EndLine < p.StartLine {
+		return fmt.Errorf("end_line (%d) must not be before start_line (%d)", p.EndLine, p.StartLine)
+	}
+	return nil
+}
+
+// ListFilesParams are the list_files tool's parameters.
+type ListFilesParams struct {
+	Pattern string `json:"pattern" validate:"required" desc:"Glob pattern to match files (e.g., '**/*.java', 'src/**/*.go')"`
+}
+
+// SearchCodeParams are the search_code tool's parameters.
+type SearchCodeParams struct {
+	Pattern       string `json:"pattern" validate:"required" desc:"The search pattern (supports regex)"`
+	Path          string `json:"path" desc:"Optional path to limit search scope (can be a directory or glob pattern)"`
+	CaseSensitive bool   `json:"case_sensitive" desc:"Whether the search should be case-sensitive (default: false)"`
+	ContextLines  int    `json:"context_lines" validate:"min=0,max=10" desc:"Lines of context to show before and after each match (default: 0)"`
+	MaxResults    int    `json:"max_results" validate:"min=0,max=500" desc:"Maximum number of results to return (default: 50)"`
+}
+
+// FindLogCallsParams are the find_log_calls tool's parameters.
+type FindLogCallsParams struct {
+	Path      string `json:"path" desc:"Optional directory or glob pattern to search, e.g. a package (default: the whole repository)"`
+	ChunkSize int    `json:"chunk_size" validate:"min=0,max=500" desc:"Maximum calls per chunk, i.e. per pull request (default: STORMSTACK_LOG_MIGRATION_CHUNK_SIZE)"`
+}
+
+// GetTreeParams are the get_tree tool's parameters.
+type GetTreeParams struct {
+	Path     string `json:"path" desc:"The path to get the tree for (default: repository root)"`
+	MaxDepth int    `json:"max_depth" validate:"min=0,max=10" desc:"Maximum depth to traverse (default: 3)"`
+}
+
+// FindDefinitionParams are the find_definition tool's parameters.
+type FindDefinitionParams struct {
+	Symbol string `json:"symbol" validate:"required" desc:"The identifier to look up, optionally qualified with its type (e.g., 'Start' or 'Server.Start')"`
+	Path   string `json:"path" desc:"Optional directory to limit the lookup to"`
+}
+
+// FindReferencesParams are the find_references tool's parameters.
+type FindReferencesParams struct {
+	Symbol     string `json:"symbol" validate:"required" desc:"The identifier to find uses of (e.g., 'Start' or 'Server.Start')"`
+	Path       string `json:"path" desc:"Optional directory to limit the search to"`
+	MaxResults int    `json:"max_results" validate:"min=0,max=500" desc:"Maximum number of references to return (default: 100)"`
+}
+
+// GetOutlineParams are the get_outline tool's parameters.
+type GetOutlineParams struct {
+	Path string `json:"path" desc:"A source file to outline, or a directory to map its packages (default: repository root)"`
+}
+
+// SemanticSearchParams are the semantic_search tool's parameters.
+type SemanticSearchParams struct {
+	Query      string `json:"query" validate:"required" desc:"What the code does, in plain words (e.g., 'where is retry logic implemented?')"`
+	Path       string `json:"path" desc:"Optional directory to limit the search to"`
+	MaxResults int    `json:"max_results" validate:"min=0,max=50" desc:"Maximum number of results to return (default: 10)"`
+}
+
+// WriteFileParams are the write_file tool's parameters.
+type WriteFileParams struct {
+	Path              string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+	Content           string `json:"content" validate:"present" desc:"The content to write to the file"`
+	OverrideGenerated bool   `json:"override_generated" desc:"Change the file even though it is generated or vendored; only when the change cannot be made in its source"`
+}
+
+// EditFileParams are the edit_file tool's parameters.
+type EditFileParams struct {
+	Path              string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+	OldText           string `json:"old_text" validate:"required" desc:"The exact text to find and replace (must be unique in the file)"`
+	NewText           string `json:"new_text" validate:"present" desc:"The text to replace old_text with"`
+	OverrideGenerated bool   `json:"override_generated" desc:"Change the file even though it is generated or vendored; only when the change cannot be made in its source"`
+}
+
+// FormatFileParams are the format_file tool's parameters.
+type FormatFileParams struct {
+	Path string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+}
+
+// FileChange is one change of the apply_changes tool.
+type FileChange struct {
+	Action  string `json:"action" validate:"required,oneof=write edit delete" desc:"What to do to the file"`
+	Path    string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+	Content string `json:"content" desc:"For write: the file's new content"`
+	OldText string `json:"old_text" desc:"For edit: the exact text to find and replace (must be unique in the file)"`
+	NewText string `json:"new_text" desc:"For edit: the text to replace old_text with"`
+}
+
+// ApplyChangesParams are the apply_changes tool's parameters.
+type ApplyChangesParams struct {
+	Changes           []FileChange `json:"changes" validate:"required,max=50" desc:"The changes to apply, in order; later changes to a file apply on top of earlier ones"`
+	Check             string       `json:"check" validate:"oneof=build tests none" desc:"What must pass before the changes are kept (default: build)"`
+	OverrideGenerated bool         `json:"override_generated" desc:"Change files even though they are generated or vendored; only when the change cannot be made in their source"`
+}
+
+// Validate checks that each edit has text to replace.
+func (p ApplyChangesParams) Validate() error {
+	for i, change := range p.Changes {
+		if change.Action == "edit" && change.OldText == "" {
+			return fmt.Errorf("changes[%d].old_text is required for an edit", i)
+		}
+	}
+	return nil
+}
+
+// RunCommandParams are the run_command tool's parameters.
+type RunCommandParams struct {
+	Command string `json:"command" validate:"required" desc:"The command to run"`
+}
+
+// RunBuildParams are the run_build tool's parameters.
+type RunBuildParams struct {
+	Args           string `json:"args" desc:"Optional additional arguments to pass to the build command"`
+	TimeoutSeconds int    `json:"timeout_seconds" desc:"Optional time limit in seconds for long builds, up to 7200 (default: the configured command timeout)" validate:"min=0,max=7200"`
+}
+
+// RunTestsParams are the run_tests tool's parameters.
+type RunTestsParams struct {
+	Args           string `json:"args" desc:"Optional additional arguments (e.g., specific test file or pattern)"`
+	Affected       bool   `json:"affected" desc:"If true, run only the tests affected by the changes on this branch instead of the whole suite"`
+	Base           string `json:"base" desc:"Ref the branch's changes are measured from when affected is true (default: origin/<default branch>)"`
+	Rerun          bool   `json:"rerun" desc:"If true, run the tests even if a result for the exact same code is cached (e.g. to check for flakiness)"`
+	TimeoutSeconds int    `json:"timeout_seconds" desc:"Optional time limit in seconds for slow suites such as integration tests, up to 7200 (default: the configured command timeout)" validate:"min=0,max=7200"`
+}
+
+// Validate checks args and base are only used where they apply.
+func (p RunTestsParams) Validate() error {
+	if p.Affected && p.Args != "" {
+		return fmt.Errorf("args cannot be combined with affected")
+	}
+	if p.Base != "" && !p.Affected {
+		return fmt.Errorf("base is only used with affected")
+	}
+	return nil
+}
+
+// RunLintParams are the run_lint tool's parameters.
+type RunLintParams struct {
+	Args string `json:"args" desc:"Optional additional arguments (e.g., the files or packages to lint)"`
+}
+
+// GetArtifactParams are the get_artifact tool's parameters.
+type GetArtifactParams struct {
+	Name   string `json:"name" desc:"The artifact's path in the repository, as listed; omit to list the artifacts"`
+	Run    string `json:"run" desc:"The run to read from, as listed (defaults to the latest)"`
+	Attach bool   `json:"attach" desc:"Upload the whole report to the Slack thread instead of returning its text"`
+}
+
+// ListDependenciesParams are the list_dependencies tool's parameters.
+type ListDependenciesParams struct {
+	Ecosystem  string `json:"ecosystem" validate:"oneof=go maven gradle npm pnpm yarn cargo poetry uv pip" desc:"The package manager, when the repository has several (default: the first detected, e.g. go for go.mod)"`
+	Filter     string `json:"filter" desc:"Only show lines containing this text, case-insensitively (e.g. a package name)"`
+	Transitive bool   `json:"transitive" desc:"If true, include indirect dependencies, as a tree where the package manager supports it"`
+}
+
+// CheckOutdatedParams are the check_outdated tool's parameters.
+type CheckOutdatedParams struct {
+	Ecosystem string `json:"ecosystem" validate:"oneof=go maven gradle npm pnpm yarn cargo poetry uv pip" desc:"The package manager, when the repository has several (default: the first detected, e.g. go for go.mod)"`
+	Filter    string `json:"filter" desc:"Only show lines containing this text, case-insensitively (e.g. a package name)"`
+}
+
+// BumpDependencyParams are the bump_dependency tool's parameters.
+type BumpDependencyParams struct {
+	Name      string `json:"name" validate:"required" desc:"The dependency, as the package manager names it (groupId:artifactId for Maven, e.g. com.google.guava:guava)"`
+	Version   string `json:"version" validate:"required" desc:"The exact version to move to, e.g. 33.2.1-jre; use check_outdated to find it"`
+	Ecosystem string `json:"ecosystem" validate:"oneof=go maven gradle npm pnpm yarn cargo poetry uv pip" desc:"The package manager, when the repository has several (default: the first detected, e.g. go for go.mod)"`
+}
+
+// ScanVulnerabilitiesParams are the scan_vulnerabilities tool's parameters.
+type ScanVulnerabilitiesParams struct {
+	ID      string `json:"id" desc:"Only report this advisory, by CVE, GHSA or GO ID, to answer whether the project is affected by it"`
+	Scanner string `json:"scanner" validate:"oneof=govulncheck npm-audit osv-scanner" desc:"Run only this scanner (default: govulncheck for Go, npm-audit for npm, osv-scanner for the rest)"`
+	Format  string `json:"format" validate:"oneof=text json" desc:"Output format: text (default) or json"`
+}
+
+// GitDiffParams are the git_diff tool's parameters.
+type GitDiffParams struct {
+	Staged bool   `json:"staged" desc:"If true, show staged changes only (--cached)"`
+	Ref    string `json:"ref" desc:"Optional commit/branch reference to diff against"`
+	Path   string `json:"path" desc:"Optional file path to limit diff to"`
+}
+
+// GitLogParams are the git_log tool's parameters.
+type GitLogParams struct {
+	Count  int    `json:"count" validate:"min=0,max=200" desc:"Number of commits to show (default: 10)"`
+	Path   string `json:"path" desc:"Optional file path to show history for"`
+	Format string `json:"format" validate:"oneof=oneline short medium full" desc:"Output format (default: 'oneline')"`
+}
+
+// CreateBranchParams are the create_branch tool's parameters.
+type CreateBranchParams struct {
+	Name string `json:"name" validate:"required" desc:"The branch name (will be sanitized)"`
+	From string `json:"from" desc:"Optional base branch/commit to create from (default: current HEAD)"`
+}
+
+// CommitParams are the commit tool's parameters.
+type CommitParams struct {
+	Message string   `json:"message" validate:"required" desc:"The commit message"`
+	Files   []string `json:"files" desc:"List of files to stage (default: all modified files)"`
+}
+
+// PushParams are the push tool's parameters.
+type PushParams struct {
+	SetUpstream bool `json:"set_upstream" desc:"Whether to set upstream tracking (-u flag, default: true for new branches)"`
+}
+
+// RebaseParams are the rebase tool's parameters.
+type RebaseParams struct {
+	Onto string `json:"onto" desc:"Ref to rebase onto (default: origin/<default branch>, fetched first)"`
+}
+
+// MergeBranchParams are the merge_branch tool's parameters.
+type MergeBranchParams struct {
+	Ref string `json:"ref" desc:"Ref to merge (default: origin/<default branch>, fetched first)"`
+}
+
+// ListConflictsParams are the list_conflicts tool's parameters.
+type ListConflictsParams struct {
+	Path string `json:"path" desc:"Optional conflicted file to show (default: all)"`
+}
+
+// CreatePRParams are the create_pr tool's parameters.
+type CreatePRParams struct {
+	Title string `json:"title" validate:"required,max=256" desc:"The PR title"`
+	Body  string `json:"body" validate:"required" desc:"The PR description/body"`
+	Base  string `json:"base" desc:"The base branch to merge into (default: the repository's default branch)"`
+	Draft bool   `json:"draft" desc:"Whether to create as draft PR (default: false)"`
+
+	ReviewResponse string `json:"review_response" desc:"When the self-review flagged issues: how each was fixed, or why it needs no change. Added to the PR description"`
+}
+
+// GetPRParams are the get_pr tool's parameters.
+type GetPRParams struct {
+	URL string `json:"url" validate:"required" desc:"The PR URL (e.g., https://github.com/owner/repo/pull/123) or just the PR number if in the same repo"`
+}
+
+// GetPRChecksParams are the get_pr_checks tool's parameters.
+type GetPRChecksParams struct {
+	URL string `json:"url" validate:"required" desc:"The PR URL or just the PR number if in the same repo"`
+}
+
+// ReviewPRParams are the review_pr tool's parameters.
+type ReviewPRParams struct {
+	URL   string `json:"url" validate:"required" desc:"The PR URL or number"`
+	Event string `json:"event" validate:"required,oneof=approve comment request-changes" desc:"The review verdict"`
+	Body  string `json:"body" desc:"The review summary (required unless approving)"`
+}
+
+// Validate checks a body accompanies every verdict except approval.
+func (p ReviewPRParams) Validate() error {
+	if p.Event != "approve" && strings.TrimSpace(p.Body) == "" {
+		return fmt.Errorf("body is required for a %s review", p.Event)
+	}
+	return nil
+}
+
+// CommentOnPRLineParams are the comment_on_pr_line tool's parameters.
+type CommentOnPRLineParams struct {
+	URL  string `json:"url" validate:"required" desc:"The PR URL or number"`
+	Path string `json:"path" validate:"required" desc:"The file path, relative to the repository root"`
+	Line int    `json:"line" validate:"required,min=1" desc:"The line number in the new version of the file"`
+	Body string `json:"body" validate:"required" desc:"The comment text (markdown)"`
+}
+
+// CommentOnIssueParams are the comment_on_issue tool's parameters.
+type CommentOnIssueParams struct {
+	Number int    `json:"number" validate:"required,min=1" desc:"The issue or PR number"`
+	Body   string `json:"body" validate:"required" desc:"The comment text (markdown)"`
+}
+
+// CreateIssueParams are the create_issue tool's parameters.
+type CreateIssueParams struct {
+	Title  string   `json:"title" validate:"required" desc:"The issue title"`
+	Body   string   `json:"body" validate:"required" desc:"The issue description (markdown)"`
+	Labels []string `json:"labels" desc:"Labels to add, from the repository's existing labels"`
+}
+
+// MergePRParams are the merge_pr tool's parameters.
+type MergePRParams struct {
+	URL          string `json:"url" validate:"required" desc:"The PR URL or number"`
+	Strategy     string `json:"strategy" validate:"oneof=squash merge rebase" desc:"How to merge (default: squash)"`
+	DeleteBranch bool   `json:"delete_branch" desc:"Delete the head branch after merging (default: true)"`
+}
+
+// RevertCommitParams are the revert_commit tool's parameters.
+type RevertCommitParams struct {
+	SHA    string `json:"sha" desc:"SHA of the commit to revert"`
+	PR     string `json:"pr" desc:"Number or URL of a merged PR whose merge commit should be reverted"`
+	Reason string `json:"reason" desc:"Why the change is being reverted, included in the PR description"`
+}
+
+// Validate checks exactly one of sha and pr is given.
+func (p RevertCommitParams) Validate() error {
+	if (p.SHA == "") == (p.PR == "") {
+		return errors.New("provide exactly one of sha or pr")
+	}
+	return nil
+}
+
+// BackportPRParams are the backport_pr tool's parameters.
+type BackportPRParams struct {
+	PR     string `json:"pr" validate:"required" desc:"The merged PR's URL or number"`
+	Target string `json:"target" validate:"required" desc:"The release branch to backport to, e.g. release-1.8"`
+}
+
+// LearnFromReviewParams are the learn_from_review tool's parameters.
+type LearnFromReviewParams struct {
+	URL string `json:"url" validate:"required" desc:"The PR URL or number"`
+}
+
+// AddressReviewCommentsParams are the address_review_comments tool's parameters.
+type AddressReviewCommentsParams struct {
+	URL string `json:"url" validate:"required" desc:"The PR URL or number"`
+}
+
+// ReplyToReviewCommentParams are the reply_to_review_comment tool's parameters.
+type ReplyToReviewCommentParams struct {
+	URL       string `json:"url" validate:"required" desc:"The PR URL or number"`
+	CommentID string `json:"comment_id" validate:"required" desc:"The comment's ID, as listed by address_review_comments"`
+	Body      string `json:"body" validate:"required" desc:"How the comment was addressed, e.g. the change made and its commit, or why it wasn't (markdown)"`
+}
+
+// WorkOnIssueParams are the work_on_issue tool's parameters.
+type WorkOnIssueParams struct {
+	Number int `json:"number" validate:"required,min=1" desc:"The issue number"`
+}
+
+// FindTestsParams are the find_tests tool's parameters.
+type FindTestsParams struct {
+	SourceFile string `json:"source_file" validate:"required" desc:"The source file path to find tests for"`
+}
+
+// AnalyzeFailuresParams are the analyze_failures tool's parameters.
+type AnalyzeFailuresParams struct {
+	Output string `json:"output" validate:"required" desc:"The build/test output to analyze"`
+	Parser string `json:"parser" desc:"Optional parser for the output, e.g. maven, gradle, go, pytest, jest, vitest, dotnet, bazel, cargo (default: detected from the build system and output)"`
+	Format string `json:"format" validate:"oneof=text json" desc:"Result format: text, grouped by file (default: text), or json"`
+}
+
+// QueryLogsParams are the query_logs tool's parameters.
+type QueryLogsParams struct {
+	Query string `json:"query" validate:"required" desc:"The query in the logs backend's syntax: LogQL for Loki (e.g. {app=\"api\"} |= \"timeout\"), a filter pattern for CloudWatch (e.g. ERROR), a query string for Elasticsearch (e.g. service:api AND level:error)"`
+	Since string `json:"since" desc:"How far back to look, as a duration such as 15m or 6h (default: 1h, at most 7 days)"`
+	Limit int    `json:"limit" validate:"min=0,max=1000" desc:"Maximum number of log lines to return, the most recent ones (default and cap: the configured maximum)"`
+}
+
+// Validate checks since is a positive duration of at most a week.
+func (p QueryLogsParams) Validate() error {
+	if p.Since == "" {
+		return nil
+	}
+	since, err := time.ParseDuration(p.Since)
+	if err != nil || since <= 0 || since > 7*24*time.Hour {
+		return fmt.Errorf("since must be a positive duration of at most 168h, such as 15m or 6h")
+	}
+	return nil
+}
+
+// DescribeDatabaseParams are the describe_database tool's parameters.
+type DescribeDatabaseParams struct {
+	Table string `json:"table" desc:"Table to describe, optionally with its schema (e.g. public.users); omit to list the tables and views"`
+}
+
+// ExplainQueryParams are the explain_query tool's parameters.
+type ExplainQueryParams struct {
+	Query string `json:"query" validate:"required" desc:"A single SELECT statement (a WITH clause is allowed) to show the plan of; it is not run"`
+}
+
+// PlanFileParams is a file a plan changes.
+type PlanFileParams struct {
+	Path   string `json:"path" validate:"required" desc:"The relative path to the file from the repository root"`
+	Change string `json:"change" validate:"required" desc:"What will change in it, e.g. \"add\", \"delete\" or a sentence on the edit"`
+}
+
+// ProposePlanParams are the propose_plan tool's parameters.
+type ProposePlanParams struct {
+	Summary string           `json:"summary" validate:"required" desc:"The approach, in a few sentences"`
+	Files   []PlanFileParams `json:"files" validate:"required,min=1,max=100" desc:"Every file to add, change or delete; a directory ending in / covers the files in it"`
+	Tests   string           `json:"tests" validate:"required" desc:"The test strategy: tests to add or update, and what to run"`
+}
+
+// Validate checks that each file has a path.
+func (p ProposePlanParams) Validate() error {
+	for i, file := range p.Files {
+		if file.Path == "" {
+			return fmt.Errorf("files[%d].path is required", i)
+		}
+	}
+	return nil
+}
+
+// AskQuestionParams are the ask_question tool's parameters.
+type AskQuestionParams struct {
+	Question string   `json:"question" validate:"required" desc:"The clarifying question, as it should be shown to the user"`
+	Options  []string `json:"options" validate:"required,min=2,max=5" desc:"The possible answers, shown as buttons (at most 75 characters each)"`
+}
+
+// Validate checks the options fit on Slack buttons and are distinct.
+func (p AskQuestionParams) Validate() error {
+	seen := make(map[string]bool, len(p.Options))
+	for _, option := range p.Options {
+		switch {
+		case strings.TrimSpace(option) == "":
+			return fmt.Errorf("options must not be blank")
+		case len(option) > 75:
+			return fmt.Errorf("option %q is longer than 75 characters", option)
+		case seen[option]:
+			return fmt.Errorf("option %q is listed twice", option)
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+// ValidationError lists what is wrong with a tool call's input. Its message is
+// returned to Claude so it can correct the call.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid tool input: %s. Fix these parameters and call the tool again.", strings.Join(e.Problems, "; "))
+}
+
+// Bind decodes a tool call's input into dst, a pointer to a parameter struct,
+// and validates it. Fields already set on dst act as defaults. Any problem is
+// reported as a *ValidationError.
+func Bind(input json.RawMessage, dst any) error {
+	if len(bytes.TrimSpace(input)) == 0 {
+		input = json.RawMessage("{}")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return &ValidationError{Problems: []string{decodeProblem(err, dst)}}
+	}
+
+	problems := missingFields(input, reflect.TypeOf(dst).Elem())
+	problems = append(problems, validateFields(reflect.ValueOf(dst).Elem())...)
+	if v, ok := dst.(interface{ Validate() error }); ok && len(problems) == 0 {
+		if err := v.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// decodeProblem describes a JSON decoding error in terms of the parameters.
+func decodeProblem(err error, dst any) string {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return "input must be a JSON object"
+		}
+		return fmt.Sprintf("%s must be %s, got %s", typeErr.Field, jsonType(typeErr.Type), typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		return "input is not valid JSON"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fmt.Sprintf("unknown parameter %q (expected: %s)", field, strings.Join(paramNames(dst), ", "))
+	default:
+		return err.Error()
+	}
+}
+
+// missingFields reports fields with the present rule that the input omits.
+func missingFields(input json.RawMessage, t reflect.Type) []string {
+	var problems []string
+	var fields map[string]json.RawMessage
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !hasRule(field.Tag.Get("validate"), "present") {
+			continue
+		}
+		if fields == nil {
+			if err := json.Unmarshal(input, &fields); err != nil {
+				return nil
+			}
+		}
+		if _, ok := fields[paramName(field)]; !ok {
+			problems = append(problems, paramName(field)+" is required (it may be empty)")
+		}
+	}
+	return problems
+}
+
+// validateFields applies the validate tags of a parameter struct's fields,
+// including those of structs in lists, whose problems are prefixed with
+// their position, e.g. "changes[2].path is required".
+func validateFields(v reflect.Value) []string {
+	var problems []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := paramName(field)
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct {
+			for j := 0; j < value.Len(); j++ {
+				for _, problem := range validateFields(value.Index(j)) {
+					problems = append(problems, fmt.Sprintf("%s[%d].%s", name, j, problem))
+				}
+			}
+		}
+
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+
+		if value.IsZero() {
+			if hasRule(rules, "required") {
+				problems = append(problems, name+" is required")
+			}
+			continue
+		}
+		if value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" && hasRule(rules, "required") {
+			problems = append(problems, name+" must not be blank")
+			continue
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			key, arg, _ := strings.Cut(rule, "=")
+			if problem := checkRule(name, value, key, arg); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+// checkRule applies one validation rule to a non-zero field value.
+func checkRule(name string, value reflect.Value, key, arg string) string {
+	switch key {
+	case "min", "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return ""
+		}
+		n, unit := measure(value)
+		if key == "min" && n < limit {
+			return fmt.Sprintf("%s must be at least %d%s, got %d", name, limit, unit, n)
+		}
+		if key == "max" && n > limit {
+			return fmt.Sprintf("%s must be at most %d%s, got %d", name, limit, unit, n)
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		for _, a := range allowed {
+			if value.String() == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value.String())
+	}
+	return ""
+}
+
+// measure returns the number a range rule applies to: the value of a number,
+// or the length of a string or list.
+func measure(value reflect.Value) (int, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return len(value.String()), " characters"
+	case reflect.Slice:
+		return value.Len(), " items"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return int(value.Int()), ""
+	default:
+		return 0, ""
+	}
+}
+
+// hasRule reports whether a validate tag contains the given rule.
+func hasRule(rules, rule string) bool {
+	for _, r := range strings.Split(rules, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// paramName returns a struct field's JSON parameter name.
+func paramName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// paramNames lists the parameter names of the struct dst points to.
+func paramNames(dst any) []string {
+	t := reflect.TypeOf(dst).Elem()
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, paramName(t.Field(i)))
+	}
+	return names
+}
+
+// jsonType names the JSON type that decodes into a Go type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint, reflect.Uint64, reflect.Uint32:
+		return "an integer"
+	case reflect.Float64, reflect.Float32:
+		return "a number"
+	case reflect.Slice:
+		return "an array of " + strings.TrimPrefix(strings.TrimPrefix(jsonType(t.Elem()), "a "), "an ") + "s"
+	default:
+		return "an object"
+	}
+}
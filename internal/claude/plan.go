@@ -0,0 +1,67 @@
+// The implementation plans proposed for approval before large tasks.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// ProposePlan records plan as a conversation's plan, replacing any earlier
+// one.
+func (m *ConversationManager) ProposePlan(ctx context.Context, conversationID, channelID string, plan *storage.Plan) error {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		conv = &storage.Conversation{
+			ID:        conversationID,
+			ChannelID: channelID,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	conv.Plan = plan
+	if err := m.store.Save(ctx, conv); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// ApprovePlan approves a conversation's proposed plan on behalf of userID.
+// It returns the plan, or nil when none is waiting for approval.
+func (m *ConversationManager) ApprovePlan(ctx context.Context, conversationID, userID string) (*storage.Plan, error) {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil || conv.Plan == nil || conv.Plan.Status != storage.PlanProposed {
+		return nil, nil
+	}
+
+	conv.Plan.Status = storage.PlanApproved
+	conv.Plan.ApprovedBy = userID
+	conv.Plan.ApprovedAt = time.Now()
+	if err := m.store.Save(ctx, conv); err != nil {
+		return nil, fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return conv.Plan, nil
+}
+
+// Plan returns a conversation's plan, or nil when none was proposed.
+func (m *ConversationManager) Plan(ctx context.Context, conversationID string) *storage.Plan {
+	conv, err := m.store.Get(ctx, conversationID)
+	if err != nil {
+		m.logger.Warn("failed to get conversation", "error", err)
+		return nil
+	}
+	if conv == nil {
+		return nil
+	}
+	return conv.Plan
+}
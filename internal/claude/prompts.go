@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
 )
 
 // DefaultSystemPrompt is the base system prompt for the bot.
@@ -17,6 +19,7 @@ You are a senior developer helping the team with code reviews, debugging, implem
 - Write and edit files
 - Run builds and tests
 - Create branches, commits, and pull requests
+- Post reviews and comments on GitHub pull requests and issues
 
 ## Guidelines
 
@@ -25,6 +28,7 @@ You are a senior developer helping the team with code reviews, debugging, implem
 - Use code blocks with language hints for code snippets
 - Break complex explanations into digestible chunks
 - Ask clarifying questions when requirements are ambiguous
+- Threads can have several people in them; each message starts with who said it. Address people by name or <@ID> mention when it matters who asked for what
 
 ### Code Quality
 - Follow the project's existing conventions and patterns
@@ -43,6 +47,7 @@ You are a senior developer helping the team with code reviews, debugging, implem
 - Use search to find related code before making changes
 - Run tests after making changes to verify nothing broke
 - Check git status before committing
+- Tool results are JSON: status is ok or error, data holds the output and error what went wrong; when truncated is true the middle of the output was cut, so narrow the request
 
 ### Safety
 - Never expose secrets, tokens, or credentials
@@ -130,6 +135,22 @@ func BuildSystemPromptWithContext(basePrompt string, context map[string]string)
 	return builder.String()
 }
 
+// BuildLessonsSection formats lessons learned from past PR reviews for the system prompt.
+func BuildLessonsSection(lessons []storage.Lesson) string {
+	if len(lessons) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n\n## Lessons From Past Reviews\n\n")
+	builder.WriteString("Reviewers have given this feedback on your previous pull requests. Follow it so they don't have to repeat themselves:\n\n")
+	for _, lesson := range lessons {
+		builder.WriteString("- " + lesson.Text + "\n")
+	}
+
+	return builder.String()
+}
+
 // TruncateGuidelines truncates guidelines to fit within token limits.
 func TruncateGuidelines(content string, maxChars int) string {
 	if len(content) <= maxChars {
@@ -0,0 +1,131 @@
+// The LLM provider registry.
+
+package claude
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/vertex"
+	"golang.org/x/oauth2/google"
+)
+
+// Provider names.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderBedrock   = "bedrock"
+	ProviderVertex    = "vertex"
+	ProviderOpenAI    = "openai"
+	ProviderFake      = "fake"
+)
+
+// ModelVertexOpus is the Vertex AI model ID for Claude Opus 4.5.
+const ModelVertexOpus = "claude-opus-4-5@20251101"
+
+// ProviderConfig holds the settings providers use to create a client. Each
+// provider reads only the fields it needs.
+type ProviderConfig struct {
+	// Model overrides the provider's default model
+	Model string
+	// APIKey authenticates with the Anthropic API or a gateway
+	APIKey string
+	// BaseURL overrides the provider's endpoint
+	BaseURL string
+	// Region and ProjectID locate cloud-hosted models
+	Region    string
+	ProjectID string
+	// Profile selects a named cloud credentials profile
+	Profile string
+	// FakeScript is the rules file for the fake provider
+	FakeScript string
+	// Retry controls retries of transient errors
+	Retry RetryPolicy
+}
+
+// ProviderFactory creates a client for a provider.
+type ProviderFactory func(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error)
+
+var providers = map[string]ProviderFactory{
+	ProviderAnthropic: newAnthropicProvider,
+	ProviderBedrock:   newBedrockProvider,
+	ProviderVertex:    newVertexProvider,
+	ProviderOpenAI:    newOpenAIProvider,
+	ProviderFake:      newFakeProvider,
+}
+
+// RegisterProvider makes a provider available by name, replacing any existing one.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providers[name] = factory
+}
+
+// Providers returns the names of the registered providers.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProviderClient creates a client using the named provider.
+func NewProviderClient(ctx context.Context, name string, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (available: %v)", name, Providers())
+	}
+
+	client, err := factory(ctx, cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client: %w", name, err)
+	}
+	return client, nil
+}
+
+// newAnthropicProvider creates a client for the public Anthropic API.
+func newAnthropicProvider(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("an API key is required")
+	}
+
+	opts := []option.RequestOption{option.WithAPIKey(cfg.APIKey)}
+	if cfg.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+	return NewAnthropicClient(modelOrDefault(cfg.Model, ModelOpus), cfg.Retry, logger, opts...), nil
+}
+
+// newVertexProvider creates a client for Claude on Google Cloud Vertex AI,
+// authenticating with application default credentials.
+func newVertexProvider(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	if cfg.Region == "" || cfg.ProjectID == "" {
+		return nil, fmt.Errorf("a region and project ID are required")
+	}
+
+	creds, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google credentials: %w", err)
+	}
+
+	opts := []option.RequestOption{vertex.WithCredentials(ctx, cfg.Region, cfg.ProjectID, creds)}
+	if cfg.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+	return NewAnthropicClient(modelOrDefault(cfg.Model, ModelVertexOpus), cfg.Retry, logger, opts...), nil
+}
+
+// newFakeProvider creates the scripted fake client.
+func newFakeProvider(ctx context.Context, cfg ProviderConfig, logger *slog.Logger) (Client, error) {
+	return LoadFakeClient(cfg.FakeScript)
+}
+
+// modelOrDefault returns model, or def if it is empty.
+func modelOrDefault(model, def string) string {
+	if model == "" {
+		return def
+	}
+	return model
+}
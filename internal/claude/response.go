@@ -0,0 +1,131 @@
+// The envelope tool results are sent to Claude in.
+
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxToolResultSize is the most tool output sent to Claude in one result.
+// Longer output keeps its start and end, where errors usually are.
+const MaxToolResultSize = 200 * 1024
+
+// Tool response statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Kinds of tool output, telling renderers how to display it.
+const (
+	KindText    = "text"
+	KindCode    = "code"
+	KindDiff    = "diff"
+	KindCommand = "command"
+	KindList    = "list"
+)
+
+// toolKinds maps tools to the kind of output they return; unlisted tools
+// return text.
+var toolKinds = map[string]string{
+	"read_file":         KindCode,
+	"get_outline":       KindCode,
+	"git_diff":          KindDiff,
+	"list_conflicts":    KindDiff,
+	"run_command":       KindCommand,
+	"run_build":         KindCommand,
+	"run_tests":         KindCommand,
+	"run_lint":          KindCommand,
+	"list_dependencies": KindCommand,
+	"check_outdated":    KindCommand,
+	"bump_dependency":   KindCommand,
+	"list_files":        KindList,
+	"search_code":       KindList,
+	"get_tree":          KindList,
+	"find_references":   KindList,
+	"find_tests":        KindList,
+}
+
+// ToolResponse is the envelope every tool result is sent to Claude in.
+type ToolResponse struct {
+	Status string `json:"status"`
+	Tool   string `json:"tool"`
+	// Kind says how the data is best displayed: text, code, diff, command or list
+	Kind  string `json:"kind"`
+	Data  string `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Truncated is set when the middle of the data was cut to fit MaxToolResultSize
+	Truncated  bool  `json:"truncated,omitempty"`
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// NewToolResponse wraps the result of a tool call, truncating long output.
+func NewToolResponse(tool, result string, err error, took time.Duration) ToolResponse {
+	response := ToolResponse{
+		Status:     StatusOK,
+		Tool:       tool,
+		Kind:       ToolKind(tool),
+		DurationMS: took.Milliseconds(),
+	}
+	if err != nil {
+		response.Status = StatusError
+		response.Error = err.Error()
+	}
+	response.Data, response.Truncated = truncateMiddle(result, MaxToolResultSize)
+	return response
+}
+
+// ToolKind returns the kind of output a tool returns.
+func ToolKind(tool string) string {
+	if kind, ok := toolKinds[tool]; ok {
+		return kind
+	}
+	return KindText
+}
+
+// JSON encodes the response for Claude. HTML characters are left as they
+// are, so code and diffs stay readable.
+func (r ToolResponse) JSON() string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r); err != nil {
+		// Only unencodable types fail, and the envelope has none
+		return FormatError(fmt.Errorf("failed to encode tool response: %w", err))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ParseToolResponse decodes a tool result sent to Claude, reporting false for
+// results that aren't in the envelope, such as those recorded before it.
+func ParseToolResponse(s string) (ToolResponse, bool) {
+	var r ToolResponse
+	if !strings.HasPrefix(s, "{") || json.Unmarshal([]byte(s), &r) != nil || r.Status == "" {
+		return ToolResponse{}, false
+	}
+	return r, true
+}
+
+// truncateMiddle shortens s to about limit bytes by cutting its middle,
+// keeping the first three quarters and the last quarter of the budget.
+func truncateMiddle(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+
+	head, tail := limit*3/4, limit/4
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	start := len(s) - tail
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	omitted := start - head
+	return fmt.Sprintf("%s\n\n[... %d bytes omitted ...]\n\n%s", s[:head], omitted, s[start:]), true
+}
@@ -0,0 +1,156 @@
+// Retry handling for transient Claude API errors.
+
+package claude
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// statusOverloaded is returned by the API when it is temporarily overloaded.
+const statusOverloaded = 529
+
+// RetryPolicy controls how transient API errors are retried.
+type RetryPolicy struct {
+	// MaxRetries is the retry budget per request (0 disables retries)
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on each attempt
+	BaseDelay time.Duration
+	// MaxDelay caps a single retry delay
+	MaxDelay time.Duration
+}
+
+// withRetry calls fn until it succeeds, fails with a permanent error, or the
+// retry budget is exhausted.
+func withRetry(
+	ctx context.Context,
+	policy RetryPolicy,
+	logger *slog.Logger,
+	fn func() (*anthropic.Message, error),
+) (*anthropic.Message, error) {
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		msg, err := fn()
+		if err == nil {
+			if attempt > 0 {
+				logger.Info("claude request succeeded after retries", "retries", attempt)
+			}
+			return msg, nil
+		}
+		lastErr = err
+
+		if !isRetryable(err) || attempt >= policy.MaxRetries {
+			if attempt > 0 {
+				logger.Warn("claude request failed after retries", "retries", attempt, "error", err)
+			}
+			return nil, lastErr
+		}
+
+		delay := policy.backoff(attempt, retryAfter(err))
+		logger.Warn("retrying claude request",
+			"attempt", attempt+1,
+			"max_retries", policy.MaxRetries,
+			"status", statusCode(err),
+			"delay", delay,
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// maxBackoffShift caps how many times the base delay is doubled.
+const maxBackoffShift = 16
+
+// backoff returns the jittered exponential delay for the given attempt. A
+// server-provided retry-after hint takes precedence when present.
+func (p RetryPolicy) backoff(attempt int, hint time.Duration) time.Duration {
+	if hint > 0 {
+		if p.MaxDelay > 0 && hint > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return hint
+	}
+
+	// The shift is capped so it can't overflow into a zero or negative delay
+	delay := p.BaseDelay << min(attempt, maxBackoffShift)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+
+	// Equal jitter: half the backoff, plus a random part of the other half
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// isRetryable reports whether an error is transient and worth retrying.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return true
+		case apiErr.StatusCode == http.StatusRequestTimeout:
+			return true
+		case apiErr.StatusCode == statusOverloaded:
+			return true
+		case apiErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Network errors (connection reset, timeouts) are transient
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// statusCode returns the HTTP status code of an API error, or 0.
+func statusCode(err error) int {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// retryAfter returns the delay requested by the server's retry-after header.
+func retryAfter(err error) time.Duration {
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		return 0
+	}
+
+	header := apiErr.Response.Header.Get("retry-after")
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+// The self-review of a change by a second model before its pull request is
+// opened.
+
+package claude
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxReviewDiff caps how much of a diff is sent for review.
+const maxReviewDiff = 100000
+
+// reviewPrompt instructs the reviewer to critique a diff.
+const reviewPrompt = `You are a senior engineer reviewing a pull request another engineer is about to open. You see only its title, description and diff.
+
+Look for real problems: bugs, broken edge cases, missing error handling, security issues, races, changes that don't match the description, missing or wrong tests, and code that breaks the surrounding conventions. Don't comment on formatting a formatter would fix, and don't invent problems: an empty list of findings is a fine answer.
+
+Rate each finding:
+- blocker: the change is wrong or unsafe and must not merge as is
+- major: a real problem that should be fixed before merging
+- minor: worth improving, but not required
+- nit: a matter of taste
+
+Reply with only a JSON object:
+{"summary": "one or two sentences on the change overall", "findings": [{"severity": "major", "path": "file.go", "line": 12, "issue": "what is wrong and how to fix it"}]}`
+
+// Severities of review findings.
+const (
+	SeverityBlocker = "blocker"
+	SeverityMajor   = "major"
+	SeverityMinor   = "minor"
+	SeverityNit     = "nit"
+)
+
+// ReviewFinding is a single issue the reviewer found in a diff.
+type ReviewFinding struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Issue    string `json:"issue"`
+}
+
+// Blocking reports whether the finding must be addressed or acknowledged
+// before the pull request is opened.
+func (f ReviewFinding) Blocking() bool {
+	return f.Severity == SeverityBlocker || f.Severity == SeverityMajor
+}
+
+// Critique is the reviewer's verdict on a diff.
+type Critique struct {
+	Summary  string          `json:"summary"`
+	Findings []ReviewFinding `json:"findings"`
+
+	// DiffHash identifies the diff reviewed
+	DiffHash  string    `json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// Blocking returns the findings that must be addressed or acknowledged.
+func (c *Critique) Blocking() []ReviewFinding {
+	var blocking []ReviewFinding
+	for _, f := range c.Findings {
+		if f.Blocking() {
+			blocking = append(blocking, f)
+		}
+	}
+	return blocking
+}
+
+// FormatFindings formats review findings for display, one per line.
+func FormatFindings(findings []ReviewFinding) string {
+	var sb strings.Builder
+	for _, f := range findings {
+		sb.WriteString(fmt.Sprintf("- [%s]", f.Severity))
+		if f.Path != "" {
+			sb.WriteString(" " + f.Path)
+			if f.Line > 0 {
+				sb.WriteString(fmt.Sprintf(":%d", f.Line))
+			}
+		}
+		sb.WriteString(": " + strings.TrimSpace(f.Issue) + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Reviewer asks a second model to critique a change before its pull request
+// is opened.
+type Reviewer struct {
+	client Client
+	logger *slog.Logger
+
+	// last holds the latest critique per key (usually a conversation), so an
+	// unchanged diff isn't reviewed twice
+	mu   sync.Mutex
+	last map[string]*Critique
+}
+
+// NewReviewer creates a new reviewer.
+func NewReviewer(client Client, logger *slog.Logger) *Reviewer {
+	return &Reviewer{
+		client: client,
+		logger: logger,
+		last:   make(map[string]*Critique),
+	}
+}
+
+// Review critiques diff, the change a pull request titled title will make.
+// It reports whether the critique is new: one for a diff already reviewed
+// under key is returned again without asking the model.
+func (r *Reviewer) Review(ctx context.Context, key, title, body, diff string) (*Critique, bool, error) {
+	sum := sha256.Sum256([]byte(diff))
+	hash := hex.EncodeToString(sum[:])
+
+	r.mu.Lock()
+	last, ok := r.last[key]
+	r.mu.Unlock()
+	if ok && last.DiffHash == hash {
+		return last, false, nil
+	}
+
+	if len(diff) > maxReviewDiff {
+		diff = diff[:maxReviewDiff] + "\n... (diff truncated)"
+	}
+	input := fmt.Sprintf("# %s\n\n%s\n\n```diff\n%s\n```", title, body, diff)
+
+	response, err := r.client.CreateMessage(ctx, anthropic.MessageNewParams{
+		System:   []anthropic.TextBlockParam{{Text: reviewPrompt}},
+		Messages: []anthropic.MessageParam{BuildUserMessage(input)},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to review diff: %w", err)
+	}
+
+	critique, err := parseCritique(ExtractTextContent(response))
+	if err != nil {
+		return nil, false, err
+	}
+	critique.DiffHash = hash
+	critique.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	r.last[key] = critique
+	r.mu.Unlock()
+
+	r.logger.Info("reviewed diff", "key", key, "findings", len(critique.Findings), "blocking", len(critique.Blocking()))
+	return critique, true, nil
+}
+
+// parseCritique extracts the reviewer's JSON verdict from its reply. Unknown
+// severities count as minor.
+func parseCritique(text string) (*Critique, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("review response contained no JSON object")
+	}
+
+	var critique Critique
+	if err := json.Unmarshal([]byte(text[start:end+1]), &critique); err != nil {
+		return nil, fmt.Errorf("failed to parse review response: %w", err)
+	}
+	for i, f := range critique.Findings {
+		switch severity := strings.ToLower(strings.TrimSpace(f.Severity)); severity {
+		case SeverityBlocker, SeverityMajor, SeverityMinor, SeverityNit:
+			critique.Findings[i].Severity = severity
+		default:
+			critique.Findings[i].Severity = SeverityMinor
+		}
+	}
+	return &critique, nil
+}
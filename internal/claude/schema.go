@@ -0,0 +1,103 @@
+// Input schema generation from tool parameter structs.
+
+package claude
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Schema derives a tool's JSON input schema from its parameter struct, so the
+// schema Claude sees and the struct the executor binds can never drift apart.
+// Field types map to JSON types, nested structs to objects, the desc tag
+// becomes the description, and the required, present, min, max and oneof
+// validation rules become required properties, bounds and enums.
+func Schema(params any) anthropic.ToolInputSchemaParam {
+	t := reflect.TypeOf(params)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	properties, required := objectSchema(t)
+	schema := anthropic.ToolInputSchemaParam{
+		Properties: properties,
+	}
+	if len(required) > 0 {
+		schema.ExtraFields = map[string]any{
+			"required": required,
+		}
+	}
+	return schema
+}
+
+// objectSchema returns the properties of a parameter struct and the names of
+// the required ones.
+func objectSchema(t reflect.Type) (map[string]any, []string) {
+	properties := make(map[string]any, t.NumField())
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := paramName(field)
+		rules := field.Tag.Get("validate")
+
+		property := typeSchema(field.Type)
+		if desc := field.Tag.Get("desc"); desc != "" {
+			property["description"] = desc
+		}
+		for _, rule := range strings.Split(rules, ",") {
+			key, arg, _ := strings.Cut(rule, "=")
+			switch key {
+			case "required", "present":
+				required = append(required, name)
+			case "oneof":
+				property["enum"] = strings.Fields(arg)
+			case "min", "max":
+				if n, err := strconv.Atoi(arg); err == nil {
+					property[boundKeyword(field.Type, key)] = n
+				}
+			}
+		}
+		properties[name] = property
+	}
+	return properties, required
+}
+
+// typeSchema returns the JSON schema type of a Go field type.
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint, reflect.Uint64, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64, reflect.Float32:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties, required := objectSchema(t)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{"type": "object"}
+	}
+}
+
+// boundKeyword returns the schema keyword for a min or max rule on a type.
+func boundKeyword(t reflect.Type, rule string) string {
+	switch t.Kind() {
+	case reflect.String:
+		return rule + "Length"
+	case reflect.Slice:
+		return rule + "Items"
+	default:
+		return rule + "imum"
+	}
+}
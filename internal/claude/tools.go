@@ -5,57 +5,20 @@ import (
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
-// GetAllTools returns all available tools for Claude.
-func GetAllTools() []anthropic.ToolUnionParam {
-	return []anthropic.ToolUnionParam{
-		// Code Understanding
-		ReadFileTool(),
-		ListFilesTool(),
-		SearchCodeTool(),
-		GetTreeTool(),
-
-		// Code Modification
-		WriteFileTool(),
-		EditFileTool(),
-
-		// Build & Test
-		RunCommandTool(),
-		RunBuildTool(),
-		RunTestsTool(),
-
-		// Git Operations
-		GitStatusTool(),
-		GitDiffTool(),
-		GitLogTool(),
-		CreateBranchTool(),
-		CommitTool(),
-		PushTool(),
-		CreatePRTool(),
-		GetPRTool(),
-
-		// Project Intelligence
-		GetGuidelinesTool(),
-		FindTestsTool(),
-		AnalyzeFailuresTool(),
-	}
+// ToolCategory is a named group of related tools.
+type ToolCategory struct {
+	Name  string
+	Tools []anthropic.ToolUnionParam
 }
 
-// helper creates a tool with the given name, description and schema
-func makeTool(name, description string, properties map[string]any, required []string) anthropic.ToolUnionParam {
-	schema := anthropic.ToolInputSchemaParam{
-		Properties: properties,
-	}
-	if len(required) > 0 {
-		schema.ExtraFields = map[string]any{
-			"required": required,
-		}
-	}
-
+// helper creates a tool with the given name and description, deriving its
+// input schema from the parameter struct the executor binds (see Schema)
+func makeTool(name, description string, params any) anthropic.ToolUnionParam {
 	return anthropic.ToolUnionParam{
 		OfTool: &anthropic.ToolParam{
 			Name:        name,
 			Description: anthropic.String(description),
-			InputSchema: schema,
+			InputSchema: Schema(params),
 		},
 	}
 }
@@ -66,22 +29,8 @@ func makeTool(name, description string, properties map[string]any, required []st
 func ReadFileTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"read_file",
-		"Read the contents of a file at the given path. Returns the file content as text.",
-		map[string]any{
-			"path": map[string]any{
-				"type":        "string",
-				"description": "The relative path to the file from the repository root",
-			},
-			"start_line": map[string]any{
-				"type":        "integer",
-				"description": "Optional start line number (1-indexed). If provided, only returns lines from this point.",
-			},
-			"end_line": map[string]any{
-				"type":        "integer",
-				"description": "Optional end line number (1-indexed). If provided, only returns lines up to this point.",
-			},
-		},
-		[]string{"path"},
+		"Read the contents of a file at the given path. Returns the file content as text. Long files are returned a page at a time with their total line count and a cursor for the next page. Binary files, and text files over 1 MB read whole, are described (size, type, first bytes) instead of read.",
+		ReadFileParams{},
 	)
 }
 
@@ -90,13 +39,7 @@ func ListFilesTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"list_files",
 		"List files matching a glob pattern. Returns a list of file paths.",
-		map[string]any{
-			"pattern": map[string]any{
-				"type":        "string",
-				"description": "Glob pattern to match files (e.g., '**/*.java', 'src/**/*.go')",
-			},
-		},
-		[]string{"pattern"},
+		ListFilesParams{},
 	)
 }
 
@@ -105,25 +48,7 @@ func SearchCodeTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"search_code",
 		"Search for a pattern in the codebase using grep-like syntax. Returns matching lines with file paths and line numbers.",
-		map[string]any{
-			"pattern": map[string]any{
-				"type":        "string",
-				"description": "The search pattern (supports regex)",
-			},
-			"path": map[string]any{
-				"type":        "string",
-				"description": "Optional path to limit search scope (can be a directory or glob pattern)",
-			},
-			"case_sensitive": map[string]any{
-				"type":        "boolean",
-				"description": "Whether the search should be case-sensitive (default: false)",
-			},
-			"max_results": map[string]any{
-				"type":        "integer",
-				"description": "Maximum number of results to return (default: 50)",
-			},
-		},
-		[]string{"pattern"},
+		SearchCodeParams{},
 	)
 }
 
@@ -132,17 +57,52 @@ func GetTreeTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"get_tree",
 		"Get the directory structure of the repository or a subdirectory.",
-		map[string]any{
-			"path": map[string]any{
-				"type":        "string",
-				"description": "The path to get the tree for (default: repository root)",
-			},
-			"max_depth": map[string]any{
-				"type":        "integer",
-				"description": "Maximum depth to traverse (default: 3)",
-			},
-		},
-		nil,
+		GetTreeParams{},
+	)
+}
+
+// FindDefinitionTool returns the find_definition tool definition.
+func FindDefinitionTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"find_definition",
+		"Go to the definition of a symbol (function, method, type, field, constant or variable) in Go and Java code. Returns each declaration with its file, line and kind. Prefer this over search_code when looking for where something is declared.",
+		FindDefinitionParams{},
+	)
+}
+
+// FindReferencesTool returns the find_references tool definition.
+func FindReferencesTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"find_references",
+		"Find the uses of a symbol in Go and Java code, ignoring matches in comments and strings. Returns each file and line, with declarations marked. Prefer this over search_code when looking for callers or usages.",
+		FindReferencesParams{},
+	)
+}
+
+// GetOutlineTool returns the get_outline tool definition.
+func GetOutlineTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"get_outline",
+		"Get the structure of a file without reading it: its functions, types and method signatures with line numbers (Go, Java, Python, JavaScript and TypeScript). Given a directory instead, returns a map of its packages with their language, file count and exported types. Use this to orient yourself before reading whole files.",
+		GetOutlineParams{},
+	)
+}
+
+// SemanticSearchTool returns the semantic_search tool definition.
+func SemanticSearchTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"semantic_search",
+		"Search the codebase by meaning rather than exact text, using an embeddings index. Use it for questions like 'where is retry logic implemented?' when you don't know the names or strings to search for; use search_code for exact identifiers and text. Returns the closest chunks of code with their file, lines and a snippet.",
+		SemanticSearchParams{},
+	)
+}
+
+// FindLogCallsTool returns the find_log_calls tool definition.
+func FindLogCallsTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"find_log_calls",
+		"Find unstructured log calls (printf-style logging matching the configured patterns) to migrate to the structured logging API. Returns the calls grouped by file into chunks, one per pull request, and the structured API to convert them to.",
+		FindLogCallsParams{},
 	)
 }
 
@@ -152,18 +112,8 @@ func GetTreeTool() anthropic.ToolUnionParam {
 func WriteFileTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"write_file",
-		"Write content to a file. Creates the file if it doesn't exist, or overwrites if it does.",
-		map[string]any{
-			"path": map[string]any{
-				"type":        "string",
-				"description": "The relative path to the file from the repository root",
-			},
-			"content": map[string]any{
-				"type":        "string",
-				"description": "The content to write to the file",
-			},
-		},
-		[]string{"path", "content"},
+		"Write content to a file. Creates the file if it doesn't exist, or overwrites if it does, then runs the formatter configured for its extension (e.g. gofmt), so re-read the file before editing it further. Writes to write-protected paths (e.g. CI workflows) wait for human approval.",
+		WriteFileParams{},
 	)
 }
 
@@ -171,22 +121,26 @@ func WriteFileTool() anthropic.ToolUnionParam {
 func EditFileTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"edit_file",
-		"Make a targeted edit to a file by finding and replacing specific text. Use this for surgical changes rather than rewriting entire files.",
-		map[string]any{
-			"path": map[string]any{
-				"type":        "string",
-				"description": "The relative path to the file from the repository root",
-			},
-			"old_text": map[string]any{
-				"type":        "string",
-				"description": "The exact text to find and replace (must be unique in the file)",
-			},
-			"new_text": map[string]any{
-				"type":        "string",
-				"description": "The text to replace old_text with",
-			},
-		},
-		[]string{"path", "old_text", "new_text"},
+		"Make a targeted edit to a file by finding and replacing specific text, then run the formatter configured for its extension (e.g. gofmt). Use this for surgical changes rather than rewriting entire files. Edits to write-protected paths (e.g. CI workflows) wait for human approval.",
+		EditFileParams{},
+	)
+}
+
+// ApplyChangesTool returns the apply_changes tool definition.
+func ApplyChangesTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"apply_changes",
+		"Apply a set of file writes, edits and deletes atomically: all changes are checked before any file is touched, then applied together, and every file is rolled back if any change fails or the build (or tests) fail afterwards. Prefer this over separate write_file and edit_file calls for changes spanning several files. Changes to write-protected paths wait for human approval.",
+		ApplyChangesParams{},
+	)
+}
+
+// FormatFileTool returns the format_file tool definition.
+func FormatFileTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"format_file",
+		"Run the code formatter configured for a file's extension (e.g. gofmt, prettier, black, google-java-format) on it in place. write_file, edit_file and apply_changes already format the files they write; use this for files changed another way.",
+		FormatFileParams{},
 	)
 }
 
@@ -197,13 +151,7 @@ func RunCommandTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"run_command",
 		"Run a shell command in the repository directory. Only allowed commands: git, gh, ls, cat, head, tail, find, grep, wc, diff, echo, pwd, date, which.",
-		map[string]any{
-			"command": map[string]any{
-				"type":        "string",
-				"description": "The command to run",
-			},
-		},
-		[]string{"command"},
+		RunCommandParams{},
 	)
 }
 
@@ -212,13 +160,7 @@ func RunBuildTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"run_build",
 		"Run the project's build command (configured via STORMSTACK_BUILD_CMD).",
-		map[string]any{
-			"args": map[string]any{
-				"type":        "string",
-				"description": "Optional additional arguments to pass to the build command",
-			},
-		},
-		nil,
+		RunBuildParams{},
 	)
 }
 
@@ -226,14 +168,64 @@ func RunBuildTool() anthropic.ToolUnionParam {
 func RunTestsTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"run_tests",
-		"Run the project's test command (configured via STORMSTACK_TEST_CMD).",
-		map[string]any{
-			"args": map[string]any{
-				"type":        "string",
-				"description": "Optional additional arguments (e.g., specific test file or pattern)",
-			},
-		},
-		nil,
+		"Run the project's test command (configured via STORMSTACK_TEST_CMD). Results are cached by commit and uncommitted changes, so re-running against unchanged code returns the cached result. Set affected to run only the tests for the files changed on this branch, which is much faster on large suites; run the full suite before opening a PR.",
+		RunTestsParams{},
+	)
+}
+
+// RunLintTool returns the run_lint tool definition.
+func RunLintTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"run_lint",
+		"Run the project's linter (configured via STORMSTACK_LINT_CMD) and return its findings grouped by file, each with line, column, severity, rule and message.",
+		RunLintParams{},
+	)
+}
+
+// GetArtifactTool returns the get_artifact tool definition.
+func GetArtifactTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"get_artifact",
+		"Read the reports run_build and run_tests generated, such as JUnit XML, surefire reports and coverage summaries, which are collected after every run. Without a name it lists the artifacts of the latest run; with a name it returns the report. Set attach to upload the report to the Slack thread so the user can download it.",
+		GetArtifactParams{},
+	)
+}
+
+// Dependency Tools
+
+// ListDependenciesTool returns the list_dependencies tool definition.
+func ListDependenciesTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"list_dependencies",
+		"List the project's dependencies and their versions with its package manager (go list -m, mvn dependency:list or dependency:tree, gradle dependencies, npm ls, cargo tree, ...).",
+		ListDependenciesParams{},
+	)
+}
+
+// CheckOutdatedTool returns the check_outdated tool definition.
+func CheckOutdatedTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"check_outdated",
+		"List the project's direct dependencies that have newer versions available, with the current and latest versions (go list -m -u, mvn versions:display-dependency-updates, npm outdated, ...).",
+		CheckOutdatedParams{},
+	)
+}
+
+// BumpDependencyTool returns the bump_dependency tool definition.
+func BumpDependencyTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"bump_dependency",
+		"Update a dependency to a version with the package manager, changing the manifest and lockfile together (go get, mvn versions:use-dep-version, npm install, cargo add, ...), then run the build and report the changed files. Resolve a version range such as 33.x to an exact version with check_outdated first. Create a branch before bumping, and commit and open a PR afterwards if the build passes.",
+		BumpDependencyParams{},
+	)
+}
+
+// ScanVulnerabilitiesTool returns the scan_vulnerabilities tool definition.
+func ScanVulnerabilitiesTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"scan_vulnerabilities",
+		"Scan the project's dependencies for known vulnerabilities with govulncheck, npm audit or osv-scanner, returning one finding per advisory and package with its IDs and aliases, severity, installed and fixed versions, and for Go whether the vulnerable code is called. Set id to answer whether the project is affected by a specific CVE. To remediate, bump the package to the fixed version with bump_dependency.",
+		ScanVulnerabilitiesParams{},
 	)
 }
 
@@ -244,8 +236,7 @@ func GitStatusTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"git_status",
 		"Show the current git status including modified, staged, and untracked files.",
-		map[string]any{},
-		nil,
+		NoParams{},
 	)
 }
 
@@ -254,21 +245,7 @@ func GitDiffTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"git_diff",
 		"Show git diff of changes. Can show staged, unstaged, or between commits.",
-		map[string]any{
-			"staged": map[string]any{
-				"type":        "boolean",
-				"description": "If true, show staged changes only (--cached)",
-			},
-			"ref": map[string]any{
-				"type":        "string",
-				"description": "Optional commit/branch reference to diff against",
-			},
-			"path": map[string]any{
-				"type":        "string",
-				"description": "Optional file path to limit diff to",
-			},
-		},
-		nil,
+		GitDiffParams{},
 	)
 }
 
@@ -277,21 +254,7 @@ func GitLogTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"git_log",
 		"Show git commit history.",
-		map[string]any{
-			"count": map[string]any{
-				"type":        "integer",
-				"description": "Number of commits to show (default: 10)",
-			},
-			"path": map[string]any{
-				"type":        "string",
-				"description": "Optional file path to show history for",
-			},
-			"format": map[string]any{
-				"type":        "string",
-				"description": "Output format: 'oneline', 'short', 'medium', 'full' (default: 'oneline')",
-			},
-		},
-		nil,
+		GitLogParams{},
 	)
 }
 
@@ -300,17 +263,7 @@ func CreateBranchTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"create_branch",
 		"Create a new git branch and switch to it.",
-		map[string]any{
-			"name": map[string]any{
-				"type":        "string",
-				"description": "The branch name (will be sanitized)",
-			},
-			"from": map[string]any{
-				"type":        "string",
-				"description": "Optional base branch/commit to create from (default: current HEAD)",
-			},
-		},
-		[]string{"name"},
+		CreateBranchParams{},
 	)
 }
 
@@ -318,19 +271,8 @@ func CreateBranchTool() anthropic.ToolUnionParam {
 func CommitTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"commit",
-		"Stage files and create a git commit.",
-		map[string]any{
-			"message": map[string]any{
-				"type":        "string",
-				"description": "The commit message",
-			},
-			"files": map[string]any{
-				"type":        "array",
-				"items":       map[string]any{"type": "string"},
-				"description": "List of files to stage (default: all modified files)",
-			},
-		},
-		[]string{"message"},
+		"Stage files and create a git commit. In bulk workflows configured for fast commits, hooks are skipped and the files are formatted and linted before committing instead; a lint failure leaves them uncommitted.",
+		CommitParams{},
 	)
 }
 
@@ -339,13 +281,52 @@ func PushTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"push",
 		"Push the current branch to the remote repository.",
-		map[string]any{
-			"set_upstream": map[string]any{
-				"type":        "boolean",
-				"description": "Whether to set upstream tracking (-u flag, default: true for new branches)",
-			},
-		},
-		nil,
+		PushParams{},
+	)
+}
+
+// RebaseTool returns the rebase tool definition.
+func RebaseTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"rebase",
+		"Rebase the current branch onto another ref (e.g. origin/main). Requires a clean working tree and backs up the branch first. If it stops on conflicts, use list_conflicts, resolve them with edit_file, then continue_rebase. Force pushes are refused, so a rebased branch that was already pushed must be pushed under a new name.",
+		RebaseParams{},
+	)
+}
+
+// MergeBranchTool returns the merge_branch tool definition.
+func MergeBranchTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"merge_branch",
+		"Merge another ref (e.g. origin/main) into the current branch. Requires a clean working tree and backs up the branch first. If it stops on conflicts, use list_conflicts, resolve them with edit_file, then continue_rebase.",
+		MergeBranchParams{},
+	)
+}
+
+// ListConflictsTool returns the list_conflicts tool definition.
+func ListConflictsTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"list_conflicts",
+		"List files with unresolved conflicts from a rebase, merge or cherry-pick in progress, showing each conflict hunk with its line numbers and both sides.",
+		ListConflictsParams{},
+	)
+}
+
+// ContinueRebaseTool returns the continue_rebase tool definition.
+func ContinueRebaseTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"continue_rebase",
+		"Continue the rebase, merge or cherry-pick in progress after resolving every conflict. Refuses while conflict markers remain. May stop again on conflicts in later commits.",
+		NoParams{},
+	)
+}
+
+// AbortRebaseTool returns the abort_rebase tool definition.
+func AbortRebaseTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"abort_rebase",
+		"Abort the rebase, merge or cherry-pick in progress and restore the branch to its state before it started.",
+		NoParams{},
 	)
 }
 
@@ -354,25 +335,7 @@ func CreatePRTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"create_pr",
 		"Create a GitHub pull request using the gh CLI.",
-		map[string]any{
-			"title": map[string]any{
-				"type":        "string",
-				"description": "The PR title",
-			},
-			"body": map[string]any{
-				"type":        "string",
-				"description": "The PR description/body",
-			},
-			"base": map[string]any{
-				"type":        "string",
-				"description": "The base branch to merge into (default: main)",
-			},
-			"draft": map[string]any{
-				"type":        "boolean",
-				"description": "Whether to create as draft PR (default: false)",
-			},
-		},
-		[]string{"title", "body"},
+		CreatePRParams{},
 	)
 }
 
@@ -381,13 +344,115 @@ func GetPRTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"get_pr",
 		"Get details about a GitHub pull request including title, description, and diff. Use this to review PRs when given a PR URL or number.",
-		map[string]any{
-			"url": map[string]any{
-				"type":        "string",
-				"description": "The PR URL (e.g., https://github.com/owner/repo/pull/123) or just the PR number if in the same repo",
-			},
-		},
-		[]string{"url"},
+		GetPRParams{},
+	)
+}
+
+// GetPRChecksTool returns the get_pr_checks tool definition.
+func GetPRChecksTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"get_pr_checks",
+		"Get the CI status of a pull request: each check or pipeline job with its state (pass, fail, pending) and a link to its logs.",
+		GetPRChecksParams{},
+	)
+}
+
+// ReviewPRTool returns the review_pr tool definition.
+func ReviewPRTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"review_pr",
+		"Submit a review on a GitHub pull request: approve it, leave a general comment, or request changes. Use this to post your review on GitHub after reading the PR with get_pr.",
+		ReviewPRParams{},
+	)
+}
+
+// CommentOnPRLineTool returns the comment_on_pr_line tool definition.
+func CommentOnPRLineTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"comment_on_pr_line",
+		"Leave an inline review comment on a specific line of a file changed in a GitHub pull request.",
+		CommentOnPRLineParams{},
+	)
+}
+
+// CommentOnIssueTool returns the comment_on_issue tool definition.
+func CommentOnIssueTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"comment_on_issue",
+		"Post a comment on a GitHub issue or on a pull request's conversation.",
+		CommentOnIssueParams{},
+	)
+}
+
+// CreateIssueTool returns the create_issue tool definition.
+func CreateIssueTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"create_issue",
+		"Open a new GitHub issue. Use this to report a problem you investigated but could not fix, with what you found and how to reproduce it.",
+		CreateIssueParams{},
+	)
+}
+
+// MergePRTool returns the merge_pr tool definition.
+func MergePRTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"merge_pr",
+		"Merge a GitHub pull request and optionally delete its branch. Requires human approval: the first call asks the thread to approve, and the merge runs once someone replies 'approve'.",
+		MergePRParams{},
+	)
+}
+
+// RevertCommitTool returns the revert_commit tool definition.
+func RevertCommitTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"revert_commit",
+		"Revert a commit or merged PR that is on the default branch. Creates a revert branch from the latest default branch, commits the revert, pushes it and opens a revert PR; nothing is pushed to the default branch directly. Provide either sha or pr.",
+		RevertCommitParams{},
+	)
+}
+
+// BackportPRTool returns the backport_pr tool definition.
+func BackportPRTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"backport_pr",
+		"Backport a merged pull request to a release branch: creates a backport branch from the release branch and cherry-picks the PR onto it. If it stops on conflicts, resolve trivial ones with list_conflicts, edit_file and continue_rebase; abort_rebase and explain when they are not trivial. Then run the tests, push, and open the PR with create_pr, which targets the release branch and links the original PR.",
+		BackportPRParams{},
+	)
+}
+
+// LearnFromReviewTool returns the learn_from_review tool definition.
+func LearnFromReviewTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"learn_from_review",
+		"Read the reviewer feedback on one of your pull requests and remember the general conventions it contains for future work. Use this after a reviewer comments on your PR.",
+		LearnFromReviewParams{},
+	)
+}
+
+// AddressReviewCommentsTool returns the address_review_comments tool definition.
+func AddressReviewCommentsTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"address_review_comments",
+		"Start addressing the review comments on one of your open pull requests: checks out the PR's branch and lists the comments you haven't replied to yet, with their IDs. Make the requested changes, run the tests, commit and push to the same branch (don't open a new PR), then answer each comment with reply_to_review_comment.",
+		AddressReviewCommentsParams{},
+	)
+}
+
+// ReplyToReviewCommentTool returns the reply_to_review_comment tool definition.
+func ReplyToReviewCommentTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"reply_to_review_comment",
+		"Reply to a review comment on a pull request, in its thread where the forge has one, saying how it was addressed or why it wasn't. Push the changes first, so reviewers can see them.",
+		ReplyToReviewCommentParams{},
+	)
+}
+
+// WorkOnIssueTool returns the work_on_issue tool definition.
+func WorkOnIssueTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"work_on_issue",
+		"Start working on a GitHub issue: fetches the issue, creates a branch named after it from the latest default branch, and returns the issue details. Then implement the fix with the other tools and open a PR with create_pr, which will link the issue.",
+		WorkOnIssueParams{},
 	)
 }
 
@@ -398,8 +463,16 @@ func GetGuidelinesTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"get_guidelines",
 		"Load project guidelines from CLAUDE.md or a custom guidelines file. Use this to understand project conventions and coding standards.",
-		map[string]any{},
-		nil,
+		NoParams{},
+	)
+}
+
+// GetProjectInfoTool returns the get_project_info tool definition.
+func GetProjectInfoTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"get_project_info",
+		"Describe the project's stack as detected from its build files (go.mod, pom.xml, build.gradle, package.json, Cargo.toml, pyproject.toml, ...): languages, build systems, package manager, notable frameworks, and the commands run_build, run_tests and run_lint run. Use it first in an unfamiliar repository.",
+		NoParams{},
 	)
 }
 
@@ -408,13 +481,7 @@ func FindTestsTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"find_tests",
 		"Find the test file(s) associated with a source file.",
-		map[string]any{
-			"source_file": map[string]any{
-				"type":        "string",
-				"description": "The source file path to find tests for",
-			},
-		},
-		[]string{"source_file"},
+		FindTestsParams{},
 	)
 }
 
@@ -423,12 +490,57 @@ func AnalyzeFailuresTool() anthropic.ToolUnionParam {
 	return makeTool(
 		"analyze_failures",
 		"Analyze test or build output to identify and summarize failures.",
-		map[string]any{
-			"output": map[string]any{
-				"type":        "string",
-				"description": "The build/test output to analyze",
-			},
-		},
-		[]string{"output"},
+		AnalyzeFailuresParams{},
+	)
+}
+
+// Observability Tools
+
+// QueryLogsTool returns the query_logs tool definition.
+func QueryLogsTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"query_logs",
+		"Fetch recent application logs matching a query from the configured observability backend (Loki, CloudWatch Logs or Elasticsearch). Use it while debugging to see what the running application logged, e.g. the errors around a reported failure, instead of asking the user to paste logs. Returns the most recent matching lines, oldest first, with their time and source.",
+		QueryLogsParams{},
+	)
+}
+
+// Database Tools
+
+// DescribeDatabaseTool returns the describe_database tool definition.
+func DescribeDatabaseTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"describe_database",
+		"Inspect the schema of the application's configured database, read-only. Without a table, lists the tables and views; with one, returns its columns, types, defaults, constraints and indexes. Use it to check a query or migration in the repository against the live schema.",
+		DescribeDatabaseParams{},
+	)
+}
+
+// ExplainQueryTool returns the explain_query tool definition.
+func ExplainQueryTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"explain_query",
+		"Show the database planner's plan (EXPLAIN, without ANALYZE) for a SELECT query against the application's configured database, to reason about indexes and performance. The query is not run, and only single read-only SELECT statements are accepted.",
+		ExplainQueryParams{},
+	)
+}
+
+// Conversation Tools
+
+// ProposePlanTool returns the propose_plan tool definition.
+func ProposePlanTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"propose_plan",
+		"Propose an implementation plan before changing any code: the approach, the files to change and the test strategy. Read the code you need first. Large plans are posted for approval and code can't be changed until someone approves them; then end your turn and wait. If they ask for changes, revise the plan and propose it again. Small plans are approved at once. Only the files listed can be changed: to change others, propose a revised plan that lists them.",
+		ProposePlanParams{},
+	)
+}
+
+// AskQuestionTool returns the ask_question tool definition.
+func AskQuestionTool() anthropic.ToolUnionParam {
+	return makeTool(
+		"ask_question",
+		"Ask the user a clarifying question with a few possible answers, e.g. \"Should I target Java 17 or 21?\" with options \"Java 17\" and \"Java 21\". The options are shown as buttons under your reply, and the one the user clicks comes back as their next message. Use it when the answers can be enumerated, then end your turn; include the question in your reply too. The user can still answer in their own words.",
+		AskQuestionParams{},
 	)
 }
@@ -0,0 +1,254 @@
+// GitHub issue triage.
+
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// DefaultTriageLimit is the number of open issues triaged when no limit is given.
+const DefaultTriageLimit = 20
+
+// MaxTriageLimit is the most open issues triaged at once.
+const MaxTriageLimit = 100
+
+// maxTriageBody caps how much of each issue body is sent for triage.
+const maxTriageBody = 1500
+
+// triagePrompt instructs Claude to categorize and prioritize issues.
+const triagePrompt = `You are triaging the open GitHub issues of a software project.
+
+For every issue, decide:
+- category: one of bug, feature, docs, chore, question
+- priority: P0 (urgent, broken for users), P1 (important), P2 (normal) or P3 (nice to have)
+- labels: labels to add, chosen ONLY from the repository's existing labels (may be empty)
+- assignee: a login chosen ONLY from the assignable users, or "" if nobody is an obvious fit
+- rationale: one short sentence
+
+Reply with only a JSON array, one object per issue:
+[{"number": 1, "category": "bug", "priority": "P1", "labels": ["bug"], "assignee": "", "rationale": "..."}]`
+
+// TriageSuggestion is the proposed triage for a single issue.
+type TriageSuggestion struct {
+	Number    int      `json:"number"`
+	Title     string   `json:"-"`
+	Category  string   `json:"category"`
+	Priority  string   `json:"priority"`
+	Labels    []string `json:"labels"`
+	Assignee  string   `json:"assignee"`
+	Rationale string   `json:"rationale"`
+}
+
+// TriageReport is the outcome of a triage run.
+type TriageReport struct {
+	Suggestions []TriageSuggestion
+	CreatedAt   time.Time
+}
+
+// Triager asks Claude to categorize, label and prioritize open issues and
+// applies the suggestions once a human has reviewed them.
+type Triager struct {
+	client Client
+	forge  git.Forge
+	logger *slog.Logger
+
+	// last holds the latest report per key (usually a Slack channel) for Apply
+	mu   sync.Mutex
+	last map[string]*TriageReport
+}
+
+// NewTriager creates a new issue triager.
+func NewTriager(client Client, forge git.Forge, logger *slog.Logger) *Triager {
+	return &Triager{
+		client: client,
+		forge:  forge,
+		logger: logger,
+		last:   make(map[string]*TriageReport),
+	}
+}
+
+// Triage proposes triage for up to limit open issues and remembers the report
+// under key so it can be applied later.
+func (t *Triager) Triage(ctx context.Context, key string, limit int) (*TriageReport, error) {
+	if limit <= 0 {
+		limit = DefaultTriageLimit
+	}
+
+	issues, err := t.forge.ListIssues(ctx, "open", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+	if len(issues) == 0 {
+		return &TriageReport{CreatedAt: time.Now()}, nil
+	}
+
+	labels, err := t.forge.ListLabels(ctx)
+	if err != nil {
+		t.logger.Warn("failed to list labels", "error", err)
+	}
+	assignees, err := t.forge.ListAssignees(ctx)
+	if err != nil {
+		t.logger.Warn("failed to list assignees", "error", err)
+	}
+
+	var input strings.Builder
+	input.WriteString("Repository labels: " + strings.Join(labels, ", ") + "\n")
+	input.WriteString("Assignable users: " + strings.Join(assignees, ", ") + "\n\n")
+	for _, issue := range issues {
+		body := issue.Body
+		if len(body) > maxTriageBody {
+			body = body[:maxTriageBody] + "..."
+		}
+		input.WriteString(fmt.Sprintf("## #%d: %s\n", issue.Number, issue.Title))
+		if len(issue.Labels) > 0 {
+			names := make([]string, len(issue.Labels))
+			for i, l := range issue.Labels {
+				names[i] = l.Name
+			}
+			input.WriteString("Current labels: " + strings.Join(names, ", ") + "\n")
+		}
+		input.WriteString(body + "\n\n")
+	}
+
+	response, err := t.client.CreateMessage(ctx, anthropic.MessageNewParams{
+		System:   []anthropic.TextBlockParam{{Text: triagePrompt}},
+		Messages: []anthropic.MessageParam{BuildUserMessage(input.String())},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to triage issues: %w", err)
+	}
+
+	suggestions, err := parseTriage(ExtractTextContent(response))
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only suggestions for the listed issues, with labels and assignees that exist
+	titles := make(map[int]string, len(issues))
+	for _, issue := range issues {
+		titles[issue.Number] = issue.Title
+	}
+	report := &TriageReport{CreatedAt: time.Now()}
+	for _, s := range suggestions {
+		title, ok := titles[s.Number]
+		if !ok {
+			continue
+		}
+		s.Title = title
+		s.Labels = filterKnown(s.Labels, labels)
+		if len(filterKnown([]string{s.Assignee}, assignees)) == 0 {
+			s.Assignee = ""
+		}
+		report.Suggestions = append(report.Suggestions, s)
+	}
+
+	t.mu.Lock()
+	t.last[key] = report
+	t.mu.Unlock()
+
+	t.logger.Info("triaged issues", "issues", len(issues), "suggestions", len(report.Suggestions))
+	return report, nil
+}
+
+// Apply adds the labels and assignees from the last report made under key.
+// It returns the number of issues updated.
+func (t *Triager) Apply(ctx context.Context, key string) (int, error) {
+	t.mu.Lock()
+	report, ok := t.last[key]
+	delete(t.last, key)
+	t.mu.Unlock()
+
+	if !ok {
+		return 0, fmt.Errorf("no triage to apply, run triage first")
+	}
+
+	updated := 0
+	for _, s := range report.Suggestions {
+		var assignees []string
+		if s.Assignee != "" {
+			assignees = []string{s.Assignee}
+		}
+		if len(s.Labels) == 0 && len(assignees) == 0 {
+			continue
+		}
+		if err := t.forge.EditIssue(ctx, s.Number, s.Labels, assignees); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// FormatTriageReport formats a report as a table for Slack.
+func FormatTriageReport(report *TriageReport) string {
+	if len(report.Suggestions) == 0 {
+		return "No open issues to triage."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*Triage of %d open issues*\n```\n", len(report.Suggestions)))
+	sb.WriteString(fmt.Sprintf("%-6s %-3s %-8s %-24s %-14s %s\n", "Issue", "Pri", "Category", "Labels", "Assignee", "Title"))
+	for _, s := range report.Suggestions {
+		title := s.Title
+		if runes := []rune(title); len(runes) > 50 {
+			title = string(runes[:47]) + "..."
+		}
+		assignee := s.Assignee
+		if assignee == "" {
+			assignee = "-"
+		}
+		sb.WriteString(fmt.Sprintf("#%-5d %-3s %-8s %-24s %-14s %s\n",
+			s.Number, s.Priority, s.Category, truncate(strings.Join(s.Labels, ","), 24), truncate(assignee, 14), title))
+	}
+	sb.WriteString("```\n")
+	sb.WriteString("Reply `triage apply` to add these labels and assignees on GitHub.")
+	return sb.String()
+}
+
+// parseTriage extracts the JSON array of suggestions from a response.
+func parseTriage(text string) ([]TriageSuggestion, error) {
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("triage response contained no JSON array")
+	}
+
+	var suggestions []TriageSuggestion
+	if err := json.Unmarshal([]byte(text[start:end+1]), &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to parse triage response: %w", err)
+	}
+	return suggestions, nil
+}
+
+// filterKnown returns the values that appear in known (case-insensitively),
+// spelled as in known.
+func filterKnown(values, known []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, k := range known {
+			if v != "" && strings.EqualFold(v, k) {
+				out = append(out, k)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "~"
+}
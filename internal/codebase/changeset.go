@@ -0,0 +1,245 @@
+// Atomic multi-file change sets.
+
+package codebase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Change actions.
+const (
+	ChangeWrite  = "write"
+	ChangeEdit   = "edit"
+	ChangeDelete = "delete"
+)
+
+// Change is one file change of a change set.
+type Change struct {
+	Action string
+	Path   string
+	// Content is the new content of a written file
+	Content string
+	// OldText and NewText are the replacement an edit makes
+	OldText string
+	NewText string
+}
+
+// stagedFile is the state a change set gives one file.
+type stagedFile struct {
+	path     string
+	fullPath string
+	// content is the file's new content, or nil when it is deleted
+	content *string
+	actions []string
+
+	// The file before the change set was applied, to roll back to
+	existed bool
+	old     []byte
+	mode    fs.FileMode
+	// createdDirs are the directories created for the file, deepest first
+	createdDirs []string
+}
+
+// ChangeSet is a set of file writes, edits and deletes that are applied
+// together or not at all. The changes are staged in memory when the set is
+// created, so an edit that doesn't apply fails before any file is touched;
+// once applied, the whole set can be rolled back, e.g. when the build fails.
+type ChangeSet struct {
+	files   []*stagedFile
+	applied bool
+}
+
+// NewChangeSet stages changes in order, later changes to a file applying on
+// top of earlier ones. It fails if any change cannot be made, without
+// modifying anything.
+func (w *Writer) NewChangeSet(changes []Change) (*ChangeSet, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("change set is empty")
+	}
+
+	cs := &ChangeSet{}
+	byPath := make(map[string]*stagedFile)
+	for i, change := range changes {
+		fullPath, err := w.resolveWritable(change.Path)
+		if err != nil {
+			return nil, fmt.Errorf("change %d (%s %s): %w", i+1, change.Action, change.Path, err)
+		}
+
+		file, ok := byPath[fullPath]
+		if !ok {
+			if file, err = loadStagedFile(change.Path, fullPath); err != nil {
+				return nil, fmt.Errorf("change %d (%s %s): %w", i+1, change.Action, change.Path, err)
+			}
+			byPath[fullPath] = file
+			cs.files = append(cs.files, file)
+		}
+
+		if err := file.stage(change); err != nil {
+			return nil, fmt.Errorf("change %d (%s %s): %w", i+1, change.Action, change.Path, err)
+		}
+	}
+	return cs, nil
+}
+
+// loadStagedFile reads the current state of a file about to be staged.
+func loadStagedFile(path, fullPath string) (*stagedFile, error) {
+	file := &stagedFile{path: path, fullPath: fullPath, mode: 0644}
+
+	info, err := os.Stat(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	if file.old, err = os.ReadFile(fullPath); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content := string(file.old)
+	file.content = &content
+	file.existed = true
+	file.mode = info.Mode().Perm()
+	return file, nil
+}
+
+// stage applies a change to the file's staged content.
+func (f *stagedFile) stage(change Change) error {
+	switch change.Action {
+	case ChangeWrite:
+		content := change.Content
+		f.content = &content
+	case ChangeEdit:
+		if f.content == nil {
+			return fmt.Errorf("file does not exist")
+		}
+		count := strings.Count(*f.content, change.OldText)
+		if count == 0 {
+			return fmt.Errorf("old_text not found in file")
+		}
+		if count > 1 {
+			return fmt.Errorf("old_text found %d times in file (must be unique)", count)
+		}
+		content := strings.Replace(*f.content, change.OldText, change.NewText, 1)
+		f.content = &content
+	case ChangeDelete:
+		if f.content == nil {
+			return fmt.Errorf("file does not exist")
+		}
+		f.content = nil
+	default:
+		return fmt.Errorf("unknown action %q", change.Action)
+	}
+	f.actions = append(f.actions, change.Action)
+	return nil
+}
+
+// Apply writes the staged files. If any file cannot be written, the files
+// already written are restored before the error is returned.
+func (cs *ChangeSet) Apply() error {
+	if cs.applied {
+		return fmt.Errorf("change set already applied")
+	}
+	cs.applied = true
+
+	for i, file := range cs.files {
+		if err := file.apply(); err != nil {
+			cs.files = cs.files[:i+1]
+			if rollbackErr := cs.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to apply %s: %w (and %v)", file.path, err, rollbackErr)
+			}
+			return fmt.Errorf("failed to apply %s, all changes were rolled back: %w", file.path, err)
+		}
+	}
+	return nil
+}
+
+// apply writes or deletes the file.
+func (f *stagedFile) apply() error {
+	if f.content == nil {
+		if !f.existed {
+			return nil
+		}
+		return os.Remove(f.fullPath)
+	}
+
+	for dir := filepath.Dir(f.fullPath); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		f.createdDirs = append(f.createdDirs, dir)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.fullPath, []byte(*f.content), f.mode)
+}
+
+// Rollback restores every file to its state before Apply, removing created
+// files and directories.
+func (cs *ChangeSet) Rollback() error {
+	if !cs.applied {
+		return nil
+	}
+
+	var errs []error
+	for i := len(cs.files) - 1; i >= 0; i-- {
+		file := cs.files[i]
+		var err error
+		if file.existed {
+			err = os.WriteFile(file.fullPath, file.old, file.mode)
+		} else if err = os.Remove(file.fullPath); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", file.path, err))
+			continue
+		}
+		for _, dir := range file.createdDirs {
+			os.Remove(dir) // Only succeeds while empty
+		}
+	}
+	cs.applied = false
+	return errors.Join(errs...)
+}
+
+// Summary lists what the change set does to each file.
+func (cs *ChangeSet) Summary() string {
+	var sb strings.Builder
+	for _, file := range cs.files {
+		state := "modified"
+		switch {
+		case file.content == nil:
+			state = "deleted"
+		case !file.existed:
+			state = "created"
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s (%s)\n", file.path, state, strings.Join(file.actions, ", ")))
+	}
+	return sb.String()
+}
+
+// Written returns the paths of the files the change set writes, i.e. all
+// but the deleted ones.
+func (cs *ChangeSet) Written() []string {
+	var paths []string
+	for _, file := range cs.files {
+		if file.content != nil {
+			paths = append(paths, file.path)
+		}
+	}
+	return paths
+}
+
+// Len returns the number of files the change set touches.
+func (cs *ChangeSet) Len() int {
+	return len(cs.files)
+}
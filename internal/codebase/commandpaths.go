@@ -0,0 +1,248 @@
+// Restricted paths in shell commands.
+
+package codebase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+// errFoundRestricted stops a walk at the first restricted path.
+var errFoundRestricted = errors.New("found restricted path")
+
+// braceRe matches a brace expansion, such as {a,b} or {1..3}.
+var braceRe = regexp.MustCompile(`\{[^{}]*(,|\.\.)[^{}]*\}`)
+
+// commandWrappers run the command that follows their options.
+var commandWrappers = map[string]bool{
+	"env": true, "nice": true, "nohup": true, "time": true, "timeout": true,
+	"sudo": true, "command": true, "exec": true, "stdbuf": true,
+}
+
+// alwaysRecursive read whole directories, the working directory when given
+// none. xargs runs a command on whatever names it is fed, so it counts too.
+var alwaysRecursive = map[string]bool{
+	"rg": true, "ag": true, "ack": true, "find": true, "xargs": true,
+	"tar": true, "zip": true, "rsync": true,
+}
+
+// recursiveFlags make the commands they belong to read directories
+// recursively, as long options and short option letters.
+var recursiveFlags = map[string]struct {
+	long  []string
+	short string
+}{
+	"grep":  {long: []string{"--recursive", "--dereference-recursive"}, short: "rR"},
+	"egrep": {long: []string{"--recursive", "--dereference-recursive"}, short: "rR"},
+	"fgrep": {long: []string{"--recursive", "--dereference-recursive"}, short: "rR"},
+	"zgrep": {long: []string{"--recursive", "--dereference-recursive"}, short: "rR"},
+	"cp":    {long: []string{"--recursive", "--archive"}, short: "rRa"},
+	"scp":   {short: "r"},
+	"diff":  {long: []string{"--recursive"}, short: "r"},
+	"ls":    {long: []string{"--recursive"}, short: "R"},
+}
+
+// CheckCommand returns an error wrapping ErrRestricted when a shell command
+// run in the checkout could read restricted paths: when it names one, as
+// relative, ./ or absolute path, when a glob in it matches one, or when it
+// reads a directory holding one recursively, as grep -r, find or rg do. It
+// can't see through variables or command substitution, so it narrows
+// rather than closes what commands can reach.
+func (p *PathPolicy) CheckCommand(command string) error {
+	if p == nil {
+		return nil
+	}
+	return p.checkCommand(command, ".")
+}
+
+// checkCommand checks command, run in the repository directory dir.
+func (p *PathPolicy) checkCommand(command, dir string) error {
+	commands, ok := executor.SplitCommandLine(strings.NewReplacer("\n", ";", "\r", ";").Replace(command))
+	if !ok {
+		return fmt.Errorf("command has an unclosed quote")
+	}
+
+	for _, words := range commands {
+		name, args := commandName(words)
+		if name == "" {
+			continue
+		}
+
+		// Shells run their -c argument as another command line
+		if (name == "sh" || name == "bash" || name == "zsh" || name == "dash") && len(args) > 1 && args[0] == "-c" {
+			if err := p.checkCommand(args[1], dir); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var dirs []string
+		for _, word := range words {
+			for _, arg := range strings.FieldsFunc(word, isPathSeparator) {
+				paths, err := p.commandPaths(dir, arg)
+				if err != nil {
+					return err
+				}
+				for _, rel := range paths {
+					if p.Restricted(rel) {
+						return fmt.Errorf("command refers to %s, which is %w", arg, ErrRestricted)
+					}
+					if info, err := os.Stat(filepath.Join(p.repoPath, filepath.FromSlash(rel))); err == nil && info.IsDir() {
+						dirs = append(dirs, rel)
+					}
+				}
+			}
+		}
+
+		if name == "cd" {
+			if len(dirs) > 0 {
+				dir = dirs[len(dirs)-1]
+			}
+			continue
+		}
+		if !readsRecursively(name, args) {
+			continue
+		}
+		if len(dirs) == 0 {
+			dirs = []string{dir}
+		}
+		for _, d := range dirs {
+			if p.holdsRestricted(d) {
+				return fmt.Errorf("%s reads %s recursively, which holds paths that are %w; name the files to read instead", name, d, ErrRestricted)
+			}
+		}
+	}
+	return nil
+}
+
+// commandName returns the command words run, past environment assignments
+// and wrappers such as env and sudo, and its arguments.
+func commandName(words []string) (string, []string) {
+	for i, word := range words {
+		switch {
+		case strings.Contains(word, "=") && !strings.HasPrefix(word, "-"):
+		case commandWrappers[path.Base(word)]:
+		case strings.HasPrefix(word, "-") && i > 0 && commandWrappers[path.Base(words[i-1])]:
+		case i > 0 && path.Base(words[i-1]) == "timeout" && strings.TrimRight(word, "0123456789.smhd") == "":
+		default:
+			return path.Base(word), words[i+1:]
+		}
+	}
+	return "", nil
+}
+
+// readsRecursively reports whether the command name, given args, reads the
+// directories it is given, or the working directory, recursively.
+func readsRecursively(name string, args []string) bool {
+	if alwaysRecursive[name] {
+		return true
+	}
+	if name == "git" {
+		for _, arg := range args {
+			if arg == "grep" || arg == "archive" {
+				return true
+			}
+		}
+		return false
+	}
+
+	flags, ok := recursiveFlags[name]
+	if !ok {
+		return false
+	}
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case strings.HasPrefix(arg, "--"):
+			long, _, _ := strings.Cut(arg, "=")
+			for _, flag := range flags.long {
+				if long == flag {
+					return true
+				}
+			}
+		case strings.HasPrefix(arg, "-") && strings.ContainsAny(arg[1:], flags.short):
+			return true
+		}
+	}
+	return false
+}
+
+// commandPaths returns the repository paths arg may stand for in a command
+// run in dir: the files a glob matches, or the path itself. Paths outside
+// the repository are left out.
+func (p *PathPolicy) commandPaths(dir, arg string) ([]string, error) {
+	arg = filepath.ToSlash(arg)
+	var target string
+	if path.IsAbs(arg) {
+		root, err := filepath.Abs(p.repoPath)
+		if err != nil {
+			return nil, nil
+		}
+		rel, err := filepath.Rel(root, filepath.FromSlash(arg))
+		if err != nil || !filepath.IsLocal(rel) && rel != "." {
+			return nil, nil
+		}
+		target = filepath.ToSlash(rel)
+	} else {
+		target = path.Join(dir, arg)
+	}
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return nil, nil
+	}
+
+	if !strings.ContainsAny(target, "*?[{") {
+		return []string{target}, nil
+	}
+
+	// Brace expansions become wildcards, matching at least as much
+	pattern := braceRe.ReplaceAllString(target, "*")
+	matches, err := filepath.Glob(filepath.Join(p.repoPath, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, fmt.Errorf("command has an invalid pattern %s: %w", arg, err)
+	}
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if rel, err := filepath.Rel(p.repoPath, match); err == nil {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+	}
+	return paths, nil
+}
+
+// holdsRestricted reports whether the repository directory dir holds a
+// restricted path at any depth.
+func (p *PathPolicy) holdsRestricted(dir string) bool {
+	root := filepath.Join(p.repoPath, filepath.FromSlash(dir))
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(p.repoPath, file)
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if p.Restricted(rel) {
+			return errFoundRestricted
+		}
+		return nil
+	})
+	return errors.Is(err, errFoundRestricted)
+}
+
+// isPathSeparator reports whether r separates paths within a command word.
+func isPathSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`"'=:;|&<>()`+"`", r)
+}
@@ -0,0 +1,110 @@
+// Code formatting of written files.
+
+package codebase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// formatTimeout bounds a single formatter run
+	formatTimeout = time.Minute
+	// maxFormatOutput bounds the formatter output reported on failure
+	maxFormatOutput = 2000
+)
+
+// Errors returned by Format when a file cannot be formatted.
+var (
+	ErrNoFormatter      = errors.New("no formatter is configured")
+	ErrFormatterMissing = errors.New("formatter is not installed")
+)
+
+// Formatter knows the code formatter to run for each file extension, e.g.
+// gofmt for .go files.
+type Formatter struct {
+	commands map[string]string
+}
+
+// NewFormatter parses formatter specs of the form ".ext=command", e.g.
+// ".go=gofmt -w". The file's path replaces {file} in the command, or is
+// appended to it.
+func NewFormatter(specs []string) (*Formatter, error) {
+	f := &Formatter{commands: make(map[string]string, len(specs))}
+	for _, spec := range specs {
+		ext, command, ok := strings.Cut(spec, "=")
+		ext, command = strings.ToLower(strings.TrimSpace(ext)), strings.TrimSpace(command)
+		if !ok || ext == "" || command == "" {
+			return nil, fmt.Errorf("invalid formatter %q: must be .ext=command", spec)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		f.commands[ext] = command
+	}
+	return f, nil
+}
+
+// command returns the formatter command for a file, or "" if there is none.
+func (f *Formatter) command(path string) string {
+	if f == nil {
+		return ""
+	}
+	return f.commands[strings.ToLower(filepath.Ext(path))]
+}
+
+// UseFormatter sets the formatter Format runs.
+func (w *Writer) UseFormatter(f *Formatter) {
+	w.formatter = f
+}
+
+// Format runs the formatter configured for a file's extension on it in
+// place and returns the formatter's name. It fails with ErrNoFormatter when
+// none is configured, ErrFormatterMissing when its program is not installed,
+// and the formatter's output when it rejects the file, e.g. for a syntax
+// error.
+func (w *Writer) Format(ctx context.Context, path string) (string, error) {
+	fullPath, err := w.resolveWritable(path)
+	if err != nil {
+		return "", err
+	}
+
+	command := w.formatter.command(path)
+	if command == "" {
+		return "", fmt.Errorf("%w for %s files", ErrNoFormatter, filepath.Ext(path))
+	}
+	program := strings.Fields(command)[0]
+	if _, err := exec.LookPath(program); err != nil {
+		return "", fmt.Errorf("%s %w", program, ErrFormatterMissing)
+	}
+
+	quoted := "'" + strings.ReplaceAll(fullPath, "'", `'\''`) + "'"
+	if strings.Contains(command, "{file}") {
+		command = strings.ReplaceAll(command, "{file}", quoted)
+	} else {
+		command += " " + quoted
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = w.repoPath
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		out := strings.TrimSpace(output.String())
+		if len(out) > maxFormatOutput {
+			out = out[:maxFormatOutput] + "\n... (truncated)"
+		}
+		return "", fmt.Errorf("%s failed on %s: %w\n%s", program, path, err, out)
+	}
+	return program, nil
+}
@@ -0,0 +1,131 @@
+// Detection of generated and vendored files.
+
+package codebase
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrGenerated is returned, wrapped, for changes to generated or vendored
+// files made without an override.
+var ErrGenerated = errors.New("generated or vendored")
+
+const (
+	// maxMarkerHeader is how much of the start of a file is searched for
+	// generated code markers
+	maxMarkerHeader = 2048
+	// maxMarkerLine bounds the marker line quoted in errors
+	maxMarkerLine = 120
+)
+
+// GeneratedRules recognise files that should not be edited by hand: paths
+// such as vendored dependencies and lockfiles, using the same pattern rules
+// as PathPolicy, and files whose header carries a marker such as
+// "Code generated ... DO NOT EDIT.". A nil GeneratedRules matches nothing.
+type GeneratedRules struct {
+	paths   []string
+	markers []string
+}
+
+// NewGeneratedRules creates rules matching the given path patterns and
+// header markers.
+func NewGeneratedRules(paths, markers []string) (*GeneratedRules, error) {
+	cleaned, err := cleanPatterns(paths, "generated")
+	if err != nil {
+		return nil, err
+	}
+	return &GeneratedRules{paths: cleaned, markers: markers}, nil
+}
+
+// match describes why the file at relPath, stored at fullPath, is generated,
+// or returns "" if it is not. Files that don't exist yet only match by path.
+func (g *GeneratedRules) match(relPath, fullPath string) string {
+	if g == nil {
+		return ""
+	}
+
+	relPath = strings.TrimPrefix(path.Clean(filepath.ToSlash(relPath)), "/")
+	if matchesAny(g.paths, relPath) {
+		return "it matches a vendored or generated path (STORMSTACK_GENERATED_PATHS)"
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var marker, source string
+	scanner := bufio.NewScanner(io.LimitReader(file, maxMarkerHeader))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if marker == "" && containsAny(line, g.markers) {
+			marker = line
+		}
+		// e.g. "// source: api/v1/user.proto" below protoc's marker
+		if _, after, ok := strings.Cut(line, "source:"); ok && source == "" {
+			source = strings.TrimSpace(after)
+		}
+	}
+	if marker == "" {
+		return ""
+	}
+
+	reason := fmt.Sprintf("its header says %q", clip(marker, maxMarkerLine))
+	if source != "" {
+		reason += fmt.Sprintf(" and names its source, %s", clip(source, maxMarkerLine))
+	}
+	return reason
+}
+
+// containsAny reports whether s contains one of substrings.
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if sub != "" && strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// clip shortens s to at most limit bytes, marking the cut.
+func clip(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
+
+// UseGeneratedRules sets the rules for the generated files the writer refuses
+// to change.
+func (w *Writer) UseGeneratedRules(g *GeneratedRules) {
+	w.generated = g
+}
+
+// AllowGenerated returns a writer that may change generated and vendored
+// files, for changes that cannot be made in the generator's source.
+func (w *Writer) AllowGenerated() *Writer {
+	allowed := *w
+	allowed.allowGenerated = true
+	return &allowed
+}
+
+// checkGenerated refuses changes to generated files unless they are allowed,
+// pointing at the generator's source instead.
+func (w *Writer) checkGenerated(relPath, fullPath string) error {
+	if w.allowGenerated {
+		return nil
+	}
+	if reason := w.generated.match(relPath, fullPath); reason != "" {
+		return fmt.Errorf("%s is %w: %s. Change the source it is generated from (or the dependency upstream) and regenerate it instead, or set override_generated if it really must be edited by hand",
+			relPath, ErrGenerated, reason)
+	}
+	return nil
+}
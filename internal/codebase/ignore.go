@@ -0,0 +1,105 @@
+// .gitignore and .stormstackignore handling.
+
+package codebase
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// IgnoreFileName is the file at the repository root listing, in .gitignore
+// format, paths the bot may not read, write, search or list.
+const IgnoreFileName = ".stormstackignore"
+
+// defaultSkipDirs are left out of listings and searches of repositories
+// without a .gitignore.
+var defaultSkipDirs = []string{"node_modules", "vendor", "target", "build", "__pycache__"}
+
+// readIgnoreFile parses the patterns of a .gitignore-format file, scoped to
+// the directory domain. A missing file has no patterns.
+func readIgnoreFile(file string, domain []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseIgnorePatterns(f, domain)
+}
+
+// parseIgnorePatterns parses .gitignore-format patterns read from r, scoped
+// to the directory domain.
+func parseIgnorePatterns(r io.Reader, domain []string) ([]gitignore.Pattern, error) {
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns, scanner.Err()
+}
+
+// gitIgnores reports which paths the repository's .gitignore files ignore,
+// reading each directory's file at most once. Repositories without a
+// .gitignore at the root fall back to skipping defaultSkipDirs.
+type gitIgnores struct {
+	repoPath string
+	fallback bool
+	dirs     map[string][]gitignore.Pattern
+}
+
+// newGitIgnores creates a gitIgnores for one listing or search of repoPath,
+// so edits to .gitignore files apply to the next one.
+func newGitIgnores(repoPath string) *gitIgnores {
+	_, err := os.Stat(filepath.Join(repoPath, ".gitignore"))
+	return &gitIgnores{
+		repoPath: repoPath,
+		fallback: err != nil,
+		dirs:     make(map[string][]gitignore.Pattern),
+	}
+}
+
+// ignored reports whether a repository-relative path is ignored.
+func (g *gitIgnores) ignored(relPath string, isDir bool) bool {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(relPath)), "/")
+	if relPath == "" || parts[0] == "." {
+		return false
+	}
+
+	if g.fallback {
+		for i, name := range parts {
+			if (isDir || i < len(parts)-1) && slices.Contains(defaultSkipDirs, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Patterns of deeper .gitignore files come later and take precedence
+	var patterns []gitignore.Pattern
+	for i := range parts {
+		patterns = append(patterns, g.patterns(parts[:i])...)
+	}
+	return gitignore.NewMatcher(patterns).Match(parts, isDir)
+}
+
+// patterns returns the patterns of the .gitignore in a directory.
+func (g *gitIgnores) patterns(dir []string) []gitignore.Pattern {
+	key := strings.Join(dir, "/")
+	if patterns, ok := g.dirs[key]; ok {
+		return patterns
+	}
+
+	file := filepath.Join(g.repoPath, filepath.FromSlash(key), ".gitignore")
+	patterns, _ := readIgnoreFile(file, dir)
+	g.dirs[key] = patterns
+	return patterns
+}
@@ -0,0 +1,91 @@
+// Discovery of unstructured log calls.
+
+package codebase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxLogCalls caps how many log calls one search reports.
+const maxLogCalls = 5000
+
+// LogCallChunk is a group of whole files whose log calls are migrated in
+// one pull request.
+type LogCallChunk struct {
+	Files []string
+	Calls []SearchResult
+}
+
+// FindLogCalls finds the lines under path matching any of patterns and
+// groups them by file into chunks of about chunkSize calls. A file is never
+// split across chunks, so a file with more calls than chunkSize gets a chunk
+// of its own.
+func (s *Searcher) FindLogCalls(patterns []string, path string, chunkSize int) ([]LogCallChunk, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no log call patterns configured")
+	}
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	alternatives := make([]string, len(patterns))
+	for i, p := range patterns {
+		alternatives[i] = "(?:" + p + ")"
+	}
+	results, err := s.SearchCode(strings.Join(alternatives, "|"), path, true, 0, maxLogCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	byFile := make(map[string][]SearchResult)
+	for _, r := range results {
+		byFile[r.File] = append(byFile[r.File], r)
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var chunks []LogCallChunk
+	var current LogCallChunk
+	for _, file := range files {
+		calls := byFile[file]
+		if len(current.Calls) > 0 && len(current.Calls)+len(calls) > chunkSize {
+			chunks = append(chunks, current)
+			current = LogCallChunk{}
+		}
+		current.Files = append(current.Files, file)
+		current.Calls = append(current.Calls, calls...)
+	}
+	if len(current.Calls) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks, nil
+}
+
+// FormatLogCallChunks formats log call chunks for display.
+func FormatLogCallChunks(chunks []LogCallChunk) string {
+	if len(chunks) == 0 {
+		return "No unstructured log calls found."
+	}
+
+	calls, files := 0, 0
+	for _, c := range chunks {
+		calls += len(c.Calls)
+		files += len(c.Files)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d log calls in %d files, in %d chunks:\n", calls, files, len(chunks)))
+	if calls >= maxLogCalls {
+		sb.WriteString(fmt.Sprintf("(stopped at %d calls; search a narrower path for the rest)\n", maxLogCalls))
+	}
+	for i, c := range chunks {
+		sb.WriteString(fmt.Sprintf("\n## Chunk %d of %d (%d calls in %d files)\n", i+1, len(chunks), len(c.Calls), len(c.Files)))
+		sb.WriteString(FormatSearchResults(c.Calls))
+	}
+	return sb.String()
+}
@@ -0,0 +1,312 @@
+// File outlines and a repository package map.
+
+package codebase
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxMapPackages caps the number of directories listed in a package map.
+const maxMapPackages = 200
+
+// OutlineEntry is one declaration in a file outline.
+type OutlineEntry struct {
+	Line int
+	// Depth is the nesting level, e.g. 1 for members of a class
+	Depth int
+	// Signature is the declaration without its body
+	Signature string
+}
+
+// Outline returns the structure of a file, or a package map when path is a
+// directory (the repository root when empty). Outlines list functions,
+// types and method signatures with line numbers without reading whole files.
+func (s *Searcher) Outline(path string) (string, error) {
+	root, err := s.navigationRoot(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return s.packageMap(root)
+	}
+
+	entries, err := FileOutline(root)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return fmt.Sprintf("%s: no declarations found", path), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(path + "\n")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%5d  %s%s\n", e.Line, strings.Repeat("  ", e.Depth), e.Signature))
+	}
+	return sb.String(), nil
+}
+
+// FileOutline returns the declarations in a Go, Java, Python, JavaScript or
+// TypeScript file.
+func FileOutline(path string) ([]OutlineEntry, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return goOutline(path, src)
+	case ".java":
+		return javaOutline(string(src)), nil
+	case ".py":
+		return patternOutline(string(src), pythonOutlineRe), nil
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return patternOutline(string(src), scriptOutlineRe), nil
+	default:
+		return nil, fmt.Errorf("outlines are not supported for %s files", filepath.Ext(path))
+	}
+}
+
+// goOutline lists a Go file's declarations, with methods and functions
+// printed as signatures.
+func goOutline(path string, src []byte) ([]OutlineEntry, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	entries := []OutlineEntry{{Line: 1, Signature: "package " + file.Name.Name}}
+	add := func(pos token.Pos, depth int, signature string) {
+		entries = append(entries, OutlineEntry{Line: fset.Position(pos).Line, Depth: depth, Signature: signature})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sig := *d
+			sig.Body, sig.Doc = nil, nil
+			add(d.Pos(), 0, printNode(fset, &sig))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					add(sp.Pos(), 0, "type "+sp.Name.Name+" "+typeSummary(fset, sp))
+					if iface, ok := sp.Type.(*ast.InterfaceType); ok {
+						for _, m := range iface.Methods.List {
+							add(m.Pos(), 1, printNode(fset, m))
+						}
+					}
+				case *ast.ValueSpec:
+					var names []string
+					for _, n := range sp.Names {
+						names = append(names, n.Name)
+					}
+					add(sp.Pos(), 0, d.Tok.String()+" "+strings.Join(names, ", "))
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// typeSummary describes a type declaration's underlying type briefly.
+func typeSummary(fset *token.FileSet, spec *ast.TypeSpec) string {
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		return fmt.Sprintf("struct (%d fields)", t.Fields.NumFields())
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	summary := printNode(fset, spec.Type)
+	if spec.Assign.IsValid() {
+		summary = "= " + summary
+	}
+	return summary
+}
+
+// printNode formats an AST node on a single line.
+func printNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// javaOutline lists a Java file's types and members, nesting members under
+// their type.
+func javaOutline(src string) []OutlineEntry {
+	var entries []OutlineEntry
+	for _, sym := range javaSymbols(src) {
+		depth := 0
+		if sym.Container != "" {
+			depth = 1
+		}
+		entries = append(entries, OutlineEntry{Line: sym.Line, Depth: depth, Signature: trimBody(sym.Content)})
+	}
+	return entries
+}
+
+// Declarations recognised in Python and in JavaScript/TypeScript. The leading
+// indentation sets the outline depth.
+var (
+	pythonOutlineRe = regexp.MustCompile(`^(\s*)((?:async\s+)?def\s+\w+|class\s+\w+)`)
+	scriptOutlineRe = regexp.MustCompile(`^(\s*)((?:export\s+)?(?:default\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\s*\*?\s*\w+|class\s+\w+|interface\s+\w+|type\s+\w+\s*=|enum\s+\w+|(?:const|let)\s+\w+\s*=\s*(?:async\s*)?(?:\([^)]*\)|\w+)\s*=>)|(?:(?:public|private|protected|static|readonly|async|get|set)\s+)*\w+\s*\([^)]*\)\s*(?::\s*[^={]+)?\{)`)
+)
+
+// scriptKeywords look like method declarations but are statements.
+var scriptKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true, "function": true}
+
+// patternOutline lists the lines of src matching a declaration pattern.
+func patternOutline(src string, re *regexp.Regexp) []OutlineEntry {
+	var entries []OutlineEntry
+	for i, line := range strings.Split(src, "\n") {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if first := strings.Fields(m[2]); len(first) > 0 && scriptKeywords[strings.TrimRight(first[0], "(")] {
+			continue
+		}
+		indent := strings.ReplaceAll(m[1], "\t", "    ")
+		entries = append(entries, OutlineEntry{Line: i + 1, Depth: len(indent) / 4, Signature: trimBody(line)})
+	}
+	return entries
+}
+
+// trimBody strips an opening brace or colon and surrounding whitespace from
+// a declaration line.
+func trimBody(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(line, "{")
+	line = strings.TrimSuffix(strings.TrimSpace(line), ":")
+	return strings.TrimSpace(line)
+}
+
+// mapPackage summarises one directory of source files.
+type mapPackage struct {
+	dir      string
+	language string
+	name     string
+	files    int
+	exported []string
+}
+
+// packageMap lists the source directories under root with their language,
+// package name, file count and exported Go types.
+func (s *Searcher) packageMap(root string) (string, error) {
+	packages := make(map[string]*mapPackage)
+	err := s.walk(root, func(filePath, relPath string) error {
+		language := outlineLanguage(filePath)
+		if language == "" || strings.HasSuffix(filePath, "_test.go") {
+			return nil
+		}
+		dir := filepath.Dir(relPath)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &mapPackage{dir: dir, language: language}
+			packages[dir] = pkg
+		}
+		pkg.files++
+
+		if language == "go" {
+			fset := token.NewFileSet()
+			if file, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution); err == nil {
+				pkg.name = file.Name.Name
+				for _, decl := range file.Decls {
+					if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+						for _, spec := range gen.Specs {
+							if ts := spec.(*ast.TypeSpec); ts.Name.IsExported() {
+								pkg.exported = append(pkg.exported, ts.Name.Name)
+							}
+						}
+					}
+				}
+			}
+		} else if language == "java" && pkg.name == "" {
+			pkg.name = javaPackage(filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to map packages: %w", err)
+	}
+	if len(packages) == 0 {
+		return "No source files found.", nil
+	}
+
+	dirs := make([]string, 0, len(packages))
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d source directories:\n", len(dirs)))
+	for i, dir := range dirs {
+		if i == maxMapPackages {
+			sb.WriteString(fmt.Sprintf("... and %d more (pass a subdirectory as path)\n", len(dirs)-i))
+			break
+		}
+		pkg := packages[dir]
+		sb.WriteString(fmt.Sprintf("%s [%s", dir, pkg.language))
+		if pkg.name != "" {
+			sb.WriteString(" " + pkg.name)
+		}
+		sb.WriteString(fmt.Sprintf(", %d files]", pkg.files))
+		if len(pkg.exported) > 0 {
+			sort.Strings(pkg.exported)
+			sb.WriteString(" types: " + strings.Join(pkg.exported, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// outlineLanguage names the language of a source file with outline support.
+func outlineLanguage(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".java":
+		return "java"
+	case ".py":
+		return "python"
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx":
+		return "typescript"
+	}
+	return ""
+}
+
+// javaPackageRe matches a Java package declaration.
+var javaPackageRe = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+
+// javaPackage returns the package a Java file declares.
+func javaPackage(path string) string {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if m := javaPackageRe.FindSubmatch(src); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,231 @@
+// Path access policies.
+
+package codebase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ErrRestricted is returned, wrapped, for paths the policy restricts.
+var ErrRestricted = errors.New("restricted by the data handling policy")
+
+// ErrProtected is returned, wrapped, for changes to write-protected paths
+// that were not approved.
+var ErrProtected = errors.New("write-protected")
+
+// ignoreRecheckInterval is how often the ignore file is checked for changes.
+const ignoreRecheckInterval = 5 * time.Second
+
+// PathPolicy marks repository paths as restricted: tools may not read, write,
+// search or list them. Patterns use gitignore-like rules:
+//   - "secrets/" restricts a directory and everything below it
+//   - "*.pem" (no slash) matches a file or directory name at any depth
+//   - "config/prod/*.yaml" (with a slash) is matched from the repository root
+//
+// The repository's .stormstackignore restricts further paths, using the
+// full .gitignore syntax including "!" exceptions. It is read from an
+// IgnoreSource, the file as committed rather than in the checkout the bot
+// can edit, and reloaded when it changes, so a repository sync applies
+// edits to it.
+//
+// Protected paths, such as CI workflows, may be read but only changed with
+// human approval. They use the same pattern rules.
+//
+// A nil PathPolicy allows everything.
+type PathPolicy struct {
+	// rulesMu guards the patterns, which a configuration reload may replace
+	rulesMu   sync.RWMutex
+	patterns  []string
+	protected []string
+
+	// repoPath is the checkout the paths are in, and ignoreSource reads its
+	// ignore file; mu guards the loaded rules
+	repoPath      string
+	ignoreSource  IgnoreSource
+	mu            sync.Mutex
+	checkedAt     time.Time
+	ignoreContent []byte
+	ignoreRules   gitignore.Matcher
+}
+
+// IgnoreSource reads the repository's .stormstackignore, returning nil when
+// it has none.
+type IgnoreSource func() ([]byte, error)
+
+// NewPathPolicy creates a policy that restricts the given patterns in the
+// checkout at repoPath and, if source is set, the paths listed in the
+// .stormstackignore it reads.
+func NewPathPolicy(patterns []string, repoPath string, source IgnoreSource) (*PathPolicy, error) {
+	p := &PathPolicy{repoPath: repoPath, ignoreSource: source}
+	var err error
+	if p.patterns, err = cleanPatterns(patterns, "restricted"); err != nil {
+		return nil, err
+	}
+	if source != nil {
+		if _, err := source(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+		}
+	}
+	return p, nil
+}
+
+// Restricted reports whether a path relative to the repository root, or any
+// directory containing it, matches a restricted pattern.
+func (p *PathPolicy) Restricted(relPath string) bool {
+	if p == nil {
+		return false
+	}
+
+	relPath = strings.TrimPrefix(path.Clean(filepath.ToSlash(relPath)), "/")
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	if rules := p.ignored(); rules != nil {
+		info, err := os.Stat(filepath.Join(p.repoPath, filepath.FromSlash(relPath)))
+		if rules.Match(strings.Split(relPath, "/"), err == nil && info.IsDir()) {
+			return true
+		}
+	}
+
+	p.rulesMu.RLock()
+	defer p.rulesMu.RUnlock()
+	return matchesAny(p.patterns, relPath)
+}
+
+// Protect write-protects the paths matching patterns.
+func (p *PathPolicy) Protect(patterns []string) error {
+	protected, err := cleanPatterns(patterns, "protected")
+	if err != nil {
+		return err
+	}
+
+	p.rulesMu.Lock()
+	defer p.rulesMu.Unlock()
+	p.protected = protected
+	return nil
+}
+
+// Update replaces the restricted and protected patterns, e.g. when the
+// configuration is reloaded, and rereads the ignore file. Invalid patterns
+// leave the policy unchanged.
+func (p *PathPolicy) Update(restricted, protected []string) error {
+	cleanRestricted, err := cleanPatterns(restricted, "restricted")
+	if err != nil {
+		return err
+	}
+	cleanProtected, err := cleanPatterns(protected, "protected")
+	if err != nil {
+		return err
+	}
+
+	p.rulesMu.Lock()
+	p.patterns, p.protected = cleanRestricted, cleanProtected
+	p.rulesMu.Unlock()
+
+	p.mu.Lock()
+	p.checkedAt = time.Time{}
+	p.mu.Unlock()
+	return nil
+}
+
+// Protected reports whether a path relative to the repository root, or any
+// directory containing it, matches a write-protected pattern.
+func (p *PathPolicy) Protected(relPath string) bool {
+	if p == nil {
+		return false
+	}
+
+	relPath = strings.TrimPrefix(path.Clean(filepath.ToSlash(relPath)), "/")
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	p.rulesMu.RLock()
+	defer p.rulesMu.RUnlock()
+	return matchesAny(p.protected, relPath)
+}
+
+// cleanPatterns normalizes and validates path patterns; kind names them in errors.
+func cleanPatterns(patterns []string, kind string) ([]string, error) {
+	var cleaned []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "/"), "/")
+		if pattern == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid %s path pattern %q", kind, pattern)
+		}
+		cleaned = append(cleaned, pattern)
+	}
+	return cleaned, nil
+}
+
+// matchesAny reports whether a clean relative path, or any directory
+// containing it, matches one of patterns.
+func matchesAny(patterns []string, relPath string) bool {
+	for current := relPath; current != "." && current != "/"; current = path.Dir(current) {
+		for _, pattern := range patterns {
+			target := current
+			if !strings.Contains(pattern, "/") {
+				target = path.Base(current)
+			}
+			if ok, _ := doublestar.Match(pattern, target); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignored returns the rules of the repository's .stormstackignore, or nil
+// when it has none, reloading them when the file has changed.
+func (p *PathPolicy) ignored() gitignore.Matcher {
+	if p.ignoreSource == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.checkedAt) < ignoreRecheckInterval {
+		return p.ignoreRules
+	}
+	p.checkedAt = time.Now()
+
+	content, err := p.ignoreSource()
+	if err != nil || bytes.Equal(content, p.ignoreContent) {
+		// Keep enforcing the last rules that could be read
+		return p.ignoreRules
+	}
+	patterns, err := parseIgnorePatterns(bytes.NewReader(content), nil)
+	if err != nil {
+		return p.ignoreRules
+	}
+	p.ignoreContent = content
+	p.ignoreRules = nil
+	if len(patterns) > 0 {
+		p.ignoreRules = gitignore.NewMatcher(patterns)
+	}
+	return p.ignoreRules
+}
+
+// check returns an error if a path is restricted.
+func (p *PathPolicy) check(relPath string) error {
+	if p.Restricted(relPath) {
+		return fmt.Errorf("access to %s is %w", relPath, ErrRestricted)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package codebase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathPolicyIgnoresEditedIgnoreFile(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, "secrets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The committed file restricts secrets/; the checkout's copy was edited
+	// to lift that
+	committed := []byte("secrets/\n")
+	if err := os.WriteFile(filepath.Join(repoPath, IgnoreFileName), []byte("!secrets/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := NewPathPolicy(nil, repoPath, func() ([]byte, error) { return committed, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Restricted("secrets/key.pem") {
+		t.Error("secrets/key.pem is not restricted after editing the ignore file in the checkout")
+	}
+	if policy.Restricted("main.go") {
+		t.Error("main.go is restricted")
+	}
+
+	// Emptying the checkout's copy changes nothing either
+	if err := os.WriteFile(filepath.Join(repoPath, IgnoreFileName), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Update(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Restricted("secrets/key.pem") {
+		t.Error("secrets/key.pem is not restricted after emptying the ignore file in the checkout")
+	}
+}
@@ -0,0 +1,382 @@
+// Detection of a project's languages and build system.
+
+package codebase
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Project describes a repository's stack, as detected from the files at its
+// root, and the commands that build, test and lint it.
+type Project struct {
+	// Languages are the languages found, in order of detection, e.g. "Go"
+	Languages []string
+	// BuildSystems are the build tools found, e.g. "Maven", "npm"
+	BuildSystems []string
+	// Markers are the root files the detection was based on
+	Markers []string
+	// Details are notable facts, such as the Go module path
+	Details []string
+
+	// BuildCmd, TestCmd and LintCmd are the inferred commands, or "" when
+	// none could be inferred
+	BuildCmd string
+	TestCmd  string
+	LintCmd  string
+}
+
+// makeTargetRe matches a Makefile target definition.
+var makeTargetRe = regexp.MustCompile(`(?m)^([A-Za-z][\w.-]*)\s*:([^=]|$)`)
+
+// DetectProject inspects the marker files at the root of repoPath (go.mod,
+// pom.xml, package.json, Cargo.toml, ...) to describe the project. The
+// commands come from the first source that defines them: a build.sh script,
+// Makefile targets, Bazel, then the language toolchains in the order found.
+func DetectProject(repoPath string) Project {
+	d := &projectDetector{root: repoPath}
+
+	if d.exists("build.sh") {
+		d.marker("build.sh")
+		d.system("build.sh")
+		d.commands("./build.sh build", "./build.sh test", "")
+	}
+	if d.exists("Makefile") {
+		d.marker("Makefile")
+		d.system("Make")
+		targets := makeTargets(d.read("Makefile"))
+		var build, test, lint string
+		if targets["build"] {
+			build = "make build"
+		}
+		if targets["test"] {
+			test = "make test"
+		}
+		if targets["lint"] {
+			lint = "make lint"
+		}
+		d.commands(build, test, lint)
+	}
+	if d.exists("MODULE.bazel") || d.exists("WORKSPACE") || d.exists("WORKSPACE.bazel") {
+		d.marker(d.first("MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE"))
+		d.system("Bazel")
+		d.commands("bazel build //...", "bazel test //...", "")
+	}
+
+	if d.exists("go.mod") {
+		d.detectGo()
+	}
+	if d.exists("pom.xml") {
+		d.detectMaven()
+	}
+	if gradle := d.first("build.gradle.kts", "build.gradle"); gradle != "" {
+		d.detectGradle(gradle)
+	}
+	if d.exists("Cargo.toml") {
+		d.marker("Cargo.toml")
+		d.language("Rust")
+		d.system("Cargo")
+		d.commands("cargo build", "cargo test", "cargo clippy")
+	}
+	if d.exists("package.json") {
+		d.detectNode()
+	}
+	if python := d.first("pyproject.toml", "setup.py", "setup.cfg", "requirements.txt"); python != "" {
+		d.detectPython(python)
+	}
+	if dotnet := d.glob("*.sln", "*.csproj", "global.json", "Directory.Build.props"); dotnet != "" {
+		d.marker(dotnet)
+		d.language("C#")
+		d.system(".NET")
+		d.commands("dotnet build", "dotnet test", "dotnet format --verify-no-changes")
+	}
+	return d.project
+}
+
+// Format describes the project for Claude.
+func (p Project) Format() string {
+	if len(p.Markers) == 0 {
+		return "No known build files (go.mod, pom.xml, build.gradle, package.json, Cargo.toml, pyproject.toml, *.sln, Makefile, build.sh) were found at the repository root."
+	}
+
+	var sb strings.Builder
+	if len(p.Languages) > 0 {
+		sb.WriteString(fmt.Sprintf("Languages: %s\n", strings.Join(p.Languages, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("Build systems: %s\n", strings.Join(p.BuildSystems, ", ")))
+	sb.WriteString(fmt.Sprintf("Detected from: %s\n", strings.Join(p.Markers, ", ")))
+	for _, detail := range p.Details {
+		sb.WriteString(fmt.Sprintf("- %s\n", detail))
+	}
+	return sb.String()
+}
+
+// projectDetector accumulates a Project from a repository's root files.
+type projectDetector struct {
+	root    string
+	project Project
+}
+
+// exists reports whether a file exists at the repository root.
+func (d *projectDetector) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(d.root, name))
+	return err == nil
+}
+
+// first returns the first of the names that exists, or "".
+func (d *projectDetector) first(names ...string) string {
+	for _, name := range names {
+		if d.exists(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// glob returns the first root file matching one of the patterns, or "".
+func (d *projectDetector) glob(patterns ...string) string {
+	for _, pattern := range patterns {
+		if matches, _ := filepath.Glob(filepath.Join(d.root, pattern)); len(matches) > 0 {
+			return filepath.Base(matches[0])
+		}
+	}
+	return ""
+}
+
+// read returns the contents of a root file, or "" if it can't be read.
+func (d *projectDetector) read(name string) string {
+	data, err := os.ReadFile(filepath.Join(d.root, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// marker records a root file the detection was based on.
+func (d *projectDetector) marker(name string) {
+	d.project.Markers = appendNew(d.project.Markers, name)
+}
+
+// language records a language the project uses.
+func (d *projectDetector) language(name string) {
+	d.project.Languages = appendNew(d.project.Languages, name)
+}
+
+// system records a build tool the project uses.
+func (d *projectDetector) system(name string) {
+	d.project.BuildSystems = appendNew(d.project.BuildSystems, name)
+}
+
+// detail records a notable fact about the project.
+func (d *projectDetector) detail(format string, args ...any) {
+	d.project.Details = append(d.project.Details, fmt.Sprintf(format, args...))
+}
+
+// commands fills in whichever commands are still unknown.
+func (d *projectDetector) commands(build, test, lint string) {
+	if d.project.BuildCmd == "" {
+		d.project.BuildCmd = build
+	}
+	if d.project.TestCmd == "" {
+		d.project.TestCmd = test
+	}
+	if d.project.LintCmd == "" {
+		d.project.LintCmd = lint
+	}
+}
+
+// detectGo describes a Go module.
+func (d *projectDetector) detectGo() {
+	d.marker("go.mod")
+	d.language("Go")
+	d.system("Go modules")
+
+	scanner := bufio.NewScanner(strings.NewReader(d.read("go.mod")))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "module" {
+			d.detail("Go module %s", fields[1])
+		}
+		if len(fields) == 2 && fields[0] == "go" {
+			d.detail("Go version %s", fields[1])
+		}
+	}
+
+	lint := "go vet ./..."
+	if golangci := d.first(".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"); golangci != "" {
+		d.marker(golangci)
+		lint = "golangci-lint run"
+	}
+	d.commands("go build ./...", "go test ./...", lint)
+}
+
+// detectMaven describes a Maven project.
+func (d *projectDetector) detectMaven() {
+	d.marker("pom.xml")
+	d.language("Java")
+	d.system("Maven")
+
+	pom := d.read("pom.xml")
+	if strings.Contains(pom, "kotlin-maven-plugin") {
+		d.language("Kotlin")
+	}
+	if strings.Contains(pom, "<modules>") {
+		d.detail("Multi-module Maven build")
+	}
+	mvn := "mvn"
+	if d.exists("mvnw") {
+		mvn = "./mvnw"
+		d.detail("Maven wrapper (mvnw)")
+	}
+	lint := ""
+	if strings.Contains(pom, "maven-checkstyle-plugin") {
+		lint = mvn + " -B checkstyle:check"
+	}
+	d.commands(mvn+" -B compile", mvn+" -B test", lint)
+}
+
+// detectGradle describes a Gradle project with the given build file.
+func (d *projectDetector) detectGradle(buildFile string) {
+	d.marker(buildFile)
+	d.language("Java")
+	d.system("Gradle")
+
+	build := d.read(buildFile)
+	if strings.HasSuffix(buildFile, ".kts") || strings.Contains(build, "kotlin") {
+		d.language("Kotlin")
+	}
+	if strings.Contains(build, "com.android") {
+		d.detail("Android project")
+	}
+	if settings := d.first("settings.gradle.kts", "settings.gradle"); settings != "" && strings.Contains(d.read(settings), "include") {
+		d.detail("Multi-project Gradle build")
+	}
+	gradle := "gradle"
+	if d.exists("gradlew") {
+		gradle = "./gradlew"
+		d.detail("Gradle wrapper (gradlew)")
+	}
+	lint := ""
+	switch {
+	case strings.Contains(build, "spotless"):
+		lint = gradle + " spotlessCheck"
+	case strings.Contains(build, "checkstyle"):
+		lint = gradle + " checkstyleMain checkstyleTest"
+	case strings.Contains(build, "detekt"):
+		lint = gradle + " detekt"
+	}
+	d.commands(gradle+" assemble", gradle+" test", lint)
+}
+
+// detectNode describes a JavaScript or TypeScript package, using the package
+// manager its lockfile belongs to and its scripts.
+func (d *projectDetector) detectNode() {
+	d.marker("package.json")
+
+	var pkg struct {
+		Name            string            `json:"name"`
+		Scripts         map[string]string `json:"scripts"`
+		Workspaces      json.RawMessage   `json:"workspaces"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(d.read("package.json")), &pkg); err != nil {
+		d.detail("package.json could not be parsed: %v", err)
+	}
+
+	if d.exists("tsconfig.json") {
+		d.marker("tsconfig.json")
+	}
+	if d.exists("tsconfig.json") || pkg.DevDependencies["typescript"] != "" || pkg.Dependencies["typescript"] != "" {
+		d.language("TypeScript")
+	} else {
+		d.language("JavaScript")
+	}
+
+	manager := "npm"
+	switch {
+	case d.exists("pnpm-lock.yaml"):
+		manager = "pnpm"
+	case d.exists("yarn.lock"):
+		manager = "yarn"
+	case d.exists("bun.lockb"), d.exists("bun.lock"):
+		manager = "bun"
+	}
+	d.system(manager)
+	if pkg.Name != "" {
+		d.detail("Package %s", pkg.Name)
+	}
+	if len(pkg.Workspaces) > 0 || d.exists("pnpm-workspace.yaml") {
+		d.detail("Workspaces (monorepo)")
+	}
+	for _, framework := range []string{"react", "next", "vue", "svelte", "@angular/core", "express", "vitest", "jest"} {
+		if pkg.Dependencies[framework] != "" || pkg.DevDependencies[framework] != "" {
+			d.detail("Uses %s", framework)
+		}
+	}
+
+	script := func(name string) string {
+		if pkg.Scripts[name] == "" {
+			return ""
+		}
+		if name == "test" {
+			return manager + " test"
+		}
+		return manager + " run " + name
+	}
+	d.commands(script("build"), script("test"), script("lint"))
+}
+
+// detectPython describes a Python project with the given marker file.
+func (d *projectDetector) detectPython(markerFile string) {
+	d.marker(markerFile)
+	d.language("Python")
+
+	pyproject := d.read("pyproject.toml")
+	switch {
+	case strings.Contains(pyproject, "[tool.poetry]"):
+		d.system("Poetry")
+	case d.exists("uv.lock"):
+		d.system("uv")
+	default:
+		d.system("pip")
+	}
+
+	test := "python -m unittest"
+	if d.exists("pytest.ini") || d.exists("conftest.py") || strings.Contains(pyproject, "pytest") || strings.Contains(d.read("requirements.txt"), "pytest") {
+		test = "pytest"
+	}
+	lint := ""
+	switch {
+	case d.exists("ruff.toml") || strings.Contains(pyproject, "[tool.ruff"):
+		lint = "ruff check ."
+	case d.exists(".flake8") || strings.Contains(d.read("setup.cfg"), "[flake8]"):
+		lint = "flake8"
+	}
+	// Python has no build step, so building checks every module compiles
+	d.commands(`python -m compileall -q -x '/(\.|node_modules/)' .`, test, lint)
+}
+
+// makeTargets returns the targets a Makefile defines.
+func makeTargets(makefile string) map[string]bool {
+	targets := make(map[string]bool)
+	for _, match := range makeTargetRe.FindAllStringSubmatch(makefile, -1) {
+		targets[match[1]] = true
+	}
+	return targets
+}
+
+// appendNew appends s to list unless it is already there.
+func appendNew(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -3,20 +3,69 @@ package codebase
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 )
 
+// Limits that keep large and binary files out of Claude's context.
+const (
+	// MaxReadSize is the largest file read whole; larger text files can be
+	// read by line range
+	MaxReadSize = 1 << 20
+	// maxLineRangeOutput bounds the output of a line range read
+	maxLineRangeOutput = 256 * 1024
+	// sniffSize is how much of a file is inspected to tell text from binary
+	sniffSize = 8000
+	// previewSize is how many leading bytes of an unreadable file are shown
+	previewSize = 64
+)
+
+// UnreadableFileError reports a file that is too large to read whole or is
+// not text. Its message summarizes the file instead.
+type UnreadableFileError struct {
+	Path   string
+	Size   int64
+	Type   string
+	Binary bool
+	// Head holds the first bytes of the file
+	Head []byte
+}
+
+// Error summarizes the file: its size, detected type and first bytes.
+func (e *UnreadableFileError) Error() string {
+	var sb strings.Builder
+	if e.Binary {
+		sb.WriteString(fmt.Sprintf("%s is a binary file (%s, %s), so its contents were not read.\n", e.Path, e.Type, formatSize(e.Size)))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s is %s (%s), over the %s limit for reading a whole file. Read it in parts with start_line and end_line, or search it with search_code.\n",
+			e.Path, formatSize(e.Size), e.Type, formatSize(MaxReadSize)))
+	}
+	switch {
+	case len(e.Head) == 0:
+	case e.Binary:
+		sb.WriteString(fmt.Sprintf("First %d bytes: % x\n", len(e.Head), e.Head))
+		sb.WriteString(fmt.Sprintf("As text: %q", printable(e.Head)))
+	default:
+		sb.WriteString(fmt.Sprintf("Starts with: %q", e.Head))
+	}
+	return sb.String()
+}
+
 // Reader provides file reading operations within a repository.
 type Reader struct {
 	repoPath string
+	policy   *PathPolicy
 }
 
-// NewReader creates a new file reader.
-func NewReader(repoPath string) *Reader {
-	return &Reader{repoPath: repoPath}
+// NewReader creates a new file reader. Paths restricted by policy cannot be read.
+func NewReader(repoPath string, policy *PathPolicy) *Reader {
+	return &Reader{repoPath: repoPath, policy: policy}
 }
 
 // ReadFile reads a file and returns its content.
@@ -26,6 +75,10 @@ func (r *Reader) ReadFile(path string) (string, error) {
 		return "", err
 	}
 
+	if err := checkReadable(path, fullPath, true); err != nil {
+		return "", err
+	}
+
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
@@ -41,6 +94,10 @@ func (r *Reader) ReadFileLines(path string, startLine, endLine int) (string, err
 		return "", err
 	}
 
+	if err := checkReadable(path, fullPath, false); err != nil {
+		return "", err
+	}
+
 	file, err := os.Open(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
@@ -49,7 +106,9 @@ func (r *Reader) ReadFileLines(path string, startLine, endLine int) (string, err
 
 	var lines []string
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), MaxReadSize)
 	lineNum := 0
+	size := 0
 
 	for scanner.Scan() {
 		lineNum++
@@ -59,7 +118,12 @@ func (r *Reader) ReadFileLines(path string, startLine, endLine int) (string, err
 		if endLine > 0 && lineNum > endLine {
 			break
 		}
-		lines = append(lines, fmt.Sprintf("%4d | %s", lineNum, scanner.Text()))
+		line := fmt.Sprintf("%4d | %s", lineNum, scanner.Text())
+		if size += len(line) + 1; size > maxLineRangeOutput {
+			lines = append(lines, fmt.Sprintf("... (output truncated at line %d; read from start_line %d to continue)", lineNum-1, lineNum))
+			break
+		}
+		lines = append(lines, line)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -69,6 +133,86 @@ func (r *Reader) ReadFileLines(path string, startLine, endLine int) (string, err
 	return strings.Join(lines, "\n"), nil
 }
 
+// FilePage is one page of a file read with ReadFilePage.
+type FilePage struct {
+	Path string
+	// Content holds the page's lines, numbered like ReadFileLines output
+	Content    string
+	StartLine  int
+	EndLine    int
+	TotalLines int
+	// NextCursor is the line the next page starts at, or 0 on the last page
+	NextCursor int
+}
+
+// ReadFilePage reads up to pageLines lines of a file starting at line cursor
+// (1-indexed; 0 means the start), and counts the file's lines so the page can
+// say where it is. A page also ends early rather than exceed
+// maxLineRangeOutput bytes.
+func (r *Reader) ReadFilePage(path string, cursor, pageLines int) (*FilePage, error) {
+	fullPath, err := r.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReadable(path, fullPath, false); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	cursor = max(cursor, 1)
+	page := &FilePage{Path: path, StartLine: cursor}
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), MaxReadSize)
+	size := 0
+	full := false
+
+	for scanner.Scan() {
+		page.TotalLines++
+		if full || page.TotalLines < cursor {
+			continue
+		}
+		line := fmt.Sprintf("%4d | %s", page.TotalLines, scanner.Text())
+		if size += len(line) + 1; len(lines) == pageLines || (size > maxLineRangeOutput && len(lines) > 0) {
+			page.NextCursor = page.TotalLines
+			full = true
+			continue
+		}
+		lines = append(lines, line)
+		page.EndLine = page.TotalLines
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	if cursor > page.TotalLines && cursor > 1 {
+		return nil, fmt.Errorf("cursor %d is past the end of %s (%d lines)", cursor, path, page.TotalLines)
+	}
+
+	page.Content = strings.Join(lines, "\n")
+	return page, nil
+}
+
+// String formats the page with its position in the file and, unless it is
+// the last page, the cursor of the next one.
+func (p *FilePage) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: lines %d-%d of %d\n\n", p.Path, p.StartLine, p.EndLine, p.TotalLines))
+	sb.WriteString(p.Content)
+	if p.NextCursor > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n(%d more lines. Read the next page with cursor %d.)", p.TotalLines-p.EndLine, p.NextCursor))
+	} else {
+		sb.WriteString("\n\n(End of file.)")
+	}
+	return sb.String()
+}
+
 // FileExists checks if a file exists.
 func (r *Reader) FileExists(path string) bool {
 	fullPath, err := r.resolvePath(path)
@@ -142,9 +286,90 @@ func (r *Reader) resolvePath(path string) (string, error) {
 		return "", fmt.Errorf("path escapes repository: %s", path)
 	}
 
+	if err := r.policy.check(path); err != nil {
+		return "", err
+	}
+
 	return absPath, nil
 }
 
+// checkReadable returns an UnreadableFileError for binary files and, when
+// whole is set, for files over MaxReadSize.
+func checkReadable(path, fullPath string, whole bool) error {
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("%s is a directory; use list_files or get_tree", path)
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	binary := isBinary(head)
+	if !binary && (!whole || stat.Size() <= MaxReadSize) {
+		return nil
+	}
+	return &UnreadableFileError{
+		Path:   path,
+		Size:   stat.Size(),
+		Type:   http.DetectContentType(head),
+		Binary: binary,
+		Head:   head[:min(len(head), previewSize)],
+	}
+}
+
+// isBinary reports whether the start of a file looks binary: like git, it
+// treats NUL bytes as binary, and also invalid UTF-8.
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	// The sniffed prefix may end inside a multi-byte character
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return !utf8.Valid(head)
+}
+
+// printable replaces the non-printable bytes of b with dots.
+func printable(b []byte) string {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		if c >= 0x20 && c < 0x7f {
+			out[i] = c
+		} else {
+			out[i] = '.'
+		}
+	}
+	return string(out)
+}
+
+// formatSize formats a byte count for display, e.g. "2.5 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
 // countLines counts the number of lines in a file.
 func (r *Reader) countLines(path string) (int, error) {
 	file, err := os.Open(path)
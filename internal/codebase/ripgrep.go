@@ -0,0 +1,156 @@
+// A ripgrep-backed code search.
+
+package codebase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ripgrepTimeout bounds a single ripgrep search.
+const ripgrepTimeout = 30 * time.Second
+
+// ripgrepPath returns the path of the rg binary, or "" when ripgrep is not installed.
+var ripgrepPath = sync.OnceValue(func() string {
+	path, err := exec.LookPath("rg")
+	if err != nil {
+		return ""
+	}
+	return path
+})
+
+// rgEvent is one line of ripgrep's --json output.
+type rgEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Path struct {
+			Text string `json:"text"`
+		} `json:"path"`
+		Lines struct {
+			Text string `json:"text"`
+		} `json:"lines"`
+		LineNumber int `json:"line_number"`
+	} `json:"data"`
+}
+
+// searchWithRipgrep searches root with ripgrep, which respects .gitignore and
+// skips hidden and binary files, like the Go search. Only files matching glob are searched when
+// it is set. The search stops once maxResults matches are found.
+func (s *Searcher) searchWithRipgrep(rg, pattern, root, glob string, caseSensitive bool, contextLines, maxResults int) ([]SearchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ripgrepTimeout)
+	defer cancel()
+
+	args := []string{"--json", "--no-config", "--max-filesize", "1M", "--max-columns", "500"}
+	if !caseSensitive {
+		args = append(args, "--ignore-case")
+	}
+	if contextLines > 0 {
+		args = append(args, "--context", strconv.Itoa(contextLines))
+	}
+	if newGitIgnores(s.repoPath).fallback {
+		for _, dir := range defaultSkipDirs {
+			args = append(args, "--glob", "!"+dir+"/")
+		}
+	}
+	if glob != "" {
+		args = append(args, "--glob", "/"+glob)
+	}
+	// Search relative to the repository so reported paths are too
+	relRoot, err := filepath.Rel(s.repoPath, root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search root: %w", err)
+	}
+	args = append(args, "--regexp", pattern, "--", relRoot)
+
+	cmd := exec.CommandContext(ctx, rg, args...)
+	cmd.Dir = s.repoPath
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ripgrep: %w", err)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run ripgrep: %w", err)
+	}
+
+	var results []SearchResult
+	// lines holds the match and context lines of the current file by number
+	lines := make(map[int]string)
+	fileStart := 0
+	finishFile := func() {
+		if contextLines > 0 {
+			for i := fileStart; i < len(results); i++ {
+				addContext(&results[i], contextLines, func(n int) (string, bool) {
+					text, ok := lines[n]
+					return text, ok
+				})
+			}
+		}
+		lines = make(map[int]string)
+		fileStart = len(results)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	done := false
+	for !done && scanner.Scan() {
+		var event rgEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "begin":
+			finishFile()
+		case "context":
+			lines[event.Data.LineNumber] = strings.TrimRight(event.Data.Lines.Text, "\n")
+		case "match":
+			text := strings.TrimRight(event.Data.Lines.Text, "\n")
+			lines[event.Data.LineNumber] = text
+
+			relPath := filepath.Clean(event.Data.Path.Text)
+			if event.Data.Path.Text == "" || s.policy.Restricted(relPath) {
+				continue
+			}
+			if len(results) >= maxResults {
+				continue
+			}
+			results = append(results, SearchResult{
+				File:    relPath,
+				Line:    event.Data.LineNumber,
+				Content: strings.TrimSpace(text),
+			})
+		case "end":
+			// Trailing context for the file's last match has arrived
+			finishFile()
+			done = len(results) >= maxResults
+		}
+	}
+	finishFile()
+
+	if done {
+		// Enough matches; stop ripgrep rather than waiting for it to finish
+		cancel()
+		cmd.Wait()
+		return results, nil
+	}
+
+	// ripgrep exits with 1 when nothing matched and 2 on errors, which
+	// includes unreadable files next to real matches
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && (exitErr.ExitCode() == 1 || len(results) > 0) {
+			return results, nil
+		}
+		return nil, fmt.Errorf("ripgrep failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return results, nil
+}
@@ -17,11 +17,13 @@ import (
 // Searcher provides code search operations.
 type Searcher struct {
 	repoPath string
+	policy   *PathPolicy
 }
 
-// NewSearcher creates a new code searcher.
-func NewSearcher(repoPath string) *Searcher {
-	return &Searcher{repoPath: repoPath}
+// NewSearcher creates a new code searcher. Paths restricted by policy are
+// never searched, listed or shown in the tree.
+func NewSearcher(repoPath string, policy *PathPolicy) *Searcher {
+	return &Searcher{repoPath: repoPath, policy: policy}
 }
 
 // SearchResult represents a single search match.
@@ -29,10 +31,16 @@ type SearchResult struct {
 	File    string
 	Line    int
 	Content string
+	// Before and After are the context lines around the match, if requested
+	Before []string
+	After  []string
 }
 
-// SearchCode searches for a pattern in the codebase.
-func (s *Searcher) SearchCode(pattern, path string, caseSensitive bool, maxResults int) ([]SearchResult, error) {
+// SearchCode searches for a pattern in the codebase, with contextLines lines
+// of context around each match. path may be a directory or a glob pattern.
+// ripgrep is used when it is installed, which also skips files ignored by
+// .gitignore; otherwise the files are walked in Go.
+func (s *Searcher) SearchCode(pattern, path string, caseSensitive bool, contextLines, maxResults int) ([]SearchResult, error) {
 	if maxResults <= 0 {
 		maxResults = 50
 	}
@@ -47,72 +55,116 @@ func (s *Searcher) SearchCode(pattern, path string, caseSensitive bool, maxResul
 		return nil, fmt.Errorf("invalid pattern: %w", err)
 	}
 
-	// Determine search root
+	// Determine search root, or the glob files must match
 	searchRoot := s.repoPath
+	glob := ""
 	if path != "" {
-		searchRoot = filepath.Join(s.repoPath, path)
+		if err := s.policy.check(path); err != nil {
+			return nil, err
+		}
+		if strings.ContainsAny(path, "*?[{") {
+			glob = filepath.ToSlash(strings.TrimPrefix(path, "/"))
+		} else {
+			searchRoot = filepath.Join(s.repoPath, path)
+		}
 	}
 
-	var results []SearchResult
-
-	err = filepath.WalkDir(searchRoot, func(filePath string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip errors
+	if rg := ripgrepPath(); rg != "" {
+		results, err := s.searchWithRipgrep(rg, pattern, searchRoot, glob, caseSensitive, contextLines, maxResults)
+		if err == nil {
+			return results, nil
 		}
+		// Fall back to the Go search, e.g. for patterns ripgrep's regex engine rejects
+	}
 
-		// Skip directories
-		if d.IsDir() {
-			// Skip hidden directories and common non-code directories
-			name := d.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "target" || name == "build" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	var results []SearchResult
 
+	err = s.walk(searchRoot, func(filePath, relPath string) error {
 		// Skip binary files and large files
 		if !isTextFile(filePath) {
 			return nil
 		}
+		if glob != "" {
+			if ok, _ := doublestar.Match(glob, filepath.ToSlash(relPath)); !ok {
+				return nil
+			}
+		}
 
 		// Search in file
-		matches, err := s.searchInFile(filePath, re)
+		matches, err := s.searchInFile(filePath, re, contextLines)
 		if err != nil {
 			return nil // Skip errors
 		}
 
-		// Get relative path
-		relPath, _ := filepath.Rel(s.repoPath, filePath)
-
 		for _, match := range matches {
 			if len(results) >= maxResults {
 				return filepath.SkipAll
 			}
-			results = append(results, SearchResult{
-				File:    relPath,
-				Line:    match.Line,
-				Content: match.Content,
-			})
+			match.File = relPath
+			results = append(results, match)
 		}
 
 		return nil
 	})
 
-	if err != nil && err != filepath.SkipAll {
+	if err != nil {
 		return nil, fmt.Errorf("search error: %w", err)
 	}
 
 	return results, nil
 }
 
-// searchInFile searches for matches in a single file.
-func (s *Searcher) searchInFile(path string, re *regexp.Regexp) ([]SearchResult, error) {
+// walk calls fn for every file under root with its absolute and
+// repository-relative path, skipping restricted paths, hidden directories and
+// paths the repository's .gitignore files ignore. fn may return
+// filepath.SkipAll to stop early.
+func (s *Searcher) walk(root string, fn func(filePath, relPath string) error) error {
+	ignores := newGitIgnores(s.repoPath)
+	err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+
+		// Skip restricted paths entirely
+		if s.restricted(filePath) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(s.repoPath, filePath)
+
+		// Skip directories
+		if d.IsDir() {
+			// Skip hidden and ignored directories
+			if filePath != root && (strings.HasPrefix(d.Name(), ".") || ignores.ignored(relPath, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if ignores.ignored(relPath, false) {
+			return nil
+		}
+		return fn(filePath, relPath)
+	})
+	if err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// searchInFile searches for matches in a single file, with contextLines
+// lines of context around each match.
+func (s *Searcher) searchInFile(path string, re *regexp.Regexp, contextLines int) ([]SearchResult, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
+	var lines []string
 	var results []SearchResult
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
@@ -120,6 +172,9 @@ func (s *Searcher) searchInFile(path string, re *regexp.Regexp) ([]SearchResult,
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		if contextLines > 0 {
+			lines = append(lines, line)
+		}
 		if re.MatchString(line) {
 			results = append(results, SearchResult{
 				Line:    lineNum,
@@ -128,9 +183,37 @@ func (s *Searcher) searchInFile(path string, re *regexp.Regexp) ([]SearchResult,
 		}
 	}
 
+	if contextLines > 0 {
+		for i := range results {
+			addContext(&results[i], contextLines, func(n int) (string, bool) {
+				if n < 1 || n > len(lines) {
+					return "", false
+				}
+				return lines[n-1], true
+			})
+		}
+	}
+
 	return results, scanner.Err()
 }
 
+// addContext fills in up to n lines of context on each side of a match,
+// looking lines up by number.
+func addContext(r *SearchResult, n int, line func(int) (string, bool)) {
+	for i := r.Line - n; i < r.Line; i++ {
+		if text, ok := line(i); ok {
+			r.Before = append(r.Before, strings.TrimRight(text, " \t\r"))
+		}
+	}
+	for i := r.Line + 1; i <= r.Line+n; i++ {
+		text, ok := line(i)
+		if !ok {
+			break
+		}
+		r.After = append(r.After, strings.TrimRight(text, " \t\r"))
+	}
+}
+
 // ListFiles lists files matching a glob pattern.
 func (s *Searcher) ListFiles(pattern string) ([]string, error) {
 	// Ensure pattern is relative
@@ -144,6 +227,7 @@ func (s *Searcher) ListFiles(pattern string) ([]string, error) {
 	}
 
 	// Convert to relative paths and filter out directories
+	ignores := newGitIgnores(s.repoPath)
 	var files []string
 	for _, match := range matches {
 		info, err := os.Stat(match)
@@ -155,7 +239,7 @@ func (s *Searcher) ListFiles(pattern string) ([]string, error) {
 		}
 
 		relPath, err := filepath.Rel(s.repoPath, match)
-		if err != nil {
+		if err != nil || s.policy.Restricted(relPath) || ignores.ignored(relPath, false) {
 			continue
 		}
 
@@ -176,11 +260,14 @@ func (s *Searcher) GetTree(path string, maxDepth int) (string, error) {
 
 	root := s.repoPath
 	if path != "" {
+		if err := s.policy.check(path); err != nil {
+			return "", err
+		}
 		root = filepath.Join(s.repoPath, path)
 	}
 
 	var builder strings.Builder
-	err := s.buildTree(&builder, root, "", 0, maxDepth)
+	err := s.buildTree(&builder, newGitIgnores(s.repoPath), root, "", 0, maxDepth)
 	if err != nil {
 		return "", err
 	}
@@ -189,7 +276,7 @@ func (s *Searcher) GetTree(path string, maxDepth int) (string, error) {
 }
 
 // buildTree recursively builds a tree representation.
-func (s *Searcher) buildTree(builder *strings.Builder, path, prefix string, depth, maxDepth int) error {
+func (s *Searcher) buildTree(builder *strings.Builder, ignores *gitIgnores, path, prefix string, depth, maxDepth int) error {
 	if depth > maxDepth {
 		return nil
 	}
@@ -203,11 +290,15 @@ func (s *Searcher) buildTree(builder *strings.Builder, path, prefix string, dept
 	var filteredEntries []os.DirEntry
 	for _, entry := range entries {
 		name := entry.Name()
-		// Skip hidden files and common non-essential directories
+		// Skip hidden, ignored and restricted files
 		if strings.HasPrefix(name, ".") {
 			continue
 		}
-		if entry.IsDir() && (name == "node_modules" || name == "vendor" || name == "target" || name == "build" || name == "__pycache__") {
+		fullPath := filepath.Join(path, name)
+		if s.restricted(fullPath) {
+			continue
+		}
+		if relPath, err := filepath.Rel(s.repoPath, fullPath); err == nil && ignores.ignored(relPath, entry.IsDir()) {
 			continue
 		}
 		filteredEntries = append(filteredEntries, entry)
@@ -233,13 +324,22 @@ func (s *Searcher) buildTree(builder *strings.Builder, path, prefix string, dept
 			} else {
 				newPrefix += "│   "
 			}
-			s.buildTree(builder, filepath.Join(path, entry.Name()), newPrefix, depth+1, maxDepth)
+			s.buildTree(builder, ignores, filepath.Join(path, entry.Name()), newPrefix, depth+1, maxDepth)
 		}
 	}
 
 	return nil
 }
 
+// restricted reports whether an absolute path inside the repository is restricted.
+func (s *Searcher) restricted(fullPath string) bool {
+	relPath, err := filepath.Rel(s.repoPath, fullPath)
+	if err != nil {
+		return false
+	}
+	return s.policy.Restricted(relPath)
+}
+
 // FindTests finds test files for a given source file.
 func (s *Searcher) FindTests(sourceFile string) ([]string, error) {
 	ext := filepath.Ext(sourceFile)
@@ -306,6 +406,58 @@ func (s *Searcher) FindTests(sourceFile string) ([]string, error) {
 	return testFiles, nil
 }
 
+// IsTestFile reports whether a path names a test file by the conventions
+// FindTests knows.
+func IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	switch filepath.Ext(base) {
+	case ".go":
+		return strings.HasSuffix(name, "_test")
+	case ".java", ".kt":
+		return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "IT")
+	case ".js", ".ts", ".jsx", ".tsx":
+		return strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec") ||
+			strings.Contains(filepath.ToSlash(path), "__tests__/")
+	case ".py":
+		return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test")
+	}
+	return false
+}
+
+// AffectedTests returns the test files to run for a set of changed files:
+// changed tests that still exist, and the tests FindTests finds for changed
+// source files.
+func (s *Searcher) AffectedTests(changed []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var tests []string
+	add := func(test string) {
+		if !seen[test] {
+			seen[test] = true
+			tests = append(tests, test)
+		}
+	}
+
+	for _, file := range changed {
+		if IsTestFile(file) {
+			if _, err := os.Stat(filepath.Join(s.repoPath, file)); err == nil {
+				add(file)
+			}
+			continue
+		}
+		found, err := s.FindTests(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, test := range found {
+			add(test)
+		}
+	}
+
+	sort.Strings(tests)
+	return tests, nil
+}
+
 // isTextFile checks if a file is likely a text file.
 func isTextFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -344,8 +496,23 @@ func isTextFile(path string) bool {
 func FormatSearchResults(results []SearchResult) string {
 	var builder strings.Builder
 
-	for _, r := range results {
+	for i, r := range results {
+		if len(r.Before) == 0 && len(r.After) == 0 {
+			builder.WriteString(fmt.Sprintf("%s:%d: %s\n", r.File, r.Line, r.Content))
+			continue
+		}
+
+		// Context lines are marked with "-", grep style, and groups separated by "--"
+		if i > 0 {
+			builder.WriteString("--\n")
+		}
+		for j, line := range r.Before {
+			builder.WriteString(fmt.Sprintf("%s-%d- %s\n", r.File, r.Line-len(r.Before)+j, line))
+		}
 		builder.WriteString(fmt.Sprintf("%s:%d: %s\n", r.File, r.Line, r.Content))
+		for j, line := range r.After {
+			builder.WriteString(fmt.Sprintf("%s-%d- %s\n", r.File, r.Line+1+j, line))
+		}
 	}
 
 	return builder.String()
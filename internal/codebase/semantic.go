@@ -0,0 +1,355 @@
+// An embeddings index for semantic code search.
+
+package codebase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Chunking and batching limits for the semantic index.
+const (
+	semanticChunkLines   = 40
+	semanticChunkOverlap = 10
+	semanticMaxFileSize  = 256 * 1024
+	semanticMaxChunkSize = 6000
+	semanticBatchSize    = 64
+	semanticSnippetLines = 12
+)
+
+// Embedder turns text into embedding vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// SemanticResult is a chunk of code matching a semantic search.
+type SemanticResult struct {
+	File      string
+	StartLine int
+	EndLine   int
+	// Score is the cosine similarity to the query, from -1 to 1
+	Score   float64
+	Snippet string
+}
+
+// semanticChunk is an embedded range of lines.
+type semanticChunk struct {
+	Start, End int
+	Vector     []float32
+}
+
+// semanticFile is the indexed state of one file.
+type semanticFile struct {
+	Hash   string
+	Chunks []semanticChunk
+}
+
+// semanticData is the persisted index.
+type semanticData struct {
+	Model string
+	Files map[string]*semanticFile
+}
+
+// SemanticIndex is an embeddings index of the repository, persisted to a
+// local file and updated incrementally: only files whose content changed are
+// embedded again.
+type SemanticIndex struct {
+	searcher *Searcher
+	embedder Embedder
+	model    string
+	file     string
+	logger   *slog.Logger
+
+	// updateMu serializes updates; mu guards files
+	updateMu sync.Mutex
+	mu       sync.RWMutex
+	files    map[string]*semanticFile
+}
+
+// NewSemanticIndex creates an index of the files searcher can see, stored in
+// file. An existing index built with the same model is loaded.
+func NewSemanticIndex(searcher *Searcher, embedder Embedder, model, file string, logger *slog.Logger) (*SemanticIndex, error) {
+	x := &SemanticIndex{
+		searcher: searcher,
+		embedder: embedder,
+		model:    model,
+		file:     file,
+		logger:   logger,
+		files:    make(map[string]*semanticFile),
+	}
+
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open semantic index: %w", err)
+	}
+	defer f.Close()
+
+	var data semanticData
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		logger.Warn("discarding unreadable semantic index", "file", file, "error", err)
+		return x, nil
+	}
+	if data.Model != model {
+		logger.Info("embedding model changed, rebuilding semantic index", "from", data.Model, "to", model)
+		return x, nil
+	}
+	x.files = data.Files
+	return x, nil
+}
+
+// Update brings the index up to date with the working tree and returns the
+// number of files embedded again.
+func (x *SemanticIndex) Update(ctx context.Context) (int, error) {
+	x.updateMu.Lock()
+	defer x.updateMu.Unlock()
+
+	x.mu.RLock()
+	current := x.files
+	x.mu.RUnlock()
+
+	updated := make(map[string]*semanticFile, len(current))
+	var changed []string
+	unchanged := 0
+	contents := make(map[string][]string)
+	err := x.searcher.walk(x.searcher.repoPath, func(filePath, relPath string) error {
+		if !semanticFileIndexable(filePath) {
+			return nil
+		}
+		src, err := os.ReadFile(filePath)
+		if err != nil || len(src) > semanticMaxFileSize || strings.ContainsRune(string(src), 0) {
+			return nil
+		}
+
+		sum := sha256.Sum256(src)
+		hash := hex.EncodeToString(sum[:])
+		if old, ok := current[relPath]; ok && old.Hash == hash {
+			updated[relPath] = old
+			unchanged++
+			return nil
+		}
+		updated[relPath] = &semanticFile{Hash: hash}
+		changed = append(changed, relPath)
+		contents[relPath] = strings.Split(string(src), "\n")
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan repository: %w", err)
+	}
+
+	// Embed the chunks of changed files in batches
+	type pending struct {
+		file  string
+		chunk semanticChunk
+		text  string
+	}
+	var queue []pending
+	for _, relPath := range changed {
+		for _, c := range chunkLines(contents[relPath]) {
+			queue = append(queue, pending{file: relPath, chunk: semanticChunk{Start: c.start, End: c.end}, text: "File: " + relPath + "\n" + c.text})
+		}
+	}
+	for start := 0; start < len(queue); start += semanticBatchSize {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		batch := queue[start:min(start+semanticBatchSize, len(queue))]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+		vectors, err := x.embedder.Embed(ctx, texts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed code: %w", err)
+		}
+		for i, p := range batch {
+			p.chunk.Vector = normalize(vectors[i])
+			updated[p.file].Chunks = append(updated[p.file].Chunks, p.chunk)
+		}
+	}
+
+	if len(changed) == 0 && unchanged == len(current) {
+		return 0, nil
+	}
+
+	x.mu.Lock()
+	x.files = updated
+	x.mu.Unlock()
+
+	if err := x.save(updated); err != nil {
+		return len(changed), err
+	}
+	x.logger.Info("updated semantic index", "embedded", len(changed), "files", len(updated))
+	return len(changed), nil
+}
+
+// save writes the index to its file atomically.
+func (x *SemanticIndex) save(files map[string]*semanticFile) error {
+	if err := os.MkdirAll(filepath.Dir(x.file), 0o755); err != nil {
+		return fmt.Errorf("failed to create semantic index directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(x.file), ".semantic-*")
+	if err != nil {
+		return fmt.Errorf("failed to save semantic index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(semanticData{Model: x.model, Files: files}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save semantic index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save semantic index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), x.file); err != nil {
+		return fmt.Errorf("failed to save semantic index: %w", err)
+	}
+	return nil
+}
+
+// Search returns the maxResults chunks under path closest in meaning to query.
+func (x *SemanticIndex) Search(ctx context.Context, query, path string, maxResults int) ([]SemanticResult, error) {
+	if maxResults <= 0 {
+		maxResults = 10
+	}
+	if path != "" {
+		if err := x.searcher.policy.check(path); err != nil {
+			return nil, err
+		}
+	}
+
+	x.mu.RLock()
+	files := x.files
+	x.mu.RUnlock()
+	if len(files) == 0 {
+		return nil, errors.New("the semantic index is still being built; use search_code for now")
+	}
+
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	q := normalize(vectors[0])
+
+	prefix := filepath.ToSlash(filepath.Clean(path))
+	var results []SemanticResult
+	for relPath, f := range files {
+		slashed := filepath.ToSlash(relPath)
+		if path != "" && prefix != "." && slashed != prefix && !strings.HasPrefix(slashed, prefix+"/") {
+			continue
+		}
+		for _, c := range f.Chunks {
+			results = append(results, SemanticResult{File: relPath, StartLine: c.Start, EndLine: c.End, Score: dot(q, c.Vector)})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	for i := range results {
+		results[i].Snippet = x.snippet(results[i])
+	}
+	return results, nil
+}
+
+// snippet returns the first lines of a result's chunk from the working tree.
+func (x *SemanticIndex) snippet(r SemanticResult) string {
+	src, err := os.ReadFile(filepath.Join(x.searcher.repoPath, r.File))
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(src), "\n")
+	end := min(r.EndLine, r.StartLine+semanticSnippetLines-1, len(lines))
+	if r.StartLine < 1 || r.StartLine > end {
+		return ""
+	}
+	return strings.Join(lines[r.StartLine-1:end], "\n")
+}
+
+// FormatSemanticResults formats semantic search results for display.
+func FormatSemanticResults(results []SemanticResult) string {
+	var sb strings.Builder
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("%s:%d-%d (score %.2f)\n", r.File, r.StartLine, r.EndLine, r.Score))
+		if r.Snippet != "" {
+			sb.WriteString(r.Snippet + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// semanticFileIndexable reports whether a file belongs in the semantic index.
+func semanticFileIndexable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sum", ".lock", ".env":
+		return false
+	}
+	return isTextFile(path)
+}
+
+// lineChunk is a range of lines to embed.
+type lineChunk struct {
+	start, end int
+	text       string
+}
+
+// chunkLines splits a file into overlapping windows of lines.
+func chunkLines(lines []string) []lineChunk {
+	var chunks []lineChunk
+	step := semanticChunkLines - semanticChunkOverlap
+	for start := 0; start < len(lines); start += step {
+		end := min(start+semanticChunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			if len(text) > semanticMaxChunkSize {
+				text = text[:semanticMaxChunkSize]
+			}
+			chunks = append(chunks, lineChunk{start: start + 1, end: end, text: text})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+// normalize scales v to unit length, so cosine similarity is a dot product.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = f / norm
+	}
+	return out
+}
+
+// dot returns the dot product of two vectors.
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
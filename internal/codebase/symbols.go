@@ -0,0 +1,517 @@
+// Symbol-aware code navigation for Go and Java.
+
+package codebase
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Symbol kinds reported by FindDefinition.
+const (
+	SymbolFunc      = "func"
+	SymbolMethod    = "method"
+	SymbolType      = "type"
+	SymbolField     = "field"
+	SymbolConst     = "const"
+	SymbolVar       = "var"
+	SymbolClass     = "class"
+	SymbolInterface = "interface"
+	SymbolEnum      = "enum"
+	SymbolRecord    = "record"
+)
+
+// Symbol is a declaration found in the codebase.
+type Symbol struct {
+	// Name is the declared identifier
+	Name string
+	// Container is the enclosing type for methods and fields, if any
+	Container string
+	Kind      string
+	File      string
+	Line      int
+	// Content is the source line of the declaration
+	Content string
+}
+
+// QualifiedName returns Container.Name, or Name for top-level symbols.
+func (s Symbol) QualifiedName() string {
+	if s.Container == "" {
+		return s.Name
+	}
+	return s.Container + "." + s.Name
+}
+
+// Reference is a use of a symbol.
+type Reference struct {
+	SearchResult
+	// Declaration is set when the reference is the symbol's own declaration
+	Declaration bool
+}
+
+// navigableFile reports whether symbol navigation understands a file.
+func navigableFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".go", ".java":
+		return true
+	}
+	return false
+}
+
+// FindDefinition finds the declarations of a symbol in Go and Java files
+// under path. The symbol may be qualified with its type, as in
+// "Server.Start", to pick one method among several with the same name.
+func (s *Searcher) FindDefinition(symbol, path string) ([]Symbol, error) {
+	container, name := splitSymbol(symbol)
+	if name == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", symbol)
+	}
+
+	root, err := s.navigationRoot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Symbol
+	err = s.walk(root, func(filePath, relPath string) error {
+		if !navigableFile(filePath) {
+			return nil
+		}
+		symbols, err := fileSymbols(filePath)
+		if err != nil {
+			return nil // Skip files that don't parse
+		}
+		for _, sym := range symbols {
+			if sym.Name == name && (container == "" || sym.Container == container) {
+				sym.File = relPath
+				found = append(found, sym)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find definition: %w", err)
+	}
+	return found, nil
+}
+
+// FindReferences finds the uses of a symbol in Go and Java files under path.
+// Matches are on the identifier, ignoring comments and string literals; a
+// qualified symbol such as "Server.Start" matches uses of Start.
+func (s *Searcher) FindReferences(symbol, path string, maxResults int) ([]Reference, error) {
+	if maxResults <= 0 {
+		maxResults = 100
+	}
+	_, name := splitSymbol(symbol)
+	if name == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", symbol)
+	}
+
+	root, err := s.navigationRoot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []Reference
+	err = s.walk(root, func(filePath, relPath string) error {
+		if !navigableFile(filePath) {
+			return nil
+		}
+		fileRefs, err := fileReferences(filePath, name)
+		if err != nil {
+			return nil // Skip files that don't parse
+		}
+		for _, ref := range fileRefs {
+			if len(refs) >= maxResults {
+				return filepath.SkipAll
+			}
+			ref.File = relPath
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find references: %w", err)
+	}
+	return refs, nil
+}
+
+// navigationRoot resolves the directory to navigate, checking the path policy.
+func (s *Searcher) navigationRoot(path string) (string, error) {
+	if path == "" {
+		return s.repoPath, nil
+	}
+	if err := s.policy.check(path); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.repoPath, path), nil
+}
+
+// splitSymbol splits "Type.Name" into its container and name.
+func splitSymbol(symbol string) (container, name string) {
+	symbol = strings.TrimSpace(symbol)
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		return symbol[:i], symbol[i+1:]
+	}
+	return "", symbol
+}
+
+// fileSymbols returns the declarations in a Go or Java file.
+func fileSymbols(path string) ([]Symbol, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".go" {
+		return goSymbols(path, src)
+	}
+	return javaSymbols(string(src)), nil
+}
+
+// fileReferences returns the uses of an identifier in a Go or Java file.
+func fileReferences(path, name string) ([]Reference, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".go" {
+		return goReferences(path, src, name)
+	}
+	return javaReferences(string(src), name), nil
+}
+
+// goSymbols parses a Go file and returns its package-level declarations,
+// methods, struct fields and interface methods.
+func goSymbols(path string, src []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(src), "\n")
+
+	var symbols []Symbol
+	add := func(ident *ast.Ident, container, kind string) {
+		line := fset.Position(ident.Pos()).Line
+		symbols = append(symbols, Symbol{
+			Name:      ident.Name,
+			Container: container,
+			Kind:      kind,
+			Line:      line,
+			Content:   sourceLine(lines, line),
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Name, receiverType(d.Recv.List[0].Type), SymbolMethod)
+			} else {
+				add(d.Name, "", SymbolFunc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					add(sp.Name, "", SymbolType)
+					goMembers(sp, add)
+				case *ast.ValueSpec:
+					kind := SymbolVar
+					if d.Tok == token.CONST {
+						kind = SymbolConst
+					}
+					for _, name := range sp.Names {
+						add(name, "", kind)
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goMembers adds the fields of a struct type or the methods of an interface.
+func goMembers(spec *ast.TypeSpec, add func(*ast.Ident, string, string)) {
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			for _, name := range field.Names {
+				add(name, spec.Name.Name, SymbolField)
+			}
+		}
+	case *ast.InterfaceType:
+		for _, method := range t.Methods.List {
+			for _, name := range method.Names {
+				add(name, spec.Name.Name, SymbolMethod)
+			}
+		}
+	}
+}
+
+// receiverType returns the type name of a method receiver, without pointer
+// or type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// goReferences parses a Go file and returns every identifier named name.
+func goReferences(path string, src []byte, name string) ([]Reference, error) {
+	// Cheap check before parsing
+	if !strings.Contains(string(src), name) {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(src), "\n")
+
+	// Lines of declarations, to mark them in the results
+	declared := make(map[int]bool)
+	symbols, _ := goSymbols(path, src)
+	for _, sym := range symbols {
+		if sym.Name == name {
+			declared[sym.Line] = true
+		}
+	}
+
+	var refs []Reference
+	seen := make(map[int]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Name != name {
+			return true
+		}
+		line := fset.Position(ident.Pos()).Line
+		if seen[line] {
+			return true
+		}
+		seen[line] = true
+		refs = append(refs, Reference{
+			SearchResult: SearchResult{Line: line, Content: sourceLine(lines, line)},
+			Declaration:  declared[line],
+		})
+		return true
+	})
+	return refs, nil
+}
+
+// Java declarations, matched against source with comments and strings blanked out.
+var (
+	javaTypeRe = regexp.MustCompile(`\b(class|interface|enum|record)\s+([A-Za-z_$][\w$]*)`)
+	// Modifiers and annotations that may precede a member declaration
+	javaModifiers = `(?:@[\w.]+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp|transient|volatile)\s+)*`
+	javaMethodRe  = regexp.MustCompile(`^\s*` + javaModifiers + `(?:<[^>]*>\s+)?([\w$.]+(?:<[^()]*>)?(?:\[\])*)\s+([A-Za-z_$][\w$]*)\s*\(`)
+	javaCtorRe    = regexp.MustCompile(`^\s*` + javaModifiers + `([A-Za-z_$][\w$]*)\s*\(`)
+	javaFieldRe   = regexp.MustCompile(`^\s*(?:@[\w.]+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|static|final|transient|volatile)\s+)+([\w$.]+(?:<[^()]*>)?(?:\[\])*)\s+([A-Za-z_$][\w$]*)\s*[=;]`)
+)
+
+// javaKeywords can't be the return type of a method declaration; lines
+// starting with them are statements such as "return foo(x);".
+var javaKeywords = map[string]bool{
+	"return": true, "new": true, "else": true, "throw": true, "case": true,
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"synchronized": true, "try": true, "do": true, "assert": true, "yield": true,
+}
+
+// javaSymbols returns the type, method, constructor and field declarations in
+// Java source. Members are attributed to the most recently declared type.
+func javaSymbols(src string) []Symbol {
+	lines := strings.Split(src, "\n")
+	code := strings.Split(stripJavaNoise(src), "\n")
+
+	var (
+		symbols []Symbol
+		types   = make(map[string]bool)
+		current string
+	)
+	for i, line := range code {
+		num := i + 1
+		add := func(name, container, kind string) {
+			symbols = append(symbols, Symbol{Name: name, Container: container, Kind: kind, Line: num, Content: sourceLine(lines, num)})
+		}
+
+		if m := javaTypeRe.FindStringSubmatch(line); m != nil {
+			add(m[2], "", m[1])
+			types[m[2]] = true
+			current = m[2]
+			continue
+		}
+		if m := javaMethodRe.FindStringSubmatch(line); m != nil && !javaKeywords[m[1]] && !javaKeywords[m[2]] {
+			add(m[2], current, SymbolMethod)
+			continue
+		}
+		if m := javaCtorRe.FindStringSubmatch(line); m != nil && types[m[1]] {
+			add(m[1], m[1], SymbolMethod)
+			continue
+		}
+		if m := javaFieldRe.FindStringSubmatch(line); m != nil {
+			add(m[2], current, SymbolField)
+		}
+	}
+	return symbols
+}
+
+// javaReferences returns the lines of Java source using an identifier outside
+// comments and string literals.
+func javaReferences(src, name string) []Reference {
+	if !strings.Contains(src, name) {
+		return nil
+	}
+
+	identRe := regexp.MustCompile(`(?:^|[^\w$])` + regexp.QuoteMeta(name) + `(?:[^\w$]|$)`)
+	declared := make(map[int]bool)
+	for _, sym := range javaSymbols(src) {
+		if sym.Name == name {
+			declared[sym.Line] = true
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	var refs []Reference
+	for i, line := range strings.Split(stripJavaNoise(src), "\n") {
+		if identRe.MatchString(line) {
+			refs = append(refs, Reference{
+				SearchResult: SearchResult{Line: i + 1, Content: sourceLine(lines, i+1)},
+				Declaration:  declared[i+1],
+			})
+		}
+	}
+	return refs
+}
+
+// stripJavaNoise blanks out comments and string and character literals,
+// keeping line breaks so line numbers are unchanged.
+func stripJavaNoise(src string) string {
+	out := []byte(src)
+	const (
+		code = iota
+		lineComment
+		blockComment
+		stringLit
+		charLit
+		textBlock
+	)
+	state := code
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		next := byte(0)
+		if i+1 < len(out) {
+			next = out[i+1]
+		}
+		switch state {
+		case code:
+			switch {
+			case c == '/' && next == '/':
+				state = lineComment
+			case c == '/' && next == '*':
+				state = blockComment
+			case c == '"' && strings.HasPrefix(string(out[i:]), `"""`):
+				state = textBlock
+				i += 2
+				continue
+			case c == '"':
+				state = stringLit
+				continue
+			case c == '\'':
+				state = charLit
+				continue
+			default:
+				continue
+			}
+		case lineComment:
+			if c == '\n' {
+				state = code
+				continue
+			}
+		case blockComment:
+			if c == '*' && next == '/' {
+				out[i], out[i+1] = ' ', ' '
+				i++
+				state = code
+				continue
+			}
+		case stringLit, charLit:
+			quote := byte('"')
+			if state == charLit {
+				quote = '\''
+			}
+			if c == '\\' && next != '\n' {
+				out[i], out[i+1] = ' ', ' '
+				i++
+				continue
+			}
+			if c == quote || c == '\n' {
+				state = code
+				continue
+			}
+		case textBlock:
+			if c == '"' && strings.HasPrefix(string(out[i:]), `"""`) {
+				i += 2
+				state = code
+				continue
+			}
+		}
+		if c != '\n' {
+			out[i] = ' '
+		}
+	}
+	return string(out)
+}
+
+// sourceLine returns a 1-based line of source, trimmed.
+func sourceLine(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+// FormatSymbols formats symbol definitions for display.
+func FormatSymbols(symbols []Symbol) string {
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].File != symbols[j].File {
+			return symbols[i].File < symbols[j].File
+		}
+		return symbols[i].Line < symbols[j].Line
+	})
+
+	var sb strings.Builder
+	for _, sym := range symbols {
+		sb.WriteString(fmt.Sprintf("%s:%d: [%s %s] %s\n", sym.File, sym.Line, sym.Kind, sym.QualifiedName(), sym.Content))
+	}
+	return sb.String()
+}
+
+// FormatReferences formats symbol references for display, marking declarations.
+func FormatReferences(refs []Reference) string {
+	var sb strings.Builder
+	for _, ref := range refs {
+		marker := ""
+		if ref.Declaration {
+			marker = " (declaration)"
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d:%s %s\n", ref.File, ref.Line, marker, ref.Content))
+	}
+	return sb.String()
+}
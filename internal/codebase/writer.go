@@ -11,16 +11,33 @@ import (
 // Writer provides file writing operations within a repository.
 type Writer struct {
 	repoPath string
+	policy   *PathPolicy
+	// approved writers may change write-protected paths
+	approved bool
+	// formatter formats files on request (see Format)
+	formatter *Formatter
+	// generated files are refused unless allowGenerated is set
+	generated      *GeneratedRules
+	allowGenerated bool
 }
 
-// NewWriter creates a new file writer.
-func NewWriter(repoPath string) *Writer {
-	return &Writer{repoPath: repoPath}
+// NewWriter creates a new file writer. Paths restricted by policy cannot be
+// written, and paths it write-protects cannot be changed without approval.
+func NewWriter(repoPath string, policy *PathPolicy) *Writer {
+	return &Writer{repoPath: repoPath, policy: policy}
+}
+
+// Approved returns a writer for a change a human has approved, which may
+// modify write-protected paths.
+func (w *Writer) Approved() *Writer {
+	approved := *w
+	approved.approved = true
+	return &approved
 }
 
 // WriteFile writes content to a file, creating directories as needed.
 func (w *Writer) WriteFile(path, content string) error {
-	fullPath, err := w.resolvePath(path)
+	fullPath, err := w.resolveWritable(path)
 	if err != nil {
 		return err
 	}
@@ -41,7 +58,7 @@ func (w *Writer) WriteFile(path, content string) error {
 
 // EditFile makes a targeted edit to a file.
 func (w *Writer) EditFile(path, oldText, newText string) error {
-	fullPath, err := w.resolvePath(path)
+	fullPath, err := w.resolveWritable(path)
 	if err != nil {
 		return err
 	}
@@ -76,7 +93,7 @@ func (w *Writer) EditFile(path, oldText, newText string) error {
 
 // DeleteFile deletes a file.
 func (w *Writer) DeleteFile(path string) error {
-	fullPath, err := w.resolvePath(path)
+	fullPath, err := w.resolveWritable(path)
 	if err != nil {
 		return err
 	}
@@ -129,9 +146,30 @@ func (w *Writer) resolvePath(path string) (string, error) {
 		return "", fmt.Errorf("path escapes repository: %s", path)
 	}
 
+	if err := w.policy.check(path); err != nil {
+		return "", err
+	}
+
 	return absPath, nil
 }
 
+// resolveWritable resolves the path of a file to change, refusing
+// write-protected paths unless the writer is approved and generated files
+// unless it allows them.
+func (w *Writer) resolveWritable(path string) (string, error) {
+	fullPath, err := w.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	if !w.approved && w.policy.Protected(path) {
+		return "", fmt.Errorf("%s is %w: changing it needs human approval", path, ErrProtected)
+	}
+	if err := w.checkGenerated(path, fullPath); err != nil {
+		return "", err
+	}
+	return fullPath, nil
+}
+
 // GetRepoPath returns the repository path.
 func (w *Writer) GetRepoPath() string {
 	return w.repoPath
@@ -2,12 +2,18 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/spf13/viper"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/scheduler"
 )
 
 // Mode represents the repository access mode.
@@ -18,8 +24,28 @@ const (
 	ModeSandbox Mode = "sandbox"
 )
 
+// Claude backends (LLM providers).
+const (
+	BackendAnthropic = "anthropic"
+	BackendBedrock   = "bedrock"
+	BackendVertex    = "vertex"
+	BackendOpenAI    = "openai"
+	BackendFake      = "fake"
+)
+
+// Code hosting forges.
+const (
+	ForgeGitHub    = "github"
+	ForgeGitLab    = "gitlab"
+	ForgeBitbucket = "bitbucket"
+)
+
 // Config holds all configuration for the bot.
 type Config struct {
+	// ConfigFile holds settings missing from the environment, as YAML, JSON,
+	// TOML or KEY=value lines, keyed without the STORMSTACK_ prefix
+	ConfigFile string
+
 	// Mode is either "local" or "sandbox"
 	Mode Mode
 
@@ -31,24 +57,351 @@ type Config struct {
 	GitHubToken   string
 	WorkspacePath string
 
+	// How sandbox mode clones: CloneDepth commits of history (0 for all),
+	// a partial clone (CloneFilter blobless or treeless), and a bare clone
+	// cache in CloneCacheDir reused across restarts
+	CloneDepth    int
+	CloneFilter   string
+	CloneCacheDir string
+
+	// Code hosting forge (github, gitlab or bitbucket); the token defaults to GitHubToken on GitHub
+	Forge        string
+	ForgeURL     string
+	ForgeToken   string
+	ForgeProject string
+
+	// DMWorkspaces gives each user a personal worktree (under DMWorkspaceDir)
+	// for conversations in direct messages
+	DMWorkspaces   bool
+	DMWorkspaceDir string
+
+	// ConversationWorkspaces gives every other conversation a worktree of
+	// its own under WorkspacePath in sandbox mode. At most
+	// MaxConversationWorkspaces are checked out at once, the least recently
+	// used idle ones being released beyond it, and each may use
+	// WorkspaceQuotaMB of disk (0 for no limit)
+	ConversationWorkspaces    bool
+	MaxConversationWorkspaces int
+	WorkspaceQuotaMB          int
+
+	// SemanticSearch indexes the repository with embeddings for the
+	// semantic_search tool, using an OpenAI-compatible embeddings API
+	SemanticSearch    bool
+	EmbeddingsURL     string
+	EmbeddingsAPIKey  string
+	EmbeddingsModel   string
+	SemanticIndexFile string
+
+	// LogsBackend is where the query_logs tool reads application logs:
+	// "loki", "cloudwatch" or "elasticsearch" ("" disables the tool)
+	LogsBackend string
+	// LogsURL is the Loki or Elasticsearch endpoint, and LogsToken its bearer
+	// token or "user:password"
+	LogsURL   string
+	LogsToken string
+	// LogsIndex is the Elasticsearch index pattern searched
+	LogsIndex string
+	// LogsGroups are the CloudWatch log groups searched, in LogsRegion
+	LogsGroups []string
+	LogsRegion string
+	// LogsMaxLines bounds the log lines a query returns
+	LogsMaxLines int
+
+	// DatabaseDSN is the database the describe_database and explain_query
+	// tools introspect, read-only ("" disables them), reached with
+	// DatabaseDriver: "postgres" or "mysql"
+	DatabaseDSN    string
+	DatabaseDriver string
+
+	// GitBackend selects how git operations run: "cli", "go-git", or "auto"
+	// (the git binary when installed, go-git otherwise)
+	GitBackend string
+
 	// Slack settings
-	SlackBotToken string
-	SlackAppToken string
+	SlackBotToken         string
+	SlackAppToken         string
+	SlackReconnectMaxWait time.Duration
+	// SlackStallTimeout is how long the event loop may hold pending events before it is replaced
+	SlackStallTimeout time.Duration
+	// SlackDisconnectAlertAfter is how long a disconnection lasts before admins are alerted
+	SlackDisconnectAlertAfter time.Duration
+	// SlackEventsAddr is the listen address Slack posts events to over HTTP,
+	// verified with SlackSigningSecret; empty uses Socket Mode
+	SlackEventsAddr    string
+	SlackSigningSecret string
+	// SlackClientID and SlackClientSecret enable installing the bot in other
+	// workspaces through OAuth, served next to the HTTP events; installations
+	// are kept in SlackInstallationsFile
+	SlackClientID          string
+	SlackClientSecret      string
+	SlackOAuthRedirectURL  string
+	SlackInstallationsFile string
+	// SlackTeams are the workspaces that may use the bot, as "T123", or
+	// "T123=path" to work on another repository checkout there; empty allows
+	// every workspace, and isn't allowed with OAuth
+	SlackTeams []string
+	// AdminChannel receives operational alerts (optional)
+	AdminChannel string
+	// AdminDigestTime is the local time ("15:04") the previous day's activity
+	// is summarized in AdminChannel (empty disables)
+	AdminDigestTime string
+	// HealthReportChannel receives the scheduled health report (empty disables it)
+	HealthReportChannel string
+	// HealthReportSchedule is the cron expression the health report runs on, in local time
+	HealthReportSchedule string
+	// PRDigestChannel receives the digest of the bot's PRs awaiting review;
+	// PRs opened from a thread are listed in its channel instead (empty disables it)
+	PRDigestChannel string
+	// PRDigestSchedule is the cron expression the PR digest is posted on, in local time
+	PRDigestSchedule string
+	// PRDigestAuthor is the forge account whose open PRs count as the bot's (optional)
+	PRDigestAuthor string
+	// PRDigestBranchPrefix marks open PRs from matching head branches as the bot's (optional)
+	PRDigestBranchPrefix string
+	// ActivityDir holds the daily activity logs the digest is built from
+	// (empty disables them)
+	ActivityDir string
+	// TraceDir holds each conversation's trace of Claude and tool calls
+	// (empty disables tracing)
+	TraceDir string
+	// Leader election between replicas: only the leader runs singleton
+	// scheduled jobs, using leases in LeaseDir (a volume shared by replicas)
+	LeaderElection bool
+	LeaseDir       string
+	LeaderLeaseTTL time.Duration
+	InstanceID     string
+
+	// HealthAddr is the listen address of the /healthz endpoint (empty disables it)
+	HealthAddr              string
+	SlackResponseBufferSize int
+
+	// WebhookAddr is the listen address of the GitHub webhook receiver (empty
+	// disables it); deliveries are verified with WebhookSecret
+	WebhookAddr   string
+	WebhookSecret string
+	// FixWorkflows are the GitHub Actions workflows whose failed runs the bot
+	// investigates, as "workflow" or "workflow=channel"; runs without a
+	// channel are reported in FixWorkflowsChannel, or AdminChannel
+	FixWorkflows        []string
+	FixWorkflowsChannel string
+
+	// APIAddr is the listen address of the REST API (empty disables it);
+	// requests authenticate with one of APIKeys, given as "name=key" or "key"
+	APIAddr string
+	APIKeys []string
+	// Merged PRs labeled BackportLabelPrefix+version (e.g. "backport-1.8")
+	// are backported to BackportBranchPrefix+version, reported in
+	// BackportChannel (empty disables label-triggered backports)
+	BackportChannel      string
+	BackportLabelPrefix  string
+	BackportBranchPrefix string
+	// ReviewFollowup has reviews of the bot's PRs, received as webhooks,
+	// addressed in the threads the PRs came from
+	ReviewFollowup bool
+
+	// MaxConcurrentConversations bounds how many conversations run at once (0 means unlimited)
+	MaxConcurrentConversations int
+	// IncidentChannels and OncallUsers (Slack IDs) get a high-priority lane that jumps the queue
+	IncidentChannels []string
+	OncallUsers      []string
 
 	// Claude settings
-	AnthropicAPIKey string
+	ClaudeBackend       string
+	ClaudeModel         string
+	ClaudeBaseURL       string
+	ClaudeFakeScript    string
+	AnthropicAPIKey     string
+	BedrockRegion       string
+	BedrockProfile      string
+	VertexRegion        string
+	VertexProjectID     string
+	ClaudeMaxRetries    int
+	ClaudeRetryBaseWait time.Duration
+	ClaudeRetryMaxWait  time.Duration
+	// Claude prices in US dollars per million tokens, for cost reporting
+	ClaudeInputPrice  float64
+	ClaudeOutputPrice float64
+
+	// Budgets of each request in a conversation: when one runs out, the bot
+	// stops, reports what it did and what remains, and asks to continue (0
+	// is unlimited)
+	BudgetMaxTokens     int64
+	BudgetMaxIterations int
+	BudgetMaxDuration   time.Duration
+
+	// Record-and-replay of Claude API calls and tool executions
+	ClaudeRecordFile string
+	ClaudeReplayFile string
 
-	// Build commands
+	// Build commands; empty infers them from the repository's build files
 	BuildCmd string
 	TestCmd  string
+	// TestSelectCmd runs selected tests, with {files}, {packages} or {classes}; empty picks one by language
+	TestSelectCmd string
+	// TestCacheDir caches test results by commit and uncommitted changes (empty disables)
+	TestCacheDir string
+	// LintCmd runs the repository's linter; empty infers it, if it can be
+	LintCmd string
+	// CommandTimeout is how long builds, tests and commands may run (capped at 2h)
+	CommandTimeout time.Duration
+	// CommandMaxOutput is how many bytes of a command's stdout and stderr are kept (capped at 10MB)
+	CommandMaxOutput int
+	// LintFormat is the linter's output format: auto, golangci-lint, eslint or checkstyle
+	LintFormat string
+	// OutputParser is the parser analyze_failures uses; auto detects it from the build system and output
+	OutputParser string
+	// OutputParsersFile holds custom regex parsers, relative to the repository
+	OutputParsersFile string
+	// ArtifactsDir keeps the reports builds and tests generate (empty disables)
+	ArtifactsDir string
+	// ArtifactPatterns are the globs of the reports collected; empty uses the built-in list
+	ArtifactPatterns []string
+
+	// Workflows whose commits skip git hooks, formatting and linting in-process instead
+	FastCommitWorkflows []string
+	// VerifyBeforeCommit refuses commit and push until run_build and run_tests
+	// have passed against the workspace's current code
+	VerifyBeforeCommit bool
 
 	// Optional settings
 	GuidelinesFile string
 	LogLevel       string
+
+	// Shadow mode records what the bot would do without posting or writing anything
+	ShadowMode bool
+	ShadowLog  string
+
+	// Redaction of secrets from tool output sent to Claude and messages posted to Slack
+	RedactionEnabled   bool
+	RedactPatternsFile string
+	RedactHostnames    []string
+
+	// ReadPageLines is how many lines of a longer file read_file returns per
+	// page (0 returns files whole)
+	ReadPageLines int
+
+	// SlowToolThreshold is how long a tool call may take before the thread is warned (0 disables)
+	SlowToolThreshold time.Duration
+	// StreamInterval is how often the output of a running command is streamed to the thread (0 disables)
+	StreamInterval time.Duration
+
+	// ToolRateLimit caps tool calls per Slack user per minute (0 disables)
+	ToolRateLimit int
+	// ToolParallelism is how many read-only tool calls of one turn run at
+	// once (1 runs them one at a time)
+	ToolParallelism int
+
+	// EnabledTools, when set, are the only tools Claude may call
+	EnabledTools []string
+	// DisabledTools are tools Claude may never call
+	DisabledTools []string
+	// CustomToolsFile defines the repository's own tools, relative to the
+	// repository
+	CustomToolsFile string
+
+	// RestrictedPaths are repository paths tools may never read, write, search or list
+	RestrictedPaths []string
+	// ProtectedPaths are repository paths tools may only change with human
+	// approval, always including RepoConfigDir
+	ProtectedPaths []string
+	// Formatters map file extensions to the formatter run on files the bot
+	// writes, as ".ext=command" entries, e.g. ".go=gofmt -w"
+	Formatters []string
+	// Generated files may only be changed when a tool call overrides the
+	// guard: paths matching GeneratedPaths (e.g. vendor/, lockfiles) and
+	// files with one of GeneratedMarkers in their header
+	GeneratedPaths   []string
+	GeneratedMarkers []string
+
+	// Logging migrations find calls matching LogMigrationPatterns (regular
+	// expressions) and convert them to LogMigrationTarget, in pull requests
+	// of at most LogMigrationChunkSize calls
+	LogMigrationPatterns  []string
+	LogMigrationTarget    string
+	LogMigrationChunkSize int
+
+	// Approvals for gated actions such as merging PRs (no approvers means anyone may approve)
+	Approvers   []string
+	ApprovalTTL time.Duration
+
+	// PlanApproval has the bot propose a plan, and wait for it to be approved
+	// in the thread, before changing code for tasks of PlanMinFiles files or
+	// more
+	PlanApproval bool
+	PlanMinFiles int
+
+	// PRSelfReview has a second model critique each change before its PR is
+	// opened; blocking findings must be fixed or answered. ReviewerModel picks
+	// that model (empty uses ClaudeModel)
+	PRSelfReview  bool
+	ReviewerModel string
+
+	// LessonsFile persists lessons learned from PR reviews (empty keeps them in memory)
+	LessonsFile string
+
+	// LearnReviewers are the forge users whose review comments lessons are
+	// learned from; none disables learning. ForgeBotUser is the bot's own
+	// forge user, whose comments are never learned from
+	LearnReviewers []string
+	ForgeBotUser   string
+
+	// WorkingHours are per-channel windows for proactive posts, "channel=[days] HH:MM-HH:MM [timezone]"
+	WorkingHours []string
+
+	// PrivateReplies is how replies only the requester should see are delivered: ephemeral or dm
+	PrivateReplies string
+
+	// ThreadContextMessages is how many earlier thread messages are read when the bot is mentioned mid-thread (0 disables)
+	ThreadContextMessages int
+
+	// PROutcomesFile persists the outcomes of bot PRs (empty keeps them in memory)
+	PROutcomesFile string
+
+	// Background jobs (an interval of 0 disables the job)
+	SchedulerJitter       time.Duration
+	RepoSyncInterval      time.Duration
+	CleanupInterval       time.Duration
+	ConversationMaxAge    time.Duration
+	ConflictCheckInterval time.Duration
+	PROutcomesInterval    time.Duration
+	// Threads idle for AutoCloseAfter are closed with a summary (0 disables)
+	AutoCloseInterval time.Duration
+	AutoCloseAfter    time.Duration
 }
 
 // Load loads configuration from environment variables.
 func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadOffline loads configuration for runs without Slack, such as evaluations
+// and the chat CLI, which need neither Slack credentials nor a configured
+// repository.
+func LoadOffline() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := cfg.validateClaude(); len(errs) > 0 {
+		return nil, errors.New("configuration errors:\n  - " + strings.Join(errs, "\n  - "))
+	}
+
+	return cfg, nil
+}
+
+// load reads configuration from environment variables, and the
+// configuration file if one is set, without validating it.
+func load() (*Config, error) {
 	v := viper.New()
 
 	// Set prefix for environment variables
@@ -56,31 +409,268 @@ func Load() (*Config, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// Settings missing from the environment are read from the file, which a
+	// reload rereads
+	if file := v.GetString("CONFIG_FILE"); file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read configuration file %s: %w", file, err)
+		}
+	}
+
 	// Set defaults
 	v.SetDefault("MODE", "local")
 	v.SetDefault("GUIDELINES_FILE", "CLAUDE.md")
 	v.SetDefault("LOG_LEVEL", "info")
-	v.SetDefault("BUILD_CMD", "./build.sh build")
-	v.SetDefault("TEST_CMD", "./build.sh test")
+	v.SetDefault("BUILD_CMD", "")
+	v.SetDefault("TEST_CMD", "")
+	v.SetDefault("TEST_SELECT_CMD", "")
+	v.SetDefault("TEST_CACHE_DIR", "./data/test-cache")
+	v.SetDefault("LINT_CMD", "")
+	v.SetDefault("COMMAND_TIMEOUT", "5m")
+	v.SetDefault("COMMAND_MAX_OUTPUT", 100*1024)
+	v.SetDefault("LINT_FORMAT", "auto")
+	v.SetDefault("OUTPUT_PARSER", "auto")
+	v.SetDefault("OUTPUT_PARSERS_FILE", ".stormstack/parsers.json")
+	v.SetDefault("CUSTOM_TOOLS_FILE", ".stormstack/tools.yaml")
+	v.SetDefault("ARTIFACTS_DIR", "./data/artifacts")
+	v.SetDefault("ARTIFACT_PATTERNS", "")
+	v.SetDefault("FAST_COMMIT_WORKFLOWS", "log_migration")
 	v.SetDefault("WORKSPACE_PATH", "./workspace")
+	v.SetDefault("SCHEDULER_JITTER", "1m")
+	v.SetDefault("REPO_SYNC_INTERVAL", "30m")
+	v.SetDefault("CLEANUP_INTERVAL", "1h")
+	v.SetDefault("CONVERSATION_MAX_AGE", "72h")
+	v.SetDefault("CONFLICT_CHECK_INTERVAL", "15m")
+	v.SetDefault("PR_OUTCOMES_INTERVAL", "1h")
+	v.SetDefault("AUTO_CLOSE_INTERVAL", "15m")
+	v.SetDefault("AUTO_CLOSE_AFTER", "0")
+	v.SetDefault("BACKPORT_LABEL_PREFIX", "backport-")
+	v.SetDefault("BACKPORT_BRANCH_PREFIX", "release-")
+	v.SetDefault("APPROVAL_TTL", "1h")
+	v.SetDefault("PLAN_MIN_FILES", 3)
+	v.SetDefault("REDACTION_ENABLED", true)
+	v.SetDefault("GENERATED_PATHS", "vendor/,third_party/,node_modules/,go.sum,package-lock.json,yarn.lock,pnpm-lock.yaml,Cargo.lock,poetry.lock,Gemfile.lock,composer.lock")
+	v.SetDefault("GENERATED_MARKERS", "Code generated,DO NOT EDIT,@generated,<auto-generated")
+	v.SetDefault("FORMATTERS", ".go=gofmt -w,.py=black -q,.java=google-java-format -i,.js=prettier --write,.jsx=prettier --write,.ts=prettier --write,.tsx=prettier --write")
+	v.SetDefault("LOG_MIGRATION_PATTERNS", `\blog\.(?:Print|Fatal|Panic)(?:f|ln)?\(,\b\w*(?:log|Log|logger|Logger)\.(?:Debug|Info|Warn|Warning|Error)f\(`)
+	v.SetDefault("LOG_MIGRATION_TARGET", `log/slog with a constant message and key-value attributes, e.g. logger.Info("user created", "id", id)`)
+	v.SetDefault("LOG_MIGRATION_CHUNK_SIZE", 40)
+	v.SetDefault("SLOW_TOOL_THRESHOLD", "30s")
+	v.SetDefault("STREAM_INTERVAL", "5s")
+	v.SetDefault("READ_PAGE_LINES", 500)
+	v.SetDefault("LESSONS_FILE", "./data/lessons.json")
+	v.SetDefault("PR_OUTCOMES_FILE", "./data/pr_outcomes.json")
+	v.SetDefault("WORKING_HOURS", "")
+	v.SetDefault("PRIVATE_REPLIES", "ephemeral")
+	v.SetDefault("THREAD_CONTEXT_MESSAGES", 50)
+	v.SetDefault("SHADOW_MODE", false)
+	v.SetDefault("SHADOW_LOG", "./data/shadow.jsonl")
+	v.SetDefault("SLACK_RECONNECT_MAX_WAIT", "1m")
+	v.SetDefault("SLACK_STALL_TIMEOUT", "2m")
+	v.SetDefault("LEADER_ELECTION", false)
+	v.SetDefault("LEASE_DIR", "./data/leases")
+	v.SetDefault("LEADER_LEASE_TTL", "30s")
+	v.SetDefault("SLACK_DISCONNECT_ALERT_AFTER", "5m")
+	v.SetDefault("SLACK_RESPONSE_BUFFER_SIZE", 50)
+	v.SetDefault("MAX_CONCURRENT_CONVERSATIONS", 4)
+	v.SetDefault("FORGE", "github")
+	v.SetDefault("GIT_BACKEND", "auto")
+	v.SetDefault("DM_WORKSPACES", false)
+	v.SetDefault("DM_WORKSPACE_DIR", "./data/worktrees")
+	v.SetDefault("SEMANTIC_SEARCH", false)
+	v.SetDefault("EMBEDDINGS_URL", "https://api.openai.com/v1")
+	v.SetDefault("EMBEDDINGS_MODEL", "text-embedding-3-small")
+	v.SetDefault("LOGS_INDEX", "logs-*")
+	v.SetDefault("LOGS_MAX_LINES", 200)
+	v.SetDefault("DATABASE_DRIVER", "postgres")
+	v.SetDefault("SEMANTIC_INDEX_FILE", "./data/semantic-index.gob")
+	v.SetDefault("CLAUDE_BACKEND", "anthropic")
+	v.SetDefault("CLAUDE_MAX_RETRIES", 4)
+	v.SetDefault("BUDGET_MAX_ITERATIONS", 20)
+	v.SetDefault("TOOL_PARALLELISM", 4)
+	v.SetDefault("BUDGET_MAX_DURATION", "0")
+	v.SetDefault("CLAUDE_RETRY_BASE_WAIT", "1s")
+	v.SetDefault("CLAUDE_RETRY_MAX_WAIT", "30s")
+	v.SetDefault("CLAUDE_INPUT_PRICE", 5.0)
+	v.SetDefault("CLAUDE_OUTPUT_PRICE", 25.0)
+	v.SetDefault("ADMIN_DIGEST_TIME", "09:00")
+	v.SetDefault("ACTIVITY_DIR", "./data/activity")
+	v.SetDefault("HEALTH_REPORT_CHANNEL", "")
+	v.SetDefault("HEALTH_REPORT_SCHEDULE", "0 6 * * 1-5")
+	v.SetDefault("PR_DIGEST_CHANNEL", "")
+	v.SetDefault("PR_DIGEST_SCHEDULE", "0 9 * * 1-5")
+	v.SetDefault("PR_DIGEST_AUTHOR", "")
+	v.SetDefault("PR_DIGEST_BRANCH_PREFIX", "")
+	v.SetDefault("TRACE_DIR", "./data/traces")
+	v.SetDefault("SLACK_INSTALLATIONS_FILE", "./data/slack-installations.json")
+	v.SetDefault("CLONE_DEPTH", 0)
+	v.SetDefault("CLONE_FILTER", "")
+	v.SetDefault("CLONE_CACHE_DIR", "")
+	v.SetDefault("CONVERSATION_WORKSPACES", false)
+	v.SetDefault("MAX_CONVERSATION_WORKSPACES", 10)
+	v.SetDefault("WORKSPACE_QUOTA_MB", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	secret := newSecretSettings(ctx, v)
 
 	cfg := &Config{
 		Mode:            Mode(v.GetString("MODE")),
 		RepoPath:        v.GetString("REPO_PATH"),
 		GitHubRepo:      v.GetString("GITHUB_REPO"),
-		GitHubToken:     v.GetString("GITHUB_TOKEN"),
+		GitHubToken:     secret.get("GITHUB_TOKEN"),
 		WorkspacePath:   v.GetString("WORKSPACE_PATH"),
-		SlackBotToken:   v.GetString("SLACK_BOT_TOKEN"),
-		SlackAppToken:   v.GetString("SLACK_APP_TOKEN"),
-		AnthropicAPIKey: v.GetString("ANTHROPIC_API_KEY"),
+		SlackBotToken:   secret.get("SLACK_BOT_TOKEN"),
+		SlackAppToken:   secret.get("SLACK_APP_TOKEN"),
+		AnthropicAPIKey: secret.get("ANTHROPIC_API_KEY"),
 		BuildCmd:        v.GetString("BUILD_CMD"),
 		TestCmd:         v.GetString("TEST_CMD"),
+		TestSelectCmd:   v.GetString("TEST_SELECT_CMD"),
+		TestCacheDir:    v.GetString("TEST_CACHE_DIR"),
+		LintCmd:         v.GetString("LINT_CMD"),
+		LintFormat:      v.GetString("LINT_FORMAT"),
 		GuidelinesFile:  v.GetString("GUIDELINES_FILE"),
 		LogLevel:        v.GetString("LOG_LEVEL"),
-	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+		SlackReconnectMaxWait:      v.GetDuration("SLACK_RECONNECT_MAX_WAIT"),
+		SlackStallTimeout:          v.GetDuration("SLACK_STALL_TIMEOUT"),
+		SlackDisconnectAlertAfter:  v.GetDuration("SLACK_DISCONNECT_ALERT_AFTER"),
+		SlackEventsAddr:            v.GetString("SLACK_EVENTS_ADDR"),
+		SlackSigningSecret:         secret.get("SLACK_SIGNING_SECRET"),
+		SlackClientID:              v.GetString("SLACK_CLIENT_ID"),
+		SlackClientSecret:          secret.get("SLACK_CLIENT_SECRET"),
+		SlackOAuthRedirectURL:      v.GetString("SLACK_OAUTH_REDIRECT_URL"),
+		SlackInstallationsFile:     v.GetString("SLACK_INSTALLATIONS_FILE"),
+		SlackTeams:                 splitList(v.GetString("SLACK_TEAMS")),
+		AdminChannel:               v.GetString("ADMIN_CHANNEL"),
+		AdminDigestTime:            v.GetString("ADMIN_DIGEST_TIME"),
+		ActivityDir:                v.GetString("ACTIVITY_DIR"),
+		HealthReportChannel:        v.GetString("HEALTH_REPORT_CHANNEL"),
+		HealthReportSchedule:       v.GetString("HEALTH_REPORT_SCHEDULE"),
+		PRDigestChannel:            v.GetString("PR_DIGEST_CHANNEL"),
+		PRDigestSchedule:           v.GetString("PR_DIGEST_SCHEDULE"),
+		PRDigestAuthor:             v.GetString("PR_DIGEST_AUTHOR"),
+		PRDigestBranchPrefix:       v.GetString("PR_DIGEST_BRANCH_PREFIX"),
+		TraceDir:                   v.GetString("TRACE_DIR"),
+		HealthAddr:                 v.GetString("HEALTH_ADDR"),
+		WebhookAddr:                v.GetString("WEBHOOK_ADDR"),
+		WebhookSecret:              secret.get("WEBHOOK_SECRET"),
+		FixWorkflows:               splitList(v.GetString("FIX_WORKFLOWS")),
+		FixWorkflowsChannel:        v.GetString("FIX_WORKFLOWS_CHANNEL"),
+		APIAddr:                    v.GetString("API_ADDR"),
+		APIKeys:                    splitList(secret.get("API_KEYS")),
+		BackportChannel:            v.GetString("BACKPORT_CHANNEL"),
+		BackportLabelPrefix:        v.GetString("BACKPORT_LABEL_PREFIX"),
+		BackportBranchPrefix:       v.GetString("BACKPORT_BRANCH_PREFIX"),
+		ReviewFollowup:             v.GetBool("REVIEW_FOLLOWUP"),
+		LeaderElection:             v.GetBool("LEADER_ELECTION"),
+		LeaseDir:                   v.GetString("LEASE_DIR"),
+		LeaderLeaseTTL:             v.GetDuration("LEADER_LEASE_TTL"),
+		InstanceID:                 v.GetString("INSTANCE_ID"),
+		SlackResponseBufferSize:    v.GetInt("SLACK_RESPONSE_BUFFER_SIZE"),
+		MaxConcurrentConversations: v.GetInt("MAX_CONCURRENT_CONVERSATIONS"),
+		ShadowMode:                 v.GetBool("SHADOW_MODE"),
+		ShadowLog:                  v.GetString("SHADOW_LOG"),
+		LessonsFile:                v.GetString("LESSONS_FILE"),
+		LearnReviewers:             splitList(v.GetString("LEARN_REVIEWERS")),
+		ForgeBotUser:               v.GetString("FORGE_BOT_USER"),
+		PROutcomesFile:             v.GetString("PR_OUTCOMES_FILE"),
+		WorkingHours:               splitList(v.GetString("WORKING_HOURS")),
+		PrivateReplies:             v.GetString("PRIVATE_REPLIES"),
+		ThreadContextMessages:      v.GetInt("THREAD_CONTEXT_MESSAGES"),
+		RedactionEnabled:           v.GetBool("REDACTION_ENABLED"),
+		RedactPatternsFile:         v.GetString("REDACT_PATTERNS_FILE"),
+		RedactHostnames:            splitList(v.GetString("REDACT_HOSTNAMES")),
+		Forge:                      v.GetString("FORGE"),
+		GitBackend:                 v.GetString("GIT_BACKEND"),
+		DMWorkspaces:               v.GetBool("DM_WORKSPACES"),
+		DMWorkspaceDir:             v.GetString("DM_WORKSPACE_DIR"),
+		SemanticSearch:             v.GetBool("SEMANTIC_SEARCH"),
+		EmbeddingsURL:              v.GetString("EMBEDDINGS_URL"),
+		EmbeddingsAPIKey:           secret.get("EMBEDDINGS_API_KEY"),
+		EmbeddingsModel:            v.GetString("EMBEDDINGS_MODEL"),
+		SemanticIndexFile:          v.GetString("SEMANTIC_INDEX_FILE"),
+		LogsBackend:                v.GetString("LOGS_BACKEND"),
+		LogsURL:                    v.GetString("LOGS_URL"),
+		LogsToken:                  secret.get("LOGS_TOKEN"),
+		LogsIndex:                  v.GetString("LOGS_INDEX"),
+		LogsGroups:                 splitList(v.GetString("LOGS_GROUPS")),
+		LogsRegion:                 v.GetString("LOGS_REGION"),
+		LogsMaxLines:               v.GetInt("LOGS_MAX_LINES"),
+		DatabaseDSN:                secret.get("DATABASE_DSN"),
+		DatabaseDriver:             v.GetString("DATABASE_DRIVER"),
+		ForgeURL:                   v.GetString("FORGE_URL"),
+		ForgeToken:                 secret.get("FORGE_TOKEN"),
+		ForgeProject:               v.GetString("FORGE_PROJECT"),
+		ToolRateLimit:              v.GetInt("TOOL_RATE_LIMIT"),
+		ToolParallelism:            v.GetInt("TOOL_PARALLELISM"),
+		EnabledTools:               splitList(v.GetString("ENABLED_TOOLS")),
+		DisabledTools:              splitList(v.GetString("DISABLED_TOOLS")),
+		CustomToolsFile:            v.GetString("CUSTOM_TOOLS_FILE"),
+		SlowToolThreshold:          v.GetDuration("SLOW_TOOL_THRESHOLD"),
+		CommandTimeout:             v.GetDuration("COMMAND_TIMEOUT"),
+		CommandMaxOutput:           v.GetInt("COMMAND_MAX_OUTPUT"),
+		OutputParser:               v.GetString("OUTPUT_PARSER"),
+		OutputParsersFile:          v.GetString("OUTPUT_PARSERS_FILE"),
+		ArtifactsDir:               v.GetString("ARTIFACTS_DIR"),
+		ArtifactPatterns:           splitList(v.GetString("ARTIFACT_PATTERNS")),
+		StreamInterval:             v.GetDuration("STREAM_INTERVAL"),
+		ReadPageLines:              v.GetInt("READ_PAGE_LINES"),
+		RestrictedPaths:            splitList(v.GetString("RESTRICTED_PATHS")),
+		ProtectedPaths:             union([]string{RepoConfigDir}, splitList(v.GetString("PROTECTED_PATHS"))),
+		Formatters:                 splitList(v.GetString("FORMATTERS")),
+		GeneratedPaths:             splitList(v.GetString("GENERATED_PATHS")),
+		FastCommitWorkflows:        splitList(v.GetString("FAST_COMMIT_WORKFLOWS")),
+		VerifyBeforeCommit:         v.GetBool("VERIFY_BEFORE_COMMIT"),
+		GeneratedMarkers:           splitList(v.GetString("GENERATED_MARKERS")),
+		LogMigrationPatterns:       splitList(v.GetString("LOG_MIGRATION_PATTERNS")),
+		LogMigrationTarget:         v.GetString("LOG_MIGRATION_TARGET"),
+		LogMigrationChunkSize:      v.GetInt("LOG_MIGRATION_CHUNK_SIZE"),
+		Approvers:                  splitList(v.GetString("APPROVERS")),
+		IncidentChannels:           splitList(v.GetString("INCIDENT_CHANNELS")),
+		OncallUsers:                splitList(v.GetString("ONCALL_USERS")),
+		ApprovalTTL:                v.GetDuration("APPROVAL_TTL"),
+		PlanApproval:               v.GetBool("PLAN_APPROVAL"),
+		PlanMinFiles:               v.GetInt("PLAN_MIN_FILES"),
+		PRSelfReview:               v.GetBool("PR_SELF_REVIEW"),
+		ReviewerModel:              v.GetString("REVIEWER_MODEL"),
+		SchedulerJitter:            v.GetDuration("SCHEDULER_JITTER"),
+		RepoSyncInterval:           v.GetDuration("REPO_SYNC_INTERVAL"),
+		CleanupInterval:            v.GetDuration("CLEANUP_INTERVAL"),
+		ConversationMaxAge:         v.GetDuration("CONVERSATION_MAX_AGE"),
+		ConflictCheckInterval:      v.GetDuration("CONFLICT_CHECK_INTERVAL"),
+		PROutcomesInterval:         v.GetDuration("PR_OUTCOMES_INTERVAL"),
+		AutoCloseInterval:          v.GetDuration("AUTO_CLOSE_INTERVAL"),
+		AutoCloseAfter:             v.GetDuration("AUTO_CLOSE_AFTER"),
+		ClaudeMaxRetries:           v.GetInt("CLAUDE_MAX_RETRIES"),
+		BudgetMaxTokens:            v.GetInt64("BUDGET_MAX_TOKENS"),
+		BudgetMaxIterations:        v.GetInt("BUDGET_MAX_ITERATIONS"),
+		BudgetMaxDuration:          v.GetDuration("BUDGET_MAX_DURATION"),
+		ClaudeRetryBaseWait:        v.GetDuration("CLAUDE_RETRY_BASE_WAIT"),
+		ClaudeRetryMaxWait:         v.GetDuration("CLAUDE_RETRY_MAX_WAIT"),
+		ClaudeInputPrice:           v.GetFloat64("CLAUDE_INPUT_PRICE"),
+		ClaudeOutputPrice:          v.GetFloat64("CLAUDE_OUTPUT_PRICE"),
+		ClaudeBackend:              v.GetString("CLAUDE_BACKEND"),
+		ClaudeModel:                v.GetString("CLAUDE_MODEL"),
+		ClaudeBaseURL:              v.GetString("CLAUDE_BASE_URL"),
+		ClaudeFakeScript:           v.GetString("CLAUDE_FAKE_SCRIPT"),
+		BedrockRegion:              v.GetString("BEDROCK_REGION"),
+		BedrockProfile:             v.GetString("BEDROCK_PROFILE"),
+		VertexRegion:               v.GetString("VERTEX_REGION"),
+		VertexProjectID:            v.GetString("VERTEX_PROJECT_ID"),
+		ClaudeRecordFile:           v.GetString("CLAUDE_RECORD"),
+		ClaudeReplayFile:           v.GetString("CLAUDE_REPLAY"),
+		ConfigFile:                 v.GetString("CONFIG_FILE"),
+		CloneDepth:                 v.GetInt("CLONE_DEPTH"),
+		CloneFilter:                v.GetString("CLONE_FILTER"),
+		CloneCacheDir:              v.GetString("CLONE_CACHE_DIR"),
+		ConversationWorkspaces:     v.GetBool("CONVERSATION_WORKSPACES"),
+		MaxConversationWorkspaces:  v.GetInt("MAX_CONVERSATION_WORKSPACES"),
+		WorkspaceQuotaMB:           v.GetInt("WORKSPACE_QUOTA_MB"),
+	}
+	if secret.err != nil {
+		return nil, secret.err
 	}
 
 	return cfg, nil
@@ -107,20 +697,208 @@ func (c *Config) Validate() error {
 		if c.GitHubRepo == "" {
 			errs = append(errs, "STORMSTACK_GITHUB_REPO is required in sandbox mode")
 		}
-		if c.GitHubToken == "" {
+		if c.GitHubToken == "" && c.Forge == ForgeGitHub {
 			errs = append(errs, "STORMSTACK_GITHUB_TOKEN is required in sandbox mode")
 		}
+		if c.CloneDepth < 0 {
+			errs = append(errs, "STORMSTACK_CLONE_DEPTH must not be negative")
+		}
+		switch c.CloneFilter {
+		case "", "blobless", "treeless":
+		default:
+			errs = append(errs, fmt.Sprintf("invalid clone filter %q, must be blobless or treeless", c.CloneFilter))
+		}
+	}
+	if c.ConversationWorkspaces && c.Mode != ModeSandbox {
+		errs = append(errs, "STORMSTACK_CONVERSATION_WORKSPACES requires sandbox mode")
+	}
+	if c.MaxConversationWorkspaces < 0 || c.WorkspaceQuotaMB < 0 {
+		errs = append(errs, "STORMSTACK_MAX_CONVERSATION_WORKSPACES and STORMSTACK_WORKSPACE_QUOTA_MB must not be negative")
+	}
+
+	switch c.Forge {
+	case ForgeGitHub:
+	case ForgeGitLab, ForgeBitbucket:
+		if c.ForgeToken == "" {
+			errs = append(errs, fmt.Sprintf("STORMSTACK_FORGE_TOKEN is required for the %s forge", c.Forge))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("invalid forge %q, must be github, gitlab or bitbucket", c.Forge))
+	}
+
+	switch c.LintFormat {
+	case "auto", "golangci-lint", "eslint", "checkstyle":
+	default:
+		errs = append(errs, fmt.Sprintf("invalid lint format %q, must be auto, golangci-lint, eslint or checkstyle", c.LintFormat))
+	}
+
+	switch c.PrivateReplies {
+	case "ephemeral", "dm":
+	default:
+		errs = append(errs, fmt.Sprintf("invalid STORMSTACK_PRIVATE_REPLIES %q, must be ephemeral or dm", c.PrivateReplies))
+	}
+
+	if c.ThreadContextMessages < 0 {
+		errs = append(errs, "STORMSTACK_THREAD_CONTEXT_MESSAGES must not be negative")
+	}
+	if c.CommandTimeout <= 0 {
+		errs = append(errs, "STORMSTACK_COMMAND_TIMEOUT must be positive")
+	}
+	if c.CommandMaxOutput <= 0 {
+		errs = append(errs, "STORMSTACK_COMMAND_MAX_OUTPUT must be positive")
+	}
+	for _, pattern := range c.ArtifactPatterns {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_ARTIFACT_PATTERNS glob %q", pattern))
+		}
+	}
+
+	switch c.GitBackend {
+	case "auto", "cli", "go-git":
+	default:
+		errs = append(errs, fmt.Sprintf("invalid git backend %q, must be auto, cli or go-git", c.GitBackend))
 	}
 
 	// Required for all modes
 	if c.SlackBotToken == "" {
 		errs = append(errs, "STORMSTACK_SLACK_BOT_TOKEN is required")
 	}
-	if c.SlackAppToken == "" {
-		errs = append(errs, "STORMSTACK_SLACK_APP_TOKEN is required")
+	switch {
+	case c.SlackEventsAddr != "" && c.SlackSigningSecret == "":
+		errs = append(errs, "STORMSTACK_SLACK_SIGNING_SECRET is required when Slack events are received over HTTP")
+	case c.SlackEventsAddr == "" && c.SlackAppToken == "":
+		errs = append(errs, "STORMSTACK_SLACK_APP_TOKEN is required (or STORMSTACK_SLACK_EVENTS_ADDR to receive events over HTTP)")
+	}
+	if (c.SlackClientID == "") != (c.SlackClientSecret == "") {
+		errs = append(errs, "STORMSTACK_SLACK_CLIENT_ID and STORMSTACK_SLACK_CLIENT_SECRET must be set together")
+	}
+	if c.SlackClientID != "" && c.SlackEventsAddr == "" {
+		errs = append(errs, "STORMSTACK_SLACK_CLIENT_ID needs Slack events over HTTP (STORMSTACK_SLACK_EVENTS_ADDR), where the install flow is served")
+	}
+	if c.SlackClientID != "" && len(c.SlackTeams) == 0 {
+		errs = append(errs, "STORMSTACK_SLACK_TEAMS is required with STORMSTACK_SLACK_CLIENT_ID, so only the listed workspaces can install the bot")
+	}
+	for _, entry := range c.SlackTeams {
+		if team, path, ok := strings.Cut(entry, "="); team == "" || (ok && path == "") {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_SLACK_TEAMS entry %q, must be \"T123\" or \"T123=path\"", entry))
+		}
+	}
+	errs = append(errs, c.validateClaude()...)
+	if c.SlackReconnectMaxWait <= 0 {
+		errs = append(errs, "STORMSTACK_SLACK_RECONNECT_MAX_WAIT must be positive")
+	}
+	if c.SlackStallTimeout <= 0 {
+		errs = append(errs, "STORMSTACK_SLACK_STALL_TIMEOUT must be positive")
+	}
+	if c.LeaderElection && c.LeaderLeaseTTL < 3*time.Second {
+		errs = append(errs, "STORMSTACK_LEADER_LEASE_TTL must be at least 3s")
+	}
+	if c.SlackDisconnectAlertAfter <= 0 {
+		errs = append(errs, "STORMSTACK_SLACK_DISCONNECT_ALERT_AFTER must be positive")
+	}
+	if c.MaxConcurrentConversations < 0 {
+		errs = append(errs, "STORMSTACK_MAX_CONCURRENT_CONVERSATIONS must not be negative")
+	}
+	if c.CleanupInterval > 0 && c.ConversationMaxAge <= 0 {
+		errs = append(errs, "STORMSTACK_CONVERSATION_MAX_AGE must be positive when cleanup is enabled")
+	}
+	if c.SemanticSearch && (c.EmbeddingsURL == "" || c.EmbeddingsModel == "" || c.SemanticIndexFile == "") {
+		errs = append(errs, "STORMSTACK_EMBEDDINGS_URL, STORMSTACK_EMBEDDINGS_MODEL and STORMSTACK_SEMANTIC_INDEX_FILE are required when semantic search is enabled")
+	}
+	switch c.LogsBackend {
+	case "":
+	case "loki", "elasticsearch":
+		if c.LogsURL == "" {
+			errs = append(errs, fmt.Sprintf("STORMSTACK_LOGS_URL is required for the %s logs backend", c.LogsBackend))
+		}
+	case "cloudwatch":
+		if len(c.LogsGroups) == 0 || c.LogsRegion == "" {
+			errs = append(errs, "STORMSTACK_LOGS_GROUPS and STORMSTACK_LOGS_REGION are required for the cloudwatch logs backend")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("invalid logs backend %q, must be loki, cloudwatch or elasticsearch", c.LogsBackend))
+	}
+	if c.LogsBackend != "" && c.LogsMaxLines < 1 {
+		errs = append(errs, "STORMSTACK_LOGS_MAX_LINES must be at least 1")
+	}
+	if c.DatabaseDSN != "" && c.DatabaseDriver != "postgres" && c.DatabaseDriver != "mysql" {
+		errs = append(errs, fmt.Sprintf("invalid database driver %q, must be postgres or mysql", c.DatabaseDriver))
+	}
+	if c.AutoCloseAfter < 0 {
+		errs = append(errs, "STORMSTACK_AUTO_CLOSE_AFTER must not be negative")
+	}
+	if c.AutoCloseAfter > 0 && c.CleanupInterval > 0 && c.AutoCloseAfter >= c.ConversationMaxAge {
+		errs = append(errs, "STORMSTACK_AUTO_CLOSE_AFTER must be shorter than STORMSTACK_CONVERSATION_MAX_AGE")
+	}
+	if c.WebhookAddr != "" && c.WebhookSecret == "" {
+		errs = append(errs, "STORMSTACK_WEBHOOK_SECRET is required when the webhook receiver is enabled")
+	}
+	if c.APIAddr != "" && len(c.APIKeys) == 0 {
+		errs = append(errs, "STORMSTACK_API_KEYS is required when the REST API is enabled")
+	}
+	if len(c.FixWorkflows) > 0 {
+		if c.WebhookAddr == "" {
+			errs = append(errs, "STORMSTACK_FIX_WORKFLOWS needs the webhook receiver (STORMSTACK_WEBHOOK_ADDR)")
+		}
+		if c.Forge != ForgeGitHub {
+			errs = append(errs, "STORMSTACK_FIX_WORKFLOWS is only supported on GitHub")
+		}
+		for _, w := range c.FixWorkflows {
+			if !strings.Contains(w, "=") && c.FixWorkflowsChannel == "" && c.AdminChannel == "" {
+				errs = append(errs, fmt.Sprintf("STORMSTACK_FIX_WORKFLOWS entry %q has no channel and neither STORMSTACK_FIX_WORKFLOWS_CHANNEL nor STORMSTACK_ADMIN_CHANNEL is set", w))
+			}
+		}
+	}
+	for _, pattern := range c.LogMigrationPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_LOG_MIGRATION_PATTERNS entry %q: %v", pattern, err))
+		}
+	}
+	if c.LogMigrationChunkSize < 1 {
+		errs = append(errs, "STORMSTACK_LOG_MIGRATION_CHUNK_SIZE must be at least 1")
+	}
+	if c.AdminDigestTime != "" {
+		if _, err := time.Parse("15:04", c.AdminDigestTime); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_ADMIN_DIGEST_TIME %q, must be HH:MM", c.AdminDigestTime))
+		}
+	}
+	if c.HealthReportChannel != "" {
+		if _, err := scheduler.ParseCron(c.HealthReportSchedule); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_HEALTH_REPORT_SCHEDULE: %v", err))
+		}
+	}
+	if c.PRDigestChannel != "" {
+		if _, err := scheduler.ParseCron(c.PRDigestSchedule); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid STORMSTACK_PR_DIGEST_SCHEDULE: %v", err))
+		}
 	}
-	if c.AnthropicAPIKey == "" {
-		errs = append(errs, "STORMSTACK_ANTHROPIC_API_KEY is required")
+	if c.ClaudeInputPrice < 0 || c.ClaudeOutputPrice < 0 {
+		errs = append(errs, "STORMSTACK_CLAUDE_INPUT_PRICE and STORMSTACK_CLAUDE_OUTPUT_PRICE must not be negative")
+	}
+	if c.ReadPageLines < 0 {
+		errs = append(errs, "STORMSTACK_READ_PAGE_LINES must not be negative")
+	}
+	if c.PlanMinFiles < 0 {
+		errs = append(errs, "STORMSTACK_PLAN_MIN_FILES must not be negative")
+	}
+	if c.BackportChannel != "" {
+		if c.WebhookAddr == "" {
+			errs = append(errs, "STORMSTACK_BACKPORT_CHANNEL needs the webhook receiver (STORMSTACK_WEBHOOK_ADDR)")
+		}
+		if c.Forge != ForgeGitHub {
+			errs = append(errs, "label-triggered backports are only supported on GitHub")
+		}
+		if c.BackportLabelPrefix == "" {
+			errs = append(errs, "STORMSTACK_BACKPORT_LABEL_PREFIX must not be empty")
+		}
+	}
+	if c.ReviewFollowup {
+		if c.WebhookAddr == "" {
+			errs = append(errs, "STORMSTACK_REVIEW_FOLLOWUP needs the webhook receiver (STORMSTACK_WEBHOOK_ADDR)")
+		}
+		if c.Forge != ForgeGitHub {
+			errs = append(errs, "review follow-ups from webhooks are only supported on GitHub")
+		}
 	}
 
 	if len(errs) > 0 {
@@ -130,6 +908,64 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validateClaude checks the Claude API settings.
+func (c *Config) validateClaude() []string {
+	var errs []string
+	// Replays answer from the recording, so the backend is never called
+	replay := c.ClaudeReplayFile != ""
+	switch c.ClaudeBackend {
+	case BackendAnthropic:
+		if c.AnthropicAPIKey == "" && !replay {
+			errs = append(errs, "STORMSTACK_ANTHROPIC_API_KEY is required")
+		}
+	case BackendBedrock:
+		if c.BedrockRegion == "" && !replay {
+			errs = append(errs, "STORMSTACK_BEDROCK_REGION is required for the bedrock backend")
+		}
+	case BackendVertex:
+		if (c.VertexRegion == "" || c.VertexProjectID == "") && !replay {
+			errs = append(errs, "STORMSTACK_VERTEX_REGION and STORMSTACK_VERTEX_PROJECT_ID are required for the vertex backend")
+		}
+	case BackendOpenAI:
+		if (c.ClaudeBaseURL == "" || c.ClaudeModel == "") && !replay {
+			errs = append(errs, "STORMSTACK_CLAUDE_BASE_URL and STORMSTACK_CLAUDE_MODEL are required for the openai backend")
+		}
+	case BackendFake:
+	default:
+		errs = append(errs, fmt.Sprintf("invalid Claude backend %q, must be one of anthropic, bedrock, vertex, openai or fake", c.ClaudeBackend))
+	}
+	if c.ClaudeRecordFile != "" && c.ClaudeReplayFile != "" {
+		errs = append(errs, "STORMSTACK_CLAUDE_RECORD and STORMSTACK_CLAUDE_REPLAY cannot both be set")
+	}
+	if c.ClaudeMaxRetries < 0 {
+		errs = append(errs, "STORMSTACK_CLAUDE_MAX_RETRIES must not be negative")
+	}
+	if c.ClaudeRetryBaseWait <= 0 {
+		errs = append(errs, "STORMSTACK_CLAUDE_RETRY_BASE_WAIT must be positive")
+	}
+	if c.ClaudeRetryMaxWait < c.ClaudeRetryBaseWait {
+		errs = append(errs, "STORMSTACK_CLAUDE_RETRY_MAX_WAIT must not be less than STORMSTACK_CLAUDE_RETRY_BASE_WAIT")
+	}
+	if c.ToolParallelism < 1 {
+		errs = append(errs, "STORMSTACK_TOOL_PARALLELISM must be at least 1")
+	}
+	if c.BudgetMaxTokens < 0 || c.BudgetMaxIterations < 0 || c.BudgetMaxDuration < 0 {
+		errs = append(errs, "STORMSTACK_BUDGET_MAX_TOKENS, STORMSTACK_BUDGET_MAX_ITERATIONS and STORMSTACK_BUDGET_MAX_DURATION must not be negative")
+	}
+	return errs
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // isDirectory checks if a path exists and is a directory.
 func isDirectory(path string) bool {
 	info, err := os.Stat(path)
@@ -0,0 +1,98 @@
+// The per-repository configuration file.
+
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigDir holds the files a repository configures the bot with,
+// relative to its root. It is always write-protected, so the bot can't
+// propose changes to its own settings and tools unattended.
+const RepoConfigDir = ".stormstack/"
+
+// RepoConfigFile is the configuration a repository carries, relative to its
+// root.
+const RepoConfigFile = RepoConfigDir + "config.yaml"
+
+// RepoConfig is a repository's own configuration, checked in with its code.
+// Its settings override the bot's, except that paths and tools can only be
+// protected or disabled further.
+type RepoConfig struct {
+	BuildCmd       string `yaml:"build_cmd"`
+	TestCmd        string `yaml:"test_cmd"`
+	LintCmd        string `yaml:"lint_cmd"`
+	GuidelinesFile string `yaml:"guidelines_file"`
+	// ProtectedPaths are protected in addition to the bot's
+	ProtectedPaths []string `yaml:"protected_paths"`
+	// EnabledTools narrow the tools the bot enables to these, and
+	// DisabledTools disable more
+	EnabledTools  []string `yaml:"enabled_tools"`
+	DisabledTools []string `yaml:"disabled_tools"`
+}
+
+// ParseRepoConfig parses a repository configuration file. Unknown settings
+// are errors, so a misspelt one isn't silently ignored.
+func ParseRepoConfig(data []byte) (*RepoConfig, error) {
+	var rc RepoConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&rc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", RepoConfigFile, err)
+	}
+	if rc.GuidelinesFile != "" && !filepath.IsLocal(filepath.FromSlash(rc.GuidelinesFile)) {
+		return nil, fmt.Errorf("%s: guidelines_file must be a path inside the repository", RepoConfigFile)
+	}
+	return &rc, nil
+}
+
+// WithRepo returns a copy of c with the repository's configuration applied.
+func (c *Config) WithRepo(rc *RepoConfig) *Config {
+	merged := *c
+	merged.BuildCmd = cmp.Or(rc.BuildCmd, c.BuildCmd)
+	merged.TestCmd = cmp.Or(rc.TestCmd, c.TestCmd)
+	merged.LintCmd = cmp.Or(rc.LintCmd, c.LintCmd)
+	merged.GuidelinesFile = cmp.Or(rc.GuidelinesFile, c.GuidelinesFile)
+	merged.ProtectedPaths = union(c.ProtectedPaths, rc.ProtectedPaths)
+	merged.DisabledTools = union(c.DisabledTools, rc.DisabledTools)
+
+	switch {
+	case len(rc.EnabledTools) == 0:
+	case len(c.EnabledTools) == 0:
+		merged.EnabledTools = slices.Clone(rc.EnabledTools)
+	default:
+		// A repository can't enable tools the bot doesn't; when none are
+		// left, every tool the bot enables is disabled
+		var both []string
+		for _, name := range rc.EnabledTools {
+			if slices.Contains(c.EnabledTools, name) {
+				both = append(both, name)
+			}
+		}
+		if len(both) == 0 {
+			merged.DisabledTools = union(merged.DisabledTools, c.EnabledTools)
+		} else {
+			merged.EnabledTools = both
+		}
+	}
+	return &merged
+}
+
+// union returns the entries of a followed by those of b not in a.
+func union(a, b []string) []string {
+	merged := slices.Clone(a)
+	for _, entry := range b {
+		if !slices.Contains(merged, entry) {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
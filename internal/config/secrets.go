@@ -0,0 +1,70 @@
+// The resolution of settings that reference secrets.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/secrets"
+)
+
+// secretsTimeout bounds resolving all the secrets referenced by settings.
+const secretsTimeout = 30 * time.Second
+
+// secretSettings reads settings that may reference a secret instead of
+// holding it, e.g. "file:/run/secrets/slack_bot_token",
+// "vault:secret/data/stormstack#slack_bot_token" or
+// "aws-sm:stormstack/slack#bot_token".
+type secretSettings struct {
+	ctx      context.Context
+	v        *viper.Viper
+	resolver *secrets.Resolver
+	err      error
+}
+
+// newSecretSettings creates a reader of v's secret settings. Vault's token
+// may itself be read from a file.
+func newSecretSettings(ctx context.Context, v *viper.Viper) *secretSettings {
+	files := secrets.NewResolver()
+	files.Register("file", secrets.File{})
+	s := &secretSettings{ctx: ctx, v: v, resolver: files}
+
+	// Vault's own environment variables are used when ours are unset
+	vaultAddr := firstSet(v.GetString("VAULT_ADDR"), os.Getenv("VAULT_ADDR"))
+	vaultToken := firstSet(s.get("VAULT_TOKEN"), os.Getenv("VAULT_TOKEN"))
+
+	resolver := secrets.NewResolver()
+	resolver.Register("file", secrets.File{})
+	resolver.Register("vault", secrets.NewVault(vaultAddr, vaultToken, v.GetString("VAULT_NAMESPACE")))
+	resolver.Register("aws-sm", secrets.NewAWSSecretsManager())
+	s.resolver = resolver
+	return s
+}
+
+// get returns the value of the setting key, resolving the secret it
+// references if any. The first failure is kept in s.err.
+func (s *secretSettings) get(key string) string {
+	value, err := s.resolver.Resolve(s.ctx, s.v.GetString(key))
+	if err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("failed to resolve STORMSTACK_%s: %w", key, err)
+		}
+		return ""
+	}
+	return value
+}
+
+// firstSet returns the first of values that isn't empty.
+func firstSet(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Package conflicts provides early warning for conflicting bot pull requests.
+package conflicts
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PendingPR is a bot-authored pull request that has not been merged yet.
+type PendingPR struct {
+	Branch    string
+	Number    int
+	URL       string
+	ChannelID string
+	ThreadTS  string
+	CreatedAt time.Time
+}
+
+// Tracker records the bot's in-flight pull requests and the threads that own them.
+type Tracker struct {
+	mu  sync.RWMutex
+	prs map[string]PendingPR
+}
+
+// NewTracker creates a new pull request tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		prs: make(map[string]PendingPR),
+	}
+}
+
+// Track starts tracking a pull request, replacing any entry for the same branch.
+func (t *Tracker) Track(pr PendingPR) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pr.CreatedAt.IsZero() {
+		pr.CreatedAt = time.Now()
+	}
+	t.prs[pr.Branch] = pr
+}
+
+// Untrack stops tracking the pull request for a branch.
+func (t *Tracker) Untrack(branch string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.prs, branch)
+}
+
+// List returns the tracked pull requests, oldest first.
+func (t *Tracker) List() []PendingPR {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	prs := make([]PendingPR, 0, len(t.prs))
+	for _, pr := range t.prs {
+		prs = append(prs, pr)
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		return prs[i].CreatedAt.Before(prs[j].CreatedAt)
+	})
+	return prs
+}
+
+// Len returns the number of tracked pull requests.
+func (t *Tracker) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.prs)
+}
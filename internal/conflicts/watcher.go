@@ -0,0 +1,231 @@
+// A watcher that test-merges in-flight bot branches.
+
+package conflicts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// Notifier posts a message to a Slack thread.
+type Notifier func(channelID, threadTS, text string) error
+
+// conflict describes files that conflict between a pull request and another ref.
+type conflict struct {
+	// other is nil when the conflict is with the default branch
+	other *PendingPR
+	files []string
+}
+
+// Watcher test-merges tracked pull requests against each other and the
+// default branch, and warns the owning threads about upcoming conflicts.
+type Watcher struct {
+	tracker *Tracker
+	gitOps  git.Operations
+	forge   git.Forge
+	notify  Notifier
+	logger  *slog.Logger
+
+	// warned holds the last warning signature per branch to avoid repeats
+	warned map[string]string
+
+	// onClosed is called when a tracked pull request is no longer open
+	onClosed func(ctx context.Context, pr PendingPR)
+}
+
+// NewWatcher creates a new conflict watcher.
+func NewWatcher(
+	tracker *Tracker,
+	gitOps git.Operations,
+	forge git.Forge,
+	notify Notifier,
+	logger *slog.Logger,
+) *Watcher {
+	return &Watcher{
+		tracker: tracker,
+		gitOps:  gitOps,
+		forge:   forge,
+		notify:  notify,
+		logger:  logger,
+		warned:  make(map[string]string),
+	}
+}
+
+// OnClosed registers a callback invoked when a tracked pull request is closed or merged.
+func (w *Watcher) OnClosed(fn func(ctx context.Context, pr PendingPR)) {
+	w.onClosed = fn
+}
+
+// Check runs a single round of test merges and notifies affected threads.
+func (w *Watcher) Check(ctx context.Context) error {
+	w.pruneClosed(ctx)
+
+	prs := w.tracker.List()
+	if len(prs) == 0 {
+		return nil
+	}
+
+	if err := w.gitOps.Fetch(ctx); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	defaultBranch, err := w.gitOps.GetDefaultBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get default branch: %w", err)
+	}
+	baseRef := "origin/" + defaultBranch
+
+	found := make(map[string][]conflict)
+
+	// Test-merge each branch against the default branch
+	for _, pr := range prs {
+		files, err := w.gitOps.MergeConflicts(ctx, baseRef, "origin/"+pr.Branch)
+		if err != nil {
+			w.logger.Debug("test merge against default branch failed", "branch", pr.Branch, "error", err)
+			continue
+		}
+		if len(files) > 0 {
+			found[pr.Branch] = append(found[pr.Branch], conflict{files: files})
+		}
+	}
+
+	// Test-merge each pair of branches against each other
+	for i := range prs {
+		for j := i + 1; j < len(prs); j++ {
+			a, b := prs[i], prs[j]
+			files, err := w.gitOps.MergeConflicts(ctx, "origin/"+a.Branch, "origin/"+b.Branch)
+			if err != nil {
+				w.logger.Debug("test merge between branches failed", "a", a.Branch, "b", b.Branch, "error", err)
+				continue
+			}
+			if len(files) > 0 {
+				found[a.Branch] = append(found[a.Branch], conflict{other: &prs[j], files: files})
+				found[b.Branch] = append(found[b.Branch], conflict{other: &prs[i], files: files})
+			}
+		}
+	}
+
+	for _, pr := range prs {
+		conflicts := found[pr.Branch]
+		if len(conflicts) == 0 {
+			delete(w.warned, pr.Branch)
+			continue
+		}
+
+		signature := conflictSignature(conflicts)
+		if w.warned[pr.Branch] == signature {
+			continue
+		}
+
+		text := formatWarning(pr, conflicts, defaultBranch)
+		if err := w.notify(pr.ChannelID, pr.ThreadTS, text); err != nil {
+			w.logger.Warn("failed to post conflict warning", "branch", pr.Branch, "error", err)
+			continue
+		}
+		w.warned[pr.Branch] = signature
+	}
+
+	return nil
+}
+
+// pruneClosed stops tracking pull requests that are no longer open.
+func (w *Watcher) pruneClosed(ctx context.Context) {
+	open, err := w.forge.ListPRs(ctx, "open", 100)
+	if err != nil {
+		w.logger.Debug("failed to list open PRs, keeping all tracked branches", "error", err)
+		return
+	}
+
+	openBranches := make(map[string]bool, len(open))
+	for _, pr := range open {
+		openBranches[pr.HeadRef] = true
+	}
+
+	for _, pr := range w.tracker.List() {
+		if !openBranches[pr.Branch] {
+			w.tracker.Untrack(pr.Branch)
+			delete(w.warned, pr.Branch)
+			if w.onClosed != nil {
+				w.onClosed(ctx, pr)
+			}
+		}
+	}
+}
+
+// conflictSignature builds a stable key describing a set of conflicts.
+func conflictSignature(conflicts []conflict) string {
+	parts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		other := ""
+		if c.other != nil {
+			other = c.other.Branch
+		}
+		files := append([]string(nil), c.files...)
+		sort.Strings(files)
+		parts = append(parts, other+":"+strings.Join(files, ","))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// formatWarning formats a conflict warning for a pull request thread.
+func formatWarning(pr PendingPR, conflicts []conflict, defaultBranch string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf(":warning: *Merge conflict warning* for %s\n", prLabel(pr)))
+
+	// Oldest pull request first is the suggested merge order
+	order := []PendingPR{pr}
+	for _, c := range conflicts {
+		if c.other == nil {
+			sb.WriteString(fmt.Sprintf("• Conflicts with `%s` in: %s\n", defaultBranch, formatFiles(c.files)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("• Conflicts with %s in: %s\n", prLabel(*c.other), formatFiles(c.files)))
+		order = append(order, *c.other)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].CreatedAt.Before(order[j].CreatedAt)
+	})
+
+	if len(order) > 1 {
+		labels := make([]string, len(order))
+		for i, o := range order {
+			labels[i] = prLabel(o)
+		}
+		sb.WriteString(fmt.Sprintf("Suggested merge order: %s — rebase each later branch onto `%s` after the one before it lands.\n",
+			strings.Join(labels, " → "), defaultBranch))
+	} else {
+		sb.WriteString(fmt.Sprintf("Suggested fix: rebase `%s` onto `%s` and resolve the conflicts.\n", pr.Branch, defaultBranch))
+	}
+
+	return sb.String()
+}
+
+// prLabel returns a short Slack-formatted reference to a pull request.
+func prLabel(pr PendingPR) string {
+	if pr.URL != "" && pr.Number > 0 {
+		return fmt.Sprintf("<%s|#%d> (`%s`)", pr.URL, pr.Number, pr.Branch)
+	}
+	return fmt.Sprintf("`%s`", pr.Branch)
+}
+
+// formatFiles formats a list of conflicting files, truncating long lists.
+func formatFiles(files []string) string {
+	const maxFiles = 5
+
+	quoted := make([]string, 0, maxFiles)
+	for i, f := range files {
+		if i >= maxFiles {
+			quoted = append(quoted, fmt.Sprintf("and %d more", len(files)-maxFiles))
+			break
+		}
+		quoted = append(quoted, "`"+f+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
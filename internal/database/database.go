@@ -0,0 +1,204 @@
+// Package database provides read-only introspection of the application's
+// database (table schemas and query plans), so the bot can reason about the
+// queries and migrations in the repository.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// queryTimeout bounds a single introspection query.
+const queryTimeout = 15 * time.Second
+
+// Drivers the database can be reached with.
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Table is a table or view.
+type Table struct {
+	Schema string
+	Name   string
+	// Kind is "table" or "view"
+	Kind string
+}
+
+// DB is a read-only connection to a database. Every statement runs in a
+// read-only transaction, and only SELECT queries are explained.
+type DB struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// dialect is the driver-specific introspection.
+type dialect interface {
+	tables(ctx context.Context, tx *sql.Tx) ([]Table, error)
+	describe(ctx context.Context, tx *sql.Tx, table string) (string, error)
+}
+
+// Open connects to the database at dsn with driver and checks it answers.
+func Open(ctx context.Context, driver, dsn string) (*DB, error) {
+	var (
+		db *sql.DB
+		d  dialect
+	)
+	switch driver {
+	case DriverPostgres:
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse database DSN: %w", err)
+		}
+		db, d = stdlib.OpenDB(*cfg), postgres{}
+	case DriverMySQL:
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse database DSN: %w", err)
+		}
+		connector, err := mysql.NewConnector(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create database connector: %w", err)
+		}
+		db, d = sql.OpenDB(connector), mySQL{}
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+	// Introspection is occasional; don't hold connections the application needs
+	db.SetMaxOpenConns(2)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return &DB{db: db, dialect: d}, nil
+}
+
+// Close closes the connections.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Tables lists the tables and views of the application's schemas.
+func (d *DB) Tables(ctx context.Context) ([]Table, error) {
+	var tables []Table
+	err := d.readOnly(ctx, func(tx *sql.Tx) error {
+		var err error
+		tables, err = d.dialect.tables(ctx, tx)
+		return err
+	})
+	return tables, err
+}
+
+// Describe returns a table's columns, constraints and indexes. The table
+// may be qualified with its schema, e.g. "public.users".
+func (d *DB) Describe(ctx context.Context, table string) (string, error) {
+	if !validIdentifier(table) {
+		return "", fmt.Errorf("invalid table name %q", table)
+	}
+	var description string
+	err := d.readOnly(ctx, func(tx *sql.Tx) error {
+		var err error
+		description, err = d.dialect.describe(ctx, tx, table)
+		return err
+	})
+	return description, err
+}
+
+// Explain returns the planner's plan for a SELECT query, without running it.
+func (d *DB) Explain(ctx context.Context, query string) (string, error) {
+	query, err := CheckSelect(query)
+	if err != nil {
+		return "", err
+	}
+	var plan string
+	err = d.readOnly(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "EXPLAIN "+query)
+		if err != nil {
+			return fmt.Errorf("failed to explain query: %w", err)
+		}
+		defer rows.Close()
+		plan, err = formatRows(rows)
+		return err
+	})
+	return plan, err
+}
+
+// readOnly runs fn in a read-only transaction, which is always rolled back.
+func (d *DB) readOnly(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// formatRows renders a result set as tab-separated lines. A single column,
+// as in PostgreSQL plans, is rendered without a header.
+func formatRows(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("failed to read result columns: %w", err)
+	}
+	var sb strings.Builder
+	if len(columns) > 1 {
+		sb.WriteString(strings.Join(columns, "\t") + "\n")
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", fmt.Errorf("failed to read result: %w", err)
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = v.String
+			if !v.Valid {
+				cells[i] = "NULL"
+			}
+		}
+		sb.WriteString(strings.Join(cells, "\t") + "\n")
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read result: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// validIdentifier reports whether name is a plain, optionally
+// schema-qualified, table name.
+func validIdentifier(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+		for _, r := range part {
+			if !(r == '_' || r == '$' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+// The MySQL (and MariaDB) introspection.
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// mySQL introspects MySQL through information_schema and SHOW CREATE TABLE.
+type mySQL struct{}
+
+func (mySQL) tables(ctx context.Context, tx *sql.Tx) ([]Table, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT table_schema, table_name, CASE table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		ORDER BY table_name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Kind); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (mySQL) describe(ctx context.Context, tx *sql.Tx, table string) (string, error) {
+	// Identifiers can't be bound as parameters; validIdentifier has already
+	// limited the name to plain characters
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = "`" + part + "`"
+	}
+	rows, err := tx.QueryContext(ctx, "SHOW CREATE TABLE "+strings.Join(parts, "."))
+	if err != nil {
+		if strings.Contains(err.Error(), "doesn't exist") {
+			return "", fmt.Errorf("table %s not found", table)
+		}
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	// Tables return two columns and views four; the statement is the second
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	if !rows.Next() {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	if len(values) < 2 {
+		return "", fmt.Errorf("failed to describe %s: unexpected SHOW CREATE TABLE result", table)
+	}
+	return values[1].String + "\n", nil
+}
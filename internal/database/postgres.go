@@ -0,0 +1,122 @@
+// The PostgreSQL introspection.
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// postgres introspects PostgreSQL through its catalogs.
+type postgres struct{}
+
+func (postgres) tables(ctx context.Context, tx *sql.Tx) ([]Table, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT table_schema, table_name, CASE table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END
+		FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema') AND table_schema NOT LIKE 'pg_toast%'
+		ORDER BY table_schema, table_name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Kind); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (postgres) describe(ctx context.Context, tx *sql.Tx, table string) (string, error) {
+	var (
+		name    sql.NullString
+		estRows int64
+	)
+	// to_regclass resolves the name through the search path, and is NULL
+	// rather than an error for a missing table
+	err := tx.QueryRowContext(ctx, `
+		SELECT c.oid::regclass::text, GREATEST(c.reltuples, 0)::bigint
+		FROM pg_class c WHERE c.oid = to_regclass($1)`, table).Scan(&name, &estRows)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !name.Valid) {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Table %s (about %d rows)\n\nColumns:\n", name.String, estRows)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	for rows.Next() {
+		var (
+			column, typ string
+			notNull     bool
+			def         sql.NullString
+		)
+		if err := rows.Scan(&column, &typ, &notNull, &def); err != nil {
+			rows.Close()
+			return "", fmt.Errorf("failed to describe %s: %w", table, err)
+		}
+		fmt.Fprintf(&sb, "  %s %s", column, typ)
+		if notNull {
+			sb.WriteString(" NOT NULL")
+		}
+		if def.Valid {
+			sb.WriteString(" DEFAULT " + def.String)
+		}
+		sb.WriteString("\n")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+
+	sections := []struct{ title, query string }{
+		{"Constraints", `SELECT conname || ' ' || pg_get_constraintdef(oid) FROM pg_constraint WHERE conrelid = to_regclass($1) ORDER BY conname`},
+		{"Indexes", `SELECT pg_get_indexdef(indexrelid) FROM pg_index WHERE indrelid = to_regclass($1) ORDER BY indexrelid::regclass::text`},
+	}
+	for _, section := range sections {
+		lines, err := queryStrings(ctx, tx, section.query, table)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe %s: %w", table, err)
+		}
+		if len(lines) > 0 {
+			sb.WriteString("\n" + section.title + ":\n  " + strings.Join(lines, "\n  ") + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// queryStrings returns the single text column of a query's rows.
+func queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,97 @@
+// The SELECT-only check of explained queries.
+
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// forbiddenKeywords can't appear in an explained query, even inside a CTE:
+// they write data, change the schema, take locks or create tables.
+var forbiddenKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true,
+	"TRUNCATE": true, "DROP": true, "ALTER": true, "CREATE": true,
+	"GRANT": true, "REVOKE": true, "INTO": true, "LOCK": true, "CALL": true,
+	"EXECUTE": true, "COPY": true,
+}
+
+// CheckSelect checks query is a single SELECT statement (possibly with a
+// WITH clause) that reads nothing but data, and returns it without a
+// trailing semicolon. The read-only transaction it runs in is the second
+// line of defence.
+func CheckSelect(query string) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	words, semicolon := keywords(query)
+	if semicolon {
+		return "", fmt.Errorf("only a single statement can be explained")
+	}
+	if len(words) == 0 || (words[0] != "SELECT" && words[0] != "WITH") {
+		return "", fmt.Errorf("only SELECT queries can be explained")
+	}
+	for _, word := range words {
+		if forbiddenKeywords[word] {
+			return "", fmt.Errorf("only read-only SELECT queries can be explained, found %s", word)
+		}
+	}
+	return query, nil
+}
+
+// keywords returns the upper-cased words of query outside string literals,
+// quoted identifiers and comments, and whether a semicolon separates
+// statements. Backslash escapes and MySQL's # comments aren't recognised, so
+// a query either dialect reads differently is rejected rather than let
+// through.
+func keywords(query string) ([]string, bool) {
+	var (
+		words     []string
+		word      strings.Builder
+		semicolon bool
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			flush()
+			// Skip to the closing quote; doubled quotes escape themselves
+			for i++; i < len(query); i++ {
+				if query[i] != c {
+					continue
+				}
+				if i+1 < len(query) && query[i+1] == c {
+					i++
+					continue
+				}
+				break
+			}
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			flush()
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			flush()
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush()
+			semicolon = true
+		case c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			word.WriteByte(c)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words, semicolon
+}
@@ -0,0 +1,110 @@
+// Package eval provides a quality evaluation harness that replays recorded
+// task fixtures through the conversation pipeline.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Check types supported in fixtures.
+const (
+	CheckResponseContains    = "response_contains"
+	CheckResponseNotContains = "response_not_contains"
+	CheckFileContains        = "file_contains"
+	CheckFileNotContains     = "file_not_contains"
+	CheckFileExists          = "file_exists"
+	CheckFileNotExists       = "file_not_exists"
+	CheckToolCalled          = "tool_called"
+	CheckToolNotCalled       = "tool_not_called"
+	CheckCommandSucceeds     = "command_succeeds"
+)
+
+// Fixture is a recorded task: the user's messages, the repository state they
+// were sent against, and checks describing the expected outcome.
+type Fixture struct {
+	// Name identifies the fixture (defaults to its directory name)
+	Name string `json:"name"`
+	// Description explains what the fixture exercises
+	Description string `json:"description"`
+	// Messages are sent in order, as one Slack thread
+	Messages []string `json:"messages"`
+	// Checks are evaluated after the last message
+	Checks []Check `json:"checks"`
+
+	// dir is the fixture directory; its testdata/ subdirectory is the starting repo state
+	dir string
+}
+
+// Check is a single expected-outcome assertion.
+type Check struct {
+	Type  string `json:"type"`
+	Path  string `json:"path,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// String returns a short description of the check.
+func (c Check) String() string {
+	switch {
+	case c.Path != "" && c.Value != "":
+		return fmt.Sprintf("%s %s %q", c.Type, c.Path, c.Value)
+	case c.Path != "":
+		return fmt.Sprintf("%s %s", c.Type, c.Path)
+	default:
+		return fmt.Sprintf("%s %q", c.Type, c.Value)
+	}
+}
+
+// RepoDir returns the directory holding the fixture's starting repository
+// state. It is named testdata so the go tool doesn't build fixture code as
+// part of this module.
+func (f *Fixture) RepoDir() string {
+	return filepath.Join(f.dir, "testdata")
+}
+
+// LoadFixtures loads every fixture.json found in the immediate subdirectories of dir.
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures directory: %w", err)
+	}
+
+	var fixtures []*Fixture
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		fixtureDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(fixtureDir, "fixture.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", entry.Name(), err)
+		}
+
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", entry.Name(), err)
+		}
+		if fixture.Name == "" {
+			fixture.Name = entry.Name()
+		}
+		if len(fixture.Messages) == 0 {
+			return nil, fmt.Errorf("fixture %s has no messages", fixture.Name)
+		}
+		fixture.dir = fixtureDir
+
+		fixtures = append(fixtures, &fixture)
+	}
+
+	sort.Slice(fixtures, func(i, j int) bool {
+		return fixtures[i].Name < fixtures[j].Name
+	})
+
+	return fixtures, nil
+}
@@ -0,0 +1,324 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/conflicts"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/slack"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+const (
+	// evalUserID and evalChannelID stand in for the Slack user and channel.
+	evalUserID    = "UEVAL"
+	evalChannelID = "CEVAL"
+
+	// commandTimeout bounds command_succeeds checks.
+	commandTimeout = 5 * time.Minute
+)
+
+// Result is the outcome of running a single fixture.
+type Result struct {
+	Fixture   string        `json:"fixture"`
+	Passed    int           `json:"passed"`
+	Total     int           `json:"total"`
+	Failures  []string      `json:"failures,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	ToolCalls []string      `json:"tool_calls"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Score returns the fraction of checks that passed.
+func (r Result) Score() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Total)
+}
+
+// Report is the outcome of an evaluation run.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Score returns the fraction of all checks that passed.
+func (r *Report) Score() float64 {
+	passed, total := 0, 0
+	for _, res := range r.Results {
+		passed += res.Passed
+		total += res.Total
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(passed) / float64(total)
+}
+
+// Regressions lists fixtures whose score dropped compared to a baseline report.
+func (r *Report) Regressions(baseline *Report) []string {
+	previous := make(map[string]Result, len(baseline.Results))
+	for _, res := range baseline.Results {
+		previous[res.Fixture] = res
+	}
+
+	var regressions []string
+	for _, res := range r.Results {
+		prev, ok := previous[res.Fixture]
+		if ok && res.Score() < prev.Score() {
+			regressions = append(regressions, fmt.Sprintf("%s: %.0f%% → %.0f%%", res.Fixture, prev.Score()*100, res.Score()*100))
+		}
+	}
+	return regressions
+}
+
+// LoadReport reads a report previously written with SaveReport.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// SaveReport writes a report as JSON.
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// FormatReport formats a report as a plain-text table.
+func FormatReport(report *Report) string {
+	var sb strings.Builder
+
+	for _, res := range report.Results {
+		status := "PASS"
+		if res.Error != "" {
+			status = "ERROR"
+		} else if res.Passed < res.Total {
+			status = "FAIL"
+		}
+		sb.WriteString(fmt.Sprintf("%-5s %-40s %d/%d checks  %d tool calls  %s\n",
+			status, res.Fixture, res.Passed, res.Total, len(res.ToolCalls), res.Duration.Round(time.Second)))
+		if res.Error != "" {
+			sb.WriteString("      error: " + res.Error + "\n")
+		}
+		for _, f := range res.Failures {
+			sb.WriteString("      ✗ " + f + "\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nOverall score: %.1f%% across %d fixtures\n", report.Score()*100, len(report.Results)))
+	return sb.String()
+}
+
+// Runner replays fixtures through the Slack handler and conversation pipeline,
+// standing in for Slack itself.
+type Runner struct {
+	cfg    *config.Config
+	logger *slog.Logger
+}
+
+// NewRunner creates a new evaluation runner.
+func NewRunner(cfg *config.Config, logger *slog.Logger) *Runner {
+	return &Runner{cfg: cfg, logger: logger}
+}
+
+// Run runs each fixture in order and returns the report.
+func (r *Runner) Run(ctx context.Context, fixtures []*Fixture) *Report {
+	report := &Report{}
+	for _, f := range fixtures {
+		r.logger.Info("running eval fixture", "fixture", f.Name)
+		report.Results = append(report.Results, r.runFixture(ctx, f))
+	}
+	return report
+}
+
+// runFixture runs a single fixture in a scratch copy of its repository.
+func (r *Runner) runFixture(ctx context.Context, f *Fixture) Result {
+	start := time.Now()
+	result := Result{Fixture: f.Name, Total: len(f.Checks)}
+	defer func() { result.Duration = time.Since(start) }()
+
+	repoPath, err := os.MkdirTemp("", "stormstack-eval-*")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer os.RemoveAll(repoPath)
+
+	if err := prepareRepo(f.RepoDir(), repoPath); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	lessons, _ := storage.NewFileLessonStore("")
+	handler, err := slack.NewHandler(r.cfg, repoPath, storage.NewMemoryStore(), lessons, conflicts.NewTracker(), nil, nil, r.logger)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var mu sync.Mutex
+	handler.ObserveTools(func(name string, input json.RawMessage, output string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.ToolCalls = append(result.ToolCalls, name)
+	})
+
+	var response string
+	for _, text := range f.Messages {
+		out, err := handler.HandleMessage(ctx, &slack.IncomingMessage{
+			Text:      text,
+			UserID:    evalUserID,
+			ChannelID: evalChannelID,
+			ThreadTS:  "eval-" + f.Name,
+		})
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		response = out.Text
+	}
+
+	for _, check := range f.Checks {
+		if err := evaluate(ctx, check, response, repoPath, result.ToolCalls); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", check, err))
+			continue
+		}
+		result.Passed++
+	}
+
+	return result
+}
+
+// evaluate runs a single check, returning an error describing any failure.
+func evaluate(ctx context.Context, check Check, response, repoPath string, toolCalls []string) error {
+	switch check.Type {
+	case CheckResponseContains:
+		if !strings.Contains(strings.ToLower(response), strings.ToLower(check.Value)) {
+			return fmt.Errorf("response does not contain it")
+		}
+	case CheckResponseNotContains:
+		if strings.Contains(strings.ToLower(response), strings.ToLower(check.Value)) {
+			return fmt.Errorf("response contains it")
+		}
+	case CheckFileContains, CheckFileNotContains:
+		data, err := os.ReadFile(filepath.Join(repoPath, check.Path))
+		if err != nil {
+			return err
+		}
+		contains := strings.Contains(string(data), check.Value)
+		if check.Type == CheckFileContains && !contains {
+			return fmt.Errorf("file does not contain it")
+		}
+		if check.Type == CheckFileNotContains && contains {
+			return fmt.Errorf("file contains it")
+		}
+	case CheckFileExists:
+		if _, err := os.Stat(filepath.Join(repoPath, check.Path)); err != nil {
+			return fmt.Errorf("file does not exist")
+		}
+	case CheckFileNotExists:
+		if _, err := os.Stat(filepath.Join(repoPath, check.Path)); err == nil {
+			return fmt.Errorf("file exists")
+		}
+	case CheckToolCalled, CheckToolNotCalled:
+		called := false
+		for _, name := range toolCalls {
+			if name == check.Value {
+				called = true
+				break
+			}
+		}
+		if check.Type == CheckToolCalled && !called {
+			return fmt.Errorf("tool was not called")
+		}
+		if check.Type == CheckToolNotCalled && called {
+			return fmt.Errorf("tool was called")
+		}
+	case CheckCommandSucceeds:
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", check.Value)
+		cmd.Dir = repoPath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+	default:
+		return fmt.Errorf("unknown check type")
+	}
+	return nil
+}
+
+// prepareRepo copies the fixture repository into dst and commits it so git
+// tools see a clean working tree.
+func prepareRepo(src, dst string) error {
+	if _, err := os.Stat(src); err == nil {
+		if err := copyDir(src, dst); err != nil {
+			return fmt.Errorf("failed to copy fixture repo: %w", err)
+		}
+	}
+
+	commands := [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "-A"},
+		{"-c", "user.name=StormStack Eval", "-c", "user.email=eval@stormstack.dev",
+			"commit", "-q", "--allow-empty", "-m", "Fixture baseline"},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dst
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed: %w\n%s", args[0], err, string(output))
+		}
+	}
+	return nil
+}
+
+// copyDir recursively copies a directory tree.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,290 @@
+// Capture of the reports builds and tests generate.
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	// maxArtifactRuns bounds the runs kept per conversation; the oldest are removed first
+	maxArtifactRuns = 20
+	// maxArtifactSize bounds the size of a collected file
+	maxArtifactSize = 20 * 1024 * 1024
+	// maxArtifactsPerRun bounds the files collected from one run
+	maxArtifactsPerRun = 200
+)
+
+// DefaultArtifactPatterns match the test and coverage reports of common
+// build tools, relative to the repository root.
+var DefaultArtifactPatterns = []string{
+	"**/target/surefire-reports/*.xml",
+	"**/target/failsafe-reports/*.xml",
+	"**/target/site/jacoco/index.html",
+	"**/build/test-results/**/*.xml",
+	"**/build/reports/tests/**/index.html",
+	"**/build/reports/jacoco/**/index.html",
+	"**/junit*.xml",
+	"**/test-results*.xml",
+	"**/coverage/index.html",
+	"**/coverage/lcov.info",
+	"**/coverage.xml",
+	"**/coverage.out",
+	"**/htmlcov/index.html",
+}
+
+// skippedArtifactDirs are never searched for artifacts.
+var skippedArtifactDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+// Artifact is a report file collected from a build or test run.
+type Artifact struct {
+	// Name is the file's path relative to the repository
+	Name string
+	// Path is where the collected copy is stored
+	Path string
+	Size int64
+	// Failed is set for test reports recording failures or errors
+	Failed bool
+}
+
+// ArtifactStore keeps copies of the reports generated by build and test
+// runs, grouped by conversation and run. A nil ArtifactStore collects
+// nothing.
+type ArtifactStore struct {
+	dir      string
+	patterns []string
+}
+
+// NewArtifactStore creates an artifact store in dir collecting the files
+// matching patterns (DefaultArtifactPatterns when empty), or returns nil if
+// dir is empty.
+func NewArtifactStore(dir string, patterns []string) *ArtifactStore {
+	if dir == "" {
+		return nil
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultArtifactPatterns
+	}
+	return &ArtifactStore{dir: dir, patterns: patterns}
+}
+
+// Collect copies the files under repoPath matching the store's patterns
+// that were written since the run started into a new run for a
+// conversation. It returns the run's ID and its artifacts, or no artifacts
+// when the run generated none.
+func (s *ArtifactStore) Collect(repoPath, conversation string, since time.Time) (string, []Artifact, error) {
+	if s == nil {
+		return "", nil, nil
+	}
+
+	// Allow for file systems with coarse modification times
+	since = since.Add(-2 * time.Second)
+	var matched []string
+	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skippedArtifactDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(matched) >= maxArtifactsPerRun {
+			return filepath.SkipAll
+		}
+
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil || !s.matches(filepath.ToSlash(rel)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().Before(since) || info.Size() > maxArtifactSize {
+			return nil
+		}
+		matched = append(matched, rel)
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to search for artifacts: %w", err)
+	}
+	if len(matched) == 0 {
+		return "", nil, nil
+	}
+
+	run := time.Now().UTC().Format("20060102-150405.000")
+	runDir := filepath.Join(s.conversationDir(conversation), run)
+	var artifacts []Artifact
+	for _, rel := range matched {
+		artifact, err := copyArtifact(filepath.Join(repoPath, rel), filepath.Join(runDir, rel))
+		if err != nil {
+			return "", nil, err
+		}
+		artifact.Name = filepath.ToSlash(rel)
+		artifacts = append(artifacts, artifact)
+	}
+
+	s.prune(conversation)
+	return run, artifacts, nil
+}
+
+// matches reports whether a repository path matches an artifact pattern.
+func (s *ArtifactStore) matches(rel string) bool {
+	for _, pattern := range s.patterns {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// copyArtifact copies a file into the store.
+func copyArtifact(src, dst string) (Artifact, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return Artifact{}, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to create artifact: %w", err)
+	}
+	defer out.Close()
+
+	size, err := io.Copy(out, in)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to copy artifact: %w", err)
+	}
+	return Artifact{Path: dst, Size: size, Failed: reportsFailure(dst)}, nil
+}
+
+// reportsFailure reports whether a file is a JUnit XML report recording a
+// failed or erroring test.
+func reportsFailure(path string) bool {
+	if !strings.HasSuffix(path, ".xml") {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	text := string(data)
+	return strings.Contains(text, "<testsuite") && (strings.Contains(text, "<failure") || strings.Contains(text, "<error"))
+}
+
+// Runs returns a conversation's runs with artifacts, newest first.
+func (s *ArtifactStore) Runs(conversation string) ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(s.conversationDir(conversation))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifact runs: %w", err)
+	}
+
+	var runs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			runs = append(runs, entry.Name())
+		}
+	}
+	// Run IDs are timestamps, so they sort chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(runs)))
+	return runs, nil
+}
+
+// List returns the artifacts of one of a conversation's runs.
+func (s *ArtifactStore) List(conversation, run string) ([]Artifact, error) {
+	runDir, err := s.runDir(conversation, run)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []Artifact
+	err = filepath.WalkDir(runDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(runDir, path)
+		artifacts = append(artifacts, Artifact{
+			Name:   filepath.ToSlash(rel),
+			Path:   path,
+			Size:   info.Size(),
+			Failed: reportsFailure(path),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+// Get returns one of the artifacts of a conversation's run.
+func (s *ArtifactStore) Get(conversation, run, name string) (Artifact, error) {
+	artifacts, err := s.List(conversation, run)
+	if err != nil {
+		return Artifact{}, err
+	}
+	for _, artifact := range artifacts {
+		if artifact.Name == name {
+			return artifact, nil
+		}
+	}
+	return Artifact{}, fmt.Errorf("no artifact %s in run %s", name, run)
+}
+
+// runDir returns the directory of a conversation's run, checking it exists.
+func (s *ArtifactStore) runDir(conversation, run string) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("artifact capture is disabled (set STORMSTACK_ARTIFACTS_DIR)")
+	}
+	if run == "" || strings.ContainsAny(run, `/\`) || strings.HasPrefix(run, ".") {
+		return "", fmt.Errorf("invalid artifact run %q", run)
+	}
+	dir := filepath.Join(s.conversationDir(conversation), run)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("no artifact run %s in this conversation", run)
+	}
+	return dir, nil
+}
+
+// conversationDir returns the directory holding a conversation's runs.
+func (s *ArtifactStore) conversationDir(conversation string) string {
+	if conversation == "" {
+		conversation = "default"
+	}
+	return filepath.Join(s.dir, strings.NewReplacer("/", "_", `\`, "_", "..", "_").Replace(conversation))
+}
+
+// prune removes a conversation's oldest runs beyond maxArtifactRuns.
+func (s *ArtifactStore) prune(conversation string) {
+	runs, err := s.Runs(conversation)
+	if err != nil || len(runs) <= maxArtifactRuns {
+		return
+	}
+	for _, run := range runs[maxArtifactRuns:] {
+		os.RemoveAll(filepath.Join(s.conversationDir(conversation), run))
+	}
+}
@@ -0,0 +1,197 @@
+// Custom tools a repository defines as shell commands in its tools file.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// customToolNameRe matches the names tools may have.
+var customToolNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// placeholderRe matches the {param} placeholders of a command template.
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Permissions a custom tool may declare.
+const (
+	CustomToolRead     = "read"
+	CustomToolWrite    = "write"
+	CustomToolApproval = "approval"
+)
+
+// CustomTool is a tool a repository defines in its tools file: a shell
+// command run in the repository with Claude's input substituted.
+type CustomTool struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Permission is read, write (the default) or approval
+	Permission string `yaml:"permission"`
+	// InputSchema is the JSON schema of the input, an object whose
+	// properties are strings, numbers or booleans
+	InputSchema map[string]any `yaml:"input_schema"`
+	// Command is run with sh; each {param} is replaced by the shell-quoted
+	// value of the input property, or nothing when it is absent, so
+	// placeholders may not be put inside quotes
+	Command string `yaml:"command"`
+}
+
+// customToolsFile is the layout of a tools file.
+type customToolsFile struct {
+	Tools []CustomTool `yaml:"tools"`
+}
+
+// ParseCustomTools parses the custom tools defined in data, the content of
+// the YAML tools file at path. An empty file defines none.
+func ParseCustomTools(data []byte, path string) ([]CustomTool, error) {
+	var file customToolsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tools file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range file.Tools {
+		t := &file.Tools[i]
+		if !customToolNameRe.MatchString(t.Name) {
+			return nil, fmt.Errorf("tools file %s: invalid tool name %q, use lowercase letters, digits and underscores", path, t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("tools file %s: tool %s is defined twice", path, t.Name)
+		}
+		seen[t.Name] = true
+		if t.Description == "" || strings.TrimSpace(t.Command) == "" {
+			return nil, fmt.Errorf("tool %s: every tool needs a description and a command", t.Name)
+		}
+		switch t.Permission {
+		case "":
+			t.Permission = CustomToolWrite
+		case CustomToolRead, CustomToolWrite, CustomToolApproval:
+		default:
+			return nil, fmt.Errorf("tool %s: invalid permission %q, must be read, write or approval", t.Name, t.Permission)
+		}
+		if t.InputSchema == nil {
+			t.InputSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		if kind, _ := t.InputSchema["type"].(string); kind != "" && kind != "object" {
+			return nil, fmt.Errorf("tool %s: the input schema must be an object", t.Name)
+		}
+		if name := quotedPlaceholder(t.Command, t.Properties()); name != "" {
+			return nil, fmt.Errorf("tool %s: placeholder {%s} is inside quotes; leave placeholders unquoted, their values are quoted when substituted", t.Name, name)
+		}
+	}
+	return file.Tools, nil
+}
+
+// Properties returns the properties of the tool's input schema.
+func (t CustomTool) Properties() map[string]any {
+	properties, _ := t.InputSchema["properties"].(map[string]any)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	return properties
+}
+
+// Required returns the names of the input properties the tool needs.
+func (t CustomTool) Required() []string {
+	list, _ := t.InputSchema["required"].([]any)
+	required := make([]string, 0, len(list))
+	for _, name := range list {
+		if s, ok := name.(string); ok {
+			required = append(required, s)
+		}
+	}
+	return required
+}
+
+// CommandFor returns the command to run for a call's input: the template
+// with every placeholder replaced by the shell-quoted value of its property.
+// It fails when a required property is missing, a property isn't in the
+// schema or a value isn't a string, number or boolean.
+func (t CustomTool) CommandFor(input map[string]any) (string, error) {
+	properties := t.Properties()
+	var problems []string
+	for _, name := range t.Required() {
+		if _, ok := input[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required parameter %q", name))
+		}
+	}
+	values := make(map[string]string, len(input))
+	for name, value := range input {
+		if _, ok := properties[name]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown parameter %q", name))
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			values[name] = v
+		case float64, bool:
+			values[name] = fmt.Sprint(v)
+		case nil:
+		default:
+			problems = append(problems, fmt.Sprintf("parameter %q must be a string, number or boolean", name))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return "", fmt.Errorf("invalid input for %s: %s", t.Name, strings.Join(problems, "; "))
+	}
+
+	command := placeholderRe.ReplaceAllStringFunc(t.Command, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if _, ok := properties[name]; !ok {
+			// Braces that don't name a property, e.g. ${HOME}, are left alone
+			return placeholder
+		}
+		value, ok := values[name]
+		if !ok {
+			return ""
+		}
+		return shellQuote(value)
+	})
+	return command, nil
+}
+
+// quotedPlaceholder returns the name of the first placeholder of properties
+// that sits inside single or double quotes in command, or "" when none does.
+// A quoted value substituted there would end the quotes around it and be read
+// as shell code.
+func quotedPlaceholder(command string, properties map[string]any) string {
+	// quoted[i] reports whether command[i] is inside quotes
+	quoted := make([]bool, len(command))
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote != 0 && c == quote:
+			quote = 0
+		case c == '\\' && quote != '\'' && i+1 < len(command):
+			quoted[i] = quote != 0
+			i++
+		}
+		if i < len(command) {
+			quoted[i] = quote != 0
+		}
+	}
+
+	for _, match := range placeholderRe.FindAllStringSubmatchIndex(command, -1) {
+		name := command[match[2]:match[3]]
+		if _, ok := properties[name]; ok && quoted[match[0]] {
+			return name
+		}
+	}
+	return ""
+}
+
+// RunCustomTool runs a command built by CustomTool.CommandFor. Tools files
+// are written by the repository's maintainers, so the command isn't checked
+// against the allowlist Claude's own commands are.
+func (r *Runner) RunCustomTool(ctx context.Context, command string) (*CommandResult, error) {
+	return r.executeCommand(ctx, command)
+}
@@ -0,0 +1,268 @@
+// Dependency inspection and updates per package manager.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ecosystems the dependency tools support.
+const (
+	EcosystemGo     = "go"
+	EcosystemMaven  = "maven"
+	EcosystemGradle = "gradle"
+	EcosystemNpm    = "npm"
+	EcosystemPnpm   = "pnpm"
+	EcosystemYarn   = "yarn"
+	EcosystemCargo  = "cargo"
+	EcosystemPoetry = "poetry"
+	EcosystemUv     = "uv"
+	EcosystemPip    = "pip"
+)
+
+// dependencyTokenRe matches the dependency names and versions that may be
+// put into a command: no spaces, shell metacharacters or leading dashes.
+var dependencyTokenRe = regexp.MustCompile(`^[A-Za-z0-9@^~][A-Za-z0-9@/._:+~^-]*$`)
+
+// Ecosystem is a package manager whose dependencies can be listed, checked
+// for updates and bumped, with the commands that do so in a repository.
+type Ecosystem struct {
+	Name string
+	// Manifests are the files that declare the dependencies, e.g. go.mod
+	Manifests []string
+	// tool is the command the package manager runs as, e.g. ./mvnw
+	tool string
+}
+
+// ecosystemMarkers maps files at a repository's root to the ecosystems they
+// belong to, most specific first.
+var ecosystemMarkers = []struct {
+	file      string
+	ecosystem string
+}{
+	{"go.mod", EcosystemGo},
+	{"pom.xml", EcosystemMaven},
+	{"build.gradle.kts", EcosystemGradle},
+	{"build.gradle", EcosystemGradle},
+	{"pnpm-lock.yaml", EcosystemPnpm},
+	{"yarn.lock", EcosystemYarn},
+	{"package.json", EcosystemNpm},
+	{"Cargo.toml", EcosystemCargo},
+	{"poetry.lock", EcosystemPoetry},
+	{"uv.lock", EcosystemUv},
+	{"requirements.txt", EcosystemPip},
+	{"pyproject.toml", EcosystemPip},
+}
+
+// ecosystemManifests are the files a dependency bump is expected to change.
+var ecosystemManifests = map[string][]string{
+	EcosystemGo:     {"go.mod", "go.sum"},
+	EcosystemMaven:  {"pom.xml"},
+	EcosystemGradle: {"build.gradle", "build.gradle.kts", "gradle/libs.versions.toml"},
+	EcosystemNpm:    {"package.json", "package-lock.json"},
+	EcosystemPnpm:   {"package.json", "pnpm-lock.yaml"},
+	EcosystemYarn:   {"package.json", "yarn.lock"},
+	EcosystemCargo:  {"Cargo.toml", "Cargo.lock"},
+	EcosystemPoetry: {"pyproject.toml", "poetry.lock"},
+	EcosystemUv:     {"pyproject.toml", "uv.lock"},
+	EcosystemPip:    {"requirements.txt", "pyproject.toml"},
+}
+
+// DetectEcosystems returns the ecosystems of the package manifests at the
+// root of repoPath, in the order of ecosystemMarkers. A Node or Python
+// project is reported once, under the package manager its lockfile belongs to.
+func DetectEcosystems(repoPath string) []Ecosystem {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(repoPath, name))
+		return err == nil
+	}
+
+	var ecosystems []Ecosystem
+	seen := make(map[string]bool)
+	node, python := false, false
+	for _, marker := range ecosystemMarkers {
+		if seen[marker.ecosystem] || !exists(marker.file) {
+			continue
+		}
+		switch marker.ecosystem {
+		case EcosystemNpm, EcosystemPnpm, EcosystemYarn:
+			if node {
+				continue
+			}
+			node = true
+		case EcosystemPoetry, EcosystemUv, EcosystemPip:
+			if python {
+				continue
+			}
+			python = true
+		}
+		seen[marker.ecosystem] = true
+
+		tool := marker.ecosystem
+		switch {
+		case marker.ecosystem == EcosystemMaven:
+			tool = "mvn -B"
+			if exists("mvnw") {
+				tool = "./mvnw -B"
+			}
+		case marker.ecosystem == EcosystemGradle && exists("gradlew"):
+			tool = "./gradlew"
+		case marker.ecosystem == EcosystemPip:
+			tool = "python -m pip"
+		}
+		ecosystems = append(ecosystems, Ecosystem{
+			Name:      marker.ecosystem,
+			Manifests: ecosystemManifests[marker.ecosystem],
+			tool:      tool,
+		})
+	}
+	return ecosystems
+}
+
+// SelectEcosystem returns the ecosystem named name among those detected in
+// repoPath, or the first detected when name is empty.
+func SelectEcosystem(repoPath, name string) (Ecosystem, error) {
+	ecosystems := DetectEcosystems(repoPath)
+	if len(ecosystems) == 0 {
+		return Ecosystem{}, fmt.Errorf("no supported package manifest (go.mod, pom.xml, build.gradle, package.json, Cargo.toml, pyproject.toml, requirements.txt) found at the repository root")
+	}
+	if name == "" {
+		return ecosystems[0], nil
+	}
+
+	var names []string
+	for _, ecosystem := range ecosystems {
+		if ecosystem.Name == name {
+			return ecosystem, nil
+		}
+		names = append(names, ecosystem.Name)
+	}
+	return Ecosystem{}, fmt.Errorf("the repository has no %s project; found %s", name, strings.Join(names, ", "))
+}
+
+// ListCommand returns the command listing the project's direct
+// dependencies, or all of them when transitive is set.
+func (e Ecosystem) ListCommand(transitive bool) string {
+	switch e.Name {
+	case EcosystemGo:
+		if transitive {
+			return "go list -m all"
+		}
+		return `go list -m -f '{{if not .Indirect}}{{.Path}} {{.Version}}{{end}}' all`
+	case EcosystemMaven:
+		if transitive {
+			return e.tool + " dependency:tree"
+		}
+		return e.tool + " dependency:list -DexcludeTransitive=true"
+	case EcosystemGradle:
+		return e.tool + " dependencies --configuration runtimeClasspath"
+	case EcosystemNpm:
+		if transitive {
+			return "npm ls --all"
+		}
+		return "npm ls --depth=0"
+	case EcosystemYarn:
+		if transitive {
+			return "yarn list"
+		}
+		return "yarn list --depth=0"
+	case EcosystemPnpm:
+		if transitive {
+			return "pnpm ls --depth Infinity"
+		}
+		return "pnpm ls --depth 0"
+	case EcosystemCargo:
+		if transitive {
+			return "cargo tree"
+		}
+		return "cargo tree --depth 1"
+	case EcosystemPoetry:
+		if transitive {
+			return "poetry show"
+		}
+		return "poetry show --top-level"
+	case EcosystemUv:
+		if transitive {
+			return "uv tree"
+		}
+		return "uv tree --depth 1"
+	default:
+		if transitive {
+			return e.tool + " list"
+		}
+		return e.tool + " list --not-required"
+	}
+}
+
+// OutdatedCommand returns the command listing the direct dependencies with
+// newer versions available.
+func (e Ecosystem) OutdatedCommand() string {
+	switch e.Name {
+	case EcosystemGo:
+		return `go list -m -u -f '{{if and .Update (not .Indirect)}}{{.Path}} {{.Version}} -> {{.Update.Version}}{{end}}' all`
+	case EcosystemMaven:
+		return e.tool + " versions:display-dependency-updates"
+	case EcosystemGradle:
+		// Needs the com.github.ben-manes.versions plugin
+		return e.tool + " dependencyUpdates"
+	case EcosystemCargo:
+		// Needs cargo-outdated
+		return "cargo outdated --root-deps-only"
+	case EcosystemPoetry:
+		return "poetry show --outdated --top-level"
+	case EcosystemUv:
+		return "uv tree --outdated --depth 1"
+	case EcosystemPip:
+		return e.tool + " list --outdated --not-required"
+	default:
+		return e.tool + " outdated"
+	}
+}
+
+// BumpCommand returns the command updating the dependency name to version
+// in the manifest and lockfile. Maven dependencies are named
+// groupId:artifactId; Go versions may omit the leading v.
+func (e Ecosystem) BumpCommand(name, version string) (string, error) {
+	for _, token := range []string{name, version} {
+		if !dependencyTokenRe.MatchString(token) {
+			return "", fmt.Errorf("invalid dependency name or version %q", token)
+		}
+	}
+
+	switch e.Name {
+	case EcosystemGo:
+		if version[0] >= '0' && version[0] <= '9' {
+			version = "v" + version
+		}
+		return fmt.Sprintf("go get %s@%s && go mod tidy", name, version), nil
+	case EcosystemMaven:
+		if strings.Count(name, ":") != 1 {
+			return "", fmt.Errorf("maven dependencies are named groupId:artifactId, e.g. com.google.guava:guava")
+		}
+		return fmt.Sprintf("%s versions:use-dep-version -Dincludes=%s -DdepVersion=%s -DforceVersion=true -DgenerateBackupPoms=false",
+			e.tool, name, version), nil
+	case EcosystemNpm:
+		return fmt.Sprintf("npm install %s@%s", name, version), nil
+	case EcosystemPnpm, EcosystemYarn:
+		return fmt.Sprintf("%s add %s@%s", e.tool, name, version), nil
+	case EcosystemCargo:
+		return fmt.Sprintf("cargo add %s@%s", name, version), nil
+	case EcosystemPoetry:
+		return fmt.Sprintf("poetry add %s@%s", name, version), nil
+	case EcosystemUv:
+		return fmt.Sprintf("uv add %s==%s", name, version), nil
+	default:
+		return "", fmt.Errorf("%s has no command to bump a dependency: edit the version in %s with edit_file, then run_build",
+			e.Name, strings.Join(e.Manifests, " or "))
+	}
+}
+
+// RunDependencyCommand runs a command built by an Ecosystem.
+func (r *Runner) RunDependencyCommand(ctx context.Context, command string) (*CommandResult, error) {
+	return r.executeCommand(ctx, command)
+}
@@ -0,0 +1,351 @@
+// Parsing of linter output.
+
+package executor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Linter output formats understood by ParseLintOutput.
+const (
+	LintFormatAuto       = "auto"
+	LintFormatGolangci   = "golangci-lint"
+	LintFormatESLint     = "eslint"
+	LintFormatCheckstyle = "checkstyle"
+)
+
+// maxLintFindings bounds the findings listed by LintReport.Summary.
+const maxLintFindings = 100
+
+// LintFinding is a problem reported by a linter.
+type LintFinding struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string // "error", "warning" or "info"
+	Rule     string
+	Message  string
+}
+
+// LintReport contains the findings parsed from a linter's output.
+type LintReport struct {
+	// Format is the output format the findings were parsed from, or "" if
+	// it wasn't recognised
+	Format   string
+	Findings []LintFinding
+}
+
+// ParseLintOutput parses linter output in the given format, or detects the
+// format when it is "" or "auto". golangci-lint is understood in its text
+// and JSON formats, ESLint in its stylish and JSON formats, and Checkstyle in
+// its plain and XML formats.
+func ParseLintOutput(format, output string) (*LintReport, error) {
+	if format == "" || format == LintFormatAuto {
+		format = detectLintFormat(output)
+	}
+
+	report := &LintReport{Format: format}
+	var err error
+	switch format {
+	case LintFormatGolangci:
+		report.Findings, err = parseGolangciLint(output)
+	case LintFormatESLint:
+		report.Findings, err = parseESLint(output)
+	case LintFormatCheckstyle:
+		report.Findings, err = parseCheckstyle(output)
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown lint format %q, must be auto, golangci-lint, eslint or checkstyle", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", format, err)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return report, nil
+}
+
+var (
+	// golangciLineRe matches "file.go:12:5: message (linter)"
+	golangciLineRe = regexp.MustCompile(`^([^\s:]+\.\w+):(\d+)(?::(\d+))?: (.+?)(?: \(([\w-]+)\))?$`)
+	// eslintLineRe matches "  12:5  error  message  rule" under a file name
+	eslintLineRe = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?$`)
+	// checkstyleLineRe matches "[WARN] File.java:12:5: message [Rule]"
+	checkstyleLineRe = regexp.MustCompile(`^\[(ERROR|WARN|INFO)\] (.+?):(\d+)(?::(\d+))?: (.+?)(?: \[(\w+)\])?$`)
+)
+
+// detectLintFormat guesses the linter that produced output, returning "" if
+// none matches.
+func detectLintFormat(output string) string {
+	trimmed := strings.TrimSpace(output)
+	switch {
+	case strings.Contains(output, "<checkstyle"):
+		return LintFormatCheckstyle
+	case strings.HasPrefix(trimmed, "{") && strings.Contains(output, `"Issues"`):
+		return LintFormatGolangci
+	case strings.HasPrefix(trimmed, "[") && strings.Contains(output, `"filePath"`):
+		return LintFormatESLint
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case checkstyleLineRe.MatchString(line):
+			return LintFormatCheckstyle
+		case eslintLineRe.MatchString(line):
+			return LintFormatESLint
+		case golangciLineRe.MatchString(line):
+			return LintFormatGolangci
+		}
+	}
+	return ""
+}
+
+// parseGolangciLint parses golangci-lint text or JSON (--out-format json)
+// output.
+func parseGolangciLint(output string) ([]LintFinding, error) {
+	if start := strings.Index(output, `{"Issues"`); start >= 0 {
+		var report struct {
+			Issues []struct {
+				FromLinter string
+				Text       string
+				Severity   string
+				Pos        struct {
+					Filename string
+					Line     int
+					Column   int
+				}
+			}
+		}
+		// Decode only the JSON document, not any summary printed after it
+		if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&report); err != nil {
+			return nil, err
+		}
+		findings := make([]LintFinding, 0, len(report.Issues))
+		for _, issue := range report.Issues {
+			findings = append(findings, LintFinding{
+				File:     issue.Pos.Filename,
+				Line:     issue.Pos.Line,
+				Column:   issue.Pos.Column,
+				Severity: lintSeverity(issue.Severity),
+				Rule:     issue.FromLinter,
+				Message:  issue.Text,
+			})
+		}
+		return findings, nil
+	}
+
+	var findings []LintFinding
+	for _, line := range strings.Split(output, "\n") {
+		match := golangciLineRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			File:     match[1],
+			Line:     parseIntSafe(match[2]),
+			Column:   parseIntSafe(match[3]),
+			Severity: "error",
+			Rule:     match[5],
+			Message:  match[4],
+		})
+	}
+	return findings, nil
+}
+
+// parseESLint parses ESLint stylish or JSON (--format json) output.
+func parseESLint(output string) ([]LintFinding, error) {
+	if trimmed := strings.TrimSpace(output); strings.HasPrefix(trimmed, "[") {
+		var files []struct {
+			FilePath string `json:"filePath"`
+			Messages []struct {
+				RuleID   string `json:"ruleId"`
+				Severity int    `json:"severity"`
+				Message  string `json:"message"`
+				Line     int    `json:"line"`
+				Column   int    `json:"column"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(strings.NewReader(trimmed)).Decode(&files); err != nil {
+			return nil, err
+		}
+		var findings []LintFinding
+		for _, file := range files {
+			for _, msg := range file.Messages {
+				severity := "warning"
+				if msg.Severity == 2 {
+					severity = "error"
+				}
+				findings = append(findings, LintFinding{
+					File:     file.FilePath,
+					Line:     msg.Line,
+					Column:   msg.Column,
+					Severity: severity,
+					Rule:     msg.RuleID,
+					Message:  msg.Message,
+				})
+			}
+		}
+		return findings, nil
+	}
+
+	var findings []LintFinding
+	var file string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := eslintLineRe.FindStringSubmatch(line); match != nil {
+			findings = append(findings, LintFinding{
+				File:     file,
+				Line:     parseIntSafe(match[1]),
+				Column:   parseIntSafe(match[2]),
+				Severity: match[3],
+				Rule:     match[5],
+				Message:  match[4],
+			})
+			continue
+		}
+		// Findings are listed under the name of their file
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "✖") {
+			file = strings.TrimSpace(line)
+		}
+	}
+	return findings, nil
+}
+
+// parseCheckstyle parses Checkstyle plain or XML (-f xml) output.
+func parseCheckstyle(output string) ([]LintFinding, error) {
+	if start := strings.Index(output, "<checkstyle"); start >= 0 {
+		var report struct {
+			Files []struct {
+				Name   string `xml:"name,attr"`
+				Errors []struct {
+					Line     int    `xml:"line,attr"`
+					Column   int    `xml:"column,attr"`
+					Severity string `xml:"severity,attr"`
+					Message  string `xml:"message,attr"`
+					Source   string `xml:"source,attr"`
+				} `xml:"error"`
+			} `xml:"file"`
+		}
+		// Decode only the XML document, skipping any build output around it
+		if err := xml.NewDecoder(strings.NewReader(output[start:])).Decode(&report); err != nil {
+			return nil, err
+		}
+		var findings []LintFinding
+		for _, file := range report.Files {
+			for _, e := range file.Errors {
+				// e.g. com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocMethodCheck
+				rule := strings.TrimSuffix(path.Ext(e.Source), "Check")
+				findings = append(findings, LintFinding{
+					File:     file.Name,
+					Line:     e.Line,
+					Column:   e.Column,
+					Severity: lintSeverity(e.Severity),
+					Rule:     strings.TrimPrefix(rule, "."),
+					Message:  e.Message,
+				})
+			}
+		}
+		return findings, nil
+	}
+
+	var findings []LintFinding
+	for _, line := range strings.Split(output, "\n") {
+		match := checkstyleLineRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			File:     match[2],
+			Line:     parseIntSafe(match[3]),
+			Column:   parseIntSafe(match[4]),
+			Severity: lintSeverity(match[1]),
+			Rule:     match[6],
+			Message:  match[5],
+		})
+	}
+	return findings, nil
+}
+
+// lintSeverity normalises a linter's severity to error, warning or info.
+// Linters that don't report one only fail on errors.
+func lintSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warn", "warning":
+		return "warning"
+	case "info", "ignore":
+		return "info"
+	default:
+		return "error"
+	}
+}
+
+// RelativeTo makes absolute finding paths under root relative to it.
+func (r *LintReport) RelativeTo(root string) {
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	for i := range r.Findings {
+		r.Findings[i].File = strings.TrimPrefix(r.Findings[i].File, prefix)
+	}
+}
+
+// Count returns the number of findings with the given severity.
+func (r *LintReport) Count(severity string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary lists the findings by file, e.g.
+//
+//	internal/a.go
+//	  12:5 error [errcheck] Error return value is not checked
+func (r *LintReport) Summary() string {
+	if len(r.Findings) == 0 {
+		return "No lint problems found."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s findings: %d (errors: %d, warnings: %d)\n",
+		r.Format, len(r.Findings), r.Count("error"), r.Count("warning")))
+
+	file := ""
+	for i, f := range r.Findings {
+		if i >= maxLintFindings {
+			sb.WriteString(fmt.Sprintf("... and %d more\n", len(r.Findings)-i))
+			break
+		}
+		if i == 0 || f.File != file {
+			file = f.File
+			sb.WriteString(file + "\n")
+		}
+		position := strconv.Itoa(f.Line)
+		if f.Column > 0 {
+			position += ":" + strconv.Itoa(f.Column)
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s ", position, f.Severity))
+		if f.Rule != "" {
+			sb.WriteString("[" + f.Rule + "] ")
+		}
+		sb.WriteString(f.Message + "\n")
+	}
+	return sb.String()
+}
@@ -2,120 +2,178 @@
 package executor
 
 import (
+	"encoding/json"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 // TestFailure represents a parsed test failure.
 type TestFailure struct {
-	TestName string
-	File     string
-	Line     int
-	Message  string
-	Expected string
-	Actual   string
+	TestName string `json:"test"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
 }
 
 // BuildError represents a parsed build error.
 type BuildError struct {
-	File    string
-	Line    int
-	Column  int
-	Message string
-	Type    string // "error" or "warning"
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	Type    string `json:"type"` // "error" or "warning"
 }
 
-// AnalyzeOutput analyzes command output for failures and errors.
+// AnalyzeOutput analyzes command output for failures and errors with the
+// built-in parsers, detecting the tool that produced it.
 func AnalyzeOutput(output string) *AnalysisResult {
-	result := &AnalysisResult{
-		Raw: output,
-	}
-
-	// Detect build system
-	switch {
-	case strings.Contains(output, "BUILD FAILURE") || strings.Contains(output, "[ERROR]"):
-		result.Type = "maven"
-		result.BuildErrors = parseMavenErrors(output)
-	case strings.Contains(output, "FAILED") && strings.Contains(output, "go test"):
-		result.Type = "go"
-		result.TestFailures = parseGoTestFailures(output)
-	case strings.Contains(output, "npm ERR!"):
-		result.Type = "npm"
-		result.BuildErrors = parseNpmErrors(output)
-	case strings.Contains(output, "FAIL") && (strings.Contains(output, "jest") || strings.Contains(output, "vitest")):
-		result.Type = "jest"
-		result.TestFailures = parseJestFailures(output)
-	case strings.Contains(output, "error:") && strings.Contains(output, "cargo"):
-		result.Type = "cargo"
-		result.BuildErrors = parseCargoErrors(output)
-	case strings.Contains(output, "FAILURES!") || strings.Contains(output, "Tests run:"):
-		result.Type = "junit"
-		result.TestFailures = parseJUnitFailures(output)
-	default:
-		result.Type = "unknown"
-		result.BuildErrors = parseGenericErrors(output)
-	}
-
-	// Set success flag
-	result.Success = len(result.BuildErrors) == 0 && len(result.TestFailures) == 0
-
+	result, _ := NewParserRegistry().Analyze(output, "")
 	return result
 }
 
 // AnalysisResult contains the parsed output analysis.
 type AnalysisResult struct {
-	Type         string
-	Success      bool
-	BuildErrors  []BuildError
-	TestFailures []TestFailure
-	Raw          string
+	Type         string        `json:"type"`
+	Success      bool          `json:"success"`
+	BuildErrors  []BuildError  `json:"build_errors"`
+	TestFailures []TestFailure `json:"test_failures"`
+	Raw          string        `json:"-"`
 }
 
-// Summary returns a human-readable summary.
+const (
+	// maxSummaryErrors bounds the build errors listed in a summary
+	maxSummaryErrors = 30
+	// maxSummaryFailures bounds the test failures listed in a summary
+	maxSummaryFailures = 20
+)
+
+// Summary returns a human-readable summary, with build errors grouped by
+// file in the order the files first appear.
 func (r *AnalysisResult) Summary() string {
 	if r.Success {
 		return "Build/tests passed successfully."
 	}
 
 	var sb strings.Builder
-
 	if len(r.BuildErrors) > 0 {
-		sb.WriteString("Build Errors:\n")
-		for i, err := range r.BuildErrors {
-			if i >= 5 {
-				sb.WriteString("  ... and more errors\n")
-				break
+		sb.WriteString(fmt.Sprintf("Build Errors (%d):\n", len(r.BuildErrors)))
+		listed := r.BuildErrors[:min(len(r.BuildErrors), maxSummaryErrors)]
+		for _, group := range groupByFile(listed) {
+			if group.file == "" {
+				for _, err := range group.errors {
+					sb.WriteString("  • " + severityPrefix(err.Type) + err.Message + "\n")
+				}
+				continue
 			}
-			if err.File != "" {
-				sb.WriteString("  • " + err.File)
-				if err.Line > 0 {
-					sb.WriteString(":" + string(rune('0'+err.Line)))
+			sb.WriteString("  " + group.file + "\n")
+			for _, err := range group.errors {
+				sb.WriteString("    • ")
+				if pos := position(err.Line, err.Column); pos != "" {
+					sb.WriteString(pos + ": ")
 				}
-				sb.WriteString(": ")
-			} else {
-				sb.WriteString("  • ")
+				sb.WriteString(severityPrefix(err.Type) + err.Message + "\n")
 			}
-			sb.WriteString(err.Message + "\n")
+		}
+		if more := len(r.BuildErrors) - len(listed); more > 0 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more errors\n", more))
 		}
 	}
 
 	if len(r.TestFailures) > 0 {
-		sb.WriteString("Test Failures:\n")
+		sb.WriteString(fmt.Sprintf("Test Failures (%d):\n", len(r.TestFailures)))
 		for i, fail := range r.TestFailures {
-			if i >= 5 {
-				sb.WriteString("  ... and more failures\n")
+			if i >= maxSummaryFailures {
+				sb.WriteString(fmt.Sprintf("  ... and %d more failures\n", len(r.TestFailures)-i))
 				break
 			}
-			sb.WriteString("  • " + fail.TestName + "\n")
+			sb.WriteString("  • " + fail.TestName)
+			if fail.File != "" {
+				sb.WriteString(" (" + fail.File)
+				if pos := position(fail.Line, 0); pos != "" {
+					sb.WriteString(":" + pos)
+				}
+				sb.WriteString(")")
+			}
+			sb.WriteString("\n")
 			if fail.Message != "" {
 				sb.WriteString("    " + fail.Message + "\n")
 			}
+			if fail.Expected != "" {
+				sb.WriteString("    " + fail.Expected + "\n")
+			}
+			if fail.Actual != "" {
+				sb.WriteString("    " + fail.Actual + "\n")
+			}
 		}
 	}
 
 	return sb.String()
 }
 
+// ToJSON returns the analysis as JSON, without the raw output.
+func (r *AnalysisResult) ToJSON() (string, error) {
+	out := *r
+	if out.BuildErrors == nil {
+		out.BuildErrors = []BuildError{}
+	}
+	if out.TestFailures == nil {
+		out.TestFailures = []TestFailure{}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode analysis: %w", err)
+	}
+	return string(data), nil
+}
+
+// fileErrors are the build errors in one file.
+type fileErrors struct {
+	file   string
+	errors []BuildError
+}
+
+// groupByFile groups build errors by file, in the order the files first
+// appear.
+func groupByFile(errs []BuildError) []fileErrors {
+	var groups []fileErrors
+	index := make(map[string]int)
+	for _, err := range errs {
+		i, ok := index[err.File]
+		if !ok {
+			i = len(groups)
+			index[err.File] = i
+			groups = append(groups, fileErrors{file: err.File})
+		}
+		groups[i].errors = append(groups[i].errors, err)
+	}
+	return groups
+}
+
+// position formats a line and column as "line:column", "line" or "" when
+// they are unknown.
+func position(line, column int) string {
+	switch {
+	case line <= 0:
+		return ""
+	case column <= 0:
+		return strconv.Itoa(line)
+	default:
+		return strconv.Itoa(line) + ":" + strconv.Itoa(column)
+	}
+}
+
+// severityPrefix marks warnings, which are listed alongside errors.
+func severityPrefix(severity string) string {
+	if severity == "warning" {
+		return "warning: "
+	}
+	return ""
+}
+
 // parseMavenErrors parses Maven build output.
 func parseMavenErrors(output string) []BuildError {
 	var errors []BuildError
@@ -295,6 +353,167 @@ func parseGenericErrors(output string) []BuildError {
 	return errors
 }
 
+// parseGradleOutput parses Gradle output: javac and kotlinc errors, failed
+// tests and, when nothing more specific is found, the failed tasks.
+func parseGradleOutput(output string, result *AnalysisResult) {
+	javacRe := regexp.MustCompile(`(?m)^(/\S+\.java):(\d+): (error|warning): (.+)$`)
+	kotlinRe := regexp.MustCompile(`(?m)^([ew]): (?:file://)?(/\S+\.kts?):(?: \()?(\d+)(?::|, )(\d+)\)?:? (.+)$`)
+	testRe := regexp.MustCompile(`(?m)^(\S+) > (.+?) FAILED\n(?:\s+(.+))?`)
+	taskRe := regexp.MustCompile(`(?m)^> Task (:\S+) FAILED$`)
+
+	for _, match := range javacRe.FindAllStringSubmatch(output, -1) {
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    match[1],
+			Line:    parseIntSafe(match[2]),
+			Message: match[4],
+			Type:    match[3],
+		})
+	}
+	for _, match := range kotlinRe.FindAllStringSubmatch(output, -1) {
+		severity := "error"
+		if match[1] == "w" {
+			severity = "warning"
+		}
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    match[2],
+			Line:    parseIntSafe(match[3]),
+			Column:  parseIntSafe(match[4]),
+			Message: match[5],
+			Type:    severity,
+		})
+	}
+	for _, match := range testRe.FindAllStringSubmatch(output, -1) {
+		result.TestFailures = append(result.TestFailures, TestFailure{
+			TestName: match[1] + "." + match[2],
+			Message:  strings.TrimSpace(match[3]),
+		})
+	}
+
+	if len(result.BuildErrors) == 0 && len(result.TestFailures) == 0 {
+		for _, match := range taskRe.FindAllStringSubmatch(output, -1) {
+			result.BuildErrors = append(result.BuildErrors, BuildError{
+				Message: "Task " + match[1] + " failed",
+				Type:    "error",
+			})
+		}
+	}
+}
+
+// parsePytestOutput parses pytest output: the short test summary and
+// collection errors.
+func parsePytestOutput(output string, result *AnalysisResult) {
+	failRe := regexp.MustCompile(`(?m)^FAILED (\S+?)::(\S+)(?: - (.*))?$`)
+	locationRe := regexp.MustCompile(`(?m)^(\S+\.py):(\d+): \w+`)
+	errorRe := regexp.MustCompile(`(?m)^ERROR (\S+?)(?:::(\S+))?(?: - (.*))?$`)
+
+	// Tracebacks name the failing line of each test file
+	lines := make(map[string]int)
+	for _, match := range locationRe.FindAllStringSubmatch(output, -1) {
+		lines[match[1]] = parseIntSafe(match[2])
+	}
+
+	for _, match := range failRe.FindAllStringSubmatch(output, -1) {
+		result.TestFailures = append(result.TestFailures, TestFailure{
+			TestName: match[1] + "::" + match[2],
+			File:     match[1],
+			Line:     lines[match[1]],
+			Message:  match[3],
+		})
+	}
+	for _, match := range errorRe.FindAllStringSubmatch(output, -1) {
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    match[1],
+			Message: strings.TrimSpace("collection error " + match[3]),
+			Type:    "error",
+		})
+	}
+}
+
+// parseDotnetOutput parses dotnet build and test output: compiler errors
+// such as CS0103, and failed tests with their error messages.
+func parseDotnetOutput(output string, result *AnalysisResult) {
+	errorRe := regexp.MustCompile(`(?m)^\s*(\S[^(\n]*)\((\d+),(\d+)\): (error|warning) (\w+): (.+?)(?: \[[^\]]+\])?\s*$`)
+	testRe := regexp.MustCompile(`(?m)^\s*Failed (\S+) \[[^\]]*\](?:\n\s*Error Message:\n\s*(.+))?`)
+
+	seen := make(map[string]bool)
+	for _, match := range errorRe.FindAllStringSubmatch(output, -1) {
+		// MSBuild repeats every error in its final summary
+		key := strings.TrimSpace(match[0])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    match[1],
+			Line:    parseIntSafe(match[2]),
+			Column:  parseIntSafe(match[3]),
+			Message: match[5] + ": " + match[6],
+			Type:    match[4],
+		})
+	}
+	for _, match := range testRe.FindAllStringSubmatch(output, -1) {
+		result.TestFailures = append(result.TestFailures, TestFailure{
+			TestName: match[1],
+			Message:  strings.TrimSpace(match[2]),
+		})
+	}
+}
+
+// parseBazelOutput parses Bazel output: errors with a location, and failed
+// or timed out test targets.
+func parseBazelOutput(output string, result *AnalysisResult) {
+	errorRe := regexp.MustCompile(`(?m)^ERROR: (/?[^:\s]+):(\d+):(\d+): (.+)$`)
+	testRe := regexp.MustCompile(`(?m)^(//\S+)\s+(FAILED|TIMEOUT) in `)
+
+	for _, match := range errorRe.FindAllStringSubmatch(output, -1) {
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    match[1],
+			Line:    parseIntSafe(match[2]),
+			Column:  parseIntSafe(match[3]),
+			Message: match[4],
+			Type:    "error",
+		})
+	}
+	for _, match := range testRe.FindAllStringSubmatch(output, -1) {
+		failure := TestFailure{TestName: match[1]}
+		if match[2] == "TIMEOUT" {
+			failure.Message = "timed out"
+		}
+		result.TestFailures = append(result.TestFailures, failure)
+	}
+}
+
+// parseVitestOutput parses Vitest output: each failed test with its error
+// and the location it points at.
+func parseVitestOutput(output string, result *AnalysisResult) {
+	failRe := regexp.MustCompile(`^\s*FAIL\s+(\S+)\s+>\s+(.+)$`)
+	errorRe := regexp.MustCompile(`^\s*(\w*Error: .+)$`)
+	locationRe := regexp.MustCompile(`❯ (\S+):(\d+):\d+`)
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		match := failRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		failure := TestFailure{TestName: strings.TrimSpace(match[2]), File: match[1]}
+
+		// The error and its location follow the test's header
+		for _, next := range lines[i+1 : min(i+12, len(lines))] {
+			if failRe.MatchString(next) {
+				break
+			}
+			if m := errorRe.FindStringSubmatch(next); m != nil && failure.Message == "" {
+				failure.Message = m[1]
+			}
+			if m := locationRe.FindStringSubmatch(next); m != nil && failure.Line == 0 {
+				failure.File, failure.Line = m[1], parseIntSafe(m[2])
+			}
+		}
+		result.TestFailures = append(result.TestFailures, failure)
+	}
+}
+
 // parseIntSafe parses an integer, returning 0 on error.
 func parseIntSafe(s string) int {
 	var n int
@@ -0,0 +1,171 @@
+// Progress reports on long-running commands.
+
+package executor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTailBytes is how much of the end of a command's output is kept for
+	// progress reports
+	maxTailBytes = 4096
+	// maxTailLines is how many lines of output a progress report carries
+	maxTailLines = 15
+)
+
+// Progress reports on a running command.
+type Progress struct {
+	Command string
+	// Tail is the end of the command's combined output
+	Tail    string
+	Elapsed time.Duration
+	// Done is set on the final report, once the command has exited
+	Done     bool
+	ExitCode int
+	TimedOut bool
+}
+
+// ProgressFunc receives progress reports. Reports for a command are never
+// delivered concurrently.
+type ProgressFunc func(Progress)
+
+// progressKey is the context key for progress reporting.
+type progressKey struct{}
+
+// progressReporting is how commands run with a context report progress.
+type progressReporting struct {
+	interval time.Duration
+	report   ProgressFunc
+}
+
+// WithProgress returns a context in which commands report the tail of their
+// output to report every interval while they run, as long as it changed.
+// Commands that finish before the first interval report nothing; the others
+// send a final report once they exit.
+func WithProgress(ctx context.Context, interval time.Duration, report ProgressFunc) context.Context {
+	if interval <= 0 || report == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, progressReporting{interval: interval, report: report})
+}
+
+// tailWriter keeps the end of what is written to it.
+type tailWriter struct {
+	mu      sync.Mutex
+	buf     []byte
+	written int64
+}
+
+// Write appends p, discarding all but the last maxTailBytes bytes.
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxTailBytes {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-maxTailBytes:]...)
+	}
+	t.written += int64(len(p))
+	return len(p), nil
+}
+
+// tail returns the last lines written and the number of bytes written in
+// total, which tells callers whether anything changed.
+func (t *tailWriter) tail() (string, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := strings.Split(strings.TrimRight(string(t.buf), "\n"), "\n")
+	if len(lines) > maxTailLines {
+		lines = lines[len(lines)-maxTailLines:]
+	}
+	return strings.Join(lines, "\n"), t.written
+}
+
+// progressTracker sends the progress reports of one command.
+type progressTracker struct {
+	reporting progressReporting
+	command   string
+	start     time.Time
+	output    *tailWriter
+	done      chan struct{}
+	wg        sync.WaitGroup
+	// reported is set once a report was sent; only read after wg.Wait
+	reported bool
+}
+
+// trackProgress starts reporting the progress of command if ctx asks for it.
+// It returns nil, which tracks nothing, if no reports are wanted.
+func trackProgress(ctx context.Context, command string) *progressTracker {
+	reporting, ok := ctx.Value(progressKey{}).(progressReporting)
+	if !ok {
+		return nil
+	}
+
+	p := &progressTracker{
+		reporting: reporting,
+		command:   command,
+		start:     time.Now(),
+		output:    &tailWriter{},
+		done:      make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// writer wraps w so the command's output is also tracked.
+func (p *progressTracker) writer(w io.Writer) io.Writer {
+	if p == nil {
+		return w
+	}
+	return io.MultiWriter(w, p.output)
+}
+
+// run sends a report every interval while the output changes.
+func (p *progressTracker) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.reporting.interval)
+	defer ticker.Stop()
+
+	var last int64 = -1
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			tail, written := p.output.tail()
+			if written == last {
+				continue
+			}
+			last = written
+			p.reported = true
+			p.reporting.report(Progress{Command: p.command, Tail: tail, Elapsed: time.Since(p.start)})
+		}
+	}
+}
+
+// finish stops the periodic reports and, if any were sent, sends the final
+// one.
+func (p *progressTracker) finish(result *CommandResult) {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+	if !p.reported {
+		return
+	}
+
+	tail, _ := p.output.tail()
+	final := Progress{Command: p.command, Tail: tail, Elapsed: time.Since(p.start), Done: true, ExitCode: -1}
+	if result != nil {
+		final.ExitCode, final.TimedOut = result.ExitCode, result.TimedOut
+	}
+	p.reporting.report(final)
+}
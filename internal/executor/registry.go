@@ -0,0 +1,379 @@
+// The registry of build and test output parsers.
+
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputParser extracts build errors and test failures from the output of
+// one build or test tool.
+type OutputParser interface {
+	// Name identifies the parser, e.g. in STORMSTACK_OUTPUT_PARSER.
+	Name() string
+	// Detect reports whether output looks like it came from the parser's tool.
+	Detect(output string) bool
+	// Parse adds the errors and failures found in output to result.
+	Parse(output string, result *AnalysisResult)
+}
+
+// funcParser is an OutputParser made of functions.
+type funcParser struct {
+	name   string
+	detect func(output string) bool
+	parse  func(output string, result *AnalysisResult)
+}
+
+// Name returns the parser's name.
+func (p funcParser) Name() string { return p.name }
+
+// Detect reports whether output looks like the parser's tool's.
+func (p funcParser) Detect(output string) bool { return p.detect(output) }
+
+// Parse adds the errors and failures found in output to result.
+func (p funcParser) Parse(output string, result *AnalysisResult) { p.parse(output, result) }
+
+// buildErrors adapts a parser returning build errors.
+func buildErrors(parse func(string) []BuildError) func(string, *AnalysisResult) {
+	return func(output string, result *AnalysisResult) {
+		result.BuildErrors = append(result.BuildErrors, parse(output)...)
+	}
+}
+
+// testFailures adapts a parser returning test failures.
+func testFailures(parse func(string) []TestFailure) func(string, *AnalysisResult) {
+	return func(output string, result *AnalysisResult) {
+		result.TestFailures = append(result.TestFailures, parse(output)...)
+	}
+}
+
+// containsAll returns a detector matching output containing every marker.
+func containsAll(markers ...string) func(string) bool {
+	return func(output string) bool {
+		for _, marker := range markers {
+			if !strings.Contains(output, marker) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// containsAny returns a detector matching output containing any marker.
+func containsAny(markers ...string) func(string) bool {
+	return func(output string) bool {
+		for _, marker := range markers {
+			if strings.Contains(output, marker) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var (
+	// dotnetRe matches C# compiler errors and the dotnet test summary
+	dotnetRe = regexp.MustCompile(`error CS\d+|Failed!\s+- Failed:`)
+	// pytestRe matches a pytest failure in the short test summary
+	pytestRe = regexp.MustCompile(`(?m)^FAILED \S+::`)
+)
+
+// builtinParsers returns the built-in parsers in the order output is tried
+// against them, most specific first.
+func builtinParsers() []OutputParser {
+	return []OutputParser{
+		funcParser{"maven", containsAny("BUILD FAILURE", "[ERROR]"), buildErrors(parseMavenErrors)},
+		funcParser{"gradle", containsAny("FAILURE: Build failed", "BUILD FAILED in"), parseGradleOutput},
+		funcParser{"bazel", func(output string) bool {
+			return containsAny("FAILED in ", "TIMEOUT in ")(output) && strings.Contains(output, "//") ||
+				strings.Contains(output, "ERROR: ") && strings.Contains(output, "bazel")
+		}, parseBazelOutput},
+		funcParser{"go", func(output string) bool {
+			return strings.Contains(output, "--- FAIL:") || containsAll("FAILED", "go test")(output)
+		}, testFailures(parseGoTestFailures)},
+		funcParser{"dotnet", dotnetRe.MatchString, parseDotnetOutput},
+		funcParser{"pytest", func(output string) bool {
+			return containsAny("short test summary info", "= FAILURES =")(output) || pytestRe.MatchString(output)
+		}, parsePytestOutput},
+		funcParser{"npm", containsAll("npm ERR!"), buildErrors(parseNpmErrors)},
+		funcParser{"vitest", func(output string) bool {
+			return strings.Contains(output, "FAIL") && (strings.Contains(output, "vitest") || strings.Contains(output, "❯"))
+		}, parseVitestOutput},
+		funcParser{"jest", containsAll("FAIL", "jest"), testFailures(parseJestFailures)},
+		funcParser{"cargo", containsAll("error:", "cargo"), buildErrors(parseCargoErrors)},
+		funcParser{"junit", containsAny("FAILURES!", "Tests run:"), testFailures(parseJUnitFailures)},
+	}
+}
+
+// ParserRegistry selects the parser for build and test output. Custom
+// parsers are tried first, then the parsers for the repository's build
+// systems, then the other built-in parsers.
+type ParserRegistry struct {
+	custom  []OutputParser
+	builtin []OutputParser
+	// preferred names the parsers for the repository's build systems
+	preferred []string
+}
+
+// NewParserRegistry creates a registry of the built-in parsers, preferring
+// those for buildSystems (see DetectBuildSystems).
+func NewParserRegistry(buildSystems ...string) *ParserRegistry {
+	return &ParserRegistry{
+		builtin:   builtinParsers(),
+		preferred: buildSystems,
+	}
+}
+
+// Register adds a custom parser, tried before those already registered. A
+// parser with the name of an existing one replaces it.
+func (r *ParserRegistry) Register(parser OutputParser) {
+	r.custom = append([]OutputParser{parser}, without(r.custom, parser.Name())...)
+	r.builtin = without(r.builtin, parser.Name())
+}
+
+// without returns parsers less the one named name.
+func without(parsers []OutputParser, name string) []OutputParser {
+	kept := make([]OutputParser, 0, len(parsers))
+	for _, p := range parsers {
+		if p.Name() != name {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// all returns the custom parsers followed by the built-in ones.
+func (r *ParserRegistry) all() []OutputParser {
+	return append(append([]OutputParser(nil), r.custom...), r.builtin...)
+}
+
+// Get returns the parser with the given name.
+func (r *ParserRegistry) Get(name string) (OutputParser, bool) {
+	for _, p := range r.all() {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of the registered parsers, sorted.
+func (r *ParserRegistry) Names() []string {
+	var names []string
+	for _, p := range r.all() {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Analyze parses output with the parser named name, or, when name is empty
+// or "auto", with the first parser that recognises it. Output no parser
+// recognises is searched for generic error patterns.
+func (r *ParserRegistry) Analyze(output, name string) (*AnalysisResult, error) {
+	result := &AnalysisResult{Raw: output}
+
+	var parser OutputParser
+	if name != "" && name != "auto" {
+		var ok bool
+		if parser, ok = r.Get(name); !ok {
+			return nil, fmt.Errorf("unknown output parser %q, must be auto or one of: %s", name, strings.Join(r.Names(), ", "))
+		}
+	} else {
+		parser = r.detect(output)
+	}
+
+	if parser != nil {
+		result.Type = parser.Name()
+		parser.Parse(output, result)
+	} else {
+		result.Type = "unknown"
+		result.BuildErrors = parseGenericErrors(output)
+	}
+
+	result.Success = len(result.BuildErrors) == 0 && len(result.TestFailures) == 0
+	return result, nil
+}
+
+// detect returns the first parser recognising output, or nil.
+func (r *ParserRegistry) detect(output string) OutputParser {
+	ordered := append([]OutputParser(nil), r.custom...)
+	preferred := make(map[string]bool)
+	for _, name := range r.preferred {
+		for _, p := range r.builtin {
+			if p.Name() == name && !preferred[name] {
+				preferred[name] = true
+				ordered = append(ordered, p)
+			}
+		}
+	}
+	for _, p := range r.builtin {
+		if !preferred[p.Name()] {
+			ordered = append(ordered, p)
+		}
+	}
+
+	for _, p := range ordered {
+		if p.Detect(output) {
+			return p
+		}
+	}
+	return nil
+}
+
+// buildSystemMarkers maps files at a repository's root to the parsers for
+// the build systems they belong to.
+var buildSystemMarkers = []struct {
+	file    string
+	parsers []string
+}{
+	{"pom.xml", []string{"maven", "junit"}},
+	{"build.gradle", []string{"gradle", "junit"}},
+	{"build.gradle.kts", []string{"gradle", "junit"}},
+	{"MODULE.bazel", []string{"bazel"}},
+	{"WORKSPACE", []string{"bazel"}},
+	{"WORKSPACE.bazel", []string{"bazel"}},
+	{"go.mod", []string{"go"}},
+	{"Cargo.toml", []string{"cargo"}},
+	{"vitest.config.ts", []string{"vitest"}},
+	{"vitest.config.js", []string{"vitest"}},
+	{"vitest.config.mts", []string{"vitest"}},
+	{"jest.config.js", []string{"jest"}},
+	{"jest.config.ts", []string{"jest"}},
+	{"package.json", []string{"npm"}},
+	{"pytest.ini", []string{"pytest"}},
+	{"pyproject.toml", []string{"pytest"}},
+	{"setup.cfg", []string{"pytest"}},
+	{"global.json", []string{"dotnet"}},
+	{"Directory.Build.props", []string{"dotnet"}},
+}
+
+// DetectBuildSystems returns the names of the parsers for the build systems
+// used by the repository at repoPath, detected from the files at its root.
+func DetectBuildSystems(repoPath string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(parsers ...string) {
+		for _, name := range parsers {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, marker := range buildSystemMarkers {
+		if _, err := os.Stat(filepath.Join(repoPath, marker.file)); err == nil {
+			add(marker.parsers...)
+		}
+	}
+	for _, pattern := range []string{"*.sln", "*.csproj"} {
+		if matches, _ := filepath.Glob(filepath.Join(repoPath, pattern)); len(matches) > 0 {
+			add("dotnet")
+		}
+	}
+	return names
+}
+
+// RegexParserConfig defines a custom parser in the parsers file. Pattern's
+// named groups file, line, column, message and, for test failures, test,
+// expected and actual fill in each match.
+type RegexParserConfig struct {
+	Name string `json:"name"`
+	// Detect matches output from the parser's tool; Pattern is used when empty
+	Detect  string `json:"detect"`
+	Pattern string `json:"pattern"`
+	// Kind is "build" for build errors (the default) or "test" for test failures
+	Kind string `json:"kind"`
+}
+
+// regexParser is a custom parser defined by regular expressions.
+type regexParser struct {
+	name    string
+	detect  *regexp.Regexp
+	pattern *regexp.Regexp
+	tests   bool
+}
+
+// Name returns the parser's name.
+func (p *regexParser) Name() string { return p.name }
+
+// Detect reports whether output matches the parser's detect pattern.
+func (p *regexParser) Detect(output string) bool { return p.detect.MatchString(output) }
+
+// Parse adds an error or failure to result for every match of the pattern.
+func (p *regexParser) Parse(output string, result *AnalysisResult) {
+	for _, match := range p.pattern.FindAllStringSubmatch(output, -1) {
+		group := func(name string) string {
+			if i := p.pattern.SubexpIndex(name); i > 0 {
+				return strings.TrimSpace(match[i])
+			}
+			return ""
+		}
+
+		if p.tests {
+			result.TestFailures = append(result.TestFailures, TestFailure{
+				TestName: group("test"),
+				File:     group("file"),
+				Line:     parseIntSafe(group("line")),
+				Message:  group("message"),
+				Expected: group("expected"),
+				Actual:   group("actual"),
+			})
+			continue
+		}
+		result.BuildErrors = append(result.BuildErrors, BuildError{
+			File:    group("file"),
+			Line:    parseIntSafe(group("line")),
+			Column:  parseIntSafe(group("column")),
+			Message: group("message"),
+			Type:    "error",
+		})
+	}
+}
+
+// LoadParsers reads custom regex parsers from a JSON file holding a list of
+// RegexParserConfig. A missing file defines none.
+func LoadParsers(path string) ([]OutputParser, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parsers file: %w", err)
+	}
+
+	var configs []RegexParserConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse parsers file %s: %w", path, err)
+	}
+
+	parsers := make([]OutputParser, 0, len(configs))
+	for _, c := range configs {
+		if c.Name == "" || c.Pattern == "" {
+			return nil, fmt.Errorf("parsers file %s: every parser needs a name and a pattern", path)
+		}
+		if c.Kind != "" && c.Kind != "build" && c.Kind != "test" {
+			return nil, fmt.Errorf("parser %s: invalid kind %q, must be build or test", c.Name, c.Kind)
+		}
+		pattern, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("parser %s: invalid pattern: %w", c.Name, err)
+		}
+		detect := pattern
+		if c.Detect != "" {
+			if detect, err = regexp.Compile(c.Detect); err != nil {
+				return nil, fmt.Errorf("parser %s: invalid detect pattern: %w", c.Name, err)
+			}
+		}
+		parsers = append(parsers, &regexParser{name: c.Name, detect: detect, pattern: pattern, tests: c.Kind == "test"})
+	}
+	return parsers, nil
+}
@@ -7,32 +7,92 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	// DefaultTimeout is the default command timeout.
 	DefaultTimeout = 5 * time.Minute
-	// MaxOutputSize is the maximum output size in bytes.
+	// MaxOutputSize is the default maximum output size in bytes.
 	MaxOutputSize = 100 * 1024 // 100KB
+	// MaxTimeout is the longest any command may run, however configured.
+	MaxTimeout = 2 * time.Hour
+	// MaxOutputLimit is the most output kept per stream, however configured.
+	MaxOutputLimit = 10 * 1024 * 1024 // 10MB
 )
 
 // Runner executes commands in the repository directory.
 type Runner struct {
 	repoPath string
+	// mu guards the commands, which a configuration reload may replace
+	mu       sync.RWMutex
 	buildCmd string
 	testCmd  string
+	lintCmd  string
+	// timeout and outputLimit bound each command unless overridden
+	timeout     time.Duration
+	outputLimit int
 }
 
-// NewRunner creates a new command runner.
-func NewRunner(repoPath, buildCmd, testCmd string) *Runner {
+// NewRunner creates a new command runner. lintCmd may be empty when the
+// repository has no linter.
+func NewRunner(repoPath, buildCmd, testCmd, lintCmd string) *Runner {
 	return &Runner{
-		repoPath: repoPath,
-		buildCmd: buildCmd,
-		testCmd:  testCmd,
+		repoPath:    repoPath,
+		buildCmd:    buildCmd,
+		testCmd:     testCmd,
+		lintCmd:     lintCmd,
+		timeout:     DefaultTimeout,
+		outputLimit: MaxOutputSize,
 	}
 }
 
+// SetLimits sets how long commands may run and how much of their stdout and
+// stderr is kept. Zero keeps the default; values are capped at MaxTimeout and
+// MaxOutputLimit.
+func (r *Runner) SetLimits(timeout time.Duration, outputLimit int) {
+	if timeout > 0 {
+		r.timeout = min(timeout, MaxTimeout)
+	}
+	if outputLimit > 0 {
+		r.outputLimit = min(outputLimit, MaxOutputLimit)
+	}
+}
+
+// SetCommands replaces the build, test and lint commands, e.g. when the
+// configuration is reloaded. Commands already running are unaffected.
+func (r *Runner) SetCommands(buildCmd, testCmd, lintCmd string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildCmd, r.testCmd, r.lintCmd = buildCmd, testCmd, lintCmd
+}
+
+// Timeout returns how long commands may run unless overridden.
+func (r *Runner) Timeout() time.Duration {
+	return r.timeout
+}
+
+// timeoutKey is the context key for a per-call command timeout.
+type timeoutKey struct{}
+
+// WithTimeout returns a context in which commands may run for timeout instead
+// of the runner's limit, capped at MaxTimeout.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, timeoutKey{}, min(timeout, MaxTimeout))
+}
+
+// timeoutFor returns how long a command run with ctx may take.
+func (r *Runner) timeoutFor(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return r.timeout
+}
+
 // CommandResult represents the result of a command execution.
 type CommandResult struct {
 	Command  string
@@ -43,48 +103,105 @@ type CommandResult struct {
 	TimedOut bool
 }
 
+// BlockedCommandError reports a command refused by the safety checks.
+type BlockedCommandError struct {
+	Err error
+}
+
+// Error returns the reason the command was refused.
+func (e *BlockedCommandError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying validation error.
+func (e *BlockedCommandError) Unwrap() error {
+	return e.Err
+}
+
 // RunCommand runs a command with safety checks.
 func (r *Runner) RunCommand(ctx context.Context, command string) (*CommandResult, error) {
 	// Validate command
 	if err := ValidateCommand(command); err != nil {
-		return nil, err
+		return nil, &BlockedCommandError{Err: err}
 	}
 
-	return r.executeCommand(ctx, command, DefaultTimeout)
+	return r.executeCommand(ctx, command)
 }
 
 // RunBuild runs the configured build command.
 func (r *Runner) RunBuild(ctx context.Context, args string) (*CommandResult, error) {
-	command := r.buildCmd
+	command, _, _ := r.Commands()
+	if command == "" {
+		return nil, fmt.Errorf("no build command was configured or detected (set STORMSTACK_BUILD_CMD)")
+	}
 	if args != "" {
 		command = command + " " + args
 	}
-	return r.executeCommand(ctx, command, DefaultTimeout)
+	return r.executeCommand(ctx, command)
 }
 
 // RunTests runs the configured test command.
 func (r *Runner) RunTests(ctx context.Context, args string) (*CommandResult, error) {
-	command := r.testCmd
+	return r.RunSelectedTests(ctx, r.TestCommand(args))
+}
+
+// RunSelectedTests runs a command built by SelectTestCommand.
+func (r *Runner) RunSelectedTests(ctx context.Context, command string) (*CommandResult, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("no test command was configured or detected (set STORMSTACK_TEST_CMD)")
+	}
+	return r.executeCommand(ctx, command)
+}
+
+// TestCommand returns the configured test command with args appended.
+func (r *Runner) TestCommand(args string) string {
+	_, command, _ := r.Commands()
+	if args == "" {
+		return command
+	}
+	return command + " " + args
+}
+
+// Commands returns the build, test and lint commands the runner runs.
+func (r *Runner) Commands() (build, test, lint string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.buildCmd, r.testCmd, r.lintCmd
+}
+
+// HasLinter reports whether a lint command is configured.
+func (r *Runner) HasLinter() bool {
+	_, _, lint := r.Commands()
+	return lint != ""
+}
+
+// RunLint runs the configured lint command.
+func (r *Runner) RunLint(ctx context.Context, args string) (*CommandResult, error) {
+	_, _, command := r.Commands()
+	if command == "" {
+		return nil, fmt.Errorf("no lint command was configured or detected (set STORMSTACK_LINT_CMD)")
+	}
 	if args != "" {
 		command = command + " " + args
 	}
-	return r.executeCommand(ctx, command, DefaultTimeout)
+	return r.executeCommand(ctx, command)
 }
 
 // executeCommand executes a shell command.
-func (r *Runner) executeCommand(ctx context.Context, command string, timeout time.Duration) (*CommandResult, error) {
+func (r *Runner) executeCommand(ctx context.Context, command string) (*CommandResult, error) {
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeoutFor(ctx))
 	defer cancel()
 
 	// Create command
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
 	cmd.Dir = r.repoPath
 
-	// Capture output
+	// Capture output, streaming its tail to any progress reports
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &limitedWriter{w: &stdout, limit: MaxOutputSize}
-	cmd.Stderr = &limitedWriter{w: &stderr, limit: MaxOutputSize}
+	progress := trackProgress(ctx, command)
+	cmd.Stdout = progress.writer(&limitedWriter{w: &stdout, limit: r.outputLimit})
+	cmd.Stderr = progress.writer(&limitedWriter{w: &stderr, limit: r.outputLimit})
 
 	// Run command
 	start := time.Now()
@@ -107,9 +224,11 @@ func (r *Runner) executeCommand(ctx context.Context, command string, timeout tim
 		} else if result.TimedOut {
 			result.ExitCode = -1
 		} else {
+			progress.finish(nil)
 			return nil, fmt.Errorf("command failed: %w", err)
 		}
 	}
+	progress.finish(result)
 
 	return result, nil
 }
@@ -59,6 +59,168 @@ var BlockedGitCommands = []string{
 	"restore .",
 }
 
+// ReadOnlyCommands are commands that never modify the repository or remote state.
+var ReadOnlyCommands = []string{
+	"ls", "cat", "head", "tail", "find", "grep", "wc", "diff",
+	"echo", "pwd", "date", "which", "file", "stat",
+}
+
+// ReadOnlyGitCommands are git subcommands that only inspect the repository.
+var ReadOnlyGitCommands = []string{
+	"status", "diff", "log", "show", "blame", "rev-parse", "ls-files", "grep", "shortlog",
+}
+
+// ReadOnlyGHCommands are gh subcommands that only read from GitHub.
+var ReadOnlyGHCommands = []string{
+	"pr view", "pr list", "pr diff", "pr checks", "issue view", "issue list", "run view", "run list",
+}
+
+// WritingFlags are the flags of otherwise read-only commands that write
+// files or run other programs. Git's long options may be abbreviated, so any
+// prefix of those counts too.
+var WritingFlags = map[string][]string{
+	"git":  {"--output", "--open-files-in-pager", "-O", "--ext-diff", "--textconv"},
+	"find": {"-delete", "-exec", "-execdir", "-ok", "-okdir", "-fls", "-fprint", "-fprint0", "-fprintf"},
+	"file": {"-C", "--compile"},
+	"date": {"-s", "--set"},
+}
+
+// IsReadOnlyCommand reports whether every command in a (possibly piped or
+// chained) command line is known not to write anything. Anything the shell
+// would expand into another command, redirect or start on a new line makes
+// it not read-only, as does a command line that doesn't parse.
+func IsReadOnlyCommand(command string) bool {
+	// Command substitution runs commands; redirection can write files
+	for _, unsafe := range []string{"`", "$(", "<(", ">", "\n", "\r"} {
+		if strings.Contains(command, unsafe) {
+			return false
+		}
+	}
+
+	commands, ok := SplitCommandLine(command)
+	if !ok {
+		return false
+	}
+	for _, parts := range commands {
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "git":
+			if len(parts) < 2 || !containsString(ReadOnlyGitCommands, parts[1]) {
+				return false
+			}
+		case "gh":
+			if len(parts) < 3 || !containsString(ReadOnlyGHCommands, parts[1]+" "+parts[2]) {
+				return false
+			}
+		default:
+			if !containsString(ReadOnlyCommands, parts[0]) {
+				return false
+			}
+		}
+		for _, arg := range parts[1:] {
+			if isWritingFlag(parts[0], arg) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isWritingFlag reports whether arg is one of the WritingFlags of command.
+func isWritingFlag(command, arg string) bool {
+	name, _, _ := strings.Cut(arg, "=")
+	for _, flag := range WritingFlags[command] {
+		switch {
+		case name == flag:
+			return true
+		case strings.HasPrefix(flag, "--"):
+			// Abbreviated git long options, e.g. --out for --output
+			if command == "git" && len(name) > 2 && strings.HasPrefix(flag, name) {
+				return true
+			}
+		case len(flag) == 2 && command != "find":
+			// A short flag, alone or combined with others, e.g. -nO
+			if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.Contains(arg[1:], flag[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SplitCommandLine splits a command line into its commands, at the pipes,
+// semicolons and ampersands outside quotes, and each command into its words
+// with the quotes removed. It reports false when a quote isn't closed.
+func SplitCommandLine(line string) ([][]string, bool) {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(line) {
+				i++
+				word.WriteByte(line[i])
+			}
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, false
+			}
+			inWord = true
+		case c == '|' || c == ';' || c == '&':
+			endWord()
+			commands = append(commands, words)
+			words = nil
+		case c == ' ' || c == '\t':
+			endWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endWord()
+	return append(commands, words), true
+}
+
+// containsString checks if a slice contains a string.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateCommand checks if a command is safe to execute.
 func ValidateCommand(command string) error {
 	// Trim and normalize
@@ -0,0 +1,144 @@
+// Caching of test results by code state.
+
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCachedResults bounds the results kept; the oldest are removed first
+	maxCachedResults = 500
+	// maxCachedOutput bounds the output kept per result
+	maxCachedOutput = 20 * 1024
+)
+
+// CachedTestResult is the result of a test run at a given code state.
+type CachedTestResult struct {
+	Command string        `json:"command"`
+	Commit  string        `json:"commit"`
+	Passed  bool          `json:"passed"`
+	Output  string        `json:"output"`
+	Took    time.Duration `json:"took"`
+	RanAt   time.Time     `json:"ran_at"`
+}
+
+// TestCache stores test results on disk keyed by the exact code state they
+// ran against, so a run can be skipped when nothing changed since. A nil
+// TestCache caches nothing.
+type TestCache struct {
+	dir string
+}
+
+// NewTestCache creates a test cache in dir, or returns nil if dir is empty.
+func NewTestCache(dir string) *TestCache {
+	if dir == "" {
+		return nil
+	}
+	return &TestCache{dir: dir}
+}
+
+// TestCacheKey returns the cache key for a test command run against a code
+// state: the HEAD commit and the fingerprint of the uncommitted changes.
+func TestCacheKey(commit, changes, command string) string {
+	sum := sha256.Sum256([]byte(commit + "\x00" + changes + "\x00" + command))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached result for key.
+func (c *TestCache) Get(key string) (*CachedTestResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var result CachedTestResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	return &result, true
+}
+
+// Put caches the result for key, removing the oldest results beyond the
+// cache's capacity.
+func (c *TestCache) Put(key string, result CachedTestResult) error {
+	if c == nil {
+		return nil
+	}
+	if len(result.Output) > maxCachedOutput {
+		result.Output = "... (truncated)\n" + result.Output[len(result.Output)-maxCachedOutput:]
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode test result: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create test cache directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write test result: %w", err)
+	}
+	return c.prune()
+}
+
+// prune removes the oldest results beyond maxCachedResults.
+func (c *TestCache) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list test cache: %w", err)
+	}
+	type cached struct {
+		name    string
+		modTime time.Time
+	}
+	var results []cached
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			results = append(results, cached{entry.Name(), info.ModTime()})
+		}
+	}
+	if len(results) <= maxCachedResults {
+		return nil
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].modTime.Before(results[j].modTime)
+	})
+	for _, r := range results[:len(results)-maxCachedResults] {
+		os.Remove(filepath.Join(c.dir, r.name))
+	}
+	return nil
+}
+
+// FingerprintChanges summarizes the content of changed files under repoPath,
+// so that any edit to them, including untracked files, changes the result.
+// Deleted files are recorded as such.
+func FingerprintChanges(repoPath string, files []string) string {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, file := range sorted {
+		h.Write([]byte(file + "\x00"))
+		if data, err := os.ReadFile(filepath.Join(repoPath, file)); err == nil {
+			h.Write(data)
+		} else {
+			h.Write([]byte("(deleted)"))
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
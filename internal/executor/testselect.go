@@ -0,0 +1,96 @@
+// Commands running a selection of tests.
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SelectTestCommand returns a command running only the given test files.
+// template may use the placeholders {files} (the test files), {packages}
+// (their directories, as ./dir) and {classes} (their comma-separated base
+// names, e.g. for Maven's -Dtest). When template is empty the command is
+// chosen from the test files' language: go test, Maven or Gradle, Jest or
+// pytest.
+func SelectTestCommand(template, repoPath string, tests []string) (string, error) {
+	if len(tests) == 0 {
+		return "", fmt.Errorf("no tests selected")
+	}
+
+	var files, packages, classes []string
+	seen := make(map[string]bool)
+	for _, test := range tests {
+		test = filepath.ToSlash(test)
+		files = append(files, shellQuote(test))
+		classes = append(classes, strings.TrimSuffix(path.Base(test), path.Ext(test)))
+		pkg := "./" + path.Dir(test)
+		if path.Dir(test) == "." {
+			pkg = "."
+		}
+		if !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, shellQuote(pkg))
+		}
+	}
+	sort.Strings(packages)
+
+	if template == "" {
+		var err error
+		if template, err = defaultTestTemplate(repoPath, tests, classes); err != nil {
+			return "", err
+		}
+	}
+
+	command := strings.ReplaceAll(template, "{files}", strings.Join(files, " "))
+	command = strings.ReplaceAll(command, "{packages}", strings.Join(packages, " "))
+	command = strings.ReplaceAll(command, "{classes}", shellQuote(strings.Join(classes, ",")))
+	return command, nil
+}
+
+// defaultTestTemplate picks the command template for tests written in one
+// language.
+func defaultTestTemplate(repoPath string, tests, classes []string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(tests[0]))
+	for _, test := range tests[1:] {
+		if strings.ToLower(filepath.Ext(test)) != ext {
+			return "", fmt.Errorf("the selected tests are in several languages; set STORMSTACK_TEST_SELECT_CMD")
+		}
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(repoPath, name))
+		return err == nil
+	}
+
+	switch ext {
+	case ".go":
+		return "go test {packages}", nil
+	case ".java", ".kt":
+		switch {
+		case exists("pom.xml"):
+			return "mvn -q test -Dtest={classes} -Dsurefire.failIfNoSpecifiedTests=false", nil
+		case exists("build.gradle"), exists("build.gradle.kts"):
+			var args []string
+			for _, class := range classes {
+				args = append(args, "--tests "+shellQuote(class))
+			}
+			return "./gradlew test " + strings.Join(args, " "), nil
+		}
+		return "", fmt.Errorf("no pom.xml or build.gradle found; set STORMSTACK_TEST_SELECT_CMD")
+	case ".js", ".jsx", ".ts", ".tsx":
+		return "npx jest {files}", nil
+	case ".py":
+		return "python -m pytest {files}", nil
+	}
+	return "", fmt.Errorf("don't know how to run %s tests selectively; set STORMSTACK_TEST_SELECT_CMD", ext)
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
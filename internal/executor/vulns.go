@@ -0,0 +1,533 @@
+// Vulnerability scanning of dependencies.
+
+package executor
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Vulnerability scanners.
+const (
+	ScannerGovulncheck = "govulncheck"
+	ScannerNpmAudit    = "npm-audit"
+	ScannerOSV         = "osv-scanner"
+)
+
+// scannerCommands are the commands that run each scanner with JSON output.
+var scannerCommands = map[string]string{
+	ScannerGovulncheck: "govulncheck -json ./...",
+	ScannerNpmAudit:    "npm audit --json",
+	ScannerOSV:         "osv-scanner --format json -r .",
+}
+
+// severityRank orders severities, most severe first.
+var severityRank = map[string]int{"CRITICAL": 0, "HIGH": 1, "MODERATE": 2, "MEDIUM": 2, "LOW": 3}
+
+// Vulnerability is a known vulnerability affecting one of the project's
+// dependencies.
+type Vulnerability struct {
+	// ID is the advisory's ID, e.g. GO-2024-2687 or GHSA-xxxx-xxxx-xxxx
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Package string   `json:"package"`
+	Version string   `json:"version,omitempty"`
+	// FixedIn is the first version without the vulnerability, if there is one
+	FixedIn  string `json:"fixed_in,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	// Called is set when govulncheck found the vulnerable code is reachable
+	Called   bool     `json:"called,omitempty"`
+	Scanners []string `json:"scanners"`
+}
+
+// Matches reports whether the vulnerability is known by id, such as a CVE.
+func (v Vulnerability) Matches(id string) bool {
+	if strings.EqualFold(v.ID, id) {
+		return true
+	}
+	for _, alias := range v.Aliases {
+		if strings.EqualFold(alias, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// VulnScan is the result of scanning a project with one or more scanners.
+type VulnScan struct {
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	// Scanned are the scanners that ran; Skipped maps the others to why
+	Scanned []string          `json:"scanned"`
+	Skipped map[string]string `json:"skipped,omitempty"`
+}
+
+// Matching returns the vulnerabilities known by id, or all of them when id
+// is empty.
+func (s *VulnScan) Matching(id string) []Vulnerability {
+	if id == "" {
+		return s.Vulnerabilities
+	}
+	var matched []Vulnerability
+	for _, v := range s.Vulnerabilities {
+		if v.Matches(id) {
+			matched = append(matched, v)
+		}
+	}
+	return matched
+}
+
+// ScannersFor returns the scanners for a project's ecosystems: govulncheck
+// for Go, npm audit for npm and osv-scanner for the rest.
+func ScannersFor(ecosystems []Ecosystem) []string {
+	var scanners []string
+	osv := false
+	for _, ecosystem := range ecosystems {
+		switch ecosystem.Name {
+		case EcosystemGo:
+			scanners = append(scanners, ScannerGovulncheck)
+		case EcosystemNpm:
+			scanners = append(scanners, ScannerNpmAudit)
+		default:
+			if !osv {
+				osv = true
+				scanners = append(scanners, ScannerOSV)
+			}
+		}
+	}
+	return scanners
+}
+
+// ScanVulnerabilities runs the scanners and merges their findings, so an
+// advisory reported by several scanners or under several IDs is listed once
+// per package. Scanners that are not installed or fail are skipped.
+func (r *Runner) ScanVulnerabilities(ctx context.Context, scanners []string) *VulnScan {
+	scan := &VulnScan{Skipped: make(map[string]string)}
+	for _, scanner := range scanners {
+		command := scannerCommands[scanner]
+		if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+			scan.Skipped[scanner] = "not installed"
+			continue
+		}
+
+		result, err := r.executeCommand(ctx, command)
+		if err != nil {
+			scan.Skipped[scanner] = err.Error()
+			continue
+		}
+		if result.TimedOut {
+			scan.Skipped[scanner] = "timed out"
+			continue
+		}
+
+		// Scanners exit non-zero when they find something, so success is judged
+		// by whether the output parses
+		found, err := parseScannerOutput(scanner, result.Stdout)
+		if err != nil {
+			scan.Skipped[scanner] = fmt.Sprintf("%v (exit code %d): %s", err, result.ExitCode, firstLine(result.Stderr))
+			continue
+		}
+		scan.Scanned = append(scan.Scanned, scanner)
+		scan.Vulnerabilities = mergeVulnerabilities(scan.Vulnerabilities, found)
+	}
+
+	sort.SliceStable(scan.Vulnerabilities, func(i, j int) bool {
+		a, b := scan.Vulnerabilities[i], scan.Vulnerabilities[j]
+		if ra, rb := severityOrder(a.Severity), severityOrder(b.Severity); ra != rb {
+			return ra < rb
+		}
+		if a.Called != b.Called {
+			return a.Called
+		}
+		return a.Package < b.Package
+	})
+	return scan
+}
+
+// parseScannerOutput parses a scanner's JSON output.
+func parseScannerOutput(scanner, output string) ([]Vulnerability, error) {
+	if strings.TrimSpace(output) == "" {
+		return nil, errors.New("no output")
+	}
+	switch scanner {
+	case ScannerGovulncheck:
+		return parseGovulncheck(output)
+	case ScannerNpmAudit:
+		return parseNpmAudit(output)
+	case ScannerOSV:
+		return parseOSVScanner(output)
+	default:
+		return nil, fmt.Errorf("unknown scanner %q", scanner)
+	}
+}
+
+// osvEntry is the part of an OSV advisory the parsers use.
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// summary returns the advisory's summary, or the start of its details.
+func (o osvEntry) summary() string {
+	if o.Summary != "" {
+		return o.Summary
+	}
+	return firstLine(o.Details)
+}
+
+// fixedIn returns the first fixed version of pkg the advisory lists.
+func (o osvEntry) fixedIn(pkg string) string {
+	for _, affected := range o.Affected {
+		if pkg != "" && affected.Package.Name != pkg {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					return event.Fixed
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// parseGovulncheck parses the stream of JSON messages govulncheck -json
+// writes: OSV entries, then findings referring to them. A finding whose
+// trace reaches a function means the vulnerable code is called.
+func parseGovulncheck(output string) ([]Vulnerability, error) {
+	type frame struct {
+		Module   string `json:"module"`
+		Version  string `json:"version"`
+		Function string `json:"function"`
+	}
+	type message struct {
+		OSV     *osvEntry `json:"osv"`
+		Finding *struct {
+			OSV          string  `json:"osv"`
+			FixedVersion string  `json:"fixed_version"`
+			Trace        []frame `json:"trace"`
+		} `json:"finding"`
+	}
+
+	entries := make(map[string]osvEntry)
+	byKey := make(map[string]int)
+	var vulns []Vulnerability
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var msg message
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+		if msg.OSV != nil {
+			entries[msg.OSV.ID] = *msg.OSV
+		}
+		if msg.Finding == nil || len(msg.Finding.Trace) == 0 {
+			continue
+		}
+
+		finding := msg.Finding
+		module := finding.Trace[0]
+		called := false
+		for _, f := range finding.Trace {
+			called = called || f.Function != ""
+		}
+		key := finding.OSV + "|" + module.Module
+		if i, ok := byKey[key]; ok {
+			vulns[i].Called = vulns[i].Called || called
+			continue
+		}
+		entry := entries[finding.OSV]
+		byKey[key] = len(vulns)
+		vulns = append(vulns, Vulnerability{
+			ID:       finding.OSV,
+			Aliases:  entry.Aliases,
+			Package:  module.Module,
+			Version:  module.Version,
+			FixedIn:  finding.FixedVersion,
+			Severity: strings.ToUpper(entry.DatabaseSpecific.Severity),
+			Summary:  entry.summary(),
+			Called:   called,
+			Scanners: []string{ScannerGovulncheck},
+		})
+	}
+	return vulns, nil
+}
+
+// parseNpmAudit parses npm audit --json output (npm 7 and later), listing
+// each advisory against the package it is in.
+func parseNpmAudit(output string) ([]Vulnerability, error) {
+	var report struct {
+		Vulnerabilities map[string]struct {
+			Name         string            `json:"name"`
+			Severity     string            `json:"severity"`
+			Via          []json.RawMessage `json:"via"`
+			FixAvailable json.RawMessage   `json:"fixAvailable"`
+		} `json:"vulnerabilities"`
+		Error *struct {
+			Summary string `json:"summary"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse npm audit output: %w", err)
+	}
+	if report.Error != nil {
+		return nil, fmt.Errorf("npm audit failed: %s", report.Error.Summary)
+	}
+
+	var vulns []Vulnerability
+	for name, pkg := range report.Vulnerabilities {
+		var fix struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		fixedIn := ""
+		if json.Unmarshal(pkg.FixAvailable, &fix) == nil && fix.Name == name {
+			fixedIn = fix.Version
+		}
+
+		for _, raw := range pkg.Via {
+			// Entries naming another package say the vulnerability is inherited
+			// through it, and are reported under that package
+			var via struct {
+				Source   json.Number `json:"source"`
+				Name     string      `json:"name"`
+				Title    string      `json:"title"`
+				URL      string      `json:"url"`
+				Severity string      `json:"severity"`
+				Range    string      `json:"range"`
+			}
+			if json.Unmarshal(raw, &via) != nil || via.URL == "" {
+				continue
+			}
+			id := path.Base(via.URL)
+			if id == "" || id == "." {
+				id = via.Source.String()
+			}
+			vulns = append(vulns, Vulnerability{
+				ID:       id,
+				Package:  name,
+				FixedIn:  fixedIn,
+				Severity: strings.ToUpper(cmp.Or(via.Severity, pkg.Severity)),
+				Summary:  fmt.Sprintf("%s (affects %s)", via.Title, via.Range),
+				Scanners: []string{ScannerNpmAudit},
+			})
+		}
+	}
+	sort.Slice(vulns, func(i, j int) bool {
+		return vulns[i].Package+vulns[i].ID < vulns[j].Package+vulns[j].ID
+	})
+	return vulns, nil
+}
+
+// parseOSVScanner parses osv-scanner --format json output.
+func parseOSVScanner(output string) ([]Vulnerability, error) {
+	var report struct {
+		Results []struct {
+			Packages []struct {
+				Package struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"package"`
+				Vulnerabilities []osvEntry `json:"vulnerabilities"`
+			} `json:"packages"`
+		} `json:"results"`
+	}
+	// osv-scanner may log to stdout before the JSON document
+	if i := strings.Index(output, "{"); i > 0 {
+		output = output[i:]
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse osv-scanner output: %w", err)
+	}
+
+	var vulns []Vulnerability
+	for _, result := range report.Results {
+		for _, pkg := range result.Packages {
+			for _, entry := range pkg.Vulnerabilities {
+				vulns = mergeVulnerabilities(vulns, []Vulnerability{{
+					ID:       entry.ID,
+					Aliases:  entry.Aliases,
+					Package:  pkg.Package.Name,
+					Version:  pkg.Package.Version,
+					FixedIn:  entry.fixedIn(pkg.Package.Name),
+					Severity: strings.ToUpper(entry.DatabaseSpecific.Severity),
+					Summary:  entry.summary(),
+					Scanners: []string{ScannerOSV},
+				}})
+			}
+		}
+	}
+	return vulns, nil
+}
+
+// mergeVulnerabilities adds found to vulns, merging each into an existing
+// finding for the same package that shares an ID or alias with it.
+func mergeVulnerabilities(vulns, found []Vulnerability) []Vulnerability {
+	for _, v := range found {
+		merged := false
+		for i := range vulns {
+			if vulns[i].Package != v.Package || !sharesID(vulns[i], v) {
+				continue
+			}
+			existing := &vulns[i]
+			existing.Aliases = appendMissing(existing.Aliases, append([]string{v.ID}, v.Aliases...), existing.ID)
+			existing.Scanners = appendMissing(existing.Scanners, v.Scanners, "")
+			existing.Called = existing.Called || v.Called
+			existing.FixedIn = cmp.Or(existing.FixedIn, v.FixedIn)
+			existing.Severity = cmp.Or(existing.Severity, v.Severity)
+			existing.Summary = cmp.Or(existing.Summary, v.Summary)
+			merged = true
+			break
+		}
+		if !merged {
+			vulns = append(vulns, v)
+		}
+	}
+	return vulns
+}
+
+// sharesID reports whether two findings name the same advisory.
+func sharesID(a, b Vulnerability) bool {
+	if a.Matches(b.ID) {
+		return true
+	}
+	for _, alias := range b.Aliases {
+		if a.Matches(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMissing appends the values not yet in list, other than skip.
+func appendMissing(list, values []string, skip string) []string {
+	for _, value := range values {
+		missing := value != skip
+		for _, existing := range list {
+			missing = missing && existing != value
+		}
+		if missing {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// severityOrder ranks a severity for sorting, unknown severities last.
+func severityOrder(severity string) int {
+	if rank, ok := severityRank[severity]; ok {
+		return rank
+	}
+	return len(severityRank)
+}
+
+// firstLine returns the first non-empty line of s.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Format describes the scan, or only the findings for id when it is set.
+func (s *VulnScan) Format(id string) string {
+	var sb strings.Builder
+	vulns := s.Matching(id)
+
+	switch {
+	case len(s.Scanned) == 0:
+		sb.WriteString("No vulnerability scanner could run.\n")
+	case id != "" && len(vulns) == 0:
+		sb.WriteString(fmt.Sprintf("Not affected: no dependency is affected by %s according to %s.\n", id, strings.Join(s.Scanned, ", ")))
+	case id != "":
+		sb.WriteString(fmt.Sprintf("Affected: %s applies to %d dependencies.\n", id, len(vulns)))
+	case len(vulns) == 0:
+		sb.WriteString(fmt.Sprintf("No known vulnerabilities (scanned with %s).\n", strings.Join(s.Scanned, ", ")))
+	default:
+		packages := make(map[string]bool)
+		for _, v := range vulns {
+			packages[v.Package] = true
+		}
+		sb.WriteString(fmt.Sprintf("%d vulnerabilities in %d packages (scanned with %s):\n", len(vulns), len(packages), strings.Join(s.Scanned, ", ")))
+	}
+
+	for _, v := range vulns {
+		sb.WriteString("\n" + v.format())
+	}
+	if len(s.Skipped) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, scanner := range sortedKeys(s.Skipped) {
+		sb.WriteString(fmt.Sprintf("\nSkipped %s: %s", scanner, s.Skipped[scanner]))
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// format describes one vulnerability on a line.
+func (v Vulnerability) format() string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if v.Severity != "" {
+		b.WriteString("[" + v.Severity + "] ")
+	}
+	b.WriteString(v.ID)
+	if len(v.Aliases) > 0 {
+		b.WriteString(" (" + strings.Join(v.Aliases, ", ") + ")")
+	}
+	b.WriteString(" in " + v.Package)
+	if v.Version != "" {
+		b.WriteString(" " + v.Version)
+	}
+	if v.FixedIn != "" {
+		b.WriteString(", fixed in " + v.FixedIn)
+	} else {
+		b.WriteString(", no fix available")
+	}
+	if v.Called {
+		b.WriteString(", vulnerable code is called")
+	}
+	if v.Summary != "" {
+		b.WriteString(": " + v.Summary)
+	}
+	return b.String()
+}
+
+// sortedKeys returns a map's keys in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,794 @@
+// Bitbucket Cloud operations via the REST API.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBitbucketURL is the Bitbucket Cloud API root.
+const DefaultBitbucketURL = "https://api.bitbucket.org/2.0"
+
+// Bitbucket provides forge operations using the Bitbucket Cloud REST API, so
+// no CLI needs to be installed. Issues use the repository's issue tracker,
+// where components stand in for labels.
+type Bitbucket struct {
+	baseURL string
+	token   string
+	project string
+	gitOps  Operations
+	http    *http.Client
+}
+
+// NewBitbucket creates a Bitbucket forge for the repository at repoPath. The
+// token is either an access token or "username:app-password".
+func NewBitbucket(repoPath string, cfg ForgeConfig) (*Bitbucket, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("a Bitbucket token is required")
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBitbucketURL
+	}
+
+	gitOps, err := NewOperations(repoPath, cfg.GitBackend)
+	if err != nil {
+		return nil, err
+	}
+	project := cfg.Project
+	if project == "" {
+		remote, err := gitOps.GetRemoteURL(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine Bitbucket repository: %w", err)
+		}
+		project = remoteProject(remote)
+	}
+	if strings.Count(project, "/") != 1 {
+		return nil, fmt.Errorf("invalid Bitbucket repository %q, expected workspace/repo", project)
+	}
+
+	return &Bitbucket{
+		baseURL: baseURL,
+		token:   cfg.Token,
+		project: project,
+		gitOps:  gitOps,
+		http:    &http.Client{Timeout: CommandTimeout},
+	}, nil
+}
+
+// Name returns the forge identifier.
+func (b *Bitbucket) Name() string {
+	return ForgeBitbucket
+}
+
+// Bitbucket API objects.
+type bbUser struct {
+	AccountID   string `json:"account_id"`
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+// name returns the handle shown for a user.
+func (u bbUser) name() string {
+	if u.Nickname != "" {
+		return u.Nickname
+	}
+	return u.DisplayName
+}
+
+type bbLink struct {
+	HTML struct {
+		Href string `json:"href"`
+	} `json:"html"`
+}
+
+type bbBranchRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+type bbPullRequest struct {
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	State       string      `json:"state"`
+	Description string      `json:"description"`
+	CreatedOn   string      `json:"created_on"`
+	Author      bbUser      `json:"author"`
+	Source      bbBranchRef `json:"source"`
+	Destination bbBranchRef `json:"destination"`
+	Links       bbLink      `json:"links"`
+	MergeCommit *struct {
+		Hash string `json:"hash"`
+	} `json:"merge_commit"`
+}
+
+type bbIssue struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Component *struct {
+		Name string `json:"name"`
+	} `json:"component"`
+	Assignee  *bbUser `json:"assignee"`
+	CreatedOn string  `json:"created_on"`
+	Links     bbLink  `json:"links"`
+}
+
+type bbComment struct {
+	ID      int `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	User    bbUser `json:"user"`
+	Deleted bool   `json:"deleted"`
+	Inline  *struct {
+		Path string `json:"path"`
+		To   int    `json:"to"`
+	} `json:"inline"`
+	Links bbLink `json:"links"`
+
+	Parent *struct {
+		ID int `json:"id"`
+	} `json:"parent"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// toPRInfo converts a Bitbucket pull request into the forge-neutral PR info.
+func (pr *bbPullRequest) toPRInfo() *PRInfo {
+	info := &PRInfo{
+		Number:    pr.ID,
+		Title:     pr.Title,
+		URL:       pr.Links.HTML.Href,
+		State:     pr.State,
+		HeadRef:   pr.Source.Branch.Name,
+		BaseRef:   pr.Destination.Branch.Name,
+		Body:      pr.Description,
+		CreatedAt: pr.CreatedOn,
+		Author:    pr.Author.name(),
+	}
+	if pr.MergeCommit != nil {
+		info.MergeCommit = pr.MergeCommit.Hash
+	}
+	return info
+}
+
+// toIssueInfo converts a Bitbucket issue into the forge-neutral issue info.
+func (i *bbIssue) toIssueInfo() IssueInfo {
+	issue := IssueInfo{
+		Number:    i.ID,
+		Title:     i.Title,
+		URL:       i.Links.HTML.Href,
+		State:     strings.ToUpper(i.State),
+		Body:      i.Content.Raw,
+		CreatedAt: i.CreatedOn,
+	}
+	if i.Component != nil {
+		issue.Labels = []IssueLabel{{Name: i.Component.Name}}
+	}
+	if i.Assignee != nil {
+		issue.Assignees = []IssueUser{{Login: i.Assignee.name()}}
+	}
+	return issue
+}
+
+// CreatePR opens a pull request from the current branch.
+func (b *Bitbucket) CreatePR(ctx context.Context, title, body, base string, draft bool) (*PRInfo, error) {
+	branch, err := b.gitOps.CurrentBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	params := map[string]any{
+		"title":       title,
+		"description": body,
+		"draft":       draft,
+		"source":      map[string]any{"branch": map[string]any{"name": branch}},
+	}
+	// Without a destination Bitbucket uses the main branch
+	if base != "" {
+		params["destination"] = map[string]any{"branch": map[string]any{"name": base}}
+	}
+
+	var pr bbPullRequest
+	if err := b.api(ctx, http.MethodPost, "/pullrequests", params, &pr); err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return pr.toPRInfo(), nil
+}
+
+// DefaultBranch returns the repository's main branch.
+func (b *Bitbucket) DefaultBranch(ctx context.Context) (string, error) {
+	var repo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := b.api(ctx, http.MethodGet, "", nil, &repo); err != nil {
+		return "", fmt.Errorf("failed to get main branch: %w", err)
+	}
+	return repo.MainBranch.Name, nil
+}
+
+// BranchProtection returns the branch restrictions applying to branch by
+// name or glob, or nil when there are none. Restrictions by branching model
+// type aren't resolved.
+func (b *Bitbucket) BranchProtection(ctx context.Context, branch string) (*BranchProtection, error) {
+	var page struct {
+		Values []struct {
+			Kind    string `json:"kind"`
+			Pattern string `json:"pattern"`
+			Match   string `json:"branch_match_kind"`
+			Value   *int   `json:"value"`
+		} `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, "/branch-restrictions?pagelen=100", nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list branch restrictions: %w", err)
+	}
+
+	var p *BranchProtection
+	for _, r := range page.Values {
+		if r.Match != "glob" || !branchMatches(r.Pattern, branch) {
+			continue
+		}
+		if p == nil {
+			p = &BranchProtection{Branch: branch}
+		}
+		switch r.Kind {
+		case "require_approvals_to_merge":
+			if r.Value != nil {
+				p.RequiredReviews = max(p.RequiredReviews, *r.Value)
+			}
+		case "require_default_reviewer_approvals_to_merge":
+			p.CodeOwnerReviews = true
+		case "require_passing_builds_to_merge":
+			p.addCheck("passing builds")
+		case "push":
+			p.PushRestricted = true
+		}
+	}
+	return p, nil
+}
+
+// GetPR gets information about a pull request.
+func (b *Bitbucket) GetPR(ctx context.Context, number int) (*PRInfo, error) {
+	pr, err := b.getPullRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return pr.toPRInfo(), nil
+}
+
+// ListPRs lists pull requests.
+func (b *Bitbucket) ListPRs(ctx context.Context, state string, limit int) ([]PRInfo, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if state == "" {
+		state = "open"
+	}
+
+	path := fmt.Sprintf("/pullrequests?pagelen=%d", limit)
+	if !strings.EqualFold(state, "all") {
+		path += "&state=" + strings.ToUpper(state)
+	}
+
+	var page struct {
+		Values []bbPullRequest `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	prs := make([]PRInfo, len(page.Values))
+	for i := range page.Values {
+		prs[i] = *page.Values[i].toPRInfo()
+	}
+	return prs, nil
+}
+
+// PRNumber returns the number of a pull request of the repository given as a
+// number or URL. The web host is the API's without its "api." prefix.
+func (b *Bitbucket) PRNumber(ctx context.Context, prRef string) (int, error) {
+	webURL := b.baseURL
+	if u, err := url.Parse(b.baseURL); err == nil {
+		webURL = "https://" + strings.TrimPrefix(u.Host, "api.")
+	}
+	return parsePRRef(prRef, webURL, b.project)
+}
+
+// GetPRForReview gets a pull request with its diff for code review.
+func (b *Bitbucket) GetPRForReview(ctx context.Context, prRef string) (*PRDetails, error) {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := b.getPullRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	details := &PRDetails{Info: pr.toPRInfo()}
+
+	diff, err := b.raw(ctx, fmt.Sprintf("/pullrequests/%d/diff", number))
+	if err != nil {
+		diff = "Failed to get diff: " + err.Error()
+	}
+	details.Diff = diff
+
+	var stat struct {
+		Values []struct {
+			New *struct {
+				Path string `json:"path"`
+			} `json:"new"`
+			Old *struct {
+				Path string `json:"path"`
+			} `json:"old"`
+		} `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, fmt.Sprintf("/pullrequests/%d/diffstat?pagelen=500", number), nil, &stat); err == nil {
+		for _, v := range stat.Values {
+			switch {
+			case v.New != nil:
+				details.FilesChanged = append(details.FilesChanged, v.New.Path)
+			case v.Old != nil:
+				details.FilesChanged = append(details.FilesChanged, v.Old.Path)
+			}
+		}
+	}
+	return details, nil
+}
+
+// GetPRReviewComments gets the general and inline comments on a pull request.
+func (b *Bitbucket) GetPRReviewComments(ctx context.Context, prRef string) ([]ReviewComment, error) {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var page struct {
+		Values []bbComment `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, fmt.Sprintf("/pullrequests/%d/comments?pagelen=100", number), nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to get pull request comments: %w", err)
+	}
+
+	// Replies name their parent; threads are named after the comment
+	// starting them
+	parents := make(map[int]int)
+	for _, c := range page.Values {
+		if c.Parent != nil {
+			parents[c.ID] = c.Parent.ID
+		}
+	}
+	root := func(id int) int {
+		for seen := 0; seen < len(page.Values); seen++ {
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+		return id
+	}
+
+	var comments []ReviewComment
+	for _, c := range page.Values {
+		if c.Deleted || strings.TrimSpace(c.Content.Raw) == "" {
+			continue
+		}
+		comment := ReviewComment{
+			Author:    c.User.name(),
+			Body:      c.Content.Raw,
+			ID:        strconv.Itoa(c.ID),
+			Thread:    strconv.Itoa(root(c.ID)),
+			CreatedAt: c.CreatedOn,
+		}
+		if c.Inline != nil {
+			comment.Path = c.Inline.Path
+			comment.Line = c.Inline.To
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// ReplyToComment replies to a pull request comment in its thread and returns
+// the reply's URL.
+func (b *Bitbucket) ReplyToComment(ctx context.Context, prRef string, comment ReviewComment, body string) (string, error) {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	parent, err := strconv.Atoi(comment.ID)
+	if err != nil {
+		return "", fmt.Errorf("invalid comment ID: %s", comment.ID)
+	}
+
+	url, err := b.comment(ctx, fmt.Sprintf("/pullrequests/%d/comments", number), map[string]any{
+		"content": map[string]any{"raw": body},
+		"parent":  map[string]any{"id": parent},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to reply to comment: %w", err)
+	}
+	return url, nil
+}
+
+// SubmitReview approves a pull request or requests changes, posting the body
+// as a comment.
+func (b *Bitbucket) SubmitReview(ctx context.Context, prRef, event, body string) error {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return err
+	}
+
+	switch event {
+	case ReviewApprove:
+		if err := b.api(ctx, http.MethodPost, fmt.Sprintf("/pullrequests/%d/approve", number), nil, nil); err != nil {
+			return fmt.Errorf("failed to approve pull request: %w", err)
+		}
+		if body == "" {
+			return nil
+		}
+	case ReviewRequestChanges:
+		if body == "" {
+			return fmt.Errorf("a review body is required for %s", event)
+		}
+		if err := b.api(ctx, http.MethodPost, fmt.Sprintf("/pullrequests/%d/request-changes", number), nil, nil); err != nil {
+			return fmt.Errorf("failed to request changes: %w", err)
+		}
+	case ReviewCommentOnly:
+		if body == "" {
+			return fmt.Errorf("a review body is required for %s", event)
+		}
+	default:
+		return fmt.Errorf("invalid review event: %s", event)
+	}
+
+	if _, err := b.comment(ctx, fmt.Sprintf("/pullrequests/%d/comments", number), map[string]any{
+		"content": map[string]any{"raw": body},
+	}); err != nil {
+		return fmt.Errorf("failed to submit review: %w", err)
+	}
+	return nil
+}
+
+// AddInlineComment comments on a line of a file in a pull request and returns
+// the comment URL.
+func (b *Bitbucket) AddInlineComment(ctx context.Context, prRef, path string, line int, body string) (string, error) {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := b.comment(ctx, fmt.Sprintf("/pullrequests/%d/comments", number), map[string]any{
+		"content": map[string]any{"raw": body},
+		"inline":  map[string]any{"path": path, "to": line},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add inline comment: %w", err)
+	}
+	return url, nil
+}
+
+// AddIssueComment posts a comment on an issue and returns the comment URL.
+func (b *Bitbucket) AddIssueComment(ctx context.Context, number int, body string) (string, error) {
+	url, err := b.comment(ctx, fmt.Sprintf("/issues/%d/comments", number), map[string]any{
+		"content": map[string]any{"raw": body},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add comment: %w", err)
+	}
+	return url, nil
+}
+
+// MergePR merges a pull request. Bitbucket has no rebase merge, so only
+// squash and merge commits are accepted.
+func (b *Bitbucket) MergePR(ctx context.Context, prRef, strategy string, deleteBranch bool) error {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]any{"close_source_branch": deleteBranch}
+	switch strategy {
+	case "", MergeSquash:
+		params["merge_strategy"] = "squash"
+	case MergeCommit:
+		params["merge_strategy"] = "merge_commit"
+	case MergeRebase:
+		return fmt.Errorf("rebase merges are not supported on Bitbucket, use squash or merge")
+	default:
+		return fmt.Errorf("invalid merge strategy: %s", strategy)
+	}
+
+	if err := b.api(ctx, http.MethodPost, fmt.Sprintf("/pullrequests/%d/merge", number), params, nil); err != nil {
+		return fmt.Errorf("failed to merge pull request: %w", err)
+	}
+	return nil
+}
+
+// GetPRChecks gets the build statuses (including Pipelines) of a pull request.
+func (b *Bitbucket) GetPRChecks(ctx context.Context, prRef string) ([]CheckRun, error) {
+	number, err := b.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var page struct {
+		Values []struct {
+			Key   string `json:"key"`
+			Name  string `json:"name"`
+			State string `json:"state"`
+			URL   string `json:"url"`
+		} `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, fmt.Sprintf("/pullrequests/%d/statuses?pagelen=100", number), nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to get pull request statuses: %w", err)
+	}
+
+	checks := make([]CheckRun, 0, len(page.Values))
+	for _, s := range page.Values {
+		name := s.Name
+		if name == "" {
+			name = s.Key
+		}
+		state := CheckPending
+		switch s.State {
+		case "SUCCESSFUL":
+			state = CheckPassed
+		case "FAILED", "STOPPED":
+			state = CheckFailed
+		}
+		checks = append(checks, CheckRun{Name: name, State: state, URL: s.URL})
+	}
+	return checks, nil
+}
+
+// GetIssue gets information about an issue.
+func (b *Bitbucket) GetIssue(ctx context.Context, number int) (*IssueInfo, error) {
+	var issue bbIssue
+	if err := b.api(ctx, http.MethodGet, fmt.Sprintf("/issues/%d", number), nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	info := issue.toIssueInfo()
+	return &info, nil
+}
+
+// ListIssues lists issues. "open" covers Bitbucket's new and open states.
+func (b *Bitbucket) ListIssues(ctx context.Context, state string, limit int) ([]IssueInfo, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var query string
+	switch strings.ToLower(state) {
+	case "", "open":
+		query = `state="new" OR state="open"`
+	case "closed":
+		query = `state="resolved" OR state="closed"`
+	case "all":
+	default:
+		query = fmt.Sprintf("state=%q", strings.ToLower(state))
+	}
+
+	path := fmt.Sprintf("/issues?pagelen=%d", limit)
+	if query != "" {
+		path += "&q=" + url.QueryEscape(query)
+	}
+
+	var page struct {
+		Values []bbIssue `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	issues := make([]IssueInfo, len(page.Values))
+	for i := range page.Values {
+		issues[i] = page.Values[i].toIssueInfo()
+	}
+	return issues, nil
+}
+
+// ListLabels lists the issue tracker's components, Bitbucket's closest
+// equivalent to labels.
+func (b *Bitbucket) ListLabels(ctx context.Context) ([]string, error) {
+	var page struct {
+		Values []struct {
+			Name string `json:"name"`
+		} `json:"values"`
+	}
+	if err := b.api(ctx, http.MethodGet, "/components?pagelen=100", nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list components: %w", err)
+	}
+
+	names := make([]string, len(page.Values))
+	for i, c := range page.Values {
+		names[i] = c.Name
+	}
+	return names, nil
+}
+
+// ListAssignees lists the nicknames of the workspace's members.
+func (b *Bitbucket) ListAssignees(ctx context.Context) ([]string, error) {
+	members, err := b.members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.name()
+	}
+	return names, nil
+}
+
+// CreateIssue opens an issue. Labels map to the issue's component, so only
+// the first is used.
+func (b *Bitbucket) CreateIssue(ctx context.Context, title, body string, labels []string) (*IssueInfo, error) {
+	params := map[string]any{
+		"title":   title,
+		"content": map[string]any{"raw": body},
+	}
+	if len(labels) > 0 {
+		params["component"] = map[string]any{"name": labels[0]}
+	}
+
+	var issue bbIssue
+	if err := b.api(ctx, http.MethodPost, "/issues", params, &issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	info := issue.toIssueInfo()
+	return &info, nil
+}
+
+// EditIssue sets an issue's component and assignee. Bitbucket issues hold a
+// single component and assignee, so only the first of each is used.
+func (b *Bitbucket) EditIssue(ctx context.Context, number int, addLabels, addAssignees []string) error {
+	params := map[string]any{}
+	if len(addLabels) > 0 {
+		params["component"] = map[string]any{"name": addLabels[0]}
+	}
+	if len(addAssignees) > 0 {
+		members, err := b.members(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			if m.name() == addAssignees[0] {
+				params["assignee"] = map[string]any{"account_id": m.AccountID}
+				break
+			}
+		}
+		if params["assignee"] == nil {
+			return fmt.Errorf("unknown Bitbucket user: %s", addAssignees[0])
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	if err := b.api(ctx, http.MethodPut, fmt.Sprintf("/issues/%d", number), params, nil); err != nil {
+		return fmt.Errorf("failed to edit issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// getPullRequest fetches a pull request by ID.
+func (b *Bitbucket) getPullRequest(ctx context.Context, number int) (*bbPullRequest, error) {
+	var pr bbPullRequest
+	if err := b.api(ctx, http.MethodGet, fmt.Sprintf("/pullrequests/%d", number), nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// members lists the members of the repository's workspace.
+func (b *Bitbucket) members(ctx context.Context) ([]bbUser, error) {
+	workspace := strings.SplitN(b.project, "/", 2)[0]
+
+	var page struct {
+		Values []struct {
+			User bbUser `json:"user"`
+		} `json:"values"`
+	}
+	if err := b.request(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(workspace)+"/members?pagelen=100", nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+
+	users := make([]bbUser, len(page.Values))
+	for i, m := range page.Values {
+		users[i] = m.User
+	}
+	return users, nil
+}
+
+// comment posts a comment and returns its URL.
+func (b *Bitbucket) comment(ctx context.Context, path string, params map[string]any) (string, error) {
+	var c bbComment
+	if err := b.api(ctx, http.MethodPost, path, params, &c); err != nil {
+		return "", err
+	}
+	return c.Links.HTML.Href, nil
+}
+
+// raw fetches a repository endpoint that returns plain text, such as a diff.
+func (b *Bitbucket) raw(ctx context.Context, path string) (string, error) {
+	data, err := b.do(ctx, http.MethodGet, "/repositories/"+b.project+path, nil)
+	return string(data), err
+}
+
+// api calls a repository-scoped endpoint, e.g. "/pullrequests/1".
+func (b *Bitbucket) api(ctx context.Context, method, path string, body, out any) error {
+	return b.request(ctx, method, "/repositories/"+b.project+path, body, out)
+}
+
+// request calls a Bitbucket API endpoint, decoding the JSON response into out.
+func (b *Bitbucket) request(ctx context.Context, method, path string, body, out any) error {
+	data, err := b.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse Bitbucket response: %w", err)
+	}
+	return nil
+}
+
+// do sends an authenticated request and returns the response body.
+func (b *Bitbucket) do(ctx context.Context, method, path string, body any) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if user, password, ok := strings.Cut(b.token, ":"); ok {
+		req.SetBasicAuth(user, password)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("Bitbucket %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,197 @@
+// The forge abstraction over code hosting services.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported forges.
+const (
+	ForgeGitHub    = "github"
+	ForgeGitLab    = "gitlab"
+	ForgeBitbucket = "bitbucket"
+)
+
+// Forge is a code hosting service the bot opens and reviews pull requests on
+// (merge requests on GitLab). PR references are a number or a web URL.
+type Forge interface {
+	// Name returns the forge identifier, e.g. "github".
+	Name() string
+
+	CreatePR(ctx context.Context, title, body, base string, draft bool) (*PRInfo, error)
+	GetPR(ctx context.Context, number int) (*PRInfo, error)
+	ListPRs(ctx context.Context, state string, limit int) ([]PRInfo, error)
+	GetPRForReview(ctx context.Context, prRef string) (*PRDetails, error)
+	// PRNumber returns the number of a PR of this repository given as a
+	// number or URL; URLs of other hosts or repositories are refused.
+	PRNumber(ctx context.Context, prRef string) (int, error)
+	GetPRReviewComments(ctx context.Context, prRef string) ([]ReviewComment, error)
+	// ReplyToComment replies to a review comment and returns the reply's URL.
+	ReplyToComment(ctx context.Context, prRef string, comment ReviewComment, body string) (string, error)
+	SubmitReview(ctx context.Context, prRef, event, body string) error
+	AddInlineComment(ctx context.Context, prRef, path string, line int, body string) (string, error)
+	AddIssueComment(ctx context.Context, number int, body string) (string, error)
+	MergePR(ctx context.Context, prRef, strategy string, deleteBranch bool) error
+	GetPRChecks(ctx context.Context, prRef string) ([]CheckRun, error)
+
+	// DefaultBranch returns the branch PRs target unless told otherwise.
+	DefaultBranch(ctx context.Context) (string, error)
+	// BranchProtection returns the rules protecting branch, or nil when it
+	// isn't protected.
+	BranchProtection(ctx context.Context, branch string) (*BranchProtection, error)
+
+	GetIssue(ctx context.Context, number int) (*IssueInfo, error)
+	ListIssues(ctx context.Context, state string, limit int) ([]IssueInfo, error)
+	CreateIssue(ctx context.Context, title, body string, labels []string) (*IssueInfo, error)
+	ListLabels(ctx context.Context) ([]string, error)
+	ListAssignees(ctx context.Context) ([]string, error)
+	EditIssue(ctx context.Context, number int, addLabels, addAssignees []string) error
+}
+
+var (
+	_ Forge = (*GitHub)(nil)
+	_ Forge = (*GitLab)(nil)
+	_ Forge = (*Bitbucket)(nil)
+)
+
+// Check states reported by GetPRChecks.
+const (
+	CheckPassed  = "pass"
+	CheckFailed  = "fail"
+	CheckPending = "pending"
+)
+
+// CheckRun is a CI check or pipeline job reported on a pull request.
+type CheckRun struct {
+	Name  string
+	State string
+	URL   string
+}
+
+// FormatChecks formats check runs for display.
+func FormatChecks(checks []CheckRun) string {
+	if len(checks) == 0 {
+		return "No checks reported."
+	}
+
+	var sb strings.Builder
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.State]++
+		sb.WriteString(fmt.Sprintf("%-7s %s", c.State, c.Name))
+		if c.URL != "" {
+			sb.WriteString("  " + c.URL)
+		}
+		sb.WriteString("\n")
+	}
+	return fmt.Sprintf("%d passed, %d failed, %d pending\n%s",
+		counts[CheckPassed], counts[CheckFailed], counts[CheckPending], sb.String())
+}
+
+// ForgeConfig holds the settings used to create a forge.
+type ForgeConfig struct {
+	// Kind is the forge to use (ForgeGitHub by default)
+	Kind string
+	// Token authenticates API calls
+	Token string
+	// BaseURL is the web/API root for self-hosted instances
+	BaseURL string
+	// Project is the repository path (e.g. "group/project"); derived from
+	// the origin remote when empty
+	Project string
+	// GitBackend selects the git operations implementation (BackendAuto by default)
+	GitBackend string
+}
+
+// NewForge creates the configured forge for the repository at repoPath.
+func NewForge(repoPath string, cfg ForgeConfig) (Forge, error) {
+	switch cfg.Kind {
+	case "", ForgeGitHub:
+		return NewGitHub(repoPath, cfg.Token), nil
+	case ForgeGitLab:
+		return NewGitLab(repoPath, cfg)
+	case ForgeBitbucket:
+		return NewBitbucket(repoPath, cfg)
+	default:
+		return nil, fmt.Errorf("unknown forge %q", cfg.Kind)
+	}
+}
+
+// prNumberRe matches the trailing number of a PR or merge request URL.
+var prNumberRe = regexp.MustCompile(`(\d+)/?$`)
+
+// ParsePRNumber extracts a PR number from a number or URL reference.
+func ParsePRNumber(prRef string) (int, error) {
+	match := prNumberRe.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(prRef, "#")))
+	if match == nil {
+		return 0, fmt.Errorf("invalid PR reference: %s", prRef)
+	}
+	return strconv.Atoi(match[1])
+}
+
+// parsePRRef extracts a PR number from a number or URL reference. A URL must
+// point at project on the host of webURL, so a link to a PR elsewhere can't
+// stand in for the PR of the same number here.
+func parsePRRef(prRef, webURL, project string) (int, error) {
+	ref := strings.TrimSpace(prRef)
+	if !strings.Contains(ref, "://") {
+		return ParsePRNumber(ref)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PR reference: %s", prRef)
+	}
+	forge, err := url.Parse(webURL)
+	if err != nil || forge.Hostname() == "" || project == "" {
+		return 0, fmt.Errorf("can't check PR URL %s: the forge's address is unknown", prRef)
+	}
+	if !strings.EqualFold(u.Hostname(), forge.Hostname()) || !strings.HasPrefix(strings.ToLower(u.Path), "/"+strings.ToLower(project)+"/") {
+		return 0, fmt.Errorf("%s is not a pull request of %s on %s", prRef, project, forge.Hostname())
+	}
+	return ParsePRNumber(ref)
+}
+
+// remoteHost returns the host of a git remote URL (https or ssh).
+func remoteHost(remoteURL string) string {
+	remote := strings.TrimSpace(remoteURL)
+	if strings.Contains(remote, "://") {
+		if u, err := url.Parse(remote); err == nil {
+			return u.Hostname()
+		}
+		return ""
+	}
+	// scp-like syntax: git@host:group/project
+	host, _, ok := strings.Cut(remote, ":")
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return host
+}
+
+// remoteProject derives a repository path such as "group/project" from a
+// git remote URL (https or ssh).
+func remoteProject(remoteURL string) string {
+	remote := strings.TrimSuffix(strings.TrimSpace(remoteURL), ".git")
+	if i := strings.Index(remote, "://"); i >= 0 {
+		remote = remote[i+3:]
+		if j := strings.Index(remote, "/"); j >= 0 {
+			return remote[j+1:]
+		}
+		return ""
+	}
+	// scp-like syntax: git@host:group/project
+	if i := strings.Index(remote, ":"); i >= 0 {
+		return remote[i+1:]
+	}
+	return ""
+}
@@ -7,13 +7,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // GitHub provides GitHub operations using the gh CLI.
 type GitHub struct {
 	repoPath string
 	token    string
+
+	// remote is the origin URL, read on first use to check PR URLs against
+	remoteOnce sync.Once
+	remote     string
 }
 
 // NewGitHub creates a new GitHub operations instance.
@@ -24,6 +31,11 @@ func NewGitHub(repoPath, token string) *GitHub {
 	}
 }
 
+// Name returns the forge identifier.
+func (g *GitHub) Name() string {
+	return ForgeGitHub
+}
+
 // PRInfo contains information about a pull request.
 type PRInfo struct {
 	Number    int    `json:"number"`
@@ -34,7 +46,31 @@ type PRInfo struct {
 	BaseRef   string `json:"baseRefName"`
 	Body      string `json:"body"`
 	CreatedAt string `json:"createdAt"`
-	Author    string `json:"author"`
+	Author    string `json:"-"`
+	// MergeCommit is the commit a merged PR landed as, when known
+	MergeCommit string `json:"-"`
+}
+
+// parsePRInfo decodes gh's PR JSON, where the author is an object.
+func parsePRInfo(data []byte) (*PRInfo, error) {
+	var view struct {
+		PRInfo
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		MergeCommit *struct {
+			OID string `json:"oid"`
+		} `json:"mergeCommit"`
+	}
+	if err := json.Unmarshal(data, &view); err != nil {
+		return nil, err
+	}
+	pr := view.PRInfo
+	pr.Author = view.Author.Login
+	if view.MergeCommit != nil {
+		pr.MergeCommit = view.MergeCommit.OID
+	}
+	return &pr, nil
 }
 
 // CreatePR creates a new pull request.
@@ -61,20 +97,106 @@ func (g *GitHub) CreatePR(ctx context.Context, title, body, base string, draft b
 	return g.GetPRByURL(ctx, url)
 }
 
+// DefaultBranch returns the repository's default branch.
+func (g *GitHub) DefaultBranch(ctx context.Context) (string, error) {
+	output, err := g.runGH(ctx, "api", "repos/{owner}/{repo}", "--jq", ".default_branch")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// BranchProtection returns the rules protecting branch, from classic branch
+// protection and repository rulesets, or nil when it isn't protected. The
+// review rules of classic protection are only visible to admins, so they
+// may be missing.
+func (g *GitHub) BranchProtection(ctx context.Context, branch string) (*BranchProtection, error) {
+	var info struct {
+		Protected  bool `json:"protected"`
+		Protection struct {
+			RequiredStatusChecks struct {
+				Contexts []string `json:"contexts"`
+			} `json:"required_status_checks"`
+		} `json:"protection"`
+	}
+	output, err := g.runGH(ctx, "api", "repos/{owner}/{repo}/branches/"+branch)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse branch: %w", err)
+	}
+
+	p := &BranchProtection{Branch: branch}
+	for _, check := range info.Protection.RequiredStatusChecks.Contexts {
+		p.addCheck(check)
+	}
+	if info.Protected {
+		var protection struct {
+			Reviews *struct {
+				Count      int  `json:"required_approving_review_count"`
+				CodeOwners bool `json:"require_code_owner_reviews"`
+			} `json:"required_pull_request_reviews"`
+			Restrictions *struct{} `json:"restrictions"`
+		}
+		if output, err := g.runGH(ctx, "api", "repos/{owner}/{repo}/branches/"+branch+"/protection"); err == nil && json.Unmarshal([]byte(output), &protection) == nil {
+			if protection.Reviews != nil {
+				p.RequiredReviews = protection.Reviews.Count
+				p.CodeOwnerReviews = protection.Reviews.CodeOwners
+			}
+			p.PushRestricted = protection.Restrictions != nil
+		}
+	}
+
+	// Rulesets apply on top of classic protection
+	var rules []struct {
+		Type       string `json:"type"`
+		Parameters struct {
+			Count      int  `json:"required_approving_review_count"`
+			CodeOwners bool `json:"require_code_owner_review"`
+			Checks     []struct {
+				Context string `json:"context"`
+			} `json:"required_status_checks"`
+		} `json:"parameters"`
+	}
+	rulesOutput, err := g.runGH(ctx, "api", "repos/{owner}/{repo}/rules/branches/"+branch)
+	if err == nil && json.Unmarshal([]byte(rulesOutput), &rules) == nil {
+		for _, rule := range rules {
+			switch rule.Type {
+			case "pull_request":
+				p.RequiredReviews = max(p.RequiredReviews, rule.Parameters.Count)
+				p.CodeOwnerReviews = p.CodeOwnerReviews || rule.Parameters.CodeOwners
+				p.PushRestricted = true
+			case "required_status_checks":
+				for _, check := range rule.Parameters.Checks {
+					p.addCheck(check.Context)
+				}
+			case "update":
+				p.PushRestricted = true
+			}
+		}
+	}
+
+	if !info.Protected && len(rules) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
 // GetPR gets information about a pull request.
 func (g *GitHub) GetPR(ctx context.Context, number int) (*PRInfo, error) {
 	output, err := g.runGH(ctx, "pr", "view", fmt.Sprintf("%d", number), "--json",
-		"number,title,url,state,headRefName,baseRefName,body,createdAt,author")
+		"number,title,url,state,headRefName,baseRefName,body,createdAt,author,mergeCommit")
 	if err != nil {
 		return nil, err
 	}
 
-	var pr PRInfo
-	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+	pr, err := parsePRInfo([]byte(output))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse PR info: %w", err)
 	}
 
-	return &pr, nil
+	return pr, nil
 }
 
 // GetPRByURL gets information about a PR by its URL.
@@ -94,12 +216,12 @@ func (g *GitHub) GetPRByURL(ctx context.Context, url string) (*PRInfo, error) {
 		return &PRInfo{URL: url, Title: "PR Created"}, nil
 	}
 
-	var pr PRInfo
-	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+	pr, err := parsePRInfo([]byte(output))
+	if err != nil {
 		return &PRInfo{URL: url}, nil
 	}
 
-	return &pr, nil
+	return pr, nil
 }
 
 // ListPRs lists open pull requests.
@@ -112,28 +234,90 @@ func (g *GitHub) ListPRs(ctx context.Context, state string, limit int) ([]PRInfo
 	}
 
 	output, err := g.runGH(ctx, "pr", "list", "--state", state, "--limit", fmt.Sprintf("%d", limit),
-		"--json", "number,title,url,state,headRefName,baseRefName,createdAt")
+		"--json", "number,title,url,state,headRefName,baseRefName,createdAt,author")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []struct {
+		PRInfo
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse PR list: %w", err)
+	}
+
+	prs := make([]PRInfo, 0, len(list))
+	for _, item := range list {
+		pr := item.PRInfo
+		pr.Author = item.Author.Login
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+// PRStatus is where one of the authenticated user's pull requests stands.
+type PRStatus struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"` // OPEN, MERGED or CLOSED
+	CreatedAt time.Time `json:"createdAt"`
+	MergedAt  time.Time `json:"mergedAt"`
+	ClosedAt  time.Time `json:"closedAt"`
+	Author    string    `json:"-"`
+}
+
+// ListOwnPRs lists up to limit pull requests opened by the authenticated
+// user, which is the bot itself, in any state, newest first.
+func (g *GitHub) ListOwnPRs(ctx context.Context, limit int) ([]PRStatus, error) {
+	output, err := g.runGH(ctx, "pr", "list", "--author", "@me", "--state", "all", "--limit", fmt.Sprintf("%d", limit),
+		"--json", "number,title,url,state,createdAt,mergedAt,closedAt,author")
 	if err != nil {
 		return nil, err
 	}
 
-	var prs []PRInfo
-	if err := json.Unmarshal([]byte(output), &prs); err != nil {
+	var list []struct {
+		PRStatus
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
 		return nil, fmt.Errorf("failed to parse PR list: %w", err)
 	}
 
+	prs := make([]PRStatus, 0, len(list))
+	for _, item := range list {
+		pr := item.PRStatus
+		pr.Author = item.Author.Login
+		prs = append(prs, pr)
+	}
 	return prs, nil
 }
 
 // IssueInfo contains information about an issue.
 type IssueInfo struct {
-	Number    int      `json:"number"`
-	Title     string   `json:"title"`
-	URL       string   `json:"url"`
-	State     string   `json:"state"`
-	Body      string   `json:"body"`
-	Labels    []string `json:"labels"`
-	CreatedAt string   `json:"createdAt"`
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	URL       string       `json:"url"`
+	State     string       `json:"state"`
+	Body      string       `json:"body"`
+	Labels    []IssueLabel `json:"labels"`
+	Assignees []IssueUser  `json:"assignees"`
+	CreatedAt string       `json:"createdAt"`
+}
+
+// IssueUser is a GitHub user referenced by an issue.
+type IssueUser struct {
+	Login string `json:"login"`
+}
+
+// IssueLabel is a label attached to an issue.
+type IssueLabel struct {
+	Name string `json:"name"`
 }
 
 // GetIssue gets information about an issue.
@@ -162,7 +346,7 @@ func (g *GitHub) ListIssues(ctx context.Context, state string, limit int) ([]Iss
 	}
 
 	output, err := g.runGH(ctx, "issue", "list", "--state", state, "--limit", fmt.Sprintf("%d", limit),
-		"--json", "number,title,url,state,createdAt")
+		"--json", "number,title,url,state,body,labels,assignees,createdAt")
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +359,127 @@ func (g *GitHub) ListIssues(ctx context.Context, state string, limit int) ([]Iss
 	return issues, nil
 }
 
+// ListLabels lists the names of the repository's labels.
+func (g *GitHub) ListLabels(ctx context.Context) ([]string, error) {
+	output, err := g.runGH(ctx, "label", "list", "--limit", "200", "--json", "name")
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []IssueLabel
+	if err := json.Unmarshal([]byte(output), &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse label list: %w", err)
+	}
+
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names, nil
+}
+
+// ListAssignees lists the logins of users issues can be assigned to.
+func (g *GitHub) ListAssignees(ctx context.Context) ([]string, error) {
+	output, err := g.runGH(ctx, "api", "--paginate", "repos/{owner}/{repo}/assignees", "--jq", ".[].login")
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line != "" {
+			logins = append(logins, line)
+		}
+	}
+	return logins, nil
+}
+
+// CreateIssue opens an issue with the given labels.
+func (g *GitHub) CreateIssue(ctx context.Context, title, body string, labels []string) (*IssueInfo, error) {
+	args := []string{"issue", "create", "--title", title, "--body", body}
+	if len(labels) > 0 {
+		args = append(args, "--label", strings.Join(labels, ","))
+	}
+
+	output, err := g.runGH(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	// gh issue create returns the issue URL
+	number, err := ParsePRNumber(strings.TrimSpace(output))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected output from gh issue create: %s", output)
+	}
+	return g.GetIssue(ctx, number)
+}
+
+// EditIssue adds labels and assignees to an issue.
+func (g *GitHub) EditIssue(ctx context.Context, number int, addLabels, addAssignees []string) error {
+	args := []string{"issue", "edit", fmt.Sprintf("%d", number)}
+	if len(addLabels) > 0 {
+		args = append(args, "--add-label", strings.Join(addLabels, ","))
+	}
+	if len(addAssignees) > 0 {
+		args = append(args, "--add-assignee", strings.Join(addAssignees, ","))
+	}
+	if len(args) == 3 {
+		return nil
+	}
+
+	if _, err := g.runGH(ctx, args...); err != nil {
+		return fmt.Errorf("failed to edit issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// FormatIssue formats an issue for display.
+func FormatIssue(issue *IssueInfo) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Issue #%d: %s\n\n", issue.Number, issue.Title))
+	sb.WriteString(fmt.Sprintf("**URL:** %s\n", issue.URL))
+	sb.WriteString(fmt.Sprintf("**State:** %s\n", issue.State))
+	if len(issue.Labels) > 0 {
+		names := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			names[i] = l.Name
+		}
+		sb.WriteString(fmt.Sprintf("**Labels:** %s\n", strings.Join(names, ", ")))
+	}
+	if issue.Body != "" {
+		sb.WriteString("\n## Description\n\n")
+		sb.WriteString(issue.Body)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// IssueBranchName builds a branch name for working on an issue, e.g.
+// "issue-42-fix-login-timeout".
+func IssueBranchName(issue *IssueInfo) string {
+	var slug strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(issue.Title) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			slug.WriteRune(r)
+			lastDash = false
+		case !lastDash && slug.Len() > 0:
+			slug.WriteRune('-')
+			lastDash = true
+		}
+		if slug.Len() >= 40 {
+			break
+		}
+	}
+
+	name := fmt.Sprintf("issue-%d", issue.Number)
+	if s := strings.Trim(slug.String(), "-"); s != "" {
+		name += "-" + s
+	}
+	return name
+}
+
 // CheckGHInstalled verifies that gh CLI is installed and authenticated.
 func (g *GitHub) CheckGHInstalled(ctx context.Context) error {
 	_, err := g.runGH(ctx, "auth", "status")
@@ -184,6 +489,37 @@ func (g *GitHub) CheckGHInstalled(ctx context.Context) error {
 	return nil
 }
 
+// PRNumber returns the number of a pull request of the repository given as a
+// number or URL; the URL must be on the host and repository of origin.
+func (g *GitHub) PRNumber(ctx context.Context, prRef string) (int, error) {
+	g.remoteOnce.Do(func() {
+		// Not bound to ctx: the result is kept for every later call
+		cmd := exec.Command("git", "remote", "get-url", "origin")
+		cmd.Dir = g.repoPath
+		if output, err := cmd.Output(); err == nil {
+			g.remote = strings.TrimSpace(string(output))
+		}
+	})
+	return parsePRRef(prRef, "https://"+remoteHost(g.remote), remoteProject(g.remote))
+}
+
+// prArg checks a PR reference before it is passed to gh: URLs must be of
+// this repository, and become its number, and options are refused.
+func (g *GitHub) prArg(ctx context.Context, prRef string) (string, error) {
+	prRef = strings.TrimSpace(prRef)
+	if strings.HasPrefix(prRef, "-") {
+		return "", fmt.Errorf("invalid PR reference: %s", prRef)
+	}
+	if !strings.Contains(prRef, "://") {
+		return prRef, nil
+	}
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(number), nil
+}
+
 // runGH executes a gh CLI command.
 func (g *GitHub) runGH(ctx context.Context, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
@@ -224,6 +560,10 @@ func FormatPR(pr *PRInfo) string {
 
 // GetPRDiff gets the diff for a pull request.
 func (g *GitHub) GetPRDiff(ctx context.Context, prRef string) (string, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
 	output, err := g.runGH(ctx, "pr", "diff", prRef)
 	if err != nil {
 		return "", err
@@ -233,6 +573,10 @@ func (g *GitHub) GetPRDiff(ctx context.Context, prRef string) (string, error) {
 
 // GetPRComments gets the review comments on a pull request.
 func (g *GitHub) GetPRComments(ctx context.Context, prRef string) (string, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
 	output, err := g.runGH(ctx, "pr", "view", prRef, "--comments")
 	if err != nil {
 		return "", err
@@ -240,8 +584,27 @@ func (g *GitHub) GetPRComments(ctx context.Context, prRef string) (string, error
 	return output, nil
 }
 
+// GetFailedRunLogs returns the last maxLines lines of the logs of the failed
+// steps of a GitHub Actions workflow run.
+func (g *GitHub) GetFailedRunLogs(ctx context.Context, runID int64, maxLines int) (string, error) {
+	output, err := g.runGH(ctx, "run", "view", fmt.Sprintf("%d", runID), "--log-failed")
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of run %d: %w", runID, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = append([]string{fmt.Sprintf("... (%d earlier lines omitted)", len(lines)-maxLines)}, lines[len(lines)-maxLines:]...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
 // GetPRFiles gets the list of files changed in a pull request.
 func (g *GitHub) GetPRFiles(ctx context.Context, prRef string) ([]string, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
 	output, err := g.runGH(ctx, "pr", "diff", prRef, "--name-only")
 	if err != nil {
 		return nil, err
@@ -256,6 +619,296 @@ func (g *GitHub) GetPRFiles(ctx context.Context, prRef string) ([]string, error)
 	return files, nil
 }
 
+// ReviewComment is a piece of reviewer feedback left on a pull request.
+type ReviewComment struct {
+	Author string
+	Body   string
+	Path   string // Set for inline comments
+	Line   int    // Set for inline comments
+	State  string // Review state for review summaries (APPROVED, CHANGES_REQUESTED, ...)
+
+	// ID identifies the comment for ReplyToComment
+	ID string
+	// Thread identifies the thread of replies the comment is in, empty for
+	// comments that can't be replied to in a thread
+	Thread    string
+	CreatedAt time.Time
+}
+
+// GetPRReviewComments gets review summaries, conversation comments and inline
+// review comments left on a pull request.
+func (g *GitHub) GetPRReviewComments(ctx context.Context, prRef string) ([]ReviewComment, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.runGH(ctx, "pr", "view", prRef, "--json", "number,reviews,comments")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR reviews: %w", err)
+	}
+
+	type author struct {
+		Login string `json:"login"`
+	}
+	var view struct {
+		Number  int `json:"number"`
+		Reviews []struct {
+			ID          string    `json:"id"`
+			Author      author    `json:"author"`
+			Body        string    `json:"body"`
+			State       string    `json:"state"`
+			SubmittedAt time.Time `json:"submittedAt"`
+		} `json:"reviews"`
+		Comments []struct {
+			ID        string    `json:"id"`
+			Author    author    `json:"author"`
+			Body      string    `json:"body"`
+			CreatedAt time.Time `json:"createdAt"`
+		} `json:"comments"`
+	}
+	if err := json.Unmarshal([]byte(output), &view); err != nil {
+		return nil, fmt.Errorf("failed to parse PR reviews: %w", err)
+	}
+
+	var comments []ReviewComment
+	for _, r := range view.Reviews {
+		if strings.TrimSpace(r.Body) != "" {
+			comments = append(comments, ReviewComment{Author: r.Author.Login, Body: r.Body, State: r.State, ID: r.ID, CreatedAt: r.SubmittedAt})
+		}
+	}
+	for _, c := range view.Comments {
+		if strings.TrimSpace(c.Body) != "" {
+			comments = append(comments, ReviewComment{Author: c.Author.Login, Body: c.Body, ID: c.ID, CreatedAt: c.CreatedAt})
+		}
+	}
+
+	// Inline comments are only available through the REST API
+	inline, err := g.runGH(ctx, "api", fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/comments", view.Number))
+	if err != nil {
+		return comments, nil
+	}
+
+	var inlineComments []struct {
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Body string `json:"body"`
+		Path string `json:"path"`
+		Line int    `json:"line"`
+
+		ID        int64     `json:"id"`
+		InReplyTo int64     `json:"in_reply_to_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal([]byte(inline), &inlineComments); err != nil {
+		return comments, nil
+	}
+	for _, c := range inlineComments {
+		// Replies go to the comment starting the thread
+		thread := c.ID
+		if c.InReplyTo != 0 {
+			thread = c.InReplyTo
+		}
+		comments = append(comments, ReviewComment{
+			Author:    c.User.Login,
+			Body:      c.Body,
+			Path:      c.Path,
+			Line:      c.Line,
+			ID:        strconv.FormatInt(c.ID, 10),
+			Thread:    strconv.FormatInt(thread, 10),
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	return comments, nil
+}
+
+// ReplyToComment replies to a review comment and returns the reply's URL.
+// Inline comments are answered in their thread; reviews and conversation
+// comments, which have none, with a PR comment quoting them.
+func (g *GitHub) ReplyToComment(ctx context.Context, prRef string, comment ReviewComment, body string) (string, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	if comment.Thread == "" {
+		return g.AddIssueComment(ctx, number, QuoteReply(comment, body))
+	}
+
+	output, err := g.runGH(ctx, "api", "--method", "POST",
+		fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/comments/%s/replies", number, comment.Thread),
+		"-f", "body="+body,
+		"--jq", ".html_url",
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to reply to comment: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Review events accepted by SubmitReview.
+const (
+	ReviewApprove        = "approve"
+	ReviewCommentOnly    = "comment"
+	ReviewRequestChanges = "request-changes"
+)
+
+// SubmitReview posts a review on a pull request. event is one of ReviewApprove,
+// ReviewCommentOnly or ReviewRequestChanges.
+func (g *GitHub) SubmitReview(ctx context.Context, prRef, event, body string) error {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return err
+	}
+	args := []string{"pr", "review", prRef}
+	switch event {
+	case ReviewApprove, ReviewCommentOnly, ReviewRequestChanges:
+		args = append(args, "--"+event)
+	default:
+		return fmt.Errorf("invalid review event: %s", event)
+	}
+	if body != "" {
+		args = append(args, "--body", body)
+	} else if event != ReviewApprove {
+		return fmt.Errorf("a review body is required for %s", event)
+	}
+
+	if _, err := g.runGH(ctx, args...); err != nil {
+		return fmt.Errorf("failed to submit review: %w", err)
+	}
+	return nil
+}
+
+// AddInlineComment comments on a line of a file in a pull request's latest
+// commit and returns the comment URL.
+func (g *GitHub) AddInlineComment(ctx context.Context, prRef, path string, line int, body string) (string, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	output, err := g.runGH(ctx, "pr", "view", prRef, "--json", "number,headRefOid")
+	if err != nil {
+		return "", fmt.Errorf("failed to get PR head: %w", err)
+	}
+
+	var pr struct {
+		Number     int    `json:"number"`
+		HeadRefOid string `json:"headRefOid"`
+	}
+	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+		return "", fmt.Errorf("failed to parse PR head: %w", err)
+	}
+
+	output, err = g.runGH(ctx, "api", "--method", "POST",
+		fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/comments", pr.Number),
+		"-f", "body="+body,
+		"-f", "commit_id="+pr.HeadRefOid,
+		"-f", "path="+path,
+		"-F", fmt.Sprintf("line=%d", line),
+		"-f", "side=RIGHT",
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to add inline comment: %w", err)
+	}
+
+	var comment struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal([]byte(output), &comment); err != nil {
+		return "", fmt.Errorf("failed to parse comment: %w", err)
+	}
+	return comment.HTMLURL, nil
+}
+
+// AddIssueComment posts a comment on an issue or pull request conversation and
+// returns the comment URL.
+func (g *GitHub) AddIssueComment(ctx context.Context, number int, body string) (string, error) {
+	output, err := g.runGH(ctx, "issue", "comment", fmt.Sprintf("%d", number), "--body", body)
+	if err != nil {
+		return "", fmt.Errorf("failed to add comment: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Merge strategies accepted by MergePR.
+const (
+	MergeSquash = "squash"
+	MergeCommit = "merge"
+	MergeRebase = "rebase"
+)
+
+// MergePR merges a pull request with the given strategy, optionally deleting
+// its branch afterwards.
+func (g *GitHub) MergePR(ctx context.Context, prRef, strategy string, deleteBranch bool) error {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return err
+	}
+	args := []string{"pr", "merge", prRef}
+	switch strategy {
+	case "":
+		args = append(args, "--"+MergeSquash)
+	case MergeSquash, MergeCommit, MergeRebase:
+		args = append(args, "--"+strategy)
+	default:
+		return fmt.Errorf("invalid merge strategy: %s", strategy)
+	}
+	if deleteBranch {
+		args = append(args, "--delete-branch")
+	}
+
+	if _, err := g.runGH(ctx, args...); err != nil {
+		return fmt.Errorf("failed to merge PR: %w", err)
+	}
+	return nil
+}
+
+// GetPRChecks gets the status checks of a pull request.
+func (g *GitHub) GetPRChecks(ctx context.Context, prRef string) ([]CheckRun, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.runGH(ctx, "pr", "view", prRef, "--json", "statusCheckRollup")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR checks: %w", err)
+	}
+
+	// The rollup mixes check runs (name/status/conclusion) and commit
+	// statuses (context/state)
+	var view struct {
+		StatusCheckRollup []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			DetailsURL string `json:"detailsUrl"`
+			Context    string `json:"context"`
+			State      string `json:"state"`
+			TargetURL  string `json:"targetUrl"`
+		} `json:"statusCheckRollup"`
+	}
+	if err := json.Unmarshal([]byte(output), &view); err != nil {
+		return nil, fmt.Errorf("failed to parse PR checks: %w", err)
+	}
+
+	checks := make([]CheckRun, 0, len(view.StatusCheckRollup))
+	for _, c := range view.StatusCheckRollup {
+		check := CheckRun{Name: c.Name, URL: c.DetailsURL, State: CheckPending}
+		result := c.Conclusion
+		if c.Context != "" {
+			check.Name, check.URL, result = c.Context, c.TargetURL, c.State
+		}
+		switch result {
+		case "SUCCESS", "NEUTRAL", "SKIPPED":
+			check.State = CheckPassed
+		case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			check.State = CheckFailed
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
 // PRDetails contains full PR information for review.
 type PRDetails struct {
 	Info         *PRInfo
@@ -265,6 +918,10 @@ type PRDetails struct {
 
 // GetPRForReview gets comprehensive PR details for code review.
 func (g *GitHub) GetPRForReview(ctx context.Context, prRef string) (*PRDetails, error) {
+	prRef, err := g.prArg(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
 	// Get basic PR info
 	output, err := g.runGH(ctx, "pr", "view", prRef, "--json",
 		"number,title,url,state,headRefName,baseRefName,body,createdAt,author")
@@ -272,8 +929,8 @@ func (g *GitHub) GetPRForReview(ctx context.Context, prRef string) (*PRDetails,
 		return nil, fmt.Errorf("failed to get PR info: %w", err)
 	}
 
-	var info PRInfo
-	if err := json.Unmarshal([]byte(output), &info); err != nil {
+	info, err := parsePRInfo([]byte(output))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse PR info: %w", err)
 	}
 
@@ -287,7 +944,7 @@ func (g *GitHub) GetPRForReview(ctx context.Context, prRef string) (*PRDetails,
 	files, _ := g.GetPRFiles(ctx, prRef)
 
 	return &PRDetails{
-		Info:         &info,
+		Info:         info,
 		Diff:         diff,
 		FilesChanged: files,
 	}, nil
@@ -0,0 +1,731 @@
+// GitLab operations via the REST API.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGitLabURL is used when no self-hosted instance is configured.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLab provides forge operations using the GitLab REST API (v4).
+type GitLab struct {
+	repoPath string
+	baseURL  string
+	token    string
+	project  string
+	gitOps   Operations
+	http     *http.Client
+}
+
+// NewGitLab creates a GitLab forge for the repository at repoPath.
+func NewGitLab(repoPath string, cfg ForgeConfig) (*GitLab, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("a GitLab token is required")
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+
+	gitOps, err := NewOperations(repoPath, cfg.GitBackend)
+	if err != nil {
+		return nil, err
+	}
+	project := cfg.Project
+	if project == "" {
+		remote, err := gitOps.GetRemoteURL(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine GitLab project: %w", err)
+		}
+		if project = remoteProject(remote); project == "" {
+			return nil, fmt.Errorf("failed to determine GitLab project from remote %q", remote)
+		}
+	}
+
+	return &GitLab{
+		repoPath: repoPath,
+		baseURL:  baseURL,
+		token:    cfg.Token,
+		project:  project,
+		gitOps:   gitOps,
+		http:     &http.Client{Timeout: CommandTimeout},
+	}, nil
+}
+
+// Name returns the forge identifier.
+func (g *GitLab) Name() string {
+	return ForgeGitLab
+}
+
+// GitLab API objects.
+type glUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type glMergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	WebURL       string `json:"web_url"`
+	State        string `json:"state"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Description  string `json:"description"`
+	CreatedAt    string `json:"created_at"`
+	Author       glUser `json:"author"`
+	MergeSHA     string `json:"merge_commit_sha"`
+	SquashSHA    string `json:"squash_commit_sha"`
+	DiffRefs     struct {
+		BaseSHA  string `json:"base_sha"`
+		HeadSHA  string `json:"head_sha"`
+		StartSHA string `json:"start_sha"`
+	} `json:"diff_refs"`
+}
+
+type glIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	WebURL      string   `json:"web_url"`
+	State       string   `json:"state"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+	Assignees   []glUser `json:"assignees"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+type glNote struct {
+	ID       int    `json:"id"`
+	Body     string `json:"body"`
+	System   bool   `json:"system"`
+	Author   glUser `json:"author"`
+	Position *struct {
+		NewPath string `json:"new_path"`
+		NewLine int    `json:"new_line"`
+	} `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// glDiscussion is a thread of notes on a merge request.
+type glDiscussion struct {
+	ID    string   `json:"id"`
+	Notes []glNote `json:"notes"`
+}
+
+// toPRInfo converts a merge request into the forge-neutral PR info.
+func (mr *glMergeRequest) toPRInfo() *PRInfo {
+	return &PRInfo{
+		Number:    mr.IID,
+		Title:     mr.Title,
+		URL:       mr.WebURL,
+		State:     glState(mr.State),
+		HeadRef:   mr.SourceBranch,
+		BaseRef:   mr.TargetBranch,
+		Body:      mr.Description,
+		CreatedAt: mr.CreatedAt,
+		Author:    mr.Author.Username,
+		// Squash merges without a merge commit land as the squash commit
+		MergeCommit: firstNonEmpty(mr.MergeSHA, mr.SquashSHA),
+	}
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// toIssueInfo converts a GitLab issue into the forge-neutral issue info.
+func (i *glIssue) toIssueInfo() IssueInfo {
+	issue := IssueInfo{
+		Number:    i.IID,
+		Title:     i.Title,
+		URL:       i.WebURL,
+		State:     glState(i.State),
+		Body:      i.Description,
+		CreatedAt: i.CreatedAt,
+	}
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, IssueLabel{Name: l})
+	}
+	for _, a := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, IssueUser{Login: a.Username})
+	}
+	return issue
+}
+
+// glState maps GitLab states onto the GitHub-style states used elsewhere.
+func glState(state string) string {
+	switch state {
+	case "opened":
+		return "OPEN"
+	case "merged":
+		return "MERGED"
+	default:
+		return strings.ToUpper(state)
+	}
+}
+
+// glQueryState maps a GitHub-style state filter onto GitLab's.
+func glQueryState(state string) string {
+	switch strings.ToLower(state) {
+	case "", "open":
+		return "opened"
+	case "all":
+		return "all"
+	default:
+		return strings.ToLower(state)
+	}
+}
+
+// CreatePR opens a merge request from the current branch.
+func (g *GitLab) CreatePR(ctx context.Context, title, body, base string, draft bool) (*PRInfo, error) {
+	branch, err := g.gitOps.CurrentBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if base == "" {
+		if base, err = g.DefaultBranch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if draft {
+		title = "Draft: " + title
+	}
+
+	var mr glMergeRequest
+	err = g.api(ctx, http.MethodPost, "/merge_requests", map[string]any{
+		"source_branch": branch,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge request: %w", err)
+	}
+	return mr.toPRInfo(), nil
+}
+
+// DefaultBranch returns the project's default branch.
+func (g *GitLab) DefaultBranch(ctx context.Context) (string, error) {
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := g.api(ctx, http.MethodGet, "", nil, &project); err != nil {
+		return "", fmt.Errorf("failed to get default branch: %w", err)
+	}
+	return project.DefaultBranch, nil
+}
+
+// BranchProtection returns the rules protecting branch, or nil when it isn't
+// protected. Approval rules are included when the plan has them.
+func (g *GitLab) BranchProtection(ctx context.Context, branch string) (*BranchProtection, error) {
+	var protected []struct {
+		Name              string `json:"name"`
+		CodeOwnerApproval bool   `json:"code_owner_approval_required"`
+		PushAccessLevels  []struct {
+			AccessLevel int `json:"access_level"`
+		} `json:"push_access_levels"`
+	}
+	if err := g.api(ctx, http.MethodGet, "/protected_branches?per_page=100", nil, &protected); err != nil {
+		return nil, fmt.Errorf("failed to list protected branches: %w", err)
+	}
+
+	var p *BranchProtection
+	for _, pb := range protected {
+		if !branchMatches(pb.Name, branch) {
+			continue
+		}
+		if p == nil {
+			p = &BranchProtection{Branch: branch}
+		}
+		p.CodeOwnerReviews = p.CodeOwnerReviews || pb.CodeOwnerApproval
+		// Anything below developer access (30) limits pushes to maintainers or no one
+		for _, level := range pb.PushAccessLevels {
+			if level.AccessLevel != 30 {
+				p.PushRestricted = true
+			}
+		}
+	}
+	if p == nil {
+		return nil, nil
+	}
+
+	var rules []struct {
+		ApprovalsRequired int `json:"approvals_required"`
+		ProtectedBranches []struct {
+			Name string `json:"name"`
+		} `json:"protected_branches"`
+		AppliesToAllProtectedBranches bool `json:"applies_to_all_protected_branches"`
+	}
+	if err := g.api(ctx, http.MethodGet, "/approval_rules", nil, &rules); err == nil {
+		for _, rule := range rules {
+			applies := rule.AppliesToAllProtectedBranches || len(rule.ProtectedBranches) == 0
+			for _, pb := range rule.ProtectedBranches {
+				applies = applies || branchMatches(pb.Name, branch)
+			}
+			if applies {
+				p.RequiredReviews = max(p.RequiredReviews, rule.ApprovalsRequired)
+			}
+		}
+	}
+	return p, nil
+}
+
+// GetPR gets information about a merge request.
+func (g *GitLab) GetPR(ctx context.Context, number int) (*PRInfo, error) {
+	mr, err := g.getMergeRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return mr.toPRInfo(), nil
+}
+
+// ListPRs lists merge requests.
+func (g *GitLab) ListPRs(ctx context.Context, state string, limit int) ([]PRInfo, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var mrs []glMergeRequest
+	path := fmt.Sprintf("/merge_requests?state=%s&per_page=%d", glQueryState(state), limit)
+	if err := g.api(ctx, http.MethodGet, path, nil, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+
+	prs := make([]PRInfo, len(mrs))
+	for i := range mrs {
+		prs[i] = *mrs[i].toPRInfo()
+	}
+	return prs, nil
+}
+
+// PRNumber returns the number of a merge request of the project given as a
+// number or URL.
+func (g *GitLab) PRNumber(ctx context.Context, prRef string) (int, error) {
+	return parsePRRef(prRef, g.baseURL, g.project)
+}
+
+// GetPRForReview gets a merge request with its diff for code review.
+func (g *GitLab) GetPRForReview(ctx context.Context, prRef string) (*PRDetails, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+	mr, err := g.getMergeRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes struct {
+		Changes []struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+			Diff    string `json:"diff"`
+		} `json:"changes"`
+	}
+	details := &PRDetails{Info: mr.toPRInfo()}
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/merge_requests/%d/changes", number), nil, &changes); err != nil {
+		details.Diff = "Failed to get diff: " + err.Error()
+		return details, nil
+	}
+
+	var diff strings.Builder
+	for _, c := range changes.Changes {
+		diff.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", c.OldPath, c.NewPath))
+		diff.WriteString(c.Diff)
+		details.FilesChanged = append(details.FilesChanged, c.NewPath)
+	}
+	details.Diff = diff.String()
+	return details, nil
+}
+
+// GetPRReviewComments gets the discussion and inline comments on a merge request.
+func (g *GitLab) GetPRReviewComments(ctx context.Context, prRef string) ([]ReviewComment, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var discussions []glDiscussion
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/merge_requests/%d/discussions?per_page=100", number), nil, &discussions); err != nil {
+		return nil, fmt.Errorf("failed to get merge request notes: %w", err)
+	}
+
+	var comments []ReviewComment
+	for _, d := range discussions {
+		for _, n := range d.Notes {
+			if n.System || strings.TrimSpace(n.Body) == "" {
+				continue
+			}
+			comment := ReviewComment{Author: n.Author.Username, Body: n.Body, ID: strconv.Itoa(n.ID), Thread: d.ID, CreatedAt: n.CreatedAt}
+			if n.Position != nil {
+				comment.Path = n.Position.NewPath
+				comment.Line = n.Position.NewLine
+			}
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// ReplyToComment replies to a note in its discussion, which turns a single
+// comment into a thread, and returns the reply's URL.
+func (g *GitLab) ReplyToComment(ctx context.Context, prRef string, comment ReviewComment, body string) (string, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	if comment.Thread == "" {
+		return "", fmt.Errorf("comment %s has no discussion to reply in", comment.ID)
+	}
+
+	var note glNote
+	if err := g.api(ctx, http.MethodPost, fmt.Sprintf("/merge_requests/%d/discussions/%s/notes", number, url.PathEscape(comment.Thread)), map[string]any{"body": body}, &note); err != nil {
+		return "", fmt.Errorf("failed to reply to comment: %w", err)
+	}
+
+	mr, err := g.getMergeRequest(ctx, number)
+	if err != nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%s#note_%d", mr.WebURL, note.ID), nil
+}
+
+// SubmitReview reviews a merge request. GitLab has no "request changes"
+// review state, so such reviews are posted as a comment.
+func (g *GitLab) SubmitReview(ctx context.Context, prRef, event, body string) error {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return err
+	}
+
+	switch event {
+	case ReviewApprove:
+		if err := g.api(ctx, http.MethodPost, fmt.Sprintf("/merge_requests/%d/approve", number), nil, nil); err != nil {
+			return fmt.Errorf("failed to approve merge request: %w", err)
+		}
+		if body == "" {
+			return nil
+		}
+	case ReviewCommentOnly:
+	case ReviewRequestChanges:
+		body = "**Changes requested**\n\n" + body
+	default:
+		return fmt.Errorf("invalid review event: %s", event)
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("a review body is required for %s", event)
+	}
+
+	if err := g.api(ctx, http.MethodPost, fmt.Sprintf("/merge_requests/%d/notes", number), map[string]any{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to submit review: %w", err)
+	}
+	return nil
+}
+
+// AddInlineComment starts a discussion on a line of a file in a merge
+// request's latest version and returns the comment URL.
+func (g *GitLab) AddInlineComment(ctx context.Context, prRef, path string, line int, body string) (string, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return "", err
+	}
+	mr, err := g.getMergeRequest(ctx, number)
+	if err != nil {
+		return "", err
+	}
+
+	var discussion struct {
+		Notes []glNote `json:"notes"`
+	}
+	err = g.api(ctx, http.MethodPost, fmt.Sprintf("/merge_requests/%d/discussions", number), map[string]any{
+		"body": body,
+		"position": map[string]any{
+			"position_type": "text",
+			"base_sha":      mr.DiffRefs.BaseSHA,
+			"start_sha":     mr.DiffRefs.StartSHA,
+			"head_sha":      mr.DiffRefs.HeadSHA,
+			"new_path":      path,
+			"new_line":      line,
+		},
+	}, &discussion)
+	if err != nil {
+		return "", fmt.Errorf("failed to add inline comment: %w", err)
+	}
+	if len(discussion.Notes) == 0 {
+		return mr.WebURL, nil
+	}
+	return fmt.Sprintf("%s#note_%d", mr.WebURL, discussion.Notes[0].ID), nil
+}
+
+// AddIssueComment posts a comment on an issue and returns the comment URL.
+func (g *GitLab) AddIssueComment(ctx context.Context, number int, body string) (string, error) {
+	var note glNote
+	if err := g.api(ctx, http.MethodPost, fmt.Sprintf("/issues/%d/notes", number), map[string]any{"body": body}, &note); err != nil {
+		return "", fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	var issue glIssue
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/issues/%d", number), nil, &issue); err != nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%s#note_%d", issue.WebURL, note.ID), nil
+}
+
+// MergePR merges a merge request. Rebase merges are not supported through the
+// API, so only squash and merge commits are accepted.
+func (g *GitLab) MergePR(ctx context.Context, prRef, strategy string, deleteBranch bool) error {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]any{"should_remove_source_branch": deleteBranch}
+	switch strategy {
+	case "", MergeSquash:
+		params["squash"] = true
+	case MergeCommit:
+		params["squash"] = false
+	case MergeRebase:
+		return fmt.Errorf("rebase merges are not supported on GitLab, use squash or merge")
+	default:
+		return fmt.Errorf("invalid merge strategy: %s", strategy)
+	}
+
+	if err := g.api(ctx, http.MethodPut, fmt.Sprintf("/merge_requests/%d/merge", number), params, nil); err != nil {
+		return fmt.Errorf("failed to merge merge request: %w", err)
+	}
+	return nil
+}
+
+// GetPRChecks gets the jobs of a merge request's latest pipeline.
+func (g *GitLab) GetPRChecks(ctx context.Context, prRef string) ([]CheckRun, error) {
+	number, err := g.PRNumber(ctx, prRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []struct {
+		ID int `json:"id"`
+	}
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/merge_requests/%d/pipelines?per_page=1", number), nil, &pipelines); err != nil {
+		return nil, fmt.Errorf("failed to get merge request pipelines: %w", err)
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+
+	var jobs []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/pipelines/%d/jobs?per_page=100", pipelines[0].ID), nil, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to get pipeline jobs: %w", err)
+	}
+
+	checks := make([]CheckRun, 0, len(jobs))
+	for _, j := range jobs {
+		state := CheckPending
+		switch j.Status {
+		case "success", "skipped", "manual":
+			state = CheckPassed
+		case "failed", "canceled":
+			state = CheckFailed
+		}
+		checks = append(checks, CheckRun{Name: j.Name, State: state, URL: j.WebURL})
+	}
+	return checks, nil
+}
+
+// GetIssue gets information about an issue.
+func (g *GitLab) GetIssue(ctx context.Context, number int) (*IssueInfo, error) {
+	var issue glIssue
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/issues/%d", number), nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	info := issue.toIssueInfo()
+	return &info, nil
+}
+
+// ListIssues lists issues.
+func (g *GitLab) ListIssues(ctx context.Context, state string, limit int) ([]IssueInfo, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var issues []glIssue
+	path := fmt.Sprintf("/issues?state=%s&per_page=%d", glQueryState(state), limit)
+	if err := g.api(ctx, http.MethodGet, path, nil, &issues); err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	infos := make([]IssueInfo, len(issues))
+	for i := range issues {
+		infos[i] = issues[i].toIssueInfo()
+	}
+	return infos, nil
+}
+
+// ListLabels lists the names of the project's labels.
+func (g *GitLab) ListLabels(ctx context.Context) ([]string, error) {
+	var labels []IssueLabel
+	if err := g.api(ctx, http.MethodGet, "/labels?per_page=100", nil, &labels); err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names, nil
+}
+
+// ListAssignees lists the usernames of the project's members.
+func (g *GitLab) ListAssignees(ctx context.Context) ([]string, error) {
+	var members []glUser
+	if err := g.api(ctx, http.MethodGet, "/members/all?per_page=100", nil, &members); err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.Username
+	}
+	return names, nil
+}
+
+// CreateIssue opens an issue with the given labels.
+func (g *GitLab) CreateIssue(ctx context.Context, title, body string, labels []string) (*IssueInfo, error) {
+	params := map[string]any{"title": title, "description": body}
+	if len(labels) > 0 {
+		params["labels"] = strings.Join(labels, ",")
+	}
+
+	var issue glIssue
+	if err := g.api(ctx, http.MethodPost, "/issues", params, &issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	info := issue.toIssueInfo()
+	return &info, nil
+}
+
+// EditIssue adds labels and assignees to an issue.
+func (g *GitLab) EditIssue(ctx context.Context, number int, addLabels, addAssignees []string) error {
+	params := map[string]any{}
+	if len(addLabels) > 0 {
+		params["add_labels"] = strings.Join(addLabels, ",")
+	}
+	if len(addAssignees) > 0 {
+		// Assignees are replaced by ID, so keep the current ones
+		var issue glIssue
+		if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/issues/%d", number), nil, &issue); err != nil {
+			return fmt.Errorf("failed to get issue #%d: %w", number, err)
+		}
+		ids := make([]int, 0, len(issue.Assignees)+len(addAssignees))
+		for _, a := range issue.Assignees {
+			ids = append(ids, a.ID)
+		}
+		for _, username := range addAssignees {
+			id, err := g.userID(ctx, username)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		params["assignee_ids"] = ids
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	if err := g.api(ctx, http.MethodPut, fmt.Sprintf("/issues/%d", number), params, nil); err != nil {
+		return fmt.Errorf("failed to edit issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// getMergeRequest fetches a merge request by IID.
+func (g *GitLab) getMergeRequest(ctx context.Context, number int) (*glMergeRequest, error) {
+	var mr glMergeRequest
+	if err := g.api(ctx, http.MethodGet, fmt.Sprintf("/merge_requests/%d", number), nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	}
+	return &mr, nil
+}
+
+// userID looks up a user's ID by username.
+func (g *GitLab) userID(ctx context.Context, username string) (int, error) {
+	var users []glUser
+	if err := g.request(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, fmt.Errorf("failed to look up user %s: %w", username, err)
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("unknown GitLab user: %s", username)
+	}
+	return users[0].ID, nil
+}
+
+// api calls a project-scoped endpoint, e.g. "/merge_requests/1".
+func (g *GitLab) api(ctx context.Context, method, path string, body, out any) error {
+	return g.request(ctx, method, "/projects/"+url.PathEscape(g.project)+path, body, out)
+}
+
+// request calls a GitLab API v4 endpoint, decoding the JSON response into out.
+func (g *GitLab) request(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+"/api/v4"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("GitLab %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse GitLab response: %w", err)
+	}
+	return nil
+}
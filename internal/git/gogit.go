@@ -0,0 +1,656 @@
+// Git operations backed by go-git.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+// GoGitOperations provides git operations using the pure Go go-git library,
+// so local operations work where no git binary is installed. Fetch, push,
+// stash and merge checks, which go-git supports poorly, still use the git
+// binary.
+type GoGitOperations struct {
+	*CLIOperations
+}
+
+// NewGoGitOperations creates git operations backed by go-git.
+func NewGoGitOperations(repoPath string) *GoGitOperations {
+	return &GoGitOperations{CLIOperations: NewCLIOperations(repoPath)}
+}
+
+// Status returns the current git status in `git status --short --branch` form.
+func (g *GoGitOperations) Status(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(status))
+	for path, st := range status {
+		if st.Staging != gogit.Unmodified || st.Worktree != gogit.Unmodified {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	sb.WriteString("## " + headBranch(repo) + "\n")
+	for _, path := range paths {
+		st := status[path]
+		name := path
+		if st.Extra != "" {
+			name = st.Extra + " -> " + path
+		}
+		sb.WriteString(fmt.Sprintf("%c%c %s\n", st.Staging, st.Worktree, name))
+	}
+	return sb.String(), nil
+}
+
+// Diff returns a unified diff. Without a ref it compares the working tree with
+// the index (or the index with HEAD when staged); with a ref it compares that
+// commit with the working tree (or the index when staged).
+func (g *GoGitOperations) Diff(ctx context.Context, staged bool, ref, path string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	var from, to snapshot
+	switch {
+	case ref != "":
+		if from, err = treeSnapshot(repo, ref); err != nil {
+			return "", err
+		}
+		if staged {
+			to, err = indexSnapshot(repo)
+		} else {
+			to, err = worktreeSnapshot(repo)
+		}
+	case staged:
+		if from, err = treeSnapshot(repo, "HEAD"); errors.Is(err, plumbing.ErrReferenceNotFound) {
+			// No commits yet: everything in the index is new
+			from, err = snapshot{}, nil
+		}
+		if err == nil {
+			to, err = indexSnapshot(repo)
+		}
+	default:
+		if from, err = indexSnapshot(repo); err == nil {
+			to, err = worktreeSnapshot(repo)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return g.formatDiff(repo, from, to, path)
+}
+
+// Log returns git log output in the given format (oneline, short, medium or full).
+func (g *GoGitOperations) Log(ctx context.Context, count int, path, format string) (string, error) {
+	if count <= 0 {
+		count = 10
+	}
+
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	opts := &gogit.LogOptions{Order: gogit.LogOrderCommitterTime}
+	if path = cleanPath(path); path != "" {
+		opts.PathFilter = func(file string) bool { return inPath(file, path) }
+	}
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+	defer iter.Close()
+
+	var sb strings.Builder
+	n := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if n >= count {
+			return storer.ErrStop
+		}
+		n++
+		sb.WriteString(formatCommit(c, format))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// CreateBranch creates a new branch and switches to it, keeping local changes.
+func (g *GoGitOperations) CreateBranch(ctx context.Context, name, from string) error {
+	// Sanitize branch name
+	name = executor.SanitizeBranchName(name)
+	if name == "" {
+		return fmt.Errorf("invalid branch name")
+	}
+
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open worktree: %w", err)
+	}
+
+	if from == "" {
+		from = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(from))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", from, err)
+	}
+
+	err = wt.Checkout(&gogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Hash:   *hash,
+		Create: true,
+		Keep:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// Commit stages files and creates a commit.
+func (g *GoGitOperations) Commit(ctx context.Context, message string, files []string) error {
+	// Sanitize commit message
+	message = executor.SanitizeCommitMessage(message)
+	if message == "" {
+		return fmt.Errorf("empty commit message")
+	}
+
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open worktree: %w", err)
+	}
+
+	// Stage files
+	if len(files) == 0 {
+		err = wt.AddWithOptions(&gogit.AddOptions{All: true})
+	} else {
+		for _, file := range files {
+			if _, err = wt.Add(file); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stage files: %w", err)
+	}
+
+	// Author and committer come from the repository's git config
+	if _, err := wt.Commit(message+commitTrailer, &gogit.CommitOptions{}); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// CommitNoVerify stages files and creates a commit. go-git never runs hooks,
+// so it is the same as Commit.
+func (g *GoGitOperations) CommitNoVerify(ctx context.Context, message string, files []string) error {
+	return g.Commit(ctx, message, files)
+}
+
+// ChangedFiles returns the paths of modified, added and untracked files in
+// the worktree or index, including deleted ones.
+func (g *GoGitOperations) ChangedFiles(ctx context.Context) ([]string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for path, st := range status {
+		if st.Staging != gogit.Unmodified || st.Worktree != gogit.Unmodified {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// CurrentBranch returns the current branch name, or "HEAD" when detached.
+func (g *GoGitOperations) CurrentBranch(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if head.Name().IsBranch() {
+		return head.Name().Short(), nil
+	}
+	return "HEAD", nil
+}
+
+// GetRemoteURL returns the remote URL.
+func (g *GoGitOperations) GetRemoteURL(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote origin: %w", err)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote origin has no URL")
+	}
+	return urls[0], nil
+}
+
+// HasUncommittedChanges checks if there are uncommitted changes.
+func (g *GoGitOperations) HasUncommittedChanges(ctx context.Context) (bool, error) {
+	repo, err := g.open()
+	if err != nil {
+		return false, err
+	}
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return false, err
+	}
+	return !status.IsClean(), nil
+}
+
+// GetDefaultBranch returns the default branch (main or master).
+func (g *GoGitOperations) GetDefaultBranch(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	// Try to get from remote HEAD
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().Short(), "origin/"), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if _, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), false); err == nil {
+			return branch, nil
+		}
+	}
+	return "main", nil
+}
+
+// CommittedFile returns the content of the file at path as committed on
+// origin's default branch, or nil when it isn't there.
+func (g *GoGitOperations) CommittedFile(ctx context.Context, path string) ([]byte, error) {
+	branch, err := g.GetDefaultBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve origin/%s: %w", branch, err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit of origin/%s: %w", branch, err)
+	}
+	file, err := commit.File(filepath.ToSlash(path))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s on origin/%s: %w", path, branch, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s on origin/%s: %w", path, branch, err)
+	}
+	return []byte(content), nil
+}
+
+// open opens the repository, searching parent directories for .git.
+func (g *GoGitOperations) open() (*gogit.Repository, error) {
+	repo, err := gogit.PlainOpenWithOptions(g.repoPath, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return repo, nil
+}
+
+// worktreeStatus returns the status of the repository's working tree.
+func worktreeStatus(repo *gogit.Repository) (gogit.Status, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	return status, nil
+}
+
+// headBranch describes what HEAD points at, including unborn branches.
+func headBranch(repo *gogit.Repository) string {
+	ref, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "HEAD (no branch)"
+	}
+	if ref.Type() == plumbing.SymbolicReference {
+		if _, err := repo.Reference(ref.Target(), false); err != nil {
+			return "No commits yet on " + ref.Target().Short()
+		}
+		return ref.Target().Short()
+	}
+	return "HEAD (no branch)"
+}
+
+// formatCommit formats a commit like the matching `git log --format`.
+func formatCommit(c *object.Commit, format string) string {
+	hash := c.Hash.String()
+	title, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+
+	switch format {
+	case "short":
+		return fmt.Sprintf("commit %s\nAuthor: %s <%s>\n\n    %s\n\n", hash, c.Author.Name, c.Author.Email, title)
+	case "medium":
+		return fmt.Sprintf("commit %s\nAuthor: %s <%s>\nDate:   %s\n\n%s\n",
+			hash, c.Author.Name, c.Author.Email, c.Author.When.Format(object.DateFormat), indentMessage(c.Message))
+	case "full":
+		return fmt.Sprintf("commit %s\nAuthor: %s <%s>\nCommit: %s <%s>\n\n%s\n",
+			hash, c.Author.Name, c.Author.Email, c.Committer.Name, c.Committer.Email, indentMessage(c.Message))
+	default:
+		return hash[:7] + " " + title + "\n"
+	}
+}
+
+// indentMessage indents each line of a commit message by four spaces.
+func indentMessage(message string) string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "    " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// cleanPath normalizes a repository-relative path filter.
+func cleanPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	if path == "." {
+		return ""
+	}
+	return path
+}
+
+// inPath reports whether file is path or lies beneath it.
+func inPath(file, path string) bool {
+	return path == "" || file == path || strings.HasPrefix(file, path+"/")
+}
+
+// snapshot maps file paths to blob hashes. A zero hash means the content must
+// be read from the working tree.
+type snapshot map[string]plumbing.Hash
+
+// treeSnapshot lists the files of the commit rev resolves to.
+func treeSnapshot(repo *gogit.Repository, rev string) (snapshot, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", rev, err)
+	}
+
+	s := make(snapshot)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		s[f.Name] = f.Hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", rev, err)
+	}
+	return s, nil
+}
+
+// indexSnapshot lists the files staged in the index.
+func indexSnapshot(repo *gogit.Repository) (snapshot, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	s := make(snapshot, len(idx.Entries))
+	for _, e := range idx.Entries {
+		s[e.Name] = e.Hash
+	}
+	return s, nil
+}
+
+// worktreeSnapshot lists the tracked files in the working tree. Untracked
+// files are left out, as in `git diff`.
+func worktreeSnapshot(repo *gogit.Repository) (snapshot, error) {
+	s, err := indexSnapshot(repo)
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+	for path, st := range status {
+		switch st.Worktree {
+		case gogit.Unmodified, gogit.Untracked:
+		case gogit.Deleted:
+			delete(s, path)
+		default:
+			s[path] = plumbing.ZeroHash
+		}
+	}
+	return s, nil
+}
+
+// formatDiff renders the differences between two snapshots as a unified
+// diff, limited to files under path.
+func (g *GoGitOperations) formatDiff(repo *gogit.Repository, from, to snapshot, path string) (string, error) {
+	path = cleanPath(path)
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, s := range []snapshot{from, to} {
+		for p := range s {
+			if !seen[p] && inPath(p, path) {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	var patches textPatch
+	for _, p := range paths {
+		fromHash, inFrom := from[p]
+		toHash, inTo := to[p]
+		if inFrom && inTo && fromHash == toHash && !fromHash.IsZero() {
+			continue
+		}
+
+		var before, after []byte
+		var fromFile, toFile fdiff.File
+		var err error
+		if inFrom {
+			if before, fromHash, err = g.content(repo, p, fromHash); err != nil {
+				return "", err
+			}
+			fromFile = textFile{path: p, hash: fromHash}
+		}
+		if inTo {
+			if after, toHash, err = g.content(repo, p, toHash); err != nil {
+				return "", err
+			}
+			toFile = textFile{path: p, hash: toHash}
+		}
+		if inFrom && inTo && fromHash == toHash {
+			continue
+		}
+
+		fp := &textFilePatch{from: fromFile, to: toFile}
+		if isBinary(before) || isBinary(after) {
+			fp.binary = true
+		} else {
+			for _, d := range diff.Do(string(before), string(after)) {
+				fp.chunks = append(fp.chunks, textChunk{content: d.Text, op: chunkOperation(d.Type)})
+			}
+		}
+		patches = append(patches, fp)
+	}
+
+	var buf bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&buf, fdiff.DefaultContextLines).Encode(patches); err != nil {
+		return "", fmt.Errorf("failed to format diff: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// content returns a file's content and blob hash, reading the working tree
+// when hash is zero.
+func (g *GoGitOperations) content(repo *gogit.Repository, path string, hash plumbing.Hash) ([]byte, plumbing.Hash, error) {
+	if hash.IsZero() {
+		wt, err := repo.Worktree()
+		if err != nil {
+			return nil, hash, fmt.Errorf("failed to open worktree: %w", err)
+		}
+		data, err := os.ReadFile(filepath.Join(wt.Filesystem.Root(), path))
+		if err != nil {
+			return nil, hash, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return data, plumbing.ComputeHash(plumbing.BlobObject, data), nil
+	}
+
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, hash, fmt.Errorf("failed to read blob for %s: %w", path, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, hash, fmt.Errorf("failed to read blob for %s: %w", path, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, hash, fmt.Errorf("failed to read blob for %s: %w", path, err)
+	}
+	return data, hash, nil
+}
+
+// isBinary reports whether content looks binary, as git decides it: a NUL
+// byte within the first 8000 bytes.
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// chunkOperation maps a diffmatchpatch operation to a diff chunk operation.
+func chunkOperation(op diffmatchpatch.Operation) fdiff.Operation {
+	switch op {
+	case diffmatchpatch.DiffInsert:
+		return fdiff.Add
+	case diffmatchpatch.DiffDelete:
+		return fdiff.Delete
+	default:
+		return fdiff.Equal
+	}
+}
+
+// textPatch is a set of file patches for the unified diff encoder.
+type textPatch []fdiff.FilePatch
+
+func (p textPatch) FilePatches() []fdiff.FilePatch { return p }
+func (p textPatch) Message() string                { return "" }
+
+// textFilePatch is the change to a single file.
+type textFilePatch struct {
+	from, to fdiff.File
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *textFilePatch) IsBinary() bool               { return p.binary }
+func (p *textFilePatch) Files() (from, to fdiff.File) { return p.from, p.to }
+func (p *textFilePatch) Chunks() []fdiff.Chunk        { return p.chunks }
+
+// textFile is one side of a file patch.
+type textFile struct {
+	path string
+	hash plumbing.Hash
+}
+
+func (f textFile) Hash() plumbing.Hash     { return f.hash }
+func (f textFile) Mode() filemode.FileMode { return filemode.Regular }
+func (f textFile) Path() string            { return f.path }
+
+// textChunk is a run of equal, added or deleted text.
+type textChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c textChunk) Content() string       { return c.content }
+func (c textChunk) Type() fdiff.Operation { return c.op }
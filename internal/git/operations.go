@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,25 +17,98 @@ import (
 const (
 	// CommandTimeout is the timeout for git commands.
 	CommandTimeout = 2 * time.Minute
+
+	// commitTrailer attributes bot commits.
+	commitTrailer = "\n\nCo-Authored-By: StormStack Dev Bot <bot@stormstack.dev>"
+)
+
+// Git backends.
+const (
+	// BackendAuto uses the git binary when it is installed and go-git otherwise
+	BackendAuto = "auto"
+	// BackendCLI shells out to the git binary
+	BackendCLI = "cli"
+	// BackendGoGit uses the pure Go go-git library
+	BackendGoGit = "go-git"
 )
 
 // Operations provides git operations for a repository.
-type Operations struct {
+type Operations interface {
+	Status(ctx context.Context) (string, error)
+	Diff(ctx context.Context, staged bool, ref, path string) (string, error)
+	Log(ctx context.Context, count int, path, format string) (string, error)
+	CreateBranch(ctx context.Context, name, from string) error
+	CheckoutBranch(ctx context.Context, name string) error
+	Commit(ctx context.Context, message string, files []string) error
+	CommitNoVerify(ctx context.Context, message string, files []string) error
+	ChangedFiles(ctx context.Context) ([]string, error)
+	ChangedSince(ctx context.Context, base string) ([]string, error)
+	Push(ctx context.Context, setUpstream bool) error
+	CurrentBranch(ctx context.Context) (string, error)
+	GetRemoteURL(ctx context.Context) (string, error)
+	HasUncommittedChanges(ctx context.Context) (bool, error)
+	GetDefaultBranch(ctx context.Context) (string, error)
+	CommittedFile(ctx context.Context, path string) ([]byte, error)
+	Fetch(ctx context.Context) error
+	Stash(ctx context.Context, message string) error
+	StashPop(ctx context.Context) error
+	MergeConflicts(ctx context.Context, base, head string) ([]string, error)
+
+	// Conflict-aware history integration
+	Rebase(ctx context.Context, onto string) (*IntegrationResult, error)
+	Merge(ctx context.Context, ref string) (*IntegrationResult, error)
+	CherryPick(ctx context.Context, sha string) (*IntegrationResult, error)
+	ConflictedFiles(ctx context.Context) ([]string, error)
+	IntegrationInProgress(ctx context.Context) (string, error)
+	ContinueIntegration(ctx context.Context) (*IntegrationResult, error)
+	AbortIntegration(ctx context.Context) (string, error)
+
+	// Commit lookup and reverts
+	ResolveCommit(ctx context.Context, rev string) (*CommitInfo, error)
+	IsAncestor(ctx context.Context, commit, ref string) (bool, error)
+	Revert(ctx context.Context, sha string) error
+}
+
+var (
+	_ Operations = (*CLIOperations)(nil)
+	_ Operations = (*GoGitOperations)(nil)
+)
+
+// NewOperations creates git operations for the repository at repoPath using
+// the given backend.
+func NewOperations(repoPath, backend string) (Operations, error) {
+	switch backend {
+	case "", BackendAuto:
+		if _, err := exec.LookPath("git"); err != nil {
+			return NewGoGitOperations(repoPath), nil
+		}
+		return NewCLIOperations(repoPath), nil
+	case BackendCLI:
+		return NewCLIOperations(repoPath), nil
+	case BackendGoGit:
+		return NewGoGitOperations(repoPath), nil
+	default:
+		return nil, fmt.Errorf("unknown git backend %q", backend)
+	}
+}
+
+// CLIOperations provides git operations by running the git binary.
+type CLIOperations struct {
 	repoPath string
 }
 
-// NewOperations creates a new git operations instance.
-func NewOperations(repoPath string) *Operations {
-	return &Operations{repoPath: repoPath}
+// NewCLIOperations creates git operations backed by the git binary.
+func NewCLIOperations(repoPath string) *CLIOperations {
+	return &CLIOperations{repoPath: repoPath}
 }
 
 // Status returns the current git status.
-func (g *Operations) Status(ctx context.Context) (string, error) {
+func (g *CLIOperations) Status(ctx context.Context) (string, error) {
 	return g.runGit(ctx, "status", "--short", "--branch")
 }
 
 // Diff returns git diff output.
-func (g *Operations) Diff(ctx context.Context, staged bool, ref, path string) (string, error) {
+func (g *CLIOperations) Diff(ctx context.Context, staged bool, ref, path string) (string, error) {
 	args := []string{"diff"}
 
 	if staged {
@@ -52,7 +127,7 @@ func (g *Operations) Diff(ctx context.Context, staged bool, ref, path string) (s
 }
 
 // Log returns git log output.
-func (g *Operations) Log(ctx context.Context, count int, path, format string) (string, error) {
+func (g *CLIOperations) Log(ctx context.Context, count int, path, format string) (string, error) {
 	if count <= 0 {
 		count = 10
 	}
@@ -80,7 +155,7 @@ func (g *Operations) Log(ctx context.Context, count int, path, format string) (s
 }
 
 // CreateBranch creates a new branch and switches to it.
-func (g *Operations) CreateBranch(ctx context.Context, name, from string) error {
+func (g *CLIOperations) CreateBranch(ctx context.Context, name, from string) error {
 	// Sanitize branch name
 	name = executor.SanitizeBranchName(name)
 	if name == "" {
@@ -89,6 +164,9 @@ func (g *Operations) CreateBranch(ctx context.Context, name, from string) error
 
 	args := []string{"checkout", "-b", name}
 	if from != "" {
+		if strings.HasPrefix(from, "-") {
+			return fmt.Errorf("invalid ref: %s", from)
+		}
 		args = append(args, from)
 	}
 
@@ -96,8 +174,44 @@ func (g *Operations) CreateBranch(ctx context.Context, name, from string) error
 	return err
 }
 
+// CheckoutBranch switches to an existing branch of the remote, such as a
+// pull request's, creating the local branch to track it when there is none
+// and fast-forwarding it otherwise. Local commits that weren't pushed are
+// kept, so the fast-forward may not happen.
+func (g *CLIOperations) CheckoutBranch(ctx context.Context, name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name: %s", name)
+	}
+	remote := "origin/" + name
+	if _, err := g.runGit(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/"+remote); err != nil {
+		return fmt.Errorf("branch %s not found on origin", name)
+	}
+
+	if _, err := g.runGit(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+name); err != nil {
+		_, err := g.runGit(ctx, "checkout", "-b", name, "--track", remote)
+		return err
+	}
+	if _, err := g.runGit(ctx, "checkout", name); err != nil {
+		return err
+	}
+	// Diverged branches are left for a rebase or merge
+	_, _ = g.runGit(ctx, "merge", "--ff-only", remote)
+	return nil
+}
+
 // Commit stages files and creates a commit.
-func (g *Operations) Commit(ctx context.Context, message string, files []string) error {
+func (g *CLIOperations) Commit(ctx context.Context, message string, files []string) error {
+	return g.commit(ctx, message, files)
+}
+
+// CommitNoVerify stages files and creates a commit without running the
+// pre-commit and commit-msg hooks.
+func (g *CLIOperations) CommitNoVerify(ctx context.Context, message string, files []string) error {
+	return g.commit(ctx, message, files, "--no-verify")
+}
+
+// commit stages files and creates a commit, passing flags to git commit.
+func (g *CLIOperations) commit(ctx context.Context, message string, files []string, flags ...string) error {
 	// Sanitize commit message
 	message = executor.SanitizeCommitMessage(message)
 	if message == "" {
@@ -119,18 +233,71 @@ func (g *Operations) Commit(ctx context.Context, message string, files []string)
 	}
 
 	// Create commit
-	// Add co-author attribution
-	message = message + "\n\nCo-Authored-By: StormStack Dev Bot <bot@stormstack.dev>"
-
-	if _, err := g.runGit(ctx, "commit", "-m", message); err != nil {
+	args := append([]string{"commit", "-m", message + commitTrailer}, flags...)
+	if _, err := g.runGit(ctx, args...); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
 
 	return nil
 }
 
+// ChangedFiles returns the paths of modified, added and untracked files in
+// the worktree or index, including deleted ones.
+func (g *CLIOperations) ChangedFiles(ctx context.Context) ([]string, error) {
+	output, err := g.runGit(ctx, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, entry[3:])
+		// Renames and copies are followed by their original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// ChangedSince returns the paths changed on the current branch since it
+// forked from base, committed or not, including untracked files.
+func (g *CLIOperations) ChangedSince(ctx context.Context, base string) ([]string, error) {
+	if strings.HasPrefix(base, "-") {
+		return nil, fmt.Errorf("invalid revision: %s", base)
+	}
+	forkPoint, err := g.runGit(ctx, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find where the branch forked from %s: %w", base, err)
+	}
+	output, err := g.runGit(ctx, "diff", "--name-only", "-z", strings.TrimSpace(forkPoint), "--")
+	if err != nil {
+		return nil, err
+	}
+	uncommitted, err := g.ChangedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, file := range append(strings.Split(output, "\x00"), uncommitted...) {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 // Push pushes the current branch to the remote.
-func (g *Operations) Push(ctx context.Context, setUpstream bool) error {
+func (g *CLIOperations) Push(ctx context.Context, setUpstream bool) error {
 	args := []string{"push"}
 
 	if setUpstream {
@@ -146,7 +313,7 @@ func (g *Operations) Push(ctx context.Context, setUpstream bool) error {
 }
 
 // CurrentBranch returns the current branch name.
-func (g *Operations) CurrentBranch(ctx context.Context) (string, error) {
+func (g *CLIOperations) CurrentBranch(ctx context.Context) (string, error) {
 	output, err := g.runGit(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", err
@@ -155,7 +322,7 @@ func (g *Operations) CurrentBranch(ctx context.Context) (string, error) {
 }
 
 // GetRemoteURL returns the remote URL.
-func (g *Operations) GetRemoteURL(ctx context.Context) (string, error) {
+func (g *CLIOperations) GetRemoteURL(ctx context.Context) (string, error) {
 	output, err := g.runGit(ctx, "remote", "get-url", "origin")
 	if err != nil {
 		return "", err
@@ -164,7 +331,7 @@ func (g *Operations) GetRemoteURL(ctx context.Context) (string, error) {
 }
 
 // HasUncommittedChanges checks if there are uncommitted changes.
-func (g *Operations) HasUncommittedChanges(ctx context.Context) (bool, error) {
+func (g *CLIOperations) HasUncommittedChanges(ctx context.Context) (bool, error) {
 	output, err := g.runGit(ctx, "status", "--porcelain")
 	if err != nil {
 		return false, err
@@ -172,8 +339,29 @@ func (g *Operations) HasUncommittedChanges(ctx context.Context) (bool, error) {
 	return strings.TrimSpace(output) != "", nil
 }
 
+// CommittedFile returns the content of the file at path, relative to the
+// repository, as committed on origin's default branch, or nil when it isn't
+// there. Edits in the checkout and unmerged commits don't change it.
+func (g *CLIOperations) CommittedFile(ctx context.Context, path string) ([]byte, error) {
+	branch, err := g.GetDefaultBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spec := "origin/" + branch + ":" + filepath.ToSlash(path)
+	if _, exitCode, err := g.runGitWithExitCode(ctx, "cat-file", "-e", spec); err != nil {
+		return nil, err
+	} else if exitCode != 0 {
+		return nil, nil
+	}
+	output, err := g.runGit(ctx, "cat-file", "blob", spec)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
 // GetDefaultBranch returns the default branch (main or master).
-func (g *Operations) GetDefaultBranch(ctx context.Context) (string, error) {
+func (g *CLIOperations) GetDefaultBranch(ctx context.Context) (string, error) {
 	// Try to get from remote HEAD
 	output, err := g.runGit(ctx, "symbolic-ref", "refs/remotes/origin/HEAD", "--short")
 	if err == nil {
@@ -196,13 +384,13 @@ func (g *Operations) GetDefaultBranch(ctx context.Context) (string, error) {
 }
 
 // Fetch fetches from all remotes.
-func (g *Operations) Fetch(ctx context.Context) error {
+func (g *CLIOperations) Fetch(ctx context.Context) error {
 	_, err := g.runGit(ctx, "fetch", "--all")
 	return err
 }
 
 // Stash stashes current changes.
-func (g *Operations) Stash(ctx context.Context, message string) error {
+func (g *CLIOperations) Stash(ctx context.Context, message string) error {
 	args := []string{"stash", "push"}
 	if message != "" {
 		args = append(args, "-m", message)
@@ -212,13 +400,70 @@ func (g *Operations) Stash(ctx context.Context, message string) error {
 }
 
 // StashPop pops the latest stash.
-func (g *Operations) StashPop(ctx context.Context) error {
+func (g *CLIOperations) StashPop(ctx context.Context) error {
 	_, err := g.runGit(ctx, "stash", "pop")
 	return err
 }
 
+// MergeConflicts test-merges head into base without touching the working tree
+// and returns the paths that would conflict. An empty result means the refs
+// merge cleanly.
+func (g *CLIOperations) MergeConflicts(ctx context.Context, base, head string) ([]string, error) {
+	output, exitCode, err := g.runGitWithExitCode(ctx, "merge-tree", "--write-tree", "--name-only", "--no-messages", base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exit code 1 means the merge has conflicts; anything else is a failure
+	switch exitCode {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("git merge-tree %s %s failed with exit code %d", base, head, exitCode)
+	}
+
+	// First line is the resulting tree OID, followed by conflicted paths
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	var files []string
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// runGitWithExitCode executes a git command and returns its exit code instead
+// of treating a non-zero exit as an error.
+func (g *CLIOperations) runGitWithExitCode(ctx context.Context, args ...string) (string, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.repoPath
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", -1, fmt.Errorf("git command timed out")
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return stdout.String(), exitErr.ExitCode(), nil
+		}
+		return "", -1, fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+
+	return stdout.String(), 0, nil
+}
+
 // runGit executes a git command.
-func (g *Operations) runGit(ctx context.Context, args ...string) (string, error) {
+func (g *CLIOperations) runGit(ctx context.Context, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
 	defer cancel()
 
@@ -0,0 +1,128 @@
+// Branch protection rules and code owners, so pull requests target the right
+// branch and say what they will need to merge.
+
+package git
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// BranchProtection describes the rules protecting a branch on the forge.
+type BranchProtection struct {
+	Branch string
+	// RequiredReviews is how many approving reviews a PR needs (0 if none)
+	RequiredReviews int
+	// CodeOwnerReviews is whether the code owners of changed paths must approve
+	CodeOwnerReviews bool
+	// RequiredChecks are the checks that must pass
+	RequiredChecks []string
+	// PushRestricted is whether pushing to the branch directly is limited
+	PushRestricted bool
+}
+
+// String describes the rules, e.g. "`main` is protected: 2 approving
+// reviews, including code owners; checks build, lint must pass".
+func (p *BranchProtection) String() string {
+	var rules []string
+	switch {
+	case p.RequiredReviews > 0 && p.CodeOwnerReviews:
+		rules = append(rules, fmt.Sprintf("%d approving review(s), including code owners", p.RequiredReviews))
+	case p.RequiredReviews > 0:
+		rules = append(rules, fmt.Sprintf("%d approving review(s)", p.RequiredReviews))
+	case p.CodeOwnerReviews:
+		rules = append(rules, "approval from code owners")
+	}
+	if len(p.RequiredChecks) > 0 {
+		rules = append(rules, "checks "+strings.Join(p.RequiredChecks, ", ")+" must pass")
+	}
+	if p.PushRestricted {
+		rules = append(rules, "no direct pushes")
+	}
+	if len(rules) == 0 {
+		return fmt.Sprintf("`%s` is protected", p.Branch)
+	}
+	return fmt.Sprintf("`%s` is protected: %s", p.Branch, strings.Join(rules, "; "))
+}
+
+// addCheck adds a required check once.
+func (p *BranchProtection) addCheck(name string) {
+	if name != "" && !slices.Contains(p.RequiredChecks, name) {
+		p.RequiredChecks = append(p.RequiredChecks, name)
+	}
+}
+
+// branchMatches reports whether branch matches a protection pattern, which
+// may use * wildcards, e.g. "release/*".
+func branchMatches(pattern, branch string) bool {
+	if pattern == branch {
+		return true
+	}
+	ok, err := path.Match(pattern, branch)
+	return err == nil && ok
+}
+
+// CodeOwnersFiles are where forges look for a CODEOWNERS file, in order.
+var CodeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// codeOwnersRule assigns owners to the paths matching a pattern.
+type codeOwnersRule struct {
+	pattern gitignore.Pattern
+	owners  []string
+}
+
+// CodeOwners maps repository paths to the owners whose review they need.
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+// ParseCodeOwners parses a CODEOWNERS file.
+func ParseCodeOwners(content string) *CodeOwners {
+	co := &CodeOwners{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		// GitLab sections ("[Docs] @docs-team") only group rules
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var owners []string
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			owners = append(owners, field)
+		}
+		co.rules = append(co.rules, codeOwnersRule{pattern: gitignore.ParsePattern(fields[0], nil), owners: owners})
+	}
+	return co
+}
+
+// Owners returns the owners of a path: those of the last rule matching it.
+func (c *CodeOwners) Owners(relPath string) []string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.Match(parts, false) == gitignore.Exclude {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Review groups paths by the owners whose review they need, as "owners" to
+// paths, leaving out paths without owners.
+func (c *CodeOwners) Review(paths []string) map[string][]string {
+	review := make(map[string][]string)
+	for _, p := range paths {
+		if owners := c.Owners(p); len(owners) > 0 {
+			key := strings.Join(owners, " ")
+			review[key] = append(review[key], p)
+		}
+	}
+	return review
+}
@@ -0,0 +1,310 @@
+// Conflict-aware rebase, merge and cherry-pick operations.
+
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kinds of history integration that can be in progress.
+const (
+	IntegrationRebase     = "rebase"
+	IntegrationMerge      = "merge"
+	IntegrationCherryPick = "cherry-pick"
+)
+
+// backupRefPrefix is where the pre-integration state of a branch is kept.
+const backupRefPrefix = "refs/stormstack/backup/"
+
+// IntegrationResult describes the outcome of a rebase, merge, cherry-pick or continue.
+type IntegrationResult struct {
+	// Kind is IntegrationRebase, IntegrationMerge or IntegrationCherryPick
+	Kind string
+	// Backup is a ref pointing at the branch before the operation started
+	Backup string
+	// Conflicts lists files that need resolving; empty when the operation completed
+	Conflicts []string
+}
+
+// Rebase rebases the current branch onto the given ref. A backup ref is
+// written first; conflicts leave the rebase in progress for resolution.
+func (g *CLIOperations) Rebase(ctx context.Context, onto string) (*IntegrationResult, error) {
+	sha, err := g.resolveRef(ctx, onto)
+	if err != nil {
+		return nil, err
+	}
+	return g.integrate(ctx, IntegrationRebase, "rebase", "--end-of-options", sha)
+}
+
+// Merge merges ref into the current branch. A backup ref is written first;
+// conflicts leave the merge in progress for resolution.
+func (g *CLIOperations) Merge(ctx context.Context, ref string) (*IntegrationResult, error) {
+	sha, err := g.resolveRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return g.integrate(ctx, IntegrationMerge, "merge", "--no-edit", "-m", "Merge "+ref, "--end-of-options", sha)
+}
+
+// resolveRef returns the commit a ref names, so that only a SHA, never text
+// that could be read as an option, reaches the command integrating it.
+func (g *CLIOperations) resolveRef(ctx context.Context, ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref: %s", ref)
+	}
+	output, err := g.runGit(ctx, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %s not found", ref)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// CherryPick applies a commit to the current branch, noting the original
+// commit in the message. Merge commits are picked against their first
+// parent. A backup ref is written first; conflicts leave the cherry-pick in
+// progress for resolution.
+func (g *CLIOperations) CherryPick(ctx context.Context, sha string) (*IntegrationResult, error) {
+	commit, err := g.ResolveCommit(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"cherry-pick", "-x"}
+	if commit.Parents > 1 {
+		args = append(args, "-m", "1")
+	}
+	return g.integrate(ctx, IntegrationCherryPick, append(args, commit.SHA)...)
+}
+
+// integrate runs a rebase, merge or cherry-pick after checking the working tree is clean
+// and backing up the current branch.
+func (g *CLIOperations) integrate(ctx context.Context, kind string, args ...string) (*IntegrationResult, error) {
+	if current, err := g.IntegrationInProgress(ctx); err != nil {
+		return nil, err
+	} else if current != "" {
+		return nil, fmt.Errorf("a %s is already in progress; continue or abort it first", current)
+	}
+
+	dirty, err := g.HasUncommittedChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("the working tree has uncommitted changes; commit or stash them before a %s", kind)
+	}
+
+	backup, err := g.backup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IntegrationResult{Kind: kind, Backup: backup}
+	return result, g.runIntegration(ctx, result, args...)
+}
+
+// runIntegration runs a rebase, merge or cherry-pick step, recording conflicts in result.
+func (g *CLIOperations) runIntegration(ctx context.Context, result *IntegrationResult, args ...string) error {
+	// Never open an editor for commit messages
+	args = append([]string{"-c", "core.editor=true"}, args...)
+
+	output, exitCode, err := g.runGitWithExitCode(ctx, args...)
+	if err != nil {
+		return err
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	conflicts, err := g.ConflictedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return fmt.Errorf("git %s failed with exit code %d: %s", args[2], exitCode, strings.TrimSpace(output))
+	}
+	result.Conflicts = conflicts
+	return nil
+}
+
+// backup points a backup ref at HEAD and returns its name.
+func (g *CLIOperations) backup(ctx context.Context) (string, error) {
+	branch, err := g.CurrentBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	ref := fmt.Sprintf("%s%s-%d", backupRefPrefix, strings.ReplaceAll(branch, "/", "-"), time.Now().UnixMilli())
+	if _, err := g.runGit(ctx, "update-ref", ref, "HEAD"); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", branch, err)
+	}
+	return ref, nil
+}
+
+// ConflictedFiles returns the files with unresolved merge conflicts.
+func (g *CLIOperations) ConflictedFiles(ctx context.Context) ([]string, error) {
+	output, err := g.runGit(ctx, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// IntegrationInProgress returns the kind of integration in progress, or ""
+// when there is none.
+func (g *CLIOperations) IntegrationInProgress(ctx context.Context) (string, error) {
+	for _, probe := range []struct{ kind, path string }{
+		{IntegrationRebase, "rebase-merge"},
+		{IntegrationRebase, "rebase-apply"},
+		{IntegrationMerge, "MERGE_HEAD"},
+		{IntegrationCherryPick, "CHERRY_PICK_HEAD"},
+	} {
+		output, err := g.runGit(ctx, "rev-parse", "--git-path", probe.path)
+		if err != nil {
+			return "", err
+		}
+		path := strings.TrimSpace(output)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(g.repoPath, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return probe.kind, nil
+		}
+	}
+	return "", nil
+}
+
+// ContinueIntegration stages the resolved files and continues the rebase,
+// merge or cherry-pick in progress. Further conflicts are reported in the result.
+func (g *CLIOperations) ContinueIntegration(ctx context.Context) (*IntegrationResult, error) {
+	kind, err := g.IntegrationInProgress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("no rebase, merge or cherry-pick is in progress")
+	}
+
+	files, err := g.ConflictedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		args := append([]string{"add", "-A", "--"}, files...)
+		if _, err := g.runGit(ctx, args...); err != nil {
+			return nil, fmt.Errorf("failed to stage resolved files: %w", err)
+		}
+	}
+
+	result := &IntegrationResult{Kind: kind}
+	switch kind {
+	case IntegrationRebase:
+		return result, g.runIntegration(ctx, result, "rebase", "--continue")
+	case IntegrationCherryPick:
+		return result, g.runIntegration(ctx, result, "cherry-pick", "--continue")
+	}
+	return result, g.runIntegration(ctx, result, "commit", "--no-edit")
+}
+
+// AbortIntegration aborts the rebase, merge or cherry-pick in progress, restoring the
+// branch to its previous state.
+func (g *CLIOperations) AbortIntegration(ctx context.Context) (string, error) {
+	kind, err := g.IntegrationInProgress(ctx)
+	if err != nil {
+		return "", err
+	}
+	if kind == "" {
+		return "", fmt.Errorf("no rebase, merge or cherry-pick is in progress")
+	}
+	if _, err := g.runGit(ctx, kind, "--abort"); err != nil {
+		return "", err
+	}
+	return kind, nil
+}
+
+// ConflictHunk is one conflicted region of a file.
+type ConflictHunk struct {
+	// StartLine and EndLine are the 1-based lines of the <<<<<<< and >>>>>>> markers
+	StartLine int
+	EndLine   int
+	// Ours and Theirs are the labels and content of each side
+	OursLabel   string
+	Ours        []string
+	TheirsLabel string
+	Theirs      []string
+	// Base is the common ancestor content, present with diff3 conflict style
+	Base []string
+}
+
+// ParseConflicts extracts the conflict hunks from a file's content.
+func ParseConflicts(content string) []ConflictHunk {
+	var (
+		hunks   []ConflictHunk
+		current *ConflictHunk
+		section *[]string
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "<<<<<<<"):
+			current = &ConflictHunk{StartLine: line, OursLabel: strings.TrimSpace(text[7:])}
+			section = &current.Ours
+		case current == nil:
+		case strings.HasPrefix(text, "|||||||"):
+			current.Base = []string{}
+			section = &current.Base
+		case text == "=======":
+			section = &current.Theirs
+		case strings.HasPrefix(text, ">>>>>>>"):
+			current.EndLine = line
+			current.TheirsLabel = strings.TrimSpace(text[7:])
+			hunks = append(hunks, *current)
+			current, section = nil, nil
+		default:
+			*section = append(*section, text)
+		}
+	}
+	return hunks
+}
+
+// FormatConflicts formats a file's conflict hunks for display.
+func FormatConflicts(path string, hunks []ConflictHunk) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %d conflict(s)\n", path, len(hunks)))
+	for _, h := range hunks {
+		sb.WriteString(fmt.Sprintf("\nLines %d-%d\n", h.StartLine, h.EndLine))
+		sb.WriteString(fmt.Sprintf("<<<<<<< %s\n", h.OursLabel))
+		writeLines(&sb, h.Ours)
+		if h.Base != nil {
+			sb.WriteString("||||||| base\n")
+			writeLines(&sb, h.Base)
+		}
+		sb.WriteString("=======\n")
+		writeLines(&sb, h.Theirs)
+		sb.WriteString(fmt.Sprintf(">>>>>>> %s\n", h.TheirsLabel))
+	}
+	return sb.String()
+}
+
+// writeLines writes each line followed by a newline.
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, l := range lines {
+		sb.WriteString(l)
+		sb.WriteString("\n")
+	}
+}
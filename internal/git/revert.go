@@ -0,0 +1,93 @@
+// Commit lookup and revert operations.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CommitInfo identifies a commit.
+type CommitInfo struct {
+	SHA     string
+	Subject string
+	// Parents is the number of parent commits; more than one means a merge
+	Parents int
+}
+
+// ResolveCommit looks up the commit a revision (SHA, branch or tag) names.
+func (g *CLIOperations) ResolveCommit(ctx context.Context, rev string) (*CommitInfo, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision: %s", rev)
+	}
+	output, err := g.runGit(ctx, "log", "-1", "--format=%H%x00%P%x00%s", rev+"^{commit}", "--")
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found", rev)
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(output), "\x00", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("failed to parse commit %s", rev)
+	}
+	return &CommitInfo{
+		SHA:     fields[0],
+		Subject: fields[2],
+		Parents: len(strings.Fields(fields[1])),
+	}, nil
+}
+
+// IsAncestor reports whether commit is reachable from ref.
+func (g *CLIOperations) IsAncestor(ctx context.Context, commit, ref string) (bool, error) {
+	_, exitCode, err := g.runGitWithExitCode(ctx, "merge-base", "--is-ancestor", commit, ref)
+	if err != nil {
+		return false, err
+	}
+	switch exitCode {
+	case 0:
+		return true, nil
+	case 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("git merge-base --is-ancestor %s %s failed with exit code %d", commit, ref, exitCode)
+	}
+}
+
+// Revert commits the inverse of the given commit on the current branch. Merge
+// commits are reverted against their first parent. On conflicts the revert is
+// aborted, leaving the branch unchanged.
+func (g *CLIOperations) Revert(ctx context.Context, sha string) error {
+	commit, err := g.ResolveCommit(ctx, sha)
+	if err != nil {
+		return err
+	}
+
+	dirty, err := g.HasUncommittedChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("the working tree has uncommitted changes; commit or stash them before reverting")
+	}
+
+	args := []string{"revert", "--no-edit"}
+	if commit.Parents > 1 {
+		args = append(args, "-m", "1")
+	}
+	args = append(args, commit.SHA)
+
+	output, exitCode, err := g.runGitWithExitCode(ctx, args...)
+	if err != nil {
+		return err
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	conflicts, _ := g.ConflictedFiles(ctx)
+	g.runGit(ctx, "revert", "--abort")
+	if len(conflicts) > 0 {
+		return fmt.Errorf("reverting %s conflicts with later changes in %s; the revert was aborted", commit.SHA[:7], strings.Join(conflicts, ", "))
+	}
+	return fmt.Errorf("git revert %s failed with exit code %d: %s", commit.SHA[:7], exitCode, strings.TrimSpace(output))
+}
@@ -0,0 +1,71 @@
+// The review comments on a pull request still waiting for its author to
+// answer them.
+
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// quoteLength is how much of a comment QuoteReply quotes.
+const quoteLength = 200
+
+// QuoteReply builds a reply to a comment that can't be answered in a thread,
+// quoting the start of it.
+func QuoteReply(comment ReviewComment, body string) string {
+	quoted := strings.TrimSpace(comment.Body)
+	if i := strings.IndexByte(quoted, '\n'); i >= 0 {
+		quoted = quoted[:i] + " …"
+	}
+	if runes := []rune(quoted); len(runes) > quoteLength {
+		quoted = string(runes[:quoteLength]) + "…"
+	}
+	return fmt.Sprintf("> @%s: %s\n\n%s", comment.Author, quoted, body)
+}
+
+// PendingReviewComments returns the comments author hasn't answered: in
+// threads, those after author's last reply, and elsewhere, those after
+// author's last comment outside threads. They are sorted oldest first.
+func PendingReviewComments(comments []ReviewComment, author string) []ReviewComment {
+	// When author last commented in each thread, and outside threads
+	answered := make(map[string]time.Time)
+	for _, c := range comments {
+		if strings.EqualFold(c.Author, author) && c.CreatedAt.After(answered[c.Thread]) {
+			answered[c.Thread] = c.CreatedAt
+		}
+	}
+
+	var pending []ReviewComment
+	for _, c := range comments {
+		if strings.EqualFold(c.Author, author) || !c.CreatedAt.After(answered[c.Thread]) {
+			continue
+		}
+		pending = append(pending, c)
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	return pending
+}
+
+// FormatReviewComments formats review comments for display, with the IDs
+// replies need.
+func FormatReviewComments(comments []ReviewComment) string {
+	var sb strings.Builder
+	for _, c := range comments {
+		sb.WriteString(fmt.Sprintf("[%s] @%s", c.ID, c.Author))
+		if c.Path != "" {
+			sb.WriteString(fmt.Sprintf(" on %s:%d", c.Path, c.Line))
+		}
+		if c.State != "" {
+			sb.WriteString(fmt.Sprintf(" (review, %s)", strings.ToLower(c.State)))
+		}
+		sb.WriteString(":\n")
+		for _, line := range strings.Split(strings.TrimSpace(c.Body), "\n") {
+			sb.WriteString("  " + line + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
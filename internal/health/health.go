@@ -0,0 +1,109 @@
+// Package health provides the HTTP health endpoint.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check reports a component's health; a non-nil error means unhealthy.
+type Check func() error
+
+// Server serves the results of registered checks at /healthz.
+type Server struct {
+	addr   string
+	mu     sync.Mutex
+	checks map[string]Check
+	logger *slog.Logger
+}
+
+// NewServer creates a health server listening on addr (e.g. ":8080").
+func NewServer(addr string, logger *slog.Logger) *Server {
+	return &Server{
+		addr:   addr,
+		checks: make(map[string]Check),
+		logger: logger,
+	}
+}
+
+// Register adds a named check.
+func (s *Server) Register(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks[name] = check
+}
+
+// Report is the body returned by the health endpoint.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Run serves the health endpoint until the context is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealth)
+
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("health endpoint listening", "addr", s.addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve health endpoint: %w", err)
+	}
+	return nil
+}
+
+// Check runs every registered check.
+func (s *Server) Check() Report {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.checks))
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	checks := make(map[string]Check, len(s.checks))
+	for name, check := range s.checks {
+		checks[name] = check
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+
+	report := Report{Status: "ok", Checks: make(map[string]string, len(names))}
+	for _, name := range names {
+		if err := checks[name](); err != nil {
+			report.Status = "unhealthy"
+			report.Checks[name] = err.Error()
+		} else {
+			report.Checks[name] = "ok"
+		}
+	}
+	return report
+}
+
+// handleHealth writes the health report, with status 503 when unhealthy.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	report := s.Check()
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,109 @@
+// Package leader elects one replica to perform singleton work such as
+// scheduled jobs, using a lease in the shared store.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/metrics"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// LeaseName is the lease contended for by replicas.
+const LeaseName = "leader"
+
+// Elector campaigns for leadership and keeps renewing the lease while it
+// holds it. Leases are renewed three times per TTL, so a crashed leader is
+// replaced within one TTL.
+type Elector struct {
+	store   storage.LeaseStore
+	id      string
+	ttl     time.Duration
+	leader  atomic.Bool
+	metrics *metrics.Registry
+	logger  *slog.Logger
+}
+
+// New creates an elector identified by id. An empty id defaults to the
+// hostname and process ID.
+func New(store storage.LeaseStore, id string, ttl time.Duration, registry *metrics.Registry, logger *slog.Logger) *Elector {
+	if id == "" {
+		id = DefaultID()
+	}
+	return &Elector{
+		store:   store,
+		id:      id,
+		ttl:     ttl,
+		metrics: registry,
+		logger:  logger.With("instance", id),
+	}
+}
+
+// DefaultID identifies this process as hostname-pid.
+func DefaultID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// ID returns this instance's identifier.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether this instance currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until the context is cancelled, then releases the lease so
+// another instance can take over immediately.
+func (e *Elector) Run(ctx context.Context) {
+	e.campaign(ctx)
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := e.store.ReleaseLease(releaseCtx, LeaseName, e.id); err != nil {
+					e.logger.Warn("failed to release leadership", "error", err)
+				}
+			}
+			return
+		case <-ticker.C:
+			e.campaign(ctx)
+		}
+	}
+}
+
+// campaign tries to acquire or renew the lease and records any change in
+// leadership. Errors cost leadership, since the lease can't be renewed.
+func (e *Elector) campaign(ctx context.Context) {
+	acquired, err := e.store.AcquireLease(ctx, LeaseName, e.id, e.ttl)
+	if err != nil {
+		e.logger.Warn("failed to renew leadership lease", "error", err)
+		acquired = false
+	}
+
+	if was := e.leader.Swap(acquired); was != acquired {
+		if acquired {
+			e.metrics.Inc("leader.elected", 1)
+			e.logger.Info("became leader")
+		} else {
+			e.metrics.Inc("leader.lost", 1)
+			e.logger.Info("no longer leader")
+		}
+	}
+}
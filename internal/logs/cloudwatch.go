@@ -0,0 +1,91 @@
+// The AWS CloudWatch Logs backend.
+
+package logs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/awsapi"
+)
+
+// maxCloudWatchPages bounds the pages of events read per log group, so a
+// broad filter over a busy group can't run for minutes.
+const maxCloudWatchPages = 10
+
+// cloudWatch filters the events of CloudWatch Logs log groups. Requests are
+// signed with SigV4 using the default AWS credential chain.
+type cloudWatch struct {
+	groups []string
+	api    *awsapi.Client
+}
+
+// filterLogEventsRequest is the body of a FilterLogEvents call.
+type filterLogEventsRequest struct {
+	LogGroupName  string `json:"logGroupName"`
+	FilterPattern string `json:"filterPattern,omitempty"`
+	StartTime     int64  `json:"startTime"`
+	EndTime       int64  `json:"endTime"`
+	Limit         int    `json:"limit,omitempty"`
+	NextToken     string `json:"nextToken,omitempty"`
+}
+
+// filterLogEventsResponse is the body of a FilterLogEvents response.
+type filterLogEventsResponse struct {
+	Events []struct {
+		LogStreamName string `json:"logStreamName"`
+		Timestamp     int64  `json:"timestamp"`
+		Message       string `json:"message"`
+	} `json:"events"`
+	NextToken string `json:"nextToken"`
+}
+
+// newCloudWatch creates a CloudWatch Logs backend for log groups in region.
+func newCloudWatch(ctx context.Context, region string, groups []string, client *http.Client) (*cloudWatch, error) {
+	api, err := awsapi.New(ctx, "logs", "Logs_20140328", region, client)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudWatch{groups: groups, api: api}, nil
+}
+
+// Name returns "cloudwatch".
+func (c *cloudWatch) Name() string {
+	return BackendCloudWatch
+}
+
+// Query filters every log group's events in the time range with the filter
+// pattern, and returns the most recent oldest first.
+func (c *cloudWatch) Query(ctx context.Context, q Query) ([]Entry, error) {
+	var entries []Entry
+	for _, group := range c.groups {
+		request := filterLogEventsRequest{
+			LogGroupName:  group,
+			FilterPattern: q.Expr,
+			StartTime:     q.Start.UnixMilli(),
+			EndTime:       q.End.UnixMilli(),
+		}
+		// Events come oldest first, so read on and keep the newest
+		for page := 0; page < maxCloudWatchPages; page++ {
+			var resp filterLogEventsResponse
+			if err := c.api.Call(ctx, "FilterLogEvents", request, &resp); err != nil {
+				return nil, fmt.Errorf("log group %s: %w", group, err)
+			}
+			for _, event := range resp.Events {
+				entries = append(entries, Entry{
+					Time:    time.UnixMilli(event.Timestamp),
+					Stream:  group + "/" + event.LogStreamName,
+					Message: event.Message,
+				})
+			}
+			entries = newest(entries, q.Limit)
+			if resp.NextToken == "" {
+				break
+			}
+			request.NextToken = resp.NextToken
+		}
+	}
+	return newest(entries, q.Limit), nil
+}
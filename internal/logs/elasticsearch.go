@@ -0,0 +1,89 @@
+// The Elasticsearch (and OpenSearch) backend.
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// elasticsearch searches log documents with a query string.
+type elasticsearch struct {
+	baseURL string
+	index   string
+	token   string
+	http    *http.Client
+}
+
+// esResponse is the body of a search response.
+type esResponse struct {
+	Hits struct {
+		Hits []struct {
+			Index  string         `json:"_index"`
+			Source map[string]any `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Name returns "elasticsearch".
+func (e *elasticsearch) Name() string {
+	return BackendElasticsearch
+}
+
+// Query searches the index pattern for documents matching the query string
+// in the time range, by @timestamp, and returns the most recent oldest first.
+func (e *elasticsearch) Query(ctx context.Context, q Query) ([]Entry, error) {
+	search := map[string]any{
+		"size": q.Limit,
+		"sort": []any{map[string]any{"@timestamp": "desc"}},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": []any{map[string]any{"query_string": map[string]any{"query": q.Expr}}},
+				"filter": []any{map[string]any{"range": map[string]any{"@timestamp": map[string]any{
+					"gte": q.Start.UTC().Format(time.RFC3339Nano),
+					"lte": q.End.UTC().Format(time.RFC3339Nano),
+				}}}},
+			},
+		},
+	}
+	data, err := json.Marshal(search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Elasticsearch query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/"+e.index+"/_search", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Elasticsearch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, e.token)
+
+	body, err := do(e.http, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp esResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Elasticsearch response: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		entry := Entry{Stream: hit.Index}
+		if ts, ok := hit.Source["@timestamp"].(string); ok {
+			entry.Time, _ = time.Parse(time.RFC3339Nano, ts)
+		}
+		// Documents without a message field are shown whole
+		if message, ok := hit.Source["message"].(string); ok {
+			entry.Message = message
+		} else {
+			raw, _ := json.Marshal(hit.Source)
+			entry.Message = string(raw)
+		}
+		entries = append(entries, entry)
+	}
+	return newest(entries, q.Limit), nil
+}
@@ -0,0 +1,135 @@
+// Package logs provides queries of application logs in observability
+// backends (Loki, CloudWatch Logs and Elasticsearch), so the bot can read
+// recent logs while debugging instead of asking users to paste them.
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds a single request to a backend.
+const requestTimeout = 30 * time.Second
+
+// Backends the logs can be read from.
+const (
+	BackendLoki          = "loki"
+	BackendCloudWatch    = "cloudwatch"
+	BackendElasticsearch = "elasticsearch"
+)
+
+// Entry is a log line.
+type Entry struct {
+	Time time.Time
+	// Stream is where the line came from: Loki labels, a CloudWatch log
+	// stream or an Elasticsearch index
+	Stream  string
+	Message string
+}
+
+// Query selects log lines.
+type Query struct {
+	// Expr is in the backend's own syntax: LogQL for Loki, a filter pattern
+	// for CloudWatch, a query string for Elasticsearch
+	Expr  string
+	Start time.Time
+	End   time.Time
+	// Limit is the most lines returned, the most recent ones
+	Limit int
+}
+
+// Backend reads log lines from an observability backend.
+type Backend interface {
+	// Name returns the backend's name, e.g. "loki".
+	Name() string
+
+	// Query returns the most recent lines matching q, oldest first.
+	Query(ctx context.Context, q Query) ([]Entry, error)
+}
+
+// Config configures a backend.
+type Config struct {
+	Backend string
+	// URL is the Loki or Elasticsearch endpoint
+	URL string
+	// Token is a bearer token, or "user:password" for basic auth
+	Token string
+	// Index is the Elasticsearch index pattern
+	Index string
+	// Groups are the CloudWatch log groups, in Region
+	Groups []string
+	Region string
+}
+
+// New creates the configured backend.
+func New(ctx context.Context, cfg Config) (Backend, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	switch cfg.Backend {
+	case BackendLoki:
+		return &loki{baseURL: strings.TrimSuffix(cfg.URL, "/"), token: cfg.Token, http: client}, nil
+	case BackendElasticsearch:
+		return &elasticsearch{baseURL: strings.TrimSuffix(cfg.URL, "/"), index: cfg.Index, token: cfg.Token, http: client}, nil
+	case BackendCloudWatch:
+		return newCloudWatch(ctx, cfg.Region, cfg.Groups, client)
+	default:
+		return nil, fmt.Errorf("unknown logs backend %q", cfg.Backend)
+	}
+}
+
+// Format renders entries one per line, with their time and stream.
+func Format(entries []Entry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString(e.Time.UTC().Format(time.RFC3339Nano))
+		if e.Stream != "" {
+			sb.WriteString(" [" + e.Stream + "]")
+		}
+		sb.WriteString(" " + strings.TrimRight(e.Message, "\n") + "\n")
+	}
+	return sb.String()
+}
+
+// newest keeps the limit most recent entries, oldest first.
+func newest(entries []Entry, limit int) []Entry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// authorize adds the token to a request: basic auth for "user:password",
+// a bearer token otherwise.
+func authorize(req *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	if user, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(user, password)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// do sends a request and returns the body of a successful response.
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("logs backend returned %s: %s", resp.Status, strings.TrimSpace(string(body[:min(len(body), 500)])))
+	}
+	return body, nil
+}
@@ -0,0 +1,91 @@
+// The Grafana Loki backend.
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loki queries Grafana Loki with LogQL.
+type loki struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// lokiResponse is the body of a query_range response for a log query.
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Name returns "loki".
+func (l *loki) Name() string {
+	return BackendLoki
+}
+
+// Query runs a LogQL log query over the time range, newest lines first, and
+// returns them oldest first.
+func (l *loki) Query(ctx context.Context, q Query) ([]Entry, error) {
+	params := url.Values{
+		"query":     {q.Expr},
+		"start":     {strconv.FormatInt(q.Start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(q.End.UnixNano(), 10)},
+		"limit":     {strconv.Itoa(q.Limit)},
+		"direction": {"backward"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.baseURL+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Loki request: %w", err)
+	}
+	authorize(req, l.token)
+
+	body, err := do(l.http, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp lokiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Loki response: %w", err)
+	}
+	if resp.Data.ResultType != "" && resp.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("the query returned %s, not log lines: use a log query such as {app=\"api\"} |= \"error\"", resp.Data.ResultType)
+	}
+
+	var entries []Entry
+	for _, stream := range resp.Data.Result {
+		labels := streamLabels(stream.Stream)
+		for _, value := range stream.Values {
+			ns, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, Entry{Time: time.Unix(0, ns), Stream: labels, Message: value[1]})
+		}
+	}
+	return newest(entries, q.Limit), nil
+}
+
+// streamLabels renders a stream's labels as in LogQL, sorted.
+func streamLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
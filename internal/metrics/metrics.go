@@ -0,0 +1,124 @@
+// Package metrics provides lightweight in-process counters and timings.
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timing aggregates observed durations for a single metric.
+type Timing struct {
+	Count int64         `json:"count"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+	Last  time.Duration `json:"last"`
+}
+
+// Mean returns the average observed duration.
+func (t Timing) Mean() time.Duration {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Count)
+}
+
+// Snapshot is a point-in-time copy of all metrics.
+type Snapshot struct {
+	Counters map[string]int64  `json:"counters"`
+	Timings  map[string]Timing `json:"timings"`
+}
+
+// Names returns the sorted names of all metrics in the snapshot.
+func (s Snapshot) Names() []string {
+	names := make([]string, 0, len(s.Counters)+len(s.Timings))
+	for name := range s.Counters {
+		names = append(names, name)
+	}
+	for name := range s.Timings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Registry holds named counters and timings.
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]int64
+	timings  map[string]*Timing
+}
+
+// NewRegistry creates a new metrics registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]int64),
+		timings:  make(map[string]*Timing),
+	}
+}
+
+// Inc adds delta to a counter.
+func (r *Registry) Inc(name string, delta int64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[name] += delta
+}
+
+// Observe records a duration for a timing.
+func (r *Registry) Observe(name string, d time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.timings[name]
+	if !ok {
+		t = &Timing{}
+		r.timings[name] = t
+	}
+	t.Count++
+	t.Total += d
+	t.Last = d
+	if d > t.Max {
+		t.Max = d
+	}
+}
+
+// Counter returns the current value of a counter.
+func (r *Registry) Counter(name string) int64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.counters[name]
+}
+
+// Snapshot returns a copy of all metrics.
+func (r *Registry) Snapshot() Snapshot {
+	snap := Snapshot{
+		Counters: make(map[string]int64),
+		Timings:  make(map[string]Timing),
+	}
+	if r == nil {
+		return snap
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name, v := range r.counters {
+		snap.Counters[name] = v
+	}
+	for name, t := range r.timings {
+		snap.Timings[name] = *t
+	}
+	return snap
+}
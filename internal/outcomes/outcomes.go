@@ -0,0 +1,238 @@
+// Package outcomes provides tracking of what became of the bot's pull
+// requests: whether they were merged, how long that took and how much
+// review they needed.
+package outcomes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// Pull request states.
+const (
+	StateOpen   = "open"
+	StateMerged = "merged"
+	StateClosed = "closed"
+)
+
+// maxCollected is how many of the bot's most recent pull requests each
+// collection looks at.
+const maxCollected = 200
+
+// Outcome is what became of one of the bot's pull requests.
+type Outcome struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	MergedAt  time.Time `json:"merged_at"`
+	ClosedAt  time.Time `json:"closed_at"`
+	// ReviewComments counts reviews, conversation and inline comments left
+	// by anyone but the bot
+	ReviewComments int       `json:"review_comments"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// Final reports whether the pull request was merged or closed, so its
+// outcome can no longer change.
+func (o Outcome) Final() bool {
+	return o.State == StateMerged || o.State == StateClosed
+}
+
+// TimeToMerge returns how long the pull request took to merge, or 0 if it
+// wasn't merged.
+func (o Outcome) TimeToMerge() time.Duration {
+	if o.State != StateMerged || o.MergedAt.IsZero() {
+		return 0
+	}
+	return o.MergedAt.Sub(o.CreatedAt)
+}
+
+// Store persists outcomes as a JSON file, read afresh on every load so
+// replicas sharing the file see the leader's updates.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	// memory holds the outcomes when there is no file
+	memory map[int]Outcome
+}
+
+// NewStore creates an outcome store persisted at path. An empty path keeps
+// outcomes in memory only.
+func NewStore(path string) *Store {
+	return &Store{path: path, memory: make(map[int]Outcome)}
+}
+
+// Load returns the stored outcomes, newest pull request first.
+func (s *Store) Load() ([]Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byNumber, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	outcomes := make([]Outcome, 0, len(byNumber))
+	for _, o := range byNumber {
+		outcomes = append(outcomes, o)
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[i].Number > outcomes[j].Number
+	})
+	return outcomes, nil
+}
+
+// Save stores outcomes, replacing any stored for the same pull requests.
+func (s *Store) Save(updated []Outcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byNumber, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, o := range updated {
+		byNumber[o.Number] = o
+	}
+	if s.path == "" {
+		s.memory = byNumber
+		return nil
+	}
+
+	outcomes := make([]Outcome, 0, len(byNumber))
+	for _, o := range byNumber {
+		outcomes = append(outcomes, o)
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[i].Number < outcomes[j].Number
+	})
+	data, err := json.MarshalIndent(outcomes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode PR outcomes: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create PR outcomes directory: %w", err)
+	}
+
+	// Write to a temp file first so a crash can't corrupt the store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write PR outcomes file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// load reads the stored outcomes by PR number. Callers must hold the lock.
+func (s *Store) load() (map[int]Outcome, error) {
+	byNumber := make(map[int]Outcome)
+	if s.path == "" {
+		for n, o := range s.memory {
+			byNumber[n] = o
+		}
+		return byNumber, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return byNumber, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PR outcomes file: %w", err)
+	}
+	var outcomes []Outcome
+	if err := json.Unmarshal(data, &outcomes); err != nil {
+		return nil, fmt.Errorf("failed to parse PR outcomes file: %w", err)
+	}
+	for _, o := range outcomes {
+		byNumber[o.Number] = o
+	}
+	return byNumber, nil
+}
+
+// Source lists the bot's pull requests and the comments left on them.
+// *git.GitHub is a Source.
+type Source interface {
+	ListOwnPRs(ctx context.Context, limit int) ([]git.PRStatus, error)
+	GetPRReviewComments(ctx context.Context, prRef string) ([]git.ReviewComment, error)
+}
+
+// Collector periodically brings the stored outcomes up to date.
+type Collector struct {
+	source Source
+	store  *Store
+	logger *slog.Logger
+}
+
+// NewCollector creates a collector storing the outcomes of source's pull
+// requests in store.
+func NewCollector(source Source, store *Store, logger *slog.Logger) *Collector {
+	return &Collector{source: source, store: store, logger: logger}
+}
+
+// Collect queries the bot's recent pull requests and stores their outcomes.
+// Pull requests already stored as merged or closed are not queried again.
+// It is meant to run as a scheduled job.
+func (c *Collector) Collect(ctx context.Context) error {
+	prs, err := c.source.ListOwnPRs(ctx, maxCollected)
+	if err != nil {
+		return fmt.Errorf("failed to list bot PRs: %w", err)
+	}
+	stored, err := c.store.Load()
+	if err != nil {
+		return err
+	}
+	known := make(map[int]Outcome, len(stored))
+	for _, o := range stored {
+		known[o.Number] = o
+	}
+
+	var updated []Outcome
+	for _, pr := range prs {
+		previous, ok := known[pr.Number]
+		if ok && previous.Final() {
+			continue
+		}
+
+		o := Outcome{
+			Number:         pr.Number,
+			Title:          pr.Title,
+			URL:            pr.URL,
+			State:          strings.ToLower(pr.State),
+			CreatedAt:      pr.CreatedAt,
+			MergedAt:       pr.MergedAt,
+			ClosedAt:       pr.ClosedAt,
+			ReviewComments: previous.ReviewComments,
+			CheckedAt:      time.Now(),
+		}
+		comments, err := c.source.GetPRReviewComments(ctx, strconv.Itoa(pr.Number))
+		if err != nil {
+			c.logger.Warn("failed to count PR review comments", "pr", pr.Number, "error", err)
+		} else {
+			o.ReviewComments = 0
+			for _, comment := range comments {
+				if comment.Author != pr.Author {
+					o.ReviewComments++
+				}
+			}
+		}
+		updated = append(updated, o)
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+	c.logger.Debug("collected PR outcomes", "updated", len(updated))
+	return c.store.Save(updated)
+}
@@ -0,0 +1,144 @@
+// Reports on the bot's pull request outcomes.
+
+package outcomes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report summarizes the outcomes of the pull requests the bot opened in a
+// period.
+type Report struct {
+	Since  time.Time
+	Opened int
+	Merged int
+	// Closed counts pull requests closed without merging
+	Closed int
+	Open   int
+	// MedianTimeToMerge and MeanTimeToMerge are over merged pull requests
+	MedianTimeToMerge time.Duration
+	MeanTimeToMerge   time.Duration
+	ReviewComments    int
+	Outcomes          []Outcome
+}
+
+// Summarize builds the report of the pull requests opened since the given
+// time.
+func Summarize(since time.Time, outcomes []Outcome) *Report {
+	report := &Report{Since: since}
+	var merges []time.Duration
+	var total time.Duration
+
+	for _, o := range outcomes {
+		if o.CreatedAt.Before(since) {
+			continue
+		}
+		report.Outcomes = append(report.Outcomes, o)
+		report.Opened++
+		report.ReviewComments += o.ReviewComments
+		switch o.State {
+		case StateMerged:
+			report.Merged++
+			merges = append(merges, o.TimeToMerge())
+			total += o.TimeToMerge()
+		case StateClosed:
+			report.Closed++
+		default:
+			report.Open++
+		}
+	}
+
+	if len(merges) > 0 {
+		sort.Slice(merges, func(i, j int) bool { return merges[i] < merges[j] })
+		report.MedianTimeToMerge = merges[len(merges)/2]
+		if len(merges)%2 == 0 {
+			report.MedianTimeToMerge = (merges[len(merges)/2-1] + merges[len(merges)/2]) / 2
+		}
+		report.MeanTimeToMerge = total / time.Duration(len(merges))
+	}
+	return report
+}
+
+// MergeRate returns the share of decided (merged or closed) pull requests
+// that were merged, from 0 to 1.
+func (r *Report) MergeRate() float64 {
+	if r.Merged+r.Closed == 0 {
+		return 0
+	}
+	return float64(r.Merged) / float64(r.Merged+r.Closed)
+}
+
+// Format renders the report as a Slack message.
+func (r *Report) Format() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":chart_with_upwards_trend: *Bot PR outcomes since %s*\n", r.Since.Format("Mon 2 Jan 2006")))
+	if r.Opened == 0 {
+		sb.WriteString("No PRs opened.")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("• %d PRs opened: %d merged, %d closed without merging, %d still open\n",
+		r.Opened, r.Merged, r.Closed, r.Open))
+	if r.Merged+r.Closed > 0 {
+		sb.WriteString(fmt.Sprintf("• Merge rate: %.0f%% of the %d decided PRs\n", 100*r.MergeRate(), r.Merged+r.Closed))
+	}
+	if r.Merged > 0 {
+		sb.WriteString(fmt.Sprintf("• Time to merge: median %s, mean %s\n",
+			formatDuration(r.MedianTimeToMerge), formatDuration(r.MeanTimeToMerge)))
+	}
+	sb.WriteString(fmt.Sprintf("• Review comments: %.1f per PR (%d in total)",
+		float64(r.ReviewComments)/float64(r.Opened), r.ReviewComments))
+	return sb.String()
+}
+
+// CSV exports the report's pull requests, one row each, for spreadsheets.
+func (r *Report) CSV() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"number", "title", "url", "state", "created_at", "merged_at", "closed_at", "hours_to_merge", "review_comments"})
+	for _, o := range r.Outcomes {
+		hours := ""
+		if o.State == StateMerged {
+			hours = strconv.FormatFloat(o.TimeToMerge().Hours(), 'f', 1, 64)
+		}
+		w.Write([]string{
+			strconv.Itoa(o.Number),
+			o.Title,
+			o.URL,
+			o.State,
+			formatTime(o.CreatedAt),
+			formatTime(o.MergedAt),
+			formatTime(o.ClosedAt),
+			hours,
+			strconv.Itoa(o.ReviewComments),
+		})
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// formatTime formats a time for export, or "" if it is unset.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatDuration abbreviates a duration, e.g. 2d 4h or 35m.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}
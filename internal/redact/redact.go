@@ -0,0 +1,124 @@
+// Package redact scrubs secrets from text before it leaves the bot.
+package redact
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces redacted text.
+const Placeholder = "[REDACTED]"
+
+// DefaultPatterns match well-known secret formats. When a pattern has capture
+// groups only the last group is redacted, so surrounding context stays readable.
+var DefaultPatterns = []string{
+	// Private keys
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	// GitHub tokens
+	`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	// Slack tokens
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`,
+	`\bxapp-[A-Za-z0-9-]{10,}\b`,
+	// Anthropic and OpenAI API keys
+	`\bsk-ant-[A-Za-z0-9_-]{20,}\b`,
+	`\bsk-(?:proj-)?[A-Za-z0-9]{32,}\b`,
+	// AWS access key IDs
+	`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`,
+	// Google API keys
+	`\bAIza[A-Za-z0-9_-]{35}\b`,
+	// JSON web tokens
+	`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`,
+	// Credentials embedded in URLs
+	`(?i)\b[a-z][a-z0-9+.-]*://([^\s:/@]+:[^\s@/]+)@`,
+	// Assignments of quoted literals such as api_key: "...", but not of
+	// expressions such as APIKey: cfg.APIKey
+	`(?i)\b[\w.-]*(?:password|passwd|secret|api_?key|access_?key|auth_?token|private_?key)["']?\s*[:=]\s*["']([^\s"']{6,})["']`,
+	// Lines of env files such as PASSWORD=...
+	`(?im)^\s*(?:export\s+)?[\w.-]*(?:password|passwd|secret|api_?key|access_?key|auth_?token|private_?key)=([^\s"'$]{6,})\s*$`,
+}
+
+// Redactor replaces matches of its patterns with Placeholder.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// New creates a redactor from the default patterns, extra regular expressions
+// and hostnames. A hostname starting with "." matches any subdomain.
+func New(extra []string, hostnames []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string{}, DefaultPatterns...), extra...) {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+
+	for _, host := range hostnames {
+		expr := regexp.QuoteMeta(strings.ToLower(host))
+		if strings.HasPrefix(host, ".") {
+			expr = `[a-z0-9-]+(?:\.[a-z0-9-]+)*` + expr
+		}
+		r.patterns = append(r.patterns, regexp.MustCompile(`(?i)\b`+expr+`\b`))
+	}
+
+	return r, nil
+}
+
+// LoadPatterns reads custom patterns from a file, one regular expression per
+// line. Blank lines and lines starting with # are ignored.
+func LoadPatterns(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open redaction patterns: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read redaction patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// Redact returns s with every match replaced by Placeholder. A nil Redactor
+// returns s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, pattern := range r.patterns {
+		if pattern.NumSubexp() == 0 {
+			s = pattern.ReplaceAllString(s, Placeholder)
+			continue
+		}
+
+		var sb strings.Builder
+		last := 0
+		group := pattern.NumSubexp()
+		for _, m := range pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[2*group], m[2*group+1]
+			if start < 0 {
+				continue
+			}
+			sb.WriteString(s[last:start])
+			sb.WriteString(Placeholder)
+			last = end
+		}
+		sb.WriteString(s[last:])
+		s = sb.String()
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+// Git authentication for sandbox clones that keeps the token out of remote
+// URLs and error messages.
+
+package repo
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// credentialsFile holds the token for git's store credential helper, in the
+// workspace beside the clone rather than inside it.
+const credentialsFile = ".git-credentials"
+
+// credentialsUser is the user name the token is sent with; forges ignore it
+// for token authentication, but git needs one.
+const credentialsUser = "x-access-token"
+
+// writeCredentials saves the token for the remote's host where the store
+// credential helper reads it. It does nothing without a token.
+func (r *SandboxRepo) writeCredentials() error {
+	if r.githubToken == "" {
+		return nil
+	}
+	remote, err := url.Parse(r.remoteURL())
+	if err != nil {
+		return fmt.Errorf("failed to parse repository URL: %w", err)
+	}
+	entry := url.URL{Scheme: remote.Scheme, Host: remote.Host, User: url.UserPassword(credentialsUser, r.githubToken)}
+	if err := os.WriteFile(r.credentialsPath(), []byte(entry.String()+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write git credentials: %w", err)
+	}
+	return nil
+}
+
+// credentialsPath returns the path of the credentials file.
+func (r *SandboxRepo) credentialsPath() string {
+	return filepath.Join(r.workspacePath, credentialsFile)
+}
+
+// credentialHelper returns the credential helper reading the credentials
+// file, quoted for the shell git runs it with.
+func (r *SandboxRepo) credentialHelper() string {
+	return "store --file='" + strings.ReplaceAll(r.credentialsPath(), "'", `'\''`) + "'"
+}
+
+// authArgs returns the git options authenticating a command run outside a
+// configured repository, such as clone. Helpers configured for the user are
+// cleared first, so they aren't asked.
+func (r *SandboxRepo) authArgs() []string {
+	if r.githubToken == "" {
+		return nil
+	}
+	return []string{"-c", "credential.helper=", "-c", "credential.helper=" + r.credentialHelper()}
+}
+
+// configureAuth points the repository at dir (a clone or the clone cache,
+// whose worktrees share its configuration) at the remote without a token in
+// its URL, authenticating with the credentials file instead. Clones made
+// with the token in the URL are fixed up.
+func (r *SandboxRepo) configureAuth(dir string) error {
+	if _, err := gitOutput(dir, "remote", "set-url", "origin", r.remoteURL()); err != nil {
+		return err
+	}
+	if r.githubToken == "" {
+		return nil
+	}
+	// Exit status 5 means there was no helper to unset
+	if err := exec.Command("git", "-C", dir, "config", "--local", "--unset-all", "credential.helper").Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 5 {
+			return fmt.Errorf("failed to reset git credential helpers: %w", err)
+		}
+	}
+	if _, err := gitOutput(dir, "config", "--local", "--add", "credential.helper", ""); err != nil {
+		return err
+	}
+	if _, err := gitOutput(dir, "config", "--local", "--add", "credential.helper", r.credentialHelper()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// urlCredentialsRe matches the user information in URLs.
+var urlCredentialsRe = regexp.MustCompile(`([a-z][a-z0-9+.-]*://)[^/@\s]+@`)
+
+// scrubCredentials removes token, and any credentials in URLs, from git
+// output before it goes into an error.
+func scrubCredentials(output []byte, token string) string {
+	s := string(output)
+	if token != "" {
+		s = strings.ReplaceAll(s, token, "***")
+	}
+	return urlCredentialsRe.ReplaceAllString(s, "$1")
+}
@@ -55,7 +55,7 @@ func (r *LocalRepo) Sync() error {
 	cmd := exec.Command("git", "fetch", "--all")
 	cmd.Dir = r.path
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git fetch failed: %w\n%s", err, string(output))
+		return fmt.Errorf("git fetch failed: %w\n%s", err, scrubCredentials(output, ""))
 	}
 	return nil
 }
@@ -3,6 +3,8 @@ package repo
 
 import (
 	"fmt"
+	"os/exec"
+	"strings"
 
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
 )
@@ -24,14 +26,75 @@ type Manager interface {
 	GetMode() config.Mode
 }
 
+// cloneFilters are the partial clone filters of the clone filter settings.
+var cloneFilters = map[string]string{
+	"blobless": "blob:none",
+	"treeless": "tree:0",
+}
+
 // NewManager creates a repository manager based on configuration.
 func NewManager(cfg *config.Config) (Manager, error) {
 	switch cfg.Mode {
 	case config.ModeLocal:
 		return NewLocalRepo(cfg.RepoPath)
 	case config.ModeSandbox:
-		return NewSandboxRepo(cfg.GitHubRepo, cfg.GitHubToken, cfg.WorkspacePath)
+		return NewSandboxRepo(cfg.GitHubRepo, cfg.GitHubToken, cfg.WorkspacePath, CloneOptions{
+			Depth:    cfg.CloneDepth,
+			Filter:   cloneFilters[cfg.CloneFilter],
+			CacheDir: cfg.CloneCacheDir,
+		})
 	default:
 		return nil, fmt.Errorf("unknown mode: %s", cfg.Mode)
 	}
 }
+
+// SyncIfIdle syncs the repository unless doing so could disturb work in
+// progress. Sandbox syncs check out the default branch, so they are skipped
+// while the checkout has uncommitted changes or is on another branch.
+// It reports whether the sync ran.
+func SyncIfIdle(m Manager) (bool, error) {
+	if m.GetMode() == config.ModeSandbox {
+		status, err := gitOutput(m.GetRepoPath(), "status", "--porcelain")
+		if err != nil {
+			return false, err
+		}
+		if status != "" {
+			return false, nil
+		}
+
+		branch, err := gitOutput(m.GetRepoPath(), "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return false, err
+		}
+		if branch != defaultBranch(m.GetRepoPath()) {
+			return false, nil
+		}
+	}
+
+	if err := m.Sync(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// defaultBranch returns the remote's default branch, falling back to main.
+func defaultBranch(dir string) string {
+	if ref, err := gitOutput(dir, "symbolic-ref", "refs/remotes/origin/HEAD", "--short"); err == nil {
+		return strings.TrimPrefix(ref, "origin/")
+	}
+	if _, err := gitOutput(dir, "show-ref", "--verify", "--quiet", "refs/remotes/origin/master"); err == nil {
+		return "master"
+	}
+	return "main"
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
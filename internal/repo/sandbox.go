@@ -6,21 +6,38 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
 )
 
+// CloneOptions control how the repository is cloned, to cut the time taken
+// by large repositories.
+type CloneOptions struct {
+	// Depth truncates the history to that many commits on every branch (0
+	// clones all of it).
+	Depth int
+	// Filter is a partial clone filter: "blob:none" fetches file contents
+	// on demand, "tree:0" directories too ("" fetches everything).
+	Filter string
+	// CacheDir keeps a bare clone that is refreshed, rather than cloned
+	// again, on each restart, and lends its objects to the checkout ("" for
+	// none).
+	CacheDir string
+}
+
 // SandboxRepo provides access to a cloned repository in a sandboxed workspace.
 type SandboxRepo struct {
 	githubRepo    string
 	githubToken   string
 	workspacePath string
 	repoPath      string
+	clone         CloneOptions
 }
 
 // NewSandboxRepo creates a new sandbox repository manager.
-func NewSandboxRepo(githubRepo, githubToken, workspacePath string) (*SandboxRepo, error) {
+func NewSandboxRepo(githubRepo, githubToken, workspacePath string, clone CloneOptions) (*SandboxRepo, error) {
 	absWorkspace, err := filepath.Abs(workspacePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve workspace path: %w", err)
@@ -35,6 +52,7 @@ func NewSandboxRepo(githubRepo, githubToken, workspacePath string) (*SandboxRepo
 		githubToken:   githubToken,
 		workspacePath: absWorkspace,
 		repoPath:      repoPath,
+		clone:         clone,
 	}, nil
 }
 
@@ -49,31 +67,116 @@ func (r *SandboxRepo) EnsureReady() error {
 	if err := os.MkdirAll(r.workspacePath, 0755); err != nil {
 		return fmt.Errorf("failed to create workspace: %w", err)
 	}
+	// The token may have changed since the last start
+	if err := r.writeCredentials(); err != nil {
+		return err
+	}
 
 	// Check if repo already exists
 	gitDir := filepath.Join(r.repoPath, ".git")
 	if _, err := os.Stat(gitDir); err == nil {
 		// Repository exists, just fetch latest
+		if err := r.configureAuth(r.repoPath); err != nil {
+			return err
+		}
 		return r.Sync()
 	}
 
 	// Clone the repository
-	cloneURL := r.buildCloneURL()
-	cmd := exec.Command("git", "clone", cloneURL, r.repoPath)
+	args := append(r.authArgs(), "clone")
+	if r.clone.Depth > 0 {
+		// --depth alone would fetch only the default branch
+		args = append(args, "--depth", strconv.Itoa(r.clone.Depth), "--no-single-branch")
+	}
+	if r.clone.Filter != "" {
+		args = append(args, "--filter="+r.clone.Filter)
+	}
+	if r.clone.CacheDir != "" {
+		cache, err := r.refreshCache()
+		if err != nil {
+			return err
+		}
+		args = append(args, "--reference", cache)
+	}
+
+	// Clone beside the checkout and move it into place, so an interrupted
+	// clone isn't taken for a ready one on the next start
+	tmpPath := r.repoPath + ".cloning"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return fmt.Errorf("failed to remove interrupted clone: %w", err)
+	}
+	cmd := exec.Command("git", append(args, r.remoteURL(), tmpPath)...)
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git clone failed: %w\n%s", err, string(output))
+		os.RemoveAll(tmpPath)
+		return fmt.Errorf("git clone failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
+	}
+	if err := r.configureAuth(tmpPath); err != nil {
+		os.RemoveAll(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, r.repoPath); err != nil {
+		return fmt.Errorf("failed to move clone into place: %w", err)
 	}
 
 	return nil
 }
 
+// refreshCache fetches the latest changes into the clone cache, cloning it
+// first if it doesn't exist, and returns its path. The cache keeps the whole
+// history, since git can't borrow objects from a shallow repository, but
+// follows the partial clone filter.
+func (r *SandboxRepo) refreshCache() (string, error) {
+	cache := filepath.Join(r.clone.CacheDir, extractRepoName(r.githubRepo)+".git")
+	if _, err := os.Stat(filepath.Join(cache, "HEAD")); err == nil {
+		if err := r.configureAuth(cache); err != nil {
+			return "", err
+		}
+		fetchCmd := exec.Command("git", "fetch", "--prune", "origin")
+		fetchCmd.Dir = cache
+		if output, err := fetchCmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git fetch of clone cache failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
+		}
+		return cache, nil
+	}
+
+	if err := os.MkdirAll(r.clone.CacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create clone cache directory: %w", err)
+	}
+	tmpCache := cache + ".cloning"
+	if err := os.RemoveAll(tmpCache); err != nil {
+		return "", fmt.Errorf("failed to remove interrupted clone: %w", err)
+	}
+	args := append(r.authArgs(), "clone", "--bare")
+	if r.clone.Filter != "" {
+		args = append(args, "--filter="+r.clone.Filter)
+	}
+	cmd := exec.Command("git", append(args, r.remoteURL(), tmpCache)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(tmpCache)
+		return "", fmt.Errorf("git clone of clone cache failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
+	}
+	if err := r.configureAuth(tmpCache); err != nil {
+		os.RemoveAll(tmpCache)
+		return "", err
+	}
+	// A bare clone doesn't fetch branches unless told to
+	if _, err := gitOutput(tmpCache, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+		os.RemoveAll(tmpCache)
+		return "", err
+	}
+	if err := os.Rename(tmpCache, cache); err != nil {
+		return "", fmt.Errorf("failed to move clone cache into place: %w", err)
+	}
+	return cache, nil
+}
+
 // Sync fetches the latest changes and resets to origin/main.
 func (r *SandboxRepo) Sync() error {
 	// Fetch all remotes
 	fetchCmd := exec.Command("git", "fetch", "--all")
 	fetchCmd.Dir = r.repoPath
 	if output, err := fetchCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git fetch failed: %w\n%s", err, string(output))
+		return fmt.Errorf("git fetch failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
 	}
 
 	// Get the default branch
@@ -86,13 +189,13 @@ func (r *SandboxRepo) Sync() error {
 	resetCmd := exec.Command("git", "checkout", defaultBranch)
 	resetCmd.Dir = r.repoPath
 	if output, err := resetCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git checkout failed: %w\n%s", err, string(output))
+		return fmt.Errorf("git checkout failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
 	}
 
 	pullCmd := exec.Command("git", "pull", "origin", defaultBranch)
 	pullCmd.Dir = r.repoPath
 	if output, err := pullCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git pull failed: %w\n%s", err, string(output))
+		return fmt.Errorf("git pull failed: %w\n%s", err, scrubCredentials(output, r.githubToken))
 	}
 
 	return nil
@@ -103,16 +206,23 @@ func (r *SandboxRepo) GetMode() config.Mode {
 	return config.ModeSandbox
 }
 
-// buildCloneURL constructs the authenticated clone URL.
-func (r *SandboxRepo) buildCloneURL() string {
+// remoteURL returns the HTTPS URL of the repository, without credentials:
+// git authenticates with the credential helper instead.
+func (r *SandboxRepo) remoteURL() string {
 	// Remove protocol prefix if present
 	repo := r.githubRepo
 	repo = strings.TrimPrefix(repo, "https://")
 	repo = strings.TrimPrefix(repo, "http://")
-	repo = strings.TrimPrefix(repo, "git@")
+	if ssh, ok := strings.CutPrefix(repo, "git@"); ok {
+		// git@host:owner/repo
+		repo = strings.Replace(ssh, ":", "/", 1)
+	}
+	// Drop credentials written into the configured URL
+	if at := strings.Index(repo, "@"); at >= 0 && !strings.Contains(repo[:at], "/") {
+		repo = repo[at+1:]
+	}
 
-	// Build authenticated HTTPS URL
-	return fmt.Sprintf("https://%s@%s", r.githubToken, repo)
+	return "https://" + repo
 }
 
 // getDefaultBranch determines the default branch (main or master).
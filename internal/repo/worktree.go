@@ -0,0 +1,236 @@
+// Personal git worktrees of the repository.
+
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// worktreeBranchPrefix prefixes the branch each personal worktree starts on.
+const worktreeBranchPrefix = "workspace/"
+
+// Worktrees manages personal git worktrees of a repository, one per owner,
+// so people can experiment without touching the main checkout or each other's work.
+type Worktrees struct {
+	repoPath string
+	dir      string
+
+	mu sync.Mutex
+}
+
+// NewWorktrees creates a worktree manager that keeps worktrees of repoPath in dir.
+// dir must be outside the repository.
+func NewWorktrees(repoPath, dir string) (*Worktrees, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve worktree directory: %w", err)
+	}
+	absRepo, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+	if rel, err := filepath.Rel(absRepo, absDir); err == nil && !strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("worktree directory %s must be outside the repository", absDir)
+	}
+	if err := os.MkdirAll(absDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+	return &Worktrees{repoPath: absRepo, dir: absDir}, nil
+}
+
+// unsafeOwnerChars are replaced in owner names used for paths and branches.
+var unsafeOwnerChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// OwnerName returns the name owner's worktree and branch are known by, the
+// form Owners returns it in.
+func OwnerName(owner string) string {
+	return unsafeOwnerChars.ReplaceAllString(owner, "-")
+}
+
+// Branch returns the branch owner's worktree starts on.
+func (w *Worktrees) Branch(owner string) string {
+	return worktreeBranchPrefix + unsafeOwnerChars.ReplaceAllString(owner, "-")
+}
+
+// Ensure returns the path of owner's worktree, creating it on first use. The
+// worktree checks out owner's branch, which starts from the remote default branch.
+func (w *Worktrees) Ensure(owner string) (string, error) {
+	name := unsafeOwnerChars.ReplaceAllString(owner, "-")
+	if name == "" {
+		return "", fmt.Errorf("invalid worktree owner: %q", owner)
+	}
+	path := filepath.Join(w.dir, name)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return path, nil
+	}
+
+	// Forget worktrees whose directories were deleted
+	if _, err := gitOutput(w.repoPath, "worktree", "prune"); err != nil {
+		return "", err
+	}
+
+	// Pick up an existing branch so earlier commits aren't lost
+	branch := w.Branch(owner)
+	args := []string{"worktree", "add", path, branch}
+	if _, err := gitOutput(w.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		base := "origin/" + defaultBranch(w.repoPath)
+		if _, err := gitOutput(w.repoPath, "rev-parse", "--verify", "--quiet", base); err != nil {
+			base = "HEAD"
+		}
+		args = []string{"worktree", "add", "-b", branch, path, base}
+	}
+	if _, err := gitOutput(w.repoPath, args...); err != nil {
+		return "", fmt.Errorf("failed to create worktree for %s: %w", owner, err)
+	}
+	return path, nil
+}
+
+// Release removes owner's worktree to free disk space while keeping its branch,
+// so Ensure can restore it with every commit. A worktree with uncommitted
+// changes is left in place; Release reports whether it was removed.
+func (w *Worktrees) Release(owner string) (bool, error) {
+	path := filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-"))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := os.Stat(path); err != nil {
+		return true, nil
+	}
+	status, err := gitOutput(path, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to check worktree for %s: %w", owner, err)
+	}
+	if status != "" {
+		return false, nil
+	}
+	if _, err := gitOutput(w.repoPath, "worktree", "remove", path); err != nil {
+		return false, fmt.Errorf("failed to remove worktree for %s: %w", owner, err)
+	}
+	return true, nil
+}
+
+// Remove deletes owner's worktree and its local branch, discarding work that
+// wasn't pushed.
+func (w *Worktrees) Remove(owner string) error {
+	path := filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-"))
+	branch := w.Branch(owner)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		if _, err := gitOutput(w.repoPath, "worktree", "remove", "--force", path); err != nil {
+			return fmt.Errorf("failed to remove worktree for %s: %w", owner, err)
+		}
+	}
+	if _, err := gitOutput(w.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		if _, err := gitOutput(w.repoPath, "branch", "-D", branch); err != nil {
+			return fmt.Errorf("failed to delete branch %s: %w", branch, err)
+		}
+	}
+	return nil
+}
+
+// Owners returns the owners with a worktree or a branch, as the names used
+// for their paths and branches.
+func (w *Worktrees) Owners() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seen := make(map[string]bool)
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			seen[entry.Name()] = true
+		}
+	}
+	branches, err := gitOutput(w.repoPath, "for-each-ref", "--format=%(refname:strip=2)", "refs/heads/"+worktreeBranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, branch := range strings.Fields(branches) {
+		seen[strings.TrimPrefix(branch, worktreeBranchPrefix)] = true
+	}
+
+	owners := make([]string, 0, len(seen))
+	for owner := range seen {
+		owners = append(owners, owner)
+	}
+	return owners, nil
+}
+
+// Size returns the disk space used by owner's worktree, in bytes. Objects
+// are shared with the repository, so they aren't counted.
+func (w *Worktrees) Size(owner string) (int64, error) {
+	path := filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-"))
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			// Files may be deleted by a command while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure worktree for %s: %w", owner, err)
+	}
+	return size, nil
+}
+
+// Clean deletes the files git ignores in owner's worktree, such as build
+// output and downloaded dependencies, which can be made again.
+func (w *Worktrees) Clean(owner string) error {
+	path := filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-"))
+	if _, err := gitOutput(path, "clean", "-fdqX"); err != nil {
+		return fmt.Errorf("failed to clean worktree for %s: %w", owner, err)
+	}
+	return nil
+}
+
+// Touch records that owner's worktree was just used, for LastUsed.
+func (w *Worktrees) Touch(owner string) error {
+	now := time.Now()
+	return os.Chtimes(filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-")), now, now)
+}
+
+// LastUsed returns when owner's worktree was last touched, or when its branch
+// was last committed to if the worktree was released.
+func (w *Worktrees) LastUsed(owner string) (time.Time, error) {
+	info, err := os.Stat(filepath.Join(w.dir, unsafeOwnerChars.ReplaceAllString(owner, "-")))
+	if err == nil {
+		return info.ModTime(), nil
+	}
+	out, err := gitOutput(w.repoPath, "log", "-1", "--format=%ct", w.Branch(owner), "--")
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read last commit time of %s: %w", w.Branch(owner), err)
+	}
+	return time.Unix(secs, 0), nil
+}
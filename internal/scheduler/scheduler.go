@@ -0,0 +1,139 @@
+// Package scheduler runs periodic background jobs.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/capacity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/metrics"
+)
+
+// Job is a periodic background task.
+type Job struct {
+	// Name identifies the job in logs and metrics
+	Name string
+	// Interval is the time between runs (0 disables the job)
+	Interval time.Duration
+	// Run performs one execution of the job
+	Run func(ctx context.Context) error
+	// LeaderOnly jobs run only on the elected leader when replicas share work
+	LeaderOnly bool
+}
+
+// Scheduler runs jobs on their intervals with random jitter so that jobs
+// (and replicas) don't all fire at the same moment.
+type Scheduler struct {
+	mu       sync.Mutex
+	jobs     []Job
+	jitter   time.Duration
+	limiter  *capacity.Limiter
+	isLeader func() bool
+	metrics  *metrics.Registry
+	logger   *slog.Logger
+}
+
+// New creates a new scheduler. Each run is delayed by a random amount up to jitter.
+func New(jitter time.Duration, registry *metrics.Registry, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		jitter:  jitter,
+		metrics: registry,
+		logger:  logger,
+	}
+}
+
+// Add registers a job. Jobs with a non-positive interval are ignored.
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		s.logger.Info("scheduled job disabled", "job", job.Name)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Limit makes jobs wait for a low-priority slot in limiter before running, so
+// background work yields to user requests.
+func (s *Scheduler) Limit(limiter *capacity.Limiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limiter = limiter
+}
+
+// Elect restricts LeaderOnly jobs to runs where isLeader reports true.
+func (s *Scheduler) Elect(isLeader func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isLeader = isLeader
+}
+
+// Start runs all registered jobs in the background until the context is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		s.logger.Info("scheduled job registered", "job", job.Name, "interval", job.Interval)
+		go s.loop(ctx, job)
+	}
+}
+
+// loop runs a job on its interval until the context is cancelled.
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(job.Interval + s.nextJitter()):
+			s.RunNow(ctx, job)
+		}
+	}
+}
+
+// RunNow executes a job once and records its metrics.
+func (s *Scheduler) RunNow(ctx context.Context, job Job) {
+	s.mu.Lock()
+	limiter, isLeader := s.limiter, s.isLeader
+	s.mu.Unlock()
+
+	if job.LeaderOnly && isLeader != nil && !isLeader() {
+		s.metrics.Inc("scheduler."+job.Name+".skipped", 1)
+		s.logger.Debug("skipped scheduled job, not the leader", "job", job.Name)
+		return
+	}
+
+	if limiter != nil {
+		if err := limiter.Acquire(ctx, capacity.Low); err != nil {
+			return
+		}
+		defer limiter.Release()
+	}
+
+	start := time.Now()
+	err := job.Run(ctx)
+	duration := time.Since(start)
+
+	s.metrics.Inc("scheduler."+job.Name+".runs", 1)
+	s.metrics.Observe("scheduler."+job.Name+".duration", duration)
+
+	if err != nil {
+		s.metrics.Inc("scheduler."+job.Name+".failures", 1)
+		s.logger.Warn("scheduled job failed", "job", job.Name, "duration", duration, "error", err)
+		return
+	}
+	s.logger.Debug("scheduled job completed", "job", job.Name, "duration", duration)
+}
+
+// nextJitter returns a random delay between zero and the configured jitter.
+func (s *Scheduler) nextJitter() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.jitter)))
+}
@@ -0,0 +1,91 @@
+// The AWS Secrets Manager provider.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/awsapi"
+)
+
+// awsTimeout bounds a call to Secrets Manager.
+const awsTimeout = 15 * time.Second
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager, with references
+// "name", or "name#key" for a field of a JSON secret. The name may be an
+// ARN, whose region is then used; other names are looked up in the default
+// region. Credentials come from the default AWS credential chain.
+type AWSSecretsManager struct {
+	mu      sync.Mutex
+	clients map[string]*awsapi.Client
+}
+
+// NewAWSSecretsManager creates an AWS Secrets Manager provider. Credentials
+// are loaded on first use, so deployments not using it need none.
+func NewAWSSecretsManager() *AWSSecretsManager {
+	return &AWSSecretsManager{clients: make(map[string]*awsapi.Client)}
+}
+
+// getSecretValueResponse is the body of a GetSecretValue response.
+type getSecretValueResponse struct {
+	SecretString string `json:"SecretString"`
+}
+
+// Resolve reads the secret called name, or the key of it.
+func (m *AWSSecretsManager) Resolve(ctx context.Context, ref string) (string, error) {
+	name, key := splitKey(ref)
+	client, err := m.client(ctx, arnRegion(name))
+	if err != nil {
+		return "", err
+	}
+
+	var resp getSecretValueResponse
+	if err := client.Call(ctx, "GetSecretValue", map[string]string{"SecretId": name}, &resp); err != nil {
+		return "", err
+	}
+	if key == "" {
+		return resp.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(resp.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object, so it has no key %s", key)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("the secret has no string key %s", key)
+	}
+	return value, nil
+}
+
+// client returns the client for region, "" meaning the default one.
+func (m *AWSSecretsManager) client(ctx context.Context, region string) (*awsapi.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if client, ok := m.clients[region]; ok {
+		return client, nil
+	}
+	client, err := awsapi.New(ctx, "secretsmanager", "secretsmanager", region, &http.Client{Timeout: awsTimeout})
+	if err != nil {
+		return nil, err
+	}
+	m.clients[region] = client
+	return client, nil
+}
+
+// arnRegion returns the region of a secret's ARN, or "" for a plain name.
+func arnRegion(name string) string {
+	// arn:partition:secretsmanager:region:account:secret:name
+	parts := strings.SplitN(name, ":", 7)
+	if len(parts) == 7 && parts[0] == "arn" {
+		return parts[3]
+	}
+	return ""
+}
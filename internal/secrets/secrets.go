@@ -0,0 +1,84 @@
+// Package secrets provides the resolution of settings that reference secrets
+// kept outside the environment, such as tokens in Vault, AWS Secrets Manager
+// or files mounted by Docker and Kubernetes.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider resolves references to the secrets it keeps.
+type Provider interface {
+	// Resolve returns the secret ref names: the part of a setting's value
+	// after its scheme, e.g. "/run/secrets/slack_bot_token" in
+	// "file:/run/secrets/slack_bot_token".
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver resolves setting values of the form "scheme:ref" with the
+// provider registered for scheme. Any other value, including one whose
+// scheme has no provider (e.g. "postgres://..."), is a literal.
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver without providers.
+func NewResolver() *Resolver {
+	return &Resolver{providers: make(map[string]Provider)}
+}
+
+// Register makes values with scheme resolve with p.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// Resolve returns the secret value references, or value itself when it
+// references none.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	r.mu.RLock()
+	p, ok := r.providers[scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := p.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret %s: %w", scheme, ref, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%s secret %s is empty", scheme, ref)
+	}
+	return secret, nil
+}
+
+// File reads secrets from files, such as Docker secrets under /run/secrets.
+// A trailing newline is dropped.
+type File struct{}
+
+// Resolve returns the contents of the file at ref.
+func (File) Resolve(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// splitKey splits "name#key" into the secret's name and the key of the
+// field wanted from it, if any.
+func splitKey(ref string) (string, string) {
+	name, key, _ := strings.Cut(ref, "#")
+	return name, key
+}
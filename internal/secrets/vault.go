@@ -0,0 +1,97 @@
+// The HashiCorp Vault provider.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultTimeout bounds a read from Vault.
+const vaultTimeout = 15 * time.Second
+
+// Vault reads secrets from a HashiCorp Vault KV engine (version 1 or 2),
+// with references "path#key", e.g. "secret/data/stormstack#slack_bot_token".
+// The path is the API path, so KV version 2 paths include "data/".
+type Vault struct {
+	addr      string
+	token     string
+	namespace string
+	http      *http.Client
+}
+
+// NewVault creates a Vault provider for the server at addr, authenticating
+// with token, in namespace when set (Vault Enterprise).
+func NewVault(addr, token, namespace string) *Vault {
+	return &Vault{
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		http:      &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+// vaultResponse is the body of a read of a KV secret.
+type vaultResponse struct {
+	Data map[string]any `json:"data"`
+}
+
+// Resolve reads the key of the secret at path.
+func (v *Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	if v.addr == "" || v.token == "" {
+		return "", fmt.Errorf("Vault is not configured (set VAULT_ADDR and VAULT_TOKEN)")
+	}
+	path, key := splitKey(ref)
+	if key == "" {
+		return "", fmt.Errorf("name the key to read, as in path#key")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from Vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Vault's errors never contain the secret, but may be long
+		return "", fmt.Errorf("Vault returned %s: %s", resp.Status, strings.TrimSpace(string(body[:min(len(body), 300)])))
+	}
+
+	var secret vaultResponse
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse Vault response: %w", err)
+	}
+	// KV version 2 nests the secret's fields under data.data
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("the secret has no key %s", key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s is not a string", key)
+	}
+	return s, nil
+}
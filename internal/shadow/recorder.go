@@ -0,0 +1,86 @@
+// Package shadow records what the bot would have done while running in shadow mode.
+package shadow
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry kinds.
+const (
+	KindTool    = "tool"
+	KindMessage = "message"
+)
+
+// Entry is a single action the bot would have taken.
+type Entry struct {
+	Time           time.Time       `json:"time"`
+	Kind           string          `json:"kind"`
+	ConversationID string          `json:"conversation_id,omitempty"`
+	ChannelID      string          `json:"channel_id,omitempty"`
+	ThreadTS       string          `json:"thread_ts,omitempty"`
+	Tool           string          `json:"tool,omitempty"`
+	Input          json.RawMessage `json:"input,omitempty"`
+	Text           string          `json:"text,omitempty"`
+}
+
+// Recorder appends shadow entries to a JSON Lines file and the log, so the
+// bot's decisions can be compared against human outcomes later.
+type Recorder struct {
+	mu     sync.Mutex
+	path   string
+	logger *slog.Logger
+}
+
+// NewRecorder creates a recorder writing to path. An empty path only logs.
+func NewRecorder(path string, logger *slog.Logger) (*Recorder, error) {
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create shadow log directory: %w", err)
+		}
+	}
+	return &Recorder{path: path, logger: logger}, nil
+}
+
+// Record stores an entry.
+func (r *Recorder) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	r.logger.Info("shadow mode: suppressed action",
+		"kind", entry.Kind,
+		"tool", entry.Tool,
+		"conversation", entry.ConversationID,
+		"channel", entry.ChannelID,
+	)
+
+	if r.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		r.logger.Warn("failed to encode shadow entry", "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		r.logger.Warn("failed to open shadow log", "error", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		r.logger.Warn("failed to write shadow entry", "error", err)
+	}
+}
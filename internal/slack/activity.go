@@ -0,0 +1,158 @@
+// Activity recording and the daily admin digest.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/activity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/codebase"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+// activityRetention is how long daily activity files are kept.
+const activityRetention = 30 * 24 * time.Hour
+
+// meteredClient records the tokens of every Claude call in the activity log,
+// attributed to the conversation's channel when there is one.
+type meteredClient struct {
+	claude.Client
+	log *activity.Log
+}
+
+// CreateMessage sends a message to Claude and records its usage.
+func (c *meteredClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	response, err := c.Client.CreateMessage(ctx, params)
+	c.record(ctx, response)
+	return response, err
+}
+
+// CreateMessageWithTools sends a message with tool definitions and records its usage.
+func (c *meteredClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	response, err := c.Client.CreateMessageWithTools(ctx, systemPrompt, messages, tools)
+	c.record(ctx, response)
+	return response, err
+}
+
+// record logs a response's token usage.
+func (c *meteredClient) record(ctx context.Context, response *anthropic.Message) {
+	if response == nil {
+		return
+	}
+	usage := response.Usage
+	event := activity.Event{
+		Kind:         activity.KindTokens,
+		InputTokens:  usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens,
+		OutputTokens: usage.OutputTokens,
+	}
+	if info, ok := ConversationFromContext(ctx); ok {
+		event.ChannelID = info.ChannelID
+		event.UserID = info.UserID
+		event.ConversationID = info.ConversationID
+	}
+	c.log.Record(event)
+}
+
+// recordActivity logs a tool call, noting the command run, the PR opened or
+// the policy that blocked it.
+func (e *ToolExecutor) recordActivity(ctx context.Context, name string, input json.RawMessage, result string, err error) {
+	if e.activity == nil {
+		return
+	}
+
+	event := activity.Event{
+		Kind:   activity.KindTool,
+		Tool:   name,
+		Failed: err != nil,
+	}
+	if info, ok := ConversationFromContext(ctx); ok {
+		event.ChannelID = info.ChannelID
+		event.UserID = info.UserID
+		event.ConversationID = info.ConversationID
+	}
+
+	var blocked *executor.BlockedCommandError
+	switch {
+	case errors.Is(err, codebase.ErrRestricted) || errors.Is(err, codebase.ErrProtected) || errors.Is(err, codebase.ErrGenerated) || errors.As(err, &blocked):
+		event.Blocked = true
+		event.Detail = err.Error()
+	case name == "create_pr" && err == nil:
+		event.Detail = prURLRe.FindString(result)
+	case name == "run_command":
+		var params claude.RunCommandParams
+		if claude.Bind(input, &params) == nil {
+			event.Detail = params.Command
+		}
+	}
+
+	// Commands and errors may quote secrets, and the log reaches the digest
+	event.Detail = e.redactor.Redact(event.Detail)
+	e.activity.Record(event)
+}
+
+// AdminDigest posts a daily summary of the previous day's activity to the
+// admin channel: conversations, tool calls, commands, PRs, Claude tokens and
+// cost per channel, and the tool calls policies blocked.
+type AdminDigest struct {
+	log     *activity.Log
+	bot     *Bot
+	channel string
+	at      time.Duration
+	prices  activity.Prices
+	logger  *slog.Logger
+}
+
+// NewAdminDigest creates a digest of log posted to cfg.AdminChannel at
+// cfg.AdminDigestTime.
+func NewAdminDigest(cfg *config.Config, log *activity.Log, bot *Bot, logger *slog.Logger) *AdminDigest {
+	at, _ := time.Parse("15:04", cfg.AdminDigestTime)
+	return &AdminDigest{
+		log:     log,
+		bot:     bot,
+		channel: cfg.AdminChannel,
+		at:      time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute,
+		prices:  activity.Prices{Input: cfg.ClaudeInputPrice, Output: cfg.ClaudeOutputPrice},
+		logger:  logger,
+	}
+}
+
+// Run posts yesterday's digest once it is past the digest time. It is meant
+// to run often as a scheduled job: each day is only reported once.
+func (d *AdminDigest) Run(ctx context.Context) error {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(midnight.Add(d.at)) {
+		return nil
+	}
+	yesterday := midnight.AddDate(0, 0, -1)
+
+	events, err := d.log.Day(yesterday)
+	if err != nil {
+		return err
+	}
+	first, err := d.log.MarkReported(yesterday)
+	if err != nil || !first {
+		return err
+	}
+
+	report := activity.Summarize(yesterday, events)
+	if err := d.bot.PostProactive(d.channel, &OutgoingMessage{Text: report.Format(d.prices)}); err != nil {
+		return fmt.Errorf("failed to post admin digest: %w", err)
+	}
+	d.logger.Info("posted admin digest", "day", yesterday.Format("2006-01-02"), "events", len(events))
+
+	return d.log.Prune(now.Add(-activityRetention))
+}
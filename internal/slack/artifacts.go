@@ -0,0 +1,195 @@
+// The reports builds and tests generate, as artifacts.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+const (
+	// maxArtifactRead bounds the report text get_artifact returns to Claude
+	maxArtifactRead = 50 * 1024
+	// maxAttachedReports bounds the failing test reports attached to a reply
+	maxAttachedReports = 3
+	// maxAttachmentSize bounds the size of a report uploaded to Slack
+	maxAttachmentSize = 1024 * 1024
+	// maxListedArtifacts bounds the artifacts listed in a tool result
+	maxListedArtifacts = 30
+)
+
+// attachments holds the files each conversation's next reply uploads. It is
+// shared by all tool executors.
+type attachments struct {
+	mu      sync.Mutex
+	pending map[string][]File
+}
+
+// newAttachments creates an empty attachment queue.
+func newAttachments() *attachments {
+	return &attachments{pending: make(map[string][]File)}
+}
+
+// add queues a file for a conversation's next reply, replacing a queued file
+// of the same name.
+func (a *attachments) add(conversationID string, file File) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files := a.pending[conversationID]
+	for i := range files {
+		if files[i].Name == file.Name {
+			files[i] = file
+			return
+		}
+	}
+	a.pending[conversationID] = append(files, file)
+}
+
+// take removes and returns the files queued for a conversation.
+func (a *attachments) take(conversationID string) []File {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files := a.pending[conversationID]
+	delete(a.pending, conversationID)
+	return files
+}
+
+// collectArtifacts stores the reports a build or test run that started at
+// since generated, queues the failing test reports for upload to the thread,
+// and returns a note about them for the tool result ("" when there are none).
+func (e *ToolExecutor) collectArtifacts(ctx context.Context, since time.Time) string {
+	if e.artifacts == nil {
+		return ""
+	}
+	info, _ := ConversationFromContext(ctx)
+	run, collected, err := e.artifacts.Collect(e.writer.GetRepoPath(), info.ConversationID, since)
+	if err != nil {
+		e.logger.Warn("failed to collect artifacts", "error", err)
+		return ""
+	}
+	if len(collected) == 0 {
+		return ""
+	}
+
+	var attached []string
+	for _, artifact := range collected {
+		if !artifact.Failed || info.ConversationID == "" || len(attached) == maxAttachedReports {
+			continue
+		}
+		if err := e.attachArtifact(info.ConversationID, artifact); err != nil {
+			e.logger.Warn("failed to attach artifact", "artifact", artifact.Name, "error", err)
+			continue
+		}
+		attached = append(attached, artifact.Name)
+	}
+
+	note := fmt.Sprintf("\nCollected %d report artifacts (run %s); use get_artifact to list or read them.\n", len(collected), run)
+	if len(attached) > 0 {
+		note += fmt.Sprintf("Failing test reports attached to the thread: %s\n", strings.Join(attached, ", "))
+	}
+	return note
+}
+
+// attachArtifact queues an artifact for upload with the conversation's next
+// reply.
+func (e *ToolExecutor) attachArtifact(conversationID string, artifact executor.Artifact) error {
+	if artifact.Size > maxAttachmentSize {
+		return fmt.Errorf("%s is %d KB, over the %d KB upload limit", artifact.Name, artifact.Size/1024, maxAttachmentSize/1024)
+	}
+	data, err := os.ReadFile(artifact.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read artifact: %w", err)
+	}
+	e.attachments.add(conversationID, File{
+		Name:    path.Base(artifact.Name),
+		Title:   artifact.Name,
+		Content: string(data),
+	})
+	return nil
+}
+
+func (e *ToolExecutor) getArtifact(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.GetArtifactParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+	if e.artifacts == nil {
+		return "Artifact capture is disabled (STORMSTACK_ARTIFACTS_DIR is empty).", nil
+	}
+
+	info, _ := ConversationFromContext(ctx)
+	runs, err := e.artifacts.Runs(info.ConversationID)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "No artifacts have been collected in this conversation yet. Run run_build or run_tests first.", nil
+	}
+	run := params.Run
+	if run == "" {
+		run = runs[0]
+	}
+
+	if params.Name == "" {
+		return e.listArtifacts(info.ConversationID, run, runs)
+	}
+
+	artifact, err := e.artifacts.Get(info.ConversationID, run, params.Name)
+	if err != nil {
+		return "", err
+	}
+	if params.Attach {
+		if info.ConversationID == "" {
+			return "", fmt.Errorf("artifacts can only be attached in a Slack conversation")
+		}
+		if err := e.attachArtifact(info.ConversationID, artifact); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s will be attached to your reply.", artifact.Name), nil
+	}
+
+	data, err := os.ReadFile(artifact.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifact: %w", err)
+	}
+	content := string(data)
+	if len(content) > maxArtifactRead {
+		content = content[:maxArtifactRead] + fmt.Sprintf("\n\n[... truncated, %d of %d bytes shown; set attach to upload the whole report ...]", maxArtifactRead, len(data))
+	}
+	return fmt.Sprintf("Artifact %s (run %s, %d bytes):\n\n%s", artifact.Name, run, artifact.Size, content), nil
+}
+
+// listArtifacts describes the artifacts of a run and the other runs kept.
+func (e *ToolExecutor) listArtifacts(conversationID, run string, runs []string) (string, error) {
+	artifacts, err := e.artifacts.List(conversationID, run)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Artifacts of run %s (%d):\n", run, len(artifacts)))
+	for i, artifact := range artifacts {
+		if i == maxListedArtifacts {
+			sb.WriteString(fmt.Sprintf("... and %d more\n", len(artifacts)-maxListedArtifacts))
+			break
+		}
+		status := ""
+		if artifact.Failed {
+			status = " [failures]"
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%d bytes)%s\n", artifact.Name, artifact.Size, status))
+	}
+	if len(runs) > 1 {
+		sb.WriteString(fmt.Sprintf("\nEarlier runs: %s\n", strings.Join(runs[1:], ", ")))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,133 @@
+// Closing of idle conversation threads.
+
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// CloseIdleConversations closes threads idle for at least idleFor: it posts a
+// closing summary of what was changed, the links and any open questions,
+// archives the conversation and frees the personal worktrees of DMs that are
+// no longer active.
+func (h *Handler) CloseIdleConversations(ctx context.Context, idleFor time.Duration) (int, error) {
+	notify := h.announce
+	if notify == nil {
+		notify = h.toolExecutor.notify
+	}
+	if notify == nil {
+		return 0, errors.New("no way to post closing summaries")
+	}
+
+	idle, err := h.conversation.IdleConversations(ctx, idleFor)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	owners := make(map[string]bool)
+	for _, conv := range idle {
+		if ctx.Err() != nil {
+			return closed, ctx.Err()
+		}
+		// Only threads are closed; slash command conversations have no thread to post in
+		if strings.Contains(conv.ID, "-") {
+			continue
+		}
+		// Threads handed off to a teammate are theirs to close
+		if conv.HandedOffTo != "" {
+			continue
+		}
+
+		summary, err := h.closingSummary(ctx, conv)
+		if err != nil {
+			h.logger.Warn("failed to summarize idle conversation", "conversation", conv.ID, "error", err)
+			continue
+		}
+		archived, err := h.conversation.Archive(ctx, conv)
+		if err != nil || !archived {
+			// The thread came back to life while it was being summarized
+			continue
+		}
+		closed++
+		h.forgetIssue(conv.ID)
+
+		if summary != "" {
+			if err := notify(conv.ChannelID, conv.ID, summary); err != nil {
+				h.logger.Warn("failed to post closing summary", "conversation", conv.ID, "error", err)
+			}
+		}
+		if owner := dmWorkspaceOwner(h.workspaces, conv); owner != "" {
+			owners[owner] = true
+		}
+	}
+
+	h.releaseWorkspaces(ctx, owners)
+	return closed, nil
+}
+
+// closingSummary returns the message posted when conv is closed, or "" for
+// threads too short to need one.
+func (h *Handler) closingSummary(ctx context.Context, conv *storage.Conversation) (string, error) {
+	if len(conv.Messages) < 2 {
+		return "", nil
+	}
+
+	var extra []string
+	for _, pr := range h.toolExecutor.tracker.List() {
+		if pr.ThreadTS == conv.ID {
+			extra = append(extra, fmt.Sprintf("Pull request #%d opened from branch %s: %s (not merged yet)", pr.Number, pr.Branch, pr.URL))
+		}
+	}
+
+	summary, err := h.conversation.Summarize(ctx, conv, extra)
+	if err != nil {
+		return "", err
+	}
+	return ":file_cabinet: This thread has gone quiet, so I'm closing it.\n\n" + summary + "\n\n_Reply here to pick it back up._", nil
+}
+
+// dmWorkspaceOwner returns the user whose personal workspace a DM
+// conversation used, or "" when it didn't use one.
+func dmWorkspaceOwner(w *workspaces, conv *storage.Conversation) string {
+	if w == nil || !strings.HasPrefix(conv.ChannelID, "D") {
+		return ""
+	}
+	if participants := conv.Participants(); len(participants) > 0 {
+		return participants[0].UserID
+	}
+	return ""
+}
+
+// releaseWorkspaces frees the worktrees of owners with no open DM
+// conversations left. Branches are kept, so nothing committed is lost.
+func (h *Handler) releaseWorkspaces(ctx context.Context, owners map[string]bool) {
+	if len(owners) == 0 {
+		return
+	}
+
+	open, err := h.conversation.IdleConversations(ctx, 0)
+	if err != nil {
+		h.logger.Warn("failed to list open conversations", "error", err)
+		return
+	}
+	for _, conv := range open {
+		delete(owners, dmWorkspaceOwner(h.workspaces, conv))
+	}
+
+	for owner := range owners {
+		released, err := h.workspaces.release(owner)
+		switch {
+		case err != nil:
+			h.logger.Warn("failed to release workspace", "user", owner, "error", err)
+		case !released:
+			h.logger.Info("kept workspace with uncommitted changes", "user", owner)
+		}
+	}
+}
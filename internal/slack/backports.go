@@ -0,0 +1,90 @@
+// Label-triggered backports of merged pull requests.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/webhook"
+)
+
+// Backporter backports merged pull requests labeled for a release, e.g.
+// "backport-1.8": it announces the backport in a channel and has the bot
+// cherry-pick the PR onto the release branch, resolve trivial conflicts, run
+// the tests and open the backport PR in a thread under it.
+type Backporter struct {
+	channel      string
+	labelPrefix  string
+	branchPrefix string
+	bot          *Bot
+	leases       storage.LeaseStore
+	holder       string
+	logger       *slog.Logger
+}
+
+// NewBackporter creates a backporter reporting in cfg.BackportChannel. Like
+// failed workflows, backports are claimed through leases so that only one
+// replica handles each.
+func NewBackporter(cfg *config.Config, bot *Bot, leases storage.LeaseStore, logger *slog.Logger) *Backporter {
+	return &Backporter{
+		channel:      cfg.BackportChannel,
+		labelPrefix:  cfg.BackportLabelPrefix,
+		branchPrefix: cfg.BackportBranchPrefix,
+		bot:          bot,
+		leases:       leases,
+		holder:       cfg.InstanceID,
+		logger:       logger,
+	}
+}
+
+// HandlePullRequest handles a pull_request webhook delivery. A PR is
+// backported when it is merged with backport labels, or labeled after it
+// was merged.
+func (b *Backporter) HandlePullRequest(ctx context.Context, deliveryID string, payload []byte) error {
+	pr, err := webhook.ParsePullRequest(payload)
+	if err != nil {
+		return err
+	}
+	if !pr.Merged {
+		return nil
+	}
+
+	var labels []string
+	switch pr.Action {
+	case "closed":
+		labels = pr.Labels
+	case "labeled":
+		labels = []string{pr.Label}
+	}
+
+	for _, label := range labels {
+		version, ok := strings.CutPrefix(label, b.labelPrefix)
+		if !ok || version == "" {
+			continue
+		}
+		target := b.branchPrefix + version
+
+		claimed, err := claimOnce(ctx, b.leases, b.holder, deliveryID, fmt.Sprintf("backport-%d-%s", pr.Number, strings.ReplaceAll(target, "/", "-")))
+		if err != nil {
+			return fmt.Errorf("failed to claim backport: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		b.logger.Info("backporting pull request", "pr", pr.Number, "target", target)
+		announcement := fmt.Sprintf(":leftwards_arrow_with_hook: Backporting <%s|#%d %s> to `%s` (labeled `%s`). I'll post the backport PR in this thread.",
+			pr.URL, pr.Number, pr.Title, target, label)
+		request := fmt.Sprintf("Backport PR #%d (%s) to the %s branch with backport_pr. Resolve only trivial conflicts; if a conflict needs judgement, abort and explain what a human needs to decide. Run the tests before pushing, then open the backport PR.",
+			pr.Number, pr.URL, target)
+		if err := b.bot.StartThread(ctx, b.channel, announcement, "GitHub", request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,13 +1,22 @@
-// Package slack provides Slack bot integration using Socket Mode.
+// Package slack provides Slack bot integration using Socket Mode, or the
+// Events API over HTTP.
 package slack
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/capacity"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/redact"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/shadow"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
@@ -22,12 +31,23 @@ type IncomingMessage struct {
 	Text string
 	// UserID is the Slack user ID of the sender
 	UserID string
+	// UserName is the sender's display name, looked up when empty
+	UserName string
 	// ChannelID is the channel where the message was sent
 	ChannelID string
 	// ThreadTS is the thread timestamp (for threading replies)
 	ThreadTS string
+	// MessageTS is the timestamp of the message itself, when known
+	MessageTS string
+	// ThreadContext is the discussion in the thread before the message that
+	// the bot hasn't seen, read when it is mentioned partway through a thread
+	ThreadContext string
+	// TeamID is the Slack workspace the message was sent in, when known
+	TeamID string
 	// IsDM indicates if this is a direct message
 	IsDM bool
+	// Command indicates a /stormstack-dev slash command
+	Command bool
 }
 
 // OutgoingMessage represents a message to send.
@@ -38,28 +58,127 @@ type OutgoingMessage struct {
 	ThreadTS string
 	// Blocks are optional Slack blocks for rich formatting
 	Blocks []slack.Block
+	// Files are uploaded to the message's thread after it is posted
+	Files []File
+	// Visibility is who sees the message; replies are public by default
+	Visibility Visibility
+}
+
+// Visibility is who sees a reply.
+type Visibility int
+
+const (
+	// VisibilityPublic replies are posted in the channel or thread
+	VisibilityPublic Visibility = iota
+	// VisibilityEphemeral replies are shown only to the requester, in the
+	// channel, or sent by DM when that isn't possible
+	VisibilityEphemeral
+	// VisibilityDM replies are sent to the requester in a direct message
+	VisibilityDM
+)
+
+// File is a text file attached to an outgoing message.
+type File struct {
+	Name    string
+	Title   string
+	Content string
 }
 
 // Bot manages the Slack connection and event handling.
 type Bot struct {
-	client       *slack.Client
-	socketClient *socketmode.Client
+	socketClient *socketmode.Client // nil when Slack posts events over HTTP
+	events       chan socketmode.Event
 	handler      MessageHandler
 	botUserID    string
+	shadow       *shadow.Recorder
+	redactor     *redact.Redactor
 	logger       *slog.Logger
+
+	// Connection resilience
+	dedup            *eventDeduper
+	buffer           *responseBuffer
+	reconnectMaxWait time.Duration
+	connected        atomic.Bool
+
+	// Supervision of the connection and event loop
+	stallTimeout         time.Duration
+	disconnectAlertAfter time.Duration
+	adminChannel         string
+	loopBeat             atomic.Int64
+	loopGen              atomic.Int64
+	disconnectedAt       atomic.Int64
+	alerted              atomic.Bool
+
+	// Concurrency limits and priority lanes
+	capacity      *capacity.Limiter
+	prioritizer   prioritizer
+	conversations *conversationLocks
+
+	// Requests not yet replied to, which edits and deletions reach
+	pending *pendingRequests
+
+	// Display names of message senders
+	users *userDirectory
+
+	// Earlier thread messages read when mentioned partway through a thread
+	threadContextLimit int
+
+	// Proactive posts held until their channel's working hours
+	hours    *WorkingHours
+	deferred *responseBuffer
+
+	// What the App Home tab shows
+	home Home
+
+	// The repository the create task form offers
+	repository string
+
+	// Events API over HTTP: where Slack posts requests, the secret they are
+	// signed with, and the acknowledgements they wait for by envelope ID
+	eventsAddr    string
+	signingSecret string
+	acks          sync.Map
+
+	// Workspaces the bot is installed in through OAuth, and the install flow
+	teams             *teams
+	installations     storage.InstallationStore
+	oauthClientID     string
+	oauthClientSecret string
+	oauthRedirectURL  string
+	oauthStates       *oauthStates
 }
 
 // NewBot creates a new Slack bot instance.
-func NewBot(cfg *config.Config, handler MessageHandler, logger *slog.Logger) (*Bot, error) {
+// When recorder is non-nil the bot runs in shadow mode and records its
+// messages instead of posting them.
+func NewBot(cfg *config.Config, handler MessageHandler, recorder *shadow.Recorder, logger *slog.Logger) (*Bot, error) {
 	client := slack.New(
 		cfg.SlackBotToken,
 		slack.OptionAppLevelToken(cfg.SlackAppToken),
 	)
 
-	socketClient := socketmode.New(
-		client,
-		socketmode.OptionDebug(cfg.LogLevel == "debug"),
-	)
+	// Events arrive over Socket Mode, or posted by Slack over HTTP
+	var socketClient *socketmode.Client
+	var events chan socketmode.Event
+	if cfg.SlackEventsAddr == "" {
+		socketClient = socketmode.New(
+			client,
+			socketmode.OptionDebug(cfg.LogLevel == "debug"),
+		)
+		events = socketClient.Events
+	} else {
+		events = make(chan socketmode.Event, httpEventQueue)
+	}
+
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := NewWorkingHours(cfg.WorkingHours)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get bot user ID for mention detection
 	authTest, err := client.AuthTest()
@@ -67,30 +186,106 @@ func NewBot(cfg *config.Config, handler MessageHandler, logger *slog.Logger) (*B
 		return nil, fmt.Errorf("failed to authenticate with Slack: %w", err)
 	}
 
-	return &Bot{
-		client:       client,
+	bot := &Bot{
 		socketClient: socketClient,
+		events:       events,
 		handler:      handler,
 		botUserID:    authTest.UserID,
+		shadow:       recorder,
+		redactor:     redactor,
 		logger:       logger,
-	}, nil
+
+		dedup:            newEventDeduper(eventDedupTTL),
+		buffer:           newResponseBuffer(cfg.SlackResponseBufferSize),
+		reconnectMaxWait: cfg.SlackReconnectMaxWait,
+		capacity:         capacity.New(cfg.MaxConcurrentConversations),
+		prioritizer:      newPrioritizer(cfg),
+		conversations:    newConversationLocks(),
+		hours:            hours,
+		deferred:         newResponseBuffer(maxDeferredPosts),
+
+		pending:            newPendingRequests(),
+		threadContextLimit: cfg.ThreadContextMessages,
+		repository:         repositoryName(cfg),
+		eventsAddr:         cfg.SlackEventsAddr,
+		signingSecret:      cfg.SlackSigningSecret,
+
+		oauthClientID:     cfg.SlackClientID,
+		oauthClientSecret: cfg.SlackClientSecret,
+		oauthRedirectURL:  cfg.SlackOAuthRedirectURL,
+		oauthStates:       &oauthStates{states: make(map[string]time.Time)},
+
+		stallTimeout:         cfg.SlackStallTimeout,
+		disconnectAlertAfter: cfg.SlackDisconnectAlertAfter,
+		adminChannel:         cfg.AdminChannel,
+	}
+
+	// Workspaces are identified as "T123" or "T123=repository"
+	var allowedTeams []string
+	for _, entry := range cfg.SlackTeams {
+		team, _, _ := strings.Cut(entry, "=")
+		allowedTeams = append(allowedTeams, team)
+	}
+	bot.teams = newTeams(client, authTest.UserID, allowedTeams)
+	bot.users = newUserDirectory(bot.clientFor, logger)
+
+	// Not connected until Socket Mode says so
+	bot.markDisconnected()
+	return bot, nil
 }
 
-// Run starts the bot and blocks until the context is cancelled.
+// Run starts the bot and blocks until the context is cancelled. Dropped
+// connections are re-established with exponential backoff, and a supervisor
+// replaces a stalled event loop and reports prolonged disconnection.
 func (b *Bot) Run(ctx context.Context) error {
-	go b.handleEvents(ctx)
+	b.startEventLoop(ctx)
+	go b.supervise(ctx)
 
+	if b.socketClient == nil {
+		return b.serveHTTP(ctx)
+	}
 	b.logger.Info("starting Slack bot", "bot_user_id", b.botUserID)
-	return b.socketClient.RunContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		err := b.socketClient.RunContext(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		b.markDisconnected()
+
+		// Reset the backoff once a connection was successfully established
+		if b.connected.Swap(false) {
+			attempt = 0
+		}
+
+		delay := reconnectDelay(attempt, b.reconnectMaxWait)
+		b.logger.Warn("socket mode connection lost, reconnecting",
+			"error", err,
+			"attempt", attempt+1,
+			"delay", delay,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-// handleEvents processes incoming Socket Mode events.
-func (b *Bot) handleEvents(ctx context.Context) {
-	for {
+// handleEvents processes incoming Socket Mode events until the context is
+// cancelled or the supervisor replaces this loop (generation gen).
+func (b *Bot) handleEvents(ctx context.Context, gen int64) {
+	ticker := time.NewTicker(supervisorMinInterval)
+	defer ticker.Stop()
+
+	for b.loopGen.Load() == gen {
+		b.loopBeat.Store(time.Now().UnixNano())
 		select {
 		case <-ctx.Done():
 			return
-		case evt := <-b.socketClient.Events:
+		case <-ticker.C:
+		case evt := <-b.events:
 			b.handleEvent(ctx, evt)
 		}
 	}
@@ -103,12 +298,18 @@ func (b *Bot) handleEvent(ctx context.Context, evt socketmode.Event) {
 		b.handleEventsAPI(ctx, evt)
 	case socketmode.EventTypeSlashCommand:
 		b.handleSlashCommand(ctx, evt)
+	case socketmode.EventTypeInteractive:
+		b.handleInteractive(ctx, evt)
 	case socketmode.EventTypeConnecting:
 		b.logger.Info("connecting to Slack...")
 	case socketmode.EventTypeConnected:
 		b.logger.Info("connected to Slack")
+		b.markConnected()
+		b.flushBuffer()
 	case socketmode.EventTypeConnectionError:
 		b.logger.Error("connection error", "error", evt.Data)
+	case socketmode.EventTypeDisconnect:
+		b.logger.Warn("disconnected from Slack")
 	}
 }
 
@@ -119,7 +320,24 @@ func (b *Bot) handleEventsAPI(ctx context.Context, evt socketmode.Event) {
 		return
 	}
 
-	b.socketClient.Ack(*evt.Request)
+	b.ack(evt.Request)
+
+	// Skip events Slack redelivers after a reconnect
+	if callback, ok := eventsAPIEvent.Data.(*slackevents.EventsAPICallbackEvent); ok {
+		if !b.dedup.firstSeen(callback.EventID) {
+			b.logger.Debug("skipping duplicate event",
+				"event_id", callback.EventID,
+				"retry_attempt", evt.Request.RetryAttempt,
+			)
+			return
+		}
+	}
+
+	if !b.teams.allows(eventsAPIEvent.TeamID) {
+		b.logger.Warn("ignoring event from a workspace that isn't allowed", "team", eventsAPIEvent.TeamID)
+		return
+	}
+	b.learnTeam(eventsAPIEvent)
 
 	switch eventsAPIEvent.Type {
 	case slackevents.CallbackEvent:
@@ -134,6 +352,10 @@ func (b *Bot) handleCallbackEvent(ctx context.Context, evt slackevents.EventsAPI
 		b.handleAppMention(ctx, innerEvent)
 	case *slackevents.MessageEvent:
 		b.handleMessageEvent(ctx, innerEvent)
+	case *slackevents.AppHomeOpenedEvent:
+		b.handleAppHomeOpened(ctx, innerEvent)
+	case *slackevents.AppUninstalledEvent:
+		b.handleAppUninstalled(ctx, evt.TeamID)
 	}
 }
 
@@ -147,6 +369,7 @@ func (b *Bot) handleAppMention(ctx context.Context, evt *slackevents.AppMentionE
 		UserID:    evt.User,
 		ChannelID: evt.Channel,
 		ThreadTS:  evt.ThreadTimeStamp,
+		MessageTS: evt.TimeStamp,
 		IsDM:      false,
 	}
 
@@ -155,12 +378,22 @@ func (b *Bot) handleAppMention(ctx context.Context, evt *slackevents.AppMentionE
 		msg.ThreadTS = evt.TimeStamp
 	}
 
-	b.processMessage(ctx, msg)
+	b.dispatch(ctx, msg)
 }
 
-// handleMessageEvent processes direct messages.
+// handleMessageEvent processes direct messages, and edits and deletions of
+// requests the bot has yet to reply to.
 func (b *Bot) handleMessageEvent(ctx context.Context, evt *slackevents.MessageEvent) {
-	// Ignore bot messages and message changes
+	switch evt.SubType {
+	case "message_changed":
+		b.handleMessageChanged(ctx, evt)
+		return
+	case "message_deleted":
+		b.handleMessageDeleted(evt)
+		return
+	}
+
+	// Ignore bot messages and other subtypes
 	if evt.BotID != "" || evt.SubType != "" {
 		return
 	}
@@ -175,6 +408,7 @@ func (b *Bot) handleMessageEvent(ctx context.Context, evt *slackevents.MessageEv
 		UserID:    evt.User,
 		ChannelID: evt.Channel,
 		ThreadTS:  evt.ThreadTimeStamp,
+		MessageTS: evt.TimeStamp,
 		IsDM:      true,
 	}
 
@@ -183,7 +417,7 @@ func (b *Bot) handleMessageEvent(ctx context.Context, evt *slackevents.MessageEv
 		msg.ThreadTS = evt.TimeStamp
 	}
 
-	b.processMessage(ctx, msg)
+	b.dispatch(ctx, msg)
 }
 
 // handleSlashCommand processes /stormstack-dev commands.
@@ -193,22 +427,105 @@ func (b *Bot) handleSlashCommand(ctx context.Context, evt socketmode.Event) {
 		return
 	}
 
-	b.socketClient.Ack(*evt.Request)
+	b.ack(evt.Request)
 
 	// Only handle our command
 	if cmd.Command != "/stormstack-dev" {
 		return
 	}
+	if !b.teams.allows(cmd.TeamID) {
+		b.logger.Warn("ignoring command from a workspace that isn't allowed", "team", cmd.TeamID)
+		return
+	}
+	b.teams.learn(cmd.TeamID, cmd.ChannelID, cmd.UserID)
+
+	// The create task form must open while the trigger is fresh
+	if name, args := parseSubcommand(cmd.Text); name == "task" {
+		b.openTaskForm(ctx, cmd.TriggerID, cmd.UserID, cmd.ChannelID, args)
+		return
+	}
 
 	msg := &IncomingMessage{
 		Text:      cmd.Text,
 		UserID:    cmd.UserID,
+		UserName:  cmd.UserName,
 		ChannelID: cmd.ChannelID,
 		ThreadTS:  "", // Slash commands don't have threads
 		IsDM:      false,
+		Command:   true,
 	}
 
-	b.processMessage(ctx, msg)
+	b.dispatch(ctx, msg)
+}
+
+// Messages telling users their request is waiting for a free slot.
+const (
+	capacityMessage         = ":hourglass: I'm at capacity, queued — I'll start on this as soon as another conversation finishes."
+	priorityCapacityMessage = ":rotating_light: I'm at capacity, but this is high priority — it's next in line."
+)
+
+// Capacity returns the limiter shared by conversations and background work.
+func (b *Bot) Capacity() *capacity.Limiter {
+	return b.capacity
+}
+
+// dispatch processes a message in the background. Messages in the same
+// conversation are handled one at a time, in order of arrival; each takes a
+// conversation slot only when its turn comes, telling the user when it has
+// to wait for one. High-priority messages jump the queue for slots. Editing
+// or deleting a message before the reply stops the work on it.
+func (b *Bot) dispatch(ctx context.Context, msg *IncomingMessage) {
+	if msg.TeamID == "" {
+		msg.TeamID = b.teams.team(msg.ChannelID)
+	}
+
+	// Quick subcommands such as cancel must not wait behind the work they are about
+	if msg.Command && immediateSubcommand(msg.Text) {
+		go b.processMessage(ctx, msg)
+		return
+	}
+
+	key := msg.ThreadTS
+	if key == "" {
+		key = msg.ChannelID + "-" + msg.UserID
+	}
+
+	// Joining the conversation's queue here, not in the goroutine, keeps
+	// the order of arrival
+	turn, leave := b.conversations.enqueue(key)
+	ctx, done := b.pending.track(ctx, msg)
+	go func() {
+		defer done()
+		defer leave()
+		select {
+		case <-turn:
+		case <-ctx.Done():
+			return
+		}
+
+		priority := b.prioritizer.priority(msg)
+		if !b.capacity.TryAcquire(priority) {
+			b.logger.Warn("at capacity, queueing message",
+				"user", msg.UserID,
+				"channel", msg.ChannelID,
+				"priority", priority,
+				"waiting", b.capacity.Waiting(),
+			)
+			text := capacityMessage
+			if priority == capacity.High {
+				text = priorityCapacityMessage
+			}
+			if err := b.sendMessage(msg.ChannelID, &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}); err != nil {
+				b.logger.Warn("failed to send capacity message", "error", err)
+			}
+			if err := b.capacity.Acquire(ctx, priority); err != nil {
+				return
+			}
+		}
+		defer b.capacity.Release()
+
+		b.processMessage(ctx, msg)
+	}()
 }
 
 // processMessage sends a message to the handler and posts the response.
@@ -222,8 +539,22 @@ func (b *Bot) processMessage(ctx context.Context, msg *IncomingMessage) {
 	// Show typing indicator
 	b.showTyping(msg.ChannelID)
 
+	// Attribute the message to its sender
+	if msg.UserName == "" {
+		msg.UserName = b.users.name(msg.UserID)
+	}
+
+	// Catch up on the discussion the bot was pulled into
+	if msg.ThreadContext == "" {
+		msg.ThreadContext = b.threadContext(ctx, msg)
+	}
+
 	// Call the handler
 	response, err := b.handler(ctx, msg)
+	if requestChanged(ctx) {
+		// The request was edited or deleted, so the reply is no longer wanted
+		return
+	}
 	if err != nil {
 		b.logger.Error("handler error", "error", err)
 		response = &OutgoingMessage{
@@ -232,14 +563,72 @@ func (b *Bot) processMessage(ctx context.Context, msg *IncomingMessage) {
 		}
 	}
 
-	// Send the response
+	// Replies only the requester should see are delivered privately
+	if response.Visibility != VisibilityPublic && !msg.IsDM {
+		b.sendPrivate(msg, response)
+		return
+	}
+
+	// Send the response, buffering it for redelivery if Slack is unreachable
 	if err := b.sendMessage(msg.ChannelID, response); err != nil {
-		b.logger.Error("failed to send message", "error", err)
+		if b.buffer.add(msg.ChannelID, response) {
+			b.logger.Warn("failed to send message, buffered for redelivery", "error", err)
+		} else {
+			b.logger.Error("failed to send message", "error", err)
+		}
+	}
+}
+
+// flushBuffer delivers responses that were buffered while disconnected.
+func (b *Bot) flushBuffer() {
+	pending := b.buffer.drain()
+	for i, p := range pending {
+		if err := b.sendMessage(p.channelID, p.msg); err != nil {
+			b.logger.Warn("failed to deliver buffered message", "error", err)
+			// Keep the undelivered messages for the next reconnect
+			for _, rest := range pending[i:] {
+				b.buffer.add(rest.channelID, rest.msg)
+			}
+			return
+		}
+	}
+	if len(pending) > 0 {
+		b.logger.Info("delivered buffered messages", "count", len(pending))
 	}
 }
 
 // sendMessage posts a message to a channel.
 func (b *Bot) sendMessage(channelID string, msg *OutgoingMessage) error {
+	_, err := b.postMessage(channelID, msg)
+	return err
+}
+
+// postMessage posts a message to a channel and returns its timestamp, which
+// is empty in shadow mode. Its text is converted to mrkdwn and redacted.
+func (b *Bot) postMessage(channelID string, msg *OutgoingMessage) (string, error) {
+	redacted := *msg
+	redacted.Text = b.redactor.Redact(ToMrkdwn(msg.Text))
+	redacted.Blocks = b.redactBlocks(msg.Blocks)
+	msg = &redacted
+
+	if b.shadow != nil {
+		b.shadow.Record(shadow.Entry{
+			Kind:      shadow.KindMessage,
+			ChannelID: channelID,
+			ThreadTS:  msg.ThreadTS,
+			Text:      msg.Text,
+		})
+		for _, file := range msg.Files {
+			b.shadow.Record(shadow.Entry{
+				Kind:      shadow.KindMessage,
+				ChannelID: channelID,
+				ThreadTS:  msg.ThreadTS,
+				Text:      fmt.Sprintf("[file %s]\n%s", file.Name, b.redactor.Redact(file.Content)),
+			})
+		}
+		return "", nil
+	}
+
 	options := []slack.MsgOption{
 		slack.MsgOptionText(msg.Text, false),
 	}
@@ -252,8 +641,50 @@ func (b *Bot) sendMessage(channelID string, msg *OutgoingMessage) error {
 		options = append(options, slack.MsgOptionBlocks(msg.Blocks...))
 	}
 
-	_, _, err := b.client.PostMessage(channelID, options...)
-	return err
+	_, ts, err := b.clientFor(channelID).PostMessage(channelID, options...)
+	if err != nil {
+		return "", err
+	}
+
+	// The message is posted, so a failed upload must not have it sent again
+	threadTS := msg.ThreadTS
+	if threadTS == "" {
+		threadTS = ts
+	}
+	for _, file := range msg.Files {
+		content := b.redactor.Redact(file.Content)
+		if _, err := b.clientFor(channelID).UploadFileV2(slack.UploadFileV2Parameters{
+			Channel:         channelID,
+			ThreadTimestamp: threadTS,
+			Filename:        file.Name,
+			Title:           file.Title,
+			Content:         content,
+			FileSize:        len(content),
+		}); err != nil {
+			b.logger.Warn("failed to upload file", "channel", channelID, "file", file.Name, "error", err)
+		}
+	}
+	return ts, nil
+}
+
+// redactBlocks scrubs secrets from the text of blocks, such as the command
+// output shown in cards. Blocks that can't be redacted are dropped, leaving
+// the message's redacted text.
+func (b *Bot) redactBlocks(blocks []slack.Block) []slack.Block {
+	if b.redactor == nil || len(blocks) == 0 {
+		return blocks
+	}
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		b.logger.Warn("failed to encode blocks for redaction, dropping them", "error", err)
+		return nil
+	}
+	var redacted slack.Blocks
+	if err := json.Unmarshal([]byte(b.redactor.Redact(string(data))), &redacted); err != nil {
+		b.logger.Warn("failed to decode redacted blocks, dropping them", "error", err)
+		return nil
+	}
+	return redacted.BlockSet
 }
 
 // SendMessage allows external callers to send messages (for streaming updates).
@@ -261,9 +692,50 @@ func (b *Bot) SendMessage(channelID string, msg *OutgoingMessage) error {
 	return b.sendMessage(channelID, msg)
 }
 
+// StartThread posts announcement to a channel and has the bot handle request
+// in a thread under it, as if sender had asked there. It is used for work the
+// bot starts on its own, such as investigating a failed workflow.
+func (b *Bot) StartThread(ctx context.Context, channelID, announcement, sender, request string) error {
+	ts, err := b.postMessage(channelID, &OutgoingMessage{Text: announcement})
+	if err != nil {
+		return fmt.Errorf("failed to start thread: %w", err)
+	}
+
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      request,
+		UserName:  sender,
+		ChannelID: channelID,
+		ThreadTS:  ts,
+	})
+	return nil
+}
+
+// ContinueThread posts note in an existing thread and has the bot handle
+// request there, as if sender had asked in it. It is used for work that
+// follows up the thread's own, such as addressing a review of its PR.
+func (b *Bot) ContinueThread(ctx context.Context, channelID, threadTS, note, sender, request string) error {
+	if _, err := b.postMessage(channelID, &OutgoingMessage{Text: note, ThreadTS: threadTS}); err != nil {
+		return fmt.Errorf("failed to post in thread: %w", err)
+	}
+
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      request,
+		UserName:  sender,
+		ChannelID: channelID,
+		ThreadTS:  threadTS,
+	})
+	return nil
+}
+
 // UpdateMessage updates an existing message.
 func (b *Bot) UpdateMessage(channelID, timestamp, text string) error {
-	_, _, _, err := b.client.UpdateMessage(channelID, timestamp, slack.MsgOptionText(text, false))
+	text = b.redactor.Redact(ToMrkdwn(text))
+	if b.shadow != nil {
+		b.shadow.Record(shadow.Entry{Kind: shadow.KindMessage, ChannelID: channelID, ThreadTS: timestamp, Text: text})
+		return nil
+	}
+
+	_, _, _, err := b.clientFor(channelID).UpdateMessage(channelID, timestamp, slack.MsgOptionText(text, false))
 	return err
 }
 
@@ -275,8 +747,9 @@ func (b *Bot) showTyping(channelID string) {
 
 // stripBotMention removes the bot mention from message text.
 func (b *Bot) stripBotMention(text string) string {
-	mention := fmt.Sprintf("<@%s>", b.botUserID)
-	text = strings.Replace(text, mention, "", 1)
+	for _, id := range b.teams.botUserIDs() {
+		text = strings.Replace(text, "<@"+id+">", "", 1)
+	}
 	return strings.TrimSpace(text)
 }
 
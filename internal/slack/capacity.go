@@ -0,0 +1,91 @@
+// Request prioritization and per-conversation ordering.
+
+package slack
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/capacity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+)
+
+// prioritizer assigns incoming messages to a capacity lane.
+type prioritizer struct {
+	incidentChannels []string
+	oncallUsers      []string
+}
+
+// newPrioritizer creates a prioritizer from the configured incident channels
+// and on-call users.
+func newPrioritizer(cfg *config.Config) prioritizer {
+	return prioritizer{
+		incidentChannels: cfg.IncidentChannels,
+		oncallUsers:      cfg.OncallUsers,
+	}
+}
+
+// priority returns the lane for a message: high for incident channels and
+// on-call users, normal otherwise.
+func (p prioritizer) priority(msg *IncomingMessage) capacity.Priority {
+	if slices.Contains(p.incidentChannels, msg.ChannelID) || slices.Contains(p.oncallUsers, msg.UserID) {
+		return capacity.High
+	}
+	return capacity.Normal
+}
+
+// conversationLocks serializes the messages of each conversation, in order
+// of arrival.
+type conversationLocks struct {
+	mu    sync.Mutex
+	locks map[string]*conversationLock
+}
+
+// conversationLock is the queue of one conversation's messages.
+type conversationLock struct {
+	// queue holds a channel per message, in order of arrival; the first
+	// one's channel is closed when that message may run
+	queue []chan struct{}
+}
+
+// newConversationLocks creates an empty set of conversation locks.
+func newConversationLocks() *conversationLocks {
+	return &conversationLocks{locks: make(map[string]*conversationLock)}
+}
+
+// enqueue queues a message of a conversation behind the ones before it. The
+// returned channel is closed when it is the message's turn; the returned
+// func leaves the queue, passing the turn on, and must be called whether or
+// not the turn came.
+func (c *conversationLocks) enqueue(key string) (<-chan struct{}, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cl, ok := c.locks[key]
+	if !ok {
+		cl = &conversationLock{}
+		c.locks[key] = cl
+	}
+	turn := make(chan struct{})
+	cl.queue = append(cl.queue, turn)
+	if len(cl.queue) == 1 {
+		close(turn)
+	}
+
+	return turn, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		i := slices.Index(cl.queue, turn)
+		if i < 0 {
+			return
+		}
+		cl.queue = slices.Delete(cl.queue, i, i+1)
+		if i == 0 && len(cl.queue) > 0 {
+			close(cl.queue[0])
+		}
+		if len(cl.queue) == 0 {
+			delete(c.locks, key)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// The cards tools add to replies and the full outputs their buttons upload.
+
+package slack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/slack-go/slack"
+)
+
+// maxKeptOutputs bounds the full outputs kept for "view full output"
+// buttons; older ones are dropped.
+const maxKeptOutputs = 200
+
+// card is a Block Kit rendering of a tool's result.
+type card struct {
+	kind   string
+	blocks []slack.Block
+}
+
+// cards holds the cards each conversation's next reply shows. It is shared by
+// all tool executors.
+type cards struct {
+	mu      sync.Mutex
+	pending map[string][]card
+}
+
+// newCards creates an empty card queue.
+func newCards() *cards {
+	return &cards{pending: make(map[string][]card)}
+}
+
+// add queues a card for a conversation's next reply, replacing a queued card
+// of the same kind, so a reply shows e.g. only the last test run.
+func (c *cards) add(conversationID, kind string, blocks []slack.Block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queued := c.pending[conversationID]
+	for i := range queued {
+		if queued[i].kind == kind {
+			queued = append(queued[:i], queued[i+1:]...)
+			break
+		}
+	}
+	c.pending[conversationID] = append(queued, card{kind: kind, blocks: blocks})
+}
+
+// take removes and returns the cards queued for a conversation.
+func (c *cards) take(conversationID string) [][]slack.Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queued := c.pending[conversationID]
+	delete(c.pending, conversationID)
+
+	blocks := make([][]slack.Block, len(queued))
+	for i, card := range queued {
+		blocks[i] = card.blocks
+	}
+	return blocks
+}
+
+// outputs keeps the full outputs cards link to, in memory. It is shared by
+// all tool executors.
+type outputs struct {
+	mu    sync.Mutex
+	files map[string]File
+	order []string
+}
+
+// newOutputs creates an empty output store.
+func newOutputs() *outputs {
+	return &outputs{files: make(map[string]File)}
+}
+
+// keep stores a file and returns the ID its button refers to it by.
+func (o *outputs) keep(file File) string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.files[id] = file
+	o.order = append(o.order, id)
+	if len(o.order) > maxKeptOutputs {
+		delete(o.files, o.order[0])
+		o.order = o.order[1:]
+	}
+	return id
+}
+
+// get returns the file kept under id.
+func (o *outputs) get(id string) (File, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	file, ok := o.files[id]
+	return file, ok
+}
+
+// addCard queues a card for the reply in the conversation of ctx.
+func (e *ToolExecutor) addCard(ctx context.Context, kind string, blocks []slack.Block) {
+	if info, ok := ConversationFromContext(ctx); ok {
+		e.cards.add(info.ConversationID, kind, blocks)
+	}
+}
+
+// keepOutput keeps content for a card's button to upload as name.
+func (e *ToolExecutor) keepOutput(name, title, content string) string {
+	return e.outputs.keep(File{Name: name, Title: title, Content: content})
+}
+
+// showOutputRe matches the request a "view full output" button sends.
+var showOutputRe = regexp.MustCompile(`(?i)^\s*show\s+output\s+([0-9a-f]{12})\s*$`)
+
+// handleShowOutput uploads a full output a card's button asked for. It
+// reports whether the message was such a request.
+func (h *Handler) handleShowOutput(msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := showOutputRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+
+	file, ok := h.toolExecutor.outputs.get(strings.ToLower(match[1]))
+	if !ok {
+		return &OutgoingMessage{Text: "That output is no longer kept; run the command again to see it.", ThreadTS: msg.ThreadTS}, true
+	}
+	return &OutgoingMessage{Text: ":page_facing_up: " + file.Title, ThreadTS: msg.ThreadTS, Files: []File{file}}, true
+}
+
+// showOutput has the handler upload the full output a card's button refers to.
+func (b *Bot) showOutput(ctx context.Context, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	channelID := callback.Channel.ID
+	threadTS := callback.Message.ThreadTimestamp
+	if threadTS == "" {
+		threadTS = callback.Message.Timestamp
+	}
+
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      "show output " + action.Value,
+		UserID:    callback.User.ID,
+		ChannelID: channelID,
+		ThreadTS:  threadTS,
+		IsDM:      strings.HasPrefix(channelID, "D"),
+	})
+}
+
+// renderPR renders a pull request as a card whose button uploads its diff.
+func (e *ToolExecutor) renderPR(pr *git.PRDetails) []slack.Block {
+	diffID := ""
+	if pr.Diff != "" {
+		diffID = e.keepOutput(fmt.Sprintf("pr-%d.diff", pr.Info.Number), fmt.Sprintf("Diff of PR #%d", pr.Info.Number), pr.Diff)
+	}
+	return RenderPR(pr, diffID)
+}
@@ -0,0 +1,403 @@
+// The /stormstack-dev subcommands.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/activity"
+)
+
+// Days the usage subcommand covers by default and at most; the activity log
+// keeps no more.
+const (
+	defaultUsageDays = 7
+	maxUsageDays     = 30
+)
+
+// subcommand is a /stormstack-dev subcommand.
+type subcommand struct {
+	Name        string
+	Args        string
+	Description string
+	// Immediate subcommands are answered at once, even while the sender's
+	// other requests are being worked on
+	Immediate bool
+}
+
+// subcommands lists the /stormstack-dev subcommands, in the order they are
+// shown in help. Any other text is sent to Claude as a request.
+var subcommands = []subcommand{
+	{Name: "help", Args: "[tools|<command>]", Description: "Show the commands, every tool, or how to use one command", Immediate: true},
+	{Name: "status", Description: "Show the repository's state, the model I use and your running requests", Immediate: true},
+	{Name: "repos", Description: "List the repositories I work on", Immediate: true},
+	{Name: "usage", Args: "[days]", Description: fmt.Sprintf("Show your requests, tool calls and Claude tokens over the last %d (or given, up to %d) days", defaultUsageDays, maxUsageDays), Immediate: true},
+	{Name: "cancel", Description: "Stop your running requests", Immediate: true},
+	{Name: "reload", Description: "Reload my configuration, and the repository's `.stormstack/config.yaml`, without a restart (approvers only)", Immediate: true},
+	{Name: "clear", Description: "Forget our conversation in this channel and start fresh"},
+	{Name: "reset", Args: "[confirm]", Description: "Like clear, but asks you to confirm first"},
+	{Name: "export", Args: "[markdown|json]", Description: "Upload our conversation in this channel (messages, tool calls and the diff so far) as a markdown or JSON file"},
+	{Name: "review", Args: "<PR number or link> [instructions]", Description: "Review a pull request"},
+	{Name: "issue", Args: "<number> [instructions]", Description: "Implement an issue end to end and open a PR that references it"},
+	{Name: "task", Args: "[description]", Description: "Open a form to describe a task: repository, details, target branch and whether to open a PR"},
+}
+
+// issueNumberRe matches an issue or PR number, with or without "#".
+var issueNumberRe = regexp.MustCompile(`^#?(\d+)$`)
+
+// parseSubcommand splits slash command text into the lowercased subcommand
+// name and its arguments.
+func parseSubcommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	name, args, _ := strings.Cut(text, " ")
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+// lookupSubcommand returns the subcommand called name.
+func lookupSubcommand(name string) (subcommand, bool) {
+	for _, c := range subcommands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// immediateSubcommand reports whether slash command text is a subcommand
+// that is answered at once.
+func immediateSubcommand(text string) bool {
+	name, _ := parseSubcommand(text)
+	c, ok := lookupSubcommand(name)
+	return name == "" || (ok && c.Immediate)
+}
+
+// handleSubcommand runs a /stormstack-dev subcommand, answering only the
+// sender. review and issue are rewritten into requests for Claude in msg.Text
+// instead. It reports whether the message was answered.
+func (h *Handler) handleSubcommand(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	if !msg.Command {
+		return nil, false
+	}
+	name, args := parseSubcommand(msg.Text)
+	reply := func(text string) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS, Visibility: h.private()}, true
+	}
+
+	switch name {
+	case "", "help":
+		return reply(h.subcommandHelp(ctx, msg, args))
+	case "status":
+		return reply(h.status(ctx, msg))
+	case "repos":
+		return reply(h.repos(ctx, msg))
+	case "usage":
+		days := defaultUsageDays
+		if args != "" {
+			n, err := strconv.Atoi(strings.TrimSuffix(args, "d"))
+			if err != nil || n < 1 || n > maxUsageDays {
+				return reply(fmt.Sprintf("Usage: `/stormstack-dev usage [days]`, with days from 1 to %d.", maxUsageDays))
+			}
+			days = n
+		}
+		return reply(h.usage(msg.UserID, days))
+	case "cancel":
+		return reply(h.cancelRequests(ctx, conversationID, msg.UserID))
+	case "reload":
+		return reply(h.reloadConfig(ctx, msg.UserID))
+	case "clear":
+		h.approvals.Cancel(conversationID)
+		h.forgetIssue(conversationID)
+		text := ":broom: Cleared our conversation in this channel; your next request starts fresh."
+		if err := h.conversation.ClearConversation(ctx, conversationID); err != nil {
+			h.logger.Error("failed to clear conversation", "conversation", conversationID, "error", err)
+			text = fmt.Sprintf("Sorry, I couldn't clear our conversation: %v", err)
+		}
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS, Visibility: h.private()}, true
+	case "reset":
+		switch {
+		case args == "":
+			h.resets.ask(conversationID, msg.UserID)
+			return reply(fmt.Sprintf(":warning: This makes me forget our conversation in this channel. Run `/stormstack-dev reset confirm` within %d minutes to go ahead.", int(resetConfirmTTL.Minutes())))
+		case strings.EqualFold(args, "confirm") && h.resets.take(conversationID, msg.UserID):
+			return &OutgoingMessage{Text: h.resetConversation(ctx, conversationID), ThreadTS: msg.ThreadTS, Visibility: h.private()}, true
+		default:
+			return reply("Run `/stormstack-dev reset` first, then `/stormstack-dev reset confirm` within a couple of minutes.")
+		}
+	case "export":
+		if args != "" && !exportRe.MatchString("export "+args) {
+			return reply("Usage: `/stormstack-dev export [markdown|json]`.")
+		}
+		return h.export(ctx, conversationID, msg, args), true
+	case "review":
+		ref, extra, _ := strings.Cut(args, " ")
+		request, err := h.reviewRequest(ctx, ref)
+		if err != nil {
+			return reply(fmt.Sprintf("Sorry, I couldn't start the review: %v. Usage: `/stormstack-dev review <PR number or link> [instructions]`.", err))
+		}
+		msg.Text = strings.TrimSpace(request + " " + extra)
+		return nil, false
+	case "issue":
+		ref, extra, _ := strings.Cut(args, " ")
+		match := issueNumberRe.FindStringSubmatch(ref)
+		if match == nil {
+			return reply("Usage: `/stormstack-dev issue <number> [instructions]`, e.g. `/stormstack-dev issue 42`.")
+		}
+		msg.Text = strings.TrimSpace(fmt.Sprintf("work on issue #%s %s", match[1], extra))
+		return nil, false
+	case "task":
+		// The bot opens the form before the command gets here
+		return reply("Use `/stormstack-dev task` to open the create task form.")
+	}
+
+	if suggestions := suggestCommands(msg.Text); len(suggestions) > 0 {
+		for i, s := range suggestions {
+			suggestions[i] = "`" + s + "`"
+		}
+		return reply(fmt.Sprintf("I don't know the command `%s`. Did you mean %s? Send `/stormstack-dev help` for every command, or ask in a full sentence.",
+			name, strings.Join(suggestions, " or ")))
+	}
+	return nil, false
+}
+
+// subcommandHelp describes the subcommands, the tools (args "tools") or one
+// subcommand, followed by the general help.
+func (h *Handler) subcommandHelp(ctx context.Context, msg *IncomingMessage, args string) string {
+	args = strings.ToLower(strings.TrimSpace(args))
+	if args == "tools" {
+		return toolHelp(h.toolExecutor.tools.categories())
+	}
+	if args != "" {
+		c, ok := lookupSubcommand(args)
+		if !ok {
+			return fmt.Sprintf("There is no `%s` command. Send `/stormstack-dev help` for every command.", args)
+		}
+		return fmt.Sprintf("`/stormstack-dev %s` — %s", strings.TrimSpace(c.Name+" "+c.Args), c.Description)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(":keyboard: *Slash commands*\n")
+	for _, c := range subcommands {
+		sb.WriteString(fmt.Sprintf("• `/stormstack-dev %s` — %s\n", strings.TrimSpace(c.Name+" "+c.Args), c.Description))
+	}
+	sb.WriteString("• `/stormstack-dev <request>` — Anything else is a request, e.g. `/stormstack-dev run the tests`\n\n")
+	sb.WriteString(h.help(ctx, msg))
+	return sb.String()
+}
+
+// status describes the repository the sender's requests run against, the
+// model and the sender's running requests.
+func (h *Handler) status(ctx context.Context, msg *IncomingMessage) string {
+	cfg := h.toolExecutor.cfg
+
+	var sb strings.Builder
+	sb.WriteString(":satellite: *Status*\n")
+	sb.WriteString("• Repository: " + h.repoDescription(ctx, msg) + "\n")
+	if e, err := h.executorFor(ctx); err == nil {
+		if head, err := e.gitOps.ResolveCommit(ctx, "HEAD"); err == nil && len(head.SHA) >= 7 {
+			sb.WriteString(fmt.Sprintf("• HEAD: `%s` %s\n", head.SHA[:7], head.Subject))
+		}
+		if changed, err := e.gitOps.ChangedFiles(ctx); err == nil {
+			if len(changed) == 0 {
+				sb.WriteString("• Working tree: clean\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("• Working tree: %d uncommitted changes\n", len(changed)))
+			}
+		}
+	}
+	settings := h.settings()
+	sb.WriteString(fmt.Sprintf("• Model: `%s` via %s\n", settings.ClaudeModel, settings.ClaudeBackend))
+	if cfg.ShadowMode {
+		sb.WriteString("• :ghost: Shadow mode: I record what I would post or change instead of doing it\n")
+	}
+
+	tasks := h.tasks.running(msg.UserID)
+	if len(tasks) == 0 {
+		sb.WriteString("• Your requests: none running")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("• Your requests: %d running (`/stormstack-dev cancel` stops them)\n", len(tasks)))
+	for _, t := range tasks {
+		sb.WriteString(fmt.Sprintf("    ◦ in <#%s> for %s\n", t.channelID, time.Since(t.started).Round(time.Second)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// repos lists the repositories the bot works on.
+func (h *Handler) repos(ctx context.Context, msg *IncomingMessage) string {
+	gitOps := h.toolExecutor.gitOps
+
+	var sb strings.Builder
+	sb.WriteString(":file_folder: *Repositories*\n")
+	sb.WriteString("• " + h.repoDescription(ctx, &IncomingMessage{UserID: msg.UserID}) + "\n")
+	if remote, err := gitOps.GetRemoteURL(ctx); err == nil {
+		sb.WriteString(fmt.Sprintf("    ◦ Remote: %s\n", displayRemote(remote)))
+	}
+	if branch, err := gitOps.GetDefaultBranch(ctx); err == nil {
+		sb.WriteString(fmt.Sprintf("    ◦ Default branch: `%s`\n", branch))
+	}
+	if h.workspaces != nil {
+		sb.WriteString(fmt.Sprintf("• Your personal workspace, used in DMs: branch `%s`\n", h.workspaces.worktrees.Branch(msg.UserID)))
+	}
+	if h.conversationWorkspaces != nil {
+		sb.WriteString("• Each conversation works in a checkout of its own, starting on a `" + h.conversationWorkspaces.worktrees.Branch(conversationWorkspacePrefix) + "…` branch\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// displayRemote returns a remote URL without any credentials in it.
+func displayRemote(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+// usageRe matches "usage", "my usage" and "usage 30" or "usage 30d".
+var usageRe = regexp.MustCompile(`(?i)^\s*(?:my\s+)?usage(?:\s+(\d+)d?)?\s*$`)
+
+// handleUsage answers the usage command in mentions and DMs with the
+// sender's usage, privately. It reports whether the message was a usage
+// command.
+func (h *Handler) handleUsage(msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := usageRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+
+	days := defaultUsageDays
+	if match[1] != "" {
+		days, _ = strconv.Atoi(match[1])
+	}
+	text := fmt.Sprintf("Usage: `usage [days]`, with days from 1 to %d.", maxUsageDays)
+	if days >= 1 && days <= maxUsageDays {
+		text = h.usage(msg.UserID, days)
+	}
+	// Not recorded, so Claude can't repeat it to the rest of the thread
+	return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS, Visibility: h.private()}, true
+}
+
+// usage reports userID's activity over the last days days.
+func (h *Handler) usage(userID string, days int) string {
+	log := h.toolExecutor.activity
+	if log == nil {
+		return "Usage isn't recorded: set `STORMSTACK_ACTIVITY_DIR` to keep an activity log."
+	}
+	usage, err := log.UserUsage(userID, days, time.Now())
+	if err != nil {
+		h.logger.Error("failed to read activity log", "error", err)
+		return fmt.Sprintf("Sorry, I couldn't read the activity log: %v", err)
+	}
+	cfg := h.toolExecutor.cfg
+	return usage.Format(activity.Prices{Input: cfg.ClaudeInputPrice, Output: cfg.ClaudeOutputPrice})
+}
+
+// cancelRequests stops userID's running requests and the action waiting for
+// approval in the conversation, if userID may reject it.
+func (h *Handler) cancelRequests(ctx context.Context, conversationID, userID string) string {
+	var stopped []string
+	if n := h.tasks.cancel(userID); n > 0 {
+		stopped = append(stopped, fmt.Sprintf("%d running request(s)", n))
+	}
+	if req, err := h.approvals.Reject(conversationID, userID, h.participantIDs(ctx, conversationID)); err == nil {
+		stopped = append(stopped, "the action waiting for approval: "+req.Summary)
+	}
+	if len(stopped) == 0 {
+		return "You have no running requests to stop."
+	}
+	return ":octagonal_sign: Stopped " + strings.Join(stopped, " and ") + "."
+}
+
+// reviewRequest turns a PR number or link into a review request.
+func (h *Handler) reviewRequest(ctx context.Context, ref string) (string, error) {
+	if url := prURLRe.FindString(ref); url != "" {
+		return "Review " + url, nil
+	}
+	match := issueNumberRe.FindStringSubmatch(ref)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a PR number or link", ref)
+	}
+	number, _ := strconv.Atoi(match[1])
+	pr, err := h.toolExecutor.forge.GetPR(ctx, number)
+	if err != nil {
+		return "", fmt.Errorf("failed to find PR #%d: %w", number, err)
+	}
+	return "Review " + pr.URL, nil
+}
+
+// commandArgRe matches the argument a mistyped command may carry: a number or
+// a link.
+var commandArgRe = regexp.MustCompile(`^(?:#?\d+|<?https?://\S+)$`)
+
+// suggestCommands returns the commands text may be a typo of. Only a single
+// word, optionally with a number or link, is taken for a command; anything
+// longer is a request.
+func suggestCommands(text string) []string {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && !commandArgRe.MatchString(fields[1])) {
+		return nil
+	}
+	word := fields[0]
+
+	names := make([]string, 0, len(subcommands)+len(builtinCommands))
+	for _, c := range subcommands {
+		names = append(names, c.Name)
+	}
+	for _, c := range builtinCommands {
+		if name, _, _ := strings.Cut(c.Usage, " "); !strings.HasPrefix(name, "<") {
+			names = append(names, name)
+		}
+	}
+
+	var suggestions []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == word {
+			// A known command handled elsewhere
+			return nil
+		}
+		limit := 1
+		if len(name) > 4 {
+			limit = 2
+		}
+		if !seen[name] && editDistance(word, name) <= limit {
+			seen[name] = true
+			suggestions = append(suggestions, name)
+		}
+	}
+	return suggestions
+}
+
+// editDistance returns the number of single-character insertions,
+// deletions, substitutions and swaps of adjacent characters turning a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,33 @@
+// Request-scoped conversation context for tools.
+
+package slack
+
+import "context"
+
+// ConversationInfo identifies the Slack conversation a tool call belongs to.
+type ConversationInfo struct {
+	// ConversationID is the storage key for the conversation
+	ConversationID string
+	// ChannelID is the channel where the conversation takes place
+	ChannelID string
+	// ThreadTS is the thread timestamp replies are posted in
+	ThreadTS string
+	// UserID is the Slack user ID of the requester
+	UserID string
+	// Workspace is the user whose personal workspace tools run in, or "" for
+	// the shared checkout
+	Workspace string
+}
+
+type conversationKey struct{}
+
+// WithConversation returns a context carrying the conversation info.
+func WithConversation(ctx context.Context, info ConversationInfo) context.Context {
+	return context.WithValue(ctx, conversationKey{}, info)
+}
+
+// ConversationFromContext returns the conversation info stored in the context.
+func ConversationFromContext(ctx context.Context) (ConversationInfo, bool) {
+	info, ok := ctx.Value(conversationKey{}).(ConversationInfo)
+	return info, ok
+}
@@ -0,0 +1,263 @@
+// Per-conversation workspaces, with eviction, disk quotas and cleanup.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/repo"
+)
+
+const (
+	// conversationWorkspaceDir is where conversation workspaces are kept,
+	// under the workspace path, in a directory per repository
+	conversationWorkspaceDir = "conversations"
+	// conversationWorkspacePrefix prefixes the keys of conversation
+	// workspaces, which name their worktrees and branches
+	conversationWorkspacePrefix = "conversation-"
+	// workspaceSizeRecheck is how long a workspace's measured size is
+	// trusted before it is measured again
+	workspaceSizeRecheck = time.Minute
+)
+
+// workspaceSize is a workspace's disk usage when it was last measured.
+type workspaceSize struct {
+	bytes      int64
+	measuredAt time.Time
+}
+
+// evict releases the least recently used idle worktrees while more than the
+// limit are checked out. Worktrees with uncommitted changes are kept, and
+// branches always are, so a conversation coming back finds its commits. The
+// caller holds w.mu.
+func (w *workspaces) evict() {
+	if w.limit <= 0 || len(w.executors) <= w.limit {
+		return
+	}
+
+	keys := make([]string, 0, len(w.executors))
+	for key := range w.executors {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return w.used[keys[i]].Before(w.used[keys[j]]) })
+
+	for _, key := range keys {
+		if len(w.executors) <= w.limit {
+			return
+		}
+		if w.busy != nil && w.busy(key) {
+			continue
+		}
+		if released, err := w.worktrees.Release(key); err != nil || !released {
+			continue
+		}
+		delete(w.executors, key)
+		delete(w.used, key)
+	}
+}
+
+// size returns the disk space used by key's worktree, measuring it at most
+// every workspaceSizeRecheck.
+func (w *workspaces) size(key string) (int64, error) {
+	w.sizesMu.Lock()
+	defer w.sizesMu.Unlock()
+
+	if s, ok := w.sizes[key]; ok && time.Since(s.measuredAt) < workspaceSizeRecheck {
+		return s.bytes, nil
+	}
+	bytes, err := w.worktrees.Size(key)
+	if err != nil {
+		return 0, err
+	}
+	w.sizes[key] = workspaceSize{bytes: bytes, measuredAt: time.Now()}
+	return bytes, nil
+}
+
+// overQuota returns an error when key's worktree uses more than its quota,
+// after deleting the files git ignores (build output and the like) to get
+// back under it.
+func (w *workspaces) overQuota(key string) error {
+	if w.quota <= 0 {
+		return nil
+	}
+	size, err := w.size(key)
+	if err != nil || size <= w.quota {
+		return err
+	}
+
+	if err := w.worktrees.Clean(key); err != nil {
+		return err
+	}
+	w.sizesMu.Lock()
+	delete(w.sizes, key)
+	w.sizesMu.Unlock()
+	if size, err = w.size(key); err != nil || size <= w.quota {
+		return err
+	}
+	return fmt.Errorf("this conversation's workspace uses %d MB, over its quota of %d MB, even without ignored files; delete large files with run_command, or ask for the quota to be raised",
+		size>>20, w.quota>>20)
+}
+
+// quotaError refuses tools that could use more disk space in a conversation
+// workspace over its quota. Reading and run_command, which can delete
+// files, are still allowed.
+func (h *Handler) quotaError(ctx context.Context, e *ToolExecutor, name string) error {
+	if h.conversationWorkspaces == nil || e == h.toolExecutor || name == "run_command" {
+		return nil
+	}
+	info, ok := ConversationFromContext(ctx)
+	if !ok || info.Workspace != "" {
+		return nil
+	}
+	if tool, ok := e.tools.lookup(name); !ok || tool.Permission == permissionRead {
+		return nil
+	}
+	return h.conversationWorkspaces.overQuota(conversationWorkspace(info.ConversationID))
+}
+
+// PruneWorkspaces frees the workspaces of conversations that were closed
+// or forgotten, keeping their branches. Those of conversations forgotten
+// and unused for longer than maxAge are removed with their branches,
+// discarding changes that weren't pushed. Workspaces whose conversation
+// isn't recorded are left alone. It returns how many were removed.
+func (h *Handler) PruneWorkspaces(ctx context.Context, maxAge time.Duration) (int, error) {
+	w := h.conversationWorkspaces
+	if w == nil {
+		return 0, nil
+	}
+
+	keys, err := w.worktrees.Owners()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, key := range keys {
+		// Personal DM workspaces share the branch namespace
+		if !strings.HasPrefix(key, conversationWorkspacePrefix) || (w.busy != nil && w.busy(key)) {
+			continue
+		}
+		conversationID, ok := w.conversations.conversation(key)
+		if !ok {
+			continue
+		}
+		conv, err := h.conversation.Conversation(ctx, conversationID)
+		if err != nil {
+			return removed, err
+		}
+		if conv != nil && !conv.Archived() {
+			continue
+		}
+
+		if lastUsed, err := w.worktrees.LastUsed(key); conv == nil && err == nil && time.Since(lastUsed) > maxAge {
+			if err := w.reset(key); err != nil {
+				h.logger.Warn("failed to remove conversation workspace", "workspace", key, "error", err)
+				continue
+			}
+			if err := w.conversations.forget(key); err != nil {
+				h.logger.Warn("failed to forget conversation workspace", "workspace", key, "error", err)
+			}
+			removed++
+			continue
+		}
+		if _, err := w.release(key); err != nil {
+			h.logger.Warn("failed to release conversation workspace", "workspace", key, "error", err)
+		}
+	}
+	return removed, nil
+}
+
+// conversationExecutor returns the tool executor for a conversation's own
+// workspace, recording which conversation the workspace belongs to.
+func (h *Handler) conversationExecutor(conversationID string) (*ToolExecutor, error) {
+	w := h.conversationWorkspaces
+	key := conversationWorkspace(conversationID)
+	if err := w.conversations.record(key, conversationID); err != nil {
+		// The workspace is only kept from pruning
+		h.logger.Warn("failed to record conversation workspace", "workspace", key, "error", err)
+	}
+	return w.executor(key)
+}
+
+// conversationWorkspace returns the key of a conversation's workspace, which
+// is safe as a path and branch name. Characters are lost on the way, so the
+// conversation is found from workspaceConversations, not the key.
+func conversationWorkspace(conversationID string) string {
+	return repo.OwnerName(conversationWorkspacePrefix + strings.ReplaceAll(conversationID, ".", "_"))
+}
+
+// workspaceConversations records the conversation each conversation
+// workspace belongs to, by key, in a JSON file, so workspaces can be told
+// apart from those of forgotten conversations after a restart.
+type workspaceConversations struct {
+	path string
+
+	mu    sync.Mutex
+	byKey map[string]string
+}
+
+// loadWorkspaceConversations reads the record kept in path, if any.
+func loadWorkspaceConversations(path string) (*workspaceConversations, error) {
+	c := &workspaceConversations{path: path, byKey: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace conversations: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.byKey); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace conversations %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// conversation returns the conversation the workspace key belongs to.
+func (c *workspaceConversations) conversation(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conversationID, ok := c.byKey[key]
+	return conversationID, ok
+}
+
+// record notes that the workspace key belongs to conversationID.
+func (c *workspaceConversations) record(key, conversationID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byKey[key] == conversationID {
+		return nil
+	}
+	c.byKey[key] = conversationID
+	return c.save()
+}
+
+// forget drops the workspace key, once it has been removed.
+func (c *workspaceConversations) forget(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.byKey[key]; !ok {
+		return nil
+	}
+	delete(c.byKey, key)
+	return c.save()
+}
+
+// save writes the record atomically. The caller holds c.mu.
+func (c *workspaceConversations) save() error {
+	data, err := json.MarshalIndent(c.byKey, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode workspace conversations: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write workspace conversations: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,82 @@
+// The describe_database and explain_query tools.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/database"
+)
+
+// databaseConfigured reports whether a database to introspect is configured.
+func databaseConfigured(cfg *config.Config) bool {
+	return cfg.DatabaseDSN != ""
+}
+
+// openDatabase connects to the configured database, or returns nil when
+// none is.
+func openDatabase(ctx context.Context, cfg *config.Config) (*database.DB, error) {
+	if !databaseConfigured(cfg) {
+		return nil, nil
+	}
+	db, err := database.Open(ctx, cfg.DatabaseDriver, cfg.DatabaseDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", cfg.DatabaseDriver, err)
+	}
+	return db, nil
+}
+
+func (e *ToolExecutor) describeDatabase(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.DescribeDatabaseParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if e.db == nil {
+		return "", fmt.Errorf("no database is configured (set STORMSTACK_DATABASE_DSN)")
+	}
+
+	if params.Table != "" {
+		return e.db.Describe(ctx, params.Table)
+	}
+
+	tables, err := e.db.Tables(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(tables) == 0 {
+		return "The database has no tables.", nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d tables and views:\n", len(tables))
+	for _, t := range tables {
+		fmt.Fprintf(&sb, "  %s.%s", t.Schema, t.Name)
+		if t.Kind == "view" {
+			sb.WriteString(" (view)")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func (e *ToolExecutor) explainQuery(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ExplainQueryParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if e.db == nil {
+		return "", fmt.Errorf("no database is configured (set STORMSTACK_DATABASE_DSN)")
+	}
+
+	plan, err := e.db.Explain(ctx, params.Query)
+	if err != nil {
+		return "", err
+	}
+	return "Query plan (estimated, the query was not run):\n" + plan, nil
+}
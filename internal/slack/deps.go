@@ -0,0 +1,148 @@
+// The dependency inspection and update tools.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+func (e *ToolExecutor) listDependencies(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ListDependenciesParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	ecosystem, err := executor.SelectEcosystem(e.writer.GetRepoPath(), params.Ecosystem)
+	if err != nil {
+		return "", err
+	}
+	return e.runDependencyQuery(ctx, ecosystem.ListCommand(params.Transitive), params.Filter)
+}
+
+func (e *ToolExecutor) checkOutdated(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CheckOutdatedParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	ecosystem, err := executor.SelectEcosystem(e.writer.GetRepoPath(), params.Ecosystem)
+	if err != nil {
+		return "", err
+	}
+	output, err := e.runDependencyQuery(ctx, ecosystem.OutdatedCommand(), params.Filter)
+	if err != nil {
+		return "", err
+	}
+	switch ecosystem.Name {
+	case executor.EcosystemGradle:
+		output += "\nGradle needs the com.github.ben-manes.versions plugin for dependencyUpdates; without it, compare list_dependencies with the latest releases instead.\n"
+	case executor.EcosystemCargo:
+		output += "\nCargo needs cargo-outdated (cargo install cargo-outdated) for this check.\n"
+	}
+	return output, nil
+}
+
+func (e *ToolExecutor) bumpDependency(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.BumpDependencyParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	ecosystem, err := executor.SelectEcosystem(e.writer.GetRepoPath(), params.Ecosystem)
+	if err != nil {
+		return "", err
+	}
+	command, err := ecosystem.BumpCommand(params.Name, params.Version)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := e.runner.RunDependencyCommand(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	if !result.IsSuccess() {
+		return fmt.Sprintf("Failed to bump %s to %s.\n\n%s", params.Name, params.Version, result.FormatResult()), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Bumped %s to %s with `%s`.\n", params.Name, params.Version, command))
+	changed, err := e.gitOps.ChangedFiles(ctx)
+	switch {
+	case err != nil:
+		e.logger.Warn("failed to list files changed by dependency bump", "error", err)
+	case len(changed) == 0:
+		sb.WriteString("No files changed: the dependency may already be at this version, or be declared through a property or version catalog to edit by hand.\n")
+		return sb.String(), nil
+	default:
+		sb.WriteString(fmt.Sprintf("Changed files: %s\n", strings.Join(changed, ", ")))
+	}
+
+	build, err := e.runner.RunBuild(ctx, "")
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("\nThe build was not run: %v\n", err))
+		return sb.String(), nil
+	}
+	if build.IsSuccess() {
+		sb.WriteString("\nThe build passed. Run the tests, then commit and open a PR.\n")
+	} else {
+		sb.WriteString("\nThe build failed after the bump; fix the breakage or choose another version:\n")
+	}
+	sb.WriteString(build.FormatResult())
+	return sb.String(), nil
+}
+
+func (e *ToolExecutor) scanVulnerabilities(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ScanVulnerabilitiesParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	scanners := []string{params.Scanner}
+	if params.Scanner == "" {
+		ecosystems := executor.DetectEcosystems(e.writer.GetRepoPath())
+		if scanners = executor.ScannersFor(ecosystems); len(scanners) == 0 {
+			return "No package manifest was found at the repository root, so there is nothing to scan.", nil
+		}
+	}
+
+	scan := e.runner.ScanVulnerabilities(ctx, scanners)
+	if params.Format == "json" {
+		scan.Vulnerabilities = scan.Matching(params.ID)
+		data, err := json.MarshalIndent(scan, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode scan: %w", err)
+		}
+		return string(data), nil
+	}
+	return scan.Format(params.ID), nil
+}
+
+// runDependencyQuery runs a command that reports on dependencies, keeping
+// only the lines containing filter when it is set.
+func (e *ToolExecutor) runDependencyQuery(ctx context.Context, command, filter string) (string, error) {
+	result, err := e.runner.RunDependencyCommand(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	if filter == "" {
+		return result.FormatResult(), nil
+	}
+
+	var matched []string
+	for _, line := range strings.Split(result.CombinedOutput(), "\n") {
+		if strings.Contains(strings.ToLower(line), strings.ToLower(filter)) {
+			matched = append(matched, line)
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Sprintf("$ %s\nNo lines mention %q (exit code %d).\n", command, filter, result.ExitCode), nil
+	}
+	return fmt.Sprintf("$ %s\n%s\n", command, strings.Join(matched, "\n")), nil
+}
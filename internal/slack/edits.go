@@ -0,0 +1,141 @@
+// Handling of requests that are edited or deleted before the bot replies to
+// them.
+
+package slack
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+var (
+	// errRequestEdited cancels the work on a request that was edited before
+	// the bot replied; the edited text is worked on instead.
+	errRequestEdited = errors.New("request was edited")
+	// errRequestDeleted cancels the work on a request that was deleted before
+	// the bot replied.
+	errRequestDeleted = errors.New("request was deleted")
+)
+
+// pendingRequest is a message the bot has yet to reply to.
+type pendingRequest struct {
+	msg    *IncomingMessage
+	cancel context.CancelCauseFunc
+}
+
+// pendingRequests tracks the messages the bot has yet to reply to, by
+// channel and timestamp, so edits and deletions can reach them.
+type pendingRequests struct {
+	mu       sync.Mutex
+	requests map[string]*pendingRequest
+}
+
+// newPendingRequests creates an empty tracker.
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{requests: make(map[string]*pendingRequest)}
+}
+
+// pendingKey identifies a message.
+func pendingKey(channelID, ts string) string {
+	return channelID + ":" + ts
+}
+
+// track registers msg until the returned done is called, and returns the
+// context to work on it with, which an edit or deletion of msg cancels.
+// Messages without a timestamp, such as slash commands, can't be edited and
+// aren't tracked.
+func (p *pendingRequests) track(ctx context.Context, msg *IncomingMessage) (context.Context, func()) {
+	if msg.MessageTS == "" {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	req := &pendingRequest{msg: msg, cancel: cancel}
+	key := pendingKey(msg.ChannelID, msg.MessageTS)
+
+	p.mu.Lock()
+	p.requests[key] = req
+	p.mu.Unlock()
+
+	return ctx, func() {
+		p.mu.Lock()
+		if p.requests[key] == req {
+			delete(p.requests, key)
+		}
+		p.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// drop stops the work on a pending message with cause and returns the
+// message, or nil when the bot has already replied to it.
+func (p *pendingRequests) drop(channelID, ts string, cause error) *IncomingMessage {
+	key := pendingKey(channelID, ts)
+
+	p.mu.Lock()
+	req, ok := p.requests[key]
+	delete(p.requests, key)
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	req.cancel(cause)
+	return req.msg
+}
+
+// requestChanged reports whether the work in ctx was stopped because its
+// request was edited or deleted, so its reply is no longer wanted.
+func requestChanged(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return errors.Is(cause, errRequestEdited) || errors.Is(cause, errRequestDeleted)
+}
+
+// handleMessageChanged works on the new text of a request edited before the
+// bot replied to it, instead of the old one. Edits that don't change the
+// text, such as link previews being added, are ignored, and a channel
+// request edited to no longer mention the bot is dropped.
+func (b *Bot) handleMessageChanged(ctx context.Context, evt *slackevents.MessageEvent) {
+	edited := evt.Message
+	if edited == nil || edited.BotID != "" || b.teams.isBot(edited.User) {
+		return
+	}
+	if evt.PreviousMessage != nil && evt.PreviousMessage.Text == edited.Text {
+		return
+	}
+
+	text := edited.Text
+	mentioned := b.mentionsBot(text)
+	cause := errRequestEdited
+	if evt.ChannelType != "im" && !mentioned {
+		cause = errRequestDeleted
+	}
+
+	previous := b.pending.drop(evt.Channel, edited.TimeStamp, cause)
+	if previous == nil {
+		return
+	}
+	if cause == errRequestDeleted {
+		b.logger.Info("request no longer mentions the bot, dropped it", "channel", evt.Channel, "ts", edited.TimeStamp)
+		return
+	}
+
+	b.logger.Info("request was edited before the reply, reprocessing it", "channel", evt.Channel, "ts", edited.TimeStamp)
+	msg := *previous
+	msg.Text = text
+	if mentioned {
+		msg.Text = b.stripBotMention(text)
+	}
+	b.dispatch(ctx, &msg)
+}
+
+// handleMessageDeleted stops the work on a request deleted before the bot
+// replied to it.
+func (b *Bot) handleMessageDeleted(evt *slackevents.MessageEvent) {
+	if b.pending.drop(evt.Channel, evt.DeletedTimeStamp, errRequestDeleted) != nil {
+		b.logger.Info("request was deleted before the reply, dropped it", "channel", evt.Channel, "ts", evt.DeletedTimeStamp)
+	}
+}
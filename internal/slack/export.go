@@ -0,0 +1,142 @@
+// Exporting a thread's conversation as a file.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/trace"
+)
+
+// exportRe matches "export", "export markdown" and "export json".
+var exportRe = regexp.MustCompile(`(?i)^\s*export(?:\s+(markdown|md|json))?\s*$`)
+
+// conversationExport is a conversation exported as JSON.
+type conversationExport struct {
+	ConversationID string            `json:"conversation_id"`
+	ChannelID      string            `json:"channel_id"`
+	ExportedAt     time.Time         `json:"exported_at"`
+	Messages       []storage.Message `json:"messages"`
+	ToolCalls      []trace.Step      `json:"tool_calls,omitempty"`
+	// Diff is the checkout's changes since the default branch
+	Diff string `json:"diff,omitempty"`
+}
+
+// handleExport answers the export command with the thread's conversation as a
+// file. It reports whether the message was an export command.
+func (h *Handler) handleExport(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := exportRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	return h.export(ctx, conversationID, msg, match[1]), true
+}
+
+// export dumps a conversation (its messages, the tool calls traced for it and
+// the diff of the checkout) as a markdown file, or JSON when format is
+// "json". The export itself isn't recorded in the conversation.
+func (h *Handler) export(ctx context.Context, conversationID string, msg *IncomingMessage, format string) *OutgoingMessage {
+	reply := func(text string) *OutgoingMessage {
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}
+	}
+
+	conv, err := h.conversation.Conversation(ctx, conversationID)
+	if err != nil {
+		h.logger.Error("failed to load conversation for export", "conversation", conversationID, "error", err)
+		return reply(fmt.Sprintf("Sorry, I couldn't load the conversation: %v", err))
+	}
+	if conv == nil || len(conv.Messages) == 0 {
+		return reply("There's nothing to export yet: this conversation has no messages.")
+	}
+
+	export := conversationExport{
+		ConversationID: conversationID,
+		ChannelID:      conv.ChannelID,
+		ExportedAt:     time.Now(),
+		Messages:       conv.Messages,
+	}
+	if h.toolExecutor.traces != nil {
+		steps, err := h.toolExecutor.traces.Load(conversationID)
+		if err != nil {
+			h.logger.Warn("failed to load trace for export", "conversation", conversationID, "error", err)
+		}
+		for _, step := range steps {
+			if step.Kind == trace.KindTool {
+				export.ToolCalls = append(export.ToolCalls, step)
+			}
+		}
+	}
+	if e, err := h.executorFor(ctx); err == nil {
+		_, export.Diff = h.handoffWork(ctx, e)
+	} else {
+		h.logger.Warn("failed to open workspace for export", "conversation", conversationID, "error", err)
+	}
+
+	summary := fmt.Sprintf(":package: Exported this conversation: %d messages, %d tool calls", len(export.Messages), len(export.ToolCalls))
+	if export.Diff != "" {
+		summary += " and the diff so far"
+	}
+	out := reply(summary + ".")
+
+	if strings.EqualFold(format, "json") {
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return reply(fmt.Sprintf("Sorry, I couldn't encode the export: %v", err))
+		}
+		out.Files = []File{{Name: "conversation.json", Title: "Conversation export (JSON)", Content: string(data)}}
+		return out
+	}
+	out.Files = []File{{Name: "conversation.md", Title: "Conversation export", Content: export.markdown()}}
+	return out
+}
+
+// markdown renders the export as a markdown document.
+func (x conversationExport) markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Conversation " + x.ConversationID + "\n\n")
+	sb.WriteString(fmt.Sprintf("Channel %s, exported %s.\n", x.ChannelID, x.ExportedAt.UTC().Format(time.RFC3339)))
+
+	sb.WriteString("\n## Messages\n")
+	for _, m := range x.Messages {
+		author := "StormStack"
+		if m.Role == "user" {
+			switch {
+			case m.UserName != "":
+				author = m.UserName
+			case m.UserID != "":
+				author = m.UserID
+			default:
+				author = "User"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\n### %s, %s\n\n%s\n", author, m.Timestamp.UTC().Format(time.RFC3339), m.Content))
+	}
+
+	if len(x.ToolCalls) > 0 {
+		sb.WriteString("\n## Tool calls\n\n")
+		sb.WriteString("| Time | Tool | Input | Duration | Error |\n|---|---|---|---|---|\n")
+		for _, step := range x.ToolCalls {
+			sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s |\n",
+				step.Time.UTC().Format(time.RFC3339), step.Tool, markdownCell(step.Text),
+				step.Duration.Round(time.Millisecond), markdownCell(step.Error)))
+		}
+	}
+
+	if x.Diff != "" {
+		sb.WriteString("\n## Changes\n\n```diff\n" + strings.TrimRight(x.Diff, "\n") + "\n```\n")
+	}
+	return sb.String()
+}
+
+// markdownCell escapes text for a markdown table cell, on one line.
+func markdownCell(text string) string {
+	text = TruncateText(text, 200)
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", " ")
+}
@@ -0,0 +1,131 @@
+// Fast commits for bulk workflows.
+
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/codebase"
+)
+
+// Workflows a conversation can run, named in STORMSTACK_FAST_COMMIT_WORKFLOWS.
+const (
+	// WorkflowIssue implements an issue end to end
+	WorkflowIssue = "issue"
+	// WorkflowBackport backports a merged PR to a release branch
+	WorkflowBackport = "backport"
+	// WorkflowLogMigration converts log calls to structured logging
+	WorkflowLogMigration = "log_migration"
+	// WorkflowReview addresses the review comments on a PR
+	WorkflowReview = "review"
+)
+
+// workflows remembers the workflow each conversation runs. It is shared by
+// all tool executors.
+type workflows struct {
+	mu             sync.Mutex
+	byConversation map[string]string
+}
+
+// newWorkflows creates an empty workflow registry.
+func newWorkflows() *workflows {
+	return &workflows{byConversation: make(map[string]string)}
+}
+
+// set records the workflow a conversation runs.
+func (w *workflows) set(conversationID, workflow string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.byConversation[conversationID] = workflow
+}
+
+// get returns the workflow a conversation runs, or "" for ad hoc requests.
+func (w *workflows) get(conversationID string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.byConversation[conversationID]
+}
+
+// startWorkflow records the workflow the conversation in ctx runs.
+func (e *ToolExecutor) startWorkflow(ctx context.Context, workflow string) {
+	if info, ok := ConversationFromContext(ctx); ok {
+		e.workflows.set(info.ConversationID, workflow)
+	}
+}
+
+// fastCommitWorkflow returns the workflow of the conversation in ctx if it
+// is configured for fast commits, or "".
+func (e *ToolExecutor) fastCommitWorkflow(ctx context.Context) string {
+	info, ok := ConversationFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	workflow := e.workflows.get(info.ConversationID)
+	if workflow == "" || !slices.Contains(e.cfg.FastCommitWorkflows, workflow) {
+		return ""
+	}
+	return workflow
+}
+
+// formatForCommit formats the files a fast commit of files (every changed
+// file when empty) takes, as a hook would, and returns how many it
+// formatted.
+func (e *ToolExecutor) formatForCommit(ctx context.Context, files []string) (int, error) {
+	if len(files) == 0 {
+		var err error
+		if files, err = e.gitOps.ChangedFiles(ctx); err != nil {
+			return 0, fmt.Errorf("failed to list changed files: %w", err)
+		}
+	}
+
+	formatted := 0
+	writer := e.writerFor(ctx, false)
+	for _, path := range files {
+		if _, err := os.Stat(filepath.Join(e.writer.GetRepoPath(), path)); err != nil {
+			continue // Deleted
+		}
+		_, err := writer.Format(ctx, path)
+		switch {
+		case errors.Is(err, codebase.ErrNoFormatter), errors.Is(err, codebase.ErrFormatterMissing),
+			errors.Is(err, codebase.ErrGenerated), errors.Is(err, codebase.ErrProtected), errors.Is(err, codebase.ErrRestricted):
+			continue
+		case err != nil:
+			return 0, fmt.Errorf("not committed: %w", err)
+		}
+		formatted++
+	}
+	return formatted, nil
+}
+
+// commitFast commits without running git hooks, which can take minutes per
+// commit in bulk refactors. It compensates by doing in-process what the
+// hooks would check: the caller has formatted the files with
+// formatForCommit (formatted is how many), and it runs the lint command,
+// refusing to commit when the linter fails.
+func (e *ToolExecutor) commitFast(ctx context.Context, params claude.CommitParams, workflow string, formatted int) (string, error) {
+	linted := ""
+	if e.runner.HasLinter() {
+		result, err := e.runner.RunLint(ctx, "")
+		if err != nil {
+			return "", err
+		}
+		if !result.IsSuccess() {
+			return "Not committed: the linter found problems. Fix them and commit again.\n" + e.lintSummary(result), nil
+		}
+		linted = ", lint passed"
+	}
+
+	if err := e.gitOps.CommitNoVerify(ctx, params.Message, params.Files); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Committed without hooks (fast commit for the %s workflow: formatted %d files%s): %s",
+		workflow, formatted, linted, params.Message), nil
+}
@@ -4,22 +4,72 @@ package slack
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/activity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/approval"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/codebase"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/conflicts"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/database"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/embeddings"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/logs"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/metrics"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/outcomes"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/redact"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/repo"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/shadow"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/trace"
 )
 
 // Handler handles incoming messages and coordinates with Claude.
 type Handler struct {
 	conversation *claude.ConversationManager
 	toolExecutor *ToolExecutor
-	logger       *slog.Logger
+	learner      *claude.Learner
+	triager      *claude.Triager
+	approvals    *approval.Manager
+	workspaces   *workspaces
+	// tasks are the requests being worked on, which their senders may cancel
+	tasks *taskRegistry
+	// resets are the requests to forget a conversation awaiting confirmation
+	resets *pendingResets
+	// outcomes holds the bot PR outcomes reported by pr stats
+	outcomes *outcomes.Store
+	// announce posts messages the bot sends on its own, such as closing summaries
+	announce conflicts.Notifier
+	// sync syncs the repository with its remote for the Home tab
+	sync func(ctx context.Context) (bool, error)
+	// client is the Claude provider a reload may replace (nil when replaying)
+	client *reloadableClient
+	// reload reloads the configuration of every handler, for the reload
+	// command; reloaded is the configuration last applied, and global the
+	// same without the repository's configuration file. reloadMu serializes
+	// reloads
+	reload   func(ctx context.Context) ([]string, error)
+	reloaded atomic.Pointer[config.Config]
+	global   atomic.Pointer[config.Config]
+	reloadMu sync.Mutex
+	// conversationWorkspaces gives other conversations a worktree each in
+	// sandbox mode (nil when disabled)
+	conversationWorkspaces *workspaces
+	// repoConfig notices changes to the repository's configuration file
+	repoConfig *repoConfigWatch
+	repoPath   string
+	redactor   *redact.Redactor
+	logger     *slog.Logger
 }
 
 // NewHandler creates a new message handler.
@@ -27,31 +77,391 @@ func NewHandler(
 	cfg *config.Config,
 	repoPath string,
 	store storage.ConversationStore,
+	lessons storage.LessonStore,
+	tracker *conflicts.Tracker,
+	recorder *shadow.Recorder,
+	activityLog *activity.Log,
 	logger *slog.Logger,
-) *Handler {
-	// Create Claude client
-	claudeClient := claude.NewClient(cfg.AnthropicAPIKey)
+) (*Handler, error) {
+	// The repository's own configuration file, as committed, overrides the
+	// bot's
+	gitOps, err := git.NewOperations(repoPath, cfg.GitBackend)
+	if err != nil {
+		return nil, err
+	}
+	global := cfg
+	cfg = withRepoConfig(cfg, gitOps, logger)
+
+	// Create Claude client, or replay a recording in its place
+	cassette, err := openCassette(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		claudeClient claude.Client
+		reloadable   *reloadableClient
+	)
+	if cfg.ClaudeReplayFile != "" {
+		claudeClient = cassette.ReplayClient()
+	} else {
+		provider, err := newClaudeClient(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		// A configuration reload may switch the provider or model
+		reloadable = &reloadableClient{client: provider}
+		claudeClient = reloadable
+		if cassette != nil {
+			claudeClient = cassette.RecordClient(claudeClient)
+		}
+	}
+	if activityLog != nil {
+		claudeClient = &meteredClient{Client: claudeClient, log: activityLog}
+	}
+
+	// Trace each conversation's Claude and tool calls
+	var traces *trace.Store
+	if cfg.TraceDir != "" {
+		if traces, err = trace.NewStore(cfg.TraceDir, logger); err != nil {
+			return nil, err
+		}
+		claudeClient = &tracedClient{Client: claudeClient, traces: traces}
+	}
+
+	// Create the code hosting forge, review feedback learner and issue
+	// triager
+	forge, err := newForge(cfg, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	learner := claude.NewLearner(claudeClient, forge, lessons, cfg.LearnReviewers, cfg.ForgeBotUser, logger)
+	triager := claude.NewTriager(claudeClient, forge, logger)
+
+	// Gate risky actions behind human approval
+	approvals := approval.NewManager(cfg.Approvers, cfg.ApprovalTTL)
+
+	// Scrub secrets from tool output before it reaches Claude
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce the data handling policy at the tool layer
+	policy, err := codebase.NewPathPolicy(cfg.RestrictedPaths, repoPath, committedIgnoreFile(gitOps))
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Protect(cfg.ProtectedPaths); err != nil {
+		return nil, err
+	}
+
+	// Format written files so PRs pass format checks
+	formatter, err := codebase.NewFormatter(cfg.Formatters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep hand edits out of generated and vendored files
+	generated, err := codebase.NewGeneratedRules(cfg.GeneratedPaths, cfg.GeneratedMarkers)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create tool executor
-	toolExecutor := NewToolExecutor(repoPath, cfg, logger)
+	toolExecutor := NewToolExecutor(repoPath, cfg, gitOps, forge, tracker, learner, approvals, policy, redactor, recorder, logger)
+	toolExecutor.activity = activityLog
+	toolExecutor.traces = traces
+	toolExecutor.writer.UseFormatter(formatter)
+	toolExecutor.writer.UseGeneratedRules(generated)
+
+	// Have a second model critique changes before their PRs are opened
+	if cfg.PRSelfReview {
+		reviewerClient, err := newReviewerClient(cfg, claudeClient, activityLog, traces, logger)
+		if err != nil {
+			return nil, err
+		}
+		toolExecutor.reviewer = claude.NewReviewer(reviewerClient, logger)
+	}
+
+	// Index the repository with embeddings for semantic search
+	if cfg.SemanticSearch {
+		embedder := embeddings.NewClient(cfg.EmbeddingsURL, cfg.EmbeddingsAPIKey, cfg.EmbeddingsModel)
+		toolExecutor.semantic, err = codebase.NewSemanticIndex(toolExecutor.searcher, embedder, cfg.EmbeddingsModel, cfg.SemanticIndexFile, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read application logs from the observability backend
+	toolExecutor.logs, err = newLogsBackend(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Introspect the application's database, read-only
+	toolExecutor.db, err = openDatabase(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handler{
+		toolExecutor: toolExecutor,
+		learner:      learner,
+		triager:      triager,
+		approvals:    approvals,
+		tasks:        newTaskRegistry(),
+		resets:       newPendingResets(),
+		client:       reloadable,
+		repoConfig:   newRepoConfigWatch(gitOps),
+		repoPath:     repoPath,
+		redactor:     redactor,
+		logger:       logger,
+	}
+	h.global.Store(global)
+
+	// Workspaces get tool executors of their own, sharing everything but the checkout
+	newWorkspaceExecutor := func(path string) (*ToolExecutor, error) {
+		ops, err := git.NewOperations(path, git.BackendCLI)
+		if err != nil {
+			return nil, err
+		}
+		forge, err := newForge(cfg, path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each checkout is walked and matched by a policy of its own
+		settings := h.settings()
+		policy, err := codebase.NewPathPolicy(settings.RestrictedPaths, path, committedIgnoreFile(ops))
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.Protect(settings.ProtectedPaths); err != nil {
+			return nil, err
+		}
+
+		e := NewToolExecutor(path, cfg, ops, forge, tracker, learner, approvals, policy, redactor, recorder, logger.With("workspace", path))
+		e.observer, e.metrics, e.notify = toolExecutor.observer, toolExecutor.metrics, toolExecutor.notify
+		e.semantic = toolExecutor.semantic
+		e.questions = toolExecutor.questions
+		e.attachments = toolExecutor.attachments
+		e.cards, e.outputs = toolExecutor.cards, toolExecutor.outputs
+		e.workflows = toolExecutor.workflows
+		e.activity = toolExecutor.activity
+		e.traces = toolExecutor.traces
+		e.logs = toolExecutor.logs
+		e.db = toolExecutor.db
+		e.plans = toolExecutor.plans
+		e.reviewer = toolExecutor.reviewer
+		e.status = toolExecutor.status
+		e.writer.UseFormatter(formatter)
+		e.writer.UseGeneratedRules(generated)
+		e.useCommands(h.settings())
+		return e, nil
+	}
+
+	// Give each user a personal worktree in DMs
+	if cfg.DMWorkspaces {
+		worktrees, err := repo.NewWorktrees(repoPath, cfg.DMWorkspaceDir)
+		if err != nil {
+			return nil, err
+		}
+		h.workspaces = newWorkspaces(worktrees, newWorkspaceExecutor)
+	}
+	// Give other conversations a worktree each in sandbox mode
+	if cfg.ConversationWorkspaces {
+		dir := filepath.Join(cfg.WorkspacePath, conversationWorkspaceDir, filepath.Base(repoPath))
+		worktrees, err := repo.NewWorktrees(repoPath, dir)
+		if err != nil {
+			return nil, err
+		}
+		conversations, err := loadWorkspaceConversations(dir + ".json")
+		if err != nil {
+			return nil, err
+		}
+		h.conversationWorkspaces = newWorkspaces(worktrees, newWorkspaceExecutor)
+		h.conversationWorkspaces.limit = cfg.MaxConversationWorkspaces
+		h.conversationWorkspaces.quota = int64(cfg.WorkspaceQuotaMB) << 20
+		h.conversationWorkspaces.conversations = conversations
+		h.conversationWorkspaces.busy = func(key string) bool {
+			conversationID, ok := conversations.conversation(key)
+			return ok && h.tasks.active(conversationID)
+		}
+	}
+
+	execute := claude.ToolExecutor(h.executeTool)
+	if cassette != nil {
+		if cfg.ClaudeReplayFile != "" {
+			execute = cassette.ReplayTools()
+		} else {
+			execute = cassette.RecordTools(execute)
+		}
+	}
 
 	// Load system prompt
-	systemPrompt := claude.LoadSystemPrompt(repoPath, cfg.GuidelinesFile)
+	systemPrompt := redactor.Redact(claude.LoadSystemPrompt(repoPath, cfg.GuidelinesFile))
 
 	// Create conversation manager
-	conversation := claude.NewConversationManager(
+	h.conversation = claude.NewConversationManager(
 		claudeClient,
 		store,
+		lessons,
 		systemPrompt,
-		toolExecutor.Execute,
+		h.toolExecutor.tools.definitions(),
+		execute,
 		logger,
 	)
+	h.conversation.SetBudget(requestBudget(cfg))
+	h.conversation.SetParallelism(toolParallelism(cfg), h.toolExecutor.tools.concurrent)
+	h.toolExecutor.plans = h.conversation
 
-	return &Handler{
-		conversation: conversation,
-		toolExecutor: toolExecutor,
-		logger:       logger,
+	return h, nil
+}
+
+// requestBudget returns the budget of each request in a conversation.
+func requestBudget(cfg *config.Config) claude.Budget {
+	return claude.Budget{
+		MaxTokens:     cfg.BudgetMaxTokens,
+		MaxIterations: cfg.BudgetMaxIterations,
+		MaxDuration:   cfg.BudgetMaxDuration,
+	}
+}
+
+// toolParallelism returns how many of a turn's tool calls run at once.
+// Recordings and replays pair tool calls with results in order, so they run
+// them one at a time.
+func toolParallelism(cfg *config.Config) int {
+	if cfg.ClaudeRecordFile != "" || cfg.ClaudeReplayFile != "" {
+		return 1
+	}
+	return cfg.ToolParallelism
+}
+
+// newClaudeClient creates the Claude client for the configured backend.
+func newClaudeClient(cfg *config.Config, logger *slog.Logger) (claude.Client, error) {
+	logger.Info("using LLM provider", "provider", cfg.ClaudeBackend, "model", cfg.ClaudeModel)
+
+	region := cfg.VertexRegion
+	if cfg.ClaudeBackend == config.BackendBedrock {
+		region = cfg.BedrockRegion
+	}
+
+	return claude.NewProviderClient(context.Background(), cfg.ClaudeBackend, claude.ProviderConfig{
+		Model:      cfg.ClaudeModel,
+		APIKey:     cfg.AnthropicAPIKey,
+		BaseURL:    cfg.ClaudeBaseURL,
+		Region:     region,
+		ProjectID:  cfg.VertexProjectID,
+		Profile:    cfg.BedrockProfile,
+		FakeScript: cfg.ClaudeFakeScript,
+		Retry: claude.RetryPolicy{
+			MaxRetries: cfg.ClaudeMaxRetries,
+			BaseDelay:  cfg.ClaudeRetryBaseWait,
+			MaxDelay:   cfg.ClaudeRetryMaxWait,
+		},
+	}, logger)
+}
+
+// newForge creates the configured code hosting forge.
+func newForge(cfg *config.Config, repoPath string) (git.Forge, error) {
+	token := cfg.ForgeToken
+	if token == "" && cfg.Forge == git.ForgeGitHub {
+		token = cfg.GitHubToken
+	}
+
+	forge, err := git.NewForge(repoPath, git.ForgeConfig{
+		Kind:       cfg.Forge,
+		Token:      token,
+		BaseURL:    cfg.ForgeURL,
+		Project:    cfg.ForgeProject,
+		GitBackend: cfg.GitBackend,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s forge: %w", cfg.Forge, err)
+	}
+	return forge, nil
+}
+
+// newRedactor creates the configured secret redactor, or nil when redaction is disabled.
+func newRedactor(cfg *config.Config) (*redact.Redactor, error) {
+	if !cfg.RedactionEnabled {
+		return nil, nil
+	}
+
+	var patterns []string
+	if cfg.RedactPatternsFile != "" {
+		var err error
+		if patterns, err = redact.LoadPatterns(cfg.RedactPatternsFile); err != nil {
+			return nil, err
+		}
+	}
+	return redact.New(patterns, cfg.RedactHostnames)
+}
+
+// openCassette opens the configured record or replay cassette, if any.
+func openCassette(cfg *config.Config, logger *slog.Logger) (*claude.Cassette, error) {
+	switch {
+	case cfg.ClaudeReplayFile != "":
+		logger.Warn("replaying recorded Claude interactions", "file", cfg.ClaudeReplayFile)
+		return claude.LoadCassette(cfg.ClaudeReplayFile)
+	case cfg.ClaudeRecordFile != "":
+		logger.Info("recording Claude interactions", "file", cfg.ClaudeRecordFile)
+		return claude.NewCassette(cfg.ClaudeRecordFile)
+	}
+	return nil, nil
+}
+
+// ObserveTools registers a callback invoked after every tool call.
+func (h *Handler) ObserveTools(fn ToolObserver) {
+	h.toolExecutor.observer = fn
+}
+
+// UseMetrics records per-tool metrics in registry.
+func (h *Handler) UseMetrics(registry *metrics.Registry) {
+	h.toolExecutor.metrics = registry
+}
+
+// NotifyWith sets how warnings such as slow tool calls are posted to threads.
+func (h *Handler) NotifyWith(notify conflicts.Notifier) {
+	h.toolExecutor.notify = notify
+}
+
+// AnnounceWith sets how messages nobody asked for, such as closing
+// summaries, are posted to threads. They are posted like warnings by default.
+func (h *Handler) AnnounceWith(announce conflicts.Notifier) {
+	h.announce = announce
+}
+
+// GitOps returns the git operations for the repository.
+func (h *Handler) GitOps() git.Operations {
+	return h.toolExecutor.gitOps
+}
+
+// Forge returns the code hosting forge.
+func (h *Handler) Forge() git.Forge {
+	return h.toolExecutor.forge
+}
+
+// UpdateSemanticIndex brings the semantic search index up to date with the
+// checkout. It does nothing when semantic search is disabled.
+func (h *Handler) UpdateSemanticIndex(ctx context.Context) error {
+	if h.toolExecutor.semantic == nil {
+		return nil
 	}
+	_, err := h.toolExecutor.semantic.Update(ctx)
+	return err
+}
+
+// PruneTraces deletes the traces of conversations idle since before. It does
+// nothing when tracing is disabled.
+func (h *Handler) PruneTraces(before time.Time) error {
+	return h.toolExecutor.traces.Prune(before)
+}
+
+// Learner returns the review feedback learner.
+func (h *Handler) Learner() *claude.Learner {
+	return h.learner
 }
 
 // HandleMessage processes an incoming message.
@@ -68,375 +478,1600 @@ func (h *Handler) HandleMessage(ctx context.Context, msg *IncomingMessage) (*Out
 		conversationID = msg.ChannelID + "-" + msg.UserID
 	}
 
-	// Process with Claude
-	response, err := h.conversation.ProcessMessage(ctx, conversationID, msg.ChannelID, msg.Text)
+	// Pick up edits to the repository's configuration file
+	h.checkRepoConfig()
+
+	// Make the conversation available to tools
+	ctx = WithConversation(ctx, ConversationInfo{
+		ConversationID: conversationID,
+		ChannelID:      msg.ChannelID,
+		ThreadTS:       msg.ThreadTS,
+		UserID:         msg.UserID,
+		Workspace:      workspaceOwner(h.workspaces, msg),
+	})
+	h.toolExecutor.activity.Record(activity.Event{
+		Kind:           activity.KindMessage,
+		ChannelID:      msg.ChannelID,
+		UserID:         msg.UserID,
+		ConversationID: conversationID,
+	})
+
+	// Slash subcommands are answered without Claude, or rewritten into requests
+	if reply, ok := h.handleSubcommand(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+
+	// Full outputs asked for with a card's button are uploaded without Claude
+	if reply, ok := h.handleShowOutput(msg); ok {
+		return reply, nil
+	}
+
+	// Approval replies are handled without Claude
+	if reply, ok := h.handleApproval(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+
+	// Built-in commands are handled without Claude
+	if reply, ok := h.handleHelp(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleTrace(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleExport(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleReset(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleUsage(msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handlePRStats(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleTriage(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleHealthReport(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleWorkspaceReset(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleHandoff(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+	if reply, ok := h.handleResume(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+
+	// Plan approvals are recorded before Claude goes ahead with the plan
+	if reply, ok := h.handlePlan(ctx, conversationID, msg); ok {
+		return reply, nil
+	}
+
+	// Expand shorthand requests into explicit instructions
+	text := expandIssueRequest(msg.Text)
+	if text != msg.Text {
+		h.toolExecutor.workflows.set(conversationID, WorkflowIssue)
+	}
+	if text == msg.Text {
+		if text = expandLogMigration(text); text != msg.Text {
+			h.toolExecutor.workflows.set(conversationID, WorkflowLogMigration)
+		}
+	}
+	if text == msg.Text {
+		text = h.expandPRReview(ctx, text)
+	}
+	if text == msg.Text {
+		text = expandPlanApproval(text)
+	}
+
+	// Give Claude the discussion in the thread it was mentioned in
+	if msg.ThreadContext != "" {
+		text = withThreadContext(msg.ThreadContext, text)
+	}
+
+	// Process with Claude, tracing the task; the sender may stop it with cancel
+	h.toolExecutor.traces.Record(conversationID, trace.Step{Kind: trace.KindRequest, Text: msg.Text})
+	taskCtx, task, finish := h.tasks.start(ctx, msg.UserID, conversationID, msg.ChannelID)
+	response, err := h.conversation.ProcessMessage(taskCtx, conversationID, msg.ChannelID, messageAuthor(msg), text)
+	finish()
+	q, asked := h.toolExecutor.questions.take(conversationID)
+	files := h.toolExecutor.attachments.take(conversationID)
+	cards := h.toolExecutor.cards.take(conversationID)
+	if err != nil && task.cancelled.Load() {
+		return &OutgoingMessage{Text: ":octagonal_sign: Stopped, as you asked.", ThreadTS: msg.ThreadTS}, nil
+	}
+	if err != nil {
+		h.logger.Error("failed to process message", "error", err)
+		return &OutgoingMessage{
+			Text:     fmt.Sprintf("Sorry, I encountered an error: %v", err),
+			ThreadTS: msg.ThreadTS,
+		}, nil
+	}
+
+	reply := &OutgoingMessage{
+		Text:     response,
+		ThreadTS: msg.ThreadTS,
+		Files:    files,
+	}
+	// Show the results the tools rendered as cards, and offer the answers to
+	// a clarifying question as buttons
+	if asked {
+		if !strings.Contains(response, q.text) {
+			reply.Text = strings.TrimSpace(response + "\n\n" + q.text)
+		}
+		reply.Blocks = replyBlocks(reply.Text, cards, &q)
+	} else if len(cards) > 0 {
+		reply.Blocks = replyBlocks(reply.Text, cards, nil)
+	}
+	return reply, nil
+}
+
+// issueRequestRe matches requests like "work on issue #42" or "fix issue 42".
+var issueRequestRe = regexp.MustCompile(`(?is)^\s*(?:work on|fix|implement|resolve)\s+issue\s+#?(\d+)\b(.*)$`)
+
+// expandIssueRequest turns a short "work on issue #N" request into explicit
+// instructions for the issue-to-PR workflow.
+func expandIssueRequest(text string) string {
+	match := issueRequestRe.FindStringSubmatch(text)
+	if match == nil {
+		return text
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Please work on GitHub issue #%s end to end:\n", match[1]))
+	sb.WriteString(fmt.Sprintf("1. Call work_on_issue with number %s to fetch it and create a branch.\n", match[1]))
+	sb.WriteString("2. Investigate the codebase and implement a fix, with tests.\n")
+	sb.WriteString("3. Run the build and tests.\n")
+	sb.WriteString("4. Commit, push, and open a PR with create_pr that references the issue.\n")
+	if extra := strings.TrimSpace(match[2]); extra != "" {
+		sb.WriteString("\nAdditional instructions: " + extra + "\n")
+	}
+	return sb.String()
+}
+
+// logMigrationRe matches requests like "migrate logging in internal/storage".
+var logMigrationRe = regexp.MustCompile(`(?is)^\s*migrate\s+(?:the\s+)?(?:logging|logs|log calls)\s+(?:in|for|of|under)\s+(\S+)(.*)$`)
+
+// expandLogMigration turns a short "migrate logging in <path>" request into
+// explicit instructions for migrating a package to structured logging, one
+// reviewable pull request per chunk of calls.
+func expandLogMigration(text string) string {
+	match := logMigrationRe.FindStringSubmatch(text)
+	if match == nil {
+		return text
+	}
+	path := strings.Trim(match[1], "`'\"")
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Please migrate the logging in %s to our structured logging API:\n", path))
+	sb.WriteString(fmt.Sprintf("1. Call find_log_calls with path %q to find the unstructured log calls, grouped into chunks.\n", path))
+	sb.WriteString("2. For each chunk, in order:\n")
+	sb.WriteString("   a. Create a branch from the latest default branch.\n")
+	sb.WriteString("   b. Convert every call in the chunk's files: keep the message constant and move interpolated values into key-value attributes, keeping the level and behavior (e.g. exits after fatal calls).\n")
+	sb.WriteString("   c. Leave calls that can't be converted cleanly unchanged and note them.\n")
+	sb.WriteString("   d. Run the build and tests, and fix anything the migration broke.\n")
+	sb.WriteString(fmt.Sprintf("   e. Commit, push, and open a PR with create_pr titled \"Structured logging in %s (part n of N)\", listing the converted files and any calls left alone.\n", path))
+	sb.WriteString("3. Finish with a summary linking every PR, with the calls that still need a human.\n")
+	if extra := strings.TrimSpace(match[2]); extra != "" {
+		sb.WriteString("\nAdditional instructions: " + extra + "\n")
+	}
+	return sb.String()
+}
+
+// triageRe matches "triage", "triage 50" and "triage apply".
+var triageRe = regexp.MustCompile(`(?i)^\s*triage(?:\s+(apply|\d+))?\s*$`)
+
+// handleTriage runs the issue triage command. Reports are kept per channel
+// and only written to GitHub on "triage apply". It reports whether the message
+// was a triage command.
+func (h *Handler) handleTriage(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := triageRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	reply := func(text string) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	}
+
+	if strings.EqualFold(match[1], "apply") {
+		// Triage writes to every issue, so it needs an approver even where
+		// anyone may approve actions in their own threads
+		if !h.approvals.IsApprover(msg.UserID) {
+			out, _ := reply("Sorry, only the configured approvers (`STORMSTACK_APPROVERS`) can apply triage.")
+			out.Visibility = h.private()
+			return out, true
+		}
+		if h.toolExecutor.shadow != nil {
+			h.toolExecutor.shadow.Record(shadow.Entry{
+				Kind:      shadow.KindTool,
+				ChannelID: msg.ChannelID,
+				Tool:      "triage_apply",
+			})
+			return reply("Shadow mode: recorded the triage instead of applying it.")
+		}
+
+		updated, err := h.triager.Apply(ctx, msg.ChannelID)
+		if err != nil {
+			return reply(fmt.Sprintf("Failed to apply triage (%d issues updated): %v", updated, err))
+		}
+		return reply(fmt.Sprintf("Applied triage to %d issues.", updated))
+	}
+
+	limit := claude.DefaultTriageLimit
+	if match[1] != "" {
+		fmt.Sscanf(match[1], "%d", &limit)
+	}
+	limit = min(limit, claude.MaxTriageLimit)
+
+	report, err := h.triager.Triage(ctx, msg.ChannelID, limit)
+	if err != nil {
+		h.logger.Error("failed to triage issues", "error", err)
+		return reply(fmt.Sprintf("Sorry, triage failed: %v", err))
+	}
+	return reply(claude.FormatTriageReport(report))
+}
+
+// prURLRe matches GitHub and Bitbucket pull request and GitLab merge request
+// URLs, including inside Slack's <url|label> links. Matches are checked
+// against the configured forge before they are used.
+var prURLRe = regexp.MustCompile(`https://github\.com/[\w.-]+/[\w.-]+/pull/\d+|https://[\w.-]+(?:/[\w.-]+)+/-/merge_requests/\d+|https://bitbucket\.org/[\w.-]+/[\w.-]+/pull-requests/\d+`)
+
+// expandPRReview turns a message containing a PR link into a code
+// review request with the PR's details and diff attached, so pasting a link is
+// enough to start a review.
+func (h *Handler) expandPRReview(ctx context.Context, text string) string {
+	url := prURLRe.FindString(text)
+	if url == "" {
+		return text
+	}
+	// Only PRs of the configured repository are reviewed
+	if _, err := h.toolExecutor.forge.PRNumber(ctx, url); err != nil {
+		h.logger.Info("ignoring link to a PR of another repository", "pr", url, "error", err)
+		return text
+	}
+
+	h.logger.Info("detected PR link, starting review", "pr", url)
+	pr, err := h.toolExecutor.forge.GetPRForReview(ctx, url)
+	if err != nil {
+		h.logger.Warn("failed to fetch linked PR", "pr", url, "error", err)
+		return text
+	}
+
+	h.toolExecutor.addCard(ctx, "pr", h.toolExecutor.renderPR(pr))
+
+	var sb strings.Builder
+	sb.WriteString(text)
+	sb.WriteString("\n\n---\n")
+	sb.WriteString("The message above links a pull request. Unless it asks for something else, review it: ")
+	sb.WriteString("check correctness, edge cases, tests, security and consistency with the codebase (read surrounding code where the diff isn't enough), ")
+	sb.WriteString("then reply with a summary and specific findings. Only post the review on GitHub if asked to.\n\n")
+	sb.WriteString(git.FormatPRForReview(pr))
+	return sb.String()
+}
+
+// approvalReplyRe matches a reply approving or rejecting a pending action.
+var approvalReplyRe = regexp.MustCompile(`(?i)^\s*(approve|approved|cancel|deny|denied|reject)\s*[.!]?\s*$`)
+
+// handleApproval resolves the conversation's pending approval request when the
+// message approves or rejects it. It reports whether the message was handled.
+func (h *Handler) handleApproval(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := approvalReplyRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	req, ok := h.approvals.Pending(conversationID)
+	if !ok {
+		return nil, false
+	}
+
+	// Only someone already in the thread may approve or reject, not a passer-by
+	participants := h.participantIDs(ctx, conversationID)
+
+	var text string
+	visibility := VisibilityPublic
+	switch strings.ToLower(match[1]) {
+	case "approve", "approved":
+		if _, err := h.approvals.Approve(conversationID, msg.UserID, participants); err != nil {
+			switch {
+			case errors.Is(err, approval.ErrSelfApproval):
+				text = fmt.Sprintf("<@%s> asked for this, so someone else has to approve it. Still waiting for another participant to reply `approve`.", msg.UserID)
+			case errors.Is(err, approval.ErrNotParticipant):
+				text = fmt.Sprintf("<@%s> hasn't taken part in this thread, so can't approve this. Still waiting for a participant to reply `approve`.", msg.UserID)
+			default:
+				text = fmt.Sprintf("<@%s> is not authorized to approve this. Still waiting for an approver to reply `approve`.", msg.UserID)
+			}
+			// Refusals concern the sender alone
+			visibility = h.private()
+			break
+		}
+
+		h.logger.Info("action approved", "tool", req.Tool, "approver", msg.UserID, "requester", req.RequestedBy)
+		result, err := h.executeTool(approval.WithApproved(ctx), req.Tool, req.Input)
+		if err != nil {
+			text = fmt.Sprintf("Approved by <@%s>, but %s failed: %v", msg.UserID, req.Summary, err)
+		} else {
+			text = fmt.Sprintf("Approved by <@%s>. %s", msg.UserID, result)
+		}
+	default:
+		if _, err := h.approvals.Reject(conversationID, msg.UserID, participants); err != nil {
+			switch {
+			case errors.Is(err, approval.ErrNotParticipant):
+				text = fmt.Sprintf("<@%s> hasn't taken part in this thread, so can't cancel this. Still waiting for a participant to reply `approve`.", msg.UserID)
+			default:
+				text = fmt.Sprintf("<@%s> is not authorized to cancel this. Still waiting for an approver to reply `approve`.", msg.UserID)
+			}
+			visibility = h.private()
+			break
+		}
+		text = "Cancelled: " + req.Summary
+	}
+
+	h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+	return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS, Visibility: visibility}, true
+}
+
+// participantIDs returns the users who have taken part in a conversation.
+func (h *Handler) participantIDs(ctx context.Context, conversationID string) []string {
+	var ids []string
+	for _, p := range h.conversation.Participants(ctx, conversationID) {
+		ids = append(ids, p.UserID)
+	}
+	return ids
+}
+
+// messageAuthor returns the sender of a message.
+func messageAuthor(msg *IncomingMessage) storage.Participant {
+	return storage.Participant{UserID: msg.UserID, Name: msg.UserName}
+}
+
+// ToolObserver is notified after every tool call with its result.
+type ToolObserver func(name string, input json.RawMessage, result string, err error)
+
+// ToolExecutor executes tools for Claude.
+type ToolExecutor struct {
+	reader    *codebase.Reader
+	project   codebase.Project
+	writer    *codebase.Writer
+	searcher  *codebase.Searcher
+	semantic  *codebase.SemanticIndex
+	policy    *codebase.PathPolicy
+	runner    *executor.Runner
+	gitOps    git.Operations
+	forge     git.Forge
+	tracker   *conflicts.Tracker
+	learner   *claude.Learner
+	approvals *approval.Manager
+	shadow    *shadow.Recorder
+	redactor  *redact.Redactor
+	cfg       *config.Config
+	logger    *slog.Logger
+
+	// issues maps conversation IDs to the issue being worked on
+	issuesMu sync.Mutex
+	issues   map[string]int
+	// backports maps conversation IDs to the backport being prepared
+	backportsMu sync.Mutex
+	backports   map[string]backport
+	// testCache holds test results by code state
+	testCache *executor.TestCache
+	// verified holds the latest build and test results, for
+	// STORMSTACK_VERIFY_BEFORE_COMMIT
+	verified *verification
+	// questions holds the clarifying questions waiting to be shown
+	questions *questions
+	// artifacts keeps the reports builds and tests generate
+	artifacts *executor.ArtifactStore
+	// attachments holds the files waiting to be uploaded with replies
+	attachments *attachments
+	// cards holds the Block Kit renderings of results waiting to be shown
+	// with replies, and outputs the full outputs their buttons upload
+	cards   *cards
+	outputs *outputs
+	// workflows records the workflow each conversation runs, for fast commits
+	workflows *workflows
+	// activity records tool calls for the admin digest
+	activity *activity.Log
+	// traces records each conversation's tool calls for the trace command
+	traces *trace.Store
+	// logs is the backend query_logs reads application logs from
+	logs logs.Backend
+	// db is the database describe_database and explain_query introspect
+	db *database.DB
+	// plans keeps the plans proposed with propose_plan
+	plans planStore
+	// reviewer critiques changes before create_pr opens their PRs
+	reviewer *claude.Reviewer
+
+	observer ToolObserver
+	metrics  *metrics.Registry
+	notify   conflicts.Notifier
+	// status streams the output of long-running commands to threads
+	status StatusPoster
+
+	// tools are the tools enabled in this deployment
+	tools *toolRegistry
+	// handler is execute wrapped in the middleware chain
+	handler ToolHandler
+}
+
+// NewToolExecutor creates a new tool executor.
+func NewToolExecutor(
+	repoPath string,
+	cfg *config.Config,
+	gitOps git.Operations,
+	forge git.Forge,
+	tracker *conflicts.Tracker,
+	learner *claude.Learner,
+	approvals *approval.Manager,
+	policy *codebase.PathPolicy,
+	redactor *redact.Redactor,
+	recorder *shadow.Recorder,
+	logger *slog.Logger,
+) *ToolExecutor {
+	e := &ToolExecutor{
+		reader:    codebase.NewReader(repoPath, policy),
+		writer:    codebase.NewWriter(repoPath, policy),
+		searcher:  codebase.NewSearcher(repoPath, policy),
+		policy:    policy,
+		project:   codebase.DetectProject(repoPath),
+		gitOps:    gitOps,
+		forge:     forge,
+		tracker:   tracker,
+		learner:   learner,
+		approvals: approvals,
+		shadow:    recorder,
+		redactor:  redactor,
+		cfg:       cfg,
+		logger:    logger,
+		issues:    make(map[string]int),
+		backports: make(map[string]backport),
+		questions: newQuestions(),
+		workflows: newWorkflows(),
+		testCache: executor.NewTestCache(cfg.TestCacheDir),
+		verified:  &verification{},
+		artifacts: executor.NewArtifactStore(cfg.ArtifactsDir, cfg.ArtifactPatterns),
+
+		attachments: newAttachments(),
+		cards:       newCards(),
+		outputs:     newOutputs(),
+	}
+	e.runner = executor.NewRunner(repoPath, "", "", "")
+	e.useCommands(cfg)
+	e.runner.SetLimits(cfg.CommandTimeout, cfg.CommandMaxOutput)
+	e.tools = newToolRegistry(cfg, gitOps, logger)
+
+	// Cross-cutting behaviour, outermost first
+	e.handler = Chain(e.execute,
+		LoggingMiddleware(logger),
+		e.traceMiddleware,
+		e.dryRunMiddleware,
+		AuthMiddleware(e.tools.gates(e), e.requestApproval),
+		RateLimitMiddleware(cfg.ToolRateLimit, time.Minute),
+		e.metricsMiddleware,
+		e.progressMiddleware,
+		e.auditMiddleware,
+		RedactionMiddleware(redactor),
+	)
+	return e
+}
+
+// Execute runs a tool call through the middleware chain.
+func (e *ToolExecutor) Execute(ctx context.Context, name string, input json.RawMessage) (string, error) {
+	return e.handler(ctx, name, input)
+}
+
+// execute dispatches a tool call to its implementation in the registry.
+func (e *ToolExecutor) execute(ctx context.Context, name string, input json.RawMessage) (string, error) {
+	tool, ok := e.tools.lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	return tool.Run(e, ctx, input)
+}
+
+// Tool implementations
+
+func (e *ToolExecutor) readFile(input json.RawMessage) (string, error) {
+	var params claude.ReadFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	var content string
+	var err error
+	switch {
+	case params.StartLine > 0 || params.EndLine > 0:
+		content, err = e.reader.ReadFileLines(params.Path, params.StartLine, params.EndLine)
+	case e.cfg.ReadPageLines > 0:
+		// Files that fit on one page are returned whole, as before
+		var page *codebase.FilePage
+		page, err = e.reader.ReadFilePage(params.Path, params.Cursor, e.cfg.ReadPageLines)
+		if err == nil && params.Cursor == 0 && page.NextCursor == 0 {
+			content, err = e.reader.ReadFile(params.Path)
+		} else if err == nil {
+			content = page.String()
+		}
+	default:
+		content, err = e.reader.ReadFile(params.Path)
+	}
+
+	// Describe large and binary files instead of failing
+	var unreadable *codebase.UnreadableFileError
+	if errors.As(err, &unreadable) {
+		return unreadable.Error(), nil
+	}
+	return content, err
+}
+
+func (e *ToolExecutor) listFiles(input json.RawMessage) (string, error) {
+	var params claude.ListFilesParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	files, err := e.searcher.ListFiles(params.Pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) == 0 {
+		return "No files found matching pattern: " + params.Pattern, nil
+	}
+
+	return fmt.Sprintf("Found %d files:\n%s", len(files), joinLines(files)), nil
+}
+
+func (e *ToolExecutor) searchCode(input json.RawMessage) (string, error) {
+	var params claude.SearchCodeParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	results, err := e.searcher.SearchCode(params.Pattern, params.Path, params.CaseSensitive, params.ContextLines, params.MaxResults)
+	if err != nil {
+		return "", err
+	}
+
+	if len(results) == 0 {
+		return "No matches found for pattern: " + params.Pattern, nil
+	}
+
+	return fmt.Sprintf("Found %d matches:\n%s", len(results), codebase.FormatSearchResults(results)), nil
+}
+
+func (e *ToolExecutor) getTree(input json.RawMessage) (string, error) {
+	var params claude.GetTreeParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	return e.searcher.GetTree(params.Path, params.MaxDepth)
+}
+
+func (e *ToolExecutor) findDefinition(input json.RawMessage) (string, error) {
+	var params claude.FindDefinitionParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	symbols, err := e.searcher.FindDefinition(params.Symbol, params.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if len(symbols) == 0 {
+		return fmt.Sprintf("No Go or Java definition found for: %s", params.Symbol), nil
+	}
+
+	return fmt.Sprintf("Found %d definition(s):\n%s", len(symbols), codebase.FormatSymbols(symbols)), nil
+}
+
+func (e *ToolExecutor) findReferences(input json.RawMessage) (string, error) {
+	var params claude.FindReferencesParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	refs, err := e.searcher.FindReferences(params.Symbol, params.Path, params.MaxResults)
+	if err != nil {
+		return "", err
+	}
+
+	if len(refs) == 0 {
+		return fmt.Sprintf("No Go or Java references found for: %s", params.Symbol), nil
+	}
+
+	return fmt.Sprintf("Found %d reference(s):\n%s", len(refs), codebase.FormatReferences(refs)), nil
+}
+
+func (e *ToolExecutor) getOutline(input json.RawMessage) (string, error) {
+	var params claude.GetOutlineParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	return e.searcher.Outline(params.Path)
+}
+
+func (e *ToolExecutor) semanticSearch(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.SemanticSearchParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if e.semantic == nil {
+		return "", fmt.Errorf("semantic search is not enabled (set STORMSTACK_SEMANTIC_SEARCH=true); use search_code instead")
+	}
+
+	results, err := e.semantic.Search(ctx, params.Query, params.Path, params.MaxResults)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No code found for: %s", params.Query), nil
+	}
+
+	return fmt.Sprintf("Found %d matching chunk(s), best first:\n\n%s", len(results), codebase.FormatSemanticResults(results)), nil
+}
+
+func (e *ToolExecutor) findLogCalls(input json.RawMessage) (string, error) {
+	var params claude.FindLogCallsParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	chunkSize := params.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = e.cfg.LogMigrationChunkSize
+	}
+
+	chunks, err := e.searcher.FindLogCalls(e.cfg.LogMigrationPatterns, params.Path, chunkSize)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Structured logging API: %s\n\n%s", e.cfg.LogMigrationTarget, codebase.FormatLogCallChunks(chunks)), nil
+}
+
+// approvalSnippetSize bounds the text of a change shown to approvers.
+const approvalSnippetSize = 500
+
+// writeSummary asks for approval of writes to write-protected paths only.
+func (e *ToolExecutor) writeSummary(input json.RawMessage) (string, error) {
+	var params claude.WriteFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+	if !e.policy.Protected(params.Path) {
+		return "", nil
+	}
+	return fmt.Sprintf("write of %d bytes to write-protected %s", len(params.Content), params.Path), nil
+}
+
+// editSummary asks for approval of edits to write-protected paths only,
+// showing the change.
+func (e *ToolExecutor) editSummary(input json.RawMessage) (string, error) {
+	var params claude.EditFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+	if !e.policy.Protected(params.Path) {
+		return "", nil
+	}
+	return fmt.Sprintf("edit of write-protected %s, replacing\n```\n%s\n```\nwith\n```\n%s\n```",
+		params.Path, TruncateText(params.OldText, approvalSnippetSize), TruncateText(params.NewText, approvalSnippetSize)), nil
+}
+
+// changesSummary asks for approval of change sets touching write-protected
+// paths only, listing the protected files.
+func (e *ToolExecutor) changesSummary(input json.RawMessage) (string, error) {
+	var params claude.ApplyChangesParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	var protected []string
+	for _, change := range params.Changes {
+		if e.policy.Protected(change.Path) {
+			protected = append(protected, fmt.Sprintf("%s of %s", change.Action, change.Path))
+		}
+	}
+	if len(protected) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("change set of %d changes, including write-protected files: %s",
+		len(params.Changes), strings.Join(protected, ", ")), nil
+}
+
+func (e *ToolExecutor) writeFile(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.WriteFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	writer := e.writerFor(ctx, params.OverrideGenerated)
+	if err := writer.WriteFile(params.Path, params.Content); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(params.Content), params.Path) + formatNote(ctx, writer, params.Path), nil
+}
+
+func (e *ToolExecutor) editFile(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.EditFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	writer := e.writerFor(ctx, params.OverrideGenerated)
+	if err := writer.EditFile(params.Path, params.OldText, params.NewText); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Successfully edited %s", params.Path) + formatNote(ctx, writer, params.Path), nil
+}
+
+func (e *ToolExecutor) formatFile(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.FormatFileParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	formatter, err := e.writerFor(ctx, false).Format(ctx, params.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Formatted %s with %s", params.Path, formatter), nil
+}
+
+// formatNote formats a file just written and describes the outcome for the
+// tool result. Files without an installed formatter are left alone quietly;
+// a formatter rejecting the file, e.g. for a syntax error, does not undo the
+// write, but its output is reported so Claude can fix the file.
+func formatNote(ctx context.Context, writer *codebase.Writer, path string) string {
+	formatter, err := writer.Format(ctx, path)
+	switch {
+	case errors.Is(err, codebase.ErrNoFormatter) || errors.Is(err, codebase.ErrFormatterMissing):
+		return ""
+	case err != nil:
+		return fmt.Sprintf("\nThe file was written but could not be formatted: %v", err)
+	default:
+		return fmt.Sprintf(" (formatted with %s)", formatter)
+	}
+}
+
+func (e *ToolExecutor) applyChanges(ctx context.Context, input json.RawMessage) (string, error) {
+	params := claude.ApplyChangesParams{Check: "build"}
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	changes := make([]codebase.Change, len(params.Changes))
+	for i, change := range params.Changes {
+		changes[i] = codebase.Change{
+			Action:  change.Action,
+			Path:    change.Path,
+			Content: change.Content,
+			OldText: change.OldText,
+			NewText: change.NewText,
+		}
+	}
+
+	writer := e.writerFor(ctx, params.OverrideGenerated)
+	changeSet, err := writer.NewChangeSet(changes)
+	if err != nil {
+		return "", fmt.Errorf("no changes were applied: %w", err)
+	}
+	if err := changeSet.Apply(); err != nil {
+		return "", err
+	}
+
+	var notes strings.Builder
+	for _, path := range changeSet.Written() {
+		if note := strings.TrimSpace(formatNote(ctx, writer, path)); note != "" {
+			notes.WriteString(fmt.Sprintf("- %s: %s\n", path, note))
+		}
+	}
+
+	var result *executor.CommandResult
+	switch params.Check {
+	case "build":
+		result, err = e.runner.RunBuild(ctx, "")
+	case "tests":
+		result, err = e.runner.RunTests(ctx, "")
+	}
+	if err == nil && (result == nil || result.IsSuccess()) {
+		checked := ""
+		if result != nil {
+			checked = fmt.Sprintf(" and the %s passed", params.Check)
+		}
+		return fmt.Sprintf("Applied changes to %d files%s:\n%s%s", changeSet.Len(), checked, changeSet.Summary(), notes.String()), nil
+	}
+
+	if rollbackErr := changeSet.Rollback(); rollbackErr != nil {
+		return "", fmt.Errorf("failed to roll back changes after the %s failed: %w", params.Check, rollbackErr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s, all changes were rolled back: %w", params.Check, err)
+	}
+	return fmt.Sprintf("The %s failed, so all changes were rolled back:\n%s", params.Check, result.FormatResult()), nil
+}
+
+// writerFor returns the writer for a tool call: approved calls may change
+// write-protected paths, and calls overriding the guard generated files.
+func (e *ToolExecutor) writerFor(ctx context.Context, overrideGenerated bool) *codebase.Writer {
+	writer := e.writer
+	if approval.IsApproved(ctx) {
+		writer = writer.Approved()
+	}
+	if overrideGenerated {
+		writer = writer.AllowGenerated()
+	}
+	return writer
+}
+
+func (e *ToolExecutor) runCommand(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RunCommandParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	// Commands may not read restricted paths (e.g. cat secrets/key, grep -r .)
+	if err := e.policy.CheckCommand(params.Command); err != nil {
+		return "", err
+	}
+
+	result, err := e.runner.RunCommand(ctx, params.Command)
+	if err != nil {
+		return "", err
+	}
+
+	return result.FormatResult(), nil
+}
+
+func (e *ToolExecutor) runBuild(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RunBuildParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	ctx = executor.WithTimeout(ctx, time.Duration(params.TimeoutSeconds)*time.Second)
+	started := time.Now()
+	result, err := e.runner.RunBuild(ctx, params.Args)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the whole build verifies the code
+	if params.Args == "" {
+		e.recordVerification(ctx, verifyBuild, result.IsSuccess())
+	}
+
+	outputID := e.keepOutput("build.log", "Build output", result.CombinedOutput())
+	e.addCard(ctx, "build", RenderCommandResult("Build", result, outputID))
+	return result.FormatResult() + e.collectArtifacts(ctx, started), nil
+}
+
+func (e *ToolExecutor) runTests(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RunTestsParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	command, note := e.runner.TestCommand(params.Args), ""
+	if params.Affected {
+		var err error
+		if command, note, err = e.affectedTestCommand(ctx, params.Base); err != nil || command == "" {
+			return note, err
+		}
+	}
+
+	ctx = executor.WithTimeout(ctx, time.Duration(params.TimeoutSeconds)*time.Second)
+	full := params.Args == "" && !params.Affected
+	return e.runTestsCached(ctx, command, note, params.Rerun, full)
+}
+
+func (e *ToolExecutor) runLint(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RunLintParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	result, err := e.runner.RunLint(ctx, params.Args)
+	if err != nil {
+		return "", err
+	}
+
+	return e.lintSummary(result), nil
+}
+
+func (e *ToolExecutor) gitStatus(ctx context.Context) (string, error) {
+	return e.gitOps.Status(ctx)
+}
+
+func (e *ToolExecutor) gitDiff(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.GitDiffParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	return e.gitOps.Diff(ctx, params.Staged, params.Ref, params.Path)
+}
+
+func (e *ToolExecutor) gitLog(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.GitLogParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	return e.gitOps.Log(ctx, params.Count, params.Path, params.Format)
+}
+
+func (e *ToolExecutor) createBranch(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CreateBranchParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if err := e.gitOps.CreateBranch(ctx, params.Name, params.From); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Created and switched to branch: %s", params.Name), nil
+}
+
+func (e *ToolExecutor) commit(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CommitParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	// Fast commits format the files first, and the code is checked as it
+	// will be committed
+	workflow, formatted := e.fastCommitWorkflow(ctx), 0
+	if workflow != "" {
+		var err error
+		if formatted, err = e.formatForCommit(ctx, params.Files); err != nil {
+			return "", err
+		}
+	}
+
+	verified, err := e.checkVerified(ctx, "commit")
+	if err != nil {
+		return "", err
+	}
+	defer e.carryVerification(ctx, verified)
+
+	if workflow != "" {
+		return e.commitFast(ctx, params, workflow, formatted)
+	}
+
+	if err := e.gitOps.Commit(ctx, params.Message, params.Files); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Committed: %s", params.Message), nil
+}
+
+func (e *ToolExecutor) push(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.PushParams
+	// Default to true for set_upstream
+	params.SetUpstream = true
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if _, err := e.checkVerified(ctx, "push"); err != nil {
+		return "", err
+	}
+
+	if err := e.gitOps.Push(ctx, params.SetUpstream); err != nil {
+		return "", err
+	}
+
+	branch, _ := e.gitOps.CurrentBranch(ctx)
+	return fmt.Sprintf("Pushed branch: %s", branch), nil
+}
+
+func (e *ToolExecutor) rebase(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RebaseParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	onto, err := e.integrationTarget(ctx, params.Onto)
+	if err != nil {
+		return "", err
+	}
+	result, err := e.gitOps.Rebase(ctx, onto)
+	if err != nil {
+		return "", err
+	}
+	return formatIntegration(result), nil
+}
+
+func (e *ToolExecutor) mergeBranch(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.MergeBranchParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	ref, err := e.integrationTarget(ctx, params.Ref)
 	if err != nil {
-		h.logger.Error("failed to process message", "error", err)
-		return &OutgoingMessage{
-			Text:     fmt.Sprintf("Sorry, I encountered an error: %v", err),
-			ThreadTS: msg.ThreadTS,
-		}, nil
+		return "", err
 	}
-
-	return &OutgoingMessage{
-		Text:     response,
-		ThreadTS: msg.ThreadTS,
-	}, nil
+	result, err := e.gitOps.Merge(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return formatIntegration(result), nil
 }
 
-// ToolExecutor executes tools for Claude.
-type ToolExecutor struct {
-	reader   *codebase.Reader
-	writer   *codebase.Writer
-	searcher *codebase.Searcher
-	runner   *executor.Runner
-	gitOps   *git.Operations
-	github   *git.GitHub
-	cfg      *config.Config
-	logger   *slog.Logger
+// integrationTarget resolves the ref to rebase onto or merge, defaulting to
+// the remote default branch and fetching first when it is a remote branch.
+func (e *ToolExecutor) integrationTarget(ctx context.Context, ref string) (string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref: %s", ref)
+	}
+	if ref != "" && !strings.HasPrefix(ref, "origin/") {
+		return ref, nil
+	}
+	if err := e.gitOps.Fetch(ctx); err != nil {
+		return "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	if ref != "" {
+		return ref, nil
+	}
+	defaultBranch, err := e.gitOps.GetDefaultBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "origin/" + defaultBranch, nil
 }
 
-// NewToolExecutor creates a new tool executor.
-func NewToolExecutor(repoPath string, cfg *config.Config, logger *slog.Logger) *ToolExecutor {
-	return &ToolExecutor{
-		reader:   codebase.NewReader(repoPath),
-		writer:   codebase.NewWriter(repoPath),
-		searcher: codebase.NewSearcher(repoPath),
-		runner:   executor.NewRunner(repoPath, cfg.BuildCmd, cfg.TestCmd),
-		gitOps:   git.NewOperations(repoPath),
-		github:   git.NewGitHub(repoPath, cfg.GitHubToken),
-		cfg:      cfg,
-		logger:   logger,
+func (e *ToolExecutor) listConflicts(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ListConflictsParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
 	}
-}
 
-// Execute executes a tool and returns the result.
-func (e *ToolExecutor) Execute(ctx context.Context, name string, input json.RawMessage) (string, error) {
-	e.logger.Debug("executing tool", "name", name)
-
-	switch name {
-	// Code Understanding
-	case "read_file":
-		return e.readFile(input)
-	case "list_files":
-		return e.listFiles(input)
-	case "search_code":
-		return e.searchCode(input)
-	case "get_tree":
-		return e.getTree(input)
-
-	// Code Modification
-	case "write_file":
-		return e.writeFile(input)
-	case "edit_file":
-		return e.editFile(input)
-
-	// Build & Test
-	case "run_command":
-		return e.runCommand(ctx, input)
-	case "run_build":
-		return e.runBuild(ctx, input)
-	case "run_tests":
-		return e.runTests(ctx, input)
-
-	// Git Operations
-	case "git_status":
-		return e.gitStatus(ctx)
-	case "git_diff":
-		return e.gitDiff(ctx, input)
-	case "git_log":
-		return e.gitLog(ctx, input)
-	case "create_branch":
-		return e.createBranch(ctx, input)
-	case "commit":
-		return e.commit(ctx, input)
-	case "push":
-		return e.push(ctx, input)
-	case "create_pr":
-		return e.createPR(ctx, input)
-	case "get_pr":
-		return e.getPR(ctx, input)
-
-	// Project Intelligence
-	case "get_guidelines":
-		return e.getGuidelines()
-	case "find_tests":
-		return e.findTests(input)
-	case "analyze_failures":
-		return e.analyzeFailures(input)
+	files, err := e.gitOps.ConflictedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "No conflicted files.", nil
+	}
 
-	default:
-		return "", fmt.Errorf("unknown tool: %s", name)
+	var sections []string
+	for _, file := range files {
+		if params.Path != "" && file != params.Path {
+			continue
+		}
+		content, err := e.reader.ReadFile(file)
+		if err != nil {
+			// Deleted on one side, or restricted
+			sections = append(sections, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		hunks := git.ParseConflicts(content)
+		if len(hunks) == 0 {
+			sections = append(sections, file+": no conflict markers left (resolved, not yet staged)")
+			continue
+		}
+		sections = append(sections, git.FormatConflicts(file, hunks))
+	}
+	if len(sections) == 0 {
+		return "", fmt.Errorf("%s has no conflicts; conflicted files: %s", params.Path, strings.Join(files, ", "))
 	}
+	return strings.Join(sections, "\n"), nil
 }
 
-// Tool implementations
+func (e *ToolExecutor) continueRebase(ctx context.Context) (string, error) {
+	files, err := e.gitOps.ConflictedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
 
-func (e *ToolExecutor) readFile(input json.RawMessage) (string, error) {
-	var params struct {
-		Path      string `json:"path"`
-		StartLine int    `json:"start_line"`
-		EndLine   int    `json:"end_line"`
+	// Refuse to commit files that still contain conflict markers
+	var unresolved []string
+	for _, file := range files {
+		content, err := e.reader.ReadFile(file)
+		if err == nil && len(git.ParseConflicts(content)) > 0 {
+			unresolved = append(unresolved, file)
+		}
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf("conflict markers remain in %s; resolve them with edit_file first", strings.Join(unresolved, ", "))
+	}
+
+	result, err := e.gitOps.ContinueIntegration(ctx)
+	if err != nil {
 		return "", err
 	}
+	return formatIntegration(result), nil
+}
 
-	if params.StartLine > 0 || params.EndLine > 0 {
-		return e.reader.ReadFileLines(params.Path, params.StartLine, params.EndLine)
+func (e *ToolExecutor) abortRebase(ctx context.Context) (string, error) {
+	kind, err := e.gitOps.AbortIntegration(ctx)
+	if err != nil {
+		return "", err
 	}
-	return e.reader.ReadFile(params.Path)
+	return fmt.Sprintf("Aborted the %s; the branch is back to its previous state.", kind), nil
 }
 
-func (e *ToolExecutor) listFiles(input json.RawMessage) (string, error) {
-	var params struct {
-		Pattern string `json:"pattern"`
+// formatIntegration describes the outcome of a rebase, merge or continue.
+func formatIntegration(result *git.IntegrationResult) string {
+	var sb strings.Builder
+	name := strings.ToUpper(result.Kind[:1]) + result.Kind[1:]
+
+	if len(result.Conflicts) == 0 {
+		sb.WriteString(name + " completed.")
+		if result.Kind == git.IntegrationRebase {
+			sb.WriteString(" History was rewritten, and force pushes are refused, so if this branch was already pushed, create_branch a new branch here, push that and open the PR from it instead.")
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("%s stopped with conflicts in %d file(s):\n", name, len(result.Conflicts)))
+		for _, file := range result.Conflicts {
+			sb.WriteString("- " + file + "\n")
+		}
+		sb.WriteString("\nUse list_conflicts to see the hunks, resolve them with edit_file, then call continue_rebase. Call abort_rebase to give up and restore the branch.")
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
-		return "", err
+
+	if result.Backup != "" {
+		sb.WriteString(fmt.Sprintf("\nThe previous state of the branch is saved as %s.", result.Backup))
 	}
+	return sb.String()
+}
 
-	files, err := e.searcher.ListFiles(params.Pattern)
-	if err != nil {
+func (e *ToolExecutor) createPR(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CreatePRParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	if len(files) == 0 {
-		return "No files found matching pattern: " + params.Pattern, nil
+	// Link the issue this conversation is working on
+	if issue := e.currentIssue(ctx); issue > 0 && !mentionsIssue(params.Body, issue) {
+		params.Body += fmt.Sprintf("\n\nCloses #%d", issue)
 	}
 
-	return fmt.Sprintf("Found %d files:\n%s", len(files), joinLines(files)), nil
-}
+	// Backports go to their release branch and link the original PR
+	if bp, ok := e.currentBackport(ctx); ok {
+		if params.Base == "" {
+			params.Base = bp.target
+		}
+		if !strings.Contains(params.Body, bp.source.URL) {
+			params.Body += fmt.Sprintf("\n\nBackport of #%d (%s) to `%s`.", bp.source.Number, bp.source.URL, bp.target)
+		}
+	}
 
-func (e *ToolExecutor) searchCode(input json.RawMessage) (string, error) {
-	var params struct {
-		Pattern       string `json:"pattern"`
-		Path          string `json:"path"`
-		CaseSensitive bool   `json:"case_sensitive"`
-		MaxResults    int    `json:"max_results"`
+	if params.Base == "" {
+		base, err := e.prBase(ctx)
+		if err != nil {
+			return "", err
+		}
+		params.Base = base
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+	head, err := e.checkPRHead(ctx, params.Base)
+	if err != nil {
 		return "", err
 	}
-
-	results, err := e.searcher.SearchCode(params.Pattern, params.Path, params.CaseSensitive, params.MaxResults)
+	review, err := e.selfReview(ctx, &params)
 	if err != nil {
 		return "", err
 	}
+	params.Body += review
 
-	if len(results) == 0 {
-		return "No matches found for pattern: " + params.Pattern, nil
+	pr, err := e.forge.CreatePR(ctx, params.Title, params.Body, params.Base, params.Draft)
+	if err != nil {
+		return "", err
 	}
 
-	return fmt.Sprintf("Found %d matches:\n%s", len(results), codebase.FormatSearchResults(results)), nil
+	e.trackPR(ctx, pr)
+
+	result := git.FormatPR(pr)
+	if notes := e.protectionNotes(ctx, head, params.Base); len(notes) > 0 {
+		result += "\n\n" + strings.Join(notes, "\n")
+	}
+	return result, nil
 }
 
-func (e *ToolExecutor) getTree(input json.RawMessage) (string, error) {
-	var params struct {
-		Path     string `json:"path"`
-		MaxDepth int    `json:"max_depth"`
+func (e *ToolExecutor) getPR(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.GetPRParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+
+	pr, err := e.forge.GetPRForReview(ctx, params.URL)
+	if err != nil {
 		return "", err
 	}
 
-	return e.searcher.GetTree(params.Path, params.MaxDepth)
+	e.addCard(ctx, "pr", e.renderPR(pr))
+	return git.FormatPRForReview(pr), nil
 }
 
-func (e *ToolExecutor) writeFile(input json.RawMessage) (string, error) {
-	var params struct {
-		Path    string `json:"path"`
-		Content string `json:"content"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) getPRChecks(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.GetPRChecksParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	if err := e.writer.WriteFile(params.Path, params.Content); err != nil {
+	checks, err := e.forge.GetPRChecks(ctx, params.URL)
+	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(params.Content), params.Path), nil
+	return git.FormatChecks(checks), nil
 }
 
-func (e *ToolExecutor) editFile(input json.RawMessage) (string, error) {
-	var params struct {
-		Path    string `json:"path"`
-		OldText string `json:"old_text"`
-		NewText string `json:"new_text"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) reviewPR(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ReviewPRParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	if err := e.writer.EditFile(params.Path, params.OldText, params.NewText); err != nil {
+	if err := e.forge.SubmitReview(ctx, params.URL, params.Event, params.Body); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("Successfully edited %s", params.Path), nil
+	return fmt.Sprintf("Submitted %s review on %s", params.Event, params.URL), nil
 }
 
-func (e *ToolExecutor) runCommand(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Command string `json:"command"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) commentOnPRLine(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CommentOnPRLineParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	result, err := e.runner.RunCommand(ctx, params.Command)
+	url, err := e.forge.AddInlineComment(ctx, params.URL, params.Path, params.Line, params.Body)
 	if err != nil {
 		return "", err
 	}
 
-	return result.FormatResult(), nil
+	return fmt.Sprintf("Commented on %s:%d: %s", params.Path, params.Line, url), nil
 }
 
-func (e *ToolExecutor) runBuild(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Args string `json:"args"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) commentOnIssue(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CommentOnIssueParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	result, err := e.runner.RunBuild(ctx, params.Args)
+	url, err := e.forge.AddIssueComment(ctx, params.Number, params.Body)
 	if err != nil {
 		return "", err
 	}
 
-	return result.FormatResult(), nil
+	return "Posted comment: " + url, nil
 }
 
-func (e *ToolExecutor) runTests(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Args string `json:"args"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) createIssue(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.CreateIssueParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	result, err := e.runner.RunTests(ctx, params.Args)
+	issue, err := e.forge.CreateIssue(ctx, params.Title, params.Body, params.Labels)
 	if err != nil {
 		return "", err
 	}
 
-	return result.FormatResult(), nil
+	return fmt.Sprintf("Created issue #%d: %s", issue.Number, issue.URL), nil
 }
 
-func (e *ToolExecutor) gitStatus(ctx context.Context) (string, error) {
-	return e.gitOps.Status(ctx)
+// parseMergeParams decodes merge_pr input, defaulting to a squash merge that
+// deletes the branch.
+func parseMergeParams(input json.RawMessage) (claude.MergePRParams, error) {
+	params := claude.MergePRParams{Strategy: git.MergeSquash, DeleteBranch: true}
+	err := claude.Bind(input, &params)
+	return params, err
 }
 
-func (e *ToolExecutor) gitDiff(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Staged bool   `json:"staged"`
-		Ref    string `json:"ref"`
-		Path   string `json:"path"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+// mergeSummary describes a merge_pr call for approvers.
+func mergeSummary(input json.RawMessage) (string, error) {
+	params, err := parseMergeParams(input)
+	if err != nil {
 		return "", err
 	}
 
-	return e.gitOps.Diff(ctx, params.Staged, params.Ref, params.Path)
+	summary := fmt.Sprintf("%s merge of PR %s", params.Strategy, params.URL)
+	if params.DeleteBranch {
+		summary += " (deleting its branch)"
+	}
+	return summary, nil
 }
 
-func (e *ToolExecutor) gitLog(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Count  int    `json:"count"`
-		Path   string `json:"path"`
-		Format string `json:"format"`
+func (e *ToolExecutor) mergePR(ctx context.Context, input json.RawMessage) (string, error) {
+	params, err := parseMergeParams(input)
+	if err != nil {
+		return "", err
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+
+	if err := e.forge.MergePR(ctx, params.URL, params.Strategy, params.DeleteBranch); err != nil {
 		return "", err
 	}
 
-	return e.gitOps.Log(ctx, params.Count, params.Path, params.Format)
+	return "Merged PR " + params.URL, nil
 }
 
-func (e *ToolExecutor) createBranch(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Name string `json:"name"`
-		From string `json:"from"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) revertCommit(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.RevertCommitParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	if err := e.gitOps.CreateBranch(ctx, params.Name, params.From); err != nil {
+	// A PR is reverted through the commit it was merged as
+	var source *git.PRInfo
+	sha := params.SHA
+	if params.PR != "" {
+		number, err := e.forge.PRNumber(ctx, params.PR)
+		if err != nil {
+			return "", err
+		}
+		if source, err = e.forge.GetPR(ctx, number); err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(source.State, "merged") {
+			return "", fmt.Errorf("PR #%d is %s, only merged PRs can be reverted", source.Number, strings.ToLower(source.State))
+		}
+		if source.MergeCommit == "" {
+			return "", fmt.Errorf("could not determine the merge commit of PR #%d; pass its sha instead", source.Number)
+		}
+		sha = source.MergeCommit
+	}
+
+	// Only revert what actually landed on the default branch
+	if err := e.gitOps.Fetch(ctx); err != nil {
+		return "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	defaultBranch, err := e.gitOps.GetDefaultBranch(ctx)
+	if err != nil {
 		return "", err
 	}
+	base := "origin/" + defaultBranch
 
-	return fmt.Sprintf("Created and switched to branch: %s", params.Name), nil
-}
+	commit, err := e.gitOps.ResolveCommit(ctx, sha)
+	if err != nil {
+		return "", err
+	}
+	onDefault, err := e.gitOps.IsAncestor(ctx, commit.SHA, base)
+	if err != nil {
+		return "", err
+	}
+	if !onDefault {
+		return "", fmt.Errorf("commit %s is not on %s; only commits on the default branch can be reverted", commit.SHA[:7], defaultBranch)
+	}
 
-func (e *ToolExecutor) commit(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Message string   `json:"message"`
-		Files   []string `json:"files"`
+	branch := "revert-" + commit.SHA[:7]
+	if err := e.gitOps.CreateBranch(ctx, branch, base); err != nil {
+		return "", err
+	}
+	if err := e.gitOps.Revert(ctx, commit.SHA); err != nil {
+		return "", err
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+	if err := e.gitOps.Push(ctx, true); err != nil {
 		return "", err
 	}
 
-	if err := e.gitOps.Commit(ctx, params.Message, params.Files); err != nil {
+	title := fmt.Sprintf("Revert %q", commit.Subject)
+	body := fmt.Sprintf("Reverts %s", commit.SHA)
+	if source != nil {
+		body = fmt.Sprintf("Reverts #%d (%s)", source.Number, commit.SHA)
+	}
+	if params.Reason != "" {
+		body += "\n\n**Reason:** " + params.Reason
+	}
+
+	pr, err := e.forge.CreatePR(ctx, title, body, defaultBranch, false)
+	if err != nil {
 		return "", err
 	}
+	e.trackPR(ctx, pr)
 
-	return fmt.Sprintf("Committed: %s", params.Message), nil
+	return fmt.Sprintf("Reverted %s on branch %s and opened a revert PR:\n%s", commit.SHA[:7], branch, git.FormatPR(pr)), nil
 }
 
-func (e *ToolExecutor) push(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		SetUpstream bool `json:"set_upstream"`
+// backport is a backport of a merged PR being prepared on a branch.
+type backport struct {
+	source *git.PRInfo
+	target string
+	branch string
+}
+
+func (e *ToolExecutor) backportPR(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.BackportPRParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
 	}
-	// Default to true for set_upstream
-	params.SetUpstream = true
-	if err := json.Unmarshal(input, &params); err != nil {
+
+	number, err := e.forge.PRNumber(ctx, params.PR)
+	if err != nil {
+		return "", err
+	}
+	source, err := e.forge.GetPR(ctx, number)
+	if err != nil {
 		return "", err
 	}
+	if !strings.EqualFold(source.State, "merged") {
+		return "", fmt.Errorf("PR #%d is %s, only merged PRs can be backported", source.Number, strings.ToLower(source.State))
+	}
+	if source.MergeCommit == "" {
+		return "", fmt.Errorf("could not determine the merge commit of PR #%d", source.Number)
+	}
 
-	if err := e.gitOps.Push(ctx, params.SetUpstream); err != nil {
+	// Branch from the latest release branch
+	target := strings.TrimPrefix(params.Target, "origin/")
+	base := "origin/" + target
+	if err := e.gitOps.Fetch(ctx); err != nil {
+		return "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	if _, err := e.gitOps.ResolveCommit(ctx, base); err != nil {
+		return "", fmt.Errorf("release branch %s not found on origin", target)
+	}
+	if backported, err := e.gitOps.IsAncestor(ctx, source.MergeCommit, base); err == nil && backported {
+		return "", fmt.Errorf("PR #%d is already on %s", source.Number, target)
+	}
+
+	branch := fmt.Sprintf("backport-%d-to-%s", source.Number, strings.ReplaceAll(target, "/", "-"))
+	if err := e.gitOps.CreateBranch(ctx, branch, base); err != nil {
+		return "", err
+	}
+	result, err := e.gitOps.CherryPick(ctx, source.MergeCommit)
+	if err != nil {
 		return "", err
 	}
 
-	branch, _ := e.gitOps.CurrentBranch(ctx)
-	return fmt.Sprintf("Pushed branch: %s", branch), nil
+	if info, ok := ConversationFromContext(ctx); ok {
+		e.backportsMu.Lock()
+		e.backports[info.ConversationID] = backport{source: source, target: target, branch: branch}
+		e.backportsMu.Unlock()
+	}
+	e.startWorkflow(ctx, WorkflowBackport)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Created branch %s from %s and cherry-picked PR #%d (%s).\n\n", branch, base, source.Number, source.Title))
+	sb.WriteString(formatIntegration(result))
+	sb.WriteString(fmt.Sprintf("\n\nNext: once the cherry-pick is complete, run the tests, push, and call create_pr titled %q. The PR will target %s and link #%d.\n",
+		fmt.Sprintf("[%s] %s", target, source.Title), target, source.Number))
+	return sb.String(), nil
 }
 
-func (e *ToolExecutor) createPR(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		Title string `json:"title"`
-		Body  string `json:"body"`
-		Base  string `json:"base"`
-		Draft bool   `json:"draft"`
+// currentBackport returns the backport the conversation in ctx is preparing,
+// if its branch is checked out.
+func (e *ToolExecutor) currentBackport(ctx context.Context) (backport, bool) {
+	info, ok := ConversationFromContext(ctx)
+	if !ok {
+		return backport{}, false
+	}
+
+	e.backportsMu.Lock()
+	bp, ok := e.backports[info.ConversationID]
+	e.backportsMu.Unlock()
+	if !ok {
+		return backport{}, false
+	}
+	if branch, err := e.gitOps.CurrentBranch(ctx); err != nil || branch != bp.branch {
+		return backport{}, false
+	}
+	return bp, true
+}
+
+// requestApproval parks a gated tool call until a human approves it in the thread.
+func (e *ToolExecutor) requestApproval(ctx context.Context, tool string, input json.RawMessage, summary string) (string, error) {
+	info, ok := ConversationFromContext(ctx)
+	if !ok || e.approvals == nil {
+		return "", fmt.Errorf("%s requires human approval, which is only available in Slack conversations", tool)
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+
+	e.approvals.Submit(approval.Request{
+		ConversationID: info.ConversationID,
+		ChannelID:      info.ChannelID,
+		Tool:           tool,
+		Input:          input,
+		Summary:        summary,
+		RequestedBy:    info.UserID,
+	})
+
+	return fmt.Sprintf("Approval required before running: %s. Tell the user to reply `approve` in this thread to proceed or `cancel` to abort. Do not retry the tool.", summary), nil
+}
+
+func (e *ToolExecutor) learnFromReview(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.LearnFromReviewParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	pr, err := e.github.CreatePR(ctx, params.Title, params.Body, params.Base, params.Draft)
+	lessons, err := e.learner.LearnFromPR(ctx, params.URL)
 	if err != nil {
 		return "", err
 	}
 
-	return git.FormatPR(pr), nil
+	if len(lessons) == 0 {
+		return "No generalizable feedback found on " + params.URL, nil
+	}
+
+	return fmt.Sprintf("Learned %d lessons:\n%s", len(lessons), joinLines(lessons)), nil
 }
 
-func (e *ToolExecutor) getPR(ctx context.Context, input json.RawMessage) (string, error) {
-	var params struct {
-		URL string `json:"url"`
+func (e *ToolExecutor) workOnIssue(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.WorkOnIssueParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+
+	issue, err := e.forge.GetIssue(ctx, params.Number)
+	if err != nil {
 		return "", err
 	}
+	if strings.EqualFold(issue.State, "closed") {
+		return "", fmt.Errorf("issue #%d is already closed", issue.Number)
+	}
 
-	pr, err := e.github.GetPRForReview(ctx, params.URL)
+	// Branch from the latest default branch
+	if err := e.gitOps.Fetch(ctx); err != nil {
+		return "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	defaultBranch, err := e.gitOps.GetDefaultBranch(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	return git.FormatPRForReview(pr), nil
+	branch := git.IssueBranchName(issue)
+	if err := e.gitOps.CreateBranch(ctx, branch, "origin/"+defaultBranch); err != nil {
+		return "", err
+	}
+
+	if info, ok := ConversationFromContext(ctx); ok {
+		e.issuesMu.Lock()
+		e.issues[info.ConversationID] = issue.Number
+		e.issuesMu.Unlock()
+	}
+	e.startWorkflow(ctx, WorkflowIssue)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Created and switched to branch %s (from origin/%s).\n\n", branch, defaultBranch))
+	sb.WriteString(git.FormatIssue(issue))
+	sb.WriteString(fmt.Sprintf("\nNext: implement the fix, run the build and tests, commit, push, and call create_pr. The PR will reference #%d.\n", issue.Number))
+	return sb.String(), nil
+}
+
+// currentIssue returns the issue the conversation in ctx is working on, or 0.
+func (e *ToolExecutor) currentIssue(ctx context.Context) int {
+	info, ok := ConversationFromContext(ctx)
+	if !ok {
+		return 0
+	}
+
+	e.issuesMu.Lock()
+	defer e.issuesMu.Unlock()
+	return e.issues[info.ConversationID]
+}
+
+// forgetIssue forgets the issue conversationID was working on.
+func (e *ToolExecutor) forgetIssue(conversationID string) {
+	e.issuesMu.Lock()
+	defer e.issuesMu.Unlock()
+	delete(e.issues, conversationID)
+}
+
+// forgetIssue forgets the issue conversationID was working on, in every
+// workspace, once the conversation is cleared or closed.
+func (h *Handler) forgetIssue(conversationID string) {
+	h.toolExecutor.forgetIssue(conversationID)
+	h.eachWorkspace(func(e *ToolExecutor) { e.forgetIssue(conversationID) })
+}
+
+// mentionsIssue reports whether text references issue, as "#42" but not as
+// part of "#421".
+func mentionsIssue(text string, issue int) bool {
+	return regexp.MustCompile(fmt.Sprintf(`#%d\b`, issue)).MatchString(text)
 }
 
 func (e *ToolExecutor) getGuidelines() (string, error) {
@@ -451,11 +2086,35 @@ func (e *ToolExecutor) getGuidelines() (string, error) {
 	return content, nil
 }
 
-func (e *ToolExecutor) findTests(input json.RawMessage) (string, error) {
-	var params struct {
-		SourceFile string `json:"source_file"`
+func (e *ToolExecutor) getProjectInfo() (string, error) {
+	project := codebase.DetectProject(e.writer.GetRepoPath())
+
+	var sb strings.Builder
+	sb.WriteString(project.Format())
+	sb.WriteString("\nCommands:\n")
+	build, test, lint := e.runner.Commands()
+	for _, command := range []struct {
+		tool, detected, run, setting string
+	}{
+		{"run_build", e.project.BuildCmd, build, "STORMSTACK_BUILD_CMD"},
+		{"run_tests", e.project.TestCmd, test, "STORMSTACK_TEST_CMD"},
+		{"run_lint", e.project.LintCmd, lint, "STORMSTACK_LINT_CMD"},
+	} {
+		switch {
+		case command.run == "":
+			sb.WriteString(fmt.Sprintf("- %s: none (set %s)\n", command.tool, command.setting))
+		case command.run != command.detected:
+			sb.WriteString(fmt.Sprintf("- %s: `%s` (configured)\n", command.tool, command.run))
+		default:
+			sb.WriteString(fmt.Sprintf("- %s: `%s` (detected)\n", command.tool, command.run))
+		}
 	}
-	if err := json.Unmarshal(input, &params); err != nil {
+	return sb.String(), nil
+}
+
+func (e *ToolExecutor) findTests(input json.RawMessage) (string, error) {
+	var params claude.FindTestsParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
@@ -471,20 +2130,143 @@ func (e *ToolExecutor) findTests(input json.RawMessage) (string, error) {
 	return fmt.Sprintf("Found test files:\n%s", joinLines(tests)), nil
 }
 
-func (e *ToolExecutor) analyzeFailures(input json.RawMessage) (string, error) {
-	var params struct {
-		Output string `json:"output"`
-	}
-	if err := json.Unmarshal(input, &params); err != nil {
+func (e *ToolExecutor) analyzeFailures(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.AnalyzeFailuresParams
+	if err := claude.Bind(input, &params); err != nil {
 		return "", err
 	}
 
-	result := executor.AnalyzeOutput(params.Output)
+	parsers, err := e.outputParsers()
+	if err != nil {
+		return "", err
+	}
+	parser := params.Parser
+	if parser == "" {
+		parser = e.cfg.OutputParser
+	}
+	result, err := parsers.Analyze(params.Output, parser)
+	if err != nil {
+		return "", err
+	}
+	e.addCard(ctx, "analysis", RenderAnalysis(result, e.keepOutput("output.log", "Analyzed output", params.Output)))
+	if params.Format == "json" {
+		return result.ToJSON()
+	}
 	return result.Summary(), nil
 }
 
+func (e *ToolExecutor) askQuestion(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.AskQuestionParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	info, ok := ConversationFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("questions can only be asked in a Slack conversation")
+	}
+
+	e.questions.ask(info.ConversationID, params.Question, params.Options)
+	return fmt.Sprintf("The options will be shown as buttons under your reply: %s. End your turn now; the user's choice (or their own answer) will arrive as their next message.",
+		strings.Join(params.Options, ", ")), nil
+}
+
 // Helper functions
 
+// outputParsers returns the parsers for build and test output: the built-in
+// ones, preferring those for the repository's build systems, and the custom
+// ones in the repository's parsers file, which is read afresh so edits to it
+// apply at once.
+func (e *ToolExecutor) outputParsers() (*executor.ParserRegistry, error) {
+	repoPath := e.writer.GetRepoPath()
+	registry := executor.NewParserRegistry(executor.DetectBuildSystems(repoPath)...)
+	if e.cfg.OutputParsersFile == "" {
+		return registry, nil
+	}
+
+	custom, err := executor.LoadParsers(filepath.Join(repoPath, e.cfg.OutputParsersFile))
+	if err != nil {
+		return nil, err
+	}
+	// Registering prepends, so the file's first parser ends up tried first
+	for i := len(custom) - 1; i >= 0; i-- {
+		registry.Register(custom[i])
+	}
+	return registry, nil
+}
+
+// lintSummary lists the findings of a lint run by file, falling back to the
+// raw output when the linter failed without findings in a known format.
+func (e *ToolExecutor) lintSummary(result *executor.CommandResult) string {
+	report, err := executor.ParseLintOutput(e.cfg.LintFormat, result.CombinedOutput())
+	if err != nil {
+		e.logger.Warn("failed to parse lint output", "error", err)
+		return result.FormatResult()
+	}
+	if result.TimedOut || (len(report.Findings) == 0 && !result.IsSuccess()) {
+		return result.FormatResult()
+	}
+	report.RelativeTo(e.writer.GetRepoPath())
+	return report.Summary()
+}
+
+// isReadOnly reports whether a tool call is safe to perform in shadow mode.
+func (e *ToolExecutor) isReadOnly(name string, input json.RawMessage) bool {
+	if tool, ok := e.tools.lookup(name); ok && tool.Permission == permissionRead {
+		return true
+	}
+
+	if name == "run_command" {
+		var params claude.RunCommandParams
+		if err := claude.Bind(input, &params); err == nil {
+			return executor.IsReadOnlyCommand(params.Command)
+		}
+	}
+
+	return false
+}
+
+// recordShadow records a suppressed tool call and tells Claude it was simulated.
+func (e *ToolExecutor) recordShadow(ctx context.Context, name string, input json.RawMessage) string {
+	entry := shadow.Entry{
+		Kind:  shadow.KindTool,
+		Tool:  name,
+		Input: input,
+	}
+	if info, ok := ConversationFromContext(ctx); ok {
+		entry.ConversationID = info.ConversationID
+		entry.ChannelID = info.ChannelID
+		entry.ThreadTS = info.ThreadTS
+	}
+	e.shadow.Record(entry)
+
+	return fmt.Sprintf("[shadow mode] %s was recorded but not executed. Continue as if it succeeded.", name)
+}
+
+// trackPR registers a newly created PR for conflict early warnings.
+func (e *ToolExecutor) trackPR(ctx context.Context, pr *git.PRInfo) {
+	info, ok := ConversationFromContext(ctx)
+	if !ok || e.tracker == nil {
+		return
+	}
+
+	branch := pr.HeadRef
+	if branch == "" {
+		branch, _ = e.gitOps.CurrentBranch(ctx)
+	}
+	if branch == "" {
+		return
+	}
+
+	e.tracker.Track(conflicts.PendingPR{
+		Branch:    branch,
+		Number:    pr.Number,
+		URL:       pr.URL,
+		ChannelID: info.ChannelID,
+		ThreadTS:  info.ThreadTS,
+	})
+}
+
 func joinLines(lines []string) string {
 	result := ""
 	for _, line := range lines {
@@ -0,0 +1,158 @@
+// Handing threads off to teammates.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxHandoffFiles bounds the changed files listed in a handoff.
+const maxHandoffFiles = 20
+
+var (
+	// handoffRe matches "handoff @teammate", "hand off to @teammate <note>".
+	handoffRe = regexp.MustCompile(`(?is)^\s*hand\s*-?\s*off\s+(?:to\s+)?<@([UW][A-Z0-9]+)(?:\|[^>]*)?>\s*(.*)$`)
+	// resumeRe matches the request re-engaging the bot after a handoff.
+	resumeRe = regexp.MustCompile(`(?i)^\s*(?:resume|take\s+back)\s*$`)
+)
+
+// handleHandoff answers the handoff command: it posts a handoff package for
+// the tagged teammate (a brief of the conversation and its open questions,
+// the branch, the changed files and the full diff as a patch) and stops the
+// bot making changes in the thread until someone says resume. It reports
+// whether the message was a handoff command.
+func (h *Handler) handleHandoff(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := handoffRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	reply := func(text string) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	}
+
+	teammate, note := match[1], strings.TrimSpace(match[2])
+	if msg.ThreadTS == "" {
+		return reply("Handoffs work in threads: mention me in the thread you want to hand off.")
+	}
+	if teammate == msg.UserID {
+		return reply("Tag the teammate taking over, e.g. `handoff @alex`.")
+	}
+
+	e, err := h.executorFor(ctx)
+	if err != nil {
+		return reply(fmt.Sprintf("Sorry, I couldn't open the workspace: %v", err))
+	}
+	work, patch := h.handoffWork(ctx, e)
+
+	var extra []string
+	for _, pr := range h.toolExecutor.tracker.List() {
+		if pr.ThreadTS == conversationID {
+			extra = append(extra, fmt.Sprintf("Pull request #%d opened from branch %s: %s (not merged yet)", pr.Number, pr.Branch, pr.URL))
+		}
+	}
+	brief, err := h.conversation.HandoffSummary(ctx, conversationID, extra)
+	if err != nil {
+		h.logger.Warn("failed to summarize conversation for handoff", "conversation", conversationID, "error", err)
+		brief = "_I couldn't summarize the thread, so please read it from the top._"
+	}
+
+	if _, err := h.conversation.HandOff(ctx, conversationID, msg.ChannelID, teammate); err != nil {
+		h.logger.Error("failed to hand off conversation", "conversation", conversationID, "error", err)
+		return reply(fmt.Sprintf("Sorry, I couldn't hand off this thread: %v", err))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":handshake: <@%s>, <@%s> is handing this thread over to you.\n", teammate, msg.UserID))
+	if note != "" {
+		sb.WriteString("> " + strings.ReplaceAll(note, "\n", "\n> ") + "\n")
+	}
+	sb.WriteString("\n" + work)
+	if brief != "" {
+		sb.WriteString("\n" + brief + "\n")
+	}
+	sb.WriteString("\n_I won't make changes in this thread until someone says `resume`, but I can still answer questions._")
+
+	out, _ := reply(sb.String())
+	if patch != "" {
+		out.Files = []File{{Name: "handoff.patch", Title: "Changes so far", Content: patch}}
+	}
+	return out, true
+}
+
+// handoffWork describes the state of the checkout for a handoff: the branch,
+// and the files changed on it since the default branch, committed or not. It
+// also returns those changes as a patch, or "" when there are none.
+func (h *Handler) handoffWork(ctx context.Context, e *ToolExecutor) (string, string) {
+	branch, err := e.gitOps.CurrentBranch(ctx)
+	if err != nil {
+		h.logger.Warn("failed to get current branch for handoff", "error", err)
+		return "", ""
+	}
+	text := fmt.Sprintf("*Branch*: `%s`\n", branch)
+
+	defaultBranch, err := e.gitOps.GetDefaultBranch(ctx)
+	if err != nil {
+		h.logger.Warn("failed to get default branch for handoff", "error", err)
+		return text, ""
+	}
+	base := "origin/" + defaultBranch
+	changed, err := e.gitOps.ChangedSince(ctx, base)
+	if err != nil || len(changed) == 0 {
+		return text + fmt.Sprintf("*Changes*: none since %s\n", base), ""
+	}
+
+	listed := changed
+	if len(listed) > maxHandoffFiles {
+		listed = append(listed[:maxHandoffFiles:maxHandoffFiles], fmt.Sprintf("... and %d more", len(changed)-maxHandoffFiles))
+	}
+	for i, file := range listed {
+		listed[i] = "• " + file
+	}
+	text += fmt.Sprintf("*Changes since %s* (%d files, full diff attached):\n%s", base, len(changed), joinLines(listed))
+
+	// Committed changes since the branch forked, then anything uncommitted.
+	// Backends without range diffs compare base with the working tree instead.
+	committed, err := e.gitOps.Diff(ctx, false, base+"...HEAD", "")
+	if err != nil {
+		patch, _ := e.gitOps.Diff(ctx, false, base, "")
+		return text, patch
+	}
+	if uncommitted, err := e.gitOps.Diff(ctx, false, "HEAD", ""); err == nil && uncommitted != "" {
+		committed += "\n# Uncommitted changes\n" + uncommitted
+	}
+	return text, committed
+}
+
+// handleResume re-engages the bot in a thread that was handed off. It
+// reports whether the message was a resume request in such a thread.
+func (h *Handler) handleResume(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	if !resumeRe.MatchString(msg.Text) || h.conversation.HandedOffTo(ctx, conversationID) == "" {
+		return nil, false
+	}
+
+	text := ":wave: I'm back on this thread and can make changes again. What should I pick up?"
+	if _, err := h.conversation.HandOff(ctx, conversationID, msg.ChannelID, ""); err != nil {
+		h.logger.Error("failed to resume conversation", "conversation", conversationID, "error", err)
+		text = fmt.Sprintf("Sorry, I couldn't resume this thread: %v", err)
+	}
+	h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+	return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+}
+
+// handedOffError refuses a tool call that would change something in a thread
+// that was handed off, or returns nil when the call may run.
+func (h *Handler) handedOffError(ctx context.Context, e *ToolExecutor, name string, input json.RawMessage) error {
+	info, ok := ConversationFromContext(ctx)
+	if !ok || e.isReadOnly(name, input) {
+		return nil
+	}
+	if teammate := h.conversation.HandedOffTo(ctx, info.ConversationID); teammate != "" {
+		return fmt.Errorf("this thread was handed off to <@%s>, so %s can't run here; answer questions only, or ask someone to say `resume` to bring you back in", teammate, name)
+	}
+	return nil
+}
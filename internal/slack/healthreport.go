@@ -0,0 +1,295 @@
+// The scheduled and on-demand repository health report.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/scheduler"
+)
+
+// healthReportRe matches "report" and "health report".
+var healthReportRe = regexp.MustCompile(`(?i)^\s*(?:health\s+)?report\s*$`)
+
+// maxHealthOutput bounds the end of each command's output kept for the
+// summary; the attached log keeps it all.
+const maxHealthOutput = 6000
+
+// healthCheck is the result of one check in a health report.
+type healthCheck struct {
+	name string
+	// status is "passed", "failed" or "skipped"
+	status string
+	// headline is the one-line result, e.g. "3 tests failed"
+	headline string
+	took     time.Duration
+	output   string
+}
+
+// emoji returns the status's emoji.
+func (c healthCheck) emoji() string {
+	switch c.status {
+	case "passed":
+		return ":white_check_mark:"
+	case "failed":
+		return ":x:"
+	default:
+		return ":heavy_minus_sign:"
+	}
+}
+
+// healthRunning keeps on-demand and scheduled reports from running at once.
+var healthRunning sync.Mutex
+
+// handleHealthReport answers the report command with a health report for
+// the channel. It reports whether the message was a report command.
+func (h *Handler) handleHealthReport(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	if !healthReportRe.MatchString(msg.Text) {
+		return nil, false
+	}
+
+	out, err := h.HealthReport(ctx)
+	if err != nil {
+		out = &OutgoingMessage{Text: fmt.Sprintf("Sorry, I couldn't produce a health report: %v", err)}
+	}
+	h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, out.Text)
+	out.ThreadTS = msg.ThreadTS
+	return out, true
+}
+
+// HealthReport runs the build, the tests, the linter and a vulnerability
+// scan on the main checkout, and returns a report of the results summarized
+// by Claude, with the full output attached.
+func (h *Handler) HealthReport(ctx context.Context) (*OutgoingMessage, error) {
+	if !healthRunning.TryLock() {
+		return nil, fmt.Errorf("a health report is already running")
+	}
+	defer healthRunning.Unlock()
+
+	e := h.toolExecutor
+	started := time.Now()
+	checks := []healthCheck{
+		e.buildCheck(ctx),
+		e.testCheck(ctx),
+		e.lintCheck(ctx),
+		e.vulnCheck(ctx),
+	}
+
+	var sb strings.Builder
+	sb.WriteString(":stethoscope: *Repository health report*")
+	if branch, err := e.gitOps.CurrentBranch(ctx); err == nil {
+		sb.WriteString(fmt.Sprintf(" for `%s`", branch))
+	}
+	if head, err := e.gitOps.ResolveCommit(ctx, "HEAD"); err == nil && len(head.SHA) >= 7 {
+		sb.WriteString(fmt.Sprintf(" at `%s`", head.SHA[:7]))
+	}
+	sb.WriteString("\n")
+
+	var results, log strings.Builder
+	for _, check := range checks {
+		line := fmt.Sprintf("%s *%s*: %s", check.emoji(), check.name, check.headline)
+		if check.took > 0 {
+			line += fmt.Sprintf(" (%s)", check.took.Round(time.Second))
+		}
+		sb.WriteString(line + "\n")
+
+		results.WriteString(fmt.Sprintf("## %s: %s — %s\n", check.name, check.status, check.headline))
+		if check.output != "" {
+			tail := check.output
+			if len(tail) > maxHealthOutput {
+				tail = "[...]\n" + tail[len(tail)-maxHealthOutput:]
+			}
+			results.WriteString(tail + "\n")
+			log.WriteString(fmt.Sprintf("===== %s =====\n%s\n\n", check.name, check.output))
+		}
+	}
+
+	summary, err := h.conversation.HealthSummary(ctx, results.String())
+	if err != nil {
+		h.logger.Warn("failed to summarize health report", "error", err)
+		summary = "_I couldn't summarize the results; see the attached log._"
+	}
+	sb.WriteString("\n" + summary)
+	h.logger.Info("produced health report", "took", time.Since(started).Round(time.Second))
+
+	out := &OutgoingMessage{Text: sb.String()}
+	if log.Len() > 0 {
+		out.Files = []File{{
+			Name:    fmt.Sprintf("health-%s.log", started.Format("2006-01-02")),
+			Title:   "Health report output",
+			Content: log.String(),
+		}}
+	}
+	return out, nil
+}
+
+// buildCheck runs the build for a health report.
+func (e *ToolExecutor) buildCheck(ctx context.Context) healthCheck {
+	check := healthCheck{name: "Build"}
+	result, err := e.runner.RunBuild(ctx, "")
+	if err != nil {
+		check.status, check.headline = "skipped", err.Error()
+		return check
+	}
+	check.took, check.output = result.Duration, result.CombinedOutput()
+	switch {
+	case result.TimedOut:
+		check.status, check.headline = "failed", "timed out"
+	case result.IsSuccess():
+		check.status, check.headline = "passed", "passed"
+	default:
+		check.status, check.headline = "failed", fmt.Sprintf("failed (exit code %d)", result.ExitCode)
+		if parsers, err := e.outputParsers(); err == nil {
+			if analysis, err := parsers.Analyze(check.output, e.cfg.OutputParser); err == nil && len(analysis.BuildErrors) > 0 {
+				check.headline = fmt.Sprintf("failed with %d errors", len(analysis.BuildErrors))
+			}
+		}
+	}
+	return check
+}
+
+// testCheck runs the full test suite for a health report.
+func (e *ToolExecutor) testCheck(ctx context.Context) healthCheck {
+	check := healthCheck{name: "Tests"}
+	result, err := e.runner.RunTests(ctx, "")
+	if err != nil {
+		check.status, check.headline = "skipped", err.Error()
+		return check
+	}
+	check.took, check.output = result.Duration, result.CombinedOutput()
+	switch {
+	case result.TimedOut:
+		check.status, check.headline = "failed", "timed out"
+	case result.IsSuccess():
+		check.status, check.headline = "passed", "passed"
+	default:
+		check.status, check.headline = "failed", fmt.Sprintf("failed (exit code %d)", result.ExitCode)
+		if parsers, err := e.outputParsers(); err == nil {
+			if analysis, err := parsers.Analyze(check.output, e.cfg.OutputParser); err == nil && len(analysis.TestFailures) > 0 {
+				check.headline = fmt.Sprintf("%d tests failed", len(analysis.TestFailures))
+			}
+		}
+	}
+	return check
+}
+
+// lintCheck runs the linter for a health report.
+func (e *ToolExecutor) lintCheck(ctx context.Context) healthCheck {
+	check := healthCheck{name: "Lint"}
+	if !e.runner.HasLinter() {
+		check.status, check.headline = "skipped", "no linter configured or detected"
+		return check
+	}
+	result, err := e.runner.RunLint(ctx, "")
+	if err != nil {
+		check.status, check.headline = "skipped", err.Error()
+		return check
+	}
+	check.took, check.output = result.Duration, result.CombinedOutput()
+	report, err := executor.ParseLintOutput(e.cfg.LintFormat, check.output)
+	switch {
+	case result.TimedOut:
+		check.status, check.headline = "failed", "timed out"
+	case result.IsSuccess() && (err != nil || len(report.Findings) == 0):
+		check.status, check.headline = "passed", "clean"
+	case err == nil && len(report.Findings) > 0:
+		errors := 0
+		for _, finding := range report.Findings {
+			if finding.Severity == "error" {
+				errors++
+			}
+		}
+		check.status = "failed"
+		check.headline = fmt.Sprintf("%d findings (%d errors)", len(report.Findings), errors)
+	default:
+		check.status, check.headline = "failed", fmt.Sprintf("failed (exit code %d)", result.ExitCode)
+	}
+	return check
+}
+
+// vulnCheck scans the dependencies for vulnerabilities for a health report.
+func (e *ToolExecutor) vulnCheck(ctx context.Context) healthCheck {
+	check := healthCheck{name: "Vulnerabilities"}
+	scanners := executor.ScannersFor(executor.DetectEcosystems(e.writer.GetRepoPath()))
+	if len(scanners) == 0 {
+		check.status, check.headline = "skipped", "no package manifest found"
+		return check
+	}
+
+	started := time.Now()
+	scan := e.runner.ScanVulnerabilities(ctx, scanners)
+	check.took, check.output = time.Since(started), scan.Format("")
+	switch {
+	case len(scan.Scanned) == 0:
+		check.status, check.headline = "skipped", "no scanner installed (govulncheck, npm, osv-scanner)"
+	case len(scan.Vulnerabilities) == 0:
+		check.status, check.headline = "passed", "none known"
+	default:
+		severe := 0
+		for _, v := range scan.Vulnerabilities {
+			if v.Severity == "CRITICAL" || v.Severity == "HIGH" {
+				severe++
+			}
+		}
+		check.status = "failed"
+		check.headline = fmt.Sprintf("%d known (%d critical or high)", len(scan.Vulnerabilities), severe)
+	}
+	return check
+}
+
+// HealthReporter posts the health report to a channel on a cron schedule.
+type HealthReporter struct {
+	handler  *Handler
+	bot      *Bot
+	channel  string
+	schedule *scheduler.Cron
+	// last is when the report was last due; reports due while the bot was
+	// down are not caught up
+	last   time.Time
+	logger *slog.Logger
+}
+
+// NewHealthReporter creates a reporter posting to cfg.HealthReportChannel on
+// cfg.HealthReportSchedule.
+func NewHealthReporter(cfg *config.Config, handler *Handler, bot *Bot, logger *slog.Logger) (*HealthReporter, error) {
+	schedule, err := scheduler.ParseCron(cfg.HealthReportSchedule)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthReporter{
+		handler:  handler,
+		bot:      bot,
+		channel:  cfg.HealthReportChannel,
+		schedule: schedule,
+		last:     time.Now(),
+		logger:   logger,
+	}, nil
+}
+
+// Run posts the report when the schedule has fired since the last run. It is
+// meant to run every minute or so as a scheduled job.
+func (r *HealthReporter) Run(ctx context.Context) error {
+	now := time.Now()
+	if !r.schedule.Due(r.last, now) {
+		return nil
+	}
+	r.last = now
+
+	out, err := r.handler.HealthReport(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.bot.PostProactive(r.channel, out); err != nil {
+		return fmt.Errorf("failed to post health report: %w", err)
+	}
+	r.logger.Info("posted health report", "channel", r.channel, "schedule", r.schedule.String())
+	return nil
+}
@@ -0,0 +1,193 @@
+// The help command.
+
+package slack
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+)
+
+// builtinCommand is a command or shortcut the bot recognises in messages.
+type builtinCommand struct {
+	Usage       string
+	Description string
+	// DMOnly commands only work in direct messages
+	DMOnly bool
+	// Gated commands need an approver
+	Gated bool
+}
+
+// builtinCommands lists the commands and shortcuts the bot understands, in
+// the order they are shown in help.
+var builtinCommands = []builtinCommand{
+	{Usage: "help [tools]", Description: "Show this help; `help tools` describes every tool"},
+	{Usage: "triage [limit]", Description: "Propose a category, priority, labels and assignee for open issues"},
+	{Usage: "triage apply", Description: "Apply the last triage proposal in this channel", Gated: true},
+	{Usage: "approve", Description: "Approve the action waiting in this thread", Gated: true},
+	{Usage: "cancel", Description: "Cancel the action waiting in this thread"},
+	{Usage: "work on issue #<number>", Description: "Implement an issue end to end and open a PR that references it"},
+	{Usage: "migrate logging in <path>", Description: "Convert a package's log calls to structured logging, in chunked PRs"},
+	{Usage: "<PR link>", Description: "Review a pull request"},
+	{Usage: "pr stats [days]", Description: "Report how the bot's PRs from the last 30 (or given) days fared: merge rate, time to merge and review comments, with a CSV export"},
+	{Usage: "usage [days]", Description: "Show your requests, tool calls and Claude tokens over the last 7 (or given) days, only to you"},
+	{Usage: "report", Description: "Run the build, tests, linter and a vulnerability scan, and post a health report summarized by Claude"},
+	{Usage: "trace [n]", Description: "Show the Claude and tool calls of this thread's latest (or nth latest) task, with a Mermaid diagram"},
+	{Usage: "export [markdown|json]", Description: "Upload this thread's conversation (messages, tool calls and the diff so far) as a markdown or JSON file"},
+	{Usage: "handoff @teammate [note]", Description: "Hand this thread to a teammate with a brief, the branch, open questions and the diff; I stop making changes here until someone says `resume`"},
+	{Usage: "resume", Description: "Bring me back into a thread that was handed off"},
+	{Usage: "reset", Description: "Forget this thread's conversation and start fresh, once you confirm"},
+	{Usage: "reset workspace", Description: "Discard your personal workspace and start fresh", DMOnly: true},
+}
+
+// helpRe matches "help", "help tools" and "?".
+var helpRe = regexp.MustCompile(`(?i)^\s*(?:help(?:\s+(tools))?|\?)\s*$`)
+
+// handleHelp answers the help command with the built-in commands, the tools
+// from the tool registry, the repository the bot works on and the caller's
+// permissions. It reports whether the message was a help request.
+func (h *Handler) handleHelp(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := helpRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+
+	var text string
+	if match[1] != "" {
+		text = toolHelp(h.toolExecutor.tools.categories())
+	} else {
+		text = h.help(ctx, msg)
+	}
+
+	h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+	return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+}
+
+// help builds the main help text for the sender of msg.
+func (h *Handler) help(ctx context.Context, msg *IncomingMessage) string {
+	cfg := h.toolExecutor.cfg
+
+	var sb strings.Builder
+	sb.WriteString(":book: *StormStack Dev Bot*\n")
+	sb.WriteString("Mention me, DM me or use `/stormstack-dev` with any request in plain words, e.g. `/stormstack-dev why is UserServiceTest failing?`\n\n")
+
+	sb.WriteString("*Repository*\n")
+	sb.WriteString("• " + h.repoDescription(ctx, msg) + "\n")
+	if cfg.ShadowMode {
+		sb.WriteString("• :ghost: Shadow mode: I record what I would post or change instead of doing it\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("*Commands*\n")
+	for _, c := range builtinCommands {
+		if c.DMOnly && h.workspaces == nil {
+			continue
+		}
+		line := fmt.Sprintf("• `%s` — %s", c.Usage, c.Description)
+		switch {
+		case c.DMOnly:
+			line += " _(DMs only)_"
+		case c.Gated:
+			line += " _(approvers)_"
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("*Your permissions*\n")
+	sb.WriteString("• " + h.permissionLevel(msg.UserID) + "\n")
+	if cfg.ToolRateLimit > 0 {
+		sb.WriteString(fmt.Sprintf("• Up to %d tool calls a minute on your behalf\n", cfg.ToolRateLimit))
+	}
+	if len(cfg.RestrictedPaths) > 0 {
+		sb.WriteString(fmt.Sprintf("• %d restricted path pattern(s) are off limits to every tool\n", len(cfg.RestrictedPaths)))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("*Tools*\n")
+	for _, category := range h.toolExecutor.tools.categories() {
+		names := make([]string, 0, len(category.Tools))
+		for _, tool := range category.Tools {
+			if tool.OfTool != nil {
+				names = append(names, "`"+tool.OfTool.Name+"`")
+			}
+		}
+		sb.WriteString(fmt.Sprintf("• %s: %s\n", category.Name, strings.Join(names, ", ")))
+	}
+	sb.WriteString("_Send `help tools` for what each tool does._")
+	return sb.String()
+}
+
+// repoDescription describes the repository tools run against for msg.
+func (h *Handler) repoDescription(ctx context.Context, msg *IncomingMessage) string {
+	cfg := h.toolExecutor.cfg
+
+	var repo string
+	switch {
+	case cfg.Mode == config.ModeSandbox:
+		repo = fmt.Sprintf("`%s` (sandbox clone)", cfg.GitHubRepo)
+	case cfg.ForgeProject != "":
+		repo = fmt.Sprintf("`%s` (local checkout)", cfg.ForgeProject)
+	default:
+		repo = fmt.Sprintf("`%s` (local checkout)", cfg.RepoPath)
+	}
+	repo += " on " + cfg.Forge
+
+	if owner := workspaceOwner(h.workspaces, msg); owner != "" {
+		return repo + fmt.Sprintf(", in your personal workspace on branch `%s`", h.workspaces.worktrees.Branch(owner))
+	}
+	if h.conversationWorkspaces != nil && msg.ChannelID != "" {
+		conversationID := cmp.Or(msg.ThreadTS, msg.ChannelID+"-"+msg.UserID)
+		return repo + fmt.Sprintf(", in this conversation's own workspace, starting on branch `%s`", h.conversationWorkspaces.worktrees.Branch(conversationWorkspace(conversationID)))
+	}
+	if branch, err := h.toolExecutor.gitOps.CurrentBranch(ctx); err == nil {
+		repo += fmt.Sprintf(", currently on branch `%s`", branch)
+	}
+	return repo
+}
+
+// permissionLevel describes what userID may do.
+func (h *Handler) permissionLevel(userID string) string {
+	approvers := h.approvals.Approvers()
+	switch {
+	case len(approvers) == 0:
+		return "Everyone may approve merges others ask for in threads they take part in; `triage apply` needs approvers to be configured"
+	case h.approvals.IsApprover(userID):
+		return "*Approver*: you can run `triage apply` and approve merges in threads you take part in"
+	default:
+		mentions := make([]string, len(approvers))
+		for i, id := range approvers {
+			mentions[i] = "<@" + id + ">"
+		}
+		return "*Contributor*: you can ask for anything; merges and `triage apply` need an approver (" + strings.Join(mentions, ", ") + ")"
+	}
+}
+
+// toolHelp describes every tool in the registry, by category.
+func toolHelp(categories []claude.ToolCategory) string {
+	var sb strings.Builder
+	sb.WriteString(":hammer_and_wrench: *Tools I can use on your behalf*\n")
+	for _, category := range categories {
+		sb.WriteString("\n*" + category.Name + "*\n")
+		for _, tool := range category.Tools {
+			if tool.OfTool == nil {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("• `%s` — %s\n", tool.OfTool.Name, firstSentence(tool.OfTool.Description.Value)))
+		}
+	}
+	return sb.String()
+}
+
+// firstSentence returns text up to the end of its first sentence.
+func firstSentence(text string) string {
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
@@ -0,0 +1,241 @@
+// The App Home tab: the bot's status, its repositories, running requests and
+// recent PRs, with quick actions.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/outcomes"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/shadow"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Quick actions of the Home tab.
+const (
+	// homeActionsBlockID identifies the Home tab's quick action buttons
+	homeActionsBlockID = "stormstack-home"
+	homeSyncAction     = "home-sync"
+	homeClearAction    = "home-clear"
+	homeRefreshAction  = "home-refresh"
+	// homeRecentPRs is how many of the bot's PRs the Home tab lists
+	homeRecentPRs = 5
+)
+
+// Home renders the App Home tab and runs its quick actions.
+type Home interface {
+	// HomeView returns the blocks of userID's Home tab.
+	HomeView(ctx context.Context, userID string) []slack.Block
+	// HomeAction runs a quick action for userID and returns what happened.
+	HomeAction(ctx context.Context, userID, actionID string) string
+}
+
+// HomeWith sets what the App Home tab shows; it stays empty until set.
+func (b *Bot) HomeWith(home Home) {
+	b.home = home
+}
+
+// handleAppHomeOpened publishes the Home tab when a user opens it.
+func (b *Bot) handleAppHomeOpened(ctx context.Context, evt *slackevents.AppHomeOpenedEvent) {
+	if evt.Tab != "home" || b.home == nil {
+		return
+	}
+	b.publishHome(ctx, evt.User, "")
+}
+
+// homeAction runs a Home tab quick action and republishes the tab with the
+// outcome at the top.
+func (b *Bot) homeAction(ctx context.Context, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	if b.home == nil {
+		return
+	}
+	userID := callback.User.ID
+	b.logger.Debug("home action", "user", userID, "action", action.ActionID)
+	b.publishHome(ctx, userID, b.home.HomeAction(ctx, userID, action.ActionID))
+}
+
+// publishHome renders userID's Home tab, with an optional notice at the top,
+// and publishes it.
+func (b *Bot) publishHome(ctx context.Context, userID, notice string) {
+	blocks := b.home.HomeView(ctx, userID)
+	if notice != "" {
+		blocks = append([]slack.Block{BuildContextBlock(notice)}, blocks...)
+	}
+	blocks = b.redactBlocks(blocks)
+
+	if b.shadow != nil {
+		b.shadow.Record(shadow.Entry{
+			Kind: shadow.KindMessage,
+			Text: fmt.Sprintf("[home tab of %s, %d blocks]\n%s", userID, len(blocks), notice),
+		})
+		return
+	}
+
+	view := slack.HomeTabViewRequest{Type: slack.VTHomeTab, Blocks: slack.Blocks{BlockSet: blocks}}
+	if _, err := b.clientFor(userID).PublishViewContext(ctx, userID, view, ""); err != nil {
+		b.logger.Warn("failed to publish home tab", "user", userID, "error", err)
+	}
+}
+
+// SyncWith sets how the Home tab's sync action syncs the repository with its
+// remote. It reports whether the sync ran, as a busy checkout is left alone.
+func (h *Handler) SyncWith(sync func(ctx context.Context) (bool, error)) {
+	h.sync = sync
+}
+
+// HomeView renders userID's Home tab: the bot's status and repositories, the
+// requests being worked on, the bot's recent PRs and the quick actions.
+func (h *Handler) HomeView(ctx context.Context, userID string) []slack.Block {
+	msg := &IncomingMessage{UserID: userID}
+	blocks := []slack.Block{
+		BuildHeaderBlock("StormStack Dev Bot"),
+		BuildSectionBlock(h.status(ctx, msg)),
+		BuildSectionBlock(h.repos(ctx, msg)),
+		BuildContextBlock(fmt.Sprintf(":gear: %d requests in progress for everyone", h.tasks.count())),
+		BuildDividerBlock(),
+		BuildSectionBlock(h.recentPRs()),
+		BuildDividerBlock(),
+	}
+
+	var buttons []slack.BlockElement
+	if h.sync != nil {
+		buttons = append(buttons, homeButton(homeSyncAction, "Sync repository", nil))
+	}
+	clear := homeButton(homeClearAction, "Clear my conversations", slack.NewConfirmationBlockObject(
+		slack.NewTextBlockObject(slack.PlainTextType, "Clear your conversations?", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "I'll forget our DMs and the threads only you took part in. Threads shared with others are kept.", false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, "Clear", false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+	))
+	clear.Style = slack.StyleDanger
+	buttons = append(buttons, clear, homeButton(homeRefreshAction, "Refresh", nil))
+	blocks = append(blocks,
+		slack.NewActionBlock(homeActionsBlockID, buttons...),
+		BuildContextBlock(fmt.Sprintf("Updated <!date^%d^{date_short_pretty} at {time}|%s>",
+			time.Now().Unix(), time.Now().UTC().Format(time.RFC1123))),
+	)
+	return blocks
+}
+
+// HomeAction runs a Home tab quick action for userID and returns what
+// happened.
+func (h *Handler) HomeAction(ctx context.Context, userID, actionID string) string {
+	switch actionID {
+	case homeSyncAction:
+		if h.sync == nil {
+			return "Syncing the repository isn't available."
+		}
+		synced, err := h.sync(ctx)
+		switch {
+		case err != nil:
+			h.logger.Error("failed to sync repository", "error", err)
+			return fmt.Sprintf(":x: Failed to sync the repository: %v", err)
+		case !synced:
+			return ":hourglass: The checkout has work in progress, so I left it alone; try again once it's done."
+		default:
+			return ":arrows_counterclockwise: Synced the repository with its remote."
+		}
+	case homeClearAction:
+		return h.clearUserConversations(ctx, userID)
+	default:
+		return ""
+	}
+}
+
+// clearUserConversations forgets the open conversations only userID took
+// part in, leaving threads shared with others, and reports what it did.
+func (h *Handler) clearUserConversations(ctx context.Context, userID string) string {
+	convs, err := h.conversation.IdleConversations(ctx, 0)
+	if err != nil {
+		h.logger.Error("failed to list conversations", "error", err)
+		return fmt.Sprintf(":x: Failed to clear your conversations: %v", err)
+	}
+
+	cleared, shared := 0, 0
+	for _, conv := range convs {
+		participants := conv.Participants()
+		mine, others := false, false
+		for _, p := range participants {
+			if p.UserID == userID {
+				mine = true
+			} else {
+				others = true
+			}
+		}
+		switch {
+		case !mine:
+			continue
+		case others:
+			shared++
+			continue
+		}
+		h.approvals.Cancel(conv.ID)
+		if err := h.conversation.ClearConversation(ctx, conv.ID); err != nil {
+			h.logger.Error("failed to clear conversation", "conversation", conv.ID, "error", err)
+			continue
+		}
+		cleared++
+	}
+
+	text := fmt.Sprintf(":broom: Cleared %d of your conversations; your next requests start fresh.", cleared)
+	if cleared == 0 {
+		text = "You have no conversations of your own to clear."
+	}
+	if shared > 0 {
+		text += fmt.Sprintf(" %d threads you share with others were kept.", shared)
+	}
+	return text
+}
+
+// recentPRs lists the bot's most recent PRs with their state: the tracked
+// outcomes when they are collected, or else the PRs still open.
+func (h *Handler) recentPRs() string {
+	var lines []string
+	if h.outcomes != nil {
+		all, err := h.outcomes.Load()
+		if err != nil {
+			h.logger.Warn("failed to load PR outcomes", "error", err)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.After(all[j].CreatedAt) })
+		for _, o := range all[:min(len(all), homeRecentPRs)] {
+			lines = append(lines, fmt.Sprintf("%s <%s|#%d %s> · %s", outcomeEmoji(o.State), o.URL, o.Number, o.Title, o.CreatedAt.Format("Jan 2")))
+		}
+	} else {
+		prs := h.toolExecutor.tracker.List()
+		for i := len(prs) - 1; i >= 0 && len(lines) < homeRecentPRs; i-- {
+			pr := prs[i]
+			lines = append(lines, fmt.Sprintf(":large_green_circle: <%s|#%d> from `%s` · %s", pr.URL, pr.Number, pr.Branch, pr.CreatedAt.Format("Jan 2")))
+		}
+	}
+
+	if len(lines) == 0 {
+		return ":rocket: *Recent PRs*\nI haven't opened any PRs yet."
+	}
+	return ":rocket: *Recent PRs*\n" + strings.Join(lines, "\n")
+}
+
+// outcomeEmoji marks a PR's state.
+func outcomeEmoji(state string) string {
+	switch state {
+	case outcomes.StateMerged:
+		return ":large_purple_circle:"
+	case outcomes.StateClosed:
+		return ":red_circle:"
+	default:
+		return ":large_green_circle:"
+	}
+}
+
+// homeButton creates a quick action button, with an optional confirmation.
+func homeButton(actionID, label string, confirm *slack.ConfirmationBlockObject) *slack.ButtonBlockElement {
+	button := slack.NewButtonBlockElement(actionID, actionID, slack.NewTextBlockObject(slack.PlainTextType, label, false, false))
+	if confirm != nil {
+		button.WithConfirm(confirm)
+	}
+	return button
+}
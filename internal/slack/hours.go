@@ -0,0 +1,193 @@
+// Per-channel working hours for proactive posts.
+
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// maxDeferredPosts bounds the proactive posts held until working hours
+	maxDeferredPosts = 200
+	// anyChannel is the working hours key applying to unlisted channels
+	anyChannel = "*"
+)
+
+// dayNames maps three-letter day names to weekdays.
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// window is the time of day, on some days of the week, a channel may be
+// posted to without being asked.
+type window struct {
+	days       [7]bool
+	start, end int // minutes after midnight; end <= start spans midnight
+	loc        *time.Location
+}
+
+// contains reports whether t falls in the window. A window spanning midnight
+// belongs to the day it starts on.
+func (w window) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+	if minute >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+// WorkingHours holds the windows in which channels may receive non-urgent
+// proactive posts such as digests and watchdog alerts. Outside them a
+// channel is in do-not-disturb and those posts wait; replies to mentions and
+// DMs are never held. Channels without working hours are always open.
+type WorkingHours struct {
+	windows map[string]window
+}
+
+// NewWorkingHours parses working hours specs of the form
+// "channel=[days] HH:MM-HH:MM [timezone]", e.g.
+// "C0123=Mon-Fri 09:00-17:30 Europe/London". Days are a day or a range of
+// days and default to every day, the timezone defaults to the server's, and
+// a channel of "*" applies to all channels not listed.
+func NewWorkingHours(specs []string) (*WorkingHours, error) {
+	hours := &WorkingHours{windows: make(map[string]window, len(specs))}
+	for _, spec := range specs {
+		channel, rest, ok := strings.Cut(spec, "=")
+		channel = strings.TrimSpace(channel)
+		if !ok || channel == "" {
+			return nil, fmt.Errorf("invalid working hours %q: must be channel=[days] HH:MM-HH:MM [timezone]", spec)
+		}
+		w, err := parseWindow(strings.Fields(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid working hours %q: %w", spec, err)
+		}
+		hours.windows[channel] = w
+	}
+	return hours, nil
+}
+
+// parseWindow parses "[days] HH:MM-HH:MM [timezone]".
+func parseWindow(fields []string) (window, error) {
+	w := window{loc: time.Local}
+	if len(fields) > 0 && !strings.Contains(fields[0], ":") {
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return w, err
+		}
+		w.days = days
+		fields = fields[1:]
+	} else {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	if len(fields) == 0 || len(fields) > 2 {
+		return w, fmt.Errorf("expected a time range such as 09:00-17:30")
+	}
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return w, fmt.Errorf("invalid time range %q", fields[0])
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return w, err
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("time range %q is empty", fields[0])
+	}
+
+	if len(fields) == 2 {
+		if w.loc, err = time.LoadLocation(fields[1]); err != nil {
+			return w, fmt.Errorf("unknown timezone %q", fields[1])
+		}
+	}
+	return w, nil
+}
+
+// parseDays parses a day ("Mon") or range of days ("Mon-Fri", "Fri-Mon").
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	from, to, isRange := strings.Cut(strings.ToLower(s), "-")
+	first, ok := dayNames[from]
+	if !ok {
+		return days, fmt.Errorf("unknown day %q", from)
+	}
+	last := first
+	if isRange {
+		if last, ok = dayNames[to]; !ok {
+			return days, fmt.Errorf("unknown day %q", to)
+		}
+	}
+	for d := first; ; d = (d + 1) % 7 {
+		days[d] = true
+		if d == last {
+			break
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses "HH:MM" as minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Open reports whether channelID may receive proactive posts at t.
+func (h *WorkingHours) Open(channelID string, t time.Time) bool {
+	if h == nil {
+		return true
+	}
+	w, ok := h.windows[channelID]
+	if !ok {
+		if w, ok = h.windows[anyChannel]; !ok {
+			return true
+		}
+	}
+	return w.contains(t)
+}
+
+// PostProactive posts a message the bot sends on its own, such as a digest
+// or an alert, unless the channel is outside its working hours, in which case
+// the message waits for the next window (see FlushDeferred).
+func (b *Bot) PostProactive(channelID string, msg *OutgoingMessage) error {
+	if b.hours.Open(channelID, time.Now()) {
+		return b.sendMessage(channelID, msg)
+	}
+	b.deferred.add(channelID, msg)
+	b.logger.Info("deferred proactive post until working hours", "channel", channelID)
+	return nil
+}
+
+// FlushDeferred posts the deferred messages whose channels are now within
+// working hours, keeping the rest. It is meant to run as a scheduled job.
+func (b *Bot) FlushDeferred() error {
+	var failed int
+	for _, pending := range b.deferred.drain() {
+		if !b.hours.Open(pending.channelID, time.Now()) {
+			b.deferred.add(pending.channelID, pending.msg)
+			continue
+		}
+		if err := b.sendMessage(pending.channelID, pending.msg); err != nil {
+			b.logger.Warn("failed to post deferred message", "channel", pending.channelID, "error", err)
+			b.deferred.add(pending.channelID, pending.msg)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to post %d deferred messages", failed)
+	}
+	return nil
+}
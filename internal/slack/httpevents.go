@@ -0,0 +1,224 @@
+// The Events API over HTTP, for workspaces that can't use Socket Mode: Slack
+// posts events, slash commands and interactions to the bot, which verifies
+// their signature and acknowledges them within Slack's three seconds while
+// the work goes on in the background.
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	// maxHTTPEventSize bounds the requests Slack posts.
+	maxHTTPEventSize = 1 << 20
+	// httpEventQueue is how many received requests may wait for the event loop.
+	httpEventQueue = 256
+	// httpAckTimeout is how long a request waits for its acknowledgement before
+	// it is answered empty, safely within Slack's three second limit.
+	httpAckTimeout = 2500 * time.Millisecond
+)
+
+// Kinds of requests Slack posts, by path.
+const (
+	httpEventsPath      = "/slack/events"
+	httpCommandsPath    = "/slack/commands"
+	httpInteractivePath = "/slack/interactive"
+)
+
+// ack acknowledges a request, with an optional response payload such as a
+// form's validation errors: over the Socket Mode connection, or as the
+// response to Slack's HTTP request.
+func (b *Bot) ack(req *socketmode.Request, payload ...any) {
+	if b.socketClient != nil {
+		b.socketClient.Ack(*req, payload...)
+		return
+	}
+
+	var response any
+	if len(payload) > 0 {
+		response = payload[0]
+	}
+	if waiting, ok := b.acks.LoadAndDelete(req.EnvelopeID); ok {
+		waiting.(chan any) <- response
+	}
+}
+
+// serveHTTP receives Slack's requests over HTTP until the context is
+// cancelled, feeding them to the event loop as Socket Mode would.
+func (b *Bot) serveHTTP(ctx context.Context) error {
+	mux := http.NewServeMux()
+	for _, path := range []string{httpEventsPath, httpCommandsPath, httpInteractivePath} {
+		mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+			b.handleHTTPRequest(w, r, path)
+		})
+	}
+	if b.oauthClientID != "" {
+		mux.HandleFunc("GET "+oauthInstallPath, b.handleInstall)
+		mux.HandleFunc("GET "+oauthCallbackPath, b.handleOAuthCallback)
+	}
+
+	srv := &http.Server{
+		Addr:              b.eventsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	// There is no connection to lose: Slack calls in
+	b.markConnected()
+	b.logger.Info("receiving Slack events over HTTP", "addr", b.eventsAddr, "bot_user_id", b.botUserID)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve Slack events: %w", err)
+	}
+	return ctx.Err()
+}
+
+// handleHTTPRequest verifies a request from Slack, passes it to the event
+// loop and answers with its acknowledgement.
+func (b *Bot) handleHTTPRequest(w http.ResponseWriter, r *http.Request, path string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPEventSize))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	if err := b.verifyHTTPRequest(r.Header, body); err != nil {
+		b.logger.Warn("rejected Slack request", "path", path, "remote", r.RemoteAddr, "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	evt, challenge, err := parseHTTPEvent(r, path, body)
+	switch {
+	case err != nil:
+		b.logger.Warn("failed to parse Slack request", "path", path, "error", err)
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	case challenge != "":
+		// Slack checks the request URL when it is configured
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, challenge)
+		return
+	case evt == nil:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	waiting := make(chan any, 1)
+	b.acks.Store(evt.Request.EnvelopeID, waiting)
+	defer b.acks.Delete(evt.Request.EnvelopeID)
+
+	timeout := time.NewTimer(httpAckTimeout)
+	defer timeout.Stop()
+
+	select {
+	case b.events <- *evt:
+	case <-timeout.C:
+		// Slack retries events that aren't acknowledged
+		b.logger.Error("event queue full, dropping Slack request", "path", path)
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return
+	}
+
+	var response any
+	select {
+	case response = <-waiting:
+	case <-timeout.C:
+		b.logger.Warn("Slack request not acknowledged in time, answering empty", "path", path)
+	}
+	if response == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// verifyHTTPRequest checks a request's signature against the signing secret;
+// it also rejects stale requests, so they can't be replayed.
+func (b *Bot) verifyHTTPRequest(header http.Header, body []byte) error {
+	verifier, err := slack.NewSecretsVerifier(header, b.signingSecret)
+	if err != nil {
+		return err
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return err
+	}
+	return verifier.Ensure()
+}
+
+// parseHTTPEvent turns a request from Slack into the event Socket Mode would
+// have delivered. It returns the challenge to echo for URL verification, and
+// neither for requests that need nothing but an empty answer.
+func parseHTTPEvent(r *http.Request, path string, body []byte) (*socketmode.Event, string, error) {
+	req := &socketmode.Request{EnvelopeID: newEnvelopeID()}
+	req.RetryAttempt, _ = strconv.Atoi(r.Header.Get("X-Slack-Retry-Num"))
+	req.RetryReason = r.Header.Get("X-Slack-Retry-Reason")
+
+	switch path {
+	case httpEventsPath:
+		event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse event: %w", err)
+		}
+		switch event.Type {
+		case slackevents.URLVerification:
+			verification, ok := event.Data.(*slackevents.EventsAPIURLVerificationEvent)
+			if !ok {
+				return nil, "", errors.New("malformed URL verification")
+			}
+			return nil, verification.Challenge, nil
+		case slackevents.CallbackEvent:
+			return &socketmode.Event{Type: socketmode.EventTypeEventsAPI, Data: event, Request: req}, "", nil
+		default:
+			return nil, "", nil
+		}
+
+	case httpCommandsPath:
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		cmd, err := slack.SlashCommandParse(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse slash command: %w", err)
+		}
+		return &socketmode.Event{Type: socketmode.EventTypeSlashCommand, Data: cmd, Request: req}, "", nil
+
+	default:
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse interaction: %w", err)
+		}
+		var callback slack.InteractionCallback
+		if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+			return nil, "", fmt.Errorf("failed to parse interaction: %w", err)
+		}
+		return &socketmode.Event{Type: socketmode.EventTypeInteractive, Data: callback, Request: req}, "", nil
+	}
+}
+
+// newEnvelopeID returns an ID matching a request to its acknowledgement.
+func newEnvelopeID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "http-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,232 @@
+// The tool middleware chain.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/approval"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/redact"
+)
+
+// ToolHandler executes a single tool call.
+type ToolHandler func(ctx context.Context, name string, input json.RawMessage) (string, error)
+
+// ToolMiddleware wraps a ToolHandler with cross-cutting behaviour (logging,
+// auth, rate limiting, ...) so individual tools don't have to implement it.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// Chain wraps handler in middleware. The first middleware is the outermost,
+// so it sees each call first and its result last.
+func Chain(handler ToolHandler, middleware ...ToolMiddleware) ToolHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// LoggingMiddleware logs every tool call with its duration and outcome.
+func LoggingMiddleware(logger *slog.Logger) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			logger.Debug("executing tool", "name", name)
+			start := time.Now()
+
+			result, err := next(ctx, name, input)
+			if err != nil {
+				logger.Warn("tool failed", "name", name, "duration", time.Since(start), "error", err)
+			} else {
+				logger.Debug("tool finished", "name", name, "duration", time.Since(start), "bytes", len(result))
+			}
+			return result, err
+		}
+	}
+}
+
+// ApprovalSummary describes a gated tool call for the humans asked to approve
+// it. An empty summary means this call needs no approval.
+type ApprovalSummary func(input json.RawMessage) (string, error)
+
+// AuthMiddleware holds calls to gated tools until a human approves them,
+// using request to park the call. Approved re-runs carry approval.WithApproved.
+func AuthMiddleware(gates map[string]ApprovalSummary, request func(ctx context.Context, tool string, input json.RawMessage, summary string) (string, error)) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			gate, ok := gates[name]
+			if !ok || approval.IsApproved(ctx) {
+				return next(ctx, name, input)
+			}
+
+			summary, err := gate(input)
+			if err != nil {
+				return "", err
+			}
+			if summary == "" {
+				return next(ctx, name, input)
+			}
+			return request(ctx, name, input, summary)
+		}
+	}
+}
+
+// RateLimitMiddleware limits each Slack user to limit tool calls per window.
+// A limit of 0 disables rate limiting.
+func RateLimitMiddleware(limit int, window time.Duration) ToolMiddleware {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string][]time.Time)
+	)
+
+	// allow records a call for key if it is within the limit
+	allow := func(key string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		recent := calls[key][:0]
+		for _, t := range calls[key] {
+			if now.Sub(t) < window {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) >= limit {
+			calls[key] = recent
+			return false
+		}
+		calls[key] = append(recent, now)
+		return true
+	}
+
+	return func(next ToolHandler) ToolHandler {
+		if limit <= 0 {
+			return next
+		}
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			key := ""
+			if info, ok := ConversationFromContext(ctx); ok {
+				key = info.UserID
+			}
+			if !allow(key) {
+				return "", fmt.Errorf("rate limit exceeded: at most %d tool calls per %s, try again shortly", limit, window)
+			}
+			return next(ctx, name, input)
+		}
+	}
+}
+
+// RedactionMiddleware scrubs secrets from tool results and errors before they
+// are sent to Claude.
+func RedactionMiddleware(redactor *redact.Redactor) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		if redactor == nil {
+			return next
+		}
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			result, err := next(ctx, name, input)
+			result = redactor.Redact(result)
+			if err != nil {
+				if redacted := redactor.Redact(err.Error()); redacted != err.Error() {
+					err = errors.New(redacted)
+				}
+			}
+			return result, err
+		}
+	}
+}
+
+// slowToolHints suggest how to speed up tools that commonly run long.
+var slowToolHints = map[string]string{
+	"search_code":          "consider narrowing the path",
+	"list_files":           "consider a more specific pattern",
+	"get_tree":             "consider a smaller max_depth or a subdirectory",
+	"run_tests":            "consider running only the affected tests",
+	"run_build":            "consider building only the affected module",
+	"run_lint":             "consider linting only the changed files",
+	"run_command":          "consider a narrower command",
+	"list_dependencies":    "consider listing only direct dependencies",
+	"scan_vulnerabilities": "consider running a single scanner",
+}
+
+// metricsMiddleware records per-tool call counts, failures, durations and
+// result sizes, and warns the thread when a call exceeds the slow threshold.
+func (e *ToolExecutor) metricsMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		start := time.Now()
+		result, err := next(ctx, name, input)
+		duration := time.Since(start)
+
+		prefix := "tool." + name
+		e.metrics.Inc(prefix+".calls", 1)
+		e.metrics.Observe(prefix+".duration", duration)
+		e.metrics.Inc(prefix+".result_bytes", int64(len(result)))
+		if err != nil {
+			e.metrics.Inc(prefix+".failures", 1)
+		}
+
+		if threshold := e.cfg.SlowToolThreshold; threshold > 0 && duration > threshold {
+			e.warnSlow(ctx, name, duration)
+		}
+		return result, err
+	}
+}
+
+// warnSlow logs a slow tool call and tells the thread about it.
+func (e *ToolExecutor) warnSlow(ctx context.Context, name string, duration time.Duration) {
+	e.metrics.Inc("tool."+name+".slow", 1)
+	e.logger.Warn("slow tool call", "name", name, "duration", duration)
+
+	info, ok := ConversationFromContext(ctx)
+	if !ok || e.notify == nil {
+		return
+	}
+
+	text := fmt.Sprintf(":hourglass: `%s` took %s", name, duration.Round(time.Second))
+	if hint := slowToolHints[name]; hint != "" {
+		text += " — " + hint
+	}
+	if err := e.notify(info.ChannelID, info.ThreadTS, text); err != nil {
+		e.logger.Warn("failed to post slow tool warning", "error", err)
+	}
+}
+
+// dryRunMiddleware records mutating tool calls instead of performing them
+// when the bot runs in shadow mode. It runs ahead of the approval gate, so a
+// recorded call never asks anyone for approval.
+func (e *ToolExecutor) dryRunMiddleware(next ToolHandler) ToolHandler {
+	if e.shadow == nil {
+		return next
+	}
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		if !e.isReadOnly(name, input) {
+			return e.recordShadow(ctx, name, input), nil
+		}
+		return next(ctx, name, input)
+	}
+}
+
+// auditMiddleware records who ran each mutating tool, logs every call for the
+// admin digest and reports it to the registered observer.
+func (e *ToolExecutor) auditMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		result, err := next(ctx, name, input)
+
+		if !e.isReadOnly(name, input) {
+			attrs := []any{"tool", name, "ok", err == nil}
+			if info, ok := ConversationFromContext(ctx); ok {
+				attrs = append(attrs, "user", info.UserID, "conversation", info.ConversationID)
+			}
+			e.logger.Info("tool audit", attrs...)
+		}
+		e.recordActivity(ctx, name, input, result, err)
+		if e.observer != nil {
+			e.observer(name, input, result, err)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,250 @@
+// Conversion of the GitHub-flavored markdown Claude writes to Slack mrkdwn.
+
+package slack
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// headingRe matches ATX headings like "## Summary".
+	headingRe = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	// ruleRe matches thematic breaks like "---" or "* * *".
+	ruleRe = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	// bulletRe matches list items marked with "-", "*" or "+".
+	bulletRe = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[([ xX])\]\s+)?`)
+	// fenceRe matches the opening or closing line of a fenced code block.
+	fenceRe = regexp.MustCompile("^\\s*(```|~~~)")
+	// languageRe matches the language hint after a code fence, like "go".
+	languageRe = regexp.MustCompile(`^[\w+#.-]*\s*$`)
+	// tableSeparatorRe matches the line under a table's header, like "|---|:-:|".
+	tableSeparatorRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	// imageRe matches images like "![alt](url)".
+	imageRe = regexp.MustCompile(`!\[([^\]]*)\]\(\s*(\S+?)(?:\s+"[^"]*")?\s*\)`)
+	// linkRe matches links like "[text](url)".
+	linkRe = regexp.MustCompile(`\[([^\]]+)\]\(\s*(\S+?)(?:\s+"[^"]*")?\s*\)`)
+	// boldItalicRe matches "***text***".
+	boldItalicRe = regexp.MustCompile(`\*\*\*(\S(?:.*?\S)?)\*\*\*`)
+	// boldRe matches "**text**".
+	boldRe = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	// underscoreBoldRe matches "__text__" that isn't part of a word, so
+	// identifiers like __init__ are left alone.
+	underscoreBoldRe = regexp.MustCompile(`(^|[^\w])__(\S(?:.*?\S)?)__([^\w]|$)`)
+	// strikeRe matches "~~text~~".
+	strikeRe = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+)
+
+// ToMrkdwn converts GitHub-flavored markdown to Slack mrkdwn: headings become
+// bold lines, **bold** becomes *bold*, ~~struck~~ becomes ~struck~, links
+// become <url|text>, list bullets become •, and tables, which Slack can't
+// show, become aligned code blocks. Code is left as it is. Single asterisks
+// are kept, as they are already bold in Slack, so converting mrkdwn changes
+// nothing.
+func ToMrkdwn(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+	// fence is the marker of the code block the line is in, if any
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if match := fenceRe.FindStringSubmatch(line); match != nil && (fence == "" || match[1] == fence) {
+			marker := match[1]
+			indent, rest, _ := strings.Cut(line, marker)
+			switch {
+			case fence == "" && strings.Contains(rest, marker):
+				// A code block on a single line, as Slack writes them
+				out = append(out, line)
+			case fence == "":
+				// Slack shows a fence's language as the block's first line
+				if languageRe.MatchString(rest) {
+					rest = ""
+				}
+				fence = marker
+				out = append(out, indent+"```"+rest)
+			default:
+				fence = ""
+				out = append(out, indent+"```"+rest)
+			}
+			continue
+		}
+		if fence != "" {
+			out = append(out, line)
+			continue
+		}
+
+		if i+1 < len(lines) && isTableRow(line) && tableSeparatorRe.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "|") {
+			end := i + 2
+			for end < len(lines) && isTableRow(lines[end]) {
+				end++
+			}
+			out = append(out, renderTable(append([]string{line}, lines[i+2:end]...)))
+			i = end - 1
+			continue
+		}
+
+		out = append(out, convertLine(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+// convertLine converts a line of markdown outside code blocks.
+func convertLine(line string) string {
+	if ruleRe.MatchString(line) {
+		return "──────────"
+	}
+	if match := headingRe.FindStringSubmatch(line); match != nil {
+		heading := strings.NewReplacer("**", "", "__", "").Replace(match[1])
+		if heading == "" {
+			return ""
+		}
+		return "*" + convertInline(heading) + "*"
+	}
+	if match := bulletRe.FindStringSubmatch(line); match != nil {
+		indent := match[1]
+		marker := "•"
+		if len(indent) >= 2 {
+			marker = "◦"
+		}
+		switch match[2] {
+		case " ":
+			marker = "☐"
+		case "x", "X":
+			marker = "☑"
+		}
+		return indent + marker + " " + convertInline(line[len(match[0]):])
+	}
+	return convertInline(line)
+}
+
+// convertInline converts the inline markup of text, leaving code spans as
+// they are.
+func convertInline(text string) string {
+	parts := strings.Split(text, "`")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = convertMarkup(parts[i])
+	}
+	return strings.Join(parts, "`")
+}
+
+// convertMarkup converts the emphasis and links of text without code spans.
+func convertMarkup(text string) string {
+	text = imageRe.ReplaceAllStringFunc(text, func(s string) string {
+		match := imageRe.FindStringSubmatch(s)
+		return slackLink(match[2], match[1])
+	})
+	text = linkRe.ReplaceAllStringFunc(text, func(s string) string {
+		match := linkRe.FindStringSubmatch(s)
+		return slackLink(match[2], match[1])
+	})
+	text = boldItalicRe.ReplaceAllString(text, "*_${1}_*")
+	text = boldRe.ReplaceAllString(text, "*${1}*")
+	text = underscoreBoldRe.ReplaceAllString(text, "${1}*${2}*${3}")
+	return strikeRe.ReplaceAllString(text, "~${1}~")
+}
+
+// slackLink formats a link, dropping its text when it only repeats the URL.
+func slackLink(url, text string) string {
+	text = strings.NewReplacer("**", "", "`", "", "|", "/").Replace(text)
+	if text == "" || text == url {
+		return "<" + url + ">"
+	}
+	return FormatLink(url, text)
+}
+
+// isTableRow reports whether a line looks like a row of a markdown table.
+func isTableRow(line string) bool {
+	return strings.Contains(line, "|") && strings.TrimSpace(line) != ""
+}
+
+// renderTable lays out the rows of a markdown table, its header first, as a
+// code block with aligned columns. Markup in the cells is removed, as a code
+// block shows it literally.
+func renderTable(rows []string) string {
+	var cells [][]string
+	var widths []int
+	for _, row := range rows {
+		row = strings.TrimSpace(row)
+		row = strings.TrimPrefix(row, "|")
+		row = strings.TrimSuffix(row, "|")
+		var rowCells []string
+		for j, cell := range splitTableRow(row) {
+			cell = plainCell(cell)
+			rowCells = append(rowCells, cell)
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+		cells = append(cells, rowCells)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```\n")
+	for i, row := range cells {
+		var line strings.Builder
+		for j, cell := range row {
+			if j > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			if j < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+			}
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+		if i == 0 {
+			total := 0
+			for j, w := range widths {
+				total += w
+				if j > 0 {
+					total += 2
+				}
+			}
+			sb.WriteString(strings.Repeat("-", total))
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
+
+// splitTableRow splits a table row into its cells at pipes that aren't
+// escaped or inside code spans.
+func splitTableRow(row string) []string {
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(row); i++ {
+		c := row[i]
+		switch {
+		case c == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, cell.String())
+}
+
+// plainCell strips the markup of a table cell, keeping link targets.
+func plainCell(cell string) string {
+	cell = linkRe.ReplaceAllStringFunc(cell, func(s string) string {
+		match := linkRe.FindStringSubmatch(s)
+		if match[1] == match[2] {
+			return match[2]
+		}
+		return match[1] + " (" + match[2] + ")"
+	})
+	cell = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "").Replace(cell)
+	return strings.TrimSpace(cell)
+}
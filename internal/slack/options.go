@@ -0,0 +1,172 @@
+// Clarifying questions answered with option buttons.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// Limits of Slack blocks.
+const (
+	// optionsBlockID identifies the actions block holding option buttons
+	optionsBlockID = "stormstack-options"
+	// maxSectionText is the most text a section block holds
+	maxSectionText = 3000
+	// maxBlocks is the most blocks a message holds
+	maxBlocks = 50
+)
+
+// question is a clarifying question Claude asked with ask_question.
+type question struct {
+	text    string
+	options []string
+}
+
+// questions holds the question each conversation is waiting to show with
+// its reply. It is shared by all tool executors.
+type questions struct {
+	mu      sync.Mutex
+	pending map[string]question
+}
+
+// newQuestions creates an empty question store.
+func newQuestions() *questions {
+	return &questions{pending: make(map[string]question)}
+}
+
+// ask records the question a conversation's reply should end with,
+// replacing any earlier one.
+func (q *questions) ask(conversationID string, text string, options []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[conversationID] = question{text: text, options: options}
+}
+
+// take removes and returns the question waiting in a conversation.
+func (q *questions) take(conversationID string) (question, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending, ok := q.pending[conversationID]
+	delete(q.pending, conversationID)
+	return pending, ok
+}
+
+// optionActions creates the actions block of a button per option of q.
+func optionActions(q question) slack.Block {
+	buttons := make([]slack.BlockElement, len(q.options))
+	for i, option := range q.options {
+		buttons[i] = slack.NewButtonBlockElement(fmt.Sprintf("option-%d", i), option,
+			slack.NewTextBlockObject(slack.PlainTextType, option, false, false))
+	}
+	return slack.NewActionBlock(optionsBlockID, buttons...)
+}
+
+// textSections splits mrkdwn text into at most limit section blocks, breaking
+// at line ends where possible.
+func textSections(text string, limit int) []slack.Block {
+	var blocks []slack.Block
+	for text != "" && len(blocks) < limit {
+		chunk := text
+		if len(chunk) > maxSectionText {
+			chunk = chunk[:maxSectionText]
+			if i := strings.LastIndex(chunk, "\n"); i > 0 {
+				chunk = chunk[:i+1]
+			}
+		}
+		text = text[len(chunk):]
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, chunk, false, false), nil, nil))
+	}
+	return blocks
+}
+
+// handleInteractive processes clicks on the bot's buttons, its shortcuts and
+// form submissions.
+func (b *Bot) handleInteractive(ctx context.Context, evt socketmode.Event) {
+	callback, ok := evt.Data.(slack.InteractionCallback)
+	if !ok {
+		return
+	}
+	if !b.teams.allows(callback.Team.ID) {
+		b.ack(evt.Request)
+		b.logger.Warn("ignoring interaction from a workspace that isn't allowed", "team", callback.Team.ID)
+		return
+	}
+	b.teams.learn(callback.Team.ID, callback.Channel.ID, callback.User.ID)
+
+	// Form submissions are acknowledged with their validation errors
+	if callback.Type == slack.InteractionTypeViewSubmission {
+		if callback.View.CallbackID == taskFormID {
+			b.submitTask(ctx, evt, &callback)
+			return
+		}
+		b.ack(evt.Request)
+		return
+	}
+	b.ack(evt.Request)
+
+	switch callback.Type {
+	case slack.InteractionTypeShortcut, slack.InteractionTypeMessageAction:
+		if callback.CallbackID == taskShortcutID {
+			// A message the shortcut was used on becomes the task's description
+			b.openTaskForm(ctx, callback.TriggerID, callback.User.ID, callback.Channel.ID, callback.Message.Text)
+		}
+		return
+	case slack.InteractionTypeBlockActions:
+	default:
+		return
+	}
+	for _, action := range callback.ActionCallback.BlockActions {
+		switch {
+		case action.BlockID == optionsBlockID:
+			b.answerOption(ctx, &callback, action)
+			return
+		case action.BlockID == planBlockID:
+			b.answerPlan(ctx, &callback, action)
+			return
+		case strings.HasPrefix(action.BlockID, outputBlockID) && action.ActionID == outputActionID:
+			b.showOutput(ctx, &callback, action)
+			return
+		case action.BlockID == homeActionsBlockID:
+			// Syncing takes a while, and must not hold up other events
+			go b.homeAction(ctx, &callback, action)
+			return
+		}
+	}
+}
+
+// answerOption replaces a question's buttons with the chosen answer, so it
+// cannot be answered twice, and feeds the answer into the conversation as if
+// the user had typed it.
+func (b *Bot) answerOption(ctx context.Context, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	channelID := callback.Channel.ID
+	choice := action.Value
+
+	text := callback.Message.Text
+	blocks := textSections(text, maxBlocks-1)
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(":white_check_mark: <@%s> chose *%s*", callback.User.ID, choice), false, false)))
+	if _, _, _, err := b.clientFor(channelID).UpdateMessage(channelID, callback.Message.Timestamp,
+		slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...)); err != nil {
+		b.logger.Warn("failed to update question", "channel", channelID, "error", err)
+	}
+
+	b.logger.Debug("option chosen", "user", callback.User.ID, "channel", channelID, "choice", choice)
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      choice,
+		UserID:    callback.User.ID,
+		ChannelID: channelID,
+		// Replies to slash commands are not threaded, and neither is the answer
+		ThreadTS: callback.Message.ThreadTimestamp,
+		IsDM:     strings.HasPrefix(channelID, "D"),
+	})
+}
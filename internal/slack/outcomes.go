@@ -0,0 +1,64 @@
+// The PR outcomes report command.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/outcomes"
+)
+
+// defaultOutcomeDays is the period the PR outcomes report covers by default.
+const defaultOutcomeDays = 30
+
+// prStatsRe matches "pr stats", "pr stats 90" and "pr stats 90d".
+var prStatsRe = regexp.MustCompile(`(?i)^\s*pr\s+(?:stats|outcomes)(?:\s+(\d+)\s*d?)?\s*$`)
+
+// UseOutcomes sets the store of bot PR outcomes reported by the pr stats
+// command.
+func (h *Handler) UseOutcomes(store *outcomes.Store) {
+	h.outcomes = store
+}
+
+// handlePRStats answers the pr stats command with how the bot's PRs opened
+// in the last n days (30 by default) fared, attaching every PR as a CSV
+// file. It reports whether the message was a pr stats command.
+func (h *Handler) handlePRStats(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := prStatsRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	reply := func(text string) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	}
+
+	if h.outcomes == nil {
+		return reply("PR outcomes are only tracked on GitHub, with `STORMSTACK_PR_OUTCOMES_INTERVAL` set.")
+	}
+	days := defaultOutcomeDays
+	if match[1] != "" {
+		days, _ = strconv.Atoi(match[1])
+	}
+
+	stored, err := h.outcomes.Load()
+	if err != nil {
+		h.logger.Error("failed to load PR outcomes", "error", err)
+		return reply(fmt.Sprintf("Sorry, I couldn't load the PR outcomes: %v", err))
+	}
+	report := outcomes.Summarize(time.Now().AddDate(0, 0, -days), stored)
+
+	out, _ := reply(report.Format())
+	if report.Opened > 0 {
+		out.Files = []File{{
+			Name:    fmt.Sprintf("pr-outcomes-%dd.csv", days),
+			Title:   "Bot PR outcomes",
+			Content: report.CSV(),
+		}}
+	}
+	return out, true
+}
@@ -0,0 +1,245 @@
+// Implementation plans approved in the thread before the bot changes code
+// for large tasks.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+)
+
+// planBlockID identifies the actions block holding a plan's buttons.
+const planBlockID = "stormstack-plan"
+
+// Actions of a plan's buttons.
+const (
+	planApproveAction = "plan-approve"
+	planRefineAction  = "plan-refine"
+)
+
+// planCommandRe matches "approve plan" and "refine the plan", which the
+// plan's buttons send.
+var planCommandRe = regexp.MustCompile(`(?i)^\s*(approve|refine)\s+(?:the\s+)?plan\s*$`)
+
+// planStore keeps the plans proposed in conversations.
+type planStore interface {
+	ProposePlan(ctx context.Context, conversationID, channelID string, plan *storage.Plan) error
+	Plan(ctx context.Context, conversationID string) *storage.Plan
+}
+
+// proposePlan records a plan for the conversation. Plans changing fewer
+// than STORMSTACK_PLAN_MIN_FILES files, and those of threads the bot
+// started itself, are approved at once; others are shown with buttons to
+// approve or refine them. A plan replacing an approved one counts the files
+// of both, so a large change can't be split into small plans.
+func (e *ToolExecutor) proposePlan(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ProposePlanParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+	info, ok := ConversationFromContext(ctx)
+	if !ok || e.plans == nil {
+		return "", fmt.Errorf("plans can only be proposed in a Slack conversation")
+	}
+
+	plan := &storage.Plan{
+		Summary:    params.Summary,
+		Tests:      params.Tests,
+		Status:     storage.PlanProposed,
+		ProposedAt: time.Now(),
+	}
+	for _, file := range params.Files {
+		plan.Files = append(plan.Files, storage.PlanFile{Path: file.Path, Change: file.Change})
+	}
+	files := plan.Files
+	if previous := e.plans.Plan(ctx, info.ConversationID); previous.Approved() {
+		for _, file := range previous.Files {
+			if !plan.Includes(file.Path) {
+				files = append(files, file)
+			}
+		}
+	}
+	small := len(files) < e.cfg.PlanMinFiles || info.UserID == ""
+	if small {
+		// Files already approved stay part of the plan
+		plan.Files = files
+		plan.Status = storage.PlanApproved
+		plan.ApprovedAt = plan.ProposedAt
+	}
+	if err := e.plans.ProposePlan(ctx, info.ConversationID, info.ChannelID, plan); err != nil {
+		return "", err
+	}
+
+	if small {
+		return "The plan is small enough to need no approval. Go ahead and implement it.", nil
+	}
+	e.addCard(ctx, "plan", renderPlan(plan))
+	return "The plan will be shown under your reply with buttons to approve or refine it. End your turn now with a short note asking for approval, and don't change any code until it is approved: the approval, or what to change, will arrive as the user's next message.", nil
+}
+
+// renderPlan renders a plan as a card with buttons to approve or refine it.
+func renderPlan(plan *storage.Plan) []slack.Block {
+	var files strings.Builder
+	for _, file := range plan.Files {
+		files.WriteString(fmt.Sprintf("• `%s`: %s\n", file.Path, file.Change))
+	}
+
+	approve := slack.NewButtonBlockElement(planApproveAction, "approve plan",
+		slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false))
+	approve.Style = slack.StylePrimary
+	refine := slack.NewButtonBlockElement(planRefineAction, "refine plan",
+		slack.NewTextBlockObject(slack.PlainTextType, "Refine", false, false))
+
+	return []slack.Block{
+		BuildSectionBlock(":clipboard: *Implementation plan*\n" + TruncateText(plan.Summary, maxFieldText)),
+		BuildSectionBlock(fmt.Sprintf("*Files* (%d)\n%s", len(plan.Files), TruncateText(files.String(), maxSectionText-20))),
+		BuildSectionBlock("*Tests*\n" + TruncateText(plan.Tests, maxFieldText)),
+		slack.NewActionBlock(planBlockID, approve, refine),
+	}
+}
+
+// planError refuses a tool call that would change code in a conversation
+// whose plan hasn't been approved, or files the approved plan doesn't list,
+// or returns nil when the call may run. Threads the bot started itself,
+// such as failed workflow fixes, need no plan.
+func (h *Handler) planError(ctx context.Context, e *ToolExecutor, name string, input json.RawMessage) error {
+	if !e.cfg.PlanApproval {
+		return nil
+	}
+	info, ok := ConversationFromContext(ctx)
+	if !ok || info.UserID == "" {
+		return nil
+	}
+	if tool, ok := e.tools.lookup(name); !ok || (tool.Category != categoryModification && name != "bump_dependency") {
+		return nil
+	}
+
+	plan := h.conversation.Plan(ctx, info.ConversationID)
+	switch {
+	case plan == nil:
+		return fmt.Errorf("propose a plan with propose_plan before changing code, so %s can't run yet", name)
+	case !plan.Approved():
+		return fmt.Errorf("the plan is waiting for approval, so %s can't run yet; end your turn and wait for it to be approved", name)
+	}
+	for _, path := range editedPaths(name, input) {
+		if !plan.Includes(path) {
+			return fmt.Errorf("%s isn't one of the files of the approved plan, so %s can't change it; propose a revised plan with propose_plan listing every file you'll change", path, name)
+		}
+	}
+	return nil
+}
+
+// editedPaths returns the files a call of a code changing tool edits, or
+// none when its input doesn't say, as for bump_dependency.
+func editedPaths(name string, input json.RawMessage) []string {
+	switch name {
+	case "write_file", "edit_file", "format_file":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(input, &params) != nil || params.Path == "" {
+			return nil
+		}
+		return []string{params.Path}
+	case "apply_changes":
+		var params claude.ApplyChangesParams
+		if json.Unmarshal(input, &params) != nil {
+			return nil
+		}
+		paths := make([]string, 0, len(params.Changes))
+		for _, change := range params.Changes {
+			paths = append(paths, change.Path)
+		}
+		return paths
+	}
+	return nil
+}
+
+// handlePlan answers the plan's buttons and their typed equivalents. An
+// approval by someone in the thread is recorded and passed on to Claude,
+// with expandPlanApproval; anything else is answered here. It reports
+// whether the message was answered.
+func (h *Handler) handlePlan(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := planCommandRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	reply := func(text string, visibility Visibility) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS, Visibility: visibility}, true
+	}
+
+	plan := h.conversation.Plan(ctx, conversationID)
+	if plan == nil || plan.Status != storage.PlanProposed {
+		return reply("There's no plan waiting for approval in this thread.", h.private())
+	}
+	if strings.EqualFold(match[1], "refine") {
+		return reply(":pencil2: What should change in the plan? Reply in this thread and I'll revise it.", VisibilityPublic)
+	}
+
+	// Only someone already in the thread may approve, not a passer-by
+	participant := false
+	for _, p := range h.conversation.Participants(ctx, conversationID) {
+		participant = participant || p.UserID == msg.UserID
+	}
+	if !participant {
+		return reply(fmt.Sprintf("<@%s> hasn't taken part in this thread, so can't approve the plan.", msg.UserID), h.private())
+	}
+	if _, err := h.conversation.ApprovePlan(ctx, conversationID, msg.UserID); err != nil {
+		h.logger.Error("failed to approve plan", "conversation", conversationID, "error", err)
+		return reply(fmt.Sprintf("Sorry, I couldn't approve the plan: %v", err), VisibilityPublic)
+	}
+	h.logger.Info("plan approved", "conversation", conversationID, "approver", msg.UserID)
+	return nil, false
+}
+
+// expandPlanApproval turns an approval handlePlan let through into the
+// instruction to implement the plan.
+func expandPlanApproval(text string) string {
+	match := planCommandRe.FindStringSubmatch(text)
+	if match == nil || !strings.EqualFold(match[1], "approve") {
+		return text
+	}
+	return "I approve the plan. Go ahead and implement it as proposed, and tell me if it has to change along the way."
+}
+
+// answerPlan replaces a plan's buttons with the choice made, so it cannot
+// be made twice, and passes the choice on to the conversation.
+func (b *Bot) answerPlan(ctx context.Context, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	channelID := callback.Channel.ID
+	verb := "approved"
+	if action.ActionID == planRefineAction {
+		verb = "asked to refine"
+	}
+
+	var blocks []slack.Block
+	for _, block := range callback.Message.Blocks.BlockSet {
+		if actions, ok := block.(*slack.ActionBlock); ok && actions.BlockID == planBlockID {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(":white_check_mark: <@%s> %s the plan", callback.User.ID, verb), false, false)))
+	if _, _, _, err := b.clientFor(channelID).UpdateMessage(channelID, callback.Message.Timestamp,
+		slack.MsgOptionText(callback.Message.Text, false), slack.MsgOptionBlocks(blocks...)); err != nil {
+		b.logger.Warn("failed to update plan", "channel", channelID, "error", err)
+	}
+
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      action.Value,
+		UserID:    callback.User.ID,
+		ChannelID: channelID,
+		ThreadTS:  callback.Message.ThreadTimestamp,
+		IsDM:      strings.HasPrefix(channelID, "D"),
+	})
+}
@@ -0,0 +1,181 @@
+// The scheduled digest of the bot's pull requests awaiting review.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/scheduler"
+)
+
+// maxDigestPRs bounds the open PRs the digest looks at.
+const maxDigestPRs = 100
+
+// digestPR is an open bot PR listed in the digest.
+type digestPR struct {
+	info    git.PRInfo
+	created time.Time
+	checks  string
+}
+
+// PRDigest posts, on a cron schedule, the open PRs the bot created with
+// their CI status and age, to nudge reviewers. PRs opened from a thread are
+// listed in that thread's channel, the rest in the digest channel.
+type PRDigest struct {
+	forge    git.Forge
+	handler  *Handler
+	bot      *Bot
+	channel  string
+	author   string
+	prefix   string
+	schedule *scheduler.Cron
+	// last is when the digest was last due; digests due while the bot was
+	// down are not caught up
+	last   time.Time
+	logger *slog.Logger
+}
+
+// NewPRDigest creates a digest posted to cfg.PRDigestChannel on
+// cfg.PRDigestSchedule.
+func NewPRDigest(cfg *config.Config, handler *Handler, bot *Bot, logger *slog.Logger) (*PRDigest, error) {
+	schedule, err := scheduler.ParseCron(cfg.PRDigestSchedule)
+	if err != nil {
+		return nil, err
+	}
+	return &PRDigest{
+		forge:    handler.Forge(),
+		handler:  handler,
+		bot:      bot,
+		channel:  cfg.PRDigestChannel,
+		author:   cfg.PRDigestAuthor,
+		prefix:   cfg.PRDigestBranchPrefix,
+		schedule: schedule,
+		last:     time.Now(),
+		logger:   logger,
+	}, nil
+}
+
+// Run posts the digests when the schedule has fired since the last run. It
+// is meant to run every minute or so as a scheduled job.
+func (d *PRDigest) Run(ctx context.Context) error {
+	now := time.Now()
+	if !d.schedule.Due(d.last, now) {
+		return nil
+	}
+	d.last = now
+
+	byChannel, err := d.collect(ctx)
+	if err != nil {
+		return err
+	}
+	for channelID, prs := range byChannel {
+		if err := d.bot.PostProactive(channelID, &OutgoingMessage{Text: formatPRDigest(prs, now)}); err != nil {
+			d.logger.Warn("failed to post PR digest", "channel", channelID, "error", err)
+			continue
+		}
+		d.logger.Info("posted PR digest", "channel", channelID, "prs", len(prs))
+	}
+	return nil
+}
+
+// collect lists the bot's open PRs with their CI status, grouped by the
+// channel their digest goes to.
+func (d *PRDigest) collect(ctx context.Context) (map[string][]digestPR, error) {
+	open, err := d.forge.ListPRs(ctx, "open", maxDigestPRs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open PRs: %w", err)
+	}
+
+	// PRs opened from a thread are reported where they were asked for
+	channels := make(map[int]string)
+	for _, pr := range d.handler.toolExecutor.tracker.List() {
+		channels[pr.Number] = pr.ChannelID
+	}
+
+	byChannel := make(map[string][]digestPR)
+	for _, pr := range open {
+		channelID, tracked := channels[pr.Number]
+		if !tracked && !d.ownPR(pr) {
+			continue
+		}
+		if channelID == "" {
+			channelID = d.channel
+		}
+
+		created, _ := time.Parse(time.RFC3339, pr.CreatedAt)
+		item := digestPR{info: pr, created: created, checks: "no checks"}
+		if checks, err := d.forge.GetPRChecks(ctx, strconv.Itoa(pr.Number)); err != nil {
+			d.logger.Warn("failed to get PR checks", "pr", pr.Number, "error", err)
+			item.checks = "checks unknown"
+		} else if len(checks) > 0 {
+			item.checks = checksSummary(checks)
+		}
+		byChannel[channelID] = append(byChannel[channelID], item)
+	}
+	return byChannel, nil
+}
+
+// ownPR reports whether an untracked PR was created by the bot, judging by
+// its author or head branch.
+func (d *PRDigest) ownPR(pr git.PRInfo) bool {
+	if d.author != "" && strings.EqualFold(pr.Author, d.author) {
+		return true
+	}
+	return d.prefix != "" && strings.HasPrefix(pr.HeadRef, d.prefix)
+}
+
+// checksSummary sums up the CI checks of a PR.
+func checksSummary(checks []git.CheckRun) string {
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.State]++
+	}
+	switch {
+	case counts[git.CheckFailed] > 0:
+		return fmt.Sprintf(":x: %d of %d checks failing", counts[git.CheckFailed], len(checks))
+	case counts[git.CheckPending] > 0:
+		return fmt.Sprintf(":hourglass_flowing_sand: %d of %d checks pending", counts[git.CheckPending], len(checks))
+	default:
+		return ":white_check_mark: checks passing"
+	}
+}
+
+// formatPRDigest formats a channel's digest, oldest PR first as it has
+// waited longest.
+func formatPRDigest(prs []digestPR, now time.Time) string {
+	sort.Slice(prs, func(i, j int) bool { return prs[i].created.Before(prs[j].created) })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ":sunrise: *My PRs awaiting review* (%d)\n", len(prs))
+	for _, pr := range prs {
+		age := "age unknown"
+		if !pr.created.IsZero() {
+			age = "opened " + formatAge(now.Sub(pr.created))
+		}
+		fmt.Fprintf(&sb, "• <%s|#%d %s> from `%s` · %s · %s\n", pr.info.URL, pr.info.Number, pr.info.Title, pr.info.HeadRef, age, pr.checks)
+	}
+	sb.WriteString("_A review would help these land; the oldest have waited longest._")
+	return sb.String()
+}
+
+// formatAge describes how long ago something happened, in days or hours.
+func formatAge(d time.Duration) string {
+	switch days := int(d.Hours() / 24); {
+	case days >= 2:
+		return fmt.Sprintf("%d days ago", days)
+	case days == 1:
+		return "yesterday"
+	case d >= time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	default:
+		return "just now"
+	}
+}
@@ -0,0 +1,103 @@
+// Private replies: ephemeral messages and DMs.
+
+package slack
+
+import (
+	"fmt"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/shadow"
+	"github.com/slack-go/slack"
+)
+
+// dmNotice tells the requester where a reply sent by DM went.
+const dmNotice = ":envelope_with_arrow: I've sent you the answer in a DM."
+
+// sendPrivate delivers a reply only the sender of msg should see. Ephemeral
+// replies fall back to a DM when they carry files, which Slack can't post
+// ephemerally, or can't be posted, e.g. because the bot isn't in the channel.
+// A private reply is never posted publicly instead.
+func (b *Bot) sendPrivate(msg *IncomingMessage, response *OutgoingMessage) {
+	if response.Visibility == VisibilityEphemeral && len(response.Files) == 0 {
+		err := b.postEphemeral(msg.ChannelID, msg.UserID, response)
+		if err == nil {
+			return
+		}
+		b.logger.Warn("failed to send ephemeral message, sending it by DM", "channel", msg.ChannelID, "error", err)
+	}
+
+	if err := b.sendDM(msg.UserID, response); err != nil {
+		b.logger.Error("failed to send private reply", "user", msg.UserID, "error", err)
+		return
+	}
+	// Let the requester know where to look, as the channel shows nothing
+	notice := &OutgoingMessage{Text: dmNotice, ThreadTS: msg.ThreadTS}
+	if err := b.postEphemeral(msg.ChannelID, msg.UserID, notice); err != nil {
+		b.logger.Debug("failed to point to DM", "error", err)
+	}
+}
+
+// postEphemeral posts a message only userID can see. Files are not
+// attached, as Slack cannot upload them ephemerally.
+func (b *Bot) postEphemeral(channelID, userID string, msg *OutgoingMessage) error {
+	text := b.redactor.Redact(ToMrkdwn(msg.Text))
+	if b.shadow != nil {
+		b.shadow.Record(shadow.Entry{
+			Kind:      shadow.KindMessage,
+			ChannelID: channelID,
+			ThreadTS:  msg.ThreadTS,
+			Text:      fmt.Sprintf("[ephemeral to %s]\n%s", userID, text),
+		})
+		return nil
+	}
+
+	options := []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+	}
+	if msg.ThreadTS != "" {
+		options = append(options, slack.MsgOptionTS(msg.ThreadTS))
+	}
+	if blocks := b.redactBlocks(msg.Blocks); len(blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(blocks...))
+	}
+
+	_, err := b.clientFor(channelID).PostEphemeral(channelID, userID, options...)
+	return err
+}
+
+// sendDM sends a message to userID in a direct message with the bot,
+// buffering it for redelivery if Slack is unreachable.
+func (b *Bot) sendDM(userID string, msg *OutgoingMessage) error {
+	dm := *msg
+	dm.ThreadTS = ""
+	dm.Visibility = VisibilityPublic
+
+	// Shadow mode records the DM without opening one
+	channelID := userID
+	if b.shadow == nil {
+		channel, _, _, err := b.clientFor(userID).OpenConversation(&slack.OpenConversationParameters{
+			Users:    []string{userID},
+			ReturnIM: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to open DM: %w", err)
+		}
+		channelID = channel.ID
+	}
+
+	if err := b.sendMessage(channelID, &dm); err != nil {
+		if b.buffer.add(channelID, &dm) {
+			b.logger.Warn("failed to send DM, buffered for redelivery", "error", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// private returns how replies only the requester should see are delivered.
+func (h *Handler) private() Visibility {
+	if h.toolExecutor.cfg.PrivateReplies == "dm" {
+		return VisibilityDM
+	}
+	return VisibilityEphemeral
+}
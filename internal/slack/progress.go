@@ -0,0 +1,102 @@
+// Streaming of long-running command output to threads.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+// StatusPoster posts a status message to a thread and keeps it up to date.
+type StatusPoster interface {
+	// PostStatus posts text to a thread and returns the message timestamp,
+	// which is empty when the message can't be updated.
+	PostStatus(channelID, threadTS, text string) (string, error)
+	// UpdateMessage replaces the text of a posted message.
+	UpdateMessage(channelID, ts, text string) error
+}
+
+// PostStatus posts a status message to a thread and returns its timestamp,
+// which is empty in shadow mode.
+func (b *Bot) PostStatus(channelID, threadTS, text string) (string, error) {
+	return b.postMessage(channelID, &OutgoingMessage{Text: text, ThreadTS: threadTS})
+}
+
+// StreamWith sets where the output of long-running commands is streamed.
+func (h *Handler) StreamWith(poster StatusPoster) {
+	h.toolExecutor.status = poster
+}
+
+// progressMiddleware streams the tail of the output of commands run by a
+// tool call to the conversation's thread, in one message updated every
+// STORMSTACK_STREAM_INTERVAL, so long builds and tests don't run silently.
+func (e *ToolExecutor) progressMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		info, ok := ConversationFromContext(ctx)
+		if !ok || e.status == nil || e.cfg.StreamInterval <= 0 {
+			return next(ctx, name, input)
+		}
+
+		stream := &outputStream{poster: e.status, channelID: info.ChannelID, threadTS: info.ThreadTS, e: e}
+		return next(executor.WithProgress(ctx, e.cfg.StreamInterval, stream.report), name, input)
+	}
+}
+
+// outputStream is the thread message showing a command's progress.
+type outputStream struct {
+	poster    StatusPoster
+	channelID string
+	threadTS  string
+	e         *ToolExecutor
+	// ts is the message's timestamp, once posted
+	ts      string
+	command string
+}
+
+// report posts or updates the progress message. Each command run by the tool
+// call gets its own message.
+func (s *outputStream) report(p executor.Progress) {
+	text := formatProgress(p)
+	if s.ts != "" && s.command == p.Command {
+		if err := s.poster.UpdateMessage(s.channelID, s.ts, text); err != nil {
+			s.e.logger.Warn("failed to update command progress", "error", err)
+		}
+		return
+	}
+
+	ts, err := s.poster.PostStatus(s.channelID, s.threadTS, text)
+	if err != nil {
+		s.e.logger.Warn("failed to post command progress", "error", err)
+		return
+	}
+	s.ts, s.command = ts, p.Command
+}
+
+// formatProgress formats a progress report for the thread.
+func formatProgress(p executor.Progress) string {
+	elapsed := p.Elapsed.Round(time.Second)
+	command := TruncateText(p.Command, 200)
+
+	var header string
+	switch {
+	case !p.Done:
+		header = fmt.Sprintf(":hourglass_flowing_sand: `%s` running for %s", command, elapsed)
+	case p.TimedOut:
+		header = fmt.Sprintf(":alarm_clock: `%s` timed out after %s", command, elapsed)
+	case p.ExitCode != 0:
+		header = fmt.Sprintf(":x: `%s` failed (exit code %d) after %s", command, p.ExitCode, elapsed)
+	default:
+		header = fmt.Sprintf(":white_check_mark: `%s` finished in %s", command, elapsed)
+	}
+
+	tail := strings.TrimSpace(strings.ReplaceAll(p.Tail, "```", "` ` `"))
+	if tail == "" {
+		return header
+	}
+	return header + "\n```\n" + tail + "\n```"
+}
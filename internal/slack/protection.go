@@ -0,0 +1,121 @@
+// The choice of a pull request's base branch and the notes on the branch
+// protection and code owners it will meet.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// maxOwnedPaths is how many paths are listed for each set of code owners.
+const maxOwnedPaths = 5
+
+// prBase returns the branch a pull request should merge into: the forge's
+// default branch, or the local clone's idea of it if the forge can't say.
+func (e *ToolExecutor) prBase(ctx context.Context) (string, error) {
+	base, err := e.forge.DefaultBranch(ctx)
+	if err == nil && base != "" {
+		return base, nil
+	}
+	e.logger.Debug("failed to get the default branch from the forge", "error", err)
+
+	base, err = e.gitOps.GetDefaultBranch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the base branch, pass base explicitly: %w", err)
+	}
+	return base, nil
+}
+
+// checkPRHead refuses a pull request from base into itself, which happens
+// when changes were committed without creating a branch first.
+func (e *ToolExecutor) checkPRHead(ctx context.Context, base string) (string, error) {
+	head, err := e.gitOps.CurrentBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if head == base {
+		return "", fmt.Errorf("the current branch is the base branch %s; use create_branch, commit and push the changes, then create the PR", base)
+	}
+	return head, nil
+}
+
+// protectionNotes describes what a pull request from head into base will
+// need to merge: base's protection rules and the code owners of the paths
+// it changes. Anything the forge or repository can't tell is left out.
+func (e *ToolExecutor) protectionNotes(ctx context.Context, head, base string) []string {
+	var notes []string
+
+	protection, err := e.forge.BranchProtection(ctx, base)
+	if err != nil {
+		e.logger.Debug("failed to get branch protection", "branch", base, "error", err)
+	}
+	if protection != nil {
+		notes = append(notes, "🔒 "+protection.String()+".")
+	}
+
+	if headProtection, err := e.forge.BranchProtection(ctx, head); err == nil && headProtection != nil {
+		notes = append(notes, fmt.Sprintf("⚠️ The PR's own branch is protected too (%s); further commits may not be pushable to it.", headProtection))
+	}
+
+	owners := e.codeOwners()
+	if owners == nil {
+		return notes
+	}
+	paths, err := e.gitOps.ChangedSince(ctx, "origin/"+base)
+	if err != nil {
+		e.logger.Debug("failed to list changed paths", "base", base, "error", err)
+		return notes
+	}
+	review := owners.Review(paths)
+	if len(review) == 0 {
+		return notes
+	}
+
+	heading := "👥 Changes paths with code owners:"
+	if protection != nil && protection.CodeOwnerReviews {
+		heading = "👥 Needs approval from the code owners of the changed paths:"
+	}
+	notes = append(notes, heading+"\n"+formatOwnedPaths(review))
+	return notes
+}
+
+// codeOwners reads the repository's CODEOWNERS file, or returns nil if it
+// has none or it is restricted.
+func (e *ToolExecutor) codeOwners() *git.CodeOwners {
+	for _, file := range git.CodeOwnersFiles {
+		if content, err := e.reader.ReadFile(file); err == nil {
+			return git.ParseCodeOwners(content)
+		}
+	}
+	return nil
+}
+
+// formatOwnedPaths lists the owners and the paths each must review, a few
+// paths at most.
+func formatOwnedPaths(review map[string][]string) string {
+	owners := make([]string, 0, len(review))
+	for o := range review {
+		owners = append(owners, o)
+	}
+	sort.Strings(owners)
+
+	var sb strings.Builder
+	for _, o := range owners {
+		paths := review[o]
+		listed := paths
+		if len(listed) > maxOwnedPaths {
+			listed = listed[:maxOwnedPaths]
+		}
+		fmt.Fprintf(&sb, "• %s: %s", o, strings.Join(listed, ", "))
+		if more := len(paths) - len(listed); more > 0 {
+			fmt.Fprintf(&sb, " and %d more", more)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
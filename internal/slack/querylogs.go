@@ -0,0 +1,71 @@
+// The query_logs tool.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/logs"
+)
+
+// defaultLogsSince is how far back query_logs looks by default.
+const defaultLogsSince = time.Hour
+
+// newLogsBackend creates the configured logs backend, or nil when none is.
+func newLogsBackend(ctx context.Context, cfg *config.Config) (logs.Backend, error) {
+	if cfg.LogsBackend == "" {
+		return nil, nil
+	}
+	backend, err := logs.New(ctx, logs.Config{
+		Backend: cfg.LogsBackend,
+		URL:     cfg.LogsURL,
+		Token:   cfg.LogsToken,
+		Index:   cfg.LogsIndex,
+		Groups:  cfg.LogsGroups,
+		Region:  cfg.LogsRegion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s logs backend: %w", cfg.LogsBackend, err)
+	}
+	return backend, nil
+}
+
+func (e *ToolExecutor) queryLogs(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.QueryLogsParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	if e.logs == nil {
+		return "", fmt.Errorf("no logs backend is configured (set STORMSTACK_LOGS_BACKEND)")
+	}
+
+	since := defaultLogsSince
+	if params.Since != "" {
+		since, _ = time.ParseDuration(params.Since)
+	}
+	limit := e.cfg.LogsMaxLines
+	if params.Limit > 0 && params.Limit < limit {
+		limit = params.Limit
+	}
+
+	end := time.Now()
+	entries, err := e.logs.Query(ctx, logs.Query{Expr: params.Query, Start: end.Add(-since), End: end, Limit: limit})
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return fmt.Sprintf("No log lines in %s matched %s over the last %s.", e.logs.Name(), params.Query, since), nil
+	}
+
+	header := fmt.Sprintf("%d log lines from %s over the last %s", len(entries), e.logs.Name(), since)
+	if len(entries) == limit {
+		header += fmt.Sprintf(" (the most recent %d; narrow the query or the time range for older lines)", limit)
+	}
+	return header + ":\n" + logs.Format(entries), nil
+}
@@ -0,0 +1,402 @@
+// The tool registry: every tool Claude may call, with its definition,
+// implementation and permission, in one place.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// toolPermission is how much a tool may change.
+type toolPermission int
+
+const (
+	// permissionRead tools never modify the repository, the forge or stored state
+	permissionRead toolPermission = iota
+	// permissionWrite tools change the checkout, the forge or stored state
+	permissionWrite
+	// permissionApproval tools change things once an approver agrees, when
+	// their gate asks for approval
+	permissionApproval
+)
+
+// String returns the permission's name.
+func (p toolPermission) String() string {
+	switch p {
+	case permissionRead:
+		return "read"
+	case permissionApproval:
+		return "approval"
+	default:
+		return "write"
+	}
+}
+
+// toolRun executes a tool call.
+type toolRun func(e *ToolExecutor, ctx context.Context, input json.RawMessage) (string, error)
+
+// registeredTool is a tool Claude may call.
+type registeredTool struct {
+	Category   string
+	Definition anthropic.ToolUnionParam
+	Permission toolPermission
+	// Concurrent tools may run alongside each other in a turn: they change
+	// nothing and don't run project commands, which may write caches or
+	// lock files. Tools that read are not concurrent unless declared so.
+	Concurrent bool
+	Run        toolRun
+	// Gate describes a call of a permissionApproval tool for approvers, or
+	// returns "" when the call needs no approval
+	Gate func(e *ToolExecutor, input json.RawMessage) (string, error)
+	// Available reports whether an optional tool is configured; tools
+	// without it always are
+	Available func(cfg *config.Config) bool
+}
+
+// Name returns the tool's name.
+func (t registeredTool) Name() string {
+	return t.Definition.OfTool.Name
+}
+
+// Categories of the tools, in the order help shows them.
+const (
+	categoryUnderstanding = "Code Understanding"
+	categoryModification  = "Code Modification"
+	categoryBuild         = "Build & Test"
+	categoryDependencies  = "Dependencies"
+	categoryGit           = "Git Operations"
+	categoryIntelligence  = "Project Intelligence"
+	categoryObservability = "Observability"
+	categoryDatabase      = "Database"
+	categoryConversation  = "Conversation"
+	categoryCustom        = "Custom"
+)
+
+// builtinTools returns every tool the bot implements, by category.
+func builtinTools() []registeredTool {
+	return []registeredTool{
+		{Category: categoryUnderstanding, Definition: claude.ReadFileTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).readFile)},
+		{Category: categoryUnderstanding, Definition: claude.ListFilesTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).listFiles)},
+		{Category: categoryUnderstanding, Definition: claude.SearchCodeTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).searchCode)},
+		{Category: categoryUnderstanding, Definition: claude.GetTreeTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).getTree)},
+		{Category: categoryUnderstanding, Definition: claude.FindDefinitionTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).findDefinition)},
+		{Category: categoryUnderstanding, Definition: claude.FindReferencesTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).findReferences)},
+		{Category: categoryUnderstanding, Definition: claude.GetOutlineTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).getOutline)},
+		{Category: categoryUnderstanding, Definition: claude.SemanticSearchTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).semanticSearch},
+		{Category: categoryUnderstanding, Definition: claude.FindLogCallsTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).findLogCalls)},
+
+		{Category: categoryModification, Definition: claude.WriteFileTool(), Permission: permissionApproval, Run: (*ToolExecutor).writeFile, Gate: (*ToolExecutor).writeSummary},
+		{Category: categoryModification, Definition: claude.EditFileTool(), Permission: permissionApproval, Run: (*ToolExecutor).editFile, Gate: (*ToolExecutor).editSummary},
+		{Category: categoryModification, Definition: claude.ApplyChangesTool(), Permission: permissionApproval, Run: (*ToolExecutor).applyChanges, Gate: (*ToolExecutor).changesSummary},
+		{Category: categoryModification, Definition: claude.FormatFileTool(), Permission: permissionWrite, Run: (*ToolExecutor).formatFile},
+
+		{Category: categoryBuild, Definition: claude.RunCommandTool(), Permission: permissionWrite, Run: (*ToolExecutor).runCommand},
+		{Category: categoryBuild, Definition: claude.RunBuildTool(), Permission: permissionWrite, Run: (*ToolExecutor).runBuild},
+		{Category: categoryBuild, Definition: claude.RunTestsTool(), Permission: permissionWrite, Run: (*ToolExecutor).runTests},
+		{Category: categoryBuild, Definition: claude.RunLintTool(), Permission: permissionWrite, Run: (*ToolExecutor).runLint},
+		{Category: categoryBuild, Definition: claude.GetArtifactTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).getArtifact},
+
+		{Category: categoryDependencies, Definition: claude.ListDependenciesTool(), Permission: permissionRead, Run: (*ToolExecutor).listDependencies},
+		{Category: categoryDependencies, Definition: claude.CheckOutdatedTool(), Permission: permissionRead, Run: (*ToolExecutor).checkOutdated},
+		{Category: categoryDependencies, Definition: claude.BumpDependencyTool(), Permission: permissionWrite, Run: (*ToolExecutor).bumpDependency},
+		{Category: categoryDependencies, Definition: claude.ScanVulnerabilitiesTool(), Permission: permissionRead, Run: (*ToolExecutor).scanVulnerabilities},
+
+		{Category: categoryGit, Definition: claude.GitStatusTool(), Permission: permissionRead, Concurrent: true, Run: contextOnly((*ToolExecutor).gitStatus)},
+		{Category: categoryGit, Definition: claude.GitDiffTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).gitDiff},
+		{Category: categoryGit, Definition: claude.GitLogTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).gitLog},
+		{Category: categoryGit, Definition: claude.CreateBranchTool(), Permission: permissionWrite, Run: (*ToolExecutor).createBranch},
+		{Category: categoryGit, Definition: claude.CommitTool(), Permission: permissionWrite, Run: (*ToolExecutor).commit},
+		{Category: categoryGit, Definition: claude.PushTool(), Permission: permissionWrite, Run: (*ToolExecutor).push},
+		{Category: categoryGit, Definition: claude.RebaseTool(), Permission: permissionWrite, Run: (*ToolExecutor).rebase},
+		{Category: categoryGit, Definition: claude.MergeBranchTool(), Permission: permissionWrite, Run: (*ToolExecutor).mergeBranch},
+		{Category: categoryGit, Definition: claude.ListConflictsTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).listConflicts},
+		{Category: categoryGit, Definition: claude.ContinueRebaseTool(), Permission: permissionWrite, Run: contextOnly((*ToolExecutor).continueRebase)},
+		{Category: categoryGit, Definition: claude.AbortRebaseTool(), Permission: permissionWrite, Run: contextOnly((*ToolExecutor).abortRebase)},
+		{Category: categoryGit, Definition: claude.CreatePRTool(), Permission: permissionWrite, Run: (*ToolExecutor).createPR},
+		{Category: categoryGit, Definition: claude.GetPRTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).getPR},
+		{Category: categoryGit, Definition: claude.GetPRChecksTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).getPRChecks},
+		{Category: categoryGit, Definition: claude.ReviewPRTool(), Permission: permissionWrite, Run: (*ToolExecutor).reviewPR},
+		{Category: categoryGit, Definition: claude.CommentOnPRLineTool(), Permission: permissionWrite, Run: (*ToolExecutor).commentOnPRLine},
+		{Category: categoryGit, Definition: claude.CommentOnIssueTool(), Permission: permissionWrite, Run: (*ToolExecutor).commentOnIssue},
+		{Category: categoryGit, Definition: claude.CreateIssueTool(), Permission: permissionWrite, Run: (*ToolExecutor).createIssue},
+		{Category: categoryGit, Definition: claude.MergePRTool(), Permission: permissionApproval, Run: (*ToolExecutor).mergePR, Gate: func(_ *ToolExecutor, input json.RawMessage) (string, error) { return mergeSummary(input) }},
+		{Category: categoryGit, Definition: claude.RevertCommitTool(), Permission: permissionWrite, Run: (*ToolExecutor).revertCommit},
+		{Category: categoryGit, Definition: claude.BackportPRTool(), Permission: permissionWrite, Run: (*ToolExecutor).backportPR},
+		{Category: categoryGit, Definition: claude.LearnFromReviewTool(), Permission: permissionWrite, Run: (*ToolExecutor).learnFromReview},
+		{Category: categoryGit, Definition: claude.AddressReviewCommentsTool(), Permission: permissionWrite, Run: (*ToolExecutor).addressReviewComments},
+		{Category: categoryGit, Definition: claude.ReplyToReviewCommentTool(), Permission: permissionWrite, Run: (*ToolExecutor).replyToReviewComment},
+		{Category: categoryGit, Definition: claude.WorkOnIssueTool(), Permission: permissionWrite, Run: (*ToolExecutor).workOnIssue},
+
+		{Category: categoryIntelligence, Definition: claude.GetGuidelinesTool(), Permission: permissionRead, Concurrent: true, Run: noInput((*ToolExecutor).getGuidelines)},
+		{Category: categoryIntelligence, Definition: claude.GetProjectInfoTool(), Permission: permissionRead, Concurrent: true, Run: noInput((*ToolExecutor).getProjectInfo)},
+		{Category: categoryIntelligence, Definition: claude.FindTestsTool(), Permission: permissionRead, Concurrent: true, Run: inputOnly((*ToolExecutor).findTests)},
+		{Category: categoryIntelligence, Definition: claude.AnalyzeFailuresTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).analyzeFailures},
+
+		{Category: categoryObservability, Definition: claude.QueryLogsTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).queryLogs, Available: func(cfg *config.Config) bool { return cfg.LogsBackend != "" }},
+
+		{Category: categoryDatabase, Definition: claude.DescribeDatabaseTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).describeDatabase, Available: databaseConfigured},
+		{Category: categoryDatabase, Definition: claude.ExplainQueryTool(), Permission: permissionRead, Concurrent: true, Run: (*ToolExecutor).explainQuery, Available: databaseConfigured},
+
+		{Category: categoryConversation, Definition: claude.AskQuestionTool(), Permission: permissionWrite, Run: (*ToolExecutor).askQuestion},
+		{Category: categoryConversation, Definition: claude.ProposePlanTool(), Permission: permissionWrite, Run: (*ToolExecutor).proposePlan, Available: func(cfg *config.Config) bool { return cfg.PlanApproval }},
+	}
+}
+
+// inputOnly adapts a tool implementation that needs no context.
+func inputOnly(run func(e *ToolExecutor, input json.RawMessage) (string, error)) toolRun {
+	return func(e *ToolExecutor, _ context.Context, input json.RawMessage) (string, error) {
+		return run(e, input)
+	}
+}
+
+// contextOnly adapts a tool implementation that takes no input.
+func contextOnly(run func(e *ToolExecutor, ctx context.Context) (string, error)) toolRun {
+	return func(e *ToolExecutor, ctx context.Context, _ json.RawMessage) (string, error) {
+		return run(e, ctx)
+	}
+}
+
+// noInput adapts a tool implementation that needs neither context nor input.
+func noInput(run func(e *ToolExecutor) (string, error)) toolRun {
+	return func(e *ToolExecutor, _ context.Context, _ json.RawMessage) (string, error) {
+		return run(e)
+	}
+}
+
+// toolRegistry holds the tools enabled in this deployment.
+type toolRegistry struct {
+	// all are the tools that may be enabled: every built-in tool that is
+	// configured, and the custom tools
+	all    []registeredTool
+	logger *slog.Logger
+
+	// mu guards the enabled tools, which a configuration reload may change
+	mu     sync.RWMutex
+	tools  []registeredTool
+	byName map[string]registeredTool
+}
+
+// newToolRegistry creates the registry of the tools the configuration
+// enables: the built-in tools (optional ones once configured) and the custom
+// tools in the repository's tools file, only EnabledTools when set, less
+// DisabledTools.
+func newToolRegistry(cfg *config.Config, gitOps git.Operations, logger *slog.Logger) *toolRegistry {
+	r := &toolRegistry{logger: logger}
+	for _, t := range append(builtinTools(), loadCustomTools(cfg, gitOps, logger)...) {
+		if t.Available == nil || t.Available(cfg) {
+			r.all = append(r.all, t)
+		}
+	}
+	r.enable(cfg.EnabledTools, cfg.DisabledTools)
+	return r
+}
+
+// enable enables only the enabled tools when any are named, less the
+// disabled ones. Unknown names are logged, as a misspelt tool would
+// otherwise go unnoticed.
+func (r *toolRegistry) enable(enabled, disabled []string) {
+	known := make(map[string]bool)
+	for _, t := range builtinTools() {
+		known[t.Name()] = true
+	}
+	for _, t := range r.all {
+		known[t.Name()] = true
+	}
+	for _, name := range append(slices.Clone(enabled), disabled...) {
+		if !known[name] {
+			r.logger.Warn("ignoring unknown tool in configuration", "tool", name)
+		}
+	}
+
+	var tools []registeredTool
+	byName := make(map[string]registeredTool)
+	for _, t := range r.all {
+		if (len(enabled) > 0 && !slices.Contains(enabled, t.Name())) || slices.Contains(disabled, t.Name()) {
+			continue
+		}
+		tools = append(tools, t)
+		byName[t.Name()] = t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools, r.byName = tools, byName
+}
+
+// loadCustomTools returns the custom tools defined in the repository's tools
+// file, as committed on the default branch, so the bot can't give itself
+// tools by editing the checkout. A broken file is logged and defines none,
+// so the bot still starts; tools named like a built-in one are skipped.
+func loadCustomTools(cfg *config.Config, gitOps git.Operations, logger *slog.Logger) []registeredTool {
+	if cfg.CustomToolsFile == "" {
+		return nil
+	}
+	path := cfg.CustomToolsFile
+	data, err := committedFile(gitOps, path)
+	if err != nil {
+		logger.Error("failed to load custom tools", "path", path, "error", err)
+		return nil
+	}
+	custom, err := executor.ParseCustomTools(data, path)
+	if err != nil {
+		logger.Error("failed to load custom tools", "path", path, "error", err)
+		return nil
+	}
+
+	builtin := make(map[string]bool)
+	for _, t := range builtinTools() {
+		builtin[t.Name()] = true
+	}
+	var tools []registeredTool
+	for _, t := range custom {
+		if builtin[t.Name] {
+			logger.Warn("skipping custom tool named like a built-in tool", "tool", t.Name)
+			continue
+		}
+		tools = append(tools, customTool(t))
+	}
+	if len(tools) > 0 {
+		logger.Info("loaded custom tools", "path", path, "tools", len(tools))
+	}
+	return tools
+}
+
+// customTool registers a custom tool: its command runs in the checkout, and
+// approval tools show the command to approvers before every run.
+func customTool(t executor.CustomTool) registeredTool {
+	schema := anthropic.ToolInputSchemaParam{Properties: t.Properties()}
+	if required := t.Required(); len(required) > 0 {
+		schema.ExtraFields = map[string]any{"required": required}
+	}
+
+	tool := registeredTool{
+		Category: categoryCustom,
+		Definition: anthropic.ToolUnionParam{OfTool: &anthropic.ToolParam{
+			Name:        t.Name,
+			Description: anthropic.String(t.Description),
+			InputSchema: schema,
+		}},
+		Permission: permissionWrite,
+		Run: func(e *ToolExecutor, ctx context.Context, input json.RawMessage) (string, error) {
+			return e.runCustomTool(ctx, t, input)
+		},
+	}
+	switch t.Permission {
+	case executor.CustomToolRead:
+		tool.Permission = permissionRead
+	case executor.CustomToolApproval:
+		tool.Permission = permissionApproval
+		tool.Gate = func(_ *ToolExecutor, input json.RawMessage) (string, error) {
+			command, err := customToolCommand(t, input)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("run of %s: `%s`", t.Name, command), nil
+		}
+	}
+	return tool
+}
+
+// customToolCommand returns the command a custom tool runs for a call's
+// input.
+func customToolCommand(t executor.CustomTool, input json.RawMessage) (string, error) {
+	var params map[string]any
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &params); err != nil {
+			return "", fmt.Errorf("invalid input for %s: %w", t.Name, err)
+		}
+	}
+	return t.CommandFor(params)
+}
+
+// runCustomTool runs a custom tool's command in the checkout.
+func (e *ToolExecutor) runCustomTool(ctx context.Context, t executor.CustomTool, input json.RawMessage) (string, error) {
+	command, err := customToolCommand(t, input)
+	if err != nil {
+		return "", err
+	}
+
+	// Inputs may not name restricted paths, as with run_command
+	if err := e.policy.CheckCommand(command); err != nil {
+		return "", err
+	}
+
+	result, err := e.runner.RunCustomTool(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	return result.FormatResult(), nil
+}
+
+// lookup returns the enabled tool called name.
+func (r *toolRegistry) lookup(name string) (registeredTool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// concurrent reports whether the tool named name is declared safe to run
+// alongside other such tools.
+func (r *toolRegistry) concurrent(name string) bool {
+	t, ok := r.lookup(name)
+	return ok && t.Concurrent
+}
+
+// definitions returns the definitions of the enabled tools, for Claude.
+func (r *toolRegistry) definitions() []anthropic.ToolUnionParam {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	definitions := make([]anthropic.ToolUnionParam, len(r.tools))
+	for i, t := range r.tools {
+		definitions[i] = t.Definition
+	}
+	return definitions
+}
+
+// categories returns the enabled tools grouped by category, in registry
+// order.
+func (r *toolRegistry) categories() []claude.ToolCategory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []claude.ToolCategory
+	index := make(map[string]int)
+	for _, t := range r.tools {
+		i, ok := index[t.Category]
+		if !ok {
+			i = len(categories)
+			index[t.Category] = i
+			categories = append(categories, claude.ToolCategory{Name: t.Category})
+		}
+		categories[i].Tools = append(categories[i].Tools, t.Definition)
+	}
+	return categories
+}
+
+// gates returns the approval gates of the tools that have one, enabled or
+// not, so a tool enabled by a reload is gated too.
+func (r *toolRegistry) gates(e *ToolExecutor) map[string]ApprovalSummary {
+	gates := make(map[string]ApprovalSummary)
+	for _, t := range r.all {
+		if t.Gate == nil {
+			continue
+		}
+		gate := t.Gate
+		gates[t.Name()] = func(input json.RawMessage) (string, error) {
+			return gate(e, input)
+		}
+	}
+	return gates
+}
@@ -0,0 +1,189 @@
+// Hot reloading of the configuration.
+
+package slack
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+)
+
+// reloadableClient is a Claude client whose provider a configuration reload
+// may replace, so conversations, review learning and triage all switch
+// model at once. Calls already made finish on the old provider.
+type reloadableClient struct {
+	mu     sync.RWMutex
+	client claude.Client
+}
+
+// current returns the provider calls are made with.
+func (c *reloadableClient) current() claude.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// replace makes later calls use client.
+func (c *reloadableClient) replace(client claude.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+}
+
+// CreateMessage sends a message to Claude with the current provider.
+func (c *reloadableClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	return c.current().CreateMessage(ctx, params)
+}
+
+// CreateMessageWithTools sends a message with tool definitions with the
+// current provider.
+func (c *reloadableClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	return c.current().CreateMessageWithTools(ctx, systemPrompt, messages, tools)
+}
+
+// Reload applies the settings of cfg, with the repository's configuration
+// file over them, that can change without a restart: the Claude model and
+// provider, the approvers, the restricted and protected paths (rereading
+// .stormstackignore), the build, test and lint commands, the guidelines
+// file and the enabled tools. Any other setting needs a restart. It returns
+// the settings that changed; nothing is applied unless all of them can be.
+func (h *Handler) Reload(cfg *config.Config) ([]string, error) {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+	current := h.settings()
+	base := cfg
+	cfg = withRepoConfig(cfg, h.toolExecutor.gitOps, h.logger)
+
+	// Prepare what may fail before applying anything
+	var changes []string
+	var provider claude.Client
+	if h.client != nil && claudeChanged(current, cfg) {
+		var err error
+		if provider, err = newClaudeClient(cfg, h.logger); err != nil {
+			return nil, err
+		}
+		changes = append(changes, fmt.Sprintf("model `%s` via %s", cfg.ClaudeModel, cfg.ClaudeBackend))
+	}
+	if err := h.toolExecutor.policy.Update(cfg.RestrictedPaths, cfg.ProtectedPaths); err != nil {
+		return nil, err
+	}
+	h.eachWorkspace(func(e *ToolExecutor) {
+		if err := e.policy.Update(cfg.RestrictedPaths, cfg.ProtectedPaths); err != nil {
+			h.logger.Warn("failed to update a workspace's data handling policy", "error", err)
+		}
+	})
+	if !slices.Equal(current.RestrictedPaths, cfg.RestrictedPaths) {
+		changes = append(changes, "restricted paths")
+	}
+	if !slices.Equal(current.ProtectedPaths, cfg.ProtectedPaths) {
+		changes = append(changes, "protected paths")
+	}
+
+	// New workspaces pick up the commands from here on
+	h.global.Store(base)
+	h.reloaded.Store(cfg)
+	if provider != nil {
+		h.client.replace(provider)
+	}
+	if !slices.Equal(current.Approvers, cfg.Approvers) {
+		h.approvals.SetApprovers(cfg.Approvers)
+		changes = append(changes, "approvers")
+	}
+	if current.BuildCmd != cfg.BuildCmd || current.TestCmd != cfg.TestCmd || current.LintCmd != cfg.LintCmd {
+		h.toolExecutor.useCommands(cfg)
+		h.eachWorkspace(func(e *ToolExecutor) { e.useCommands(cfg) })
+		changes = append(changes, "build, test and lint commands")
+	}
+	if current.GuidelinesFile != cfg.GuidelinesFile {
+		h.conversation.SetSystemPrompt(h.redactor.Redact(claude.LoadSystemPrompt(h.repoPath, cfg.GuidelinesFile)))
+		changes = append(changes, "guidelines file")
+	}
+	if requestBudget(current) != requestBudget(cfg) {
+		h.conversation.SetBudget(requestBudget(cfg))
+		changes = append(changes, "request budget")
+	}
+	if toolParallelism(current) != toolParallelism(cfg) {
+		h.conversation.SetParallelism(toolParallelism(cfg), h.toolExecutor.tools.concurrent)
+		changes = append(changes, "tool parallelism")
+	}
+	if !slices.Equal(current.EnabledTools, cfg.EnabledTools) || !slices.Equal(current.DisabledTools, cfg.DisabledTools) {
+		h.toolExecutor.tools.enable(cfg.EnabledTools, cfg.DisabledTools)
+		h.eachWorkspace(func(e *ToolExecutor) { e.tools.enable(cfg.EnabledTools, cfg.DisabledTools) })
+		h.conversation.SetTools(h.toolExecutor.tools.definitions())
+		changes = append(changes, "enabled tools")
+	}
+
+	h.logger.Info("configuration reloaded", "changed", strings.Join(changes, ", "))
+	return changes, nil
+}
+
+// settings returns the configuration the reloadable settings were last
+// applied from.
+func (h *Handler) settings() *config.Config {
+	if cfg := h.reloaded.Load(); cfg != nil {
+		return cfg
+	}
+	return h.toolExecutor.cfg
+}
+
+// base returns the configuration last applied, without the repository's
+// configuration file.
+func (h *Handler) base() *config.Config {
+	return h.global.Load()
+}
+
+// claudeChanged reports whether the Claude provider settings differ.
+func claudeChanged(a, b *config.Config) bool {
+	return a.ClaudeBackend != b.ClaudeBackend || a.ClaudeModel != b.ClaudeModel ||
+		a.ClaudeBaseURL != b.ClaudeBaseURL || a.AnthropicAPIKey != b.AnthropicAPIKey ||
+		a.BedrockRegion != b.BedrockRegion || a.BedrockProfile != b.BedrockProfile ||
+		a.VertexRegion != b.VertexRegion || a.VertexProjectID != b.VertexProjectID ||
+		a.ClaudeFakeScript != b.ClaudeFakeScript
+}
+
+// useCommands sets the build, test and lint commands from cfg; commands that
+// aren't configured are inferred from the build files.
+func (e *ToolExecutor) useCommands(cfg *config.Config) {
+	e.runner.SetCommands(
+		cmp.Or(cfg.BuildCmd, e.project.BuildCmd),
+		cmp.Or(cfg.TestCmd, e.project.TestCmd),
+		cmp.Or(cfg.LintCmd, e.project.LintCmd))
+}
+
+// ReloadWith sets how the reload command reloads the configuration, for
+// every handler; it returns what changed.
+func (h *Handler) ReloadWith(reload func(ctx context.Context) ([]string, error)) {
+	h.reload = reload
+}
+
+// reloadConfig answers the reload command: approvers (anyone, when there
+// are none) reload the configuration.
+func (h *Handler) reloadConfig(ctx context.Context, userID string) string {
+	if !h.approvals.CanApprove(userID) {
+		return "Sorry, only the configured approvers can reload my configuration."
+	}
+	if h.reload == nil {
+		return "Sorry, reloading the configuration isn't available here."
+	}
+	changes, err := h.reload(ctx)
+	if err != nil {
+		h.logger.Error("failed to reload configuration", "user", userID, "error", err)
+		return fmt.Sprintf(":x: I kept the current configuration: %v", err)
+	}
+	if len(changes) == 0 {
+		return ":arrows_counterclockwise: Reloaded the configuration; none of the settings I can change without a restart were different."
+	}
+	return ":arrows_counterclockwise: Reloaded the configuration. Changed: " + strings.Join(changes, ", ") + "."
+}
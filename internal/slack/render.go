@@ -0,0 +1,267 @@
+// Block Kit rendering of pull requests, command results and failure
+// analyses.
+
+package slack
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/slack-go/slack"
+)
+
+// Limits of rendered cards.
+const (
+	// outputBlockID prefixes the actions block of a card's "view full
+	// output" button; block IDs must be unique within a message
+	outputBlockID = "stormstack-output"
+	// outputActionID identifies "view full output" buttons
+	outputActionID = "view-output"
+	// maxHeaderText is the most text a header block holds
+	maxHeaderText = 150
+	// maxFieldText is the most text a section field holds
+	maxFieldText = 2000
+	// tailLines is how many of a command's last output lines a card shows
+	tailLines = 15
+	// maxCardFailures bounds the failures a card lists
+	maxCardFailures = 10
+	// maxCardFiles bounds the changed files a PR card lists
+	maxCardFiles = 10
+)
+
+// RenderPR renders a pull request as a card: its title, state, author,
+// branches and diff size, the start of its description and the files it
+// changes, with buttons to open it and, when diffID is set, to upload the
+// full diff.
+func RenderPR(pr *git.PRDetails, diffID string) []slack.Block {
+	info := pr.Info
+	files, added, removed := diffStats(pr.Diff)
+	if len(pr.FilesChanged) > 0 {
+		files = len(pr.FilesChanged)
+	}
+
+	blocks := []slack.Block{
+		BuildHeaderBlock(TruncateText(fmt.Sprintf("PR #%d: %s", info.Number, info.Title), maxHeaderText)),
+		fieldsSection(
+			FormatBold("State")+"\n"+info.State,
+			FormatBold("Author")+"\n"+info.Author,
+			FormatBold("Branch")+"\n"+FormatInlineCode(info.HeadRef)+" → "+FormatInlineCode(info.BaseRef),
+			FormatBold("Changes")+"\n"+fmt.Sprintf("%d files, +%d −%d", files, added, removed),
+		),
+	}
+	if body := strings.TrimSpace(info.Body); body != "" {
+		blocks = append(blocks, BuildSectionBlock(TruncateText(body, 500)))
+	}
+	if len(pr.FilesChanged) > 0 {
+		names := make([]string, 0, maxCardFiles)
+		for _, f := range pr.FilesChanged[:min(len(pr.FilesChanged), maxCardFiles)] {
+			names = append(names, FormatInlineCode(f))
+		}
+		if extra := len(pr.FilesChanged) - maxCardFiles; extra > 0 {
+			names = append(names, fmt.Sprintf("and %d more", extra))
+		}
+		blocks = append(blocks, BuildContextBlock(strings.Join(names, ", ")))
+	}
+
+	var buttons []slack.BlockElement
+	if info.URL != "" {
+		open := slack.NewButtonBlockElement("open-pr", info.URL, slack.NewTextBlockObject(slack.PlainTextType, "Open PR", false, false))
+		open.URL = info.URL
+		buttons = append(buttons, open)
+	}
+	if diffID != "" {
+		buttons = append(buttons, outputButton(diffID, "View diff"))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, slack.NewActionBlock(outputBlockID+"-"+cmp.Or(diffID, "pr"), buttons...))
+	}
+	return blocks
+}
+
+// RenderCommandResult renders a command's result as a card: whether it
+// passed, its exit code and duration and the end of its output, with a
+// button to upload the full output when outputID is set.
+func RenderCommandResult(title string, result *executor.CommandResult, outputID string) []slack.Block {
+	status := ":white_check_mark: Passed"
+	verdict := "passed"
+	switch {
+	case result.TimedOut:
+		status, verdict = ":hourglass: Timed out", "timed out"
+	case !result.IsSuccess():
+		status, verdict = fmt.Sprintf(":x: Failed (exit code %d)", result.ExitCode), "failed"
+	}
+
+	blocks := []slack.Block{
+		BuildHeaderBlock(TruncateText(title+" "+verdict, maxHeaderText)),
+		fieldsSection(
+			FormatBold("Result")+"\n"+status,
+			FormatBold("Duration")+"\n"+result.Duration.Round(100*time.Millisecond).String(),
+		),
+		BuildContextBlock(FormatInlineCode(TruncateText(result.Command, maxFieldText))),
+	}
+	if tail := outputTail(result.CombinedOutput(), tailLines); tail != "" {
+		blocks = append(blocks, BuildSectionBlock(FormatCodeBlock(tail)))
+	}
+	if outputID != "" {
+		blocks = append(blocks, slack.NewActionBlock(outputBlockID+"-"+outputID, outputButton(outputID, "View full output")))
+	}
+	return blocks
+}
+
+// RenderAnalysis renders a failure analysis as a card listing the failing
+// tests and build errors with their locations, with a button to upload the
+// full output when outputID is set.
+func RenderAnalysis(result *executor.AnalysisResult, outputID string) []slack.Block {
+	if result.Success {
+		return []slack.Block{BuildSectionBlock(FormatSuccess("No build errors or test failures found."))}
+	}
+
+	var counts []string
+	if n := len(result.TestFailures); n > 0 {
+		counts = append(counts, fmt.Sprintf("%d failing tests", n))
+	}
+	if n := len(result.BuildErrors); n > 0 {
+		counts = append(counts, fmt.Sprintf("%d build errors", n))
+	}
+	summary := "the output shows a failure the parser couldn't break down"
+	if len(counts) > 0 {
+		summary = strings.Join(counts, ", ")
+	}
+	blocks := []slack.Block{BuildSectionBlock(FormatBold("Failure analysis") + ": " + summary)}
+
+	var lines []string
+	for _, f := range result.TestFailures[:min(len(result.TestFailures), maxCardFailures)] {
+		line := ":x: " + FormatInlineCode(f.TestName)
+		if loc := location(f.File, f.Line); loc != "" {
+			line += " at " + FormatInlineCode(loc)
+		}
+		if f.Message != "" {
+			line += "\n      " + TruncateText(firstLine(f.Message), 200)
+		}
+		lines = append(lines, line)
+	}
+	for _, e := range result.BuildErrors[:min(len(result.BuildErrors), maxCardFailures)] {
+		icon := ":no_entry:"
+		if e.Type == "warning" {
+			icon = ":warning:"
+		}
+		line := icon + " " + TruncateText(firstLine(e.Message), 200)
+		if loc := location(e.File, e.Line); loc != "" {
+			line = icon + " " + FormatInlineCode(loc) + " " + TruncateText(firstLine(e.Message), 200)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		blocks = append(blocks, textSections(strings.Join(lines, "\n"), 2)...)
+	}
+
+	var omitted []string
+	if extra := len(result.TestFailures) - maxCardFailures; extra > 0 {
+		omitted = append(omitted, fmt.Sprintf("%d more failing tests", extra))
+	}
+	if extra := len(result.BuildErrors) - maxCardFailures; extra > 0 {
+		omitted = append(omitted, fmt.Sprintf("%d more build errors", extra))
+	}
+	note := "Parsed as " + FormatInlineCode(result.Type)
+	if len(omitted) > 0 {
+		note += " · " + strings.Join(omitted, ", ") + " not shown"
+	}
+	blocks = append(blocks, BuildContextBlock(note))
+
+	if outputID != "" {
+		blocks = append(blocks, slack.NewActionBlock(outputBlockID+"-"+outputID, outputButton(outputID, "View full output")))
+	}
+	return blocks
+}
+
+// replyBlocks lays out a reply as sections of mrkdwn, then the cards the tools
+// rendered, then a button per option of a clarifying question, if any. Cards
+// that don't fit in a message are left out.
+func replyBlocks(text string, cards [][]slack.Block, q *question) []slack.Block {
+	limit := maxBlocks
+	if q != nil {
+		limit--
+	}
+
+	blocks := textSections(ToMrkdwn(text), limit)
+	for _, card := range cards {
+		if len(blocks)+1+len(card) > limit {
+			break
+		}
+		blocks = append(blocks, BuildDividerBlock())
+		blocks = append(blocks, card...)
+	}
+	if q != nil {
+		blocks = append(blocks, optionActions(*q))
+	}
+	return blocks
+}
+
+// fieldsSection creates a section block of mrkdwn fields.
+func fieldsSection(fields ...string) *slack.SectionBlock {
+	objects := make([]*slack.TextBlockObject, len(fields))
+	for i, field := range fields {
+		objects[i] = slack.NewTextBlockObject(slack.MarkdownType, TruncateText(field, maxFieldText), false, false)
+	}
+	return slack.NewSectionBlock(nil, objects, nil)
+}
+
+// outputButton creates a button that uploads the output kept under id.
+func outputButton(id, label string) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(outputActionID, id, slack.NewTextBlockObject(slack.PlainTextType, label, false, false))
+}
+
+// outputTail returns the last n lines of output, fitting in a section block
+// as a code block.
+func outputTail(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	tail := strings.Join(lines, "\n")
+	// A fence in the output would end the code block early
+	tail = strings.ReplaceAll(tail, "```", "'''")
+	if limit := maxSectionText - 100; len(tail) > limit {
+		tail = "…" + tail[len(tail)-limit:]
+	}
+	return strings.TrimSpace(tail)
+}
+
+// diffStats counts the files, added lines and removed lines of a unified diff.
+func diffStats(diff string) (int, int, int) {
+	var files, added, removed int
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files++
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return files, added, removed
+}
+
+// location formats a file and line as "file:line".
+func location(file string, line int) string {
+	switch {
+	case file == "":
+		return ""
+	case line > 0:
+		return fmt.Sprintf("%s:%d", file, line)
+	default:
+		return file
+	}
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,113 @@
+// The per-repository configuration file.
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/codebase"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+)
+
+// repoConfigRecheckInterval is how often the repository's configuration
+// file is checked for changes.
+const repoConfigRecheckInterval = 5 * time.Second
+
+// committedFileTimeout bounds reading a file committed to the repository.
+const committedFileTimeout = 10 * time.Second
+
+// committedFile reads the file at path, relative to the repository, as
+// committed on origin's default branch, or returns nil when it isn't there.
+// Files the bot reads its own settings and tools from come from here rather
+// than the checkout, which the bot can edit: a change to them takes effect
+// once it is merged and synced.
+func committedFile(gitOps git.Operations, path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), committedFileTimeout)
+	defer cancel()
+	return gitOps.CommittedFile(ctx, path)
+}
+
+// committedIgnoreFile reads the repository's .stormstackignore as committed
+// on the default branch, so the bot can't lift its own restrictions by
+// editing the checkout.
+func committedIgnoreFile(gitOps git.Operations) codebase.IgnoreSource {
+	return func() ([]byte, error) {
+		return committedFile(gitOps, codebase.IgnoreFileName)
+	}
+}
+
+// withRepoConfig returns cfg with the repository's configuration file, as
+// committed on the default branch, applied. A broken file is logged and
+// ignored, so a bad commit can't stop the bot.
+func withRepoConfig(cfg *config.Config, gitOps git.Operations, logger *slog.Logger) *config.Config {
+	data, err := committedFile(gitOps, config.RepoConfigFile)
+	if err != nil {
+		logger.Error("ignoring repository configuration", "path", config.RepoConfigFile, "error", err)
+		return cfg
+	}
+	rc, err := config.ParseRepoConfig(data)
+	if err != nil {
+		logger.Error("ignoring repository configuration", "path", config.RepoConfigFile, "error", err)
+		return cfg
+	}
+	merged := cfg.WithRepo(rc)
+	if err := (&codebase.PathPolicy{}).Protect(merged.ProtectedPaths); err != nil {
+		logger.Error("ignoring repository configuration", "path", config.RepoConfigFile, "error", err)
+		return cfg
+	}
+	return merged
+}
+
+// repoConfigWatch notices changes to the repository's committed
+// configuration file, e.g. from a sync.
+type repoConfigWatch struct {
+	gitOps git.Operations
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	content   []byte
+}
+
+// newRepoConfigWatch watches the configuration file committed to the
+// repository gitOps works on, as it is now.
+func newRepoConfigWatch(gitOps git.Operations) *repoConfigWatch {
+	w := &repoConfigWatch{gitOps: gitOps, checkedAt: time.Now()}
+	w.content, _ = committedFile(gitOps, config.RepoConfigFile)
+	return w
+}
+
+// changed reports whether the file has changed since it was last checked,
+// checking at most every repoConfigRecheckInterval.
+func (w *repoConfigWatch) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if time.Since(w.checkedAt) < repoConfigRecheckInterval {
+		return false
+	}
+	w.checkedAt = time.Now()
+
+	content, err := committedFile(w.gitOps, config.RepoConfigFile)
+	if err != nil || bytes.Equal(content, w.content) {
+		return false
+	}
+	w.content = content
+	return true
+}
+
+// checkRepoConfig reloads the configuration when the repository's
+// configuration file has changed.
+func (h *Handler) checkRepoConfig() {
+	if h.repoConfig == nil || !h.repoConfig.changed() {
+		return
+	}
+	h.logger.Info("repository configuration changed, reloading", "path", config.RepoConfigFile)
+	if _, err := h.Reload(h.base()); err != nil {
+		h.logger.Error("failed to reload configuration", "error", err)
+	}
+}
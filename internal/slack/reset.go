@@ -0,0 +1,113 @@
+// Forgetting a thread's conversation on request, once the requester
+// confirms.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// resetConfirmTTL is how long a reset request waits for its confirmation.
+const resetConfirmTTL = 2 * time.Minute
+
+var (
+	// resetRe matches a request to forget the thread's conversation.
+	resetRe = regexp.MustCompile(`(?i)^\s*(?:reset(?:\s+(?:this\s+)?(?:thread|conversation))?|forget\s+(?:this\s+)?(?:thread|conversation)|start\s+over)\s*[.!]?\s*$`)
+	// resetConfirmRe matches the reply confirming a reset.
+	resetConfirmRe = regexp.MustCompile(`(?i)^\s*(?:yes|y|confirm|yes,?\s+reset)\s*[.!]?\s*$`)
+	// resetKeepRe matches the reply calling a reset off.
+	resetKeepRe = regexp.MustCompile(`(?i)^\s*(?:no|n|cancel|keep(?:\s+it)?)\s*[.!]?\s*$`)
+)
+
+// pendingResets holds the reset requests waiting for confirmation, by
+// conversation.
+type pendingResets struct {
+	mu       sync.Mutex
+	requests map[string]pendingReset
+}
+
+// pendingReset is a reset request waiting for its requester to confirm.
+type pendingReset struct {
+	userID  string
+	expires time.Time
+}
+
+// newPendingResets creates an empty set of reset requests.
+func newPendingResets() *pendingResets {
+	return &pendingResets{requests: make(map[string]pendingReset)}
+}
+
+// ask records that userID asked to reset a conversation.
+func (p *pendingResets) ask(conversationID, userID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests[conversationID] = pendingReset{userID: userID, expires: time.Now().Add(resetConfirmTTL)}
+}
+
+// pending reports whether userID has an unexpired reset request in a
+// conversation.
+func (p *pendingResets) pending(conversationID, userID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	req, ok := p.requests[conversationID]
+	if ok && time.Now().After(req.expires) {
+		delete(p.requests, conversationID)
+		return false
+	}
+	return ok && req.userID == userID
+}
+
+// take removes userID's reset request in a conversation and reports whether
+// there was one to confirm.
+func (p *pendingResets) take(conversationID, userID string) bool {
+	if !p.pending(conversationID, userID) {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.requests, conversationID)
+	return true
+}
+
+// handleReset answers a request to forget the thread's conversation by
+// asking the requester to confirm, then forgets it on "yes" or keeps it on
+// "no". It reports whether the message was handled.
+func (h *Handler) handleReset(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	switch {
+	case resetRe.MatchString(msg.Text):
+		h.resets.ask(conversationID, msg.UserID)
+		text := fmt.Sprintf(":warning: This makes me forget everything we discussed here, and any action waiting for approval. "+
+			"Reply `yes` within %d minutes to go ahead, or `no` to keep it.", int(resetConfirmTTL.Minutes()))
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	case !h.resets.pending(conversationID, msg.UserID):
+		return nil, false
+	case resetConfirmRe.MatchString(msg.Text):
+		h.resets.take(conversationID, msg.UserID)
+		return &OutgoingMessage{Text: h.resetConversation(ctx, conversationID), ThreadTS: msg.ThreadTS}, true
+	case resetKeepRe.MatchString(msg.Text):
+		h.resets.take(conversationID, msg.UserID)
+		text := "Okay, I'll keep our conversation as it is."
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	default:
+		return nil, false
+	}
+}
+
+// resetConversation forgets a conversation and its pending approval, and
+// reports what it did.
+func (h *Handler) resetConversation(ctx context.Context, conversationID string) string {
+	h.approvals.Cancel(conversationID)
+	h.forgetIssue(conversationID)
+	if err := h.conversation.ClearConversation(ctx, conversationID); err != nil {
+		h.logger.Error("failed to reset conversation", "conversation", conversationID, "error", err)
+		return fmt.Sprintf("Sorry, I couldn't reset our conversation: %v", err)
+	}
+	h.logger.Info("conversation reset", "conversation", conversationID)
+	return ":broom: Done: I've forgotten our conversation here, so your next message starts fresh."
+}
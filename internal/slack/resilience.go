@@ -0,0 +1,119 @@
+// Connection resilience helpers for the bot.
+
+package slack
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// eventDedupTTL is how long processed event IDs are remembered.
+	eventDedupTTL = 30 * time.Minute
+	// reconnectBaseDelay is the delay before the first reconnection attempt.
+	reconnectBaseDelay = time.Second
+)
+
+// eventDeduper remembers recently processed event IDs so events redelivered
+// by Slack after a reconnect are only handled once.
+type eventDeduper struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	ttl  time.Duration
+}
+
+// newEventDeduper creates a new event deduplicator.
+func newEventDeduper(ttl time.Duration) *eventDeduper {
+	return &eventDeduper{
+		seen: make(map[string]time.Time),
+		ttl:  ttl,
+	}
+}
+
+// firstSeen records an event ID and reports whether it has not been seen before.
+func (d *eventDeduper) firstSeen(eventID string) bool {
+	if eventID == "" {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range d.seen {
+		if now.Sub(at) > d.ttl {
+			delete(d.seen, id)
+		}
+	}
+
+	if _, ok := d.seen[eventID]; ok {
+		return false
+	}
+	d.seen[eventID] = now
+	return true
+}
+
+// pendingMessage is an outgoing message that could not be delivered.
+type pendingMessage struct {
+	channelID string
+	msg       *OutgoingMessage
+}
+
+// responseBuffer holds responses that failed to send while disconnected so they
+// can be delivered once the connection is restored.
+type responseBuffer struct {
+	mu       sync.Mutex
+	messages []pendingMessage
+	size     int
+}
+
+// newResponseBuffer creates a buffer holding at most size messages.
+func newResponseBuffer(size int) *responseBuffer {
+	return &responseBuffer{size: size}
+}
+
+// add buffers a message, dropping the oldest one when full. It reports whether
+// the message was buffered.
+func (b *responseBuffer) add(channelID string, msg *OutgoingMessage) bool {
+	if b == nil || b.size <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.messages) >= b.size {
+		b.messages = b.messages[1:]
+	}
+	b.messages = append(b.messages, pendingMessage{channelID: channelID, msg: msg})
+	return true
+}
+
+// drain removes and returns all buffered messages.
+func (b *responseBuffer) drain() []pendingMessage {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := b.messages
+	b.messages = nil
+	return messages
+}
+
+// reconnectDelay returns the jittered exponential delay before reconnect attempt n.
+func reconnectDelay(attempt int, maxDelay time.Duration) time.Duration {
+	delay := reconnectBaseDelay << attempt
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
@@ -0,0 +1,161 @@
+// Follow-ups on review comments left on the bot's pull requests, from Slack
+// or from review webhooks.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/conflicts"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/webhook"
+)
+
+// addressReviewComments checks out an open PR's branch and lists the review
+// comments its author, normally the bot, hasn't replied to. The PR is
+// tracked in the conversation, so later reviews are followed up there.
+func (e *ToolExecutor) addressReviewComments(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.AddressReviewCommentsParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	number, err := e.forge.PRNumber(ctx, params.URL)
+	if err != nil {
+		return "", err
+	}
+	pr, err := e.forge.GetPR(ctx, number)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(pr.State, "open") {
+		return "", fmt.Errorf("PR #%d is %s, only open PRs can be updated", pr.Number, strings.ToLower(pr.State))
+	}
+
+	if err := e.gitOps.Fetch(ctx); err != nil {
+		return "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	if err := e.gitOps.CheckoutBranch(ctx, pr.HeadRef); err != nil {
+		return "", err
+	}
+
+	comments, err := e.forge.GetPRReviewComments(ctx, params.URL)
+	if err != nil {
+		return "", err
+	}
+	pending := git.PendingReviewComments(comments, pr.Author)
+
+	e.trackPR(ctx, pr)
+	e.startWorkflow(ctx, WorkflowReview)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Checked out %s, the branch of PR #%d (%s).\n\n", pr.HeadRef, pr.Number, pr.Title))
+	if len(pending) == 0 {
+		sb.WriteString("No review comments are waiting for a reply.")
+		return sb.String(), nil
+	}
+	sb.WriteString(fmt.Sprintf("%d comment(s) waiting for a reply:\n\n", len(pending)))
+	sb.WriteString(git.FormatReviewComments(pending))
+	sb.WriteString("\n\nNext: make the requested changes (comments from bots or that need no change only need a reply), run the tests, commit and push to this branch. Then answer every comment with reply_to_review_comment, saying what changed or why it didn't.\n")
+	return sb.String(), nil
+}
+
+// replyToReviewComment answers a review comment by its ID.
+func (e *ToolExecutor) replyToReviewComment(ctx context.Context, input json.RawMessage) (string, error) {
+	var params claude.ReplyToReviewCommentParams
+	if err := claude.Bind(input, &params); err != nil {
+		return "", err
+	}
+
+	comments, err := e.forge.GetPRReviewComments(ctx, params.URL)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range comments {
+		if c.ID != params.CommentID {
+			continue
+		}
+		url, err := e.forge.ReplyToComment(ctx, params.URL, c, params.Body)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Replied to @%s: %s", c.Author, url), nil
+	}
+	return "", fmt.Errorf("no review comment %s on %s", params.CommentID, params.URL)
+}
+
+// ReviewFollower follows up reviews of the bot's pull requests: when a
+// review is submitted on a PR the bot is tracking, it posts in the thread
+// the PR came from and has the bot address the comments there.
+type ReviewFollower struct {
+	tracker *conflicts.Tracker
+	bot     *Bot
+	leases  storage.LeaseStore
+	holder  string
+	logger  *slog.Logger
+}
+
+// NewReviewFollower creates a review follower. Like backports, reviews are
+// claimed through leases so that only one replica follows each up.
+func NewReviewFollower(cfg *config.Config, tracker *conflicts.Tracker, bot *Bot, leases storage.LeaseStore, logger *slog.Logger) *ReviewFollower {
+	return &ReviewFollower{
+		tracker: tracker,
+		bot:     bot,
+		leases:  leases,
+		holder:  cfg.InstanceID,
+		logger:  logger,
+	}
+}
+
+// HandlePullRequestReview handles a pull_request_review webhook delivery.
+// The bot's own reviews and approvals without comments are ignored.
+func (f *ReviewFollower) HandlePullRequestReview(ctx context.Context, deliveryID string, payload []byte) error {
+	review, err := webhook.ParsePullRequestReview(payload)
+	if err != nil {
+		return err
+	}
+	if review.Action != "submitted" || strings.EqualFold(review.Reviewer, review.PRAuthor) {
+		return nil
+	}
+	if strings.EqualFold(review.State, "approved") && strings.TrimSpace(review.Body) == "" {
+		return nil
+	}
+
+	pr, ok := f.trackedPR(review.Number)
+	if !ok {
+		f.logger.Debug("ignoring review of an untracked pull request", "pr", review.Number)
+		return nil
+	}
+
+	claimed, err := claimOnce(ctx, f.leases, f.holder, deliveryID, fmt.Sprintf("review-%d-%d", review.Number, review.ID))
+	if err != nil {
+		return fmt.Errorf("failed to claim review: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	f.logger.Info("following up pull request review", "pr", review.Number, "reviewer", review.Reviewer)
+	note := fmt.Sprintf(":speech_balloon: @%s reviewed <%s|#%d> (%s). I'll address the comments and reply to them on the PR.",
+		review.Reviewer, review.PRURL, review.Number, strings.ToLower(strings.ReplaceAll(review.State, "_", " ")))
+	request := fmt.Sprintf("%s reviewed PR #%d (%s). Use address_review_comments to check out its branch and read the comments waiting for a reply. Make the requested changes, run the tests, commit and push to the PR's branch without opening a new PR, then answer every comment with reply_to_review_comment saying how it was addressed, or why it wasn't.",
+		review.Reviewer, review.Number, review.PRURL)
+	return f.bot.ContinueThread(ctx, pr.ChannelID, pr.ThreadTS, note, "GitHub", request)
+}
+
+// trackedPR returns the tracked pull request numbered number.
+func (f *ReviewFollower) trackedPR(number int) (conflicts.PendingPR, bool) {
+	for _, pr := range f.tracker.List() {
+		if pr.Number == number && pr.ThreadTS != "" {
+			return pr, true
+		}
+	}
+	return conflicts.PendingPR{}, false
+}
@@ -0,0 +1,106 @@
+// The self-review a second model makes of each change before create_pr opens
+// its pull request.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/activity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/trace"
+)
+
+// newReviewerClient creates the client self-reviews are made with: the
+// conversation's own client, or one for STORMSTACK_REVIEWER_MODEL when that
+// is set. Replays always use the recording.
+func newReviewerClient(cfg *config.Config, client claude.Client, activityLog *activity.Log, traces *trace.Store, logger *slog.Logger) (claude.Client, error) {
+	if cfg.ReviewerModel == "" || cfg.ReviewerModel == cfg.ClaudeModel || cfg.ClaudeReplayFile != "" {
+		return client, nil
+	}
+
+	reviewerCfg := *cfg
+	reviewerCfg.ClaudeModel = cfg.ReviewerModel
+	reviewer, err := newClaudeClient(&reviewerCfg, logger.With("role", "reviewer"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reviewer client: %w", err)
+	}
+	if activityLog != nil {
+		reviewer = &meteredClient{Client: reviewer, log: activityLog}
+	}
+	if traces != nil {
+		reviewer = &tracedClient{Client: reviewer, traces: traces}
+	}
+	return reviewer, nil
+}
+
+// selfReview has the reviewer critique the change a PR from the current
+// branch into base would make, posting new critiques to the thread. It
+// refuses the PR while blocking findings are neither fixed nor answered in
+// review_response, and otherwise returns the section added to the PR's
+// description. A reviewer that fails doesn't hold the PR up.
+func (e *ToolExecutor) selfReview(ctx context.Context, params *claude.CreatePRParams) (string, error) {
+	if e.reviewer == nil {
+		return "", nil
+	}
+
+	// Committed changes since the branch forked; the PR has nothing else
+	diff, err := e.gitOps.Diff(ctx, false, "origin/"+params.Base+"...HEAD", "")
+	if err != nil {
+		e.logger.Warn("failed to diff for self-review", "base", params.Base, "error", err)
+		return "", nil
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", nil
+	}
+
+	key := e.writer.GetRepoPath()
+	info, ok := ConversationFromContext(ctx)
+	if ok {
+		key = info.ConversationID
+	}
+	critique, fresh, err := e.reviewer.Review(ctx, key, params.Title, params.Body, diff)
+	if err != nil {
+		e.logger.Warn("self-review failed", "error", err)
+		return "", nil
+	}
+	if fresh && ok && e.notify != nil {
+		if err := e.notify(info.ChannelID, info.ThreadTS, formatCritique(critique)); err != nil {
+			e.logger.Warn("failed to post self-review", "error", err)
+		}
+	}
+
+	blocking := critique.Blocking()
+	response := strings.TrimSpace(params.ReviewResponse)
+	if len(blocking) > 0 && response == "" {
+		return "", fmt.Errorf("the self-review flagged %d issue(s) that must be addressed before the PR is opened:\n%s\n\nFix them, run the tests, commit and push, then call create_pr again. If a finding is wrong or needs no change, call create_pr again with review_response explaining why, for each finding",
+			len(blocking), claude.FormatFindings(blocking))
+	}
+	if len(critique.Findings) == 0 {
+		return "", nil
+	}
+
+	section := "\n\n### Self-review\n\n" + claude.FormatFindings(critique.Findings)
+	if response != "" {
+		section += "\n\n**Response:** " + response
+	}
+	return section, nil
+}
+
+// formatCritique formats a critique for Slack.
+func formatCritique(critique *claude.Critique) string {
+	if len(critique.Findings) == 0 {
+		return ":mag: *Self-review*: no issues found. " + critique.Summary
+	}
+
+	emoji := ":mag:"
+	if len(critique.Blocking()) > 0 {
+		emoji = ":warning:"
+	}
+	return fmt.Sprintf("%s *Self-review* (%d finding(s), %d blocking): %s\n%s",
+		emoji, len(critique.Findings), len(critique.Blocking()), critique.Summary, claude.FormatFindings(critique.Findings))
+}
@@ -0,0 +1,121 @@
+// Supervision of the Socket Mode connection.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// supervisorMinInterval bounds how often the supervisor checks the connection.
+const supervisorMinInterval = time.Second
+
+// supervise watches the event loop and connection until the context is
+// cancelled. A stalled event loop is replaced, and a disconnection lasting
+// longer than the alert threshold is reported to the admin channel.
+func (b *Bot) supervise(ctx context.Context) {
+	interval := b.stallTimeout / 4
+	if interval < supervisorMinInterval {
+		interval = supervisorMinInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.checkEventLoop(ctx)
+			b.checkDisconnection()
+		}
+	}
+}
+
+// checkEventLoop replaces the event loop when events are waiting but it has
+// not made progress within the stall timeout.
+func (b *Bot) checkEventLoop(ctx context.Context) {
+	since := time.Since(time.Unix(0, b.loopBeat.Load()))
+	if len(b.events) == 0 || since < b.stallTimeout {
+		return
+	}
+
+	b.logger.Error("event loop stalled, starting a new one",
+		"since", since,
+		"pending", len(b.events),
+	)
+	b.startEventLoop(ctx)
+}
+
+// checkDisconnection alerts the admin channel once the bot has been
+// disconnected for longer than the alert threshold.
+func (b *Bot) checkDisconnection() {
+	since := b.disconnectedFor()
+	if since < b.disconnectAlertAfter || b.alerted.Swap(true) {
+		return
+	}
+
+	b.logger.Error("disconnected from Slack for too long", "since", since)
+	b.alertAdmins(fmt.Sprintf(":warning: I've been disconnected from Slack for %s and am still trying to reconnect. Mentions and DMs won't be answered until I'm back.",
+		since.Round(time.Second)))
+}
+
+// markConnected records a successful connection, reporting recovery from a
+// prolonged disconnection.
+func (b *Bot) markConnected() {
+	since := b.disconnectedFor()
+	b.disconnectedAt.Store(0)
+	b.connected.Store(true)
+
+	if b.alerted.Swap(false) {
+		b.logger.Info("reconnected to Slack", "after", since)
+		b.alertAdmins(fmt.Sprintf(":white_check_mark: Reconnected to Slack after %s.", since.Round(time.Second)))
+	}
+}
+
+// markDisconnected records when the connection was lost, keeping the
+// earliest time across failed reconnect attempts.
+func (b *Bot) markDisconnected() {
+	b.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// disconnectedFor returns how long the bot has been disconnected, or zero
+// when it is connected.
+func (b *Bot) disconnectedFor() time.Duration {
+	at := b.disconnectedAt.Load()
+	if at == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, at))
+}
+
+// alertAdmins posts a message to the admin channel, if one is configured.
+func (b *Bot) alertAdmins(text string) {
+	if b.adminChannel == "" {
+		return
+	}
+	if err := b.PostProactive(b.adminChannel, &OutgoingMessage{Text: text}); err != nil {
+		b.logger.Warn("failed to alert admin channel", "error", err)
+	}
+}
+
+// startEventLoop starts a new event loop, retiring any previous one once it
+// finishes the event it is handling.
+func (b *Bot) startEventLoop(ctx context.Context) {
+	b.loopBeat.Store(time.Now().UnixNano())
+	go b.handleEvents(ctx, b.loopGen.Add(1))
+}
+
+// Health reports whether the bot is connected to Slack and processing events.
+func (b *Bot) Health() error {
+	if since := b.disconnectedFor(); since >= b.disconnectAlertAfter {
+		return fmt.Errorf("disconnected from Slack for %s", since.Round(time.Second))
+	}
+	if pending := len(b.events); pending > 0 {
+		if since := time.Since(time.Unix(0, b.loopBeat.Load())); since >= b.stallTimeout {
+			return fmt.Errorf("event loop stalled for %s with %d pending events", since.Round(time.Second), pending)
+		}
+	}
+	return nil
+}
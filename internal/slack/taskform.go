@@ -0,0 +1,221 @@
+// The create task form: a modal for complex requests, opened with
+// /stormstack-dev task or the "Create a task" shortcut.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// IDs of the create task form and its fields.
+const (
+	// taskShortcutID is the callback ID of the "Create a task" shortcut
+	taskShortcutID = "stormstack-create-task"
+	// taskFormID is the callback ID of the form's modal
+	taskFormID = "stormstack-task-form"
+
+	taskRepoBlock        = "task-repo"
+	taskDescriptionBlock = "task-description"
+	taskBranchBlock      = "task-branch"
+	taskPRBlock          = "task-pr"
+	taskChannelBlock     = "task-channel"
+	taskFieldAction      = "value"
+	taskOpenPROption     = "open-pr"
+)
+
+// branchNameRe matches plausible git branch names.
+var branchNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// taskForm is a submitted create task form.
+type taskForm struct {
+	repository  string
+	description string
+	// branch is the branch to base the work on and target with the PR;
+	// empty means the default branch
+	branch string
+	openPR bool
+	// channelID is where the task is worked on; empty means in a DM
+	channelID string
+}
+
+// repositoryName names the repository the bot works on, as the form shows it.
+func repositoryName(cfg *config.Config) string {
+	switch {
+	case cfg.Mode == config.ModeSandbox && cfg.GitHubRepo != "":
+		return cfg.GitHubRepo
+	case cfg.ForgeProject != "":
+		return cfg.ForgeProject
+	default:
+		return filepath.Base(cfg.RepoPath)
+	}
+}
+
+// openTaskForm opens the create task form for userID, defaulting its channel
+// to channelID and its description to text.
+func (b *Bot) openTaskForm(ctx context.Context, triggerID, userID, channelID, text string) {
+	plain := func(text string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+	}
+
+	repoOption := slack.NewOptionBlockObject(b.repository, plain(b.repository), nil)
+	repo := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, plain("Choose a repository"), taskFieldAction, repoOption)
+	repo.InitialOption = repoOption
+
+	description := slack.NewPlainTextInputBlockElement(plain("What should I do? Include the context, constraints and how to check the result."), taskFieldAction)
+	description.Multiline = true
+	description.InitialValue = text
+
+	branch := slack.NewPlainTextInputBlockElement(plain("The default branch"), taskFieldAction)
+	branchBlock := slack.NewInputBlock(taskBranchBlock, plain("Target branch"), plain("The branch to start from and to open the PR against"), branch)
+	branchBlock.Optional = true
+
+	openPR := slack.NewOptionBlockObject(taskOpenPROption, plain("Open a pull request when done"), nil)
+	pr := slack.NewCheckboxGroupsBlockElement(taskFieldAction, openPR)
+	pr.InitialOptions = []*slack.OptionBlockObject{openPR}
+	prBlock := slack.NewInputBlock(taskPRBlock, plain("Pull request"), nil, pr)
+	prBlock.Optional = true
+
+	channel := slack.NewOptionsSelectBlockElement(slack.OptTypeConversations, plain("Choose a channel"), taskFieldAction)
+	channel.Filter = &slack.SelectBlockElementFilter{Include: []string{"public", "private"}, ExcludeBotUsers: true}
+	if channelID != "" && !strings.HasPrefix(channelID, "D") {
+		channel.InitialConversation = channelID
+	}
+	channelBlock := slack.NewInputBlock(taskChannelBlock, plain("Channel"), plain("Where I work on it, in a new thread; leave empty to work on it in a DM"), channel)
+	channelBlock.Optional = true
+
+	view := slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: taskFormID,
+		Title:      plain("Create a task"),
+		Submit:     plain("Start"),
+		Close:      plain("Cancel"),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewInputBlock(taskRepoBlock, plain("Repository"), nil, repo),
+			slack.NewInputBlock(taskDescriptionBlock, plain("Task"), nil, description),
+			branchBlock,
+			prBlock,
+			channelBlock,
+		}},
+	}
+	if _, err := b.clientFor(userID).OpenViewContext(ctx, triggerID, view); err != nil {
+		b.logger.Warn("failed to open task form", "error", err)
+	}
+}
+
+// submitTask validates a submitted create task form, acknowledging it with
+// the errors to show in the form, or closing it and starting the task.
+func (b *Bot) submitTask(ctx context.Context, evt socketmode.Event, callback *slack.InteractionCallback) {
+	values := callback.View.State.Values
+	value := func(block string) slack.BlockAction {
+		return values[block][taskFieldAction]
+	}
+
+	form := taskForm{
+		repository:  value(taskRepoBlock).SelectedOption.Value,
+		description: strings.TrimSpace(value(taskDescriptionBlock).Value),
+		branch:      strings.TrimSpace(value(taskBranchBlock).Value),
+		channelID:   value(taskChannelBlock).SelectedConversation,
+	}
+	for _, option := range value(taskPRBlock).SelectedOptions {
+		if option.Value == taskOpenPROption {
+			form.openPR = true
+		}
+	}
+
+	errs := make(map[string]string)
+	if form.description == "" {
+		errs[taskDescriptionBlock] = "Describe the task."
+	}
+	if form.branch != "" && (!branchNameRe.MatchString(form.branch) || strings.Contains(form.branch, "..")) {
+		errs[taskBranchBlock] = "That isn't a valid branch name."
+	}
+	if len(errs) > 0 {
+		b.ack(evt.Request, slack.NewErrorsViewSubmissionResponse(errs))
+		return
+	}
+	b.ack(evt.Request)
+
+	go b.startTask(ctx, callback.User.ID, form)
+}
+
+// startTask announces a task where it is to be worked on and has the bot
+// work on it in a thread under the announcement, as the sender's request.
+func (b *Bot) startTask(ctx context.Context, userID string, form taskForm) {
+	channelID, isDM := form.channelID, false
+	if channelID == "" {
+		isDM = true
+		// Shadow mode records the DM without opening one
+		channelID = userID
+		if b.shadow == nil {
+			channel, _, _, err := b.clientFor(userID).OpenConversation(&slack.OpenConversationParameters{Users: []string{userID}, ReturnIM: true})
+			if err != nil {
+				b.logger.Error("failed to open DM for task", "user", userID, "error", err)
+				return
+			}
+			channelID = channel.ID
+		}
+	}
+
+	ts, err := b.postMessage(channelID, &OutgoingMessage{Text: taskAnnouncement(userID, form)})
+	if err != nil {
+		b.logger.Error("failed to announce task", "channel", channelID, "error", err)
+		if !isDM {
+			notice := &OutgoingMessage{Text: fmt.Sprintf("Sorry, I couldn't post your task in <#%s>; invite me to the channel, or leave it empty to work on it in a DM.", channelID)}
+			if err := b.sendDM(userID, notice); err != nil {
+				b.logger.Warn("failed to report task failure", "user", userID, "error", err)
+			}
+		}
+		return
+	}
+
+	b.dispatch(ctx, &IncomingMessage{
+		Text:      taskRequest(form),
+		UserID:    userID,
+		ChannelID: channelID,
+		ThreadTS:  ts,
+		IsDM:      isDM,
+	})
+}
+
+// taskAnnouncement describes a task in the message its thread hangs off.
+func taskAnnouncement(userID string, form taskForm) string {
+	branch := "the default branch"
+	if form.branch != "" {
+		branch = FormatInlineCode(form.branch)
+	}
+	pr := "no"
+	if form.openPR {
+		pr = "yes"
+	}
+
+	quoted := strings.ReplaceAll(TruncateText(form.description, 1000), "\n", "\n>")
+	return fmt.Sprintf(":clipboard: *Task from <@%s>*\n>%s\n• Repository: %s\n• Target branch: %s\n• Pull request: %s\n_I'll work on it in this thread._",
+		userID, quoted, FormatInlineCode(form.repository), branch, pr)
+}
+
+// taskRequest turns a task into explicit instructions for Claude.
+func taskRequest(form taskForm) string {
+	var sb strings.Builder
+	sb.WriteString("Please work on this task, submitted with the create task form.\n\n")
+	sb.WriteString("Repository: " + form.repository + "\n")
+	if form.branch != "" {
+		sb.WriteString(fmt.Sprintf("Target branch: %s. Start your work from it, and open any PR against it.\n", form.branch))
+	} else {
+		sb.WriteString("Target branch: the default branch.\n")
+	}
+	if form.openPR {
+		sb.WriteString("When done: run the build and tests, then commit, push and open a PR with create_pr.\n")
+	} else {
+		sb.WriteString("When done: run the build and tests and commit to a branch, but do not open a PR; summarize what changed instead.\n")
+	}
+	sb.WriteString("\nTask:\n" + form.description + "\n")
+	return sb.String()
+}
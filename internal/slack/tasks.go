@@ -0,0 +1,103 @@
+// Tracking of the requests being worked on, so users can see and stop their
+// own.
+
+package slack
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// task is a request being worked on.
+type task struct {
+	userID         string
+	conversationID string
+	channelID      string
+	started        time.Time
+	cancel         context.CancelFunc
+	// cancelled is set when the user stopped the task
+	cancelled atomic.Bool
+}
+
+// taskRegistry tracks the requests being worked on.
+type taskRegistry struct {
+	mu    sync.Mutex
+	tasks map[*task]bool
+}
+
+// newTaskRegistry creates an empty registry.
+func newTaskRegistry() *taskRegistry {
+	return &taskRegistry{tasks: make(map[*task]bool)}
+}
+
+// start registers a request of userID's and returns the context to work on
+// it with; call finish once it is done.
+func (r *taskRegistry) start(ctx context.Context, userID, conversationID, channelID string) (context.Context, *task, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &task{
+		userID:         userID,
+		conversationID: conversationID,
+		channelID:      channelID,
+		started:        time.Now(),
+		cancel:         cancel,
+	}
+
+	r.mu.Lock()
+	r.tasks[t] = true
+	r.mu.Unlock()
+
+	return ctx, t, func() {
+		r.mu.Lock()
+		delete(r.tasks, t)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// running returns userID's requests, oldest first.
+func (r *taskRegistry) running(userID string) []*task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tasks []*task
+	for t := range r.tasks {
+		if t.userID == userID {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].started.Before(tasks[j].started) })
+	return tasks
+}
+
+// active reports whether a request in conversationID is being worked on.
+func (r *taskRegistry) active(conversationID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for t := range r.tasks {
+		if t.conversationID == conversationID {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns how many requests are being worked on, for anyone.
+func (r *taskRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.tasks)
+}
+
+// cancel stops userID's requests and returns how many were running.
+func (r *taskRegistry) cancel(userID string) int {
+	tasks := r.running(userID)
+	for _, t := range tasks {
+		t.cancelled.Store(true)
+		t.cancel()
+	}
+	return len(tasks)
+}
@@ -0,0 +1,318 @@
+// Installation in several Slack workspaces: the OAuth install flow, the bot
+// token of each workspace, and which workspace a channel or user belongs to,
+// so replies go out with the right token.
+
+package slack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	// oauthInstallPath starts the install flow.
+	oauthInstallPath = "/slack/install"
+	// oauthCallbackPath is where Slack redirects after the user approves.
+	oauthCallbackPath = "/slack/oauth/callback"
+	// oauthStateTTL is how long an install flow may take.
+	oauthStateTTL = 10 * time.Minute
+)
+
+// installScopes are the bot scopes requested on install, as in
+// configs/slack-manifest.yml.
+var installScopes = []string{
+	"app_mentions:read",
+	"channels:history",
+	"chat:write",
+	"groups:history",
+	"im:history",
+	"im:read",
+	"im:write",
+	"commands",
+	"files:write",
+	"users:read",
+}
+
+// teams holds the Slack client of each workspace the bot is installed in and
+// the workspace of each channel and user it has heard from. Without
+// installations every call uses the configured bot token.
+type teams struct {
+	fallback      *slack.Client
+	fallbackBotID string
+	// allowed are the workspaces that may install and use the bot; empty
+	// allows every workspace
+	allowed map[string]bool
+
+	mu      sync.RWMutex
+	clients map[string]*slack.Client
+	botIDs  map[string]string
+	// owners maps channel and user IDs to their workspace
+	owners map[string]string
+}
+
+// newTeams creates the workspace registry around the configured bot token.
+func newTeams(fallback *slack.Client, fallbackBotID string, allowed []string) *teams {
+	t := &teams{
+		fallback:      fallback,
+		fallbackBotID: fallbackBotID,
+		allowed:       make(map[string]bool),
+		clients:       make(map[string]*slack.Client),
+		botIDs:        make(map[string]string),
+		owners:        make(map[string]string),
+	}
+	for _, team := range allowed {
+		t.allowed[team] = true
+	}
+	return t
+}
+
+// add registers an installation's client.
+func (t *teams) add(inst storage.Installation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clients[inst.TeamID] = slack.New(inst.BotToken)
+	t.botIDs[inst.TeamID] = inst.BotUserID
+}
+
+// remove forgets a workspace's client, after the bot was uninstalled.
+func (t *teams) remove(teamID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.clients, teamID)
+	delete(t.botIDs, teamID)
+}
+
+// allows reports whether a workspace may use the bot. An empty list allows
+// every workspace, which configuration only permits without OAuth installs.
+func (t *teams) allows(teamID string) bool {
+	return len(t.allowed) == 0 || teamID == "" || t.allowed[teamID]
+}
+
+// learn records the workspace of channel and user IDs seen in an event.
+func (t *teams) learn(teamID string, ids ...string) {
+	if teamID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, id := range ids {
+		if id != "" {
+			t.owners[id] = teamID
+		}
+	}
+}
+
+// team returns the workspace of a channel or user ID, or "" when unknown.
+func (t *teams) team(id string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.owners[id]
+}
+
+// client returns the client to call Slack with about a channel or user ID:
+// its workspace's installation, or the configured bot token.
+func (t *teams) client(id string) *slack.Client {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if client, ok := t.clients[t.owners[id]]; ok {
+		return client
+	}
+	return t.fallback
+}
+
+// isBot reports whether a user ID is the bot's in any workspace.
+func (t *teams) isBot(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, id := range t.botUserIDs() {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// botUserIDs returns the bot's user ID in every workspace.
+func (t *teams) botUserIDs() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ids := []string{t.fallbackBotID}
+	for _, id := range t.botIDs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// mentionsBot reports whether text mentions the bot.
+func (b *Bot) mentionsBot(text string) bool {
+	for _, id := range b.teams.botUserIDs() {
+		if strings.Contains(text, "<@"+id+">") {
+			return true
+		}
+	}
+	return false
+}
+
+// clientFor returns the client to call Slack with about a channel or user.
+func (b *Bot) clientFor(id string) *slack.Client {
+	return b.teams.client(id)
+}
+
+// LoadInstallations registers the workspaces the bot was installed in, and
+// stores later installations in store.
+func (b *Bot) LoadInstallations(ctx context.Context, store storage.InstallationStore) error {
+	installations, err := store.ListInstallations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load installations: %w", err)
+	}
+	for _, inst := range installations {
+		if !b.teams.allows(inst.TeamID) {
+			b.logger.Warn("skipping installation in a workspace that isn't allowed", "team", inst.TeamID)
+			continue
+		}
+		b.teams.add(inst)
+	}
+	b.installations = store
+	b.logger.Info("loaded Slack installations", "workspaces", len(installations))
+	return nil
+}
+
+// learnTeam records the workspace of the channel and user of an event.
+func (b *Bot) learnTeam(evt slackevents.EventsAPIEvent) {
+	switch inner := evt.InnerEvent.Data.(type) {
+	case *slackevents.AppMentionEvent:
+		b.teams.learn(evt.TeamID, inner.Channel, inner.User)
+	case *slackevents.MessageEvent:
+		b.teams.learn(evt.TeamID, inner.Channel, inner.User)
+	case *slackevents.AppHomeOpenedEvent:
+		b.teams.learn(evt.TeamID, inner.Channel, inner.User)
+	}
+}
+
+// handleAppUninstalled forgets a workspace the bot was removed from.
+func (b *Bot) handleAppUninstalled(ctx context.Context, teamID string) {
+	b.teams.remove(teamID)
+	if b.installations == nil {
+		return
+	}
+	if err := b.installations.DeleteInstallation(ctx, teamID); err != nil {
+		b.logger.Error("failed to delete installation", "team", teamID, "error", err)
+		return
+	}
+	b.logger.Info("removed from Slack workspace", "team", teamID)
+}
+
+// oauthStates holds the state parameters of install flows in progress, which
+// guard the callback against forged requests.
+type oauthStates struct {
+	mu     sync.Mutex
+	states map[string]time.Time
+}
+
+// issue returns a new state parameter.
+func (s *oauthStates) issue() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	state := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for old, expires := range s.states {
+		if now.After(expires) {
+			delete(s.states, old)
+		}
+	}
+	s.states[state] = now.Add(oauthStateTTL)
+	return state
+}
+
+// redeem reports whether a state parameter was issued and hasn't expired or
+// been used.
+func (s *oauthStates) redeem(state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.states[state]
+	delete(s.states, state)
+	return ok && time.Now().Before(expires)
+}
+
+// handleInstall starts the install flow by sending the user to Slack's
+// authorization page.
+func (b *Bot) handleInstall(w http.ResponseWriter, r *http.Request) {
+	query := url.Values{
+		"client_id": {b.oauthClientID},
+		"scope":     {strings.Join(installScopes, ",")},
+		"state":     {b.oauthStates.issue()},
+	}
+	if b.oauthRedirectURL != "" {
+		query.Set("redirect_uri", b.oauthRedirectURL)
+	}
+	http.Redirect(w, r, "https://slack.com/oauth/v2/authorize?"+query.Encode(), http.StatusFound)
+}
+
+// handleOAuthCallback completes an install: it exchanges the code for the
+// workspace's bot token and stores the installation.
+func (b *Bot) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		writeInstallPage(w, http.StatusOK, "Installation cancelled", "The bot was not installed ("+reason+").")
+		return
+	}
+	if !b.oauthStates.redeem(query.Get("state")) {
+		writeInstallPage(w, http.StatusBadRequest, "Installation failed", "The install link expired; start again from the install page.")
+		return
+	}
+
+	resp, err := slack.GetOAuthV2ResponseContext(r.Context(), http.DefaultClient, b.oauthClientID, b.oauthClientSecret, query.Get("code"), b.oauthRedirectURL)
+	if err != nil {
+		b.logger.Error("failed to complete Slack installation", "error", err)
+		writeInstallPage(w, http.StatusBadGateway, "Installation failed", "Slack didn't accept the installation; try again.")
+		return
+	}
+	if !b.teams.allows(resp.Team.ID) {
+		b.logger.Warn("rejected installation in a workspace that isn't allowed", "team", resp.Team.ID, "name", resp.Team.Name)
+		writeInstallPage(w, http.StatusForbidden, "Installation not allowed", "This bot can't be installed in "+resp.Team.Name+".")
+		return
+	}
+
+	inst := storage.Installation{
+		TeamID:      resp.Team.ID,
+		TeamName:    resp.Team.Name,
+		BotToken:    resp.AccessToken,
+		BotUserID:   resp.BotUserID,
+		InstalledBy: resp.AuthedUser.ID,
+		InstalledAt: time.Now(),
+	}
+	if err := b.installations.SaveInstallation(r.Context(), inst); err != nil {
+		b.logger.Error("failed to save Slack installation", "team", inst.TeamID, "error", err)
+		writeInstallPage(w, http.StatusInternalServerError, "Installation failed", "The installation couldn't be saved; try again.")
+		return
+	}
+	b.teams.add(inst)
+
+	b.logger.Info("installed in Slack workspace", "team", inst.TeamID, "name", inst.TeamName, "by", inst.InstalledBy)
+	writeInstallPage(w, http.StatusOK, "Installed", "The bot is now installed in "+inst.TeamName+". Mention it in a channel or send it a DM to get started.")
+}
+
+// writeInstallPage writes a minimal page telling the user how the install
+// went.
+func writeInstallPage(w http.ResponseWriter, code int, title, text string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>%s</title></head><body><h1>%s</h1><p>%s</p></body></html>",
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(text))
+}
@@ -0,0 +1,149 @@
+// Affected-test selection and cached test results.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+	"github.com/slack-go/slack"
+)
+
+// maxListedTests bounds the affected test files listed to Claude.
+const maxListedTests = 30
+
+// affectedTestCommand returns the command running the tests affected by the
+// changes on the branch since base (origin/<default branch> by default),
+// and a note describing the selection. When no tests are affected it returns
+// no command, only the note.
+func (e *ToolExecutor) affectedTestCommand(ctx context.Context, base string) (string, string, error) {
+	if base == "" {
+		defaultBranch, err := e.gitOps.GetDefaultBranch(ctx)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get default branch: %w", err)
+		}
+		base = "origin/" + defaultBranch
+	}
+
+	changed, err := e.gitOps.ChangedSince(ctx, base)
+	if err != nil {
+		return "", "", err
+	}
+	if len(changed) == 0 {
+		return "", fmt.Sprintf("Nothing has changed since %s, so no tests are affected.", base), nil
+	}
+	tests, err := e.searcher.AffectedTests(changed)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find affected tests: %w", err)
+	}
+	if len(tests) == 0 {
+		return "", fmt.Sprintf("No tests were found for the %d files changed since %s (%s). Run the full suite, without affected, to be sure.",
+			len(changed), base, TruncateText(strings.Join(changed, ", "), 300)), nil
+	}
+
+	command, err := executor.SelectTestCommand(e.cfg.TestSelectCmd, e.writer.GetRepoPath(), tests)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to select tests: %w", err)
+	}
+
+	listed := tests
+	if len(listed) > maxListedTests {
+		listed = append(listed[:maxListedTests:maxListedTests], fmt.Sprintf("... and %d more", len(tests)-maxListedTests))
+	}
+	note := fmt.Sprintf("Running the %d test files affected by the %d files changed since %s:\n%s\n",
+		len(tests), len(changed), base, joinLines(listed))
+	return command, note, nil
+}
+
+// runTestsCached runs a test command, or returns the cached result of running
+// it against exactly the same code unless rerun is set. note is prepended to
+// the result. full reports whether command runs the whole suite, as only
+// such a run verifies the code for a commit or push.
+func (e *ToolExecutor) runTestsCached(ctx context.Context, command, note string, rerun, full bool) (string, error) {
+	key, commit := e.testCacheKey(ctx, command)
+	if key != "" && !rerun {
+		if cached, ok := e.testCache.Get(key); ok {
+			if full {
+				e.recordVerification(ctx, verifyTests, cached.Passed)
+			}
+			verdict := "passed"
+			if !cached.Passed {
+				verdict = "failed"
+			}
+			return note + fmt.Sprintf("Cached result: these tests %s %s ago against exactly this code (commit %s and the same uncommitted changes). Set rerun to run them again, e.g. to check for a flaky test.\n\n%s",
+				verdict, time.Since(cached.RanAt).Round(time.Second), commit[:7], cached.Output), nil
+		}
+	}
+
+	started := time.Now()
+	result, err := e.runner.RunSelectedTests(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	if full {
+		e.recordVerification(ctx, verifyTests, result.IsSuccess())
+	}
+	output := result.FormatResult()
+	artifacts := e.collectArtifacts(ctx, started)
+	e.addCard(ctx, "tests", e.renderTests(result))
+	if result.TimedOut {
+		output += fmt.Sprintf("The tests were stopped after %s. Set timeout_seconds (up to %d) if the suite needs longer.\n",
+			result.Duration.Round(time.Second), int(executor.MaxTimeout.Seconds()))
+	}
+
+	if key != "" && !result.TimedOut {
+		err := e.testCache.Put(key, executor.CachedTestResult{
+			Command: command,
+			Commit:  commit,
+			Passed:  result.IsSuccess(),
+			Output:  output,
+			Took:    result.Duration,
+			RanAt:   time.Now(),
+		})
+		if err != nil {
+			e.logger.Warn("failed to cache test result", "error", err)
+		}
+	}
+	return note + output + artifacts, nil
+}
+
+// testCacheKey returns the cache key for running command against the code
+// as it is now, and the HEAD commit, or "" if the result can't be cached.
+func (e *ToolExecutor) testCacheKey(ctx context.Context, command string) (string, string) {
+	if e.testCache == nil {
+		return "", ""
+	}
+	head, err := e.gitOps.ResolveCommit(ctx, "HEAD")
+	if err != nil {
+		return "", ""
+	}
+	changed, err := e.gitOps.ChangedFiles(ctx)
+	if err != nil {
+		return "", ""
+	}
+	changes := executor.FingerprintChanges(e.writer.GetRepoPath(), changed)
+	return executor.TestCacheKey(head.SHA, changes, command), head.SHA
+}
+
+// renderTests renders a test run as a card, with a breakdown of the failures
+// when it failed.
+func (e *ToolExecutor) renderTests(result *executor.CommandResult) []slack.Block {
+	outputID := e.keepOutput("tests.log", "Test output", result.CombinedOutput())
+	blocks := RenderCommandResult("Tests", result, outputID)
+	if result.IsSuccess() || result.TimedOut {
+		return blocks
+	}
+	parsers, err := e.outputParsers()
+	if err != nil {
+		return blocks
+	}
+	analysis, err := parsers.Analyze(result.CombinedOutput(), e.cfg.OutputParser)
+	if err != nil || len(analysis.TestFailures)+len(analysis.BuildErrors) == 0 {
+		return blocks
+	}
+	// The analysis shares the run's button
+	return append(blocks[:len(blocks)-1], RenderAnalysis(analysis, outputID)...)
+}
@@ -0,0 +1,104 @@
+// The import of the discussion in a thread the bot is mentioned in partway
+// through.
+
+package slack
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// threadContextPage is how many replies are fetched per conversations.replies call.
+const threadContextPage = 200
+
+// threadContext returns the messages posted in a thread before the bot was
+// mentioned in it that the bot hasn't seen, formatted as a transcript, or ""
+// when there are none. Messages before the bot's last reply or mention in the
+// thread are skipped, as they are already part of its conversation.
+func (b *Bot) threadContext(ctx context.Context, msg *IncomingMessage) string {
+	if b.threadContextLimit <= 0 || msg.IsDM || msg.Command || msg.MessageTS == "" || msg.MessageTS == msg.ThreadTS {
+		return ""
+	}
+
+	replies, err := b.threadReplies(ctx, msg.ChannelID, msg.ThreadTS, msg.MessageTS)
+	if err != nil {
+		b.logger.Warn("failed to read thread history", "channel", msg.ChannelID, "thread", msg.ThreadTS, "error", err)
+		return ""
+	}
+
+	var unseen []slack.Message
+	for _, reply := range replies {
+		switch {
+		case b.teams.isBot(reply.User) || b.mentionsBot(reply.Text):
+			unseen = unseen[:0]
+		case reply.SubType != "" && reply.SubType != "bot_message" && reply.SubType != "thread_broadcast":
+			// Joins, topic changes and the like say nothing about the discussion
+		case strings.TrimSpace(reply.Text) != "":
+			unseen = append(unseen, reply)
+		}
+	}
+	if len(unseen) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if omitted := len(unseen) - b.threadContextLimit; omitted > 0 {
+		fmt.Fprintf(&sb, "(%d earlier messages omitted)\n", omitted)
+		unseen = unseen[omitted:]
+	}
+	for _, reply := range unseen {
+		fmt.Fprintf(&sb, "%s:\n%s\n", b.threadAuthor(reply), strings.TrimSpace(reply.Text))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// threadReplies returns the messages of a thread posted before latest,
+// oldest first, including the one that started it.
+func (b *Bot) threadReplies(ctx context.Context, channelID, threadTS, latest string) ([]slack.Message, error) {
+	var messages []slack.Message
+	cursor := ""
+	for {
+		page, hasMore, next, err := b.clientFor(channelID).GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: channelID,
+			Timestamp: threadTS,
+			Latest:    latest,
+			Cursor:    cursor,
+			Limit:     threadContextPage,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get thread replies: %w", err)
+		}
+		for _, m := range page {
+			if m.Timestamp != latest {
+				messages = append(messages, m)
+			}
+		}
+		if !hasMore || next == "" {
+			return messages, nil
+		}
+		cursor = next
+	}
+}
+
+// threadAuthor names the sender of a thread message as Claude sees message
+// senders elsewhere.
+func (b *Bot) threadAuthor(msg slack.Message) string {
+	if msg.User == "" {
+		return cmp.Or(msg.Username, "A bot") + " (bot)"
+	}
+	if name := b.users.name(msg.User); name != "" {
+		return fmt.Sprintf("%s (<@%s>)", name, msg.User)
+	}
+	return fmt.Sprintf("<@%s>", msg.User)
+}
+
+// withThreadContext prefixes a request with the discussion in the thread
+// before it, so Claude knows what the bot was pulled into.
+func withThreadContext(discussion, request string) string {
+	return "Messages in this Slack thread you haven't seen yet:\n\n" +
+		discussion + "\n\nThe message you were mentioned in:\n" + request
+}
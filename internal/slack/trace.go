@@ -0,0 +1,143 @@
+// Tool-call traces and the trace command.
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/claude"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/trace"
+)
+
+// tracedClient records every Claude call made for a conversation in its trace.
+type tracedClient struct {
+	claude.Client
+	traces *trace.Store
+}
+
+// CreateMessage sends a message to Claude and traces it.
+func (c *tracedClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	start := time.Now()
+	response, err := c.Client.CreateMessage(ctx, params)
+	c.record(ctx, response, time.Since(start))
+	return response, err
+}
+
+// CreateMessageWithTools sends a message with tool definitions and traces it.
+func (c *tracedClient) CreateMessageWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []anthropic.MessageParam,
+	tools []anthropic.ToolUnionParam,
+) (*anthropic.Message, error) {
+	start := time.Now()
+	response, err := c.Client.CreateMessageWithTools(ctx, systemPrompt, messages, tools)
+	c.record(ctx, response, time.Since(start))
+	return response, err
+}
+
+// record adds a Claude call to the conversation's trace.
+func (c *tracedClient) record(ctx context.Context, response *anthropic.Message, took time.Duration) {
+	info, ok := ConversationFromContext(ctx)
+	if !ok || response == nil {
+		return
+	}
+	usage := response.Usage
+	c.traces.Record(info.ConversationID, trace.Step{
+		Kind:         trace.KindModel,
+		Duration:     took,
+		InputTokens:  usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens,
+		OutputTokens: usage.OutputTokens,
+		ToolCalls:    len(claude.ExtractToolUses(response)),
+	})
+}
+
+// traceMiddleware adds every tool call, including those refused by
+// approval or rate limits, to the conversation's trace.
+func (e *ToolExecutor) traceMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		info, ok := ConversationFromContext(ctx)
+		if e.traces == nil || !ok {
+			return next(ctx, name, input)
+		}
+
+		start := time.Now()
+		result, err := next(ctx, name, input)
+
+		step := trace.Step{
+			Kind:     trace.KindTool,
+			Tool:     name,
+			Text:     compactInput(input),
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			step.Error = err.Error()
+		}
+		e.traces.Record(info.ConversationID, step)
+		return result, err
+	}
+}
+
+// compactInput returns a tool call's input on one line.
+func compactInput(input json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, input); err != nil {
+		return string(input)
+	}
+	if buf.String() == "{}" {
+		return ""
+	}
+	return buf.String()
+}
+
+// traceRe matches "trace" and "trace 2".
+var traceRe = regexp.MustCompile(`(?i)^\s*trace(?:\s+(\d+))?\s*$`)
+
+// handleTrace answers the trace command with a summary of a task in the
+// thread and its Mermaid sequence diagram as a file: the latest task, or the
+// nth latest with "trace n". It reports whether the message was a trace
+// command.
+func (h *Handler) handleTrace(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	match := traceRe.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return nil, false
+	}
+	reply := func(text string) (*OutgoingMessage, bool) {
+		h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+		return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+	}
+
+	if h.toolExecutor.traces == nil {
+		return reply("Tracing is disabled. Set `STORMSTACK_TRACE_DIR` to record tool-call traces.")
+	}
+	steps, err := h.toolExecutor.traces.Load(conversationID)
+	if err != nil {
+		h.logger.Error("failed to load trace", "conversation", conversationID, "error", err)
+		return reply(fmt.Sprintf("Sorry, I couldn't load the trace: %v", err))
+	}
+	tasks := trace.Tasks(steps)
+
+	back := 1
+	if match[1] != "" {
+		fmt.Sscanf(match[1], "%d", &back)
+	}
+	if back < 1 || back > len(tasks) {
+		return reply(fmt.Sprintf("There are %d traced tasks in this thread.", len(tasks)))
+	}
+	task := tasks[len(tasks)-back]
+
+	text := fmt.Sprintf(":mag: *Trace of \"%s\"*\n%s", TruncateText(task.Request.Text, 100), task.Summary())
+	out, _ := reply(text)
+	out.Files = []File{{
+		Name:    "trace.mmd",
+		Title:   "Tool-call trace (Mermaid)",
+		Content: task.Mermaid(),
+	}}
+	return out, true
+}
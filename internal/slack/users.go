@@ -0,0 +1,63 @@
+// Slack user name lookups.
+
+package slack
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// userDirectory resolves Slack user IDs to display names, caching the results.
+type userDirectory struct {
+	// client returns the client of a user's workspace
+	client func(userID string) *slack.Client
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	names map[string]string
+}
+
+// newUserDirectory creates a user directory backed by the Slack API.
+func newUserDirectory(client func(userID string) *slack.Client, logger *slog.Logger) *userDirectory {
+	return &userDirectory{
+		client: client,
+		logger: logger,
+		names:  make(map[string]string),
+	}
+}
+
+// name returns a user's display name, or "" if it can't be looked up.
+func (d *userDirectory) name(userID string) string {
+	if userID == "" {
+		return ""
+	}
+
+	d.mu.Lock()
+	name, ok := d.names[userID]
+	d.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	user, err := d.client(userID).GetUserInfo(userID)
+	if err != nil {
+		// Not cached, so the lookup is retried on the user's next message
+		d.logger.Warn("failed to look up Slack user", "user", userID, "error", err)
+		return ""
+	}
+
+	name = user.Profile.DisplayName
+	if name == "" {
+		name = user.RealName
+	}
+	if name == "" {
+		name = user.Name
+	}
+
+	d.mu.Lock()
+	d.names[userID] = name
+	d.mu.Unlock()
+	return name
+}
@@ -0,0 +1,141 @@
+// The build and test verification commits and pushes wait for.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/executor"
+)
+
+// Kinds of verified runs.
+const (
+	verifyBuild = "run_build"
+	verifyTests = "run_tests"
+)
+
+// verifiedRun is the latest run of a build or the tests.
+type verifiedRun struct {
+	// state identifies the code the run saw, as codeState returns it
+	state  string
+	passed bool
+	at     time.Time
+}
+
+// verification remembers a workspace's latest build and test runs. Each tool
+// executor, and so each workspace, has its own.
+type verification struct {
+	mu   sync.Mutex
+	runs map[string]verifiedRun
+}
+
+// record stores the outcome of a run against the code in state.
+func (v *verification) record(kind, state string, passed bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.runs == nil {
+		v.runs = make(map[string]verifiedRun)
+	}
+	v.runs[kind] = verifiedRun{state: state, passed: passed, at: time.Now()}
+}
+
+// get returns the latest run of kind.
+func (v *verification) get(kind string) (verifiedRun, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	run, ok := v.runs[kind]
+	return run, ok
+}
+
+// carry moves the runs made against the code in from to the code in to, as
+// a commit changes HEAD but not the code that was verified.
+func (v *verification) carry(from, to string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for kind, run := range v.runs {
+		if run.state == from {
+			run.state = to
+			v.runs[kind] = run
+		}
+	}
+}
+
+// codeState identifies the code in the workspace as it is now: the HEAD
+// commit and the content of every changed file, the same way test results
+// are cached. Any edit, by whatever tool, changes it.
+func (e *ToolExecutor) codeState(ctx context.Context) (string, error) {
+	head, err := e.gitOps.ResolveCommit(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	changed, err := e.gitOps.ChangedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	return head.SHA + ":" + executor.FingerprintChanges(e.writer.GetRepoPath(), changed), nil
+}
+
+// recordVerification records a run of the whole build or test suite with
+// STORMSTACK_VERIFY_BEFORE_COMMIT set; runs with args, or of the affected
+// tests only, don't verify the code.
+func (e *ToolExecutor) recordVerification(ctx context.Context, kind string, passed bool) {
+	if !e.cfg.VerifyBeforeCommit {
+		return
+	}
+	state, err := e.codeState(ctx)
+	if err != nil {
+		e.logger.Warn("failed to record verification", "kind", kind, "error", err)
+		return
+	}
+	e.verified.record(kind, state, passed)
+}
+
+// checkVerified refuses action, a commit or push, unless the build and
+// tests have passed since the files last changed, and otherwise returns the
+// state of the code checked. Without
+// STORMSTACK_VERIFY_BEFORE_COMMIT nothing is checked, and a project with no
+// build or test command needs no run of that step.
+func (e *ToolExecutor) checkVerified(ctx context.Context, action string) (string, error) {
+	if !e.cfg.VerifyBeforeCommit {
+		return "", nil
+	}
+	state, err := e.codeState(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to check the build and tests before %s: %w", action, err)
+	}
+
+	build, tests, _ := e.runner.Commands()
+	required := map[string]string{verifyBuild: build, verifyTests: tests}
+	for _, kind := range []string{verifyBuild, verifyTests} {
+		if required[kind] == "" {
+			continue
+		}
+		run, ok := e.verified.get(kind)
+		switch {
+		case !ok:
+			return "", fmt.Errorf("%s is refused until %s has passed without args or affected; run %s and %s in full, fix any failures, then %s again", action, kind, verifyBuild, verifyTests, action)
+		case run.state != state:
+			return "", fmt.Errorf("%s is refused: files changed after the last %s (%s ago); run %s and %s again, then %s", action, kind, time.Since(run.at).Round(time.Second), verifyBuild, verifyTests, action)
+		case !run.passed:
+			return "", fmt.Errorf("%s is refused: the last %s failed; fix it and run %s and %s again, then %s", action, kind, verifyBuild, verifyTests, action)
+		}
+	}
+	return state, nil
+}
+
+// carryVerification keeps the build and test results of the code in before
+// valid after a commit, which changes HEAD but not the code.
+func (e *ToolExecutor) carryVerification(ctx context.Context, before string) {
+	if !e.cfg.VerifyBeforeCommit || before == "" {
+		return
+	}
+	after, err := e.codeState(ctx)
+	if err != nil {
+		e.logger.Warn("failed to carry verification over commit", "error", err)
+		return
+	}
+	e.verified.carry(before, after)
+}
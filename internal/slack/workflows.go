@@ -0,0 +1,152 @@
+// Investigation of failed GitHub Actions workflows.
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/webhook"
+)
+
+// Limits for failed workflow investigations.
+const (
+	// workflowLogLines is how much of the failed steps' logs goes in the request
+	workflowLogLines = 150
+	// webhookClaimTTL is how long work started by a webhook stays claimed
+	webhookClaimTTL = 24 * time.Hour
+)
+
+// watchedWorkflow is a workflow whose failures are investigated, and where.
+type watchedWorkflow struct {
+	name    string
+	channel string
+}
+
+// WorkflowFixer investigates failed runs of watched GitHub Actions workflows,
+// such as a nightly end-to-end suite: it announces the failure in the owning
+// channel and has the bot look into it in a thread, fixing it with a pull
+// request or filing a detailed issue.
+type WorkflowFixer struct {
+	watched []watchedWorkflow
+	handler *Handler
+	bot     *Bot
+	leases  storage.LeaseStore
+	holder  string
+	logger  *slog.Logger
+}
+
+// NewWorkflowFixer creates a fixer for the workflows in cfg.FixWorkflows.
+// Runs are claimed through leases, so that with several replicas receiving
+// the same webhook only one investigates each run.
+func NewWorkflowFixer(cfg *config.Config, handler *Handler, bot *Bot, leases storage.LeaseStore, logger *slog.Logger) *WorkflowFixer {
+	defaultChannel := cfg.FixWorkflowsChannel
+	if defaultChannel == "" {
+		defaultChannel = cfg.AdminChannel
+	}
+
+	watched := make([]watchedWorkflow, 0, len(cfg.FixWorkflows))
+	for _, entry := range cfg.FixWorkflows {
+		name, channel, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(channel) == "" {
+			channel = defaultChannel
+		}
+		watched = append(watched, watchedWorkflow{name: strings.TrimSpace(name), channel: strings.TrimSpace(channel)})
+	}
+
+	return &WorkflowFixer{
+		watched: watched,
+		handler: handler,
+		bot:     bot,
+		leases:  leases,
+		holder:  cfg.InstanceID,
+		logger:  logger,
+	}
+}
+
+// HandleWorkflowRun handles a workflow_run webhook delivery. Only failed
+// runs of watched workflows are investigated; everything else is ignored.
+func (f *WorkflowFixer) HandleWorkflowRun(ctx context.Context, deliveryID string, payload []byte) error {
+	run, err := webhook.ParseWorkflowRun(payload)
+	if err != nil {
+		return err
+	}
+	if !run.Failed() {
+		return nil
+	}
+	channel, ok := f.channelFor(run)
+	if !ok {
+		return nil
+	}
+
+	claimed, err := claimOnce(ctx, f.leases, f.holder, deliveryID, fmt.Sprintf("workflow-run-%d-%d", run.ID, run.RunAttempt))
+	if err != nil {
+		return fmt.Errorf("failed to claim workflow run: %w", err)
+	}
+	if !claimed {
+		f.logger.Debug("workflow run already claimed", "run", run.ID, "delivery", deliveryID)
+		return nil
+	}
+
+	f.logger.Info("investigating failed workflow run", "workflow", run.Name, "run", run.ID, "channel", channel)
+	return f.bot.StartThread(ctx, channel, workflowAnnouncement(run), "GitHub Actions", f.request(ctx, run))
+}
+
+// claimOnce claims the work called name for one webhook delivery. GitHub
+// redelivers events, and each replica may receive them, so only the first
+// claim succeeds; every claim has its own holder, so a repeat is never
+// mistaken for a renewal.
+func claimOnce(ctx context.Context, leases storage.LeaseStore, instanceID, deliveryID, name string) (bool, error) {
+	holder := fmt.Sprintf("%s/%s/%d", instanceID, deliveryID, time.Now().UnixNano())
+	return leases.AcquireLease(ctx, name, holder, webhookClaimTTL)
+}
+
+// channelFor returns the channel that owns run's workflow, if it is watched.
+func (f *WorkflowFixer) channelFor(run *webhook.WorkflowRun) (string, bool) {
+	for _, w := range f.watched {
+		if run.Matches(w.name) {
+			return w.channel, true
+		}
+	}
+	return "", false
+}
+
+// request builds the instructions the bot follows to investigate run.
+func (f *WorkflowFixer) request(ctx context.Context, run *webhook.WorkflowRun) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The scheduled GitHub Actions workflow %q failed and needs investigating.\n\n", run.Name))
+	sb.WriteString(fmt.Sprintf("Repository: %s\n", run.Repository))
+	sb.WriteString(fmt.Sprintf("Run: #%d (attempt %d), triggered by %s\n", run.RunNumber, run.RunAttempt, run.Event))
+	sb.WriteString(fmt.Sprintf("Workflow file: %s\n", run.Path))
+	sb.WriteString(fmt.Sprintf("Branch: %s\nCommit: %s\n", run.HeadBranch, run.HeadSHA))
+	sb.WriteString(fmt.Sprintf("Conclusion: %s\nURL: %s\n\n", run.Conclusion, run.URL))
+
+	logs := "(logs unavailable)"
+	if gh, ok := f.handler.Forge().(*git.GitHub); ok {
+		if out, err := gh.GetFailedRunLogs(ctx, run.ID, workflowLogLines); err != nil {
+			f.logger.Warn("failed to get workflow logs", "run", run.ID, "error", err)
+		} else if out != "" {
+			logs = out
+		}
+	}
+	sb.WriteString("Logs of the failed steps:\n```\n" + logs + "\n```\n\n")
+
+	sb.WriteString("Find the root cause using the repository, the recent git log and the logs above. ")
+	sb.WriteString("If you can fix it with confidence, do so on a new branch, run the relevant build and tests, and open a pull request that links the run. ")
+	sb.WriteString("If the fix is unclear, risky, or outside the repository (flaky infrastructure, expired credentials), do not change code; ")
+	sb.WriteString("instead open an issue with create_issue describing the failure, the evidence, the likely cause and suggested next steps. ")
+	sb.WriteString("Finish with a short summary of what you found and what you did.")
+	return sb.String()
+}
+
+// workflowAnnouncement is the message that starts the investigation thread.
+func workflowAnnouncement(run *webhook.WorkflowRun) string {
+	return fmt.Sprintf(":rotating_light: Workflow *%s* failed on `%s` (<%s|run #%d>). I'm looking into it in this thread.",
+		run.Name, run.HeadBranch, run.URL, run.RunNumber)
+}
@@ -0,0 +1,189 @@
+// Personal DM workspaces.
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/repo"
+)
+
+// workspaces gives each user a personal worktree in DMs, or each
+// conversation one of its own, with a tool executor of its own, so work there
+// never touches the shared checkout.
+type workspaces struct {
+	worktrees *repo.Worktrees
+	// newExecutor creates a tool executor for the worktree at path
+	newExecutor func(path string) (*ToolExecutor, error)
+
+	// limit is how many worktrees may be checked out at once (0 for no
+	// limit); beyond it the least recently used idle ones are released
+	limit int
+	// quota is the disk space each worktree may use, in bytes (0 for none)
+	quota int64
+	// busy reports whether a workspace is being worked in, so it isn't released
+	busy func(key string) bool
+	// conversations records whose conversation workspaces are, by key
+	conversations *workspaceConversations
+
+	mu        sync.Mutex
+	executors map[string]*ToolExecutor
+	used      map[string]time.Time
+
+	sizesMu sync.Mutex
+	sizes   map[string]workspaceSize
+}
+
+// newWorkspaces creates the personal workspaces.
+func newWorkspaces(worktrees *repo.Worktrees, newExecutor func(path string) (*ToolExecutor, error)) *workspaces {
+	return &workspaces{
+		worktrees:   worktrees,
+		newExecutor: newExecutor,
+		executors:   make(map[string]*ToolExecutor),
+		used:        make(map[string]time.Time),
+		sizes:       make(map[string]workspaceSize),
+	}
+}
+
+// executor returns the tool executor for userID's workspace, creating the
+// worktree on first use.
+func (w *workspaces) executor(userID string) (*ToolExecutor, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.used[userID] = time.Now()
+	if e, ok := w.executors[userID]; ok {
+		// Failing to record the use only makes the workspace look older
+		_ = w.worktrees.Touch(userID)
+		return e, nil
+	}
+
+	path, err := w.worktrees.Ensure(userID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := w.newExecutor(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace tools: %w", err)
+	}
+	w.executors[userID] = e
+	w.evict()
+	return e, nil
+}
+
+// each calls fn with the tool executor of every workspace in use.
+func (w *workspaces) each(fn func(e *ToolExecutor)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, e := range w.executors {
+		fn(e)
+	}
+}
+
+// reset discards userID's workspace; the next DM starts a fresh one.
+func (w *workspaces) reset(userID string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.executors, userID)
+	delete(w.used, userID)
+	return w.worktrees.Remove(userID)
+}
+
+// release frees userID's worktree while keeping its branch; the next DM
+// restores it. A worktree with uncommitted changes is kept.
+func (w *workspaces) release(userID string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	released, err := w.worktrees.Release(userID)
+	if released {
+		delete(w.executors, userID)
+		delete(w.used, userID)
+	}
+	return released, err
+}
+
+// resetWorkspaceRe matches a request to reset the personal workspace.
+var resetWorkspaceRe = regexp.MustCompile(`(?i)^\s*reset\s+(?:my\s+)?workspace\s*[.!]?\s*$`)
+
+// handleWorkspaceReset resets the sender's personal workspace on request in
+// a DM. It reports whether the message was a reset request.
+func (h *Handler) handleWorkspaceReset(ctx context.Context, conversationID string, msg *IncomingMessage) (*OutgoingMessage, bool) {
+	if h.workspaces == nil || !msg.IsDM || !resetWorkspaceRe.MatchString(msg.Text) {
+		return nil, false
+	}
+
+	text := "Reset your workspace: unpushed changes and the local `" + h.workspaces.worktrees.Branch(msg.UserID) + "` branch were discarded. Your next message starts from a fresh checkout of the default branch."
+	if err := h.workspaces.reset(msg.UserID); err != nil {
+		h.logger.Error("failed to reset workspace", "user", msg.UserID, "error", err)
+		text = fmt.Sprintf("Sorry, I couldn't reset your workspace: %v", err)
+	}
+
+	h.conversation.RecordExchange(ctx, conversationID, msg.ChannelID, messageAuthor(msg), msg.Text, text)
+	return &OutgoingMessage{Text: text, ThreadTS: msg.ThreadTS}, true
+}
+
+// workspaceOwner returns the user whose personal workspace a message's tools
+// run in: the sender for DMs when workspaces are enabled, "" otherwise.
+func workspaceOwner(w *workspaces, msg *IncomingMessage) string {
+	if w == nil || !msg.IsDM {
+		return ""
+	}
+	return msg.UserID
+}
+
+// executorFor returns the tool executor for the conversation in ctx: the
+// user's personal workspace in DMs when enabled, the conversation's own
+// workspace when enabled, the shared checkout otherwise.
+func (h *Handler) executorFor(ctx context.Context) (*ToolExecutor, error) {
+	info, ok := ConversationFromContext(ctx)
+	switch {
+	case !ok:
+		return h.toolExecutor, nil
+	case info.Workspace != "" && h.workspaces != nil:
+		return h.workspaces.executor(info.Workspace)
+	case info.ConversationID != "" && h.conversationWorkspaces != nil:
+		return h.conversationExecutor(info.ConversationID)
+	default:
+		return h.toolExecutor, nil
+	}
+}
+
+// executeTool runs a tool call in the conversation's workspace. Calls that
+// would change something are refused in threads that were handed off, and
+// in conversation workspaces over their disk quota; code changes wait for
+// the plan to be approved when plans need approval.
+func (h *Handler) executeTool(ctx context.Context, name string, input json.RawMessage) (string, error) {
+	e, err := h.executorFor(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := h.handedOffError(ctx, e, name, input); err != nil {
+		return "", err
+	}
+	if err := h.quotaError(ctx, e, name); err != nil {
+		return "", err
+	}
+	if err := h.planError(ctx, e, name, input); err != nil {
+		return "", err
+	}
+	return e.Execute(ctx, name, input)
+}
+
+// eachWorkspace calls fn with the tool executor of every personal and
+// conversation workspace in use.
+func (h *Handler) eachWorkspace(fn func(e *ToolExecutor)) {
+	if h.workspaces != nil {
+		h.workspaces.each(fn)
+	}
+	if h.conversationWorkspaces != nil {
+		h.conversationWorkspaces.each(fn)
+	}
+}
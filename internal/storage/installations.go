@@ -0,0 +1,185 @@
+// Storage for the bot's installations in Slack workspaces.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Installation is the bot installed in a Slack workspace through OAuth.
+type Installation struct {
+	TeamID      string    `json:"team_id"`      // Workspace the bot is installed in
+	TeamName    string    `json:"team_name"`    // Workspace name, for logs and messages
+	BotToken    string    `json:"bot_token"`    // Bot token (xoxb-...) for the workspace
+	BotUserID   string    `json:"bot_user_id"`  // The bot's user ID in the workspace
+	InstalledBy string    `json:"installed_by"` // User who installed the bot
+	InstalledAt time.Time `json:"installed_at"` // When the bot was last installed
+}
+
+// InstallationStore stores the bot's workspace installations.
+type InstallationStore interface {
+	// SaveInstallation stores an installation, replacing any earlier one in
+	// the same workspace.
+	SaveInstallation(ctx context.Context, inst Installation) error
+
+	// DeleteInstallation forgets the installation in a workspace.
+	DeleteInstallation(ctx context.Context, teamID string) error
+
+	// ListInstallations returns every installation, by workspace ID.
+	ListInstallations(ctx context.Context) ([]Installation, error)
+}
+
+// FileInstallationStore is a JSON file-backed implementation of
+// InstallationStore. The file holds bot tokens and is only readable by its
+// owner.
+type FileInstallationStore struct {
+	mu            sync.RWMutex
+	path          string
+	installations map[string]Installation
+}
+
+// NewFileInstallationStore creates an installation store persisted at path.
+func NewFileInstallationStore(path string) (*FileInstallationStore, error) {
+	s := &FileInstallationStore{
+		path:          path,
+		installations: make(map[string]Installation),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installations file: %w", err)
+	}
+
+	var installations []Installation
+	if err := json.Unmarshal(data, &installations); err != nil {
+		return nil, fmt.Errorf("failed to parse installations file: %w", err)
+	}
+	for _, inst := range installations {
+		s.installations[inst.TeamID] = inst
+	}
+
+	return s, nil
+}
+
+// SaveInstallation stores an installation, replacing any earlier one in the
+// same workspace.
+func (s *FileInstallationStore) SaveInstallation(ctx context.Context, inst Installation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.installations[inst.TeamID] = inst
+	return s.persist()
+}
+
+// DeleteInstallation forgets the installation in a workspace.
+func (s *FileInstallationStore) DeleteInstallation(ctx context.Context, teamID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.installations[teamID]; !ok {
+		return nil
+	}
+	delete(s.installations, teamID)
+	return s.persist()
+}
+
+// ListInstallations returns every installation, by workspace ID.
+func (s *FileInstallationStore) ListInstallations(ctx context.Context) ([]Installation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	installations := make([]Installation, 0, len(s.installations))
+	for _, inst := range s.installations {
+		installations = append(installations, inst)
+	}
+	sort.Slice(installations, func(i, j int) bool { return installations[i].TeamID < installations[j].TeamID })
+	return installations, nil
+}
+
+// persist writes all installations to disk. Callers must hold the write lock.
+func (s *FileInstallationStore) persist() error {
+	installations := make([]Installation, 0, len(s.installations))
+	for _, inst := range s.installations {
+		installations = append(installations, inst)
+	}
+
+	data, err := json.MarshalIndent(installations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode installations: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create installations directory: %w", err)
+	}
+
+	// Write to a temp file first so a crash can't corrupt the store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write installations file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SaveInstallation stores an installation, replacing any earlier one in the
+// same workspace.
+func (s *MemoryStore) SaveInstallation(ctx context.Context, inst Installation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.installations[inst.TeamID] = inst
+	return nil
+}
+
+// DeleteInstallation forgets the installation in a workspace.
+func (s *MemoryStore) DeleteInstallation(ctx context.Context, teamID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.installations, teamID)
+	return nil
+}
+
+// ListInstallations returns every installation, by workspace ID.
+func (s *MemoryStore) ListInstallations(ctx context.Context) ([]Installation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	installations := make([]Installation, 0, len(s.installations))
+	for _, inst := range s.installations {
+		installations = append(installations, inst)
+	}
+	sort.Slice(installations, func(i, j int) bool { return installations[i].TeamID < installations[j].TeamID })
+	return installations, nil
+}
+
+// SaveInstallation stores an installation.
+func (s *RedisStore) SaveInstallation(ctx context.Context, inst Installation) error {
+	return errors.New("redis store not implemented")
+}
+
+// DeleteInstallation forgets the installation in a workspace.
+func (s *RedisStore) DeleteInstallation(ctx context.Context, teamID string) error {
+	return errors.New("redis store not implemented")
+}
+
+// ListInstallations returns every installation.
+func (s *RedisStore) ListInstallations(ctx context.Context) ([]Installation, error) {
+	return nil, errors.New("redis store not implemented")
+}
+
+var (
+	_ InstallationStore = (*FileInstallationStore)(nil)
+	_ InstallationStore = (*MemoryStore)(nil)
+	_ InstallationStore = (*RedisStore)(nil)
+)
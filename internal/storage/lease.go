@@ -0,0 +1,179 @@
+// Leases used for leader election between replicas.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Lease is a named, time-limited claim held by one instance.
+type Lease struct {
+	Name      string    `json:"name"`       // What the lease guards, e.g. "leader"
+	Holder    string    `json:"holder"`     // Instance ID of the current holder
+	ExpiresAt time.Time `json:"expires_at"` // When the claim lapses unless renewed
+}
+
+// LeaseStore stores leases shared by every instance.
+type LeaseStore interface {
+	// AcquireLease claims or renews the named lease for holder. It reports
+	// whether holder owns the lease afterwards.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease gives up the named lease if holder owns it.
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// lockStaleAfter is when a leftover lock file is assumed abandoned.
+const lockStaleAfter = 10 * time.Second
+
+// FileLeaseStore keeps leases as JSON files in a directory, which replicas
+// share through a common volume.
+type FileLeaseStore struct {
+	dir string
+}
+
+// NewFileLeaseStore creates a lease store in dir.
+func NewFileLeaseStore(dir string) (*FileLeaseStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lease directory: %w", err)
+	}
+	return &FileLeaseStore{dir: dir}, nil
+}
+
+// AcquireLease claims or renews the named lease for holder.
+func (s *FileLeaseStore) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	unlock, err := s.lock(name)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	lease, err := s.read(name)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if lease != nil && lease.Holder != holder && now.Before(lease.ExpiresAt) {
+		return false, nil
+	}
+
+	return true, s.write(&Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)})
+}
+
+// ReleaseLease gives up the named lease if holder owns it.
+func (s *FileLeaseStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	unlock, err := s.lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lease, err := s.read(name)
+	if err != nil || lease == nil || lease.Holder != holder {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// lock takes an exclusive lock file guarding the named lease.
+func (s *FileLeaseStore) lock(name string) (func(), error) {
+	path := s.path(name) + ".lock"
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		// Break locks left behind by a crashed instance
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(path)
+			f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock lease %s: %w", name, err)
+	}
+	f.Close()
+	return func() { os.Remove(path) }, nil
+}
+
+// read loads the named lease, returning nil if there is none.
+func (s *FileLeaseStore) read(name string) (*Lease, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease: %w", err)
+	}
+
+	var lease Lease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		// A corrupt lease is treated as expired
+		return nil, nil
+	}
+	return &lease, nil
+}
+
+// write atomically replaces the lease file.
+func (s *FileLeaseStore) write(lease *Lease) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("failed to encode lease: %w", err)
+	}
+
+	tmp := s.path(lease.Name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(lease.Name)); err != nil {
+		return fmt.Errorf("failed to write lease: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the named lease.
+func (s *FileLeaseStore) path(name string) string {
+	return filepath.Join(s.dir, name+".lease")
+}
+
+// MemoryLeaseStore is an in-process LeaseStore for single-instance deployments.
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]Lease
+}
+
+// NewMemoryLeaseStore creates an in-memory lease store.
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{leases: make(map[string]Lease)}
+}
+
+// AcquireLease claims or renews the named lease for holder.
+func (s *MemoryLeaseStore) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := s.leases[name]; ok && lease.Holder != holder && now.Before(lease.ExpiresAt) {
+		return false, nil
+	}
+	s.leases[name] = Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease gives up the named lease if holder owns it.
+func (s *MemoryLeaseStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lease, ok := s.leases[name]; ok && lease.Holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
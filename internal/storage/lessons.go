@@ -0,0 +1,223 @@
+// Long-term storage for lessons learned from reviews.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lesson is a distilled piece of reviewer feedback the bot should remember.
+type Lesson struct {
+	Text      string    `json:"text"`       // The distilled rule, e.g. "Never panic in handlers"
+	Source    string    `json:"source"`     // PR URL the lesson was last learned from
+	Count     int       `json:"count"`      // How many times reviewers raised it
+	CreatedAt time.Time `json:"created_at"` // When the lesson was first learned
+	UpdatedAt time.Time `json:"updated_at"` // When the lesson was last reinforced
+}
+
+// LessonStore provides long-term storage for reviewer feedback.
+type LessonStore interface {
+	// AddLesson stores a lesson, reinforcing an existing one with the same text.
+	AddLesson(ctx context.Context, lesson Lesson) error
+
+	// ListLessons returns up to limit lessons, most reinforced first.
+	ListLessons(ctx context.Context, limit int) ([]Lesson, error)
+
+	// LearnedComments returns which of the review comments keys identify
+	// lessons were already learned from.
+	LearnedComments(ctx context.Context, keys []string) (map[string]bool, error)
+
+	// MarkLearned records that lessons were learned from the review comments
+	// keys identify, so they aren't learned from again.
+	MarkLearned(ctx context.Context, keys []string) error
+}
+
+// lessonsFile is the content of the lessons file.
+type lessonsFile struct {
+	Lessons  []Lesson `json:"lessons"`
+	Comments []string `json:"comments"`
+}
+
+// FileLessonStore is a JSON file-backed implementation of LessonStore.
+type FileLessonStore struct {
+	mu      sync.RWMutex
+	path    string
+	lessons map[string]*Lesson
+
+	// comments holds the keys of the review comments already learned from
+	comments map[string]bool
+}
+
+// NewFileLessonStore creates a lesson store persisted at path.
+// An empty path keeps lessons in memory only.
+func NewFileLessonStore(path string) (*FileLessonStore, error) {
+	s := &FileLessonStore{
+		path:     path,
+		lessons:  make(map[string]*Lesson),
+		comments: make(map[string]bool),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lessons file: %w", err)
+	}
+
+	// Older files hold only the list of lessons
+	var file lessonsFile
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &file.Lessons)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lessons file: %w", err)
+	}
+	for i := range file.Lessons {
+		s.lessons[lessonKey(file.Lessons[i].Text)] = &file.Lessons[i]
+	}
+	for _, key := range file.Comments {
+		s.comments[key] = true
+	}
+
+	return s, nil
+}
+
+// AddLesson stores a lesson, reinforcing an existing one with the same text.
+func (s *FileLessonStore) AddLesson(ctx context.Context, lesson Lesson) error {
+	text := strings.TrimSpace(lesson.Text)
+	if text == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key := lessonKey(text)
+	if existing, ok := s.lessons[key]; ok {
+		existing.Count++
+		existing.Source = lesson.Source
+		existing.UpdatedAt = now
+	} else {
+		s.lessons[key] = &Lesson{
+			Text:      text,
+			Source:    lesson.Source,
+			Count:     1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	return s.persist()
+}
+
+// ListLessons returns up to limit lessons, most reinforced first.
+func (s *FileLessonStore) ListLessons(ctx context.Context, limit int) ([]Lesson, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lessons := make([]Lesson, 0, len(s.lessons))
+	for _, l := range s.lessons {
+		lessons = append(lessons, *l)
+	}
+
+	sort.Slice(lessons, func(i, j int) bool {
+		if lessons[i].Count != lessons[j].Count {
+			return lessons[i].Count > lessons[j].Count
+		}
+		return lessons[i].UpdatedAt.After(lessons[j].UpdatedAt)
+	})
+
+	if limit > 0 && len(lessons) > limit {
+		lessons = lessons[:limit]
+	}
+	return lessons, nil
+}
+
+// LearnedComments returns which of the review comments keys identify lessons
+// were already learned from.
+func (s *FileLessonStore) LearnedComments(ctx context.Context, keys []string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	learned := make(map[string]bool)
+	for _, key := range keys {
+		if s.comments[key] {
+			learned[key] = true
+		}
+	}
+	return learned, nil
+}
+
+// MarkLearned records that lessons were learned from the review comments keys
+// identify.
+func (s *FileLessonStore) MarkLearned(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		s.comments[key] = true
+	}
+	return s.persist()
+}
+
+// persist writes all lessons to disk. Callers must hold the write lock.
+func (s *FileLessonStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := lessonsFile{
+		Lessons:  make([]Lesson, 0, len(s.lessons)),
+		Comments: make([]string, 0, len(s.comments)),
+	}
+	for _, l := range s.lessons {
+		file.Lessons = append(file.Lessons, *l)
+	}
+	for key := range s.comments {
+		file.Comments = append(file.Comments, key)
+	}
+	sort.Strings(file.Comments)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lessons: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create lessons directory: %w", err)
+	}
+
+	// Write to a temp file first so a crash can't corrupt the store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lessons file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// lessonKey normalizes lesson text for deduplication.
+func lessonKey(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.TrimSuffix(text, ".")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -11,12 +11,14 @@ import (
 type MemoryStore struct {
 	mu            sync.RWMutex
 	conversations map[string]*Conversation
+	installations map[string]Installation
 }
 
 // NewMemoryStore creates a new in-memory conversation store.
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		conversations: make(map[string]*Conversation),
+		installations: make(map[string]Installation),
 	}
 }
 
@@ -61,6 +63,8 @@ func (s *MemoryStore) AddMessage(ctx context.Context, id, channelID string, msg
 
 	conv.Messages = append(conv.Messages, msg)
 	conv.UpdatedAt = time.Now()
+	// New activity reopens an archived conversation
+	conv.ArchivedAt = time.Time{}
 
 	return nil
 }
@@ -89,14 +93,33 @@ func (s *MemoryStore) Cleanup(ctx context.Context, olderThan time.Duration) erro
 	return nil
 }
 
+// ListIdle returns the open conversations with no activity for at least idleFor.
+func (s *MemoryStore) ListIdle(ctx context.Context, idleFor time.Duration) ([]*Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := time.Now().Add(-idleFor)
+	var idle []*Conversation
+	for _, conv := range s.conversations {
+		if !conv.Archived() && conv.UpdatedAt.Before(cutoff) {
+			idle = append(idle, s.copyConversation(conv))
+		}
+	}
+	return idle, nil
+}
+
 // copyConversation creates a deep copy of a conversation.
 func (s *MemoryStore) copyConversation(conv *Conversation) *Conversation {
 	copy := &Conversation{
-		ID:        conv.ID,
-		ChannelID: conv.ChannelID,
-		Messages:  make([]Message, len(conv.Messages)),
-		CreatedAt: conv.CreatedAt,
-		UpdatedAt: conv.UpdatedAt,
+		ID:          conv.ID,
+		ChannelID:   conv.ChannelID,
+		Messages:    make([]Message, len(conv.Messages)),
+		CreatedAt:   conv.CreatedAt,
+		UpdatedAt:   conv.UpdatedAt,
+		ArchivedAt:  conv.ArchivedAt,
+		HandedOffTo: conv.HandedOffTo,
+
+		Plan: conv.Plan.clone(),
 	}
 	for i, msg := range conv.Messages {
 		copy.Messages[i] = msg
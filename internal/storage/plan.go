@@ -0,0 +1,72 @@
+// The implementation plans kept with conversations.
+
+package storage
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Plan statuses.
+const (
+	// PlanProposed plans wait for someone in the thread to approve them
+	PlanProposed = "proposed"
+	// PlanApproved plans may be implemented
+	PlanApproved = "approved"
+)
+
+// PlanFile is a file a plan changes, and how.
+type PlanFile struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// Plan is the implementation plan of a task, approved in its thread before
+// the bot changes any code.
+type Plan struct {
+	Summary    string     `json:"summary"`
+	Files      []PlanFile `json:"files"`
+	Tests      string     `json:"tests"`
+	Status     string     `json:"status"`
+	ProposedAt time.Time  `json:"proposed_at"`
+	// ApprovedBy is the Slack user who approved the plan, "" when it was
+	// approved automatically
+	ApprovedBy string    `json:"approved_by,omitempty"`
+	ApprovedAt time.Time `json:"approved_at,omitempty"`
+}
+
+// Approved reports whether the plan may be implemented.
+func (p *Plan) Approved() bool {
+	return p != nil && p.Status == PlanApproved
+}
+
+// Includes reports whether file, relative to the repository root, is one
+// of the plan's files, or lies in a directory the plan lists with a trailing
+// slash.
+func (p *Plan) Includes(file string) bool {
+	file = cleanPlanPath(file)
+	for _, f := range p.Files {
+		planned := cleanPlanPath(f.Path)
+		if file == planned || (strings.HasSuffix(f.Path, "/") && strings.HasPrefix(file, planned+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPlanPath normalizes a path relative to the repository root.
+func cleanPlanPath(file string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(file)), "/")
+}
+
+// clone returns a copy of the plan, or nil for none.
+func (p *Plan) clone() *Plan {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.Files = slices.Clone(p.Files)
+	return &c
+}
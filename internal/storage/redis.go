@@ -46,3 +46,18 @@ func (s *RedisStore) Delete(ctx context.Context, id string) error {
 func (s *RedisStore) Cleanup(ctx context.Context, olderThan time.Duration) error {
 	return errors.New("redis store not implemented")
 }
+
+// ListIdle returns the open conversations with no activity for at least idleFor.
+func (s *RedisStore) ListIdle(ctx context.Context, idleFor time.Duration) ([]*Conversation, error) {
+	return nil, errors.New("redis store not implemented")
+}
+
+// AcquireLease claims or renews the named lease for holder.
+func (s *RedisStore) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	return false, errors.New("redis store not implemented")
+}
+
+// ReleaseLease gives up the named lease if holder owns it.
+func (s *RedisStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	return errors.New("redis store not implemented")
+}
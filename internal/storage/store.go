@@ -8,9 +8,43 @@ import (
 
 // Message represents a single message in a conversation.
 type Message struct {
-	Role      string    `json:"role"`       // "user" or "assistant"
-	Content   string    `json:"content"`    // The message content
-	Timestamp time.Time `json:"timestamp"`  // When the message was sent
+	Role      string    `json:"role"`                // "user" or "assistant"
+	Content   string    `json:"content"`             // The message content
+	Timestamp time.Time `json:"timestamp"`           // When the message was sent
+	UserID    string    `json:"user_id,omitempty"`   // Slack user ID of the sender of a user message
+	UserName  string    `json:"user_name,omitempty"` // Display name of the sender
+}
+
+// Participant is a user taking part in a conversation.
+type Participant struct {
+	UserID string
+	Name   string
+}
+
+// Participants returns the users who have sent messages in the conversation,
+// in order of their first message.
+func (c *Conversation) Participants() []Participant {
+	if c == nil {
+		return nil
+	}
+
+	var participants []Participant
+	seen := make(map[string]int)
+	for _, msg := range c.Messages {
+		if msg.UserID == "" {
+			continue
+		}
+		if i, ok := seen[msg.UserID]; ok {
+			// Keep the most recent display name
+			if msg.UserName != "" {
+				participants[i].Name = msg.UserName
+			}
+			continue
+		}
+		seen[msg.UserID] = len(participants)
+		participants = append(participants, Participant{UserID: msg.UserID, Name: msg.UserName})
+	}
+	return participants
 }
 
 // Conversation represents a conversation thread.
@@ -20,6 +54,19 @@ type Conversation struct {
 	Messages  []Message `json:"messages"`   // Message history
 	CreatedAt time.Time `json:"created_at"` // When the conversation started
 	UpdatedAt time.Time `json:"updated_at"` // Last activity
+	// ArchivedAt is when the conversation was closed for inactivity (zero while open)
+	ArchivedAt time.Time `json:"archived_at,omitempty"`
+	// HandedOffTo is the Slack user the conversation was handed off to; the
+	// bot makes no changes in it until re-engaged ("" when it wasn't)
+	HandedOffTo string `json:"handed_off_to,omitempty"`
+	// Plan is the implementation plan proposed for the conversation's task,
+	// if any
+	Plan *Plan `json:"plan,omitempty"`
+}
+
+// Archived reports whether the conversation has been closed.
+func (c *Conversation) Archived() bool {
+	return !c.ArchivedAt.IsZero()
 }
 
 // ConversationStore provides storage for conversation history.
@@ -39,4 +86,7 @@ type ConversationStore interface {
 
 	// Cleanup removes conversations older than the given duration.
 	Cleanup(ctx context.Context, olderThan time.Duration) error
+
+	// ListIdle returns the open conversations with no activity for at least idleFor.
+	ListIdle(ctx context.Context, idleFor time.Duration) ([]*Conversation, error)
 }
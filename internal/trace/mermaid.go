@@ -0,0 +1,161 @@
+// Task summaries and Mermaid diagrams of traces.
+
+package trace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLabel bounds the text of a message in the diagram.
+const maxLabel = 80
+
+// Task is the work done for one request: the Claude calls and tool calls
+// between it and the next request.
+type Task struct {
+	Request Step
+	Steps   []Step
+}
+
+// Tasks splits a conversation's steps into its tasks. Steps recorded before
+// the first request, e.g. by a command, are left out.
+func Tasks(steps []Step) []Task {
+	var tasks []Task
+	for _, step := range steps {
+		if step.Kind == KindRequest {
+			tasks = append(tasks, Task{Request: step})
+			continue
+		}
+		if len(tasks) > 0 {
+			tasks[len(tasks)-1].Steps = append(tasks[len(tasks)-1].Steps, step)
+		}
+	}
+	return tasks
+}
+
+// Iterations returns the number of Claude calls the task took.
+func (t *Task) Iterations() int {
+	return t.count(func(s Step) bool { return s.Kind == KindModel })
+}
+
+// ToolCalls returns the number of tools the task called.
+func (t *Task) ToolCalls() int {
+	return t.count(func(s Step) bool { return s.Kind == KindTool })
+}
+
+// FailedCalls returns the number of tool calls that failed.
+func (t *Task) FailedCalls() int {
+	return t.count(func(s Step) bool { return s.Kind == KindTool && s.Error != "" })
+}
+
+// count returns the number of the task's steps matching fn.
+func (t *Task) count(fn func(Step) bool) int {
+	n := 0
+	for _, step := range t.Steps {
+		if fn(step) {
+			n++
+		}
+	}
+	return n
+}
+
+// Duration returns the time from the request to the task's last step.
+func (t *Task) Duration() time.Duration {
+	if len(t.Steps) == 0 {
+		return 0
+	}
+	return t.Steps[len(t.Steps)-1].Time.Sub(t.Request.Time)
+}
+
+// Summary describes the task in a line or two of Slack mrkdwn: iterations,
+// tool calls, tokens, duration and the most used tools.
+func (t *Task) Summary() string {
+	var input, output int64
+	uses := make(map[string]int)
+	for _, step := range t.Steps {
+		input += step.InputTokens
+		output += step.OutputTokens
+		if step.Kind == KindTool {
+			uses[step.Tool]++
+		}
+	}
+
+	tools := make([]string, 0, len(uses))
+	for tool := range uses {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		if uses[tools[i]] != uses[tools[j]] {
+			return uses[tools[i]] > uses[tools[j]]
+		}
+		return tools[i] < tools[j]
+	})
+	if len(tools) > 5 {
+		tools = tools[:5]
+	}
+	for i, tool := range tools {
+		tools[i] = fmt.Sprintf("`%s` ×%d", tool, uses[tool])
+	}
+
+	summary := fmt.Sprintf("%d iterations, %d tool calls (%d failed), %d input and %d output tokens, %s",
+		t.Iterations(), t.ToolCalls(), t.FailedCalls(), input, output, t.Duration().Round(time.Second))
+	if len(tools) > 0 {
+		summary += "\nMost used: " + strings.Join(tools, ", ")
+	}
+	return summary
+}
+
+// Mermaid renders the task as a Mermaid sequence diagram between the user,
+// the bot, Claude and the tools, with each iteration of the tool loop marked.
+func (t *Task) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("sequenceDiagram\n")
+	sb.WriteString("    actor User\n")
+	sb.WriteString("    participant Bot\n")
+	sb.WriteString("    participant Claude\n")
+	sb.WriteString("    participant Tools\n")
+	sb.WriteString(fmt.Sprintf("    User->>Bot: %s\n", label(t.Request.Text)))
+
+	iteration := 0
+	for _, step := range t.Steps {
+		switch step.Kind {
+		case KindModel:
+			iteration++
+			sb.WriteString(fmt.Sprintf("    Note over Bot,Claude: Iteration %d\n", iteration))
+			sb.WriteString(fmt.Sprintf("    Bot->>Claude: %d input tokens\n", step.InputTokens))
+			reply := fmt.Sprintf("%d tool calls, %d output tokens", step.ToolCalls, step.OutputTokens)
+			if step.ToolCalls == 0 {
+				reply = fmt.Sprintf("final answer, %d output tokens", step.OutputTokens)
+			}
+			sb.WriteString(fmt.Sprintf("    Claude-->>Bot: %s (%s)\n", reply, step.Duration.Round(time.Millisecond)))
+		case KindTool:
+			call := step.Tool
+			if step.Text != "" {
+				call += " " + step.Text
+			}
+			sb.WriteString(fmt.Sprintf("    Bot->>Tools: %s\n", label(call)))
+			if step.Error != "" {
+				sb.WriteString(fmt.Sprintf("    Tools--xBot: failed: %s\n", label(step.Error)))
+			} else {
+				sb.WriteString(fmt.Sprintf("    Tools-->>Bot: ok (%s)\n", step.Duration.Round(time.Millisecond)))
+			}
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("    Bot-->>User: reply after %d iterations\n", iteration))
+	return sb.String()
+}
+
+// labelReplacer removes the characters that end or escape a Mermaid message.
+var labelReplacer = strings.NewReplacer("\r", "", "\n", " ", ";", ",", "#", "")
+
+// label makes text safe to use as a Mermaid message.
+func label(text string) string {
+	text = strings.TrimSpace(labelReplacer.Replace(text))
+	if text == "" {
+		return "(empty)"
+	}
+	return clip(text, maxLabel)
+}
@@ -0,0 +1,160 @@
+// Package trace provides per-conversation traces of Claude and tool calls.
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Step kinds.
+const (
+	// KindRequest is a user's message, which starts a task
+	KindRequest = "request"
+	// KindModel is a Claude call, one iteration of the tool loop
+	KindModel = "model"
+	// KindTool is a tool call
+	KindTool = "tool"
+)
+
+// maxStepText bounds the request or tool input stored with a step.
+const maxStepText = 200
+
+// Step is a single thing the bot did while working on a conversation.
+type Step struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Text is the request, or a summary of the tool's input
+	Text     string        `json:"text,omitempty"`
+	Tool     string        `json:"tool,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is why a tool call failed
+	Error        string `json:"error,omitempty"`
+	InputTokens  int64  `json:"input_tokens,omitempty"`
+	OutputTokens int64  `json:"output_tokens,omitempty"`
+	// ToolCalls is the number of tools a Claude call asked for
+	ToolCalls int `json:"tool_calls,omitempty"`
+}
+
+// Store appends the steps of each conversation to a JSON Lines file in a
+// directory. Replicas may share the directory: each step is a single append.
+type Store struct {
+	mu     sync.Mutex
+	dir    string
+	logger *slog.Logger
+}
+
+// NewStore creates a trace store in dir. A nil *Store records nothing.
+func NewStore(dir string, logger *slog.Logger) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trace directory: %w", err)
+	}
+	return &Store{dir: dir, logger: logger}, nil
+}
+
+// Record appends a step to a conversation's trace.
+func (s *Store) Record(conversationID string, step Step) {
+	if s == nil || conversationID == "" {
+		return
+	}
+	if step.Time.IsZero() {
+		step.Time = time.Now()
+	}
+	step.Text = clip(step.Text, maxStepText)
+
+	data, err := json.Marshal(step)
+	if err != nil {
+		s.logger.Warn("failed to encode trace step", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.file(conversationID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		s.logger.Warn("failed to open trace", "error", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		s.logger.Warn("failed to write trace step", "error", err)
+	}
+}
+
+// Load returns a conversation's steps in the order they were recorded.
+func (s *Store) Load(conversationID string) ([]Step, error) {
+	file, err := os.Open(s.file(conversationID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace: %w", err)
+	}
+	defer file.Close()
+
+	var steps []Step
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var step Step
+		if err := json.Unmarshal(scanner.Bytes(), &step); err != nil {
+			continue // Skip lines torn by a crash
+		}
+		steps = append(steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+	return steps, nil
+}
+
+// Prune deletes the traces of conversations with no steps since before.
+func (s *Store) Prune(before time.Time) error {
+	if s == nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list trace directory: %w", err)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to prune trace: %w", err)
+		}
+	}
+	return nil
+}
+
+// unsafeNameRe matches characters not allowed in trace file names.
+var unsafeNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// file returns the trace file of a conversation.
+func (s *Store) file(conversationID string) string {
+	return filepath.Join(s.dir, unsafeNameRe.ReplaceAllString(conversationID, "_")+".jsonl")
+}
+
+// clip shortens text to at most limit bytes on a rune boundary, marking the cut.
+func clip(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return strings.TrimSpace(text[:limit]) + "…"
+}
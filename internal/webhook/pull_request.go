@@ -0,0 +1,56 @@
+// Parsing of GitHub pull_request events.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PullRequest is a pull request reported by a pull_request event.
+type PullRequest struct {
+	// Action is the event's action, e.g. closed or labeled
+	Action string
+	// Label is the label added or removed by labeled and unlabeled actions
+	Label string
+	// Repository is the repository's full name, e.g. "owner/repo"
+	Repository string
+
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"html_url"`
+	Merged bool   `json:"merged"`
+	// Labels are the names of the pull request's labels
+	Labels []string `json:"-"`
+}
+
+// ParsePullRequest decodes the payload of a pull_request event.
+func ParsePullRequest(payload []byte) (*PullRequest, error) {
+	var event struct {
+		Action      string `json:"action"`
+		PullRequest struct {
+			PullRequest
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		} `json:"pull_request"`
+		Label struct {
+			Name string `json:"name"`
+		} `json:"label"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse pull_request event: %w", err)
+	}
+
+	pr := event.PullRequest.PullRequest
+	pr.Action = event.Action
+	pr.Label = event.Label.Name
+	pr.Repository = event.Repository.FullName
+	for _, l := range event.PullRequest.Labels {
+		pr.Labels = append(pr.Labels, l.Name)
+	}
+	return &pr, nil
+}
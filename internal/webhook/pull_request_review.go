@@ -0,0 +1,68 @@
+// Parsing of GitHub pull_request_review events.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PullRequestReview is a review reported by a pull_request_review event.
+type PullRequestReview struct {
+	// Action is the event's action, e.g. submitted or dismissed
+	Action string
+	// Repository is the repository's full name, e.g. "owner/repo"
+	Repository string
+
+	// ID identifies the review
+	ID int64
+	// Reviewer is the login of the review's author
+	Reviewer string
+	// State is the review's state, e.g. approved or changes_requested
+	State string
+	Body  string
+
+	Number int
+	PRURL  string
+	// PRAuthor is the login of the pull request's author
+	PRAuthor string
+}
+
+// ParsePullRequestReview decodes the payload of a pull_request_review event.
+func ParsePullRequestReview(payload []byte) (*PullRequestReview, error) {
+	type user struct {
+		Login string `json:"login"`
+	}
+	var event struct {
+		Action string `json:"action"`
+		Review struct {
+			ID    int64  `json:"id"`
+			User  user   `json:"user"`
+			State string `json:"state"`
+			Body  string `json:"body"`
+		} `json:"review"`
+		PullRequest struct {
+			Number int    `json:"number"`
+			URL    string `json:"html_url"`
+			User   user   `json:"user"`
+		} `json:"pull_request"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse pull_request_review event: %w", err)
+	}
+
+	return &PullRequestReview{
+		Action:     event.Action,
+		Repository: event.Repository.FullName,
+		ID:         event.Review.ID,
+		Reviewer:   event.Review.User.Login,
+		State:      event.Review.State,
+		Body:       event.Review.Body,
+		Number:     event.PullRequest.Number,
+		PRURL:      event.PullRequest.URL,
+		PRAuthor:   event.PullRequest.User.Login,
+	}, nil
+}
@@ -0,0 +1,137 @@
+// Package webhook provides an HTTP receiver for GitHub webhook deliveries.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPayloadSize is the largest payload GitHub delivers.
+const maxPayloadSize = 25 << 20
+
+// Handler processes the payload of one delivery.
+type Handler func(ctx context.Context, deliveryID string, payload []byte) error
+
+// Server receives GitHub webhooks at /webhooks/github, verifies their
+// signature and passes each event to the handler registered for its type.
+// Deliveries are acknowledged at once and handled in the background, since
+// GitHub gives up on a delivery after ten seconds.
+type Server struct {
+	addr     string
+	secret   []byte
+	mu       sync.Mutex
+	handlers map[string]Handler
+	logger   *slog.Logger
+}
+
+// NewServer creates a webhook receiver listening on addr that accepts
+// deliveries signed with secret.
+func NewServer(addr, secret string, logger *slog.Logger) *Server {
+	return &Server{
+		addr:     addr,
+		secret:   []byte(secret),
+		handlers: make(map[string]Handler),
+		logger:   logger,
+	}
+}
+
+// On registers the handler for an event type, e.g. "workflow_run".
+func (s *Server) On(event string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[event] = handler
+}
+
+// Run serves webhooks until the context is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/github", func(w http.ResponseWriter, r *http.Request) {
+		s.handleGitHub(ctx, w, r)
+	})
+
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("webhook receiver listening", "addr", s.addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve webhooks: %w", err)
+	}
+	return nil
+}
+
+// handleGitHub verifies a delivery and hands it to its event's handler.
+func (s *Server) handleGitHub(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if !s.verify(payload, r.Header.Get("X-Hub-Signature-256")) {
+		s.logger.Warn("rejected webhook with invalid signature", "remote", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	delivery := r.Header.Get("X-GitHub-Delivery")
+	if event == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	s.mu.Lock()
+	handler, ok := s.handlers[event]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	go func() {
+		if err := handler(ctx, delivery, payload); err != nil {
+			s.logger.Error("failed to handle webhook", "event", event, "delivery", delivery, "error", err)
+		}
+	}()
+}
+
+// verify checks a payload against its "sha256=<hex>" HMAC signature.
+func (s *Server) verify(payload []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
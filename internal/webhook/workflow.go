@@ -0,0 +1,60 @@
+// Parsing of GitHub Actions workflow_run events.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// WorkflowRun is a GitHub Actions workflow run reported by a workflow_run event.
+type WorkflowRun struct {
+	// Action is the event's action: requested, in_progress or completed
+	Action string
+	// Repository is the repository's full name, e.g. "owner/repo"
+	Repository string
+
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Event      string `json:"event"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+	URL        string `json:"html_url"`
+	RunNumber  int    `json:"run_number"`
+	RunAttempt int    `json:"run_attempt"`
+}
+
+// ParseWorkflowRun decodes the payload of a workflow_run event.
+func ParseWorkflowRun(payload []byte) (*WorkflowRun, error) {
+	var event struct {
+		Action      string      `json:"action"`
+		WorkflowRun WorkflowRun `json:"workflow_run"`
+		Repository  struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow_run event: %w", err)
+	}
+
+	run := event.WorkflowRun
+	run.Action = event.Action
+	run.Repository = event.Repository.FullName
+	return &run, nil
+}
+
+// Failed reports whether the run has completed unsuccessfully.
+func (r *WorkflowRun) Failed() bool {
+	return r.Action == "completed" && (r.Conclusion == "failure" || r.Conclusion == "timed_out")
+}
+
+// Matches reports whether the run belongs to workflow, given by name or by
+// the file name of its definition (e.g. "nightly-e2e.yml").
+func (r *WorkflowRun) Matches(workflow string) bool {
+	return strings.EqualFold(r.Name, workflow) || strings.EqualFold(path.Base(r.Path), workflow)
+}
@@ -3,17 +3,55 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"maps"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/activity"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/api"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/config"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/conflicts"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/git"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/health"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/leader"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/metrics"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/outcomes"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/repo"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/scheduler"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/shadow"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/slack"
 	"github.com/ireland-samantha/stormstack-dev-bot/internal/storage"
+	"github.com/ireland-samantha/stormstack-dev-bot/internal/webhook"
 )
 
+// adminDigestInterval is how often the admin digest job checks whether the
+// day's digest is due.
+const adminDigestInterval = 10 * time.Minute
+
+// healthReportInterval is how often the health report job checks whether its
+// schedule has fired.
+const healthReportInterval = time.Minute
+
+// prDigestInterval is how often the PR digest job checks whether its schedule
+// has fired.
+const prDigestInterval = time.Minute
+
+// apiChannelID is the channel REST API conversations are held in, apart from
+// Slack's.
+const apiChannelID = "api"
+
+// deferredPostsInterval is how often proactive posts held outside working
+// hours are checked for delivery.
+const deferredPostsInterval = time.Minute
+
 func main() {
 	// Setup logger
 	logLevel := slog.LevelInfo
@@ -25,6 +63,14 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		os.Exit(runEval(os.Args[2:], logger))
+	}
+	if len(os.Args) > 1 && (os.Args[1] == "chat" || os.Args[1] == "--cli") {
+		os.Exit(runChat(os.Args[2:], logger))
+	}
+
 	logger.Info("Starting StormStack Dev Bot...")
 
 	// Load configuration
@@ -53,19 +99,142 @@ func main() {
 	}
 	logger.Info("Repository ready", "path", repoManager.GetRepoPath())
 
+	// Create metrics registry
+	registry := metrics.NewRegistry()
+
 	// Create conversation store
 	store := storage.NewMemoryStore()
 
+	// Create long-term store for lessons learned from PR reviews
+	lessons, err := storage.NewFileLessonStore(cfg.LessonsFile)
+	if err != nil {
+		logger.Error("Failed to load lessons", "error", err)
+		os.Exit(1)
+	}
+
+	// In shadow mode, record actions instead of performing them
+	var recorder *shadow.Recorder
+	if cfg.ShadowMode {
+		recorder, err = shadow.NewRecorder(cfg.ShadowLog, logger)
+		if err != nil {
+			logger.Error("Failed to create shadow recorder", "error", err)
+			os.Exit(1)
+		}
+		logger.Warn("Shadow mode enabled: nothing will be posted or written", "log", cfg.ShadowLog)
+	}
+
+	// Track in-flight bot PRs for conflict early warnings
+	tracker := conflicts.NewTracker()
+
+	// Log daily activity for the admin digest
+	var activityLog *activity.Log
+	if cfg.ActivityDir != "" {
+		activityLog, err = activity.NewLog(cfg.ActivityDir, logger)
+		if err != nil {
+			logger.Error("Failed to create activity log", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Create message handler
-	handler := slack.NewHandler(cfg, repoManager.GetRepoPath(), store, logger)
+	handler, err := slack.NewHandler(cfg, repoManager.GetRepoPath(), store, lessons, tracker, recorder, activityLog, logger)
+	if err != nil {
+		logger.Error("Failed to create message handler", "error", err)
+		os.Exit(1)
+	}
+
+	// Workspaces mapped to another checkout get a handler of their own
+	teamHandlers := make(map[string]*slack.Handler)
+	for _, entry := range cfg.SlackTeams {
+		team, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		teamHandler, err := slack.NewHandler(cfg, path, store, lessons, tracker, recorder, activityLog, logger.With("team", team))
+		if err != nil {
+			logger.Error("Failed to create message handler", "team", team, "error", err)
+			os.Exit(1)
+		}
+		teamHandlers[team] = teamHandler
+	}
+	route := func(ctx context.Context, msg *slack.IncomingMessage) (*slack.OutgoingMessage, error) {
+		if teamHandler, ok := teamHandlers[msg.TeamID]; ok {
+			return teamHandler.HandleMessage(ctx, msg)
+		}
+		return handler.HandleMessage(ctx, msg)
+	}
 
 	// Create Slack bot
-	bot, err := slack.NewBot(cfg, handler.HandleMessage, logger)
+	bot, err := slack.NewBot(cfg, route, recorder, logger)
 	if err != nil {
 		logger.Error("Failed to create Slack bot", "error", err)
 		os.Exit(1)
 	}
 
+	// Workspaces the bot was installed in through OAuth
+	if cfg.SlackClientID != "" {
+		installations, err := storage.NewFileInstallationStore(cfg.SlackInstallationsFile)
+		if err != nil {
+			logger.Error("Failed to open Slack installations", "error", err)
+			os.Exit(1)
+		}
+		if err := bot.LoadInstallations(context.Background(), installations); err != nil {
+			logger.Error("Failed to load Slack installations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, h := range append([]*slack.Handler{handler}, slices.Collect(maps.Values(teamHandlers))...) {
+		// Tool metrics and slow tool warnings
+		h.UseMetrics(registry)
+		h.NotifyWith(func(channelID, threadTS, text string) error {
+			return bot.SendMessage(channelID, &slack.OutgoingMessage{Text: text, ThreadTS: threadTS})
+		})
+		// Long-running command output is streamed to the thread
+		h.StreamWith(bot)
+		// Messages nobody asked for wait for the channel's working hours
+		h.AnnounceWith(func(channelID, threadTS, text string) error {
+			return bot.PostProactive(channelID, &slack.OutgoingMessage{Text: text, ThreadTS: threadTS})
+		})
+	}
+	// The Home tab shows the bot's status and can sync the repository
+	syncRepo := func(ctx context.Context) (bool, error) {
+		synced, err := repo.SyncIfIdle(repoManager)
+		if err != nil || !synced {
+			return synced, err
+		}
+		// Keep the semantic search index in step with the synced checkout
+		return true, handler.UpdateSemanticIndex(ctx)
+	}
+	handler.SyncWith(syncRepo)
+	bot.HomeWith(handler)
+
+	// Reload the model, approvers, path policies and build commands without a
+	// restart, on SIGHUP or the reload command
+	var reloading sync.Mutex
+	reload := func(ctx context.Context) ([]string, error) {
+		reloading.Lock()
+		defer reloading.Unlock()
+
+		newCfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		changes, err := handler.Reload(newCfg)
+		if err != nil {
+			return nil, err
+		}
+		for team, teamHandler := range teamHandlers {
+			if _, err := teamHandler.Reload(newCfg); err != nil {
+				return changes, fmt.Errorf("workspace %s: %w", team, err)
+			}
+		}
+		return changes, nil
+	}
+	for _, h := range append([]*slack.Handler{handler}, slices.Collect(maps.Values(teamHandlers))...) {
+		h.ReloadWith(reload)
+	}
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -79,6 +248,244 @@ func main() {
 		cancel()
 	}()
 
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			logger.Info("Received SIGHUP, reloading configuration")
+			if _, err := reload(ctx); err != nil {
+				logger.Error("Failed to reload configuration, keeping the current one", "error", err)
+			}
+		}
+	}()
+
+	// Cross-PR conflict watcher
+	watcher := conflicts.NewWatcher(
+		tracker,
+		handler.GitOps(),
+		handler.Forge(),
+		func(channelID, threadTS, text string) error {
+			return bot.PostProactive(channelID, &slack.OutgoingMessage{Text: text, ThreadTS: threadTS})
+		},
+		logger,
+	)
+	// Learn from the reviewers' feedback once a bot PR is merged or closed
+	if len(cfg.LearnReviewers) > 0 {
+		watcher.OnClosed(func(ctx context.Context, pr conflicts.PendingPR) {
+			ref := pr.URL
+			if ref == "" {
+				ref = pr.Branch
+			}
+			if _, err := handler.Learner().LearnFromPR(ctx, ref); err != nil {
+				logger.Warn("failed to learn from PR review", "pr", ref, "error", err)
+			}
+		})
+	}
+
+	// Start background jobs
+	sched := scheduler.New(cfg.SchedulerJitter, registry, logger)
+	sched.Limit(bot.Capacity())
+
+	// With replicas, only the elected leader runs singleton jobs
+	var leases storage.LeaseStore = storage.NewMemoryLeaseStore()
+	if cfg.LeaderElection {
+		fileLeases, err := storage.NewFileLeaseStore(cfg.LeaseDir)
+		if err != nil {
+			logger.Error("Failed to create lease store", "error", err)
+			os.Exit(1)
+		}
+		leases = fileLeases
+		elector := leader.New(leases, cfg.InstanceID, cfg.LeaderLeaseTTL, registry, logger)
+		sched.Elect(elector.IsLeader)
+		go elector.Run(ctx)
+	}
+
+	// Every replica has its own checkout to keep up to date
+	sched.Add(scheduler.Job{
+		Name:     "repo_sync",
+		Interval: cfg.RepoSyncInterval,
+		Run: func(ctx context.Context) error {
+			synced, err := syncRepo(ctx)
+			if err == nil && !synced {
+				logger.Debug("skipped repository sync, checkout is busy")
+			}
+			return err
+		},
+	})
+	// Conversations are kept in memory, so every instance cleans up its own
+	sched.Add(scheduler.Job{
+		Name:     "conversation_cleanup",
+		Interval: cfg.CleanupInterval,
+		Run: func(ctx context.Context) error {
+			before := store.Len()
+			if err := store.Cleanup(ctx, cfg.ConversationMaxAge); err != nil {
+				return err
+			}
+			registry.Inc("conversations.cleaned", int64(before-store.Len()))
+			if err := handler.PruneTraces(time.Now().Add(-cfg.ConversationMaxAge)); err != nil {
+				return err
+			}
+			for _, h := range append([]*slack.Handler{handler}, slices.Collect(maps.Values(teamHandlers))...) {
+				removed, err := h.PruneWorkspaces(ctx, cfg.ConversationMaxAge)
+				registry.Inc("workspaces.removed", int64(removed))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	// Close idle threads with a summary; like cleanup, each instance closes its own
+	if cfg.AutoCloseAfter > 0 {
+		sched.Add(scheduler.Job{
+			Name:     "conversation_autoclose",
+			Interval: cfg.AutoCloseInterval,
+			Run: func(ctx context.Context) error {
+				closed, err := handler.CloseIdleConversations(ctx, cfg.AutoCloseAfter)
+				registry.Inc("conversations.closed", int64(closed))
+				return err
+			},
+		})
+	}
+	// Track what becomes of the bot's PRs
+	if gh, ok := handler.Forge().(*git.GitHub); ok && cfg.PROutcomesInterval > 0 {
+		prOutcomes := outcomes.NewStore(cfg.PROutcomesFile)
+		handler.UseOutcomes(prOutcomes)
+		sched.Add(scheduler.Job{
+			Name:       "pr_outcomes",
+			Interval:   cfg.PROutcomesInterval,
+			Run:        outcomes.NewCollector(gh, prOutcomes, logger).Collect,
+			LeaderOnly: true,
+		})
+	}
+	// Deferred posts are held in memory, so every instance flushes its own
+	if len(cfg.WorkingHours) > 0 {
+		sched.Add(scheduler.Job{
+			Name:     "deferred_posts",
+			Interval: deferredPostsInterval,
+			Run: func(ctx context.Context) error {
+				return bot.FlushDeferred()
+			},
+		})
+	}
+	sched.Add(scheduler.Job{
+		Name:       "conflict_check",
+		Interval:   cfg.ConflictCheckInterval,
+		Run:        watcher.Check,
+		LeaderOnly: true,
+	})
+	// Summarize yesterday's activity for admins once the digest time has passed
+	if cfg.AdminChannel != "" && cfg.AdminDigestTime != "" && activityLog != nil {
+		digest := slack.NewAdminDigest(cfg, activityLog, bot, logger)
+		sched.Add(scheduler.Job{
+			Name:       "admin_digest",
+			Interval:   adminDigestInterval,
+			Run:        digest.Run,
+			LeaderOnly: true,
+		})
+	}
+	// Post the repository health report on its cron schedule
+	if cfg.HealthReportChannel != "" {
+		reporter, err := slack.NewHealthReporter(cfg, handler, bot, logger)
+		if err != nil {
+			logger.Error("Failed to create health reporter", "error", err)
+			os.Exit(1)
+		}
+		sched.Add(scheduler.Job{
+			Name:       "health_report",
+			Interval:   healthReportInterval,
+			Run:        reporter.Run,
+			LeaderOnly: true,
+		})
+	}
+	// Nudge reviewers about the bot's open PRs on the digest schedule
+	if cfg.PRDigestChannel != "" {
+		prDigest, err := slack.NewPRDigest(cfg, handler, bot, logger)
+		if err != nil {
+			logger.Error("Failed to create PR digest", "error", err)
+			os.Exit(1)
+		}
+		sched.Add(scheduler.Job{
+			Name:       "pr_digest",
+			Interval:   prDigestInterval,
+			Run:        prDigest.Run,
+			LeaderOnly: true,
+		})
+	}
+	sched.Start(ctx)
+
+	// Build or refresh the semantic search index without delaying startup
+	if cfg.SemanticSearch {
+		go func() {
+			if err := handler.UpdateSemanticIndex(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("failed to build semantic search index", "error", err)
+			}
+		}()
+	}
+
+	// Serve the health endpoint
+	if cfg.HealthAddr != "" {
+		healthServer := health.NewServer(cfg.HealthAddr, logger)
+		healthServer.Register("slack", bot.Health)
+		go func() {
+			if err := healthServer.Run(ctx); err != nil {
+				logger.Error("health endpoint failed", "error", err)
+			}
+		}()
+	}
+
+	// Receive GitHub webhooks to investigate failed scheduled workflows,
+	// backport labeled pull requests and follow up reviews
+	if cfg.WebhookAddr != "" {
+		hooks := webhook.NewServer(cfg.WebhookAddr, cfg.WebhookSecret, logger)
+		if len(cfg.FixWorkflows) > 0 {
+			fixer := slack.NewWorkflowFixer(cfg, handler, bot, leases, logger)
+			hooks.On("workflow_run", fixer.HandleWorkflowRun)
+		}
+		if cfg.BackportChannel != "" {
+			backporter := slack.NewBackporter(cfg, bot, leases, logger)
+			hooks.On("pull_request", backporter.HandlePullRequest)
+		}
+		if cfg.ReviewFollowup {
+			follower := slack.NewReviewFollower(cfg, tracker, bot, leases, logger)
+			hooks.On("pull_request_review", follower.HandlePullRequestReview)
+		}
+		go func() {
+			if err := hooks.Run(ctx); err != nil {
+				logger.Error("webhook receiver failed", "error", err)
+			}
+		}()
+	}
+
+	// Let other systems such as CI and dashboards drive the bot over HTTP
+	if cfg.APIAddr != "" {
+		server := api.NewServer(cfg.APIAddr, cfg.APIKeys, func(ctx context.Context, req api.Request) (*api.Reply, error) {
+			// Callers can't reach each other's conversations by picking the same ID
+			threadTS := "api-" + url.PathEscape(req.Caller) + "/" + req.ConversationID
+			out, err := handler.HandleMessage(ctx, &slack.IncomingMessage{
+				Text:      req.Text,
+				UserID:    "api:" + req.Caller,
+				UserName:  req.Caller,
+				ChannelID: apiChannelID,
+				ThreadTS:  threadTS,
+				IsDM:      true,
+			})
+			if err != nil || out == nil {
+				return nil, err
+			}
+			reply := &api.Reply{Text: out.Text}
+			for _, f := range out.Files {
+				reply.Files = append(reply.Files, api.File{Name: f.Name, Content: f.Content})
+			}
+			return reply, nil
+		}, logger)
+		go func() {
+			if err := server.Run(ctx); err != nil {
+				logger.Error("REST API failed", "error", err)
+			}
+		}()
+	}
+
 	// Run the bot
 	logger.Info("StormStack Dev Bot is running. Press Ctrl+C to stop.")
 	if err := bot.Run(ctx); err != nil && ctx.Err() == nil {